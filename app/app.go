@@ -30,14 +30,23 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
 	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/atlas"
+	"github.com/haakenlabs/arc/system/asset/audio"
+	"github.com/haakenlabs/arc/system/asset/cue"
+	"github.com/haakenlabs/arc/system/asset/fbx"
 	"github.com/haakenlabs/arc/system/asset/font"
+	"github.com/haakenlabs/arc/system/asset/gltf"
 	"github.com/haakenlabs/arc/system/asset/mesh"
 	"github.com/haakenlabs/arc/system/asset/shader"
 	"github.com/haakenlabs/arc/system/asset/skybox"
 	"github.com/haakenlabs/arc/system/asset/texture"
+	"github.com/haakenlabs/arc/system/asset/tree"
 )
 
 const (
@@ -87,10 +96,20 @@ func (a *App) Setup() error {
 
 	core.LoadGlobalConfig()
 
+	profile, err := graphics.ParseProfile(viper.GetString("graphics.profile"))
+	if err != nil {
+		return err
+	}
+	graphics.SetActiveProfile(profile)
+	graphics.SetProgramCache(viper.GetString("graphics.shader_cache"))
+	graphics.SetGPUTiming(viper.GetBool("graphics.gpu_timing"))
+
 	a.RegisterSystem(core.NewWindowSystem(a.Name))
 	a.RegisterSystem(core.NewInstanceSystem())
 	a.RegisterSystem(core.NewAssetSystem())
 	a.RegisterSystem(core.NewTimeSystem())
+	a.RegisterSystem(core.NewTweenSystem())
+	a.RegisterSystem(core.NewNetworkSystem())
 	a.RegisterSystem(core.NewSceneSystem())
 
 	if a.PreSetupFunc != nil {
@@ -107,11 +126,37 @@ func (a *App) Setup() error {
 		}
 	}
 
-	asset.RegisterHandler(texture.NewHandler())
-	asset.RegisterHandler(shader.NewHandler())
+	// Occlude positional sounds by the colliders of the active scene. Apps
+	// can replace the query from PostSetupFunc.
+	if s := core.GetAudioSystem(); s != nil {
+		s.SetOcclusionQuery(scene.ColliderOcclusionQuery(scene.LayerMaskAll))
+	}
+
+	graphics.DetectCapabilities()
+	graphics.InitCapture()
+	if viper.GetBool("graphics.debug") {
+		graphics.EnableDebugOutput(viper.GetBool("graphics.debug_errors"))
+	}
+
+	textures := texture.NewHandler()
+	textures.SetAnisotropy(float32(viper.GetFloat64("graphics.anisotropy")))
+
+	shaders := shader.NewHandler()
+	shaders.SetHotReload(viper.GetBool("graphics.shader_reload"), viper.GetString("graphics.shader_source"))
+
+	asset.RegisterHandler(textures)
+	asset.RegisterHandler(shaders)
 	asset.RegisterHandler(mesh.NewHandler())
 	asset.RegisterHandler(font.NewHandler())
 	asset.RegisterHandler(skybox.NewHandler())
+	asset.RegisterHandler(gltf.NewHandler())
+	asset.RegisterHandler(fbx.NewHandler())
+	asset.RegisterHandler(audio.NewHandler())
+	asset.RegisterHandler(cue.NewHandler())
+	asset.RegisterHandler(tree.NewHandler())
+	asset.RegisterHandler(atlas.NewHandler())
+
+	asset.SetHotReload(viper.GetBool("assets.hot_reload"), viper.GetString("assets.source"))
 
 	if err := asset.LoadManifest(builtinAssets); err != nil {
 		return err
@@ -154,6 +199,14 @@ func (a *App) Run() error {
 	time := a.MustSystem(core.SysNameTime).(*core.TimeSystem)
 	window := a.MustSystem(core.SysNameWindow).(*core.WindowSystem)
 	scene := a.MustSystem(core.SysNameScene).(*core.SceneSystem)
+	tween := a.MustSystem(core.SysNameTween).(*core.TweenSystem)
+	network := a.MustSystem(core.SysNameNetwork).(*core.NetworkSystem)
+	assets := a.MustSystem(core.SysNameAsset).(*core.AssetSystem)
+
+	var audio *core.AudioSystem
+	if s, err := a.System(core.SysNameAudio); err == nil {
+		audio = s.(*core.AudioSystem)
+	}
 
 	for a.running {
 		a.running = !window.ShouldClose()
@@ -162,7 +215,11 @@ func (a *App) Run() error {
 
 		frame++
 
+		network.Update()
+		assets.Update()
+		core.Events().Flush()
 		scene.OnUpdate()
+		tween.Update()
 
 		loops = 0
 		for time.LogicUpdate() && loops < maxFrameSkip {
@@ -171,8 +228,19 @@ func (a *App) Run() error {
 			loops++
 		}
 
+		scene.OnLateUpdate()
+
+		if audio != nil {
+			audio.Update()
+		}
+
+		shader.Poll()
+		assets.Poll()
+
+		graphics.BeginFrame()
 		window.ClearBuffers()
 		scene.OnDisplay()
+		graphics.EndFrame()
 		window.SwapBuffers()
 
 		window.HandleEvents()
@@ -0,0 +1,195 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package behavior
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ scene.ScriptComponent = &Agent{}
+
+// Agent is a component which runs a behavior tree. The tree is ticked from
+// the scene update, either every frame or at a fixed interval.
+type Agent struct {
+	scene.BaseScriptComponent
+
+	tree       *Tree
+	root       Node
+	blackboard *Blackboard
+	ctx        *Context
+	status     Status
+	interval   float64
+	elapsed    float64
+}
+
+// NewAgent creates a new agent which runs tree.
+func NewAgent(tree *Tree) *Agent {
+	a := newAgent()
+
+	if err := a.SetTree(tree); err != nil {
+		logrus.Error("[Behavior] ", err)
+	}
+
+	return a
+}
+
+// NewTreeAgent creates a new agent which runs tree. Unlike NewAgent, no agent
+// is created if the tree cannot be instantiated.
+func NewTreeAgent(tree *Tree) (*Agent, error) {
+	root, err := tree.Instantiate()
+	if err != nil {
+		return nil, err
+	}
+
+	a := newAgent()
+	a.SetRoot(root)
+	a.tree = tree
+
+	return a, nil
+}
+
+// NewAgentNode creates a new agent which runs a tree of nodes built in code.
+func NewAgentNode(root Node) *Agent {
+	a := newAgent()
+	a.SetRoot(root)
+
+	return a
+}
+
+func newAgent() *Agent {
+	a := &Agent{
+		blackboard: NewBlackboard(),
+	}
+
+	a.ctx = &Context{
+		Agent:      a,
+		Blackboard: a.blackboard,
+	}
+
+	a.SetName("Agent")
+	instance.MustAssign(a)
+
+	return a
+}
+
+// Tree returns the tree run by the agent, or nil if it runs nodes built in
+// code.
+func (a *Agent) Tree() *Tree {
+	return a.tree
+}
+
+// SetTree sets the tree run by the agent. The blackboard is kept.
+func (a *Agent) SetTree(tree *Tree) error {
+	if tree == nil {
+		a.SetRoot(nil)
+		return nil
+	}
+
+	root, err := tree.Instantiate()
+	if err != nil {
+		return err
+	}
+
+	a.SetRoot(root)
+	a.tree = tree
+
+	return nil
+}
+
+// Root returns the root node run by the agent.
+func (a *Agent) Root() Node {
+	return a.root
+}
+
+// SetRoot sets the root node run by the agent. The running tree, if any, is
+// reset.
+func (a *Agent) SetRoot(root Node) {
+	if a.root != nil {
+		a.root.Reset()
+	}
+
+	a.tree = nil
+	a.root = root
+	a.status = StatusRunning
+}
+
+// Blackboard returns the blackboard of the agent.
+func (a *Agent) Blackboard() *Blackboard {
+	return a.blackboard
+}
+
+// Status returns the status of the last tick.
+func (a *Agent) Status() Status {
+	return a.status
+}
+
+// Interval returns the time in seconds between ticks.
+func (a *Agent) Interval() float64 {
+	return a.interval
+}
+
+// SetInterval sets the time in seconds between ticks. An interval of zero
+// ticks the tree every frame.
+func (a *Agent) SetInterval(interval float64) {
+	a.interval = interval
+}
+
+// Restart resets the running tree, so the next tick starts from the root.
+func (a *Agent) Restart() {
+	if a.root != nil {
+		a.root.Reset()
+	}
+
+	a.status = StatusRunning
+}
+
+// Tick ticks the tree once. The tree starts over on the next tick once it has
+// finished.
+func (a *Agent) Tick(delta float64) Status {
+	if a.root == nil {
+		return StatusFailure
+	}
+
+	a.ctx.Delta = delta
+	a.ctx.Time += delta
+	a.status = a.root.Tick(a.ctx)
+
+	return a.status
+}
+
+// Update ticks the tree when the interval has passed.
+func (a *Agent) Update() {
+	a.elapsed += time.ScaledDelta()
+	if a.elapsed < a.interval {
+		return
+	}
+
+	delta := a.elapsed
+	a.elapsed = 0
+
+	a.Tick(delta)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package behavior
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Blackboard is the memory of an agent, shared by all nodes of its tree.
+// Blackboards are safe for concurrent use.
+type Blackboard struct {
+	values map[string]interface{}
+	mu     sync.RWMutex
+}
+
+// NewBlackboard creates a new, empty blackboard.
+func NewBlackboard() *Blackboard {
+	return &Blackboard{
+		values: make(map[string]interface{}),
+	}
+}
+
+// Get gets the value of an entry.
+func (b *Blackboard) Get(key string) (interface{}, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	v, ok := b.values[key]
+
+	return v, ok
+}
+
+// Set sets the value of an entry.
+func (b *Blackboard) Set(key string, value interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.values[key] = value
+}
+
+// Has reports if an entry is set.
+func (b *Blackboard) Has(key string) bool {
+	_, ok := b.Get(key)
+
+	return ok
+}
+
+// Delete deletes an entry.
+func (b *Blackboard) Delete(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.values, key)
+}
+
+// Clear deletes all entries.
+func (b *Blackboard) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.values = make(map[string]interface{})
+}
+
+// Keys returns the keys of all entries, sorted.
+func (b *Blackboard) Keys() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	keys := make([]string, 0, len(b.values))
+	for k := range b.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Bool gets an entry as a bool. It returns false if the entry is not set or is
+// not a bool.
+func (b *Blackboard) Bool(key string) bool {
+	v, _ := b.Get(key)
+	r, _ := v.(bool)
+
+	return r
+}
+
+// Float gets an entry as a float64. Any numeric value is converted. It returns
+// 0 if the entry is not set or is not numeric.
+func (b *Blackboard) Float(key string) float64 {
+	v, _ := b.Get(key)
+	r, _ := toFloat(v)
+
+	return r
+}
+
+// String gets an entry as a string. It returns an empty string if the entry is
+// not set or is not a string.
+func (b *Blackboard) String(key string) string {
+	v, _ := b.Get(key)
+	r, _ := v.(string)
+
+	return r
+}
+
+// equal compares two blackboard values. Numbers are compared by value, as
+// values decoded from tree assets are always float64.
+func equal(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return x == y
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
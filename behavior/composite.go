@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package behavior
+
+// ParallelPolicy decides when a parallel node finishes.
+type ParallelPolicy int
+
+const (
+	// RequireAll succeeds once all children have succeeded, and fails as soon
+	// as one child fails.
+	RequireAll ParallelPolicy = iota
+
+	// RequireOne succeeds as soon as one child succeeds, and fails once all
+	// children have failed.
+	RequireOne
+)
+
+var _ Node = &Sequence{}
+var _ Node = &Selector{}
+var _ Node = &Parallel{}
+
+// Sequence is a composite node which ticks its children in order. It fails as
+// soon as a child fails, and succeeds once all children have succeeded.
+type Sequence struct {
+	children []Node
+	current  int
+}
+
+// Selector is a composite node which ticks its children in order. It succeeds
+// as soon as a child succeeds, and fails once all children have failed.
+type Selector struct {
+	children []Node
+	current  int
+}
+
+// Parallel is a composite node which ticks all of its children each tick.
+type Parallel struct {
+	children []Node
+	results  []Status
+	policy   ParallelPolicy
+}
+
+// NewSequence creates a new sequence node.
+func NewSequence(children ...Node) *Sequence {
+	return &Sequence{children: children}
+}
+
+// NewSelector creates a new selector node.
+func NewSelector(children ...Node) *Selector {
+	return &Selector{children: children}
+}
+
+// NewParallel creates a new parallel node.
+func NewParallel(policy ParallelPolicy, children ...Node) *Parallel {
+	return &Parallel{
+		children: children,
+		results:  make([]Status, len(children)),
+		policy:   policy,
+	}
+}
+
+func (n *Sequence) Tick(ctx *Context) Status {
+	for n.current < len(n.children) {
+		switch n.children[n.current].Tick(ctx) {
+		case StatusRunning:
+			return StatusRunning
+		case StatusFailure:
+			n.current = 0
+			return StatusFailure
+		}
+
+		n.current++
+	}
+
+	n.current = 0
+
+	return StatusSuccess
+}
+
+func (n *Sequence) Reset() {
+	if n.current < len(n.children) {
+		n.children[n.current].Reset()
+	}
+
+	n.current = 0
+}
+
+func (n *Selector) Tick(ctx *Context) Status {
+	for n.current < len(n.children) {
+		switch n.children[n.current].Tick(ctx) {
+		case StatusRunning:
+			return StatusRunning
+		case StatusSuccess:
+			n.current = 0
+			return StatusSuccess
+		}
+
+		n.current++
+	}
+
+	n.current = 0
+
+	return StatusFailure
+}
+
+func (n *Selector) Reset() {
+	if n.current < len(n.children) {
+		n.children[n.current].Reset()
+	}
+
+	n.current = 0
+}
+
+func (n *Parallel) Tick(ctx *Context) Status {
+	succeeded := 0
+	failed := 0
+
+	for i := range n.children {
+		if n.results[i] == StatusRunning {
+			n.results[i] = n.children[i].Tick(ctx)
+		}
+
+		switch n.results[i] {
+		case StatusSuccess:
+			succeeded++
+		case StatusFailure:
+			failed++
+		}
+	}
+
+	status := StatusRunning
+
+	switch n.policy {
+	case RequireAll:
+		if failed > 0 {
+			status = StatusFailure
+		} else if succeeded == len(n.children) {
+			status = StatusSuccess
+		}
+	case RequireOne:
+		if succeeded > 0 {
+			status = StatusSuccess
+		} else if failed == len(n.children) {
+			status = StatusFailure
+		}
+	}
+
+	if status != StatusRunning {
+		n.Reset()
+	}
+
+	return status
+}
+
+func (n *Parallel) Reset() {
+	for i := range n.children {
+		if n.results[i] == StatusRunning {
+			n.children[i].Reset()
+		}
+
+		n.results[i] = StatusRunning
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package behavior
+
+var _ Node = &Inverter{}
+var _ Node = &Succeeder{}
+var _ Node = &Failer{}
+var _ Node = &Repeater{}
+var _ Node = &Retry{}
+var _ Node = &UntilFail{}
+var _ Node = &Cooldown{}
+
+// Inverter is a decorator node which turns success of its child into failure
+// and failure into success.
+type Inverter struct {
+	child Node
+}
+
+// Succeeder is a decorator node which succeeds once its child has finished.
+type Succeeder struct {
+	child Node
+}
+
+// Failer is a decorator node which fails once its child has finished.
+type Failer struct {
+	child Node
+}
+
+// Repeater is a decorator node which runs its child a number of times,
+// regardless of its result, then succeeds. A count of zero repeats forever.
+type Repeater struct {
+	child Node
+	count int
+	runs  int
+}
+
+// Retry is a decorator node which runs its child again when it fails, up to a
+// number of attempts.
+type Retry struct {
+	child    Node
+	attempts int
+	failures int
+}
+
+// UntilFail is a decorator node which runs its child until it fails, then
+// succeeds.
+type UntilFail struct {
+	child Node
+}
+
+// Cooldown is a decorator node which fails without ticking its child until a
+// duration has passed since the child last finished.
+type Cooldown struct {
+	child    Node
+	duration float64
+	ready    float64
+}
+
+// NewInverter creates a new inverter node.
+func NewInverter(child Node) *Inverter {
+	return &Inverter{child: child}
+}
+
+// NewSucceeder creates a new succeeder node.
+func NewSucceeder(child Node) *Succeeder {
+	return &Succeeder{child: child}
+}
+
+// NewFailer creates a new failer node.
+func NewFailer(child Node) *Failer {
+	return &Failer{child: child}
+}
+
+// NewRepeater creates a new repeater node.
+func NewRepeater(count int, child Node) *Repeater {
+	return &Repeater{
+		child: child,
+		count: count,
+	}
+}
+
+// NewRetry creates a new retry node.
+func NewRetry(attempts int, child Node) *Retry {
+	return &Retry{
+		child:    child,
+		attempts: attempts,
+	}
+}
+
+// NewUntilFail creates a new until fail node.
+func NewUntilFail(child Node) *UntilFail {
+	return &UntilFail{child: child}
+}
+
+// NewCooldown creates a new cooldown node.
+func NewCooldown(duration float64, child Node) *Cooldown {
+	return &Cooldown{
+		child:    child,
+		duration: duration,
+	}
+}
+
+func (n *Inverter) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case StatusSuccess:
+		return StatusFailure
+	case StatusFailure:
+		return StatusSuccess
+	}
+
+	return StatusRunning
+}
+
+func (n *Inverter) Reset() {
+	n.child.Reset()
+}
+
+func (n *Succeeder) Tick(ctx *Context) Status {
+	if n.child.Tick(ctx) == StatusRunning {
+		return StatusRunning
+	}
+
+	return StatusSuccess
+}
+
+func (n *Succeeder) Reset() {
+	n.child.Reset()
+}
+
+func (n *Failer) Tick(ctx *Context) Status {
+	if n.child.Tick(ctx) == StatusRunning {
+		return StatusRunning
+	}
+
+	return StatusFailure
+}
+
+func (n *Failer) Reset() {
+	n.child.Reset()
+}
+
+// Tick runs the child once per tick, so a repeater never blocks a frame.
+func (n *Repeater) Tick(ctx *Context) Status {
+	if n.child.Tick(ctx) == StatusRunning {
+		return StatusRunning
+	}
+
+	n.runs++
+	if n.count > 0 && n.runs >= n.count {
+		n.runs = 0
+		return StatusSuccess
+	}
+
+	return StatusRunning
+}
+
+func (n *Repeater) Reset() {
+	n.child.Reset()
+	n.runs = 0
+}
+
+func (n *Retry) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case StatusRunning:
+		return StatusRunning
+	case StatusSuccess:
+		n.failures = 0
+		return StatusSuccess
+	}
+
+	n.failures++
+	if n.failures >= n.attempts {
+		n.failures = 0
+		return StatusFailure
+	}
+
+	return StatusRunning
+}
+
+func (n *Retry) Reset() {
+	n.child.Reset()
+	n.failures = 0
+}
+
+func (n *UntilFail) Tick(ctx *Context) Status {
+	if n.child.Tick(ctx) == StatusFailure {
+		return StatusSuccess
+	}
+
+	return StatusRunning
+}
+
+func (n *UntilFail) Reset() {
+	n.child.Reset()
+}
+
+func (n *Cooldown) Tick(ctx *Context) Status {
+	if ctx.Time < n.ready {
+		return StatusFailure
+	}
+
+	status := n.child.Tick(ctx)
+	if status != StatusRunning {
+		n.ready = ctx.Time + n.duration
+	}
+
+	return status
+}
+
+// Reset resets the child. The cooldown itself keeps running.
+func (n *Cooldown) Reset() {
+	n.child.Reset()
+}
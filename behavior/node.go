@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package behavior
+
+// Status is the result of ticking a node.
+type Status int
+
+const (
+	// StatusRunning means the node has not finished and should be ticked again.
+	StatusRunning Status = iota
+
+	// StatusSuccess means the node has finished successfully.
+	StatusSuccess
+
+	// StatusFailure means the node has finished unsuccessfully.
+	StatusFailure
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusRunning:
+		return "running"
+	case StatusSuccess:
+		return "success"
+	case StatusFailure:
+		return "failure"
+	}
+
+	return "unknown"
+}
+
+// Node is a node of a behavior tree. Nodes keep the state of a running tick,
+// so a tree of nodes must not be shared between agents.
+type Node interface {
+	// Tick runs the node.
+	Tick(ctx *Context) Status
+
+	// Reset discards the state of a running node. It is called when the
+	// parent of the node stops ticking it before it has finished.
+	Reset()
+}
+
+// Context is passed to each node when a tree is ticked.
+type Context struct {
+	// Agent is the agent running the tree.
+	Agent *Agent
+
+	// Blackboard is the blackboard of the agent.
+	Blackboard *Blackboard
+
+	// Delta is the time in seconds since the last tick.
+	Delta float64
+
+	// Time is the time in seconds the agent has been running.
+	Time float64
+}
+
+// ActionFunc is the function of an action node.
+type ActionFunc func(ctx *Context) Status
+
+// ConditionFunc is the function of a condition node.
+type ConditionFunc func(ctx *Context) bool
+
+var _ Node = &Action{}
+var _ Node = &Condition{}
+var _ Node = &Wait{}
+var _ Node = &BlackboardCheck{}
+var _ Node = &BlackboardSet{}
+
+// Action is a leaf node which runs a function.
+type Action struct {
+	fn ActionFunc
+}
+
+// Condition is a leaf node which succeeds if a function returns true and
+// fails otherwise.
+type Condition struct {
+	fn ConditionFunc
+}
+
+// Wait is a leaf node which is running until a duration has passed, then
+// succeeds.
+type Wait struct {
+	duration float64
+	elapsed  float64
+}
+
+// BlackboardCheck is a leaf node which succeeds if a blackboard entry has a
+// value.
+type BlackboardCheck struct {
+	key   string
+	value interface{}
+}
+
+// BlackboardSet is a leaf node which sets a blackboard entry and succeeds.
+type BlackboardSet struct {
+	key   string
+	value interface{}
+}
+
+// NewAction creates a new action node.
+func NewAction(fn ActionFunc) *Action {
+	return &Action{fn: fn}
+}
+
+// NewCondition creates a new condition node.
+func NewCondition(fn ConditionFunc) *Condition {
+	return &Condition{fn: fn}
+}
+
+// NewWait creates a new wait node which runs for duration seconds.
+func NewWait(duration float64) *Wait {
+	return &Wait{duration: duration}
+}
+
+// NewBlackboardCheck creates a node which checks the blackboard entry key. If
+// value is nil, the node succeeds if the entry is set.
+func NewBlackboardCheck(key string, value interface{}) *BlackboardCheck {
+	return &BlackboardCheck{
+		key:   key,
+		value: value,
+	}
+}
+
+// NewBlackboardSet creates a node which sets the blackboard entry key. If value
+// is nil, the entry is deleted.
+func NewBlackboardSet(key string, value interface{}) *BlackboardSet {
+	return &BlackboardSet{
+		key:   key,
+		value: value,
+	}
+}
+
+func (n *Action) Tick(ctx *Context) Status {
+	return n.fn(ctx)
+}
+
+func (n *Action) Reset() {}
+
+func (n *Condition) Tick(ctx *Context) Status {
+	if n.fn(ctx) {
+		return StatusSuccess
+	}
+
+	return StatusFailure
+}
+
+func (n *Condition) Reset() {}
+
+func (n *Wait) Tick(ctx *Context) Status {
+	n.elapsed += ctx.Delta
+	if n.elapsed < n.duration {
+		return StatusRunning
+	}
+
+	n.elapsed = 0
+
+	return StatusSuccess
+}
+
+func (n *Wait) Reset() {
+	n.elapsed = 0
+}
+
+func (n *BlackboardCheck) Tick(ctx *Context) Status {
+	v, ok := ctx.Blackboard.Get(n.key)
+	if !ok {
+		return StatusFailure
+	}
+	if n.value != nil && !equal(v, n.value) {
+		return StatusFailure
+	}
+
+	return StatusSuccess
+}
+
+func (n *BlackboardCheck) Reset() {}
+
+func (n *BlackboardSet) Tick(ctx *Context) Status {
+	if n.value == nil {
+		ctx.Blackboard.Delete(n.key)
+	} else {
+		ctx.Blackboard.Set(n.key, n.value)
+	}
+
+	return StatusSuccess
+}
+
+func (n *BlackboardSet) Reset() {}
@@ -0,0 +1,289 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package behavior
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// ErrNodeType is returned when a tree uses a node type which is not
+// registered.
+type ErrNodeType string
+
+// ErrNodeChildren is returned when a node has the wrong number of children.
+type ErrNodeChildren string
+
+// ErrActionUnknown is returned when a tree uses an action which is not
+// registered.
+type ErrActionUnknown string
+
+// ErrConditionUnknown is returned when a tree uses a condition which is not
+// registered.
+type ErrConditionUnknown string
+
+func (e ErrNodeType) Error() string {
+	return "behavior: unknown node type: " + string(e)
+}
+
+func (e ErrNodeChildren) Error() string {
+	return "behavior: wrong number of children for node: " + string(e)
+}
+
+func (e ErrActionUnknown) Error() string {
+	return "behavior: unknown action: " + string(e)
+}
+
+func (e ErrConditionUnknown) Error() string {
+	return "behavior: unknown condition: " + string(e)
+}
+
+// Spec is the data definition of a node and its children.
+type Spec struct {
+	// Type is the registered type of the node.
+	Type string `json:"type"`
+
+	// Name is the name of the action or condition run by a leaf node.
+	Name string `json:"name,omitempty"`
+
+	// Key and Value are the blackboard entry of blackboard nodes.
+	Key   string      `json:"key,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+
+	// Count is the number of runs of repeater and retry nodes.
+	Count int `json:"count,omitempty"`
+
+	// Seconds is the duration of wait and cooldown nodes.
+	Seconds float64 `json:"seconds,omitempty"`
+
+	// Policy is the policy of parallel nodes, either "all" or "one".
+	Policy string `json:"policy,omitempty"`
+
+	// Params holds the parameters of custom node types.
+	Params json.RawMessage `json:"params,omitempty"`
+
+	// Children are the children of composite and decorator nodes.
+	Children []*Spec `json:"children,omitempty"`
+}
+
+// NodeFactory creates a node from its spec and already built children.
+type NodeFactory func(spec *Spec, children []Node) (Node, error)
+
+var (
+	registryMu sync.RWMutex
+	nodeTypes  = map[string]NodeFactory{}
+	actions    = map[string]ActionFunc{}
+	conditions = map[string]ConditionFunc{}
+)
+
+func init() {
+	RegisterNode("sequence", buildComposite(func(s *Spec, c []Node) Node { return NewSequence(c...) }))
+	RegisterNode("selector", buildComposite(func(s *Spec, c []Node) Node { return NewSelector(c...) }))
+	RegisterNode("parallel", buildParallel)
+	RegisterNode("inverter", buildDecorator(func(s *Spec, c Node) Node { return NewInverter(c) }))
+	RegisterNode("succeeder", buildDecorator(func(s *Spec, c Node) Node { return NewSucceeder(c) }))
+	RegisterNode("failer", buildDecorator(func(s *Spec, c Node) Node { return NewFailer(c) }))
+	RegisterNode("repeater", buildDecorator(func(s *Spec, c Node) Node { return NewRepeater(s.Count, c) }))
+	RegisterNode("retry", buildDecorator(func(s *Spec, c Node) Node { return NewRetry(s.Count, c) }))
+	RegisterNode("until_fail", buildDecorator(func(s *Spec, c Node) Node { return NewUntilFail(c) }))
+	RegisterNode("cooldown", buildDecorator(func(s *Spec, c Node) Node { return NewCooldown(s.Seconds, c) }))
+	RegisterNode("action", buildAction)
+	RegisterNode("condition", buildCondition)
+	RegisterNode("wait", buildLeaf(func(s *Spec) Node { return NewWait(s.Seconds) }))
+	RegisterNode("blackboard_check", buildLeaf(func(s *Spec) Node { return NewBlackboardCheck(s.Key, s.Value) }))
+	RegisterNode("blackboard_set", buildLeaf(func(s *Spec) Node { return NewBlackboardSet(s.Key, s.Value) }))
+}
+
+// RegisterNode registers a node type for data defined trees, replacing any
+// node type of the same name.
+func RegisterNode(nodeType string, factory NodeFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	nodeTypes[nodeType] = factory
+}
+
+// RegisterAction registers an action which action nodes can run by name.
+func RegisterAction(name string, fn ActionFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	actions[name] = fn
+}
+
+// RegisterCondition registers a condition which condition nodes can run by
+// name.
+func RegisterCondition(name string, fn ConditionFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	conditions[name] = fn
+}
+
+// Build builds the nodes of a spec.
+func Build(spec *Spec) (Node, error) {
+	registryMu.RLock()
+	factory, ok := nodeTypes[spec.Type]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, ErrNodeType(spec.Type)
+	}
+
+	children := make([]Node, 0, len(spec.Children))
+	for _, c := range spec.Children {
+		n, err := Build(c)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, n)
+	}
+
+	return factory(spec, children)
+}
+
+// Tree is a data defined behavior tree. A tree is only a definition, each
+// agent running it gets its own nodes from Instantiate.
+type Tree struct {
+	core.BaseObject
+
+	root *Spec
+}
+
+// NewTree creates a new tree from the spec of its root node.
+func NewTree(root *Spec) *Tree {
+	t := &Tree{
+		root: root,
+	}
+
+	t.SetName("BehaviorTree")
+	instance.MustAssign(t)
+
+	return t
+}
+
+// Root returns the spec of the root node.
+func (t *Tree) Root() *Spec {
+	return t.root
+}
+
+// Alloc checks that the tree has a root node.
+func (t *Tree) Alloc() error {
+	if t.root == nil {
+		return fmt.Errorf("behavior: tree %s has no root", t.Name())
+	}
+
+	return nil
+}
+
+// Instantiate builds a new set of nodes for the tree. Actions and conditions
+// are resolved now, so they may be registered after the tree was loaded.
+func (t *Tree) Instantiate() (Node, error) {
+	if err := t.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return Build(t.root)
+}
+
+func buildComposite(fn func(*Spec, []Node) Node) NodeFactory {
+	return func(spec *Spec, children []Node) (Node, error) {
+		if len(children) == 0 {
+			return nil, ErrNodeChildren(spec.Type)
+		}
+
+		return fn(spec, children), nil
+	}
+}
+
+func buildDecorator(fn func(*Spec, Node) Node) NodeFactory {
+	return func(spec *Spec, children []Node) (Node, error) {
+		if len(children) != 1 {
+			return nil, ErrNodeChildren(spec.Type)
+		}
+
+		return fn(spec, children[0]), nil
+	}
+}
+
+func buildLeaf(fn func(*Spec) Node) NodeFactory {
+	return func(spec *Spec, children []Node) (Node, error) {
+		if len(children) != 0 {
+			return nil, ErrNodeChildren(spec.Type)
+		}
+
+		return fn(spec), nil
+	}
+}
+
+func buildParallel(spec *Spec, children []Node) (Node, error) {
+	if len(children) == 0 {
+		return nil, ErrNodeChildren(spec.Type)
+	}
+
+	switch spec.Policy {
+	case "", "all":
+		return NewParallel(RequireAll, children...), nil
+	case "one":
+		return NewParallel(RequireOne, children...), nil
+	}
+
+	return nil, fmt.Errorf("behavior: unknown parallel policy: %s", spec.Policy)
+}
+
+func buildAction(spec *Spec, children []Node) (Node, error) {
+	if len(children) != 0 {
+		return nil, ErrNodeChildren(spec.Type)
+	}
+
+	registryMu.RLock()
+	fn, ok := actions[spec.Name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, ErrActionUnknown(spec.Name)
+	}
+
+	return NewAction(fn), nil
+}
+
+func buildCondition(spec *Spec, children []Node) (Node, error) {
+	if len(children) != 0 {
+		return nil, ErrNodeChildren(spec.Type)
+	}
+
+	registryMu.RLock()
+	fn, ok := conditions[spec.Name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, ErrConditionUnknown(spec.Name)
+	}
+
+	return NewCondition(fn), nil
+}
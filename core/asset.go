@@ -23,12 +23,11 @@ SOFTWARE.
 package core
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"path"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -83,6 +82,12 @@ type AssetHandler interface {
 
 	// Count returns the number of assets tracked by this handler.
 	Count() int
+
+	// Unload removes an asset from the handler and releases it.
+	Unload(string) error
+
+	// Names returns the names of the assets tracked by this handler.
+	Names() []string
 }
 
 var _ System = &AssetSystem{}
@@ -90,13 +95,36 @@ var _ System = &AssetSystem{}
 type AssetSystem struct {
 	handlers map[string]AssetHandler
 	packages map[string]*Package
+	mounts   []*mount
 	mu       *sync.RWMutex
+
+	pending   []*assetJob
+	pendingMu sync.Mutex
+
+	refs   map[assetRef]int
+	refsMu sync.Mutex
+
+	sources map[assetRef]assetSource
+	loading map[assetRef]int
+	infoMu  sync.Mutex
+
+	hotReload  bool
+	builtinDir string
+	watched    []*watchedAsset
+	onReload   []func(AssetReloadEvent)
+	lastPoll   time.Time
 }
 
+// AssetManifest lists assets by the name of their handler. Dependencies map
+// an asset to the assets it needs loaded first, and groups name sets of
+// assets loaded together by LoadGroup, such as the assets of a level. Both
+// refer to assets by "kind:file", with files relative to the manifest.
 type AssetManifest struct {
-	Name        string              `json:"name"`
-	Description string              `json:"description"`
-	Assets      map[string][]string `json:"assets,required"`
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	Assets       map[string][]string `json:"assets,required"`
+	Dependencies map[string][]string `json:"dependencies"`
+	Groups       map[string][]string `json:"groups"`
 }
 
 type AssetMetadata struct {
@@ -105,11 +133,21 @@ type AssetMetadata struct {
 	Files []string `json:"files,required"`
 }
 
+// BaseAssetHandler tracks the assets of a handler by name. Handlers embed it
+// and add their own Load and Name methods.
 type BaseAssetHandler struct {
 	Items map[string]int32
 	Mu    *sync.RWMutex
 }
 
+// NewBaseAssetHandler creates an empty BaseAssetHandler.
+func NewBaseAssetHandler() BaseAssetHandler {
+	return BaseAssetHandler{
+		Items: make(map[string]int32),
+		Mu:    &sync.RWMutex{},
+	}
+}
+
 // Setup sets up the System.
 func (a *AssetSystem) Setup() error {
 	if assetInst != nil {
@@ -123,6 +161,7 @@ func (a *AssetSystem) Setup() error {
 // Teardown tears down the System.
 func (a *AssetSystem) Teardown() {
 	a.ReleaseAll()
+	a.UnmountAll()
 	a.UnmountAllPackages()
 }
 
@@ -191,54 +230,50 @@ func (a *AssetSystem) MustGet(kind, name string) Object {
 	}
 }
 
-// LoadManifest loads a manifest of assets.
+// LoadManifest loads manifests of assets. Assets are loaded after the assets
+// they depend on, and nothing of a manifest is loaded if any of its assets is
+// missing.
 func (a *AssetSystem) LoadManifest(files ...string) error {
 	for _, v := range files {
-		m := NewAssetManifest()
-
-		r, err := NewResource(v)
+		m, r, err := a.readManifest(v)
 		if err != nil {
 			logrus.Error("Error reading manifest: ", err)
 			continue
 		}
-		if err := a.ReadResource(r); err != nil {
-			logrus.Error("Error reading manifest: ", err)
-			continue
-		}
 
-		if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		if err := a.loadKeys(m, r.DirPrefix(), m.keys()); err != nil {
 			return err
 		}
+	}
 
-		// Load assets.
-		for t := range m.Assets {
-			h, err := a.GetHandler(t)
-			if err != nil {
-				logrus.Error(err)
-				continue
-			}
-
-			logrus.Debug(">>>>>>>> Loading asset type: ", t)
+	return nil
+}
 
-			// Read and load assets.
-			for n := range m.Assets[t] {
-				ar, err := NewResource(path.Join(r.DirPrefix(), m.Assets[t][n]))
-				if err != nil {
-					return err
-				}
+// mountFor mounts the package holding a resource if it is not mounted, so
+// manifests can refer to bundles which were not mounted beforehand.
+func (a *AssetSystem) mountFor(r *Resource) error {
+	if r.resType != ResourcePackage {
+		return nil
+	}
 
-				if err := a.ReadResource(ar); err != nil {
-					return err
-				}
+	a.mu.RLock()
+	_, mounted := a.packages[r.container]
+	a.mu.RUnlock()
 
-				logrus.Debug("Read asset: ", m.Assets[t][n])
+	if mounted {
+		return nil
+	}
 
-				if err := h.Load(ar); err != nil {
-					return err
-				}
+	// Packages provided only by a mounted filesystem have no archive.
+	if a.covers(r.container) {
+		if _, err := os.Stat(NewPackage(r.container).Path()); err != nil {
+			return nil
+		}
+	}
 
-				logrus.Debug("Loaded asset: ", m.Assets[t][n])
-			}
+	if err := a.MountPackage(r.container); err != nil {
+		if _, ok := err.(ErrPackageMounted); !ok {
+			return err
 		}
 	}
 
@@ -250,6 +285,10 @@ func (a *AssetSystem) ReadResource(r *Resource) error {
 		return nil
 	}
 
+	if found, err := a.readMounted(r); found {
+		return err
+	}
+
 	switch r.resType {
 	case ResourceFile:
 		f, err := os.Open(r.location)
@@ -262,7 +301,9 @@ func (a *AssetSystem) ReadResource(r *Resource) error {
 
 		return err
 	case ResourcePackage:
+		a.mu.RLock()
 		p, ok := a.packages[r.container]
+		a.mu.RUnlock()
 		if !ok {
 			return ErrPackageNotMounted(r.container)
 		}
@@ -382,6 +423,28 @@ func (a *AssetSystem) CountByKind(kind string) (int, error) {
 	return a.handlers[kind].Count(), nil
 }
 
+// AddAsset adds an asset to the handler, assigning it an instance ID if it
+// has none. Handlers of user defined kinds can use it to store their assets.
+func (h *BaseAssetHandler) AddAsset(name string, asset Object) error {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+
+	if _, dup := h.Items[name]; dup {
+		return ErrAssetExists(name)
+	}
+
+	if asset.ID() == 0 {
+		asset.SetName(name)
+		if err := GetInstanceSystem().Assign(asset); err != nil {
+			return err
+		}
+	}
+
+	h.Items[name] = asset.ID()
+
+	return nil
+}
+
 // GetAsset gets an asset by name.
 func (h *BaseAssetHandler) GetAsset(name string) (Object, error) {
 	h.Mu.RLock()
@@ -424,6 +487,9 @@ func NewAssetSystem() *AssetSystem {
 	return &AssetSystem{
 		handlers: make(map[string]AssetHandler),
 		packages: make(map[string]*Package),
+		refs:     make(map[assetRef]int),
+		sources:  make(map[assetRef]assetSource),
+		loading:  make(map[assetRef]int),
 		mu:       &sync.RWMutex{},
 	}
 }
@@ -0,0 +1,300 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// asyncUploadBudget is the time spent uploading loaded assets per frame.
+const asyncUploadBudget = 4 * time.Millisecond
+
+// AsyncAssetHandler is implemented by handlers which split loading into a
+// decode step, which is safe to call from any goroutine, and an upload step
+// on the main thread. Other handlers have their files read on worker
+// goroutines and are loaded on the main thread.
+type AsyncAssetHandler interface {
+	AssetHandler
+
+	// Decode decodes an asset without touching the GPU.
+	Decode(*Resource) (interface{}, error)
+
+	// Upload allocates an asset returned by Decode.
+	Upload(*Resource, interface{}) error
+}
+
+// AssetLoad tracks the progress of LoadManifestAsync.
+type AssetLoad struct {
+	total  int32
+	loaded int32
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// Progress returns the fraction of assets loaded, from 0 to 1.
+func (l *AssetLoad) Progress() float64 {
+	total := atomic.LoadInt32(&l.total)
+	if total <= 0 {
+		select {
+		case <-l.done:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return float64(atomic.LoadInt32(&l.loaded)) / float64(total)
+}
+
+// Done returns a channel which is closed when loading has finished or
+// failed.
+func (l *AssetLoad) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns the first error encountered while loading.
+func (l *AssetLoad) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+func (l *AssetLoad) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// assetJob is an asset of an asynchronous load.
+type assetJob struct {
+	load    *AssetLoad
+	handler AssetHandler
+	res     *Resource
+	data    interface{}
+	err     error
+	decoded chan struct{}
+}
+
+// manifestRequest is a manifest, or a group of one, to load asynchronously.
+type manifestRequest struct {
+	file  string
+	group string
+}
+
+// LoadManifestAsync loads manifests of assets without blocking. Files are
+// read and decoded on worker goroutines, and assets are allocated on the
+// main thread by Update, a few per frame, after the assets they depend on.
+func (a *AssetSystem) LoadManifestAsync(files ...string) *AssetLoad {
+	requests := make([]manifestRequest, len(files))
+	for i := range files {
+		requests[i].file = files[i]
+	}
+
+	return a.startAsync(requests)
+}
+
+// LoadGroupAsync is like LoadGroup, but loads the group without blocking
+// like LoadManifestAsync.
+func (a *AssetSystem) LoadGroupAsync(file, group string) *AssetLoad {
+	return a.startAsync([]manifestRequest{{file: file, group: group}})
+}
+
+// startAsync starts an asynchronous load.
+func (a *AssetSystem) startAsync(requests []manifestRequest) *AssetLoad {
+	l := &AssetLoad{
+		done: make(chan struct{}),
+	}
+
+	go a.loadAsync(l, requests)
+
+	return l
+}
+
+// loadAsync reads the manifests of a load and decodes their assets. Jobs are
+// decoded in parallel but queued for upload in dependency order.
+func (a *AssetSystem) loadAsync(l *AssetLoad, requests []manifestRequest) {
+	var jobs []*assetJob
+
+	for _, req := range requests {
+		m, r, err := a.readManifest(req.file)
+
+		var keys []string
+		if err == nil {
+			if req.group == "" {
+				keys = m.keys()
+			} else {
+				keys, err = m.groupKeys(req.group)
+			}
+		}
+
+		var entries []*manifestEntry
+		var missing ErrMissingAssets
+		if err == nil {
+			entries, missing, err = a.planManifest(m, r.DirPrefix(), keys)
+		}
+		if err == nil && len(missing) != 0 {
+			sort.Strings(missing)
+			err = missing
+		}
+		if err != nil {
+			l.fail(err)
+			a.enqueue(&assetJob{load: l})
+			return
+		}
+
+		for _, e := range entries {
+			jobs = append(jobs, &assetJob{
+				load:    l,
+				handler: e.handler,
+				res:     e.res,
+				decoded: make(chan struct{}),
+			})
+		}
+	}
+
+	for _, job := range jobs {
+		a.setLoading(job.handler, job.res, true)
+	}
+
+	atomic.StoreInt32(&l.total, int32(len(jobs)))
+	if len(jobs) == 0 {
+		a.enqueue(&assetJob{load: l})
+		return
+	}
+
+	queue := make(chan *assetJob)
+
+	for i := 0; i < runtime.NumCPU(); i++ {
+		go func() {
+			for job := range queue {
+				a.decode(job)
+				close(job.decoded)
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range jobs {
+			queue <- job
+		}
+		close(queue)
+	}()
+
+	for _, job := range jobs {
+		<-job.decoded
+		a.enqueue(job)
+	}
+}
+
+// decode reads the file of a job and decodes it if the handler supports it.
+func (a *AssetSystem) decode(job *assetJob) {
+	if job.load.Err() != nil {
+		return
+	}
+
+	if job.err = a.ReadResource(job.res); job.err != nil {
+		return
+	}
+
+	if h, ok := job.handler.(AsyncAssetHandler); ok {
+		job.data, job.err = h.Decode(job.res)
+	}
+}
+
+func (a *AssetSystem) enqueue(job *assetJob) {
+	a.pendingMu.Lock()
+	a.pending = append(a.pending, job)
+	a.pendingMu.Unlock()
+}
+
+// Update allocates assets decoded by asynchronous loads. It must be called
+// once per frame on the main thread.
+func (a *AssetSystem) Update() {
+	start := time.Now()
+
+	for time.Since(start) < asyncUploadBudget {
+		a.pendingMu.Lock()
+		if len(a.pending) == 0 {
+			a.pendingMu.Unlock()
+			return
+		}
+
+		job := a.pending[0]
+		a.pending[0] = nil
+		a.pending = a.pending[1:]
+		a.pendingMu.Unlock()
+
+		a.upload(job)
+	}
+}
+
+// upload allocates the asset of a job and finishes its load once all of its
+// assets are accounted for. Jobs without a resource finish a load early.
+func (a *AssetSystem) upload(job *assetJob) {
+	l := job.load
+
+	select {
+	case <-l.done:
+		return
+	default:
+	}
+
+	if job.res == nil {
+		close(l.done)
+		return
+	}
+
+	if job.err == nil && l.Err() == nil {
+		before := job.handler.Names()
+
+		if h, ok := job.handler.(AsyncAssetHandler); ok {
+			job.err = h.Upload(job.res, job.data)
+		} else {
+			job.err = job.handler.Load(job.res)
+		}
+
+		if _, ok := job.err.(ErrAssetExists); ok {
+			job.err = nil
+		} else if job.err == nil {
+			a.watch(job.handler, job.res)
+			a.recordSource(job.handler, job.res, before)
+		}
+	}
+	a.setLoading(job.handler, job.res, false)
+	if job.err != nil {
+		l.fail(job.err)
+	}
+
+	if atomic.AddInt32(&l.loaded, 1) == atomic.LoadInt32(&l.total) {
+		close(l.done)
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrGroupNotFound reports that a manifest has no group with the given name.
+type ErrGroupNotFound string
+
+func (e ErrGroupNotFound) Error() string {
+	return "asset: no such group: " + string(e)
+}
+
+// ErrAssetCycle reports that assets of a manifest depend on each other.
+type ErrAssetCycle string
+
+func (e ErrAssetCycle) Error() string {
+	return "asset: dependency cycle at: " + string(e)
+}
+
+// ErrMissingAssets lists every asset of a load which could not be found,
+// along with the reason.
+type ErrMissingAssets []string
+
+func (e ErrMissingAssets) Error() string {
+	return "asset: missing assets: " + strings.Join(e, "; ")
+}
+
+// manifestEntry is an asset of a manifest resolved for loading.
+type manifestEntry struct {
+	key     string
+	handler AssetHandler
+	res     *Resource
+}
+
+// keys returns the keys of the assets listed by the manifest, which take the
+// form "kind:file". Kinds are sorted so the load order does not depend on map
+// iteration.
+func (m *AssetManifest) keys() []string {
+	kinds := make([]string, 0, len(m.Assets))
+	for kind := range m.Assets {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var keys []string
+	for _, kind := range kinds {
+		for _, file := range m.Assets[kind] {
+			keys = append(keys, kind+":"+file)
+		}
+	}
+
+	return keys
+}
+
+// groupKeys returns the keys of the assets of a group.
+func (m *AssetManifest) groupKeys(group string) ([]string, error) {
+	keys, ok := m.Groups[group]
+	if !ok {
+		return nil, ErrGroupNotFound(group)
+	}
+
+	return keys, nil
+}
+
+// readManifest reads and parses a manifest.
+func (a *AssetSystem) readManifest(file string) (*AssetManifest, *Resource, error) {
+	m := NewAssetManifest()
+
+	r, err := NewResource(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := a.mountFor(r); err != nil {
+		return nil, nil, err
+	}
+	if err := a.ReadResource(r); err != nil {
+		return nil, nil, err
+	}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return nil, nil, err
+	}
+
+	return m, r, nil
+}
+
+// LoadGroup loads the assets of a group of a manifest, along with everything
+// they depend on. Assets which are already loaded are skipped, so groups can
+// share assets. Nothing is loaded if any asset is missing.
+func (a *AssetSystem) LoadGroup(file, group string) error {
+	m, r, err := a.readManifest(file)
+	if err != nil {
+		return err
+	}
+
+	keys, err := m.groupKeys(group)
+	if err != nil {
+		return err
+	}
+
+	return a.loadKeys(m, r.DirPrefix(), keys)
+}
+
+// loadKeys loads assets of a manifest by key. Every file is read before the
+// first asset is loaded, so a load either fails listing all missing assets or
+// loads them as a unit.
+func (a *AssetSystem) loadKeys(m *AssetManifest, dir string, keys []string) error {
+	entries, missing, err := a.planManifest(m, dir, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := a.ReadResource(e.res); err != nil {
+			missing = append(missing, e.key+": "+err.Error())
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return missing
+	}
+
+	for _, e := range entries {
+		before := e.handler.Names()
+
+		err := e.handler.Load(e.res)
+		if _, ok := err.(ErrAssetExists); ok {
+			logrus.Debug("Asset already loaded: ", e.key)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", e.key, err)
+		}
+
+		a.watch(e.handler, e.res)
+		a.recordSource(e.handler, e.res, before)
+
+		logrus.Debug("Loaded asset: ", e.key)
+	}
+
+	return nil
+}
+
+// planManifest resolves assets of a manifest by key, along with the assets
+// they depend on, and orders them so dependencies are loaded first. Keys
+// which cannot be resolved, such as those naming no handler, are returned as
+// missing.
+func (a *AssetSystem) planManifest(m *AssetManifest, dir string, keys []string) ([]*manifestEntry, ErrMissingAssets, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	var order []*manifestEntry
+	var missing ErrMissingAssets
+	state := make(map[string]int)
+
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch state[key] {
+		case visiting:
+			return ErrAssetCycle(key)
+		case visited:
+			return nil
+		}
+		state[key] = visiting
+
+		e, err := a.resolveEntry(dir, key)
+		if err != nil {
+			missing = append(missing, key+": "+err.Error())
+		}
+
+		for _, dep := range m.Dependencies[key] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[key] = visited
+		if e != nil {
+			order = append(order, e)
+		}
+
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := visit(key); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return order, missing, nil
+}
+
+// resolveEntry finds the handler and resource of an asset key.
+func (a *AssetSystem) resolveEntry(dir, key string) (*manifestEntry, error) {
+	i := strings.Index(key, ":")
+	if i <= 0 || i == len(key)-1 {
+		return nil, fmt.Errorf("invalid asset key, want kind:file")
+	}
+
+	h, err := a.GetHandler(key[:i])
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewResource(path.Join(dir, key[i+1:]))
+	if err != nil {
+		return nil, err
+	}
+	if err := a.mountFor(r); err != nil {
+		return nil, err
+	}
+
+	return &manifestEntry{key: key, handler: h, res: r}, nil
+}
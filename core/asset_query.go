@@ -0,0 +1,202 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"sort"
+)
+
+// AssetState is the load state of an asset.
+type AssetState int
+
+const (
+	// AssetNotLoaded is the state of assets which are not loaded.
+	AssetNotLoaded AssetState = iota
+
+	// AssetLoading is the state of assets queued by an asynchronous load.
+	AssetLoading
+
+	// AssetLoaded is the state of assets which can be used.
+	AssetLoaded
+)
+
+func (s AssetState) String() string {
+	switch s {
+	case AssetLoading:
+		return "loading"
+	case AssetLoaded:
+		return "loaded"
+	}
+
+	return "not loaded"
+}
+
+// AssetInfo describes an asset.
+type AssetInfo struct {
+	// Kind is the name of the handler of the asset.
+	Kind string
+
+	// Name is the name of the asset.
+	Name string
+
+	// Source is the path of the file the asset was loaded from, with the
+	// package or "<builtin>" prefix if it came from one. It is empty for
+	// assets added directly to their handler.
+	Source string
+
+	// Size is the size of the source file in bytes.
+	Size int
+
+	// State is the load state of the asset.
+	State AssetState
+
+	// Refs is the number of references added by Acquire.
+	Refs int
+}
+
+// assetSource is the file an asset was loaded from.
+type assetSource struct {
+	location string
+	size     int
+}
+
+// Kinds returns the names of the registered handlers in sorted order.
+func (a *AssetSystem) Kinds() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	kinds := make([]string, 0, len(a.handlers))
+	for kind := range a.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// List returns the names of the loaded assets of a kind in sorted order.
+func (a *AssetSystem) List(kind string) ([]string, error) {
+	h, err := a.GetHandler(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Names(), nil
+}
+
+// Exists reports if an asset of a kind is loaded.
+func (a *AssetSystem) Exists(kind, name string) bool {
+	_, err := a.GetAsset(kind, name)
+
+	return err == nil
+}
+
+// Info describes an asset. Assets queued by an asynchronous load are reported
+// as loading, provided their name is the name of their file.
+func (a *AssetSystem) Info(kind, name string) (AssetInfo, error) {
+	if _, err := a.GetHandler(kind); err != nil {
+		return AssetInfo{}, err
+	}
+
+	ref := assetRef{kind, name}
+	info := AssetInfo{
+		Kind: kind,
+		Name: name,
+		Refs: a.RefCount(kind, name),
+	}
+
+	a.infoMu.Lock()
+	src, hasSource := a.sources[ref]
+	loading := a.loading[ref] > 0
+	a.infoMu.Unlock()
+
+	switch {
+	case a.Exists(kind, name):
+		info.State = AssetLoaded
+		if hasSource {
+			info.Source = src.location
+			info.Size = src.size
+		}
+	case loading:
+		info.State = AssetLoading
+	default:
+		return info, ErrAssetNotFound(name)
+	}
+
+	return info, nil
+}
+
+// recordSource records the file of the assets a handler added while loading
+// a resource, given the names the handler had before.
+func (a *AssetSystem) recordSource(h AssetHandler, r *Resource, before []string) {
+	existed := make(map[string]bool, len(before))
+	for _, name := range before {
+		existed[name] = true
+	}
+
+	names := h.Names()
+
+	a.infoMu.Lock()
+	defer a.infoMu.Unlock()
+
+	for _, name := range names {
+		if !existed[name] {
+			a.sources[assetRef{h.Name(), name}] = assetSource{location: r.virtualPath(), size: r.Size()}
+		}
+	}
+}
+
+// setLoading marks the asset of a resource as queued by an asynchronous load,
+// or no longer queued.
+func (a *AssetSystem) setLoading(h AssetHandler, r *Resource, loading bool) {
+	a.infoMu.Lock()
+	defer a.infoMu.Unlock()
+
+	ref := assetRef{h.Name(), r.Base()}
+	if loading {
+		a.loading[ref]++
+	} else if a.loading[ref]--; a.loading[ref] <= 0 {
+		delete(a.loading, ref)
+	}
+}
+
+// forgetSource drops the source of an unloaded asset.
+func (a *AssetSystem) forgetSource(kind, name string) {
+	a.infoMu.Lock()
+	delete(a.sources, assetRef{kind, name})
+	a.infoMu.Unlock()
+}
+
+// Names returns the names of the assets tracked by this handler in sorted
+// order.
+func (h *BaseAssetHandler) Names() []string {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+
+	names := make([]string, 0, len(h.Items))
+	for name := range h.Items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
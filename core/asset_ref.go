@@ -0,0 +1,187 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrAssetInUse reports that an asset cannot be unloaded while it is
+// referenced.
+type ErrAssetInUse string
+
+func (e ErrAssetInUse) Error() string {
+	return "asset: asset in use: " + string(e)
+}
+
+// AssetOwner is implemented by assets which own other objects, such as the
+// meshes and textures of a model, so they are released with the asset.
+type AssetOwner interface {
+	// Owned returns the instance IDs of the objects owned by the asset.
+	Owned() []int32
+}
+
+// assetRef identifies an asset by the name of its handler and its name.
+type assetRef struct {
+	kind string
+	name string
+}
+
+// Acquire gets an asset like Get and adds a reference to it. Assets which were
+// acquired are unloaded by UnloadUnused once every reference is released.
+// Assets which were never acquired are kept until they are unloaded
+// explicitly.
+func (a *AssetSystem) Acquire(kind, name string) (Object, error) {
+	asset, err := a.Get(kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a.refsMu.Lock()
+	a.refs[assetRef{kind, name}]++
+	a.refsMu.Unlock()
+
+	return asset, nil
+}
+
+// MustAcquire is like Acquire, but panics if an error is encountered.
+func (a *AssetSystem) MustAcquire(kind, name string) Object {
+	asset, err := a.Acquire(kind, name)
+	if err != nil {
+		panic(err)
+	}
+
+	return asset
+}
+
+// Release removes a reference added by Acquire. The asset stays loaded until
+// UnloadUnused or Unload is called.
+func (a *AssetSystem) Release(kind, name string) {
+	a.refsMu.Lock()
+	defer a.refsMu.Unlock()
+
+	ref := assetRef{kind, name}
+	if a.refs[ref] == 0 {
+		logrus.Warnf("asset: release of unreferenced asset: %s/%s", kind, name)
+		return
+	}
+
+	a.refs[ref]--
+}
+
+// RefCount returns the number of references to an asset.
+func (a *AssetSystem) RefCount(kind, name string) int {
+	a.refsMu.Lock()
+	defer a.refsMu.Unlock()
+
+	return a.refs[assetRef{kind, name}]
+}
+
+// Unload removes an asset from its handler and releases it and the objects it
+// owns through the instance system. It fails if the asset is referenced.
+func (a *AssetSystem) Unload(kind, name string) error {
+	h, err := a.GetHandler(kind)
+	if err != nil {
+		return err
+	}
+
+	ref := assetRef{kind, name}
+
+	a.refsMu.Lock()
+	if a.refs[ref] != 0 {
+		a.refsMu.Unlock()
+		return ErrAssetInUse(name)
+	}
+	delete(a.refs, ref)
+	a.refsMu.Unlock()
+
+	if err := h.Unload(name); err != nil {
+		return err
+	}
+	a.forgetSource(kind, name)
+
+	logrus.Debug("Unloaded asset: ", name)
+
+	return nil
+}
+
+// UnloadUnused unloads every acquired asset which has no references left and
+// returns the number of assets unloaded. It is called when the active scene
+// changes, so assets only used by the previous scene are freed.
+func (a *AssetSystem) UnloadUnused() int {
+	a.refsMu.Lock()
+	var unused []assetRef
+	for ref, count := range a.refs {
+		if count == 0 {
+			unused = append(unused, ref)
+		}
+	}
+	a.refsMu.Unlock()
+
+	// Unload in a fixed order, so releases are reproducible between runs.
+	sort.Slice(unused, func(i, j int) bool {
+		if unused[i].kind != unused[j].kind {
+			return unused[i].kind < unused[j].kind
+		}
+		return unused[i].name < unused[j].name
+	})
+
+	var count int
+	for _, ref := range unused {
+		switch err := a.Unload(ref.kind, ref.name); err.(type) {
+		case nil:
+			count++
+		case ErrAssetInUse, ErrAssetNotFound:
+			// Acquired again since, or already unloaded.
+		default:
+			logrus.Error(err)
+		}
+	}
+
+	return count
+}
+
+// Unload removes an asset from the handler and releases it and the objects it
+// owns.
+func (h *BaseAssetHandler) Unload(name string) error {
+	h.Mu.Lock()
+	id, ok := h.Items[name]
+	delete(h.Items, name)
+	h.Mu.Unlock()
+
+	if !ok {
+		return ErrAssetNotFound(name)
+	}
+
+	ids := []int32{id}
+	if obj, err := GetInstanceSystem().Get(id); err == nil {
+		if owner, ok := obj.(AssetOwner); ok {
+			ids = append(owner.Owned(), id)
+		}
+	}
+
+	GetInstanceSystem().Release(ids...)
+
+	return nil
+}
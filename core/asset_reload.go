@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// assetReloadInterval is the minimum time between two checks for changed
+// asset files.
+const assetReloadInterval = 500 * time.Millisecond
+
+// ReloadableAssetHandler is implemented by handlers which can replace the
+// contents of a loaded asset in place, so objects holding the asset see the
+// change without being updated.
+type ReloadableAssetHandler interface {
+	AssetHandler
+
+	// Reload reloads the asset loaded from a resource and returns its name.
+	Reload(*Resource) (string, error)
+}
+
+// AssetReloadEvent describes an asset which was reloaded.
+type AssetReloadEvent struct {
+	// Kind is the name of the handler of the asset.
+	Kind string
+
+	// Name is the name of the asset.
+	Name string
+}
+
+// watchedAsset is an asset file watched for changes.
+type watchedAsset struct {
+	handler ReloadableAssetHandler
+	path    string
+	modTime time.Time
+}
+
+// SetHotReload enables or disables reloading assets when their files change
+// on disk. Only assets loaded after it is called are watched. Builtin assets
+// are only watched if dir is set to the directory holding the builtin assets,
+// such as internal/builtin/assets of an engine checkout.
+func (a *AssetSystem) SetHotReload(enable bool, dir string) {
+	a.hotReload = enable
+	a.builtinDir = dir
+}
+
+// HotReload reports if assets are reloaded when their files change.
+func (a *AssetSystem) HotReload() bool {
+	return a.hotReload
+}
+
+// OnReload adds a callback invoked when an asset is reloaded.
+func (a *AssetSystem) OnReload(fn func(AssetReloadEvent)) {
+	a.onReload = append(a.onReload, fn)
+}
+
+// NotifyReload invokes the reload callbacks for an asset. Handlers which
+// reload assets on their own call it so dependents are notified.
+func (a *AssetSystem) NotifyReload(kind, name string) {
+	event := AssetReloadEvent{
+		Kind: kind,
+		Name: name,
+	}
+
+	for _, fn := range a.onReload {
+		fn(event)
+	}
+}
+
+// Poll reloads the assets whose files changed on disk since the last call.
+// An asset failing to reload keeps its previous contents. Poll must be
+// called from the thread owning the graphics context.
+func (a *AssetSystem) Poll() {
+	if !a.hotReload || time.Since(a.lastPoll) < assetReloadInterval {
+		return
+	}
+	a.lastPoll = time.Now()
+
+	for _, w := range a.watched {
+		info, err := os.Stat(w.path)
+		if err != nil || info.ModTime().Equal(w.modTime) {
+			continue
+		}
+		w.modTime = info.ModTime()
+
+		if err := a.reload(w); err != nil {
+			logrus.Errorf("asset: reload of %s failed: %v", w.path, err)
+		}
+	}
+}
+
+func (a *AssetSystem) reload(w *watchedAsset) error {
+	r, err := NewResource(w.path)
+	if err != nil {
+		return err
+	}
+	if err := a.ReadResource(r); err != nil {
+		return err
+	}
+
+	name, err := w.handler.Reload(r)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("asset: reloaded %s %s", w.handler.Name(), name)
+	a.NotifyReload(w.handler.Name(), name)
+
+	return nil
+}
+
+// watch records the file of a loaded asset if its handler can reload it.
+// Assets which are not backed by files, such as packaged assets, are not
+// watched.
+func (a *AssetSystem) watch(h AssetHandler, r *Resource) {
+	if !a.hotReload {
+		return
+	}
+
+	rh, ok := h.(ReloadableAssetHandler)
+	if !ok {
+		return
+	}
+
+	var path string
+	switch r.Type() {
+	case ResourceFile:
+		path = r.Location()
+	case ResourceBindata:
+		if a.builtinDir == "" {
+			return
+		}
+		path = filepath.Join(a.builtinDir, r.Location())
+	default:
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	a.watched = append(a.watched, &watchedAsset{
+		handler: rh,
+		path:    path,
+		modTime: info.ModTime(),
+	})
+}
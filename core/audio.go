@@ -27,6 +27,8 @@ import (
 
 	"github.com/faiface/beep"
 	"github.com/faiface/beep/speaker"
+	"github.com/go-gl/mathgl/mgl32"
+
 	"github.com/haakenlabs/arc/pkg/math"
 )
 
@@ -36,29 +38,61 @@ var audioInst *AudioSystem
 
 const SysNameAudio = "audio"
 
+// DefaultOcclusionCutoff is the default low-pass cutoff in Hz applied to
+// sounds which are occluded from the listener.
+const DefaultOcclusionCutoff = 800.0
+
 type AudioChannel uint8
 
+// OcclusionQuery reports if the path between the listener and a sound source
+// is obstructed. This is typically implemented with a physics raycast.
+type OcclusionQuery func(listener, source mgl32.Vec3) bool
+
+// ReverbZone is a spherical region of space in which positional sounds have
+// reverb applied.
+type ReverbZone struct {
+	Center mgl32.Vec3
+	Radius float32
+	Reverb *Reverb
+}
+
+// Contains reports if the point is inside the zone.
+func (z *ReverbZone) Contains(point mgl32.Vec3) bool {
+	return point.Sub(z.Center).Len() <= z.Radius
+}
+
 type AudioSystem struct {
-	volume     float64
-	channels   AudioChannel
-	sampleRate beep.SampleRate
-	mute       bool
+	buses           map[string]*AudioBus
+	voices          []*voice
+	pending         []*voice
+	states          []voiceState
+	zones           []*ReverbZone
+	occlusionQuery  OcclusionQuery
+	occlusionCutoff float64
+	listener        mgl32.Vec3
+	volume          float64
+	channels        AudioChannel
+	sampleRate      beep.SampleRate
+	mute            bool
 }
 
 // Setup sets up the System.
 func (s *AudioSystem) Setup() error {
-	if timeInst != nil {
+	if audioInst != nil {
 		return ErrSystemInit(SysNameAudio)
 	}
 	audioInst = s
 
 	speaker.Init(s.sampleRate, s.sampleRate.N(time.Second/10))
+	speaker.Play(s.buses[AudioBusMaster])
 
 	return nil
 }
 
 // Teardown tears down the System.
 func (s *AudioSystem) Teardown() {
+	speaker.Clear()
+
 	audioInst = nil
 }
 
@@ -73,6 +107,7 @@ func (s *AudioSystem) Volume() float64 {
 
 func (s *AudioSystem) SetVolume(volume float64) {
 	s.volume = math.Clamp(volume, 0.0, 1.0)
+	s.buses[AudioBusMaster].SetVolume(s.volume)
 }
 
 func (s *AudioSystem) Mute() {
@@ -85,16 +120,202 @@ func (s *AudioSystem) Unmute() {
 
 func (s *AudioSystem) SetMute(mute bool) {
 	s.mute = mute
+	s.buses[AudioBusMaster].SetMute(mute)
+}
+
+// SampleRate returns the output sample rate.
+func (s *AudioSystem) SampleRate() beep.SampleRate {
+	return s.sampleRate
+}
+
+// NewBus creates a new bus routed to the master bus. If a bus with the given
+// name already exists, it is returned instead.
+func (s *AudioSystem) NewBus(name string) *AudioBus {
+	if b, ok := s.buses[name]; ok {
+		return b
+	}
+
+	b := newAudioBus(name, s.sampleRate)
+	s.buses[name] = b
+
+	speaker.Lock()
+	s.buses[AudioBusMaster].add(b)
+	speaker.Unlock()
+
+	return b
+}
+
+// Bus returns a bus by name, or nil if no such bus exists.
+func (s *AudioSystem) Bus(name string) *AudioBus {
+	return s.buses[name]
+}
+
+// MasterBus returns the master bus.
+func (s *AudioSystem) MasterBus() *AudioBus {
+	return s.buses[AudioBusMaster]
+}
+
+// ListenerPosition returns the world position of the listener.
+func (s *AudioSystem) ListenerPosition() mgl32.Vec3 {
+	return s.listener
+}
+
+// SetListenerPosition sets the world position of the listener.
+func (s *AudioSystem) SetListenerPosition(position mgl32.Vec3) {
+	s.listener = position
+}
+
+// SetOcclusionQuery sets the function used to test if positional sounds are
+// occluded from the listener. Passing nil disables occlusion.
+func (s *AudioSystem) SetOcclusionQuery(query OcclusionQuery) {
+	s.occlusionQuery = query
+}
+
+// OcclusionCutoff returns the low-pass cutoff applied to occluded sounds.
+func (s *AudioSystem) OcclusionCutoff() float64 {
+	return s.occlusionCutoff
+}
+
+// SetOcclusionCutoff sets the low-pass cutoff applied to occluded sounds.
+func (s *AudioSystem) SetOcclusionCutoff(cutoff float64) {
+	s.occlusionCutoff = cutoff
+}
+
+// AddReverbZone adds a reverb zone.
+func (s *AudioSystem) AddReverbZone(zone *ReverbZone) {
+	if zone == nil || zone.Reverb == nil {
+		return
+	}
+
+	s.zones = append(s.zones, zone)
 }
 
+// RemoveReverbZone removes a reverb zone.
+func (s *AudioSystem) RemoveReverbZone(zone *ReverbZone) {
+	for i := range s.zones {
+		if s.zones[i] == zone {
+			s.zones = append(s.zones[:i], s.zones[i+1:]...)
+			return
+		}
+	}
+}
+
+// PlaySound plays the sound on its bus.
 func (s *AudioSystem) PlaySound(sound *Sound) {
-	speaker.Play(sound.streamer)
+	s.playSound(sound, sound.bus, 1.0, 1.0)
+}
+
+// playSound plays the sound on the named bus. Volume scales the amplitude of
+// the sound, and pitch scales its playback rate.
+func (s *AudioSystem) playSound(sound *Sound, busName string, volume, pitch float64) {
+	bus := s.Bus(busName)
+	if bus == nil {
+		bus = s.MasterBus()
+	}
+
+	ratio := pitch
+	if sound.format.SampleRate != 0 {
+		ratio *= float64(sound.format.SampleRate) / float64(s.sampleRate)
+	}
+
+	// The voice is not shared with the speaker yet, so its spatial state can
+	// be set without holding the lock.
+	v := newVoice(sound, s.sampleRate, volume)
+	if sound.positional {
+		v.apply(s.spatialState(sound.position))
+	}
+
+	speaker.Lock()
+	if sound.stream != nil {
+		// A streamed sound has a single decoder, so only one instance of it
+		// can play at a time.
+		for i := range sound.voices {
+			sound.voices[i].done = true
+		}
+		sound.stream.Seek(0)
+	}
+
+	streamer := sound.streamer()
+	if ratio > 0 && ratio != 1.0 {
+		streamer = beep.ResampleRatio(4, ratio, streamer)
+	}
+	v.streamer = streamer
+
+	sound.voices = append(sound.voices, v)
+	s.voices = append(s.voices, v)
+	bus.add(v)
+	speaker.Unlock()
+}
+
+// Update refreshes occlusion and reverb zones for all playing positional
+// sounds. This should be called once per frame from the main thread.
+//
+// Occlusion queries may be expensive, so they are run without holding the
+// speaker lock. The lock is only taken to prune finished voices and to
+// assign the results.
+func (s *AudioSystem) Update() {
+	speaker.Lock()
+	active := s.voices[:0]
+	for _, v := range s.voices {
+		if v.done {
+			v.sound.removeVoice(v)
+			continue
+		}
+
+		active = append(active, v)
+	}
+	s.voices = active
+	s.pending = append(s.pending[:0], s.voices...)
+	speaker.Unlock()
+
+	s.states = s.states[:0]
+	for _, v := range s.pending {
+		if v.sound.positional {
+			s.states = append(s.states, s.spatialState(v.sound.position))
+		} else {
+			s.states = append(s.states, voiceState{})
+		}
+	}
+
+	speaker.Lock()
+	for i, v := range s.pending {
+		if v.sound.positional {
+			v.apply(s.states[i])
+		}
+	}
+	speaker.Unlock()
+}
+
+// spatialState computes the occlusion and reverb of a positional sound at
+// the given position.
+func (s *AudioSystem) spatialState(position mgl32.Vec3) voiceState {
+	var state voiceState
+
+	if s.occlusionQuery != nil && s.occlusionQuery(s.listener, position) {
+		state.cutoff = s.occlusionCutoff
+	}
+
+	for _, z := range s.zones {
+		if z.Contains(position) {
+			state.zone = z
+			break
+		}
+	}
+
+	return state
 }
 
 func NewAudioSystem(rate beep.SampleRate) *AudioSystem {
-	return &AudioSystem{
-		sampleRate: rate,
+	s := &AudioSystem{
+		buses:           make(map[string]*AudioBus),
+		occlusionCutoff: DefaultOcclusionCutoff,
+		sampleRate:      rate,
+		volume:          1.0,
 	}
+
+	s.buses[AudioBusMaster] = newAudioBus(AudioBusMaster, rate)
+
+	return s
 }
 
 // GetTime gets the time system from the current app.
@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+// AudioBusMaster is the name of the bus all other buses are routed to.
+const AudioBusMaster = "master"
+
+var _ beep.Streamer = &AudioBus{}
+
+// AudioBus mixes a group of sounds together and applies a chain of effects
+// to the result. Every bus except the master bus is routed to the master bus.
+type AudioBus struct {
+	name       string
+	mixer      *beep.Mixer
+	effects    []AudioEffect
+	sampleRate beep.SampleRate
+	volume     float64
+	mute       bool
+}
+
+func newAudioBus(name string, sampleRate beep.SampleRate) *AudioBus {
+	return &AudioBus{
+		name:       name,
+		mixer:      &beep.Mixer{},
+		sampleRate: sampleRate,
+		volume:     1.0,
+	}
+}
+
+// Name returns the name of this bus.
+func (b *AudioBus) Name() string {
+	return b.name
+}
+
+// Volume returns the volume of this bus.
+func (b *AudioBus) Volume() float64 {
+	return b.volume
+}
+
+// SetVolume sets the volume of this bus, in the range [0, 1].
+func (b *AudioBus) SetVolume(volume float64) {
+	speaker.Lock()
+	b.volume = math.Clamp(volume, 0.0, 1.0)
+	speaker.Unlock()
+}
+
+// Muted reports if this bus is muted.
+func (b *AudioBus) Muted() bool {
+	return b.mute
+}
+
+// SetMute sets the mute state of this bus.
+func (b *AudioBus) SetMute(mute bool) {
+	speaker.Lock()
+	b.mute = mute
+	speaker.Unlock()
+}
+
+// Effects returns the effects applied to this bus.
+func (b *AudioBus) Effects() []AudioEffect {
+	return b.effects
+}
+
+// AddEffect appends an effect to the end of the effect chain of this bus.
+func (b *AudioBus) AddEffect(effect AudioEffect) {
+	if effect == nil {
+		return
+	}
+
+	speaker.Lock()
+	b.effects = append(b.effects, effect)
+	speaker.Unlock()
+}
+
+// RemoveEffect removes an effect from the effect chain of this bus.
+func (b *AudioBus) RemoveEffect(effect AudioEffect) {
+	speaker.Lock()
+	defer speaker.Unlock()
+
+	for i := range b.effects {
+		if b.effects[i] == effect {
+			b.effects = append(b.effects[:i], b.effects[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stream mixes all streamers on this bus and applies the effect chain.
+func (b *AudioBus) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = b.mixer.Stream(samples)
+
+	for i := range b.effects {
+		b.effects[i].Process(samples[:n], b.sampleRate)
+	}
+
+	gain := b.volume
+	if b.mute {
+		gain = 0
+	}
+
+	for i := range samples[:n] {
+		samples[i][0] *= gain
+		samples[i][1] *= gain
+	}
+
+	return n, ok
+}
+
+// Err always returns nil for AudioBus.
+func (b *AudioBus) Err() error {
+	return nil
+}
+
+// add adds a streamer to this bus. The speaker must be locked by the caller.
+func (b *AudioBus) add(s beep.Streamer) {
+	b.mixer.Add(s)
+}
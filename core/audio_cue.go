@@ -0,0 +1,186 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import "math/rand"
+
+// AudioCueClip is a weighted entry of an AudioCue.
+type AudioCueClip struct {
+	// Name is the name of the sound asset this clip refers to.
+	Name string
+
+	// Sound is the sound played by this clip. Clips without a sound are
+	// skipped when the cue is played.
+	Sound *Sound
+
+	// Weight is the relative likelihood of this clip being selected.
+	Weight float64
+}
+
+// AudioCue groups a set of sounds which are selected at random when the cue
+// is played. Each play also picks a random volume and pitch from the ranges
+// of the cue.
+type AudioCue struct {
+	BaseObject
+
+	clips     []AudioCueClip
+	bus       string
+	volumeMin float64
+	volumeMax float64
+	pitchMin  float64
+	pitchMax  float64
+}
+
+func NewAudioCue() *AudioCue {
+	c := &AudioCue{
+		volumeMin: 1.0,
+		volumeMax: 1.0,
+		pitchMin:  1.0,
+		pitchMax:  1.0,
+	}
+
+	c.SetName("AudioCue")
+	GetInstanceSystem().MustAssign(c)
+
+	return c
+}
+
+// Play selects a clip from this cue and plays it. The selected sound is
+// returned, or nil if no clip could be played.
+func (c *AudioCue) Play() *Sound {
+	clip := c.pick()
+	if clip == nil {
+		return nil
+	}
+
+	bus := c.bus
+	if bus == "" {
+		bus = clip.Sound.bus
+	}
+
+	volume := randRange(c.volumeMin, c.volumeMax)
+	pitch := randRange(c.pitchMin, c.pitchMax)
+
+	GetAudioSystem().playSound(clip.Sound, bus, volume, pitch)
+
+	return clip.Sound
+}
+
+// Clips returns the clips of this cue.
+func (c *AudioCue) Clips() []AudioCueClip {
+	return c.clips
+}
+
+// AddClip adds a sound to this cue with the given weight.
+func (c *AudioCue) AddClip(sound *Sound, weight float64) {
+	clip := AudioCueClip{
+		Sound:  sound,
+		Weight: weight,
+	}
+
+	if sound != nil {
+		clip.Name = sound.Name()
+	}
+
+	c.clips = append(c.clips, clip)
+}
+
+// AddNamedClip adds a clip to this cue which refers to a sound asset by name.
+// The sound must be set on the clip before it can be played.
+func (c *AudioCue) AddNamedClip(name string, weight float64) {
+	c.clips = append(c.clips, AudioCueClip{
+		Name:   name,
+		Weight: weight,
+	})
+}
+
+// Bus returns the name of the bus this cue is routed to. If empty, the bus of
+// the selected sound is used.
+func (c *AudioCue) Bus() string {
+	return c.bus
+}
+
+// SetBus sets the name of the bus this cue is routed to.
+func (c *AudioCue) SetBus(bus string) {
+	c.bus = bus
+}
+
+// VolumeRange returns the range volume is randomly selected from.
+func (c *AudioCue) VolumeRange() (min, max float64) {
+	return c.volumeMin, c.volumeMax
+}
+
+// SetVolumeRange sets the range volume is randomly selected from.
+func (c *AudioCue) SetVolumeRange(min, max float64) {
+	c.volumeMin, c.volumeMax = min, max
+}
+
+// PitchRange returns the range pitch is randomly selected from.
+func (c *AudioCue) PitchRange() (min, max float64) {
+	return c.pitchMin, c.pitchMax
+}
+
+// SetPitchRange sets the range pitch is randomly selected from.
+func (c *AudioCue) SetPitchRange(min, max float64) {
+	c.pitchMin, c.pitchMax = min, max
+}
+
+// pick selects a clip at random, weighted by the weight of each clip.
+func (c *AudioCue) pick() *AudioCueClip {
+	var total float64
+
+	for i := range c.clips {
+		if c.clips[i].Sound != nil && c.clips[i].Weight > 0 {
+			total += c.clips[i].Weight
+		}
+	}
+
+	if total <= 0 {
+		return nil
+	}
+
+	r := rand.Float64() * total
+
+	var last *AudioCueClip
+	for i := range c.clips {
+		if c.clips[i].Sound == nil || c.clips[i].Weight <= 0 {
+			continue
+		}
+
+		last = &c.clips[i]
+		if r < c.clips[i].Weight {
+			return last
+		}
+		r -= c.clips[i].Weight
+	}
+
+	return last
+}
+
+func randRange(min, max float64) float64 {
+	if max <= min {
+		return min
+	}
+
+	return min + rand.Float64()*(max-min)
+}
@@ -0,0 +1,301 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"math"
+
+	"github.com/faiface/beep"
+)
+
+var _ AudioEffect = &LowPassFilter{}
+var _ AudioEffect = &Reverb{}
+
+// AudioEffect is a DSP stage which processes a block of stereo samples in
+// place. Effects can be attached to an AudioBus or to an individual Sound.
+// Effects are invoked from the audio thread, so parameters should only be
+// modified while the speaker is locked.
+type AudioEffect interface {
+	// Process filters the samples in place.
+	Process(samples [][2]float64, sampleRate beep.SampleRate)
+
+	// Reset clears any internal state held by the effect.
+	Reset()
+}
+
+// AudioEffectInstancer is implemented by effects which keep state between
+// blocks, such as filters and reverbs. A Sound creates an instance of these
+// effects for each of its voices, so voices which play at the same time do
+// not share state. Instances follow the parameters of the effect.
+type AudioEffectInstancer interface {
+	AudioEffect
+
+	// Instance returns an effect with its own state.
+	Instance() AudioEffect
+}
+
+// instanceEffect returns an instance of effect for a single voice, or the
+// effect itself if it keeps no state.
+func instanceEffect(effect AudioEffect) AudioEffect {
+	if i, ok := effect.(AudioEffectInstancer); ok {
+		return i.Instance()
+	}
+
+	return effect
+}
+
+// LowPassFilter is a one-pole low-pass filter. A Cutoff of zero or less
+// bypasses the filter.
+type LowPassFilter struct {
+	// Cutoff is the cutoff frequency of the filter in Hz.
+	Cutoff float64
+
+	state [2]float64
+}
+
+// NewLowPassFilter creates a new low-pass filter with the given cutoff.
+func NewLowPassFilter(cutoff float64) *LowPassFilter {
+	return &LowPassFilter{
+		Cutoff: cutoff,
+	}
+}
+
+// Process filters the samples in place.
+func (f *LowPassFilter) Process(samples [][2]float64, sampleRate beep.SampleRate) {
+	if f.Cutoff <= 0 || sampleRate <= 0 {
+		return
+	}
+
+	a := 1.0 - math.Exp(-2.0*math.Pi*f.Cutoff/float64(sampleRate))
+
+	for i := range samples {
+		f.state[0] += a * (samples[i][0] - f.state[0])
+		f.state[1] += a * (samples[i][1] - f.state[1])
+		samples[i] = f.state
+	}
+}
+
+// Reset clears any internal state held by the effect.
+func (f *LowPassFilter) Reset() {
+	f.state = [2]float64{}
+}
+
+// Instance returns a filter with its own state which uses the cutoff of f.
+func (f *LowPassFilter) Instance() AudioEffect {
+	return &lowPassInstance{params: f}
+}
+
+// lowPassInstance is the state of a LowPassFilter for a single voice.
+type lowPassInstance struct {
+	params *LowPassFilter
+	filter LowPassFilter
+}
+
+func (i *lowPassInstance) Process(samples [][2]float64, sampleRate beep.SampleRate) {
+	i.filter.Cutoff = i.params.Cutoff
+	i.filter.Process(samples, sampleRate)
+}
+
+func (i *lowPassInstance) Reset() {
+	i.filter.Reset()
+}
+
+// Comb and all-pass delay lengths (in samples at 44.1kHz) used by the reverb.
+// These are the Freeverb tunings.
+var (
+	reverbCombTuning    = [...]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpassTuning = [...]int{556, 441, 341, 225}
+)
+
+const reverbStereoSpread = 23
+
+// Reverb is a Schroeder style reverberator built from parallel feedback comb
+// filters followed by serial all-pass filters.
+type Reverb struct {
+	// RoomSize controls the length of the reverb tail, in the range [0, 1].
+	RoomSize float64
+
+	// Damping controls the absorption of high frequencies, in the range [0, 1].
+	Damping float64
+
+	// Wet is the amount of processed signal mixed into the output.
+	Wet float64
+
+	// Dry is the amount of unprocessed signal mixed into the output.
+	Dry float64
+
+	combs      [2][len(reverbCombTuning)]reverbComb
+	allpasses  [2][len(reverbAllpassTuning)]reverbAllpass
+	sampleRate beep.SampleRate
+}
+
+type reverbComb struct {
+	buffer []float64
+	index  int
+	store  float64
+}
+
+type reverbAllpass struct {
+	buffer []float64
+	index  int
+}
+
+// NewReverb creates a new reverb with the given room size and wet level.
+func NewReverb(roomSize, wet float64) *Reverb {
+	return &Reverb{
+		RoomSize: roomSize,
+		Damping:  0.5,
+		Wet:      wet,
+		Dry:      1.0,
+	}
+}
+
+// Process filters the samples in place.
+func (r *Reverb) Process(samples [][2]float64, sampleRate beep.SampleRate) {
+	if sampleRate <= 0 {
+		return
+	}
+	if r.sampleRate != sampleRate {
+		r.alloc(sampleRate)
+	}
+
+	feedback := 0.7 + 0.28*clampUnit(r.RoomSize)
+	damp := 0.4 * clampUnit(r.Damping)
+
+	for i := range samples {
+		in := (samples[i][0] + samples[i][1]) * 0.015
+
+		for ch := 0; ch < 2; ch++ {
+			var out float64
+
+			for j := range r.combs[ch] {
+				c := &r.combs[ch][j]
+				y := c.buffer[c.index]
+				c.store = y*(1.0-damp) + c.store*damp
+				c.buffer[c.index] = in + c.store*feedback
+				c.index = (c.index + 1) % len(c.buffer)
+				out += y
+			}
+
+			for j := range r.allpasses[ch] {
+				a := &r.allpasses[ch][j]
+				y := a.buffer[a.index]
+				a.buffer[a.index] = out + y*0.5
+				a.index = (a.index + 1) % len(a.buffer)
+				out = y - out
+			}
+
+			samples[i][ch] = samples[i][ch]*r.Dry + out*r.Wet
+		}
+	}
+}
+
+// Reset clears any internal state held by the effect.
+func (r *Reverb) Reset() {
+	for ch := range r.combs {
+		for j := range r.combs[ch] {
+			c := &r.combs[ch][j]
+			for k := range c.buffer {
+				c.buffer[k] = 0
+			}
+			c.index = 0
+			c.store = 0
+		}
+		for j := range r.allpasses[ch] {
+			a := &r.allpasses[ch][j]
+			for k := range a.buffer {
+				a.buffer[k] = 0
+			}
+			a.index = 0
+		}
+	}
+}
+
+// Copy returns a new Reverb with the same parameters, but without any of the
+// internal state of this Reverb.
+func (r *Reverb) Copy() *Reverb {
+	return &Reverb{
+		RoomSize: r.RoomSize,
+		Damping:  r.Damping,
+		Wet:      r.Wet,
+		Dry:      r.Dry,
+	}
+}
+
+// Instance returns a reverb with its own state which uses the parameters of
+// r.
+func (r *Reverb) Instance() AudioEffect {
+	return &reverbInstance{params: r, reverb: r.Copy()}
+}
+
+// reverbInstance is the state of a Reverb for a single voice.
+type reverbInstance struct {
+	params *Reverb
+	reverb *Reverb
+}
+
+func (i *reverbInstance) Process(samples [][2]float64, sampleRate beep.SampleRate) {
+	i.reverb.RoomSize = i.params.RoomSize
+	i.reverb.Damping = i.params.Damping
+	i.reverb.Wet = i.params.Wet
+	i.reverb.Dry = i.params.Dry
+	i.reverb.Process(samples, sampleRate)
+}
+
+func (i *reverbInstance) Reset() {
+	i.reverb.Reset()
+}
+
+func (r *Reverb) alloc(sampleRate beep.SampleRate) {
+	scale := float64(sampleRate) / 44100.0
+
+	for ch := range r.combs {
+		spread := ch * reverbStereoSpread
+
+		for j := range r.combs[ch] {
+			r.combs[ch][j] = reverbComb{
+				buffer: make([]float64, delayLength(reverbCombTuning[j]+spread, scale)),
+			}
+		}
+		for j := range r.allpasses[ch] {
+			r.allpasses[ch][j] = reverbAllpass{
+				buffer: make([]float64, delayLength(reverbAllpassTuning[j]+spread, scale)),
+			}
+		}
+	}
+
+	r.sampleRate = sampleRate
+}
+
+func delayLength(samples int, scale float64) int {
+	n := int(float64(samples) * scale)
+	if n < 1 {
+		n = 1
+	}
+
+	return n
+}
+
+func clampUnit(x float64) float64 {
+	return math.Max(0.0, math.Min(1.0, x))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+// testTone returns a block of alternating full scale samples.
+func testTone(n int) [][2]float64 {
+	samples := make([][2]float64, n)
+	for i := range samples {
+		if i%2 == 0 {
+			samples[i] = [2]float64{1, 1}
+		} else {
+			samples[i] = [2]float64{-1, -1}
+		}
+	}
+
+	return samples
+}
+
+func TestSound_VoiceEffects(t *testing.T) {
+	tests := []AudioEffect{
+		NewLowPassFilter(2000),
+		NewReverb(0.8, 0.5),
+	}
+
+	for i, v := range tests {
+		s := &Sound{effects: []AudioEffect{v}}
+
+		// Two voices of the same sound, played at the same time, must
+		// produce the same output from the same input.
+		a := newVoice(s, 44100, 1)
+		b := newVoice(s, 44100, 1)
+		a.streamer = beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+			return copy(samples, testTone(len(samples))), true
+		})
+		b.streamer = a.streamer
+
+		for block := 0; block < 4; block++ {
+			outA := make([][2]float64, 512)
+			outB := make([][2]float64, 512)
+			a.Stream(outA)
+			b.Stream(outB)
+
+			for j := range outA {
+				if outA[j] != outB[j] {
+					t.Fatalf("Stream case %d failed. want: %v got: %v", i, outA[j], outB[j])
+				}
+			}
+		}
+	}
+}
+
+func TestLowPassFilter_Instance(t *testing.T) {
+	f := NewLowPassFilter(2000)
+	e := f.Instance()
+
+	f.Cutoff = 0
+
+	samples := testTone(16)
+	e.Process(samples, 44100)
+
+	for i, v := range testTone(16) {
+		if samples[i] != v {
+			t.Errorf("Instance failed. want: %v got: %v", v, samples[i])
+		}
+	}
+}
@@ -23,6 +23,10 @@ SOFTWARE.
 package core
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/viper"
 
 	"github.com/haakenlabs/arc/pkg/math"
@@ -37,6 +41,7 @@ const (
 func LoadGlobalConfig() error {
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix(cfgPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.SetConfigFile(cfgFilename)
 	//viper.AddConfigPath(AppDir)
 	viper.SetConfigType("json")
@@ -53,10 +58,35 @@ func LoadGlobalConfig() error {
 	return nil
 }
 
+// defaultShaderCache returns the default directory of the shader program
+// cache, or an empty string if the user has no cache directory.
+func defaultShaderCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, cfgPrefix, "shaders")
+}
+
 // loadDefaultSettings sets default settings.
 func loadDefaultSettings() {
 	// Graphics Options
 	viper.SetDefault("graphics.resolution", math.IVec2{1280, 720})
 	viper.SetDefault("graphics.mode", 0)
 	viper.SetDefault("graphics.vsync", true)
+	viper.SetDefault("graphics.headless", false)
+	viper.SetDefault("graphics.anisotropy", 8.0)
+	viper.SetDefault("graphics.profile", "")
+	viper.SetDefault("graphics.gl_version", "")
+	viper.SetDefault("graphics.shader_reload", false)
+	viper.SetDefault("graphics.shader_source", "")
+	viper.SetDefault("graphics.shader_cache", defaultShaderCache())
+	viper.SetDefault("graphics.debug", false)
+	viper.SetDefault("graphics.debug_errors", false)
+	viper.SetDefault("graphics.gpu_timing", false)
+
+	// Asset Options
+	viper.SetDefault("assets.hot_reload", false)
+	viper.SetDefault("assets.source", "")
 }
@@ -0,0 +1,43 @@
+//go:build gles
+// +build gles
+
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// contextVersions are the OpenGL ES versions tried when creating the
+// context, newest first. The renderer needs at least ES 3.0.
+var contextVersions = [][2]int{{3, 2}, {3, 1}, {3, 0}}
+
+// setContextHints requests an OpenGL ES context.
+func setContextHints() {
+	glfw.WindowHint(glfw.ClientAPI, glfw.OpenGLESAPI)
+}
+
+// setContextState does nothing, ES 3 always filters across the faces of
+// cube maps and rejects TEXTURE_CUBE_MAP_SEAMLESS.
+func setContextState() {}
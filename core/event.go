@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"reflect"
+	"sync"
+)
+
+var globalEvents = NewEventBus()
+
+// EventBus delivers events to the functions subscribed to their type, so
+// that senders and receivers need no references to each other. Events are
+// queued by Publish and delivered in order by Flush, at a defined point in
+// the frame. It is safe to publish from any goroutine.
+type EventBus struct {
+	mu       sync.Mutex
+	handlers map[reflect.Type][]eventHandler
+	queue    []queuedEvent
+	nextID   uint64
+}
+
+// Subscription is returned by Subscribe, and removes the subscription.
+type Subscription struct {
+	bus *EventBus
+	key reflect.Type
+	id  uint64
+}
+
+type eventHandler struct {
+	id uint64
+	fn func(interface{})
+}
+
+type queuedEvent struct {
+	key   reflect.Type
+	event interface{}
+}
+
+// NewEventBus creates a new event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[reflect.Type][]eventHandler),
+	}
+}
+
+// Events returns the global event bus. Its events are delivered once per
+// frame, before the scenes are updated.
+func Events() *EventBus {
+	return globalEvents
+}
+
+// Subscribe calls fn with every event of type T sent through the bus, until
+// the subscription is removed. Subscribers of an interface type receive only
+// events published as that interface type.
+func Subscribe[T any](bus *EventBus, fn func(T)) Subscription {
+	key := eventKey[T]()
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.nextID++
+	bus.handlers[key] = append(bus.handlers[key], eventHandler{
+		id: bus.nextID,
+		fn: func(event interface{}) { fn(event.(T)) },
+	})
+
+	return Subscription{bus: bus, key: key, id: bus.nextID}
+}
+
+// Publish queues an event, to be delivered to the subscribers of type T by
+// the next Flush of the bus.
+func Publish[T any](bus *EventBus, event T) {
+	bus.mu.Lock()
+	bus.queue = append(bus.queue, queuedEvent{key: eventKey[T](), event: event})
+	bus.mu.Unlock()
+}
+
+// Send delivers an event to the subscribers of type T right away.
+func Send[T any](bus *EventBus, event T) {
+	bus.deliver(eventKey[T](), event)
+}
+
+// Unsubscribe removes the subscription. It is safe to call more than once,
+// and from within a subscriber.
+func (s Subscription) Unsubscribe() {
+	if s.bus == nil {
+		return
+	}
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	handlers := s.bus.handlers[s.key]
+	for i := range handlers {
+		if handlers[i].id == s.id {
+			// Copy, as the slice may be being delivered to.
+			h := make([]eventHandler, 0, len(handlers)-1)
+			h = append(h, handlers[:i]...)
+			s.bus.handlers[s.key] = append(h, handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Flush delivers the queued events in the order they were published. Events
+// published by subscribers during a flush are delivered by the next flush.
+func (b *EventBus) Flush() {
+	b.mu.Lock()
+	queue := b.queue
+	b.queue = nil
+	b.mu.Unlock()
+
+	for i := range queue {
+		b.deliver(queue[i].key, queue[i].event)
+	}
+}
+
+// Clear drops the queued events and removes every subscription.
+func (b *EventBus) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.queue = nil
+	b.handlers = make(map[reflect.Type][]eventHandler)
+}
+
+// deliver calls the subscribers of an event type.
+func (b *EventBus) deliver(key reflect.Type, event interface{}) {
+	b.mu.Lock()
+	handlers := b.handlers[key]
+	b.mu.Unlock()
+
+	for i := range handlers {
+		handlers[i].fn(event)
+	}
+}
+
+// eventKey returns the key of the subscribers of events of type T.
+func eventKey[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
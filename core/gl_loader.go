@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+/*
+#include <stdio.h>
+#include <stdlib.h>
+
+static void arcMissingGLFunction(void) {
+	fprintf(stderr, "arc: called an OpenGL function the context does not provide\n");
+	abort();
+}
+
+static void *arcMissingGLFunctionAddr(void) {
+	return (void *)arcMissingGLFunction;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// requiredGLFunctions are the functions the renderer calls on every
+// context. They are part of both OpenGL 3.3 and OpenGL ES 3.0, the oldest
+// contexts which are created.
+var requiredGLFunctions = map[string]bool{
+	"glActiveTexture":                  true,
+	"glAttachShader":                   true,
+	"glBeginQuery":                     true,
+	"glBindBuffer":                     true,
+	"glBindBufferBase":                 true,
+	"glBindFramebuffer":                true,
+	"glBindRenderbuffer":               true,
+	"glBindSampler":                    true,
+	"glBindTexture":                    true,
+	"glBindVertexArray":                true,
+	"glBlendFuncSeparate":              true,
+	"glBlitFramebuffer":                true,
+	"glBufferData":                     true,
+	"glBufferSubData":                  true,
+	"glCheckFramebufferStatus":         true,
+	"glClear":                          true,
+	"glClearColor":                     true,
+	"glClientWaitSync":                 true,
+	"glColorMask":                      true,
+	"glCompileShader":                  true,
+	"glCompressedTexImage2D":           true,
+	"glCreateProgram":                  true,
+	"glCreateShader":                   true,
+	"glCullFace":                       true,
+	"glDeleteBuffers":                  true,
+	"glDeleteFramebuffers":             true,
+	"glDeleteProgram":                  true,
+	"glDeleteQueries":                  true,
+	"glDeleteRenderbuffers":            true,
+	"glDeleteSamplers":                 true,
+	"glDeleteShader":                   true,
+	"glDeleteSync":                     true,
+	"glDeleteTextures":                 true,
+	"glDeleteVertexArrays":             true,
+	"glDepthFunc":                      true,
+	"glDepthMask":                      true,
+	"glDetachShader":                   true,
+	"glDisable":                        true,
+	"glDisableVertexAttribArray":       true,
+	"glDrawArrays":                     true,
+	"glDrawBuffers":                    true,
+	"glDrawElements":                   true,
+	"glEnable":                         true,
+	"glEnableVertexAttribArray":        true,
+	"glEndQuery":                       true,
+	"glFenceSync":                      true,
+	"glFramebufferRenderbuffer":        true,
+	"glFramebufferTexture2D":           true,
+	"glFramebufferTextureLayer":        true,
+	"glGenBuffers":                     true,
+	"glGenFramebuffers":                true,
+	"glGenQueries":                     true,
+	"glGenRenderbuffers":               true,
+	"glGenSamplers":                    true,
+	"glGenTextures":                    true,
+	"glGenVertexArrays":                true,
+	"glGenerateMipmap":                 true,
+	"glGetActiveUniform":               true,
+	"glGetError":                       true,
+	"glGetFloatv":                      true,
+	"glGetIntegerv":                    true,
+	"glGetProgramInfoLog":              true,
+	"glGetProgramiv":                   true,
+	"glGetQueryObjectuiv":              true,
+	"glGetShaderInfoLog":               true,
+	"glGetShaderiv":                    true,
+	"glGetString":                      true,
+	"glGetStringi":                     true,
+	"glGetUniformBlockIndex":           true,
+	"glGetUniformLocation":             true,
+	"glGetUniformfv":                   true,
+	"glGetUniformiv":                   true,
+	"glGetUniformuiv":                  true,
+	"glLinkProgram":                    true,
+	"glMapBufferRange":                 true,
+	"glPixelStorei":                    true,
+	"glReadBuffer":                     true,
+	"glReadPixels":                     true,
+	"glRenderbufferStorage":            true,
+	"glRenderbufferStorageMultisample": true,
+	"glSamplerParameterf":              true,
+	"glSamplerParameteri":              true,
+	"glShaderSource":                   true,
+	"glStencilFunc":                    true,
+	"glStencilMask":                    true,
+	"glStencilOp":                      true,
+	"glTexImage2D":                     true,
+	"glTexImage3D":                     true,
+	"glTexParameterf":                  true,
+	"glTexParameterfv":                 true,
+	"glTexParameteri":                  true,
+	"glTexSubImage3D":                  true,
+	"glUniform1f":                      true,
+	"glUniform1fv":                     true,
+	"glUniform1i":                      true,
+	"glUniform1iv":                     true,
+	"glUniform1ui":                     true,
+	"glUniform1uiv":                    true,
+	"glUniform2fv":                     true,
+	"glUniform2iv":                     true,
+	"glUniform2uiv":                    true,
+	"glUniform3fv":                     true,
+	"glUniform3iv":                     true,
+	"glUniform3uiv":                    true,
+	"glUniform4fv":                     true,
+	"glUniform4iv":                     true,
+	"glUniform4uiv":                    true,
+	"glUniformBlockBinding":            true,
+	"glUniformMatrix2fv":               true,
+	"glUniformMatrix3fv":               true,
+	"glUniformMatrix4fv":               true,
+	"glUnmapBuffer":                    true,
+	"glUseProgram":                     true,
+	"glVertexAttribPointer":            true,
+	"glViewport":                       true,
+}
+
+// glLoader resolves OpenGL functions for the bindings, which target 4.3.
+// Functions missing from the context are bound to a stub which aborts the
+// program when called, the graphics capabilities keep the renderer from
+// calling them.
+type glLoader struct {
+	lookup  func(name string) unsafe.Pointer
+	missing []string
+}
+
+// procAddress returns the address of the function name.
+func (l *glLoader) procAddress(name string) unsafe.Pointer {
+	if p := l.lookup(name); p != nil {
+		return p
+	}
+
+	l.missing = append(l.missing, name)
+
+	return C.arcMissingGLFunctionAddr()
+}
+
+// check returns an error naming the required functions which are missing.
+func (l *glLoader) check() error {
+	var missing []string
+	for _, name := range l.missing {
+		if requiredGLFunctions[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+
+	return fmt.Errorf("window: OpenGL context lacks required functions: %s", strings.Join(missing, ", "))
+}
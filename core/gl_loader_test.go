@@ -0,0 +1,67 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestGLLoader_ProcAddress(t *testing.T) {
+	var found int
+	l := &glLoader{lookup: func(name string) unsafe.Pointer {
+		if name == "glClear" {
+			return unsafe.Pointer(&found)
+		}
+		return nil
+	}}
+
+	if p := l.procAddress("glClear"); p != unsafe.Pointer(&found) {
+		t.Errorf("procAddress failed. want: %v got: %v", unsafe.Pointer(&found), p)
+	}
+	if p := l.procAddress("glDispatchCompute"); p == nil {
+		t.Error("procAddress failed. want: stub got: nil")
+	}
+	if len(l.missing) != 1 || l.missing[0] != "glDispatchCompute" {
+		t.Errorf("procAddress failed. want: [glDispatchCompute] got: %v", l.missing)
+	}
+}
+
+func TestGLLoader_Check(t *testing.T) {
+	tests := []struct {
+		missing []string
+		fail    bool
+	}{
+		{nil, false},
+		{[]string{"glDispatchCompute", "glObjectLabel"}, false},
+		{[]string{"glDispatchCompute", "glDrawElements"}, true},
+	}
+
+	for i, v := range tests {
+		l := &glLoader{missing: v.missing}
+
+		if err := l.check(); (err != nil) != v.fail {
+			t.Errorf("check case %d failed. want: %v got: %v", i, v.fail, err)
+		}
+	}
+}
@@ -25,6 +25,7 @@ package core
 import (
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 
 	"github.com/juju/errors"
@@ -192,6 +193,23 @@ func (s *InstanceSystem) Get(id int32) (Object, error) {
 	return object, nil
 }
 
+// Objects returns all objects in the instance database, ordered by ID.
+func (s *InstanceSystem) Objects() []Object {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	objects := make([]Object, 0, len(s.objects))
+	for _, v := range s.objects {
+		objects = append(objects, v)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ID() < objects[j].ID()
+	})
+
+	return objects
+}
+
 // NewInstance creates a new instance system.
 func NewInstanceSystem() *InstanceSystem {
 	s := &InstanceSystem{
@@ -0,0 +1,436 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ System = &NetworkSystem{}
+
+var networkInst *NetworkSystem
+
+const SysNameNetwork = "network"
+
+// PeerID identifies a connection of a transport.
+type PeerID uint32
+
+// NetChannel selects the delivery guarantees of a message.
+type NetChannel uint8
+
+const (
+	// NetChannelUnreliable messages may be lost, duplicated or reordered.
+	NetChannelUnreliable NetChannel = iota
+
+	// NetChannelReliable messages are delivered once, in order.
+	NetChannelReliable
+)
+
+// NetEventType is the type of a NetEvent.
+type NetEventType uint8
+
+const (
+	NetEventConnect NetEventType = iota
+	NetEventDisconnect
+	NetEventData
+)
+
+// NetEvent is an event produced by a transport.
+type NetEvent struct {
+	Type    NetEventType
+	Peer    PeerID
+	Channel NetChannel
+	Data    []byte
+}
+
+// NetTransport moves packets between peers. Transports are polled from the
+// main thread once per frame.
+type NetTransport interface {
+	// Listen starts accepting connections on the address.
+	Listen(addr string) error
+
+	// Connect starts connecting to the address. A NetEventConnect is produced
+	// once the connection is established, or a NetEventDisconnect if it is
+	// refused or times out.
+	Connect(addr string) error
+
+	// Send sends data to a peer.
+	Send(peer PeerID, channel NetChannel, data []byte) error
+
+	// Disconnect closes the connection to a peer.
+	Disconnect(peer PeerID)
+
+	// Poll performs any periodic work of the transport, and returns the
+	// events received since the last call.
+	Poll() []NetEvent
+
+	// Close closes all connections and releases the transport.
+	Close() error
+}
+
+// NetMessage is a message which can be sent over the network. Every message
+// type must be registered with the NetworkSystem under a unique ID.
+type NetMessage interface {
+	// NetID returns the ID the message type is registered under.
+	NetID() uint16
+
+	// Encode writes the message.
+	Encode(w *NetWriter)
+
+	// Decode reads the message.
+	Decode(r *NetReader) error
+}
+
+// NetMessageHandler handles a message received from a peer.
+type NetMessageHandler func(peer PeerID, msg NetMessage)
+
+// ErrNetMessageUnknown reports that a message type was not registered.
+type ErrNetMessageUnknown uint16
+
+func (e ErrNetMessageUnknown) Error() string {
+	return fmt.Sprintf("network: unknown message type: %d", uint16(e))
+}
+
+// ErrNetNotStarted reports that the network system has no transport.
+type ErrNetNotStarted string
+
+func (e ErrNetNotStarted) Error() string {
+	return "network: not started: " + string(e)
+}
+
+// NetSubscription is returned when adding a message handler or a peer
+// callback, and removes it.
+type NetSubscription struct {
+	n  *NetworkSystem
+	id uint64
+}
+
+type netHandler struct {
+	id uint64
+	fn NetMessageHandler
+}
+
+type peerCallback struct {
+	id uint64
+	fn func(PeerID)
+}
+
+// NetworkSystem serializes messages, dispatches them to handlers, and tracks
+// the peers of a transport.
+type NetworkSystem struct {
+	transport NetTransport
+	factories map[uint16]func() NetMessage
+	handlers  map[uint16][]netHandler
+	peers     map[PeerID]bool
+
+	onConnect    []peerCallback
+	onDisconnect []peerCallback
+
+	nextID uint64
+	server bool
+}
+
+// Setup sets up the System.
+func (n *NetworkSystem) Setup() error {
+	if networkInst != nil {
+		return ErrSystemInit(SysNameNetwork)
+	}
+	networkInst = n
+
+	return nil
+}
+
+// Teardown tears down the System.
+func (n *NetworkSystem) Teardown() {
+	n.Shutdown()
+
+	networkInst = nil
+}
+
+// Name returns the name of the System.
+func (n *NetworkSystem) Name() string {
+	return SysNameNetwork
+}
+
+// SetTransport sets the transport used by the system. Any existing transport
+// is shut down.
+func (n *NetworkSystem) SetTransport(transport NetTransport) {
+	n.Shutdown()
+
+	n.transport = transport
+}
+
+// Transport returns the transport used by the system.
+func (n *NetworkSystem) Transport() NetTransport {
+	return n.transport
+}
+
+// Listen starts a server on the address.
+func (n *NetworkSystem) Listen(addr string) error {
+	if n.transport == nil {
+		n.transport = NewUDPTransport()
+	}
+
+	if err := n.transport.Listen(addr); err != nil {
+		return err
+	}
+
+	n.server = true
+
+	return nil
+}
+
+// Connect starts connecting to a server at the address.
+func (n *NetworkSystem) Connect(addr string) error {
+	if n.transport == nil {
+		n.transport = NewUDPTransport()
+	}
+
+	n.server = false
+
+	return n.transport.Connect(addr)
+}
+
+// Shutdown disconnects all peers and closes the transport. The disconnect
+// callbacks are invoked for every connected peer.
+func (n *NetworkSystem) Shutdown() {
+	if n.transport == nil {
+		return
+	}
+
+	if err := n.transport.Close(); err != nil {
+		logrus.Error("[Network] ", err)
+	}
+
+	peers := n.peers
+
+	n.transport = nil
+	n.peers = make(map[PeerID]bool)
+	n.server = false
+
+	for p := range peers {
+		for _, c := range n.onDisconnect {
+			c.fn(p)
+		}
+	}
+}
+
+// IsServer reports if the system is listening for connections.
+func (n *NetworkSystem) IsServer() bool {
+	return n.server
+}
+
+// Peers returns the connected peers.
+func (n *NetworkSystem) Peers() []PeerID {
+	peers := make([]PeerID, 0, len(n.peers))
+	for p := range n.peers {
+		peers = append(peers, p)
+	}
+
+	return peers
+}
+
+// Connected reports if the peer is connected.
+func (n *NetworkSystem) Connected(peer PeerID) bool {
+	return n.peers[peer]
+}
+
+// RegisterMessage registers a message type. The factory must return a new,
+// empty message.
+func (n *NetworkSystem) RegisterMessage(factory func() NetMessage) {
+	n.factories[factory().NetID()] = factory
+}
+
+// Handle adds a handler for a message type.
+func (n *NetworkSystem) Handle(id uint16, handler NetMessageHandler) NetSubscription {
+	n.nextID++
+	n.handlers[id] = append(n.handlers[id], netHandler{id: n.nextID, fn: handler})
+
+	return NetSubscription{n: n, id: n.nextID}
+}
+
+// OnConnect adds a callback invoked when a peer connects.
+func (n *NetworkSystem) OnConnect(fn func(PeerID)) NetSubscription {
+	n.nextID++
+	n.onConnect = append(n.onConnect, peerCallback{id: n.nextID, fn: fn})
+
+	return NetSubscription{n: n, id: n.nextID}
+}
+
+// OnDisconnect adds a callback invoked when a peer disconnects.
+func (n *NetworkSystem) OnDisconnect(fn func(PeerID)) NetSubscription {
+	n.nextID++
+	n.onDisconnect = append(n.onDisconnect, peerCallback{id: n.nextID, fn: fn})
+
+	return NetSubscription{n: n, id: n.nextID}
+}
+
+// Unsubscribe removes the handler or callback. It is safe to call more than
+// once, and from within a handler.
+func (s NetSubscription) Unsubscribe() {
+	if s.n == nil {
+		return
+	}
+
+	for msgID, handlers := range s.n.handlers {
+		for i := range handlers {
+			if handlers[i].id == s.id {
+				// Copy, as the slice may be being dispatched to.
+				h := make([]netHandler, 0, len(handlers)-1)
+				h = append(h, handlers[:i]...)
+				s.n.handlers[msgID] = append(h, handlers[i+1:]...)
+				return
+			}
+		}
+	}
+
+	s.n.onConnect = removePeerCallback(s.n.onConnect, s.id)
+	s.n.onDisconnect = removePeerCallback(s.n.onDisconnect, s.id)
+}
+
+func removePeerCallback(callbacks []peerCallback, id uint64) []peerCallback {
+	for i := range callbacks {
+		if callbacks[i].id == id {
+			c := make([]peerCallback, 0, len(callbacks)-1)
+			c = append(c, callbacks[:i]...)
+			return append(c, callbacks[i+1:]...)
+		}
+	}
+
+	return callbacks
+}
+
+// Send sends a message to a peer.
+func (n *NetworkSystem) Send(peer PeerID, channel NetChannel, msg NetMessage) error {
+	if n.transport == nil {
+		return ErrNetNotStarted(SysNameNetwork)
+	}
+
+	return n.transport.Send(peer, channel, EncodeNetMessage(msg))
+}
+
+// Broadcast sends a message to all connected peers. It returns the first
+// error encountered, after sending to the remaining peers.
+func (n *NetworkSystem) Broadcast(channel NetChannel, msg NetMessage) error {
+	if n.transport == nil {
+		return ErrNetNotStarted(SysNameNetwork)
+	}
+
+	data := EncodeNetMessage(msg)
+
+	var err error
+	for p := range n.peers {
+		if e := n.transport.Send(p, channel, data); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// Disconnect closes the connection to a peer.
+func (n *NetworkSystem) Disconnect(peer PeerID) {
+	if n.transport != nil {
+		n.transport.Disconnect(peer)
+	}
+}
+
+// Update polls the transport and dispatches received events. This should be
+// called once per frame from the main thread.
+func (n *NetworkSystem) Update() {
+	if n.transport == nil {
+		return
+	}
+
+	for _, e := range n.transport.Poll() {
+		switch e.Type {
+		case NetEventConnect:
+			n.peers[e.Peer] = true
+			for _, c := range n.onConnect {
+				c.fn(e.Peer)
+			}
+		case NetEventDisconnect:
+			delete(n.peers, e.Peer)
+			for _, c := range n.onDisconnect {
+				c.fn(e.Peer)
+			}
+		case NetEventData:
+			if err := n.dispatch(e.Peer, e.Data); err != nil {
+				logrus.Warn("[Network] ", err)
+			}
+		}
+	}
+}
+
+func (n *NetworkSystem) dispatch(peer PeerID, data []byte) error {
+	r := NewNetReader(data)
+
+	id := r.ReadUint16()
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	factory, ok := n.factories[id]
+	if !ok {
+		return ErrNetMessageUnknown(id)
+	}
+
+	msg := factory()
+	if err := msg.Decode(r); err != nil {
+		return err
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	for _, h := range n.handlers[id] {
+		h.fn(peer, msg)
+	}
+
+	return nil
+}
+
+// EncodeNetMessage serializes a message along with its ID.
+func EncodeNetMessage(msg NetMessage) []byte {
+	w := NewNetWriter()
+	w.WriteUint16(msg.NetID())
+	msg.Encode(w)
+
+	return w.Bytes()
+}
+
+// NewNetworkSystem creates a new network system.
+func NewNetworkSystem() *NetworkSystem {
+	return &NetworkSystem{
+		factories: make(map[uint16]func() NetMessage),
+		handlers:  make(map[uint16][]netHandler),
+		peers:     make(map[PeerID]bool),
+	}
+}
+
+// GetNetworkSystem gets the network system from the current app.
+func GetNetworkSystem() *NetworkSystem {
+	return networkInst
+}
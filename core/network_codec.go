@@ -0,0 +1,264 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ErrNetShortRead is returned by a NetReader when a read runs past the end of
+// the data.
+var ErrNetShortRead = errors.New("network: short read")
+
+// NetWriter serializes values into a byte slice in little-endian order.
+type NetWriter struct {
+	buf []byte
+}
+
+// NetReader deserializes values written by a NetWriter. The first error
+// encountered is sticky; subsequent reads return zero values.
+type NetReader struct {
+	buf []byte
+	off int
+	err error
+}
+
+// NewNetWriter creates a new writer.
+func NewNetWriter() *NetWriter {
+	return &NetWriter{}
+}
+
+// Bytes returns the serialized data.
+func (w *NetWriter) Bytes() []byte {
+	return w.buf
+}
+
+// Len returns the number of bytes written.
+func (w *NetWriter) Len() int {
+	return len(w.buf)
+}
+
+// Reset discards all written data.
+func (w *NetWriter) Reset() {
+	w.buf = w.buf[:0]
+}
+
+func (w *NetWriter) WriteUint8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *NetWriter) WriteUint16(v uint16) {
+	w.buf = append(w.buf, byte(v), byte(v>>8))
+}
+
+func (w *NetWriter) WriteUint32(v uint32) {
+	w.buf = append(w.buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (w *NetWriter) WriteUint64(v uint64) {
+	w.WriteUint32(uint32(v))
+	w.WriteUint32(uint32(v >> 32))
+}
+
+func (w *NetWriter) WriteInt32(v int32) {
+	w.WriteUint32(uint32(v))
+}
+
+func (w *NetWriter) WriteInt64(v int64) {
+	w.WriteUint64(uint64(v))
+}
+
+func (w *NetWriter) WriteFloat32(v float32) {
+	w.WriteUint32(math.Float32bits(v))
+}
+
+func (w *NetWriter) WriteFloat64(v float64) {
+	w.WriteUint64(math.Float64bits(v))
+}
+
+func (w *NetWriter) WriteBool(v bool) {
+	if v {
+		w.WriteUint8(1)
+	} else {
+		w.WriteUint8(0)
+	}
+}
+
+// WriteBytes writes a length prefixed byte slice.
+func (w *NetWriter) WriteBytes(v []byte) {
+	w.WriteUint32(uint32(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// WriteString writes a length prefixed string.
+func (w *NetWriter) WriteString(v string) {
+	w.WriteUint32(uint32(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *NetWriter) WriteVec2(v mgl32.Vec2) {
+	w.WriteFloat32(v[0])
+	w.WriteFloat32(v[1])
+}
+
+func (w *NetWriter) WriteVec3(v mgl32.Vec3) {
+	w.WriteFloat32(v[0])
+	w.WriteFloat32(v[1])
+	w.WriteFloat32(v[2])
+}
+
+func (w *NetWriter) WriteQuat(v mgl32.Quat) {
+	w.WriteFloat32(v.W)
+	w.WriteVec3(v.V)
+}
+
+// NewNetReader creates a new reader over the data.
+func NewNetReader(data []byte) *NetReader {
+	return &NetReader{
+		buf: data,
+	}
+}
+
+// Err returns the first error encountered by the reader.
+func (r *NetReader) Err() error {
+	return r.err
+}
+
+// Remaining returns the number of unread bytes.
+func (r *NetReader) Remaining() int {
+	return len(r.buf) - r.off
+}
+
+func (r *NetReader) ReadUint8() uint8 {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+
+	return b[0]
+}
+
+func (r *NetReader) ReadUint16() uint16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (r *NetReader) ReadUint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *NetReader) ReadUint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (r *NetReader) ReadInt32() int32 {
+	return int32(r.ReadUint32())
+}
+
+func (r *NetReader) ReadInt64() int64 {
+	return int64(r.ReadUint64())
+}
+
+func (r *NetReader) ReadFloat32() float32 {
+	return math.Float32frombits(r.ReadUint32())
+}
+
+func (r *NetReader) ReadFloat64() float64 {
+	return math.Float64frombits(r.ReadUint64())
+}
+
+func (r *NetReader) ReadBool() bool {
+	return r.ReadUint8() != 0
+}
+
+// ReadBytes reads a length prefixed byte slice. The returned slice is a copy.
+func (r *NetReader) ReadBytes() []byte {
+	n := r.ReadUint32()
+	if r.err != nil {
+		return nil
+	}
+
+	b := r.next(int(n))
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte(nil), b...)
+}
+
+// ReadString reads a length prefixed string.
+func (r *NetReader) ReadString() string {
+	n := r.ReadUint32()
+	if r.err != nil {
+		return ""
+	}
+
+	return string(r.next(int(n)))
+}
+
+func (r *NetReader) ReadVec2() mgl32.Vec2 {
+	return mgl32.Vec2{r.ReadFloat32(), r.ReadFloat32()}
+}
+
+func (r *NetReader) ReadVec3() mgl32.Vec3 {
+	return mgl32.Vec3{r.ReadFloat32(), r.ReadFloat32(), r.ReadFloat32()}
+}
+
+func (r *NetReader) ReadQuat() mgl32.Quat {
+	w := r.ReadFloat32()
+
+	return mgl32.Quat{W: w, V: r.ReadVec3()}
+}
+
+func (r *NetReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.off+n > len(r.buf) {
+		r.err = ErrNetShortRead
+		return nil
+	}
+
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+
+	return b
+}
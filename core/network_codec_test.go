@@ -0,0 +1,216 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestNetReader_RoundTrip(t *testing.T) {
+	tests := []struct {
+		write func(w *NetWriter)
+		read  func(r *NetReader) interface{}
+		want  interface{}
+	}{
+		{
+			write: func(w *NetWriter) { w.WriteUint8(0xAB) },
+			read:  func(r *NetReader) interface{} { return r.ReadUint8() },
+			want:  uint8(0xAB),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteUint16(0xBEEF) },
+			read:  func(r *NetReader) interface{} { return r.ReadUint16() },
+			want:  uint16(0xBEEF),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteUint32(0xDEADBEEF) },
+			read:  func(r *NetReader) interface{} { return r.ReadUint32() },
+			want:  uint32(0xDEADBEEF),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteUint64(math.MaxUint64 - 1) },
+			read:  func(r *NetReader) interface{} { return r.ReadUint64() },
+			want:  uint64(math.MaxUint64 - 1),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteInt32(math.MinInt32) },
+			read:  func(r *NetReader) interface{} { return r.ReadInt32() },
+			want:  int32(math.MinInt32),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteInt64(-42) },
+			read:  func(r *NetReader) interface{} { return r.ReadInt64() },
+			want:  int64(-42),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteFloat32(-1.5) },
+			read:  func(r *NetReader) interface{} { return r.ReadFloat32() },
+			want:  float32(-1.5),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteFloat64(math.Pi) },
+			read:  func(r *NetReader) interface{} { return r.ReadFloat64() },
+			want:  math.Pi,
+		},
+		{
+			write: func(w *NetWriter) { w.WriteBool(true) },
+			read:  func(r *NetReader) interface{} { return r.ReadBool() },
+			want:  true,
+		},
+		{
+			write: func(w *NetWriter) { w.WriteBool(false) },
+			read:  func(r *NetReader) interface{} { return r.ReadBool() },
+			want:  false,
+		},
+		{
+			write: func(w *NetWriter) { w.WriteBytes([]byte{1, 2, 3}) },
+			read:  func(r *NetReader) interface{} { return r.ReadBytes() },
+			want:  []byte{1, 2, 3},
+		},
+		{
+			write: func(w *NetWriter) { w.WriteBytes([]byte{}) },
+			read:  func(r *NetReader) interface{} { return r.ReadBytes() },
+			want:  []byte(nil),
+		},
+		{
+			write: func(w *NetWriter) { w.WriteString("arc") },
+			read:  func(r *NetReader) interface{} { return r.ReadString() },
+			want:  "arc",
+		},
+		{
+			write: func(w *NetWriter) { w.WriteVec2(mgl32.Vec2{1, -2}) },
+			read:  func(r *NetReader) interface{} { return r.ReadVec2() },
+			want:  mgl32.Vec2{1, -2},
+		},
+		{
+			write: func(w *NetWriter) { w.WriteVec3(mgl32.Vec3{1, -2, 3}) },
+			read:  func(r *NetReader) interface{} { return r.ReadVec3() },
+			want:  mgl32.Vec3{1, -2, 3},
+		},
+		{
+			write: func(w *NetWriter) { w.WriteQuat(mgl32.Quat{W: 0.5, V: mgl32.Vec3{0.5, -0.5, 0.5}}) },
+			read:  func(r *NetReader) interface{} { return r.ReadQuat() },
+			want:  mgl32.Quat{W: 0.5, V: mgl32.Vec3{0.5, -0.5, 0.5}},
+		},
+	}
+
+	for i, v := range tests {
+		w := NewNetWriter()
+		v.write(w)
+
+		r := NewNetReader(w.Bytes())
+		got := v.read(r)
+		if r.Err() != nil {
+			t.Errorf("RoundTrip case %d failed. error: %v", i, r.Err())
+			continue
+		}
+		if !reflect.DeepEqual(v.want, got) {
+			t.Errorf(
+				"RoundTrip case %d failed. want: %v got: %v",
+				i, v.want, got)
+		}
+		if r.Remaining() != 0 {
+			t.Errorf(
+				"RoundTrip case %d failed. want: 0 remaining got: %d",
+				i, r.Remaining())
+		}
+	}
+}
+
+func TestNetReader_ShortRead(t *testing.T) {
+	tests := []struct {
+		data []byte
+		read func(r *NetReader) interface{}
+		want interface{}
+	}{
+		{
+			data: nil,
+			read: func(r *NetReader) interface{} { return r.ReadUint8() },
+			want: uint8(0),
+		},
+		{
+			data: []byte{1},
+			read: func(r *NetReader) interface{} { return r.ReadUint16() },
+			want: uint16(0),
+		},
+		{
+			data: []byte{1, 2, 3},
+			read: func(r *NetReader) interface{} { return r.ReadUint32() },
+			want: uint32(0),
+		},
+		{
+			data: []byte{1, 2, 3, 4, 5, 6, 7},
+			read: func(r *NetReader) interface{} { return r.ReadUint64() },
+			want: uint64(0),
+		},
+		{
+			data: []byte{4, 0, 0, 0, 1, 2},
+			read: func(r *NetReader) interface{} { return r.ReadBytes() },
+			want: []byte(nil),
+		},
+		{
+			data: []byte{3, 0},
+			read: func(r *NetReader) interface{} { return r.ReadString() },
+			want: "",
+		},
+		{
+			data: []byte{3, 0, 0, 0, 'a', 'r'},
+			read: func(r *NetReader) interface{} { return r.ReadString() },
+			want: "",
+		},
+	}
+
+	for i, v := range tests {
+		r := NewNetReader(v.data)
+		got := v.read(r)
+		if r.Err() != ErrNetShortRead {
+			t.Errorf(
+				"ShortRead case %d failed. want: %v got: %v",
+				i, ErrNetShortRead, r.Err())
+		}
+		if !reflect.DeepEqual(v.want, got) {
+			t.Errorf(
+				"ShortRead case %d failed. want: %v got: %v",
+				i, v.want, got)
+		}
+	}
+}
+
+func TestNetReader_StickyError(t *testing.T) {
+	w := NewNetWriter()
+	w.WriteUint8(7)
+
+	r := NewNetReader(append(w.Bytes(), 0))
+	r.ReadUint32()
+	if r.Err() != ErrNetShortRead {
+		t.Fatalf("want: %v got: %v", ErrNetShortRead, r.Err())
+	}
+
+	if got := r.ReadUint8(); got != 0 || r.Err() != ErrNetShortRead {
+		t.Errorf("want: 0, %v got: %d, %v", ErrNetShortRead, got, r.Err())
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+type testTransport struct {
+	events []NetEvent
+	sent   map[PeerID]int
+	fail   map[PeerID]bool
+}
+
+func newTestTransport() *testTransport {
+	return &testTransport{
+		sent: make(map[PeerID]int),
+		fail: make(map[PeerID]bool),
+	}
+}
+
+func (t *testTransport) Listen(addr string) error  { return nil }
+func (t *testTransport) Connect(addr string) error { return nil }
+func (t *testTransport) Disconnect(peer PeerID)    {}
+func (t *testTransport) Close() error              { return nil }
+
+func (t *testTransport) Send(peer PeerID, channel NetChannel, data []byte) error {
+	if t.fail[peer] {
+		return errors.New("send failed")
+	}
+	t.sent[peer]++
+
+	return nil
+}
+
+func (t *testTransport) Poll() []NetEvent {
+	events := t.events
+	t.events = nil
+
+	return events
+}
+
+func setupTestNetwork(peers ...PeerID) (*NetworkSystem, *testTransport) {
+	n := NewNetworkSystem()
+	tr := newTestTransport()
+	n.SetTransport(tr)
+
+	for _, p := range peers {
+		tr.events = append(tr.events, NetEvent{Type: NetEventConnect, Peer: p})
+	}
+	n.Update()
+
+	return n, tr
+}
+
+type testMsg struct{}
+
+func (m *testMsg) NetID() uint16             { return 1 }
+func (m *testMsg) Encode(w *NetWriter)       {}
+func (m *testMsg) Decode(r *NetReader) error { return nil }
+
+func TestNetworkSystem_Broadcast(t *testing.T) {
+	n, tr := setupTestNetwork(1, 2, 3)
+	tr.fail[2] = true
+
+	if err := n.Broadcast(NetChannelReliable, &testMsg{}); err == nil {
+		t.Error("want: error got: nil")
+	}
+
+	for _, p := range []PeerID{1, 3} {
+		if tr.sent[p] != 1 {
+			t.Errorf("Broadcast peer %d failed. want: 1 sent got: %d", p, tr.sent[p])
+		}
+	}
+}
+
+func TestNetworkSystem_Shutdown(t *testing.T) {
+	n, _ := setupTestNetwork(1, 2)
+
+	disconnected := make(map[PeerID]bool)
+	n.OnDisconnect(func(p PeerID) { disconnected[p] = true })
+
+	n.Shutdown()
+
+	if len(disconnected) != 2 || !disconnected[1] || !disconnected[2] {
+		t.Errorf("want: peers 1 and 2 disconnected got: %v", disconnected)
+	}
+	if len(n.Peers()) != 0 {
+		t.Errorf("want: no peers got: %v", n.Peers())
+	}
+}
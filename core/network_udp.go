@@ -0,0 +1,743 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ NetTransport = &UDPTransport{}
+
+const (
+	// udpProtocolID prefixes every packet, so that stray datagrams are ignored.
+	udpProtocolID uint16 = 0x4152
+
+	// UDPMaxPayload is the largest message which can be sent in a packet.
+	// Larger reliable messages are split across packets.
+	UDPMaxPayload = 1200
+
+	// UDPMaxMessage is the largest reliable message which can be sent.
+	UDPMaxMessage = 256 * UDPMaxPayload
+
+	// UDPDefaultMaxPeers is the default number of peers a listening
+	// transport accepts.
+	UDPDefaultMaxPeers = 32
+
+	udpHeaderSize   = 16
+	udpRecvQueue    = 1024
+	udpReliableWnd  = 1024
+	udpResendTime   = 200 * time.Millisecond
+	udpConnectRetry = 250 * time.Millisecond
+	udpKeepAlive    = time.Second
+	udpTimeout      = 10 * time.Second
+)
+
+const (
+	udpPacketConnect uint8 = iota
+	udpPacketAccept
+	udpPacketDisconnect
+	udpPacketData
+	udpPacketKeepAlive
+)
+
+// ErrNetPeerNotFound reports that a peer is not connected.
+type ErrNetPeerNotFound PeerID
+
+func (e ErrNetPeerNotFound) Error() string {
+	return fmt.Sprintf("network: peer not found: %d", uint32(e))
+}
+
+// ErrNetPayloadSize is returned when a message is larger than UDPMaxPayload.
+var ErrNetPayloadSize = errors.New("network: payload too large")
+
+// ErrNetStarted is returned when listening or connecting with a transport
+// which has already been started.
+var ErrNetStarted = errors.New("network: transport already started")
+
+// UDPTransport is a NetTransport over UDP. Reliable messages are acknowledged
+// with a sliding window of packet sequence numbers, resent until
+// acknowledged, and delivered in order. Unreliable messages are delivered as
+// they arrive.
+type UDPTransport struct {
+	conn   *net.UDPConn
+	recv   chan udpDatagram
+	closed chan struct{}
+	wg     sync.WaitGroup
+
+	peers    map[PeerID]*udpPeer
+	addrs    map[string]PeerID
+	nextID   PeerID
+	maxPeers int
+	events   []NetEvent
+	server   bool
+}
+
+type udpDatagram struct {
+	addr *net.UDPAddr
+	data []byte
+}
+
+type udpPeer struct {
+	id        PeerID
+	addr      *net.UDPAddr
+	salt      uint64
+	connected bool
+	needAck   bool
+
+	started  time.Time
+	lastRecv time.Time
+	lastSend time.Time
+
+	// Packet sequencing, used for acknowledgements.
+	seq       uint16
+	remoteSeq uint16
+	ackBits   uint32
+	hasRemote bool
+
+	// Reliable sending. At most udpReliableWnd messages from ackRSeq on are
+	// in flight, so the peer can always buffer them; the rest are queued.
+	// sent maps packet sequence numbers to the reliable message carried by
+	// the packet.
+	sendRSeq uint16
+	ackRSeq  uint16
+	pending  map[uint16]*udpReliable
+	sent     map[uint16]uint16
+	queue    []*udpReliable
+
+	// Reliable receiving. partial holds the fragments of a message split
+	// across packets.
+	recvRSeq uint16
+	buffered map[uint16]*udpReliable
+	partial  []byte
+}
+
+// udpReliable is a reliable message, or a fragment of one. more is set on
+// every fragment but the last.
+type udpReliable struct {
+	data     []byte
+	more     bool
+	lastSent time.Time
+}
+
+// NewUDPTransport creates a new UDP transport.
+func NewUDPTransport() *UDPTransport {
+	return &UDPTransport{
+		peers:    make(map[PeerID]*udpPeer),
+		addrs:    make(map[string]PeerID),
+		nextID:   1,
+		maxPeers: UDPDefaultMaxPeers,
+	}
+}
+
+// SetMaxPeers sets the number of peers a listening transport accepts.
+// Connection attempts over the limit are refused.
+func (t *UDPTransport) SetMaxPeers(n int) {
+	t.maxPeers = n
+}
+
+// MaxPeers returns the number of peers a listening transport accepts.
+func (t *UDPTransport) MaxPeers() int {
+	return t.maxPeers
+}
+
+// Listen starts accepting connections on the address. It returns
+// ErrNetStarted if the transport is already listening or connecting.
+func (t *UDPTransport) Listen(addr string) error {
+	if t.conn != nil {
+		return ErrNetStarted
+	}
+
+	a, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", a)
+	if err != nil {
+		return err
+	}
+
+	t.server = true
+	t.start(conn)
+
+	logrus.Debug("[Network] Listening on ", conn.LocalAddr())
+
+	return nil
+}
+
+// Connect starts connecting to the address. It returns ErrNetStarted if the
+// transport is already listening or connecting.
+func (t *UDPTransport) Connect(addr string) error {
+	if t.conn != nil {
+		return ErrNetStarted
+	}
+
+	a, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return err
+	}
+
+	t.server = false
+	t.start(conn)
+
+	p := t.addPeer(a)
+	p.salt = rand.Uint64()
+	t.sendConnect(p)
+
+	return nil
+}
+
+// LocalAddr returns the local address of the transport, or nil if it has not
+// been started.
+func (t *UDPTransport) LocalAddr() net.Addr {
+	if t.conn == nil {
+		return nil
+	}
+
+	return t.conn.LocalAddr()
+}
+
+// Send sends data to a peer.
+func (t *UDPTransport) Send(peer PeerID, channel NetChannel, data []byte) error {
+	p, ok := t.peers[peer]
+	if !ok || !p.connected {
+		return ErrNetPeerNotFound(peer)
+	}
+
+	if channel == NetChannelReliable {
+		if len(data) > UDPMaxMessage {
+			return ErrNetPayloadSize
+		}
+
+		for len(data) > UDPMaxPayload {
+			p.queue = append(p.queue, &udpReliable{data: data[:UDPMaxPayload], more: true})
+			data = data[UDPMaxPayload:]
+		}
+		p.queue = append(p.queue, &udpReliable{data: data})
+
+		return t.flush(p)
+	}
+
+	if len(data) > UDPMaxPayload {
+		return ErrNetPayloadSize
+	}
+
+	return t.sendData(p, NetChannelUnreliable, 0, false, data)
+}
+
+// Disconnect closes the connection to a peer.
+func (t *UDPTransport) Disconnect(peer PeerID) {
+	p, ok := t.peers[peer]
+	if !ok {
+		return
+	}
+
+	t.write(p, t.header(udpPacketDisconnect))
+	t.removePeer(p)
+}
+
+// Poll processes received packets, resends unacknowledged messages and times
+// out dead connections.
+func (t *UDPTransport) Poll() []NetEvent {
+	if t.conn == nil {
+		return nil
+	}
+
+	for {
+		select {
+		case d := <-t.recv:
+			t.process(d)
+			continue
+		default:
+		}
+		break
+	}
+
+	now := time.Now()
+
+	for _, p := range t.peers {
+		if !p.connected {
+			if now.Sub(p.started) > udpTimeout {
+				logrus.Warn("[Network] Connection to ", p.addr, " timed out")
+				t.removePeer(p)
+			} else if now.Sub(p.lastSend) > udpConnectRetry {
+				t.sendConnect(p)
+			}
+			continue
+		}
+
+		if now.Sub(p.lastRecv) > udpTimeout {
+			logrus.Warn("[Network] Peer ", p.id, " timed out")
+			t.removePeer(p)
+			continue
+		}
+
+		if err := t.flush(p); err != nil {
+			logrus.Warn("[Network] ", err)
+		}
+
+		for rseq, r := range p.pending {
+			if now.Sub(r.lastSent) > udpResendTime {
+				if err := t.sendReliable(p, rseq, r); err != nil {
+					logrus.Warn("[Network] ", err)
+				}
+			}
+		}
+
+		if p.needAck || now.Sub(p.lastSend) > udpKeepAlive {
+			w := t.header(udpPacketKeepAlive)
+			t.writeAcks(w, p)
+			t.write(p, w)
+		}
+		p.needAck = false
+	}
+
+	events := t.events
+	t.events = nil
+
+	return events
+}
+
+// Close closes all connections and releases the transport.
+func (t *UDPTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+
+	for _, p := range t.peers {
+		t.write(p, t.header(udpPacketDisconnect))
+	}
+
+	close(t.closed)
+	err := t.conn.Close()
+	t.wg.Wait()
+
+	t.conn = nil
+	t.peers = make(map[PeerID]*udpPeer)
+	t.addrs = make(map[string]PeerID)
+
+	return err
+}
+
+func (t *UDPTransport) start(conn *net.UDPConn) {
+	t.conn = conn
+	t.recv = make(chan udpDatagram, udpRecvQueue)
+	t.closed = make(chan struct{})
+
+	t.wg.Add(1)
+	go t.read()
+}
+
+// read receives datagrams until the transport is closed. Datagrams are dropped
+// if the queue is full; reliable messages will be resent.
+func (t *UDPTransport) read() {
+	defer t.wg.Done()
+
+	buf := make([]byte, UDPMaxPayload+udpHeaderSize)
+
+	for {
+		n, addr, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-t.closed:
+				return
+			default:
+				continue
+			}
+		}
+
+		d := udpDatagram{
+			addr: addr,
+			data: append([]byte(nil), buf[:n]...),
+		}
+
+		select {
+		case t.recv <- d:
+		case <-t.closed:
+			return
+		default:
+		}
+	}
+}
+
+func (t *UDPTransport) process(d udpDatagram) {
+	r := NewNetReader(d.data)
+	if r.ReadUint16() != udpProtocolID {
+		return
+	}
+	kind := r.ReadUint8()
+	if r.Err() != nil {
+		return
+	}
+
+	id, known := t.addrs[d.addr.String()]
+	p := t.peers[id]
+
+	switch kind {
+	case udpPacketConnect:
+		if !t.server {
+			return
+		}
+
+		salt := r.ReadUint64()
+		if r.Err() != nil {
+			return
+		}
+
+		if !known {
+			if len(t.peers) >= t.maxPeers {
+				t.refuse(d.addr)
+				return
+			}
+
+			p = t.addPeer(d.addr)
+			p.salt = salt
+			t.connected(p)
+		}
+		if p.salt != salt {
+			return
+		}
+
+		p.lastRecv = time.Now()
+		t.sendAccept(p)
+
+	case udpPacketAccept:
+		if t.server || !known {
+			return
+		}
+
+		if r.ReadUint64() != p.salt || r.Err() != nil {
+			return
+		}
+
+		p.lastRecv = time.Now()
+		if !p.connected {
+			t.connected(p)
+		}
+
+	case udpPacketDisconnect:
+		if known {
+			t.removePeer(p)
+		}
+
+	case udpPacketKeepAlive, udpPacketData:
+		if !known || !p.connected {
+			return
+		}
+
+		seq := r.ReadUint16()
+		hasAck := r.ReadBool()
+		ack := r.ReadUint16()
+		ackBits := r.ReadUint32()
+		if r.Err() != nil {
+			return
+		}
+
+		p.lastRecv = time.Now()
+		p.receiveSeq(seq)
+		if hasAck {
+			p.receiveAcks(ack, ackBits)
+		}
+
+		if kind == udpPacketData {
+			p.needAck = true
+			t.processData(p, r)
+		}
+	}
+}
+
+func (t *UDPTransport) processData(p *udpPeer, r *NetReader) {
+	channel := NetChannel(r.ReadUint8())
+
+	if channel != NetChannelReliable {
+		data := r.next(r.Remaining())
+		if r.Err() == nil {
+			t.emit(NetEventData, p.id, channel, append([]byte(nil), data...))
+		}
+		return
+	}
+
+	rseq := r.ReadUint16()
+	more := r.ReadBool()
+	data := r.next(r.Remaining())
+	if r.Err() != nil {
+		return
+	}
+
+	switch {
+	case rseq == p.recvRSeq:
+		t.deliver(p, &udpReliable{data: append([]byte(nil), data...), more: more})
+		p.recvRSeq++
+
+		for {
+			b, ok := p.buffered[p.recvRSeq]
+			if !ok {
+				break
+			}
+
+			delete(p.buffered, p.recvRSeq)
+			t.deliver(p, b)
+			p.recvRSeq++
+		}
+	case seqGreater(rseq, p.recvRSeq) && rseq-p.recvRSeq < udpReliableWnd:
+		if _, dup := p.buffered[rseq]; !dup {
+			p.buffered[rseq] = &udpReliable{data: append([]byte(nil), data...), more: more}
+		}
+	}
+}
+
+// deliver emits the next reliable message in order, once all of its
+// fragments have arrived. A peer sending a message larger than
+// UDPMaxMessage is disconnected.
+func (t *UDPTransport) deliver(p *udpPeer, r *udpReliable) {
+	if !p.connected {
+		return
+	}
+
+	if !r.more && p.partial == nil {
+		t.emit(NetEventData, p.id, NetChannelReliable, r.data)
+		return
+	}
+
+	p.partial = append(p.partial, r.data...)
+	if len(p.partial) > UDPMaxMessage {
+		logrus.Warn("[Network] Peer ", p.id, " sent a message over ", UDPMaxMessage, " bytes")
+		t.Disconnect(p.id)
+		return
+	}
+
+	if !r.more {
+		t.emit(NetEventData, p.id, NetChannelReliable, p.partial)
+		p.partial = nil
+	}
+}
+
+// flush sends queued reliable messages while there is room in the window.
+func (t *UDPTransport) flush(p *udpPeer) error {
+	for len(p.queue) > 0 && p.sendRSeq-p.ackRSeq < udpReliableWnd {
+		r := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+
+		rseq := p.sendRSeq
+		p.sendRSeq++
+		p.pending[rseq] = r
+
+		if err := t.sendReliable(p, rseq, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *UDPTransport) sendConnect(p *udpPeer) {
+	w := t.header(udpPacketConnect)
+	w.WriteUint64(p.salt)
+	t.write(p, w)
+}
+
+func (t *UDPTransport) sendAccept(p *udpPeer) {
+	w := t.header(udpPacketAccept)
+	w.WriteUint64(p.salt)
+	t.write(p, w)
+}
+
+func (t *UDPTransport) sendReliable(p *udpPeer, rseq uint16, r *udpReliable) error {
+	r.lastSent = time.Now()
+	p.sent[p.seq] = rseq
+
+	return t.sendData(p, NetChannelReliable, rseq, r.more, r.data)
+}
+
+func (t *UDPTransport) sendData(p *udpPeer, channel NetChannel, rseq uint16, more bool, data []byte) error {
+	w := t.header(udpPacketData)
+	t.writeAcks(w, p)
+	w.WriteUint8(uint8(channel))
+	if channel == NetChannelReliable {
+		w.WriteUint16(rseq)
+		w.WriteBool(more)
+	}
+	w.buf = append(w.buf, data...)
+
+	return t.write(p, w)
+}
+
+// refuse tells an address which is not a peer that its connection attempt was
+// refused.
+func (t *UDPTransport) refuse(addr *net.UDPAddr) {
+	logrus.Debug("[Network] Refused connection from ", addr, ": peer limit reached")
+
+	t.conn.WriteToUDP(t.header(udpPacketDisconnect).Bytes(), addr)
+}
+
+func (t *UDPTransport) header(kind uint8) *NetWriter {
+	w := NewNetWriter()
+	w.WriteUint16(udpProtocolID)
+	w.WriteUint8(kind)
+
+	return w
+}
+
+func (t *UDPTransport) writeAcks(w *NetWriter, p *udpPeer) {
+	w.WriteUint16(p.seq)
+	w.WriteBool(p.hasRemote)
+	w.WriteUint16(p.remoteSeq)
+	w.WriteUint32(p.ackBits)
+
+	p.seq++
+}
+
+func (t *UDPTransport) write(p *udpPeer, w *NetWriter) error {
+	p.lastSend = time.Now()
+
+	_, err := t.conn.WriteToUDP(w.Bytes(), p.addr)
+
+	return err
+}
+
+func (t *UDPTransport) addPeer(addr *net.UDPAddr) *udpPeer {
+	p := &udpPeer{
+		id:       t.nextID,
+		addr:     addr,
+		started:  time.Now(),
+		lastRecv: time.Now(),
+		pending:  make(map[uint16]*udpReliable),
+		sent:     make(map[uint16]uint16),
+		buffered: make(map[uint16]*udpReliable),
+	}
+
+	t.nextID++
+	t.peers[p.id] = p
+	t.addrs[addr.String()] = p.id
+
+	return p
+}
+
+// removePeer forgets a peer. A peer which never connected was refused or
+// timed out, which is reported as a disconnect as well.
+func (t *UDPTransport) removePeer(p *udpPeer) {
+	delete(t.peers, p.id)
+	delete(t.addrs, p.addr.String())
+
+	p.connected = false
+	t.emit(NetEventDisconnect, p.id, 0, nil)
+}
+
+func (t *UDPTransport) connected(p *udpPeer) {
+	p.connected = true
+	t.emit(NetEventConnect, p.id, 0, nil)
+}
+
+func (t *UDPTransport) emit(kind NetEventType, peer PeerID, channel NetChannel, data []byte) {
+	t.events = append(t.events, NetEvent{
+		Type:    kind,
+		Peer:    peer,
+		Channel: channel,
+		Data:    data,
+	})
+}
+
+// receiveSeq records a received packet sequence number for acknowledgement.
+func (p *udpPeer) receiveSeq(seq uint16) {
+	if !p.hasRemote {
+		p.hasRemote = true
+		p.remoteSeq = seq
+		p.ackBits = 0
+		return
+	}
+
+	if seqGreater(seq, p.remoteSeq) {
+		shift := seq - p.remoteSeq
+		if shift < 32 {
+			p.ackBits = (p.ackBits << shift) | (1 << (shift - 1))
+		} else if shift == 32 {
+			p.ackBits = 1 << 31
+		} else {
+			p.ackBits = 0
+		}
+		p.remoteSeq = seq
+		return
+	}
+
+	if d := p.remoteSeq - seq; d >= 1 && d <= 32 {
+		p.ackBits |= 1 << (d - 1)
+	}
+}
+
+// receiveAcks marks the reliable messages carried by acknowledged packets as
+// delivered.
+func (p *udpPeer) receiveAcks(ack uint16, ackBits uint32) {
+	p.ack(ack)
+
+	for i := uint16(0); i < 32; i++ {
+		if ackBits&(1<<i) != 0 {
+			p.ack(ack - i - 1)
+		}
+	}
+}
+
+func (p *udpPeer) ack(seq uint16) {
+	rseq, ok := p.sent[seq]
+	if !ok {
+		return
+	}
+
+	delete(p.sent, seq)
+	delete(p.pending, rseq)
+
+	// Slide the window past the messages which have been delivered.
+	for p.ackRSeq != p.sendRSeq {
+		if _, ok := p.pending[p.ackRSeq]; ok {
+			break
+		}
+		p.ackRSeq++
+	}
+
+	// Forget older sends of messages which are no longer pending.
+	if len(p.sent) > udpReliableWnd {
+		for s, r := range p.sent {
+			if _, ok := p.pending[r]; !ok {
+				delete(p.sent, s)
+			}
+		}
+	}
+}
+
+// seqGreater reports if sequence number a is more recent than b, accounting
+// for wrap around.
+func seqGreater(a, b uint16) bool {
+	return (a > b && a-b <= 32768) || (a < b && b-a > 32768)
+}
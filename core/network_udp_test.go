@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// udpTestPoller polls transports and keeps the events they produce.
+type udpTestPoller struct {
+	transports []*UDPTransport
+	events     map[*UDPTransport][]NetEvent
+}
+
+func newUDPTestPoller(transports ...*UDPTransport) *udpTestPoller {
+	return &udpTestPoller{
+		transports: transports,
+		events:     make(map[*UDPTransport][]NetEvent),
+	}
+}
+
+func (p *udpTestPoller) until(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+
+		for _, tr := range p.transports {
+			p.events[tr] = append(p.events[tr], tr.Poll()...)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (p *udpTestPoller) count(tr *UDPTransport, kind NetEventType) int {
+	n := 0
+	for _, e := range p.events[tr] {
+		if e.Type == kind {
+			n++
+		}
+	}
+
+	return n
+}
+
+func setupTestUDP(t *testing.T) (*UDPTransport, *UDPTransport, *udpTestPoller) {
+	server := NewUDPTransport()
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewUDPTransport()
+	if err := client.Connect(server.LocalAddr().String()); err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	p := newUDPTestPoller(server, client)
+	p.until(t, func() bool {
+		return p.count(server, NetEventConnect) == 1 && p.count(client, NetEventConnect) == 1
+	})
+
+	return server, client, p
+}
+
+func TestUDPTransport_Started(t *testing.T) {
+	tr := NewUDPTransport()
+	if err := tr.Listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	if err := tr.Listen("127.0.0.1:0"); err != ErrNetStarted {
+		t.Errorf("Listen want: %v got: %v", ErrNetStarted, err)
+	}
+	if err := tr.Connect("127.0.0.1:1"); err != ErrNetStarted {
+		t.Errorf("Connect want: %v got: %v", ErrNetStarted, err)
+	}
+
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Listen("127.0.0.1:0"); err != nil {
+		t.Errorf("Listen after Close want: nil got: %v", err)
+	}
+}
+
+func TestUDPTransport_MaxPeers(t *testing.T) {
+	server, a, p := setupTestUDP(t)
+	defer server.Close()
+	defer a.Close()
+
+	server.SetMaxPeers(1)
+
+	b := NewUDPTransport()
+	if err := b.Connect(server.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	p.transports = append(p.transports, b)
+	p.until(t, func() bool { return p.count(b, NetEventDisconnect) == 1 })
+
+	if n := p.count(b, NetEventConnect); n != 0 {
+		t.Errorf("want: 0 connects got: %d", n)
+	}
+	if len(server.peers) != 1 {
+		t.Errorf("want: 1 peer got: %d", len(server.peers))
+	}
+}
+
+func TestUDPTransport_ReliableBurst(t *testing.T) {
+	server, client, p := setupTestUDP(t)
+	defer server.Close()
+	defer client.Close()
+
+	const n = udpReliableWnd + 100
+
+	for i := 0; i < n; i++ {
+		w := NewNetWriter()
+		w.WriteUint32(uint32(i))
+		if err := client.Send(1, NetChannelReliable, w.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p.until(t, func() bool { return p.count(server, NetEventData) == n })
+
+	i := uint32(0)
+	for _, e := range p.events[server] {
+		if e.Type != NetEventData {
+			continue
+		}
+		if got := NewNetReader(e.Data).ReadUint32(); got != i {
+			t.Fatalf("want: message %d got: %d", i, got)
+		}
+		i++
+	}
+}
+
+func TestUDPTransport_ReliableFragments(t *testing.T) {
+	server, client, p := setupTestUDP(t)
+	defer server.Close()
+	defer client.Close()
+
+	tests := [][]byte{
+		make([]byte, UDPMaxPayload),
+		make([]byte, UDPMaxPayload+1),
+		make([]byte, 10*UDPMaxPayload+7),
+		{42},
+	}
+	for i, v := range tests {
+		for j := range v {
+			v[j] = byte(i + j)
+		}
+		if err := client.Send(1, NetChannelReliable, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := client.Send(1, NetChannelReliable, make([]byte, UDPMaxMessage+1)); err != ErrNetPayloadSize {
+		t.Errorf("want: %v got: %v", ErrNetPayloadSize, err)
+	}
+	if err := client.Send(1, NetChannelUnreliable, make([]byte, UDPMaxPayload+1)); err != ErrNetPayloadSize {
+		t.Errorf("want: %v got: %v", ErrNetPayloadSize, err)
+	}
+
+	p.until(t, func() bool { return p.count(server, NetEventData) == len(tests) })
+
+	i := 0
+	for _, e := range p.events[server] {
+		if e.Type != NetEventData {
+			continue
+		}
+		if !bytes.Equal(tests[i], e.Data) {
+			t.Errorf("Fragments case %d failed. want: %d bytes got: %d", i, len(tests[i]), len(e.Data))
+		}
+		i++
+	}
+}
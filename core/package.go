@@ -24,25 +24,33 @@ package core
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/pkg/arcpak"
 )
 
 var (
-	pkgRe        = regexp.MustCompile(`^([\w\d-_]+):([\w\d-_/.\\]+)$`)
-	pkgExtension = ".pkg"
-	pkgRoot      = "assets"
+	pkgRe           = regexp.MustCompile(`^([\w\d-_]+):([\w\d-_/.\\]+)$`)
+	pkgExtension    = ".pkg"
+	bundleExtension = ".arcpak"
+	pkgRoot         = "assets"
 )
 
+// Package is a mounted archive of assets. Packages are either zip files or
+// arcpak bundles, which are preferred if both exist.
 type Package struct {
 	name   string
 	path   string
 	reader *zip.ReadCloser
+	bundle *arcpak.Reader
 }
 
 // ErrPackageNotFound reports that package was not found/mounted.
@@ -74,8 +82,12 @@ func NewPackage(name string) *Package {
 	}
 
 	pkgPath := filepath.Join(pkgRoot, p.name)
-	if !strings.HasSuffix(pkgPath, pkgExtension) {
-		pkgPath = fmt.Sprintf("%s%s", pkgPath, pkgExtension)
+	if !strings.HasSuffix(pkgPath, pkgExtension) && !strings.HasSuffix(pkgPath, bundleExtension) {
+		if _, err := os.Stat(pkgPath + bundleExtension); err == nil {
+			pkgPath += bundleExtension
+		} else {
+			pkgPath = fmt.Sprintf("%s%s", pkgPath, pkgExtension)
+		}
 	}
 
 	p.path = pkgPath
@@ -84,16 +96,25 @@ func NewPackage(name string) *Package {
 }
 
 func (p *Package) Mount() error {
-	if p.reader != nil {
+	if p.reader != nil || p.bundle != nil {
 		return ErrPackageMounted(p.name)
 	}
 
-	reader, err := zip.OpenReader(p.path)
-	if err != nil {
-		panic(err)
-	}
+	if strings.HasSuffix(p.path, bundleExtension) {
+		bundle, err := arcpak.Open(p.path)
+		if err != nil {
+			return err
+		}
+
+		p.bundle = bundle
+	} else {
+		reader, err := zip.OpenReader(p.path)
+		if err != nil {
+			return err
+		}
 
-	p.reader = reader
+		p.reader = reader
+	}
 
 	logrus.Info("Mounted package: ", p.name)
 
@@ -101,8 +122,14 @@ func (p *Package) Mount() error {
 }
 
 func (p *Package) Unmount() error {
-	err := p.reader.Close()
-	p.reader = nil
+	var err error
+	if p.bundle != nil {
+		err = p.bundle.Close()
+		p.bundle = nil
+	} else if p.reader != nil {
+		err = p.reader.Close()
+		p.reader = nil
+	}
 
 	logrus.Info("Unmounted package: ", p.name)
 
@@ -118,6 +145,20 @@ func (p *Package) Path() string {
 }
 
 func (p *Package) Read(filename string, w io.Writer) error {
+	if p.bundle != nil {
+		data, err := p.bundle.ReadFile(filename)
+		if _, ok := err.(arcpak.ErrNotFound); ok {
+			return ErrPackageFileNotFound{p.name, filename}
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(w, bytes.NewReader(data))
+
+		return err
+	}
+
 	if p.reader == nil {
 		return ErrPackageNotMounted(p.name)
 	}
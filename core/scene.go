@@ -44,9 +44,18 @@ type Scene interface {
 	// Display is called every frame for logic updates.
 	Update()
 
+	// LateUpdate is called every frame once every running scene has been
+	// updated, and before rendering, for logic which reads the results of
+	// Update, such as cameras following their targets.
+	LateUpdate()
+
 	// Load is called when the scene is being initialized.
 	Load() error
 
+	// Unload is called when the scene is no longer needed. The scene may be
+	// loaded again afterwards.
+	Unload()
+
 	// Loaded reports if the scene has been loaded.
 	Loaded() bool
 
@@ -60,9 +69,19 @@ const SysNameScene = "scene"
 
 var _ System = &SceneSystem{}
 
+// SceneSystem manages the scenes of the app. Scenes are pushed onto a stack,
+// of which only the top scene runs. Additive scenes run alongside it, in the
+// order they were loaded, and are kept across changes to the stack, so they
+// suit persistent managers and the chunks of large worlds.
 type SceneSystem struct {
-	scenes map[string]Scene
-	active []string
+	scenes   map[string]Scene
+	active   []string
+	additive []string
+	primary  string
+	loading  []*SceneLoad
+
+	willLoad    []func(string)
+	loadedFuncs []func(string)
 }
 
 // Setup sets up the System.
@@ -102,20 +121,118 @@ func (s *SceneSystem) Load(name string) error {
 		return fmt.Errorf("load scene: '%s' not registered", name)
 	}
 
-	if !s.scenes[name].Loaded() {
-		return s.scenes[name].Load()
+	if s.scenes[name].Loaded() {
+		return nil
+	}
+
+	s.cancelLoad(name)
+	s.sceneWillLoad(name)
+	if err := s.scenes[name].Load(); err != nil {
+		return err
 	}
+	s.sceneLoaded(name)
 
 	return nil
 }
 
+// LoadAdditive loads a scene and runs it alongside the scenes already
+// running. It stays loaded until it is unloaded with Unload.
+func (s *SceneSystem) LoadAdditive(name string) error {
+	if !s.Registered(name) {
+		return fmt.Errorf("load additive scene: '%s' not registered", name)
+	}
+	if s.Running(name) {
+		return fmt.Errorf("load additive scene: '%s' already running", name)
+	}
+
+	if err := s.Load(name); err != nil {
+		return err
+	}
+
+	s.additive = append(s.additive, name)
+	s.scenes[name].OnActivate()
+
+	return nil
+}
+
+// Unload stops a running scene and unloads it, releasing its objects. The
+// scene stays registered, and can be loaded again.
+func (s *SceneSystem) Unload(name string) error {
+	if !s.Registered(name) {
+		return fmt.Errorf("unload scene: '%s' not registered", name)
+	}
+
+	if i := indexOf(s.additive, name); i != -1 {
+		s.additive = append(s.additive[:i], s.additive[i+1:]...)
+		s.scenes[name].OnDeactivate()
+	} else if i := indexOf(s.active, name); i != -1 {
+		if i == len(s.active)-1 {
+			s.scenes[name].OnDeactivate()
+		}
+		s.active = append(s.active[:i], s.active[i+1:]...)
+	}
+
+	if s.primary == name {
+		s.primary = ""
+	}
+
+	s.cancelLoad(name)
+
+	s.scenes[name].Unload()
+	s.unloadUnused()
+
+	return nil
+}
+
+// SetActive makes a running scene the active scene, which is returned by
+// Active. An empty name makes the top of the stack the active scene again.
+func (s *SceneSystem) SetActive(name string) error {
+	if name != "" && !s.Running(name) {
+		return fmt.Errorf("set active scene: '%s' not running", name)
+	}
+
+	s.primary = name
+
+	return nil
+}
+
+// Running reports if a scene is running, either at the top of the stack or
+// loaded additively.
+func (s *SceneSystem) Running(name string) bool {
+	for _, v := range s.Scenes() {
+		if v.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scenes returns the running scenes: the top of the stack followed by the
+// additive scenes, in the order they were loaded.
+func (s *SceneSystem) Scenes() []Scene {
+	scenes := make([]Scene, 0, len(s.additive)+1)
+
+	if len(s.active) != 0 {
+		scenes = append(scenes, s.scenes[s.active[len(s.active)-1]])
+	}
+	for _, name := range s.additive {
+		scenes = append(scenes, s.scenes[name])
+	}
+
+	return scenes
+}
+
 func (s *SceneSystem) PurgePush(name string) error {
 	if !s.Registered(name) {
 		return fmt.Errorf("purge push scene: '%s' not registered", name)
 	}
 
 	s.active = s.active[:0]
-	s.Push(name)
+	if err := s.Push(name); err != nil {
+		return err
+	}
+	s.unloadUnused()
 
 	return nil
 }
@@ -126,15 +243,31 @@ func (s *SceneSystem) Replace(name string) error {
 	}
 
 	s.Pop()
-	s.Push(name)
+	if err := s.Push(name); err != nil {
+		return err
+	}
+	s.unloadUnused()
 
 	return nil
 }
 
+// unloadUnused frees the assets which were released by the scenes, once the
+// scene which replaced them had the chance to acquire them again.
+func (s *SceneSystem) unloadUnused() {
+	if a := GetAssetSystem(); a != nil {
+		if n := a.UnloadUnused(); n != 0 {
+			logrus.Debugf("Unloaded %d unused assets", n)
+		}
+	}
+}
+
 func (s *SceneSystem) Push(name string) error {
 	if !s.Registered(name) {
 		return fmt.Errorf("push: '%s' not registered", name)
 	}
+	if indexOf(s.additive, name) != -1 {
+		return fmt.Errorf("push: '%s' loaded additively", name)
+	}
 
 	if err := s.Load(name); err != nil {
 		return err
@@ -164,28 +297,47 @@ func (s *SceneSystem) RemoveAll() {
 		delete(s.scenes, key)
 	}
 	s.active = s.active[:0]
+	s.additive = s.additive[:0]
+	s.primary = ""
+
+	for len(s.loading) != 0 {
+		s.cancelLoad(s.loading[0].name)
+	}
 }
 
 func (s *SceneSystem) Unregister(name string) error {
 	if !s.Registered(name) {
 		return fmt.Errorf("unregister scene: '%s' not registered", name)
 	}
+	if s.Running(name) {
+		return fmt.Errorf("unregister scene: '%s' running", name)
+	}
 
 	delete(s.scenes, name)
 
 	return nil
 }
 
+// Get returns a registered scene, or nil if there is none by that name.
+func (s *SceneSystem) Get(name string) Scene {
+	return s.scenes[name]
+}
+
 func (s *SceneSystem) Registered(name string) bool {
 	_, ok := s.scenes[name]
 
 	return ok
 }
 
+// Active returns the active scene. This is the scene set with SetActive if it
+// is still running, or else the first of the running scenes.
 func (s *SceneSystem) Active() Scene {
-	if s.ActiveCount() != 0 {
-		name := s.active[len(s.active)-1]
-		return s.scenes[name]
+	if s.primary != "" && s.Running(s.primary) {
+		return s.scenes[s.primary]
+	}
+
+	if scenes := s.Scenes(); len(scenes) != 0 {
+		return scenes[0]
 	}
 
 	return nil
@@ -207,24 +359,47 @@ func (s *SceneSystem) ActiveCount() int {
 	return len(s.active)
 }
 
+// OnDisplay renders the running scenes in order. Cameras of additive scenes
+// should not clear the color buffer, or they hide the scenes before them.
 func (s *SceneSystem) OnDisplay() {
-	if sc := s.Active(); sc != nil {
+	for _, sc := range s.Scenes() {
 		sc.Display()
 	}
 }
 
 func (s *SceneSystem) OnUpdate() {
-	if sc := s.Active(); sc != nil {
+	s.updateLoading()
+
+	for _, sc := range s.Scenes() {
 		sc.Update()
 	}
 }
 
+// OnLateUpdate late updates the running scenes in order. It must be called
+// after OnUpdate and OnFixedUpdate, and before OnDisplay.
+func (s *SceneSystem) OnLateUpdate() {
+	for _, sc := range s.Scenes() {
+		sc.LateUpdate()
+	}
+}
+
 func (s *SceneSystem) OnFixedUpdate() {
-	if sc := s.Active(); sc != nil {
+	for _, sc := range s.Scenes() {
 		sc.FixedUpdate()
 	}
 }
 
+// indexOf returns the index of name in names, or -1 if it is not there.
+func indexOf(names []string, name string) int {
+	for i := range names {
+		if names[i] == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
 // NewSceneSystem creates a new scene system.
 func NewSceneSystem() *SceneSystem {
 	return &SceneSystem{
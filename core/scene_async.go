@@ -0,0 +1,181 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sceneLoadBudget is the time spent loading scenes per frame.
+const sceneLoadBudget = 4 * time.Millisecond
+
+// SceneStepLoader is implemented by scenes which load in steps, so that
+// loading can be spread over several frames. Other scenes are loaded in one
+// step.
+type SceneStepLoader interface {
+	Scene
+
+	// LoadStep runs the next step of loading the scene, and returns the
+	// progress of the load, from 0 to 1. The scene is loaded once the
+	// progress reaches 1.
+	LoadStep() (float64, error)
+}
+
+// SceneLoad tracks the progress of LoadAsync.
+type SceneLoad struct {
+	name     string
+	progress float64
+	done     chan struct{}
+	err      error
+}
+
+// Name returns the name of the scene being loaded.
+func (l *SceneLoad) Name() string {
+	return l.name
+}
+
+// Progress returns the fraction of the scene loaded, from 0 to 1.
+func (l *SceneLoad) Progress() float64 {
+	return l.progress
+}
+
+// Done returns a channel which is closed when loading has finished or
+// failed.
+func (l *SceneLoad) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns the error which stopped the load.
+func (l *SceneLoad) Err() error {
+	return l.err
+}
+
+func (l *SceneLoad) finish(err error) {
+	if err == nil {
+		l.progress = 1
+	}
+	l.err = err
+	close(l.done)
+}
+
+// OnSceneWillLoad adds a function which is called before a scene starts
+// loading, for example to show a loading screen.
+func (s *SceneSystem) OnSceneWillLoad(fn func(name string)) {
+	s.willLoad = append(s.willLoad, fn)
+}
+
+// OnSceneLoaded adds a function which is called once a scene has loaded,
+// for example to switch to it.
+func (s *SceneSystem) OnSceneLoaded(fn func(name string)) {
+	s.loadedFuncs = append(s.loadedFuncs, fn)
+}
+
+// LoadAsync loads a scene without blocking. The scene is loaded by Update
+// on the main thread, a step at a time within a budget per frame. The
+// scene is not run; switch to it with Push, Replace or LoadAdditive once
+// it has loaded.
+func (s *SceneSystem) LoadAsync(name string) (*SceneLoad, error) {
+	if !s.Registered(name) {
+		return nil, fmt.Errorf("load scene async: '%s' not registered", name)
+	}
+
+	for _, l := range s.loading {
+		if l.name == name {
+			return l, nil
+		}
+	}
+
+	l := &SceneLoad{
+		name: name,
+		done: make(chan struct{}),
+	}
+
+	if s.scenes[name].Loaded() {
+		l.finish(nil)
+		return l, nil
+	}
+
+	s.sceneWillLoad(name)
+	s.loading = append(s.loading, l)
+
+	return l, nil
+}
+
+// updateLoading steps the scenes being loaded asynchronously, in the order
+// their loads were started.
+func (s *SceneSystem) updateLoading() {
+	start := time.Now()
+
+	for len(s.loading) != 0 && time.Since(start) < sceneLoadBudget {
+		l := s.loading[0]
+		sc := s.scenes[l.name]
+
+		var err error
+		if sl, ok := sc.(SceneStepLoader); ok {
+			l.progress, err = sl.LoadStep()
+		} else {
+			err = sc.Load()
+		}
+
+		if err == nil && !sc.Loaded() {
+			continue
+		}
+
+		s.loading[0] = nil
+		s.loading = s.loading[1:]
+
+		if err != nil {
+			logrus.Errorf("load scene async: '%s': %v", l.name, err)
+			l.finish(err)
+			continue
+		}
+
+		l.finish(nil)
+		s.sceneLoaded(l.name)
+	}
+}
+
+// cancelLoad stops the asynchronous load of a scene, if there is one.
+func (s *SceneSystem) cancelLoad(name string) {
+	for i, l := range s.loading {
+		if l.name == name {
+			s.loading = append(s.loading[:i], s.loading[i+1:]...)
+			l.finish(fmt.Errorf("load scene async: '%s' unloaded", name))
+			return
+		}
+	}
+}
+
+func (s *SceneSystem) sceneWillLoad(name string) {
+	for _, fn := range s.willLoad {
+		fn(name)
+	}
+}
+
+func (s *SceneSystem) sceneLoaded(name string) {
+	for _, fn := range s.loadedFuncs {
+		fn(name)
+	}
+}
@@ -22,21 +22,62 @@ SOFTWARE.
 
 package core
 
-import "github.com/faiface/beep"
+import (
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+	"github.com/go-gl/mathgl/mgl32"
+)
 
 type Sound struct {
 	BaseObject
 
-	streamer beep.Streamer
+	buffer   *beep.Buffer
+	stream   beep.StreamSeekCloser
 	format   beep.Format
+	effects  []AudioEffect
+	voices   []*voice
+	bus      string
+	position mgl32.Vec3
 
-	loop bool
+	loop       bool
+	positional bool
 }
 
+// voice is a playing instance of a Sound. A voice applies the per-source
+// effects of a sound, along with occlusion and reverb zone processing. Its
+// effects are instances of the effects of the sound, in the same order.
+type voice struct {
+	sound      *Sound
+	streamer   beep.Streamer
+	effects    []AudioEffect
+	occlusion  *LowPassFilter
+	reverb     *Reverb
+	sampleRate beep.SampleRate
+	gain       float64
+	done       bool
+}
+
+// voiceState is the occlusion and reverb zone of a positional voice.
+type voiceState struct {
+	cutoff float64
+	zone   *ReverbZone
+}
+
+// NewSound creates a new sound from the streamer. The streamer is decoded
+// into memory so that the sound can be played any number of times.
 func NewSound(streamer beep.Streamer, format beep.Format) *Sound {
+	buffer := beep.NewBuffer(format)
+	buffer.Append(streamer)
+
+	return NewSoundBuffer(buffer)
+}
+
+// NewSoundBuffer creates a new sound playing the samples of a decoded buffer.
+func NewSoundBuffer(buffer *beep.Buffer) *Sound {
 	s := &Sound{
-		streamer: streamer,
-		format:   format,
+		buffer: buffer,
+		format: buffer.Format(),
+		bus:    AudioBusMaster,
 	}
 
 	s.SetName("Sound")
@@ -45,12 +86,79 @@ func NewSound(streamer beep.Streamer, format beep.Format) *Sound {
 	return s
 }
 
+// NewSoundStream creates a new sound which decodes the streamer as it plays,
+// rather than holding all of its samples in memory. This suits long sounds
+// such as music. Only one instance of a streamed sound can play at a time;
+// playing it again restarts it from the beginning. The streamer is closed
+// when the sound is deallocated.
+func NewSoundStream(streamer beep.StreamSeekCloser, format beep.Format) *Sound {
+	s := &Sound{
+		stream: streamer,
+		format: format,
+		bus:    AudioBusMaster,
+	}
+
+	s.SetName("Sound")
+	GetInstanceSystem().MustAssign(s)
+
+	return s
+}
+
+// Dealloc stops this sound and closes its stream, if any.
+func (s *Sound) Dealloc() {
+	s.Stop()
+
+	if s.stream != nil {
+		speaker.Lock()
+		s.stream.Close()
+		speaker.Unlock()
+	}
+}
+
 func (s *Sound) Play() {
 	GetAudioSystem().PlaySound(s)
 }
 
+// Stop stops all playing instances of this sound.
 func (s *Sound) Stop() {
+	speaker.Lock()
+	for i := range s.voices {
+		s.voices[i].done = true
+	}
+	speaker.Unlock()
+}
+
+// Playing reports if any instance of this sound is playing.
+func (s *Sound) Playing() bool {
+	speaker.Lock()
+	defer speaker.Unlock()
 
+	for i := range s.voices {
+		if !s.voices[i].done {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Format returns the format of this sound.
+func (s *Sound) Format() beep.Format {
+	return s.format
+}
+
+// Len returns the length of this sound in samples.
+func (s *Sound) Len() int {
+	if s.stream != nil {
+		return s.stream.Len()
+	}
+
+	return s.buffer.Len()
+}
+
+// Streamed reports if this sound is decoded as it plays.
+func (s *Sound) Streamed() bool {
+	return s.stream != nil
 }
 
 func (s *Sound) Loop() bool {
@@ -60,3 +168,165 @@ func (s *Sound) Loop() bool {
 func (s *Sound) SetLoop(loop bool) {
 	s.loop = loop
 }
+
+// Bus returns the name of the bus this sound is routed to.
+func (s *Sound) Bus() string {
+	return s.bus
+}
+
+// SetBus sets the name of the bus this sound is routed to. Changes take effect
+// the next time the sound is played.
+func (s *Sound) SetBus(bus string) {
+	s.bus = bus
+}
+
+// Effects returns the per-source effects of this sound.
+func (s *Sound) Effects() []AudioEffect {
+	return s.effects
+}
+
+// AddEffect appends a per-source effect to this sound. Each voice of the
+// sound gets its own instance of an AudioEffectInstancer.
+func (s *Sound) AddEffect(effect AudioEffect) {
+	if effect == nil {
+		return
+	}
+
+	speaker.Lock()
+	s.effects = append(s.effects, effect)
+	for _, v := range s.voices {
+		v.effects = append(v.effects, instanceEffect(effect))
+	}
+	speaker.Unlock()
+}
+
+// RemoveEffect removes a per-source effect from this sound.
+func (s *Sound) RemoveEffect(effect AudioEffect) {
+	speaker.Lock()
+	defer speaker.Unlock()
+
+	for i := range s.effects {
+		if s.effects[i] == effect {
+			s.effects = append(s.effects[:i], s.effects[i+1:]...)
+			for _, v := range s.voices {
+				v.effects = append(v.effects[:i], v.effects[i+1:]...)
+			}
+			return
+		}
+	}
+}
+
+// Positional reports if this sound has a position in the world. Only
+// positional sounds are affected by occlusion and reverb zones.
+func (s *Sound) Positional() bool {
+	return s.positional
+}
+
+// Position returns the world position of this sound.
+func (s *Sound) Position() mgl32.Vec3 {
+	return s.position
+}
+
+// SetPosition sets the world position of this sound and marks it positional.
+func (s *Sound) SetPosition(position mgl32.Vec3) {
+	s.position = position
+	s.positional = true
+}
+
+// ClearPosition marks this sound as non-positional.
+func (s *Sound) ClearPosition() {
+	s.positional = false
+}
+
+// streamer returns a new streamer over the samples of this sound. For a
+// streamed sound, the speaker must be locked by the caller.
+func (s *Sound) streamer() beep.Streamer {
+	var streamer beep.StreamSeeker
+	if s.stream != nil {
+		streamer = s.stream
+	} else {
+		streamer = s.buffer.Streamer(0, s.buffer.Len())
+	}
+
+	if s.loop {
+		return beep.Loop(-1, streamer)
+	}
+
+	return streamer
+}
+
+func newVoice(sound *Sound, sampleRate beep.SampleRate, gain float64) *voice {
+	effects := make([]AudioEffect, len(sound.effects))
+	for i := range sound.effects {
+		effects[i] = instanceEffect(sound.effects[i])
+	}
+
+	return &voice{
+		sound:      sound,
+		effects:    effects,
+		occlusion:  NewLowPassFilter(0),
+		reverb:     NewReverb(0, 0),
+		sampleRate: sampleRate,
+		gain:       gain,
+	}
+}
+
+// apply sets the occlusion and reverb of this voice. Once the voice is
+// playing, the speaker must be locked by the caller.
+func (v *voice) apply(state voiceState) {
+	v.occlusion.Cutoff = state.cutoff
+
+	v.reverb.Wet = 0
+	if z := state.zone; z != nil {
+		v.reverb.RoomSize = z.Reverb.RoomSize
+		v.reverb.Damping = z.Reverb.Damping
+		v.reverb.Wet = z.Reverb.Wet
+		v.reverb.Dry = z.Reverb.Dry
+	}
+}
+
+// removeVoice removes a finished voice from this sound. The speaker must be
+// locked by the caller.
+func (s *Sound) removeVoice(v *voice) {
+	for i := range s.voices {
+		if s.voices[i] == v {
+			s.voices = append(s.voices[:i], s.voices[i+1:]...)
+			return
+		}
+	}
+}
+
+func (v *voice) Stream(samples [][2]float64) (n int, ok bool) {
+	if v.done {
+		return 0, false
+	}
+
+	n, ok = v.streamer.Stream(samples)
+
+	if v.gain != 1 {
+		for i := range samples[:n] {
+			samples[i][0] *= v.gain
+			samples[i][1] *= v.gain
+		}
+	}
+
+	for i := range v.effects {
+		v.effects[i].Process(samples[:n], v.sampleRate)
+	}
+
+	v.occlusion.Process(samples[:n], v.sampleRate)
+
+	if v.reverb.Wet > 0 {
+		v.reverb.Process(samples[:n], v.sampleRate)
+	}
+
+	if !ok {
+		v.done = true
+	}
+
+	return n, ok
+}
+
+func (v *voice) Err() error {
+	return v.streamer.Err()
+}
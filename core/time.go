@@ -39,6 +39,7 @@ type TimeSystem struct {
 	frameTime     float64
 	deltaTime     float64
 	nextLogicTick float64
+	timeScale     float64
 	frame         uint64
 }
 
@@ -78,6 +79,26 @@ func (t *TimeSystem) Delta() float64 {
 	return t.deltaTime
 }
 
+// TimeScale returns the scale applied to ScaledDelta.
+func (t *TimeSystem) TimeScale() float64 {
+	return t.timeScale
+}
+
+// SetTimeScale sets the scale applied to ScaledDelta. A scale of zero pauses
+// anything driven by scaled time.
+func (t *TimeSystem) SetTimeScale(scale float64) {
+	if scale < 0 {
+		scale = 0
+	}
+
+	t.timeScale = scale
+}
+
+// ScaledDelta returns the delta time multiplied by the time scale.
+func (t *TimeSystem) ScaledDelta() float64 {
+	return t.deltaTime * t.timeScale
+}
+
 func (t *TimeSystem) Now() float64 {
 	return glfw.GetTime()
 }
@@ -105,7 +126,9 @@ func (t *TimeSystem) LogicUpdate() bool {
 
 // NewTime creates a new time system.
 func NewTimeSystem() *TimeSystem {
-	return &TimeSystem{}
+	return &TimeSystem{
+		timeScale: 1.0,
+	}
 }
 
 // GetTime gets the time system from the current app.
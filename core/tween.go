@@ -0,0 +1,478 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import "github.com/haakenlabs/arc/pkg/math"
+
+var _ System = &TweenSystem{}
+
+var tweenInst *TweenSystem
+
+const SysNameTween = "tween"
+
+var _ Tweener = &Tween{}
+var _ Tweener = &TweenSequence{}
+
+// EaseFunc maps linear progress in the range [0, 1] to eased progress.
+type EaseFunc func(float64) float64
+
+// Tweener is anything which can be advanced by the TweenSystem.
+type Tweener interface {
+	// Advance steps the tweener by dt seconds. It returns true once the
+	// tweener has finished.
+	Advance(dt float64) bool
+
+	// Unscaled reports if the tweener ignores the time scale.
+	Unscaled() bool
+
+	// Kill stops the tweener without completing it.
+	Kill()
+}
+
+// Tween interpolates a value over time. The value itself is written by the
+// update function, which is passed the eased progress of the tween.
+type Tween struct {
+	init       func()
+	update     func(float64)
+	ease       EaseFunc
+	onStart    func()
+	onUpdate   func()
+	onComplete func()
+
+	duration float64
+	delay    float64
+	waited   float64
+	elapsed  float64
+	loops    int
+	loop     int
+
+	yoyo     bool
+	reversed bool
+	unscaled bool
+	started  bool
+	paused   bool
+	killed   bool
+	done     bool
+}
+
+// TweenSequence plays a series of tweens one after another. Tweens joined to a
+// step of the sequence are played in parallel with that step.
+type TweenSequence struct {
+	steps      [][]*Tween
+	step       int
+	onComplete func()
+
+	unscaled bool
+	paused   bool
+	killed   bool
+	done     bool
+}
+
+// TweenSystem advances all active tweens once per frame.
+type TweenSystem struct {
+	tweens []Tweener
+}
+
+// NewTween creates a new tween with the given duration in seconds. The init
+// function, which may be nil, is called when the tween starts after any delay
+// and is typically used to capture the starting value. The update function is
+// called every step with the eased progress.
+func NewTween(duration float64, init func(), update func(float64)) *Tween {
+	return &Tween{
+		init:     init,
+		update:   update,
+		ease:     math.EaseNone,
+		duration: duration,
+	}
+}
+
+// SetEase sets the ease function of the tween.
+func (t *Tween) SetEase(ease EaseFunc) *Tween {
+	if ease == nil {
+		ease = math.EaseNone
+	}
+
+	t.ease = ease
+
+	return t
+}
+
+// SetDelay sets the time in seconds to wait before the tween starts.
+func (t *Tween) SetDelay(delay float64) *Tween {
+	t.delay = delay
+
+	return t
+}
+
+// SetLoops sets the number of times the tween is played. A value less than
+// zero loops forever. If yoyo is true, every other loop is played backwards.
+func (t *Tween) SetLoops(loops int, yoyo bool) *Tween {
+	t.loops = loops
+	t.yoyo = yoyo
+
+	return t
+}
+
+// SetUnscaled sets if the tween ignores the time scale.
+func (t *Tween) SetUnscaled(unscaled bool) *Tween {
+	t.unscaled = unscaled
+
+	return t
+}
+
+// OnStart sets a callback invoked when the tween starts.
+func (t *Tween) OnStart(fn func()) *Tween {
+	t.onStart = fn
+
+	return t
+}
+
+// OnUpdate sets a callback invoked after every step of the tween.
+func (t *Tween) OnUpdate(fn func()) *Tween {
+	t.onUpdate = fn
+
+	return t
+}
+
+// OnComplete sets a callback invoked when the tween finishes.
+func (t *Tween) OnComplete(fn func()) *Tween {
+	t.onComplete = fn
+
+	return t
+}
+
+// Play adds the tween to the tween system.
+func (t *Tween) Play() *Tween {
+	GetTweenSystem().Add(t)
+
+	return t
+}
+
+// Pause pauses the tween.
+func (t *Tween) Pause() {
+	t.paused = true
+}
+
+// Resume resumes a paused tween.
+func (t *Tween) Resume() {
+	t.paused = false
+}
+
+// Kill stops the tween without completing it.
+func (t *Tween) Kill() {
+	t.killed = true
+}
+
+// Done reports if the tween has finished or was killed.
+func (t *Tween) Done() bool {
+	return t.done || t.killed
+}
+
+// Unscaled reports if the tween ignores the time scale.
+func (t *Tween) Unscaled() bool {
+	return t.unscaled
+}
+
+// Duration returns the duration of a single loop of the tween.
+func (t *Tween) Duration() float64 {
+	return t.duration
+}
+
+// Advance steps the tween by dt seconds.
+func (t *Tween) Advance(dt float64) bool {
+	if t.Done() {
+		return true
+	}
+	if t.paused {
+		return false
+	}
+
+	if t.waited < t.delay {
+		t.waited += dt
+		if t.waited < t.delay {
+			return false
+		}
+
+		dt = t.waited - t.delay
+		t.waited = t.delay
+	}
+
+	if !t.started {
+		t.started = true
+
+		if t.init != nil {
+			t.init()
+		}
+		if t.onStart != nil {
+			t.onStart()
+		}
+	}
+
+	t.elapsed += dt
+
+	for {
+		progress := 1.0
+		if t.duration > 0 {
+			progress = math.Clamp(t.elapsed/t.duration, 0, 1)
+		}
+		if t.reversed {
+			progress = 1.0 - progress
+		}
+
+		t.update(t.ease(progress))
+
+		if t.onUpdate != nil {
+			t.onUpdate()
+		}
+
+		if t.duration > 0 && t.elapsed < t.duration {
+			return false
+		}
+
+		t.loop++
+		if t.loops >= 0 && t.loop >= t.loops {
+			break
+		}
+
+		if t.yoyo {
+			t.reversed = !t.reversed
+		}
+
+		if t.duration > 0 {
+			t.elapsed -= t.duration
+		} else {
+			t.elapsed = 0
+		}
+
+		// A tween without duration looping forever would never yield.
+		if t.duration <= 0 {
+			return false
+		}
+	}
+
+	t.done = true
+
+	if t.onComplete != nil {
+		t.onComplete()
+	}
+
+	return true
+}
+
+// NewTweenSequence creates a new, empty sequence.
+func NewTweenSequence() *TweenSequence {
+	return &TweenSequence{}
+}
+
+// Append adds a tween to the end of the sequence.
+func (s *TweenSequence) Append(t *Tween) *TweenSequence {
+	s.steps = append(s.steps, []*Tween{t})
+
+	return s
+}
+
+// Join adds a tween which plays in parallel with the last appended step.
+func (s *TweenSequence) Join(t *Tween) *TweenSequence {
+	if len(s.steps) == 0 {
+		return s.Append(t)
+	}
+
+	last := len(s.steps) - 1
+	s.steps[last] = append(s.steps[last], t)
+
+	return s
+}
+
+// AppendInterval adds a delay to the end of the sequence.
+func (s *TweenSequence) AppendInterval(interval float64) *TweenSequence {
+	return s.Append(NewTween(interval, nil, func(float64) {}))
+}
+
+// AppendCallback adds a callback to the end of the sequence.
+func (s *TweenSequence) AppendCallback(fn func()) *TweenSequence {
+	return s.Append(NewTween(0, nil, func(float64) {}).OnComplete(fn))
+}
+
+// SetUnscaled sets if the sequence ignores the time scale.
+func (s *TweenSequence) SetUnscaled(unscaled bool) *TweenSequence {
+	s.unscaled = unscaled
+
+	return s
+}
+
+// OnComplete sets a callback invoked when the sequence finishes.
+func (s *TweenSequence) OnComplete(fn func()) *TweenSequence {
+	s.onComplete = fn
+
+	return s
+}
+
+// Play adds the sequence to the tween system.
+func (s *TweenSequence) Play() *TweenSequence {
+	GetTweenSystem().Add(s)
+
+	return s
+}
+
+// Pause pauses the sequence.
+func (s *TweenSequence) Pause() {
+	s.paused = true
+}
+
+// Resume resumes a paused sequence.
+func (s *TweenSequence) Resume() {
+	s.paused = false
+}
+
+// Kill stops the sequence without completing it.
+func (s *TweenSequence) Kill() {
+	s.killed = true
+}
+
+// Done reports if the sequence has finished or was killed.
+func (s *TweenSequence) Done() bool {
+	return s.done || s.killed
+}
+
+// Unscaled reports if the sequence ignores the time scale.
+func (s *TweenSequence) Unscaled() bool {
+	return s.unscaled
+}
+
+// Advance steps the sequence by dt seconds.
+func (s *TweenSequence) Advance(dt float64) bool {
+	if s.Done() {
+		return true
+	}
+	if s.paused {
+		return false
+	}
+
+	for s.step < len(s.steps) {
+		finished := true
+
+		for _, t := range s.steps[s.step] {
+			if !t.Advance(dt) {
+				finished = false
+			}
+		}
+
+		if !finished {
+			return false
+		}
+
+		s.step++
+		dt = 0
+	}
+
+	s.done = true
+
+	if s.onComplete != nil {
+		s.onComplete()
+	}
+
+	return true
+}
+
+// Setup sets up the System.
+func (s *TweenSystem) Setup() error {
+	if tweenInst != nil {
+		return ErrSystemInit(SysNameTween)
+	}
+	tweenInst = s
+
+	return nil
+}
+
+// Teardown tears down the System.
+func (s *TweenSystem) Teardown() {
+	s.tweens = nil
+
+	tweenInst = nil
+}
+
+// Name returns the name of the System.
+func (s *TweenSystem) Name() string {
+	return SysNameTween
+}
+
+// Add adds a tweener to the system.
+func (s *TweenSystem) Add(t Tweener) {
+	if t == nil {
+		return
+	}
+
+	s.tweens = append(s.tweens, t)
+}
+
+// KillAll stops all active tweeners.
+func (s *TweenSystem) KillAll() {
+	for i := range s.tweens {
+		s.tweens[i].Kill()
+	}
+
+	s.tweens = nil
+}
+
+// Count returns the number of active tweeners.
+func (s *TweenSystem) Count() int {
+	return len(s.tweens)
+}
+
+// Update advances all active tweeners by the frame delta time.
+func (s *TweenSystem) Update() {
+	delta := GetTimeSystem().Delta()
+	scaled := GetTimeSystem().ScaledDelta()
+
+	// Tweeners added by callbacks during this update are kept, but not
+	// advanced until the next update.
+	n := len(s.tweens)
+	for i := 0; i < n && i < len(s.tweens); i++ {
+		dt := scaled
+		if s.tweens[i].Unscaled() {
+			dt = delta
+		}
+
+		if s.tweens[i].Advance(dt) {
+			s.tweens[i] = nil
+		}
+	}
+
+	active := s.tweens[:0]
+	for i := range s.tweens {
+		if s.tweens[i] != nil {
+			active = append(active, s.tweens[i])
+		}
+	}
+	s.tweens = active
+}
+
+// NewTweenSystem creates a new tween system.
+func NewTweenSystem() *TweenSystem {
+	return &TweenSystem{}
+}
+
+// GetTweenSystem gets the tween system from the current app.
+func GetTweenSystem() *TweenSystem {
+	return tweenInst
+}
@@ -0,0 +1,276 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/internal/builtin"
+)
+
+// ErrNotMounted reports that nothing is mounted at a prefix.
+type ErrNotMounted string
+
+func (e ErrNotMounted) Error() string {
+	return "asset: nothing mounted at: " + string(e)
+}
+
+// mount is a filesystem overlaid on the resources below a prefix.
+type mount struct {
+	prefix string
+	fsys   fs.FS
+}
+
+// Mount overlays a filesystem on the resources below prefix. The prefix is
+// the name of a package, "<builtin>" for the builtin assets, an optional
+// directory within either ("base:textures"), a directory of plain files, or
+// empty to overlay every plain file.
+//
+// Resources are looked up in the most recently mounted filesystem first, then
+// in older mounts, and finally in their package, the builtin assets or on
+// disk. Mounting a mod or DLC after the base content therefore overrides any
+// file the two have in common, while other files fall through to the base.
+func (a *AssetSystem) Mount(prefix string, fsys fs.FS) error {
+	if fsys == nil {
+		return errors.New("asset: mount of nil filesystem at: " + prefix)
+	}
+
+	m := &mount{
+		prefix: cleanMountPrefix(prefix),
+		fsys:   fsys,
+	}
+
+	a.mu.Lock()
+	a.mounts = append([]*mount{m}, a.mounts...)
+	a.mu.Unlock()
+
+	logrus.Info("Mounted filesystem at: ", prefix)
+
+	return nil
+}
+
+// Unmount removes all filesystems mounted at prefix. Filesystems which
+// implement io.Closer, such as those from OpenZipFS, are closed.
+func (a *AssetSystem) Unmount(prefix string) error {
+	prefix = cleanMountPrefix(prefix)
+
+	a.mu.Lock()
+	var removed []*mount
+	kept := a.mounts[:0]
+	for _, m := range a.mounts {
+		if m.prefix == prefix {
+			removed = append(removed, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+	a.mounts = kept
+	a.mu.Unlock()
+
+	if len(removed) == 0 {
+		return ErrNotMounted(prefix)
+	}
+
+	var err error
+	for _, m := range removed {
+		if c, ok := m.fsys.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+
+	logrus.Info("Unmounted filesystem at: ", prefix)
+
+	return err
+}
+
+// UnmountAll removes all mounted filesystems.
+func (a *AssetSystem) UnmountAll() {
+	a.mu.RLock()
+	prefixes := make([]string, 0, len(a.mounts))
+	seen := make(map[string]bool)
+	for _, m := range a.mounts {
+		if !seen[m.prefix] {
+			seen[m.prefix] = true
+			prefixes = append(prefixes, m.prefix)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, p := range prefixes {
+		if err := a.Unmount(p); err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+// covers reports if a filesystem is mounted over a package or "<builtin>".
+func (a *AssetSystem) covers(container string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, m := range a.mounts {
+		if m.prefix == container || strings.HasPrefix(m.prefix, container+":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readMounted reads a resource from the newest mount holding it. It reports
+// false if no mount holds the resource.
+func (a *AssetSystem) readMounted(r *Resource) (bool, error) {
+	a.mu.RLock()
+	if len(a.mounts) == 0 {
+		a.mu.RUnlock()
+		return false, nil
+	}
+	mounts := append([]*mount(nil), a.mounts...)
+	a.mu.RUnlock()
+
+	vp := r.virtualPath()
+
+	for _, m := range mounts {
+		name, ok := m.resolve(vp)
+		if !ok {
+			continue
+		}
+
+		data, err := fs.ReadFile(m.fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return true, err
+		}
+
+		_, err = r.buffer.Write(data)
+
+		return true, err
+	}
+
+	return false, nil
+}
+
+// resolve returns the name within the mounted filesystem of a virtual path.
+func (m *mount) resolve(vp string) (string, bool) {
+	var name string
+
+	switch {
+	case m.prefix == "":
+		if strings.Contains(vp, ":") {
+			return "", false
+		}
+		name = vp
+	case strings.HasPrefix(vp, m.prefix+":"):
+		name = vp[len(m.prefix)+1:]
+	case strings.HasPrefix(vp, m.prefix+"/"):
+		name = vp[len(m.prefix)+1:]
+	default:
+		return "", false
+	}
+
+	return name, fs.ValidPath(name)
+}
+
+// cleanMountPrefix normalizes a mount prefix so it compares equal to the
+// virtual paths of resources.
+func cleanMountPrefix(prefix string) string {
+	prefix = strings.Replace(prefix, "\\", "/", -1)
+	prefix = strings.TrimRight(prefix, "/:")
+
+	if prefix == "." {
+		return ""
+	}
+
+	return prefix
+}
+
+// virtualPath returns the path of the resource as matched against mounts:
+// "container:location" for package and builtin resources, or the cleaned
+// location for files.
+func (r *Resource) virtualPath() string {
+	if r.resType == ResourceBindata || r.resType == ResourcePackage {
+		return r.container + ":" + r.location
+	}
+
+	return path.Clean(strings.Replace(r.location, "\\", "/", -1))
+}
+
+// OpenZipFS opens a zip archive as a filesystem for Mount. The archive is
+// closed when it is unmounted.
+func OpenZipFS(name string) (fs.FS, error) {
+	return zip.OpenReader(name)
+}
+
+// BuiltinFS returns the builtin assets as a filesystem for Mount. Mounting it
+// under a package name lets the package fall back to the builtin assets.
+func BuiltinFS() fs.FS {
+	return builtinFS{}
+}
+
+// builtinFS is a filesystem of the assets built in to the binary.
+type builtinFS struct{}
+
+func (builtinFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := builtin.Asset(name)
+	if err != nil || data == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &builtinFile{name: path.Base(name), Reader: bytes.NewReader(data)}, nil
+}
+
+// builtinFile is an open builtin asset.
+type builtinFile struct {
+	*bytes.Reader
+	name string
+}
+
+func (f *builtinFile) Stat() (fs.FileInfo, error) {
+	return f, nil
+}
+
+func (f *builtinFile) Close() error {
+	return nil
+}
+
+func (f *builtinFile) Name() string       { return f.name }
+func (f *builtinFile) Mode() fs.FileMode  { return 0444 }
+func (f *builtinFile) ModTime() time.Time { return time.Time{} }
+func (f *builtinFile) IsDir() bool        { return false }
+func (f *builtinFile) Sys() interface{}   { return nil }
@@ -101,6 +101,7 @@ type WindowSystem struct {
 	windowResized     bool
 	shouldClose       bool
 	hasEvents         bool
+	headless          bool
 }
 
 func (w *WindowSystem) Setup() (err error) {
@@ -118,14 +119,26 @@ func (w *WindowSystem) Setup() (err error) {
 	logrus.Debug("[GLFW] Library initialized")
 
 	glfw.WindowHint(glfw.Resizable, glfw.True)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 3)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	setContextHints()
+	if viper.GetBool("graphics.debug") {
+		glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
+	}
 
 	w.displayMode = DisplayMode(viper.GetInt("graphics.mode"))
 	w.resolution = math.ToIVec2(viper.Get("graphics.resolution"))
 	w.vsync = viper.GetBool("graphics.vsync")
+	w.headless = viper.GetBool("graphics.headless")
+
+	// A headless window is never shown, so it has no monitor and does not
+	// wait for vertical sync. Rendering goes to offscreen framebuffers or the
+	// hidden default framebuffer, which can still be read back. GLFW needs a
+	// display server to create the context, on CI a virtual one such as Xvfb
+	// works.
+	if w.headless {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+		w.displayMode = DisplayModeWindow
+		w.vsync = false
+	}
 
 	resX := int(w.resolution.X())
 	resY := int(w.resolution.Y())
@@ -157,7 +170,7 @@ func (w *WindowSystem) Setup() (err error) {
 
 	w.resolution = math.IVec2{int32(resX), int32(resY)}
 
-	if w.window, err = glfw.CreateWindow(resX, resY, w.title, monitor, nil); err != nil {
+	if w.window, err = w.createWindow(resX, resY, monitor); err != nil {
 		return err
 	}
 
@@ -182,17 +195,57 @@ func (w *WindowSystem) Setup() (err error) {
 	return nil
 }
 
+// createWindow creates the window with the newest OpenGL context available,
+// limited by the graphics.gl_version setting.
+func (w *WindowSystem) createWindow(width, height int, monitor *glfw.Monitor) (*glfw.Window, error) {
+	var max [2]int
+	if v := viper.GetString("graphics.gl_version"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d.%d", &max[0], &max[1]); err != nil {
+			return nil, fmt.Errorf("window: invalid graphics.gl_version: %s", v)
+		}
+	}
+
+	var err error
+
+	for _, v := range contextVersions {
+		if max[0] != 0 && (v[0] > max[0] || (v[0] == max[0] && v[1] > max[1])) {
+			continue
+		}
+
+		glfw.WindowHint(glfw.ContextVersionMajor, v[0])
+		glfw.WindowHint(glfw.ContextVersionMinor, v[1])
+
+		var window *glfw.Window
+		if window, err = glfw.CreateWindow(width, height, w.title, monitor, nil); err == nil {
+			return window, nil
+		}
+
+		logrus.Debugf("[GLFW] OpenGL %d.%d context unavailable: %v", v[0], v[1], err)
+	}
+
+	if err == nil {
+		err = fmt.Errorf("window: no OpenGL version at or below %d.%d", max[0], max[1])
+	}
+
+	return nil, err
+}
+
 func (w *WindowSystem) setupGL() error {
 	w.window.MakeContextCurrent()
 
-	if err := gl.Init(); err != nil {
+	loader := &glLoader{lookup: glfw.GetProcAddress}
+	if err := gl.InitWithProcAddrFunc(loader.procAddress); err != nil {
+		return err
+	}
+	if err := loader.check(); err != nil {
 		return err
 	}
 
 	logrus.Debug("[OpenGL] Version: ", gl.GoStr(gl.GetString(gl.VERSION)))
+	logrus.Debugf("[OpenGL] %d functions unavailable", len(loader.missing))
 
 	gl.Enable(gl.DEPTH_TEST)
-	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+	setContextState()
 	gl.DepthFunc(gl.LEQUAL)
 	gl.ClearColor(0.0, 0.0, 0.0, 1.0)
 
@@ -229,6 +282,12 @@ func (w *WindowSystem) Vsync() bool {
 	return w.vsync
 }
 
+// Headless reports if the window is hidden, for rendering without a
+// visible display.
+func (w *WindowSystem) Headless() bool {
+	return w.headless
+}
+
 func (w *WindowSystem) CenterWindow() {
 	monitor := w.window.GetMonitor()
 	if monitor == nil {
@@ -277,6 +336,10 @@ func (w *WindowSystem) OrthoMatrix() mgl32.Mat4 {
 }
 
 func (w *WindowSystem) SetDisplayMode(mode DisplayMode) {
+	if w.headless {
+		return
+	}
+
 	var monitor *glfw.Monitor
 	var refresh int
 
@@ -0,0 +1,181 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package effect
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+var _ scene.Effect = &Bloom{}
+
+// Bloom makes bright parts of the image bleed into their surroundings. The
+// bright parts are extracted and blurred at half resolution, then added back
+// on top of the image.
+type Bloom struct {
+	shader      graphics.Shader
+	mesh        *graphics.Mesh
+	framebuffer graphics.Framebuffer
+	targets     [2]*graphics.AttachmentTexture2D
+	size        math.IVec2
+	threshold   float32
+	intensity   float32
+	iterations  int
+}
+
+// NewBloom creates a bloom effect.
+func NewBloom() *Bloom {
+	return &Bloom{
+		shader:     shader.NewShaderEffectBloom(),
+		threshold:  1.0,
+		intensity:  0.8,
+		iterations: 2,
+	}
+}
+
+// Type returns the type of the effect. Bloom runs before tonemapping, so it
+// can pick up HDR values above one.
+func (b *Bloom) Type() scene.EffectType {
+	return scene.EffectTypeHDR
+}
+
+// Threshold returns the brightness above which pixels bloom.
+func (b *Bloom) Threshold() float32 {
+	return b.threshold
+}
+
+// SetThreshold sets the brightness above which pixels bloom.
+func (b *Bloom) SetThreshold(threshold float32) {
+	if threshold >= 0 {
+		b.threshold = threshold
+	}
+}
+
+// Intensity returns the strength of the bloom added to the image.
+func (b *Bloom) Intensity() float32 {
+	return b.intensity
+}
+
+// SetIntensity sets the strength of the bloom added to the image.
+func (b *Bloom) SetIntensity(intensity float32) {
+	if intensity >= 0 {
+		b.intensity = intensity
+	}
+}
+
+// Iterations returns the number of blur passes.
+func (b *Bloom) Iterations() int {
+	return b.iterations
+}
+
+// SetIterations sets the number of blur passes. More passes give a wider
+// bloom.
+func (b *Bloom) SetIterations(iterations int) {
+	if iterations > 0 {
+		b.iterations = iterations
+	}
+}
+
+// Render renders the effect.
+func (b *Bloom) Render(w scene.EffectWriter) {
+	b.setup(w.EffectSize())
+
+	device := graphics.ActiveDevice()
+	texel := mgl32.Vec2{1 / float32(b.size.X()), 1 / float32(b.size.Y())}
+
+	b.framebuffer.Bind()
+	b.shader.Bind()
+	b.mesh.Bind()
+
+	// Pass 1 : Bright
+
+	b.shader.SetPass("pass_bright")
+	b.shader.SetUniform("f_threshold", b.threshold)
+	b.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+	device.BindTexture(0, w.EffectSource())
+	b.mesh.Draw()
+
+	// Pass 2 : Blur
+
+	b.shader.SetPass("pass_blur")
+	for i := 0; i < b.iterations; i++ {
+		b.shader.SetUniform("f_direction", mgl32.Vec2{texel.X(), 0})
+		b.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
+		device.BindTexture(0, b.targets[0].AttachmentObject())
+		b.mesh.Draw()
+
+		b.shader.SetUniform("f_direction", mgl32.Vec2{0, texel.Y()})
+		b.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+		device.BindTexture(0, b.targets[1].AttachmentObject())
+		b.mesh.Draw()
+	}
+
+	b.mesh.Unbind()
+	b.framebuffer.Unbind()
+
+	// Pass 3 : Composite
+
+	b.shader.SetPass("pass_composite")
+	b.shader.SetUniform("f_intensity", b.intensity)
+	device.BindTexture(2, b.targets[0].AttachmentObject())
+	w.EffectPass()
+
+	b.shader.Unbind()
+}
+
+// setup allocates the blur targets at half the size of the effect targets.
+func (b *Bloom) setup(size math.IVec2) {
+	half := math.IVec2{size.X() / 2, size.Y() / 2}
+	if half.X() < 1 {
+		half[0] = 1
+	}
+	if half.Y() < 1 {
+		half[1] = 1
+	}
+
+	if b.framebuffer != nil {
+		if half != b.size {
+			b.size = half
+			b.framebuffer.SetSize(half)
+		}
+		return
+	}
+
+	b.size = half
+	b.mesh = graphics.NewMeshQuad()
+	b.framebuffer = graphics.NewFramebuffer(half)
+
+	for i := range b.targets {
+		b.targets[i] = graphics.NewAttachmentTexture2D(half, graphics.TextureFormatDefaultHDRColor)
+	}
+
+	b.framebuffer.SetAttachment(graphics.AttachmentColor0, b.targets[0])
+	b.framebuffer.SetAttachment(graphics.AttachmentColor1, b.targets[1])
+
+	if err := b.framebuffer.Alloc(); err != nil {
+		panic(err)
+	}
+}
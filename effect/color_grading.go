@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package effect
+
+import (
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+var _ scene.Effect = &ColorGrading{}
+
+// ColorGrading remaps the colors of the final image through a 3D lookup
+// table. Tables are loaded by the texture handler from .cube files or strip
+// images named *.lut.png.
+type ColorGrading struct {
+	shader       graphics.Shader
+	lut          *graphics.Texture3D
+	contribution float32
+}
+
+// NewColorGrading creates a color grading effect using a lookup table.
+func NewColorGrading(lut *graphics.Texture3D) *ColorGrading {
+	return &ColorGrading{
+		shader:       shader.NewShaderEffectColorGrading(),
+		lut:          lut,
+		contribution: 1.0,
+	}
+}
+
+// Type returns the type of the effect. Grading is applied to the tonemapped
+// image.
+func (c *ColorGrading) Type() scene.EffectType {
+	return scene.EffectTypeLDR
+}
+
+// LUT returns the lookup table.
+func (c *ColorGrading) LUT() *graphics.Texture3D {
+	return c.lut
+}
+
+// SetLUT sets the lookup table. A nil table disables the effect.
+func (c *ColorGrading) SetLUT(lut *graphics.Texture3D) {
+	c.lut = lut
+}
+
+// Contribution returns how much of the graded image is blended in.
+func (c *ColorGrading) Contribution() float32 {
+	return c.contribution
+}
+
+// SetContribution sets how much of the graded image is blended in, from zero
+// for the original image to one for the fully graded image.
+func (c *ColorGrading) SetContribution(contribution float32) {
+	if contribution >= 0 && contribution <= 1 {
+		c.contribution = contribution
+	}
+}
+
+// Render renders the effect.
+func (c *ColorGrading) Render(w scene.EffectWriter) {
+	if c.lut == nil {
+		return
+	}
+
+	c.shader.Bind()
+	c.shader.SetPass("pass_0")
+	c.shader.SetUniform("f_lut_size", float32(c.lut.Layers()))
+	c.shader.SetUniform("f_contribution", c.contribution)
+
+	graphics.ActiveDevice().BindTexture(2, c.lut)
+	w.EffectPass()
+
+	c.shader.Unbind()
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package effect
+
+import (
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+var _ scene.Effect = &MotionBlur{}
+
+// MotionBlur blurs pixels along their motion since the last frame. It reads
+// the velocity written by the deferred geometry pass, so it has no effect on
+// cameras using the forward render path.
+type MotionBlur struct {
+	shader    graphics.Shader
+	samples   int32
+	scale     float32
+	maxLength float32
+}
+
+// NewMotionBlur creates a motion blur effect.
+func NewMotionBlur() *MotionBlur {
+	return &MotionBlur{
+		shader:    shader.NewShaderEffectMotionBlur(),
+		samples:   8,
+		scale:     1.0,
+		maxLength: 0.05,
+	}
+}
+
+// Type returns the type of the effect.
+func (m *MotionBlur) Type() scene.EffectType {
+	return scene.EffectTypeHDR
+}
+
+// Samples returns the number of samples taken along the motion of a pixel.
+func (m *MotionBlur) Samples() int32 {
+	return m.samples
+}
+
+// SetSamples sets the number of samples taken along the motion of a pixel.
+func (m *MotionBlur) SetSamples(samples int32) {
+	if samples > 0 {
+		m.samples = samples
+	}
+}
+
+// Scale returns the length of the blur relative to the motion of a frame.
+func (m *MotionBlur) Scale() float32 {
+	return m.scale
+}
+
+// SetScale sets the length of the blur relative to the motion of a frame,
+// like the shutter speed of a camera.
+func (m *MotionBlur) SetScale(scale float32) {
+	if scale >= 0 {
+		m.scale = scale
+	}
+}
+
+// MaxLength returns the longest blur in texture coordinates.
+func (m *MotionBlur) MaxLength() float32 {
+	return m.maxLength
+}
+
+// SetMaxLength sets the longest blur in texture coordinates.
+func (m *MotionBlur) SetMaxLength(length float32) {
+	if length > 0 {
+		m.maxLength = length
+	}
+}
+
+// Render renders the effect.
+func (m *MotionBlur) Render(w scene.EffectWriter) {
+	velocity := w.EffectTexture(scene.CameraTextureVelocity)
+	if velocity == nil {
+		return
+	}
+
+	m.shader.Bind()
+	m.shader.SetPass("pass_0")
+	m.shader.SetUniform("f_samples", m.samples)
+	m.shader.SetUniform("f_scale", m.scale)
+	m.shader.SetUniform("f_max_length", m.maxLength)
+
+	graphics.ActiveDevice().BindTexture(2, velocity)
+	w.EffectPass()
+
+	m.shader.Unbind()
+}
@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package effect
+
+import (
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+var _ scene.Effect = &ScreenSpaceReflections{}
+
+// SSRQuality is a preset for the ray march of screen space reflections.
+type SSRQuality int
+
+const (
+	SSRQualityLow SSRQuality = iota
+	SSRQualityMedium
+	SSRQualityHigh
+)
+
+// ScreenSpaceReflections reflects the lit image by marching reflected rays
+// through the depth of the GBuffer. It reads the position and material of
+// the deferred geometry pass, so it has no effect on cameras using the
+// forward render path.
+type ScreenSpaceReflections struct {
+	shader      graphics.Shader
+	steps       int32
+	refine      int32
+	maxDistance float32
+	thickness   float32
+	intensity   float32
+}
+
+// NewScreenSpaceReflections creates a screen space reflections effect with
+// medium quality.
+func NewScreenSpaceReflections() *ScreenSpaceReflections {
+	s := &ScreenSpaceReflections{
+		shader:    shader.NewShaderEffectSSR(),
+		thickness: 0.5,
+		intensity: 1.0,
+	}
+
+	s.SetQuality(SSRQualityMedium)
+
+	return s
+}
+
+// Type returns the type of the effect.
+func (s *ScreenSpaceReflections) Type() scene.EffectType {
+	return scene.EffectTypeHDR
+}
+
+// SetQuality sets the step count, refinement steps and max distance of the
+// ray march from a preset.
+func (s *ScreenSpaceReflections) SetQuality(quality SSRQuality) {
+	switch quality {
+	case SSRQualityLow:
+		s.steps = 16
+		s.refine = 3
+		s.maxDistance = 10
+	case SSRQualityHigh:
+		s.steps = 64
+		s.refine = 8
+		s.maxDistance = 50
+	default:
+		s.steps = 32
+		s.refine = 5
+		s.maxDistance = 25
+	}
+}
+
+// Steps returns the number of steps of the ray march.
+func (s *ScreenSpaceReflections) Steps() int32 {
+	return s.steps
+}
+
+// SetSteps sets the number of steps of the ray march.
+func (s *ScreenSpaceReflections) SetSteps(steps int32) {
+	if steps > 0 {
+		s.steps = steps
+	}
+}
+
+// Refine returns the number of binary search steps used to refine a hit.
+func (s *ScreenSpaceReflections) Refine() int32 {
+	return s.refine
+}
+
+// SetRefine sets the number of binary search steps used to refine a hit.
+func (s *ScreenSpaceReflections) SetRefine(refine int32) {
+	if refine >= 0 {
+		s.refine = refine
+	}
+}
+
+// MaxDistance returns the longest distance a ray travels in world units.
+func (s *ScreenSpaceReflections) MaxDistance() float32 {
+	return s.maxDistance
+}
+
+// SetMaxDistance sets the longest distance a ray travels in world units.
+func (s *ScreenSpaceReflections) SetMaxDistance(distance float32) {
+	if distance > 0 {
+		s.maxDistance = distance
+	}
+}
+
+// Thickness returns the depth of surfaces a ray can hit, in world units.
+func (s *ScreenSpaceReflections) Thickness() float32 {
+	return s.thickness
+}
+
+// SetThickness sets the depth of surfaces a ray can hit, in world units.
+func (s *ScreenSpaceReflections) SetThickness(thickness float32) {
+	if thickness > 0 {
+		s.thickness = thickness
+	}
+}
+
+// Intensity returns the strength of the reflections.
+func (s *ScreenSpaceReflections) Intensity() float32 {
+	return s.intensity
+}
+
+// SetIntensity sets the strength of the reflections.
+func (s *ScreenSpaceReflections) SetIntensity(intensity float32) {
+	if intensity >= 0 {
+		s.intensity = intensity
+	}
+}
+
+// Render renders the effect.
+func (s *ScreenSpaceReflections) Render(w scene.EffectWriter) {
+	position := w.EffectTexture(scene.CameraTexturePosition)
+	material := w.EffectTexture(scene.CameraTextureMaterial)
+	if position == nil || material == nil {
+		return
+	}
+
+	view := w.ViewMatrix()
+
+	s.shader.Bind()
+	s.shader.SetPass("pass_0")
+	s.shader.SetUniform("f_view", view)
+	s.shader.SetUniform("f_projection", w.ProjectionMatrix())
+	s.shader.SetUniform("f_camera", view.Inv().Col(3).Vec3())
+	s.shader.SetUniform("f_steps", s.steps)
+	s.shader.SetUniform("f_refine", s.refine)
+	s.shader.SetUniform("f_max_distance", s.maxDistance)
+	s.shader.SetUniform("f_thickness", s.thickness)
+	s.shader.SetUniform("f_intensity", s.intensity)
+
+	graphics.ActiveDevice().BindTexture(2, position)
+	graphics.ActiveDevice().BindTexture(3, material)
+	w.EffectPass()
+
+	s.shader.Unbind()
+}
@@ -0,0 +1,306 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package effect
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	fmath "github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset/shader"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ scene.Effect = &Tonemap{}
+
+// TonemapOperator is a curve which maps HDR colors to the displayable range.
+type TonemapOperator int32
+
+const (
+	TonemapReinhard TonemapOperator = iota
+	TonemapReinhardExtended
+	TonemapACES
+	TonemapUncharted2
+)
+
+// tonemapLuminanceSize is the size of the first level of the luminance chain.
+// Each following level is a quarter of the size, down to a single texel.
+const tonemapLuminanceSize = 256
+
+// tonemapLevel is a render target of the luminance chain.
+type tonemapLevel struct {
+	framebuffer graphics.Framebuffer
+	target      *graphics.AttachmentTexture2D
+}
+
+// Tonemap converts the HDR image of a camera to LDR. The exposure is either
+// set manually, or adapts to the average luminance of the image over time
+// like the human eye.
+type Tonemap struct {
+	shader          graphics.Shader
+	mesh            *graphics.Mesh
+	levels          []tonemapLevel
+	adapt           graphics.Framebuffer
+	adapted         [2]*graphics.AttachmentTexture2D
+	current         int
+	operator        TonemapOperator
+	exposure        float32
+	key             float32
+	minExposure     float32
+	maxExposure     float32
+	white           float32
+	adaptationSpeed float32
+	autoExposure    bool
+	adapting        bool
+}
+
+// NewTonemap creates a tonemap effect with the given operator.
+func NewTonemap(operator TonemapOperator) *Tonemap {
+	return &Tonemap{
+		shader:          shader.NewShaderEffectTonemapper(),
+		operator:        operator,
+		exposure:        1.0,
+		key:             0.18,
+		minExposure:     0.1,
+		maxExposure:     10.0,
+		white:           4.0,
+		adaptationSpeed: 1.5,
+	}
+}
+
+// Type returns the type of the effect.
+func (t *Tonemap) Type() scene.EffectType {
+	return scene.EffectTypeTonemapper
+}
+
+// Operator returns the tonemapping curve.
+func (t *Tonemap) Operator() TonemapOperator {
+	return t.operator
+}
+
+// SetOperator sets the tonemapping curve.
+func (t *Tonemap) SetOperator(operator TonemapOperator) {
+	t.operator = operator
+}
+
+// Exposure returns the manual exposure.
+func (t *Tonemap) Exposure() float32 {
+	return t.exposure
+}
+
+// SetExposure sets the manual exposure. It is used when auto exposure is
+// disabled.
+func (t *Tonemap) SetExposure(exposure float32) {
+	if exposure > 0 {
+		t.exposure = exposure
+	}
+}
+
+// AutoExposure reports if the exposure adapts to the image.
+func (t *Tonemap) AutoExposure() bool {
+	return t.autoExposure
+}
+
+// SetAutoExposure enables or disables eye adaptation. Enabling it snaps the
+// adapted luminance to the next frame.
+func (t *Tonemap) SetAutoExposure(enable bool) {
+	if enable && !t.autoExposure {
+		t.adapting = false
+	}
+
+	t.autoExposure = enable
+}
+
+// Key returns the luminance the average of the image is mapped to by auto
+// exposure.
+func (t *Tonemap) Key() float32 {
+	return t.key
+}
+
+// SetKey sets the luminance the average of the image is mapped to by auto
+// exposure. Higher values give a brighter image.
+func (t *Tonemap) SetKey(key float32) {
+	if key > 0 {
+		t.key = key
+	}
+}
+
+// ExposureRange returns the limits of auto exposure.
+func (t *Tonemap) ExposureRange() (float32, float32) {
+	return t.minExposure, t.maxExposure
+}
+
+// SetExposureRange sets the limits of auto exposure.
+func (t *Tonemap) SetExposureRange(min, max float32) {
+	if min > 0 && max >= min {
+		t.minExposure = min
+		t.maxExposure = max
+	}
+}
+
+// White returns the white point used by the extended Reinhard and Uncharted 2
+// operators.
+func (t *Tonemap) White() float32 {
+	return t.white
+}
+
+// SetWhite sets the white point used by the extended Reinhard and Uncharted 2
+// operators.
+func (t *Tonemap) SetWhite(white float32) {
+	if white > 0 {
+		t.white = white
+	}
+}
+
+// AdaptationSpeed returns how fast auto exposure follows changes in
+// brightness.
+func (t *Tonemap) AdaptationSpeed() float32 {
+	return t.adaptationSpeed
+}
+
+// SetAdaptationSpeed sets how fast auto exposure follows changes in
+// brightness.
+func (t *Tonemap) SetAdaptationSpeed(speed float32) {
+	if speed > 0 {
+		t.adaptationSpeed = speed
+	}
+}
+
+// Render renders the effect.
+func (t *Tonemap) Render(w scene.EffectWriter) {
+	t.setup()
+
+	t.shader.Bind()
+
+	if t.autoExposure {
+		t.renderLuminance(w.EffectSource())
+	}
+
+	t.shader.SetPass("pass_tonemap")
+	t.shader.SetUniform("f_operator", int32(t.operator))
+	t.shader.SetUniform("f_exposure", t.exposure)
+	t.shader.SetUniform("f_auto_exposure", t.autoExposure)
+	t.shader.SetUniform("f_key", t.key)
+	t.shader.SetUniform("f_min_exposure", t.minExposure)
+	t.shader.SetUniform("f_max_exposure", t.maxExposure)
+	t.shader.SetUniform("f_white", t.white)
+	graphics.ActiveDevice().BindTexture(2, t.adapted[t.current].AttachmentObject())
+	w.EffectPass()
+
+	t.shader.Unbind()
+}
+
+// renderLuminance reduces the log luminance of the source to a single texel,
+// and moves the adapted luminance towards it.
+func (t *Tonemap) renderLuminance(source graphics.Texture) {
+	device := graphics.ActiveDevice()
+
+	t.mesh.Bind()
+
+	t.shader.SetPass("pass_luminance")
+	device.BindTexture(0, source)
+
+	for i := range t.levels {
+		if i == 1 {
+			t.shader.SetPass("pass_downsample")
+		}
+		if i > 0 {
+			prev := t.levels[i-1].target.AttachmentObject()
+			t.shader.SetUniform("f_texel", mgl32.Vec2{1 / float32(prev.Width()), 1 / float32(prev.Height())})
+			device.BindTexture(0, prev)
+		}
+
+		t.levels[i].framebuffer.Bind()
+		t.mesh.Draw()
+		t.levels[i].framebuffer.Unbind()
+	}
+
+	// The first frame snaps to the luminance, later frames blend towards it.
+	adaptation := float32(1)
+	if t.adapting {
+		adaptation = 1 - float32(math.Exp(-time.Delta()*float64(t.adaptationSpeed)))
+	}
+	t.adapting = true
+
+	next := 1 - t.current
+
+	t.shader.SetPass("pass_adapt")
+	t.shader.SetUniform("f_adaptation", adaptation)
+	device.BindTexture(0, t.levels[len(t.levels)-1].target.AttachmentObject())
+	device.BindTexture(2, t.adapted[t.current].AttachmentObject())
+
+	t.adapt.Bind()
+	t.adapt.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0 + uint32(next)})
+	t.mesh.Draw()
+	t.adapt.Unbind()
+
+	t.mesh.Unbind()
+
+	t.current = next
+}
+
+// setup allocates the luminance chain.
+func (t *Tonemap) setup() {
+	if t.mesh != nil {
+		return
+	}
+
+	t.mesh = graphics.NewMeshQuad()
+
+	for size := int32(tonemapLuminanceSize); size >= 1; size /= 4 {
+		t.levels = append(t.levels, newTonemapLevel(fmath.IVec2{size, size}))
+	}
+
+	t.adapt = graphics.NewFramebuffer(fmath.IVec2{1, 1})
+	for i := range t.adapted {
+		t.adapted[i] = graphics.NewAttachmentTexture2D(fmath.IVec2{1, 1}, graphics.TextureFormatR32)
+		t.adapt.SetAttachment(graphics.AttachmentColor0+uint32(i), t.adapted[i])
+	}
+
+	if err := t.adapt.Alloc(); err != nil {
+		panic(err)
+	}
+
+	t.adapt.Bind()
+	t.adapt.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0, graphics.AttachmentColor1})
+	graphics.ActiveDevice().Clear(graphics.ClearColor)
+	t.adapt.Unbind()
+}
+
+func newTonemapLevel(size fmath.IVec2) tonemapLevel {
+	l := tonemapLevel{
+		framebuffer: graphics.NewFramebuffer(size),
+		target:      graphics.NewAttachmentTexture2D(size, graphics.TextureFormatR32),
+	}
+
+	l.framebuffer.SetAttachment(graphics.AttachmentColor0, l.target)
+	l.framebuffer.SetDrawBuffers([]uint32{graphics.AttachmentColor0})
+
+	if err := l.framebuffer.Alloc(); err != nil {
+		panic(err)
+	}
+
+	return l
+}
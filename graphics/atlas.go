@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package graphics
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// Sprite is a rectangular region of a texture atlas.
+type Sprite struct {
+	name    string
+	texture *Texture2D
+	rect    core.Rect
+	uv      core.Rect
+}
+
+// Name returns the name of the sprite.
+func (s *Sprite) Name() string {
+	return s.name
+}
+
+// Texture returns the atlas texture holding the sprite.
+func (s *Sprite) Texture() *Texture2D {
+	return s.texture
+}
+
+// Rect returns the region of the sprite in pixels, from the top left corner
+// of the texture.
+func (s *Sprite) Rect() core.Rect {
+	return s.rect
+}
+
+// Size returns the size of the sprite in pixels.
+func (s *Sprite) Size() mgl32.Vec2 {
+	return s.rect.Size()
+}
+
+// UV returns the region of the sprite in texture coordinates. Like the rect,
+// the origin is the top left corner of the image, which is the first row of
+// the uploaded texture data.
+func (s *Sprite) UV() core.Rect {
+	return s.uv
+}
+
+// TextureAtlas is a texture holding many images, which are addressed by name
+// as sprites. Drawing several images from one atlas avoids switching textures.
+type TextureAtlas struct {
+	core.BaseObject
+
+	texture *Texture2D
+	sprites map[string]*Sprite
+}
+
+// NewTextureAtlas creates an atlas over a texture.
+func NewTextureAtlas(texture *Texture2D) *TextureAtlas {
+	a := &TextureAtlas{
+		texture: texture,
+		sprites: make(map[string]*Sprite),
+	}
+
+	a.SetName("TextureAtlas")
+	instance.MustAssign(a)
+
+	return a
+}
+
+// Texture returns the texture of the atlas.
+func (a *TextureAtlas) Texture() *Texture2D {
+	return a.texture
+}
+
+// AddSprite adds a sprite covering a region of the texture in pixels, measured
+// from the top left corner. A sprite with the same name is replaced.
+func (a *TextureAtlas) AddSprite(name string, rect core.Rect) *Sprite {
+	size := a.texture.Size().Vec2()
+
+	s := &Sprite{
+		name:    name,
+		texture: a.texture,
+		rect:    rect,
+		uv: core.NewRect(
+			mgl32.Vec2{rect.Left() / size.X(), rect.Top() / size.Y()},
+			mgl32.Vec2{rect.Width() / size.X(), rect.Height() / size.Y()},
+		),
+	}
+
+	a.sprites[name] = s
+
+	return s
+}
+
+// Sprite returns the sprite with the given name, or nil if there is none.
+func (a *TextureAtlas) Sprite(name string) *Sprite {
+	return a.sprites[name]
+}
+
+// UV returns the texture coordinates of a sprite. It reports false if the
+// atlas has no sprite with the given name.
+func (a *TextureAtlas) UV(name string) (core.Rect, bool) {
+	s, ok := a.sprites[name]
+	if !ok {
+		return core.Rect{}, false
+	}
+
+	return s.uv, true
+}
+
+// Names returns the names of the sprites of the atlas in sorted order.
+func (a *TextureAtlas) Names() []string {
+	names := make([]string, 0, len(a.sprites))
+	for name := range a.sprites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Len returns the number of sprites of the atlas.
+func (a *TextureAtlas) Len() int {
+	return len(a.sprites)
+}
+
+// Owned returns the ID of the atlas texture, so it is released with the
+// atlas.
+func (a *TextureAtlas) Owned() []int32 {
+	if a.texture == nil {
+		return nil
+	}
+
+	return []int32{a.texture.ID()}
+}
@@ -42,12 +42,36 @@ type AttachmentTexture2D struct {
 	mipLevel   int32
 }
 
+type AttachmentTexture2DMultisample struct {
+	attachment *Texture2DMultisample
+}
+
+// AttachmentTextureLayer attaches a single layer of a texture array.
+type AttachmentTextureLayer struct {
+	attachment *Texture2DArray
+	layer      int32
+}
+
+// AttachmentTextureCubemap attaches a single face of a mip level of a
+// cubemap.
+type AttachmentTextureCubemap struct {
+	attachment *TextureCubemap
+	face       int32
+	mipLevel   int32
+}
+
 func NewAttachmentRenderBuffer(size math.IVec2, format TextureFormat) *AttachmentRenderbuffer {
 	rbuffer := NewRenderBuffer(size, format)
 
 	return NewAttachmentRenderBufferFrom(rbuffer)
 }
 
+// NewAttachmentRenderBufferMultisample creates an attachment backed by a
+// multisampled render buffer.
+func NewAttachmentRenderBufferMultisample(size math.IVec2, format TextureFormat, samples int32) *AttachmentRenderbuffer {
+	return NewAttachmentRenderBufferFrom(NewRenderBufferMultisample(size, format, samples))
+}
+
 func NewAttachmentRenderBufferFrom(buffer *RenderBuffer) *AttachmentRenderbuffer {
 	a := &AttachmentRenderbuffer{
 		attachment: buffer,
@@ -102,3 +126,96 @@ func (a *AttachmentTexture2D) SetMipLevel(mipLevel int32) {
 func (a *AttachmentTexture2D) AttachmentObject() *Texture2D {
 	return a.attachment
 }
+
+// NewAttachmentTexture2DMultisample creates an attachment backed by a
+// multisampled texture.
+func NewAttachmentTexture2DMultisample(size math.IVec2, format TextureFormat, samples int32) *AttachmentTexture2DMultisample {
+	t := NewTexture2DMultisample(size, samples, format)
+	t.Alloc()
+
+	return &AttachmentTexture2DMultisample{
+		attachment: t,
+	}
+}
+
+func (a *AttachmentTexture2DMultisample) Attach(location uint32) {
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, location, gl.TEXTURE_2D_MULTISAMPLE, a.attachment.Reference(), 0)
+}
+
+func (a *AttachmentTexture2DMultisample) SetSize(size math.IVec2) {
+	a.attachment.SetSize(size)
+}
+
+func (a *AttachmentTexture2DMultisample) AttachmentObject() *Texture2DMultisample {
+	return a.attachment
+}
+
+// NewAttachmentTextureLayer creates an attachment of a layer of a texture
+// array.
+func NewAttachmentTextureLayer(texture *Texture2DArray, layer int32) *AttachmentTextureLayer {
+	return &AttachmentTextureLayer{
+		attachment: texture,
+		layer:      layer,
+	}
+}
+
+func (a *AttachmentTextureLayer) Attach(location uint32) {
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, location, a.attachment.Reference(), 0, a.layer)
+}
+
+func (a *AttachmentTextureLayer) SetSize(size math.IVec2) {
+	a.attachment.SetSize(size)
+}
+
+func (a *AttachmentTextureLayer) Layer() int32 {
+	return a.layer
+}
+
+func (a *AttachmentTextureLayer) AttachmentObject() *Texture2DArray {
+	return a.attachment
+}
+
+// NewAttachmentTextureCubemap creates an attachment of a face of a cubemap.
+// Faces are numbered in the order +X, -X, +Y, -Y, +Z, -Z.
+func NewAttachmentTextureCubemap(texture *TextureCubemap, face int32) *AttachmentTextureCubemap {
+	return &AttachmentTextureCubemap{
+		attachment: texture,
+		face:       face,
+	}
+}
+
+func (a *AttachmentTextureCubemap) Attach(location uint32) {
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, location, gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(a.face), a.attachment.Reference(), a.mipLevel)
+}
+
+// SetSize resizes the cubemap if the base level is attached. Other mip levels
+// are smaller than the cubemap, so their size is left alone.
+func (a *AttachmentTextureCubemap) SetSize(size math.IVec2) {
+	if a.mipLevel == 0 && size != a.attachment.Size() {
+		a.attachment.SetSize(size)
+	}
+}
+
+func (a *AttachmentTextureCubemap) Face() int32 {
+	return a.face
+}
+
+// SetFace selects the attached face. The framebuffer must be reattached for
+// the change to take effect.
+func (a *AttachmentTextureCubemap) SetFace(face int32) {
+	a.face = face
+}
+
+func (a *AttachmentTextureCubemap) MipLevel() int32 {
+	return a.mipLevel
+}
+
+// SetMipLevel selects the attached mip level. The framebuffer must be
+// reattached for the change to take effect.
+func (a *AttachmentTextureCubemap) SetMipLevel(mipLevel int32) {
+	a.mipLevel = mipLevel
+}
+
+func (a *AttachmentTextureCubemap) AttachmentObject() *TextureCubemap {
+	return a.attachment
+}
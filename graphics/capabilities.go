@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/sirupsen/logrus"
+)
+
+// Capabilities describes what the GL context supports. Core versions imply
+// the extensions they absorbed, so older contexts are matched by either.
+// Extensions of ES contexts name their functions with a suffix the bindings
+// do not load, so ES features are only taken from the version.
+type Capabilities struct {
+	Major    int
+	Minor    int
+	ES       bool
+	Vendor   string
+	Renderer string
+
+	Extensions map[string]bool
+
+	Subroutines    bool
+	Tessellation   bool
+	ProgramBinary  bool
+	BufferStorage  bool
+	ImageStore     bool
+	Compute        bool
+	StorageBuffers bool
+	DebugOutput    bool
+	CopyImage      bool
+	TimerQuery     bool
+	BPTC           bool
+	S3TC           bool
+
+	MaxTextureUnits     int32
+	MaxColorAttachments int32
+	MaxSamples          int32
+	MaxUniformBlockSize int32
+}
+
+var capabilities *Capabilities
+
+// DetectCapabilities queries the capabilities of the current context. It
+// must be called once the context is created, before shaders are built.
+func DetectCapabilities() *Capabilities {
+	c := &Capabilities{
+		Vendor:     gl.GoStr(gl.GetString(gl.VENDOR)),
+		Renderer:   gl.GoStr(gl.GetString(gl.RENDERER)),
+		Extensions: make(map[string]bool),
+	}
+
+	var major, minor, count int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	c.Major, c.Minor = int(major), int(minor)
+	c.ES = strings.HasPrefix(gl.GoStr(gl.GetString(gl.VERSION)), "OpenGL ES")
+
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		c.Extensions[gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i)))] = true
+	}
+
+	if c.ES {
+		c.Tessellation = c.AtLeast(3, 2)
+		c.ProgramBinary = true
+		c.ImageStore = c.AtLeast(3, 1)
+		c.BPTC = c.Extensions["GL_EXT_texture_compression_bptc"]
+		c.Compute = c.AtLeast(3, 1)
+		c.StorageBuffers = c.AtLeast(3, 1)
+		c.DebugOutput = c.AtLeast(3, 2)
+		c.CopyImage = c.AtLeast(3, 2)
+	} else {
+		c.Subroutines = c.has(4, 0, "GL_ARB_shader_subroutine")
+		c.Tessellation = c.has(4, 0, "GL_ARB_tessellation_shader")
+		c.ProgramBinary = c.has(4, 1, "GL_ARB_get_program_binary")
+		c.ImageStore = c.has(4, 2, "GL_ARB_shader_image_load_store")
+		c.BPTC = c.has(4, 2, "GL_ARB_texture_compression_bptc")
+		c.Compute = c.has(4, 3, "GL_ARB_compute_shader")
+		c.StorageBuffers = c.has(4, 3, "GL_ARB_shader_storage_buffer_object")
+		c.DebugOutput = c.has(4, 3, "GL_KHR_debug")
+		c.CopyImage = c.has(4, 3, "GL_ARB_copy_image")
+		c.TimerQuery = c.has(3, 3, "GL_ARB_timer_query")
+		c.BufferStorage = c.has(4, 4, "GL_ARB_buffer_storage")
+	}
+	c.S3TC = c.Extensions["GL_EXT_texture_compression_s3tc"]
+
+	gl.GetIntegerv(gl.MAX_COMBINED_TEXTURE_IMAGE_UNITS, &c.MaxTextureUnits)
+	gl.GetIntegerv(gl.MAX_COLOR_ATTACHMENTS, &c.MaxColorAttachments)
+	gl.GetIntegerv(gl.MAX_SAMPLES, &c.MaxSamples)
+	gl.GetIntegerv(gl.MAX_UNIFORM_BLOCK_SIZE, &c.MaxUniformBlockSize)
+
+	capabilities = c
+
+	logrus.Debugf("[OpenGL] %s on %s (%s)", c.Version(), c.Renderer, c.Vendor)
+	if !c.ES && !c.AtLeast(4, 3) {
+		logrus.Warnf("[OpenGL] %s context, features which need OpenGL 4.3 are disabled", c.Version())
+	}
+
+	return c
+}
+
+// ActiveCapabilities returns the capabilities of the context, or nil if
+// they were not detected yet.
+func ActiveCapabilities() *Capabilities {
+	return capabilities
+}
+
+// Supported reports if a feature can be used with the active profile and
+// the capabilities of the context. Before the capabilities are detected, it
+// only checks the profile.
+func Supported(feature Feature) bool {
+	if !activeProfile.Supports(feature) {
+		return false
+	}
+	if capabilities == nil {
+		return true
+	}
+
+	return capabilities.Supports(feature)
+}
+
+// Version returns the context version, such as "OpenGL 4.3".
+func (c *Capabilities) Version() string {
+	if c.ES {
+		return fmt.Sprintf("OpenGL ES %d.%d", c.Major, c.Minor)
+	}
+
+	return fmt.Sprintf("OpenGL %d.%d", c.Major, c.Minor)
+}
+
+// AtLeast reports if the context version is at least major.minor.
+func (c *Capabilities) AtLeast(major, minor int) bool {
+	return c.Major > major || (c.Major == major && c.Minor >= minor)
+}
+
+// Supports reports if the context supports a feature.
+func (c *Capabilities) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureSubroutines:
+		return c.Subroutines
+	case FeatureTessellation:
+		return c.Tessellation
+	case FeatureCompute:
+		return c.Compute
+	case FeatureStorageBuffers:
+		return c.StorageBuffers
+	case FeatureImageStore:
+		return c.ImageStore
+	case FeatureDeferred:
+		return c.MaxColorAttachments >= 4
+	}
+
+	return true
+}
+
+// shaderHeader returns the GLSL version header matching the context. The
+// shaders bind uniforms and samplers with layout qualifiers, which older
+// versions get through GL_ARB_shading_language_420pack.
+func (c *Capabilities) shaderHeader() string {
+	if c.AtLeast(4, 3) {
+		return "#version 430\n"
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "#version %d%d0 core\n", c.Major, c.Minor)
+	if !c.AtLeast(4, 2) {
+		b.WriteString("#extension GL_ARB_shading_language_420pack : enable\n")
+	}
+	if !c.AtLeast(4, 0) {
+		b.WriteString("#extension GL_ARB_gpu_shader5 : enable\n")
+	}
+
+	return b.String()
+}
+
+func (c *Capabilities) has(major, minor int, extension string) bool {
+	return c.AtLeast(major, minor) || c.Extensions[extension]
+}
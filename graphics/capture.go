@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/system/input"
+)
+
+// CaptureTool is an in-application API of a frame capture tool, such as
+// RenderDoc.
+type CaptureTool interface {
+	// Name returns the name of the tool.
+	Name() string
+
+	// StartFrameCapture starts capturing GPU commands.
+	StartFrameCapture()
+
+	// EndFrameCapture ends a capture started by StartFrameCapture. It
+	// reports if the capture was saved.
+	EndFrameCapture() bool
+
+	// SetCapturePath sets the path template captures are written to.
+	SetCapturePath(template string)
+
+	// NumCaptures returns the number of captures made.
+	NumCaptures() int
+}
+
+var (
+	captureTool    CaptureTool
+	captureKey     = glfw.KeyUnknown
+	capturePending int
+	capturing      bool
+	frameNumber    uint64
+)
+
+// InitCapture detects capture tools attached to the process. It must be
+// called after the graphics context has been created.
+func InitCapture() {
+	if captureTool = loadRenderDoc(); captureTool != nil {
+		logrus.Info("[Capture] ", captureTool.Name(), " detected")
+	}
+}
+
+// SetCaptureTool sets the capture tool used by Capture.
+func SetCaptureTool(tool CaptureTool) {
+	captureTool = tool
+}
+
+// CaptureAvailable reports if a capture tool is attached.
+func CaptureAvailable() bool {
+	return captureTool != nil
+}
+
+// Capture requests the next frame to be captured.
+func Capture() {
+	CaptureFrames(1)
+}
+
+// CaptureFrames requests the next n frames to be captured, each as its own
+// capture.
+func CaptureFrames(n int) {
+	if captureTool == nil {
+		logrus.Warn("[Capture] No capture tool attached")
+		return
+	}
+
+	capturePending += n
+}
+
+// SetCaptureKey sets a key which captures the next frame when released.
+// RenderDoc's own capture keys keep working regardless.
+func SetCaptureKey(key glfw.Key) {
+	captureKey = key
+}
+
+// SetCapturePath sets the path template captures are written to.
+func SetCapturePath(template string) {
+	if captureTool != nil {
+		captureTool.SetCapturePath(template)
+	}
+}
+
+// BeginFrame marks the start of the rendering of a frame. It starts pending
+// captures and opens a debug group for the frame.
+func BeginFrame() {
+	frameNumber++
+	currentState.ResetStats()
+
+	if captureKey != glfw.KeyUnknown && input.KeyUp(captureKey) {
+		Capture()
+	}
+
+	if capturePending > 0 && captureTool != nil {
+		capturePending--
+		capturing = true
+		captureTool.StartFrameCapture()
+	}
+
+	activeDevice.PushDebugGroup("Frame")
+}
+
+// EndFrame marks the end of the rendering of a frame. It must be called before
+// the buffers are swapped.
+func EndFrame() {
+	activeDevice.PopDebugGroup()
+
+	if capturing {
+		capturing = false
+		if captureTool.EndFrameCapture() {
+			logrus.Info("[Capture] Captured frame ", frameNumber)
+		} else {
+			logrus.Warn("[Capture] Capture of frame ", frameNumber, " failed")
+		}
+	}
+}
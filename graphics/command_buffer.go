@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/haakenlabs/arc/core"
+)
+
+// CommandType is the type of a recorded Command.
+type CommandType uint8
+
+const (
+	CommandSetPipelineState CommandType = iota
+	CommandSetViewport
+	CommandClear
+	CommandBindTexture
+	CommandDraw
+	CommandDrawIndexed
+	CommandBindShader
+	CommandSetUniform
+	CommandBindMesh
+	CommandDrawMesh
+	CommandCallback
+)
+
+// Command is a command recorded into a CommandBuffer. Only the fields
+// relevant to its type are set.
+type Command struct {
+	Type CommandType
+
+	Pipeline   PipelineState
+	Viewport   [4]int32
+	Clear      ClearFlags
+	ClearColor core.Color
+	Unit       uint32
+	Texture    Texture
+	Primitive  Primitive
+	First      int32
+	Count      int32
+	Shader     Shader
+	Pass       string
+	Uniform    string
+	Value      interface{}
+	Mesh       *Mesh
+	Callback   func()
+}
+
+// CommandBuffer records rendering commands to be executed later by a Device.
+// Backends which submit work explicitly translate the recorded commands,
+// while immediate backends execute them in order.
+type CommandBuffer struct {
+	commands []Command
+}
+
+func NewCommandBuffer() *CommandBuffer {
+	return &CommandBuffer{}
+}
+
+// Commands returns the recorded commands.
+func (c *CommandBuffer) Commands() []Command {
+	return c.commands
+}
+
+// Len returns the number of recorded commands.
+func (c *CommandBuffer) Len() int {
+	return len(c.commands)
+}
+
+// Reset discards all recorded commands.
+func (c *CommandBuffer) Reset() {
+	c.commands = c.commands[:0]
+}
+
+func (c *CommandBuffer) SetPipelineState(state PipelineState) {
+	c.commands = append(c.commands, Command{Type: CommandSetPipelineState, Pipeline: state})
+}
+
+func (c *CommandBuffer) SetViewport(x, y, width, height int32) {
+	c.commands = append(c.commands, Command{Type: CommandSetViewport, Viewport: [4]int32{x, y, width, height}})
+}
+
+func (c *CommandBuffer) Clear(flags ClearFlags, color core.Color) {
+	c.commands = append(c.commands, Command{Type: CommandClear, Clear: flags, ClearColor: color})
+}
+
+func (c *CommandBuffer) BindTexture(unit uint32, texture Texture) {
+	c.commands = append(c.commands, Command{Type: CommandBindTexture, Unit: unit, Texture: texture})
+}
+
+func (c *CommandBuffer) Draw(primitive Primitive, first, count int32) {
+	c.commands = append(c.commands, Command{Type: CommandDraw, Primitive: primitive, First: first, Count: count})
+}
+
+func (c *CommandBuffer) DrawIndexed(primitive Primitive, count int32) {
+	c.commands = append(c.commands, Command{Type: CommandDrawIndexed, Primitive: primitive, Count: count})
+}
+
+// BindShader binds a shader and selects the pass function of its fragment
+// shader. An empty pass keeps the current selection.
+func (c *CommandBuffer) BindShader(shader Shader, pass string) {
+	c.commands = append(c.commands, Command{Type: CommandBindShader, Shader: shader, Pass: pass})
+}
+
+// SetUniform sets a uniform of a shader. The shader must be bound by an
+// earlier command.
+func (c *CommandBuffer) SetUniform(shader Shader, name string, value interface{}) {
+	c.commands = append(c.commands, Command{Type: CommandSetUniform, Shader: shader, Uniform: name, Value: value})
+}
+
+// BindMesh binds the vertex and index buffers of a mesh.
+func (c *CommandBuffer) BindMesh(mesh *Mesh) {
+	c.commands = append(c.commands, Command{Type: CommandBindMesh, Mesh: mesh})
+}
+
+// DrawMesh draws a mesh bound by an earlier command.
+func (c *CommandBuffer) DrawMesh(mesh *Mesh) {
+	c.commands = append(c.commands, Command{Type: CommandDrawMesh, Mesh: mesh})
+}
+
+// Callback records a function to be called when the command buffer is
+// executed, such as binding a shader or mesh.
+func (c *CommandBuffer) Callback(fn func()) {
+	c.commands = append(c.commands, Command{Type: CommandCallback, Callback: fn})
+}
@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	debugOutput bool
+	checkErrors bool
+)
+
+// EnableDebugOutput routes debug messages of the GL context to the log.
+// Messages are only reported in full by contexts created with the debug
+// flag. If errors is set, glGetError is also checked after draw calls and
+// resource uploads, so the failing operation shows up in the log.
+func EnableDebugOutput(errors bool) {
+	checkErrors = errors
+
+	if capabilities != nil && !capabilities.DebugOutput {
+		logrus.Warnf("[OpenGL] Debug output is not supported by %s", capabilities.Version())
+		return
+	}
+
+	var flags int32
+	gl.GetIntegerv(gl.CONTEXT_FLAGS, &flags)
+	if flags&gl.CONTEXT_FLAG_DEBUG_BIT == 0 {
+		logrus.Warn("[OpenGL] Context is not a debug context, debug output may be incomplete")
+	}
+
+	gl.Enable(gl.DEBUG_OUTPUT)
+	gl.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS)
+	gl.DebugMessageCallback(debugCallback, nil)
+
+	// Notifications include the debug groups of every pass, which would
+	// drown out everything else.
+	gl.DebugMessageControl(gl.DONT_CARE, gl.DONT_CARE, gl.DEBUG_SEVERITY_NOTIFICATION, 0, nil, false)
+
+	debugOutput = true
+
+	logrus.Debug("[OpenGL] Debug output enabled")
+}
+
+// debugLabels reports if the context supports debug groups and object labels.
+func debugLabels() bool {
+	return capabilities != nil && capabilities.DebugOutput
+}
+
+// objectLabel names a GL object, so frame capture tools show it by name.
+// Identifier is the namespace of the object, such as gl.TEXTURE. The object
+// must have been bound at least once.
+func objectLabel(identifier, reference uint32, label string) {
+	if reference == 0 || label == "" || !debugLabels() {
+		return
+	}
+
+	gl.ObjectLabel(identifier, reference, int32(len(label)), gl.Str(label+"\x00"))
+}
+
+// DebugOutput reports if debug output is enabled.
+func DebugOutput() bool {
+	return debugOutput
+}
+
+// checkError logs the errors raised by GL since the last check. It does
+// nothing unless error checks are enabled.
+func checkError(op string) {
+	if !checkErrors {
+		return
+	}
+
+	for err := gl.GetError(); err != gl.NO_ERROR; err = gl.GetError() {
+		logrus.Errorf("[OpenGL] %s: %s", op, glErrorString(err))
+	}
+}
+
+func debugCallback(source, xtype, id, severity uint32, length int32, message string, userParam unsafe.Pointer) {
+	entry := logrus.WithFields(logrus.Fields{
+		"source": debugSourceString(source),
+		"type":   debugTypeString(xtype),
+		"id":     id,
+	})
+
+	switch severity {
+	case gl.DEBUG_SEVERITY_HIGH:
+		entry.Error("[OpenGL] ", message)
+	case gl.DEBUG_SEVERITY_MEDIUM:
+		entry.Warn("[OpenGL] ", message)
+	case gl.DEBUG_SEVERITY_LOW:
+		entry.Info("[OpenGL] ", message)
+	default:
+		entry.Debug("[OpenGL] ", message)
+	}
+}
+
+func glErrorString(err uint32) string {
+	switch err {
+	case gl.INVALID_ENUM:
+		return "invalid enum"
+	case gl.INVALID_VALUE:
+		return "invalid value"
+	case gl.INVALID_OPERATION:
+		return "invalid operation"
+	case gl.INVALID_FRAMEBUFFER_OPERATION:
+		return "invalid framebuffer operation"
+	case gl.OUT_OF_MEMORY:
+		return "out of memory"
+	case gl.STACK_UNDERFLOW:
+		return "stack underflow"
+	case gl.STACK_OVERFLOW:
+		return "stack overflow"
+	}
+
+	return fmt.Sprintf("error 0x%x", err)
+}
+
+func debugSourceString(source uint32) string {
+	switch source {
+	case gl.DEBUG_SOURCE_API:
+		return "api"
+	case gl.DEBUG_SOURCE_WINDOW_SYSTEM:
+		return "window system"
+	case gl.DEBUG_SOURCE_SHADER_COMPILER:
+		return "shader compiler"
+	case gl.DEBUG_SOURCE_THIRD_PARTY:
+		return "third party"
+	case gl.DEBUG_SOURCE_APPLICATION:
+		return "application"
+	}
+
+	return "other"
+}
+
+func debugTypeString(xtype uint32) string {
+	switch xtype {
+	case gl.DEBUG_TYPE_ERROR:
+		return "error"
+	case gl.DEBUG_TYPE_DEPRECATED_BEHAVIOR:
+		return "deprecated"
+	case gl.DEBUG_TYPE_UNDEFINED_BEHAVIOR:
+		return "undefined behavior"
+	case gl.DEBUG_TYPE_PORTABILITY:
+		return "portability"
+	case gl.DEBUG_TYPE_PERFORMANCE:
+		return "performance"
+	case gl.DEBUG_TYPE_MARKER:
+		return "marker"
+	}
+
+	return "other"
+}
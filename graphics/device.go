@@ -0,0 +1,313 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+// CompareFunc is a depth comparison function.
+type CompareFunc uint8
+
+const (
+	CompareLess CompareFunc = iota
+	CompareLessEqual
+	CompareEqual
+	CompareGreater
+	CompareGreaterEqual
+	CompareNotEqual
+	CompareAlways
+	CompareNever
+)
+
+// CullMode selects which faces are culled.
+type CullMode uint8
+
+const (
+	CullNone CullMode = iota
+	CullBack
+	CullFront
+)
+
+// BlendMode selects how fragments are blended with the framebuffer.
+type BlendMode uint8
+
+const (
+	BlendNone BlendMode = iota
+	BlendAlpha
+	BlendAdditive
+	BlendPremultiplied
+)
+
+// Primitive is the primitive type of a draw call.
+type Primitive uint8
+
+const (
+	PrimitiveTriangles Primitive = iota
+	PrimitiveTriangleStrip
+	PrimitiveLines
+	PrimitiveLineStrip
+	PrimitivePoints
+)
+
+// ClearFlags selects the buffers cleared by Device.Clear.
+type ClearFlags uint8
+
+const (
+	ClearColor ClearFlags = 1 << iota
+	ClearDepth
+	ClearStencil
+
+	ClearAll = ClearColor | ClearDepth | ClearStencil
+)
+
+// BarrierFlags selects the accesses a memory barrier waits for.
+type BarrierFlags uint8
+
+const (
+	BarrierTexture BarrierFlags = 1 << iota
+	BarrierImage
+	BarrierFramebuffer
+	BarrierStorage
+	BarrierVertex
+	BarrierIndirect
+	BarrierUniform
+
+	BarrierAll = BarrierTexture | BarrierImage | BarrierFramebuffer | BarrierStorage |
+		BarrierVertex | BarrierIndirect | BarrierUniform
+)
+
+// BufferType is the type of a GPU buffer.
+type BufferType uint8
+
+const (
+	BufferVertex BufferType = iota
+	BufferIndex
+	BufferUniform
+	BufferStorage
+)
+
+// BufferUsage hints how often the contents of a buffer change.
+type BufferUsage uint8
+
+const (
+	BufferUsageStatic BufferUsage = iota
+	BufferUsageDynamic
+	BufferUsageStream
+)
+
+// BufferAccess selects how the memory of a mapped buffer is accessed.
+type BufferAccess uint8
+
+const (
+	BufferAccessRead BufferAccess = iota
+	BufferAccessWrite
+	BufferAccessReadWrite
+)
+
+// TextureKind is the type of a texture created by a Device.
+type TextureKind uint8
+
+const (
+	TextureKind2D TextureKind = iota
+	TextureKind2DArray
+	TextureKind3D
+	TextureKindCubemap
+	TextureKind2DMultisample
+)
+
+// PipelineState holds the fixed function state of a draw call.
+type PipelineState struct {
+	DepthTest  bool
+	DepthWrite bool
+	DepthFunc  CompareFunc
+	Cull       CullMode
+	Blend      BlendMode
+	Wireframe  bool
+}
+
+// DefaultPipelineState returns the state used for opaque geometry.
+func DefaultPipelineState() PipelineState {
+	return PipelineState{
+		DepthTest:  true,
+		DepthWrite: true,
+		DepthFunc:  CompareLess,
+		Cull:       CullBack,
+		Blend:      BlendNone,
+	}
+}
+
+// Buffer is a GPU buffer created by a Device.
+type Buffer interface {
+	// Type returns the type of the buffer.
+	Type() BufferType
+
+	// Size returns the size of the buffer in bytes.
+	Size() int
+
+	// Upload replaces the contents of the buffer. Data must be a pointer or
+	// slice of fixed size values.
+	Upload(size int, data interface{})
+
+	// Write replaces size bytes of the buffer starting at offset. The buffer
+	// must have been allocated with Upload.
+	Write(offset, size int, data interface{})
+
+	// Map maps the buffer into client memory. The slice is only valid until
+	// Unmap is called. Map returns nil if the buffer could not be mapped.
+	Map(access BufferAccess) []byte
+
+	// Unmap releases the mapping made by Map. It returns false if the
+	// contents of the buffer were corrupted while mapped.
+	Unmap() bool
+
+	// Bind binds the buffer to its target.
+	Bind()
+
+	// BindBase binds a uniform or storage buffer to a binding point.
+	BindBase(index uint32)
+
+	// Release frees the buffer.
+	Release()
+}
+
+// OcclusionQuery counts the samples of draw calls which pass the depth test.
+// Results arrive asynchronously, so they are usually read a frame later.
+type OcclusionQuery interface {
+	// Begin starts the query. Draw calls until End are counted.
+	Begin()
+
+	// End ends the query.
+	End()
+
+	// Available reports if the result of the last query can be read without
+	// waiting for the GPU.
+	Available() bool
+
+	// Visible reports if any sample passed the depth test in the last query.
+	// It waits for the result if it is not available.
+	Visible() bool
+
+	// Release frees the query.
+	Release()
+}
+
+// Device is a rendering backend. It owns the GPU state, creates resources,
+// and executes draw calls. Rendering code should go through the active
+// device rather than calling a graphics API directly.
+type Device interface {
+	// Name returns the name of the backend.
+	Name() string
+
+	// PipelineState returns the current pipeline state.
+	PipelineState() PipelineState
+
+	// SetPipelineState applies a pipeline state.
+	SetPipelineState(state PipelineState)
+
+	SetDepthTest(enable bool)
+	SetDepthWrite(enable bool)
+	SetDepthFunc(fn CompareFunc)
+	SetCullMode(mode CullMode)
+	SetBlendMode(mode BlendMode)
+	SetWireframe(enable bool)
+
+	// SetColorWrite enables or disables writes to the color buffers.
+	SetColorWrite(enable bool)
+
+	// SetViewport sets the viewport rectangle in pixels.
+	SetViewport(x, y, width, height int32)
+
+	// SetClearColor sets the color used when clearing color buffers.
+	SetClearColor(color core.Color)
+
+	// Clear clears buffers of the bound framebuffer.
+	Clear(flags ClearFlags)
+
+	// BindTexture binds a texture to a texture unit.
+	BindTexture(unit uint32, texture Texture)
+
+	// Draw draws non-indexed primitives from the bound vertex buffers.
+	Draw(primitive Primitive, first, count int32)
+
+	// DrawIndexed draws indexed primitives from the bound index buffer.
+	DrawIndexed(primitive Primitive, count int32)
+
+	// NewBuffer creates a buffer.
+	NewBuffer(bufferType BufferType, usage BufferUsage) Buffer
+
+	// NewTexture creates a texture. Layers is the number of layers of array
+	// and 3D textures, and the number of samples of multisample textures. It
+	// is ignored by other kinds.
+	NewTexture(kind TextureKind, size math.IVec2, layers int32, format TextureFormat) Texture
+
+	// NewShader creates an empty shader program. Sources are added to it
+	// before it is built.
+	NewShader(deferred bool) Shader
+
+	// NewFramebuffer creates a framebuffer without attachments.
+	NewFramebuffer(size math.IVec2) Framebuffer
+
+	// NewSampler creates a sampler with trilinear filtering and repeating
+	// coordinates.
+	NewSampler() Sampler
+
+	// NewOcclusionQuery creates an occlusion query.
+	NewOcclusionQuery() OcclusionQuery
+
+	// Barrier orders writes made through image or buffer stores before
+	// later accesses of the given kinds.
+	Barrier(flags BarrierFlags)
+
+	// Submit executes the commands of a command buffer in order.
+	Submit(cb *CommandBuffer)
+
+	// PushDebugGroup opens a named group of commands. Groups show up as
+	// markers in frame capture tools.
+	PushDebugGroup(name string)
+
+	// PopDebugGroup closes the group opened by the last PushDebugGroup.
+	PopDebugGroup()
+}
+
+var activeDevice Device = NewGLDevice()
+
+// MemoryBarrier orders the image and buffer stores of earlier compute or
+// draw calls before later accesses of the given kinds on the active device.
+func MemoryBarrier(flags BarrierFlags) {
+	activeDevice.Barrier(flags)
+}
+
+// ActiveDevice returns the device used for rendering.
+func ActiveDevice() Device {
+	return activeDevice
+}
+
+// SetActiveDevice sets the device used for rendering. It must be set before
+// any graphics resources are created.
+func SetActiveDevice(device Device) {
+	if device != nil {
+		activeDevice = device
+	}
+}
@@ -0,0 +1,487 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+var _ Device = &GLDevice{}
+var _ Buffer = &glBuffer{}
+var _ OcclusionQuery = &glOcclusionQuery{}
+
+// GLDevice is the OpenGL 4.3 core device.
+type GLDevice struct {
+	state PipelineState
+}
+
+// NewGLDevice creates a device for the current OpenGL context. The initial
+// state matches the state set up by the window system.
+func NewGLDevice() *GLDevice {
+	return &GLDevice{
+		state: PipelineState{
+			DepthTest:  true,
+			DepthWrite: true,
+			DepthFunc:  CompareLessEqual,
+			Cull:       CullNone,
+			Blend:      BlendNone,
+		},
+	}
+}
+
+// Name returns the name of the backend.
+func (d *GLDevice) Name() string {
+	return "OpenGL 4.3"
+}
+
+func (d *GLDevice) PipelineState() PipelineState {
+	return d.state
+}
+
+func (d *GLDevice) SetPipelineState(state PipelineState) {
+	d.SetDepthTest(state.DepthTest)
+	d.SetDepthWrite(state.DepthWrite)
+	d.SetDepthFunc(state.DepthFunc)
+	d.SetCullMode(state.Cull)
+	d.SetBlendMode(state.Blend)
+	d.SetWireframe(state.Wireframe)
+}
+
+func (d *GLDevice) SetDepthTest(enable bool) {
+	d.state.DepthTest = enable
+	currentState.Enable(gl.DEPTH_TEST, enable)
+}
+
+func (d *GLDevice) SetDepthWrite(enable bool) {
+	d.state.DepthWrite = enable
+	currentState.DepthMask(enable)
+}
+
+func (d *GLDevice) SetDepthFunc(fn CompareFunc) {
+	d.state.DepthFunc = fn
+	currentState.DepthFunc(glCompareFunc(fn))
+}
+
+func (d *GLDevice) SetCullMode(mode CullMode) {
+	d.state.Cull = mode
+
+	switch mode {
+	case CullNone:
+		currentState.Enable(gl.CULL_FACE, false)
+	case CullFront:
+		currentState.Enable(gl.CULL_FACE, true)
+		currentState.CullFace(gl.FRONT)
+	default:
+		currentState.Enable(gl.CULL_FACE, true)
+		currentState.CullFace(gl.BACK)
+	}
+}
+
+func (d *GLDevice) SetBlendMode(mode BlendMode) {
+	d.state.Blend = mode
+
+	switch mode {
+	case BlendNone:
+		currentState.Enable(gl.BLEND, false)
+	case BlendAdditive:
+		currentState.Enable(gl.BLEND, true)
+		currentState.BlendFunc(gl.ONE, gl.ONE)
+	case BlendPremultiplied:
+		currentState.Enable(gl.BLEND, true)
+		currentState.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	default:
+		currentState.Enable(gl.BLEND, true)
+		currentState.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	}
+}
+
+func (d *GLDevice) SetWireframe(enable bool) {
+	if !Supported(FeatureWireframe) {
+		return
+	}
+
+	d.state.Wireframe = enable
+
+	if enable {
+		currentState.PolygonMode(gl.LINE)
+	} else {
+		currentState.PolygonMode(gl.FILL)
+	}
+}
+
+func (d *GLDevice) SetColorWrite(enable bool) {
+	currentState.ColorMask(enable)
+}
+
+func (d *GLDevice) SetViewport(x, y, width, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+func (d *GLDevice) SetClearColor(color core.Color) {
+	gl.ClearColor(color.Elem())
+}
+
+func (d *GLDevice) Clear(flags ClearFlags) {
+	var mask uint32
+
+	if flags&ClearColor != 0 {
+		mask |= gl.COLOR_BUFFER_BIT
+	}
+	if flags&ClearDepth != 0 {
+		mask |= gl.DEPTH_BUFFER_BIT
+	}
+	if flags&ClearStencil != 0 {
+		mask |= gl.STENCIL_BUFFER_BIT
+	}
+
+	gl.Clear(mask)
+}
+
+func (d *GLDevice) BindTexture(unit uint32, texture Texture) {
+	texture.ActivateTexture(gl.TEXTURE0 + unit)
+}
+
+func (d *GLDevice) Draw(primitive Primitive, first, count int32) {
+	gl.DrawArrays(glPrimitive(primitive), first, count)
+	checkError("draw")
+}
+
+func (d *GLDevice) DrawIndexed(primitive Primitive, count int32) {
+	gl.DrawElements(glPrimitive(primitive), count, gl.UNSIGNED_INT, nil)
+	checkError("draw indexed")
+}
+
+func (d *GLDevice) NewBuffer(bufferType BufferType, usage BufferUsage) Buffer {
+	b := &glBuffer{
+		bufferType: bufferType,
+		target:     glBufferTarget(bufferType),
+		usage:      glBufferUsage(usage),
+	}
+
+	gl.GenBuffers(1, &b.reference)
+
+	return b
+}
+
+func (d *GLDevice) NewTexture(kind TextureKind, size math.IVec2, layers int32, format TextureFormat) Texture {
+	switch kind {
+	case TextureKind2DArray:
+		return NewTexture2DArray(size, layers, format)
+	case TextureKind3D:
+		return NewTexture3D(size, layers, format)
+	case TextureKindCubemap:
+		return NewTextureCubemap(size, format)
+	case TextureKind2DMultisample:
+		return NewTexture2DMultisample(size, layers, format)
+	}
+
+	return NewTexture2D(size, format)
+}
+
+func (d *GLDevice) NewShader(deferred bool) Shader {
+	return newGLShader(deferred)
+}
+
+func (d *GLDevice) NewFramebuffer(size math.IVec2) Framebuffer {
+	return newGLFramebuffer(size)
+}
+
+func (d *GLDevice) NewSampler() Sampler {
+	return newGLSampler()
+}
+
+func (d *GLDevice) NewOcclusionQuery() OcclusionQuery {
+	q := &glOcclusionQuery{}
+
+	gl.GenQueries(1, &q.reference)
+
+	return q
+}
+
+func (d *GLDevice) Barrier(flags BarrierFlags) {
+	var bits uint32
+
+	if flags&BarrierTexture != 0 {
+		bits |= gl.TEXTURE_FETCH_BARRIER_BIT
+	}
+	if flags&BarrierImage != 0 {
+		bits |= gl.SHADER_IMAGE_ACCESS_BARRIER_BIT
+	}
+	if flags&BarrierFramebuffer != 0 {
+		bits |= gl.FRAMEBUFFER_BARRIER_BIT
+	}
+	if flags&BarrierStorage != 0 {
+		bits |= gl.SHADER_STORAGE_BARRIER_BIT
+	}
+	if flags&BarrierVertex != 0 {
+		bits |= gl.VERTEX_ATTRIB_ARRAY_BARRIER_BIT | gl.ELEMENT_ARRAY_BARRIER_BIT
+	}
+	if flags&BarrierIndirect != 0 {
+		bits |= gl.COMMAND_BARRIER_BIT
+	}
+	if flags&BarrierUniform != 0 {
+		bits |= gl.UNIFORM_BARRIER_BIT
+	}
+
+	gl.MemoryBarrier(bits)
+}
+
+func (d *GLDevice) PushDebugGroup(name string) {
+	if !debugLabels() {
+		return
+	}
+
+	gl.PushDebugGroup(gl.DEBUG_SOURCE_APPLICATION, 0, int32(len(name)), gl.Str(name+"\x00"))
+}
+
+func (d *GLDevice) PopDebugGroup() {
+	if !debugLabels() {
+		return
+	}
+
+	gl.PopDebugGroup()
+}
+
+func (d *GLDevice) Submit(cb *CommandBuffer) {
+	for _, c := range cb.Commands() {
+		switch c.Type {
+		case CommandSetPipelineState:
+			d.SetPipelineState(c.Pipeline)
+		case CommandSetViewport:
+			d.SetViewport(c.Viewport[0], c.Viewport[1], c.Viewport[2], c.Viewport[3])
+		case CommandClear:
+			d.SetClearColor(c.ClearColor)
+			d.Clear(c.Clear)
+		case CommandBindTexture:
+			d.BindTexture(c.Unit, c.Texture)
+		case CommandDraw:
+			d.Draw(c.Primitive, c.First, c.Count)
+		case CommandDrawIndexed:
+			d.DrawIndexed(c.Primitive, c.Count)
+		case CommandBindShader:
+			c.Shader.Bind()
+			if c.Pass != "" {
+				c.Shader.SetPass(c.Pass)
+			}
+		case CommandSetUniform:
+			c.Shader.SetUniform(c.Uniform, c.Value)
+		case CommandBindMesh:
+			c.Mesh.Bind()
+		case CommandDrawMesh:
+			c.Mesh.Draw()
+		case CommandCallback:
+			c.Callback()
+		}
+	}
+}
+
+type glBuffer struct {
+	bufferType BufferType
+	reference  uint32
+	target     uint32
+	usage      uint32
+	size       int
+}
+
+func (b *glBuffer) Type() BufferType {
+	return b.bufferType
+}
+
+func (b *glBuffer) Size() int {
+	return b.size
+}
+
+func (b *glBuffer) Upload(size int, data interface{}) {
+	b.size = size
+
+	gl.BindBuffer(b.target, b.reference)
+	if data == nil {
+		gl.BufferData(b.target, size, nil, b.usage)
+	} else {
+		gl.BufferData(b.target, size, gl.Ptr(data), b.usage)
+	}
+	checkError("buffer upload")
+}
+
+func (b *glBuffer) Write(offset, size int, data interface{}) {
+	gl.BindBuffer(b.target, b.reference)
+	gl.BufferSubData(b.target, offset, size, gl.Ptr(data))
+	checkError("buffer write")
+}
+
+func (b *glBuffer) Map(access BufferAccess) []byte {
+	if b.size == 0 {
+		return nil
+	}
+
+	gl.BindBuffer(b.target, b.reference)
+	ptr := gl.MapBufferRange(b.target, 0, b.size, glBufferAccess(access))
+	if ptr == nil {
+		return nil
+	}
+
+	return (*[1 << 30]byte)(ptr)[:b.size:b.size]
+}
+
+func (b *glBuffer) Unmap() bool {
+	gl.BindBuffer(b.target, b.reference)
+
+	return gl.UnmapBuffer(b.target)
+}
+
+func (b *glBuffer) Bind() {
+	gl.BindBuffer(b.target, b.reference)
+}
+
+func (b *glBuffer) BindBase(index uint32) {
+	gl.BindBufferBase(b.target, index, b.reference)
+}
+
+func (b *glBuffer) Release() {
+	gl.DeleteBuffers(1, &b.reference)
+	b.reference = 0
+}
+
+type glOcclusionQuery struct {
+	reference uint32
+	issued    bool
+}
+
+func (q *glOcclusionQuery) Begin() {
+	gl.BeginQuery(gl.ANY_SAMPLES_PASSED_CONSERVATIVE, q.reference)
+}
+
+func (q *glOcclusionQuery) End() {
+	gl.EndQuery(gl.ANY_SAMPLES_PASSED_CONSERVATIVE)
+	q.issued = true
+}
+
+func (q *glOcclusionQuery) Available() bool {
+	if !q.issued {
+		return false
+	}
+
+	var available uint32
+	gl.GetQueryObjectuiv(q.reference, gl.QUERY_RESULT_AVAILABLE, &available)
+
+	return available != 0
+}
+
+func (q *glOcclusionQuery) Visible() bool {
+	if !q.issued {
+		return true
+	}
+
+	var result uint32
+	gl.GetQueryObjectuiv(q.reference, gl.QUERY_RESULT, &result)
+
+	return result != 0
+}
+
+func (q *glOcclusionQuery) Release() {
+	gl.DeleteQueries(1, &q.reference)
+	q.reference = 0
+	q.issued = false
+}
+
+func glToggle(capability uint32, enable bool) {
+	if enable {
+		gl.Enable(capability)
+	} else {
+		gl.Disable(capability)
+	}
+}
+
+func glCompareFunc(fn CompareFunc) uint32 {
+	switch fn {
+	case CompareLessEqual:
+		return gl.LEQUAL
+	case CompareEqual:
+		return gl.EQUAL
+	case CompareGreater:
+		return gl.GREATER
+	case CompareGreaterEqual:
+		return gl.GEQUAL
+	case CompareNotEqual:
+		return gl.NOTEQUAL
+	case CompareAlways:
+		return gl.ALWAYS
+	case CompareNever:
+		return gl.NEVER
+	}
+
+	return gl.LESS
+}
+
+func glPrimitive(primitive Primitive) uint32 {
+	switch primitive {
+	case PrimitiveTriangleStrip:
+		return gl.TRIANGLE_STRIP
+	case PrimitiveLines:
+		return gl.LINES
+	case PrimitiveLineStrip:
+		return gl.LINE_STRIP
+	case PrimitivePoints:
+		return gl.POINTS
+	}
+
+	return gl.TRIANGLES
+}
+
+func glBufferTarget(bufferType BufferType) uint32 {
+	switch bufferType {
+	case BufferIndex:
+		return gl.ELEMENT_ARRAY_BUFFER
+	case BufferUniform:
+		return gl.UNIFORM_BUFFER
+	case BufferStorage:
+		return gl.SHADER_STORAGE_BUFFER
+	}
+
+	return gl.ARRAY_BUFFER
+}
+
+func glBufferAccess(access BufferAccess) uint32 {
+	switch access {
+	case BufferAccessRead:
+		return gl.MAP_READ_BIT
+	case BufferAccessWrite:
+		return gl.MAP_WRITE_BIT
+	}
+
+	return gl.MAP_READ_BIT | gl.MAP_WRITE_BIT
+}
+
+func glBufferUsage(usage BufferUsage) uint32 {
+	switch usage {
+	case BufferUsageDynamic:
+		return gl.DYNAMIC_DRAW
+	case BufferUsageStream:
+		return gl.STREAM_DRAW
+	}
+
+	return gl.STATIC_DRAW
+}
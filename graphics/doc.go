@@ -0,0 +1,45 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package graphics implements the renderer on top of a Device, which owns
+// the GPU state and creates buffers, textures, shaders, samplers and
+// framebuffers.
+//
+// The OpenGL device binds desktop OpenGL 4.3 through go-gl, so it needs cgo
+// and a GLFW window. Builds with the gles tag create an OpenGL ES 3.x
+// context instead and use the ES profile, which compiles shaders as GLSL ES
+// 3.00 and has no compute, geometry or tessellation shaders. The entry
+// points are still loaded through the desktop bindings, so gles builds
+// target desktop drivers which provide ES contexts. Functions the context
+// lacks abort the program when called, and the context is refused if the
+// renderer needs any of them.
+//
+// The ES profile is only the shader and feature groundwork for mobile and
+// web targets. There is no WebGL 2 device, no WebAssembly build and no
+// gomobile build: those targets need an OpenGL ES or WebGL 2 binding for the
+// device, which is not a dependency of this module, and remain to be done.
+//
+// OpenGL is the only backend. A Vulkan device is deferred: Vulkan bindings
+// are not a dependency of this module, the GLFW 3.2 bindings cannot create
+// Vulkan surfaces, and parts of the renderer still call go-gl directly
+// instead of going through the Device.
+package graphics
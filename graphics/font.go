@@ -64,14 +64,18 @@ type Atlas struct {
 	ascent     float64
 	descent    float64
 	lineHeight float64
+	sdf        bool
+	padding    float64
 }
 
 type Font struct {
 	core.BaseObject
 
-	ttf     *truetype.Font
-	atlases map[float64]*Atlas
-	runes   []rune
+	ttf       *truetype.Font
+	atlases   map[float64]*Atlas
+	runes     []rune
+	sdfSize   float64
+	sdfSpread float64
 }
 
 type Rect64 struct {
@@ -153,7 +157,13 @@ func NewFont(ttf *truetype.Font, runeSets ...[]rune) *Font {
 	return f
 }
 
+// Atlas returns the glyph atlas of a size, generating it if needed. Distance
+// field fonts have a single atlas, which is returned for every size.
 func (f *Font) Atlas(size float64) *Atlas {
+	if f.SDF() {
+		size = f.sdfSize
+	}
+
 	if atlas, ok := f.atlases[size]; ok {
 		return atlas
 	}
@@ -167,19 +177,33 @@ func (f *Font) generateAtlas(size float64) *Atlas {
 		return nil
 	}
 
+	// Hinting snaps outlines to the pixel grid of one size, which looks off
+	// once a distance field is scaled.
+	hinting := font.HintingFull
+	if f.SDF() {
+		hinting = font.HintingNone
+	}
+
 	face := truetype.NewFace(f.ttf, &truetype.Options{
 		Size:              size,
-		Hinting:           font.HintingFull,
+		Hinting:           hinting,
 		GlyphCacheEntries: 1,
 	})
 
-	fixedMapping, fixedBounds := makeSquareMapping(face, f.runes, fixed.I(2))
+	// The distance field of a glyph reaches spread pixels beyond its
+	// outline, so glyphs are kept twice that apart.
+	var spread int
+	if f.SDF() {
+		spread = int(math.Ceil(f.sdfSpread))
+	}
+
+	fixedMapping, fixedBounds := makeSquareMapping(face, f.runes, fixed.I(2+2*spread))
 
 	atlasImg := image.NewRGBA(image.Rect(
-		fixedBounds.Min.X.Floor(),
-		fixedBounds.Min.Y.Floor(),
-		fixedBounds.Max.X.Ceil(),
-		fixedBounds.Max.Y.Ceil(),
+		fixedBounds.Min.X.Floor()-spread,
+		fixedBounds.Min.Y.Floor()-spread,
+		fixedBounds.Max.X.Ceil()+spread,
+		fixedBounds.Max.Y.Ceil()+spread,
 	))
 
 	for r, fg := range fixedMapping {
@@ -187,11 +211,16 @@ func (f *Font) generateAtlas(size float64) *Atlas {
 		draw.Draw(atlasImg, dr, mask, maskp, draw.Src)
 	}
 
+	if f.SDF() {
+		distanceField(atlasImg, f.sdfSpread)
+	}
+
+	pad := float64(spread)
 	bounds := R64(
-		i2f(fixedBounds.Min.X),
-		i2f(fixedBounds.Min.Y),
-		i2f(fixedBounds.Max.X),
-		i2f(fixedBounds.Max.Y),
+		float64(atlasImg.Bounds().Min.X),
+		float64(atlasImg.Bounds().Min.Y),
+		float64(atlasImg.Bounds().Max.X),
+		float64(atlasImg.Bounds().Max.Y),
 	)
 
 	mapping := make(map[rune]Glyph)
@@ -203,10 +232,10 @@ func (f *Font) generateAtlas(size float64) *Atlas {
 				i2f(fg.dot.Y),
 			},
 			Frame: R64(
-				i2f(fg.frame.Min.X),
-				i2f(fg.frame.Min.Y)+(i2f(fg.frame.Max.Y-fg.frame.Min.Y))-bounds.Min.Y(),
-				i2f(fg.frame.Max.X),
-				i2f(fg.frame.Min.Y)-bounds.Min.Y(),
+				i2f(fg.frame.Min.X)-pad-bounds.Min.X(),
+				i2f(fg.frame.Min.Y)+(i2f(fg.frame.Max.Y-fg.frame.Min.Y))+pad-bounds.Min.Y(),
+				i2f(fg.frame.Max.X)+pad-bounds.Min.X(),
+				i2f(fg.frame.Min.Y)-pad-bounds.Min.Y(),
 			).Norm(),
 			Advance: i2f(fg.advance),
 		}
@@ -218,6 +247,8 @@ func (f *Font) generateAtlas(size float64) *Atlas {
 		ascent:     i2f(face.Metrics().Ascent),
 		descent:    i2f(face.Metrics().Descent),
 		lineHeight: i2f(face.Metrics().Height),
+		sdf:        f.SDF(),
+		padding:    pad,
 	}
 
 	atlas.texture = NewTextureFont(fmath.IVec2{
@@ -232,6 +263,43 @@ func (f *Font) generateAtlas(size float64) *Atlas {
 	return atlas
 }
 
+// Replace replaces the typeface of the font. The atlases of the sizes in use
+// are regenerated into their existing textures, so materials holding them
+// stay valid. Text must be laid out again to use the new glyph metrics.
+func (f *Font) Replace(ttf *truetype.Font) {
+	f.ttf = ttf
+
+	for size, prev := range f.atlases {
+		atlas := f.generateAtlas(size)
+
+		prev.texture.size = atlas.texture.size
+		prev.texture.data = atlas.texture.data
+		prev.texture.Upload()
+
+		instance.Release(atlas.texture.ID())
+		atlas.texture = prev.texture
+	}
+}
+
+// releaseAtlases releases the atlases of the font.
+func (f *Font) releaseAtlases() {
+	for size, atlas := range f.atlases {
+		instance.Release(atlas.texture.ID())
+		delete(f.atlases, size)
+	}
+}
+
+// Owned returns the IDs of the atlas textures of the font, so they are
+// released when the font is unloaded.
+func (f *Font) Owned() []int32 {
+	ids := make([]int32, 0, len(f.atlases))
+	for _, atlas := range f.atlases {
+		ids = append(ids, atlas.texture.ID())
+	}
+
+	return ids
+}
+
 func (f *Font) HasSize(size float64) bool {
 	_, ok := f.atlases[size]
 
@@ -249,16 +317,17 @@ func (f *Font) DrawText(text string, size float64) ([]Vertex, mgl32.Vec2) {
 		return nil, mgl32.Vec2{}
 	}
 
-	if !f.HasSize(size) {
-		atlas = f.generateAtlas(size)
-	} else {
-		atlas = f.atlases[size]
-	}
-
+	atlas = f.Atlas(size)
 	if atlas == nil {
 		return nil, mgl32.Vec2{}
 	}
 
+	// Distance field atlases are laid out at their own size and scaled.
+	scale := float32(1)
+	if atlas.sdf {
+		scale = float32(size / f.sdfSize)
+	}
+
 	verts := make([]Vertex, 6*len(text))
 	tw := float32(atlas.Texture().Width())
 	th := float32(atlas.Texture().Height())
@@ -270,19 +339,19 @@ func (f *Font) DrawText(text string, size float64) ([]Vertex, mgl32.Vec2) {
 		prev = r
 
 		ul := Vertex{
-			V: mgl32.Vec3{float32(rect.Min.X()), float32(rect.Min.Y()), 0},
+			V: mgl32.Vec3{float32(rect.Min.X()) * scale, float32(rect.Min.Y()) * scale, 0},
 			U: mgl32.Vec2{float32(frame.Min.X()) / tw, float32(frame.Min.Y()) / th},
 		}
 		ur := Vertex{
-			V: mgl32.Vec3{float32(rect.Max.X()), float32(rect.Min.Y()), 0},
+			V: mgl32.Vec3{float32(rect.Max.X()) * scale, float32(rect.Min.Y()) * scale, 0},
 			U: mgl32.Vec2{float32(frame.Max.X()) / tw, float32(frame.Min.Y()) / th},
 		}
 		lr := Vertex{
-			V: mgl32.Vec3{float32(rect.Max.X()), float32(rect.Max.Y()), 0},
+			V: mgl32.Vec3{float32(rect.Max.X()) * scale, float32(rect.Max.Y()) * scale, 0},
 			U: mgl32.Vec2{float32(frame.Max.X()) / tw, float32(frame.Max.Y()) / th},
 		}
 		ll := Vertex{
-			V: mgl32.Vec3{float32(rect.Min.X()), float32(rect.Max.Y()), 0},
+			V: mgl32.Vec3{float32(rect.Min.X()) * scale, float32(rect.Max.Y()) * scale, 0},
 			U: mgl32.Vec2{float32(frame.Min.X()) / tw, float32(frame.Max.Y()) / th},
 		}
 
@@ -303,14 +372,20 @@ func (f *Font) DrawText(text string, size float64) ([]Vertex, mgl32.Vec2) {
 	}
 
 	return verts, mgl32.Vec2{
-		float32(math.Floor(boundings.W())),
-		float32(math.Ceil(boundings.H()))}
+		float32(math.Floor(boundings.W() * float64(scale))),
+		float32(math.Ceil(boundings.H() * float64(scale)))}
 }
 
 func (a *Atlas) Texture() *TextureFont {
 	return a.texture
 }
 
+// SDF reports if the atlas holds signed distance fields of the glyphs rather
+// than their coverage.
+func (a *Atlas) SDF() bool {
+	return a.sdf
+}
+
 // Contains reports whether r in contained within the Atlas.
 func (a *Atlas) Contains(r rune) bool {
 	_, ok := a.mapping[r]
@@ -365,7 +440,14 @@ func (a *Atlas) DrawRune(prev, r rune, dot mgl64.Vec2) (rect, frame, bounds Rect
 	glyph, _ := a.Glyph(r)
 
 	rect = glyph.Frame.Moved(dot.Sub(glyph.Dot))
-	bounds = rect
+
+	// The padding around distance field glyphs is not part of the text.
+	bounds = R64(
+		rect.Min.X()+a.padding,
+		rect.Min.Y()+a.padding,
+		rect.Max.X()-a.padding,
+		rect.Max.Y()-a.padding,
+	)
 
 	if bounds.W()*bounds.H() != 0 {
 		bounds = R64(
@@ -0,0 +1,190 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package graphics
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// DefaultSDFSize is the size in pixels glyphs of distance field fonts are
+	// rendered at.
+	DefaultSDFSize = 64.0
+
+	// DefaultSDFSpread is the distance in pixels of the atlas over which the
+	// distance field of a glyph fades out.
+	DefaultSDFSpread = 8.0
+)
+
+// edtInf stands in for an infinite squared distance. It is finite so the
+// parabola intersections of edt1d stay defined, and large enough that they
+// never fall below -edtInf.
+const edtInf = 1e20
+
+// SetSDF makes the font render its glyphs into a single signed distance field
+// atlas of the given size, which is scaled to every size the font is drawn
+// at. Text stays crisp when scaled, and the UI text shader can draw outlines
+// and glows from the field. Spread is the distance in atlas pixels covered by
+// the field on each side of an outline. Atlases generated before are
+// dropped, so it should be called before the font is used.
+func (f *Font) SetSDF(size, spread float64) {
+	if size <= 0 {
+		size = DefaultSDFSize
+	}
+	if spread <= 0 {
+		spread = DefaultSDFSpread
+	}
+
+	f.releaseAtlases()
+
+	f.sdfSize = size
+	f.sdfSpread = spread
+}
+
+// SDF reports if the font renders its glyphs as a signed distance field.
+func (f *Font) SDF() bool {
+	return f.sdfSize > 0
+}
+
+// SDFSize returns the size the glyphs of a distance field font are rendered
+// at.
+func (f *Font) SDFSize() float64 {
+	return f.sdfSize
+}
+
+// SDFSpread returns the distance in atlas pixels covered by the distance
+// field on each side of an outline.
+func (f *Font) SDFSpread() float64 {
+	return f.sdfSpread
+}
+
+// distanceField replaces the glyph coverage in the red channel of img with a
+// signed distance field, stored in every channel. Outlines map to 0.5, and
+// the field falls to zero outside and rises to one inside over spread pixels.
+func distanceField(img *image.RGBA, spread float64) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	outer := make([]float64, w*h)
+	inner := make([]float64, w*h)
+
+	// Partly covered pixels are placed at a fraction of a pixel from the
+	// outline, which keeps anti-aliased edges smooth.
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := float64(img.Pix[y*img.Stride+x*4]) / 255
+			i := y*w + x
+
+			switch {
+			case a >= 1:
+				outer[i], inner[i] = 0, edtInf
+			case a <= 0:
+				outer[i], inner[i] = edtInf, 0
+			default:
+				d := 0.5 - a
+				outer[i], inner[i] = math.Max(d, 0)*math.Max(d, 0), math.Min(d, 0)*math.Min(d, 0)
+			}
+		}
+	}
+
+	edt(outer, w, h)
+	edt(inner, w, h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			dist := math.Sqrt(outer[i]) - math.Sqrt(inner[i])
+			v := 0.5 - dist/(2*spread)
+			c := uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+
+			p := img.Pix[y*img.Stride+x*4:]
+			p[0], p[1], p[2], p[3] = c, c, c, c
+		}
+	}
+}
+
+// edt replaces the values of grid with the squared distance to the nearest
+// zero, using the linear time transform of Felzenszwalb and Huttenlocher.
+func edt(grid []float64, w, h int) {
+	n := w
+	if h > n {
+		n = h
+	}
+
+	f := make([]float64, n)
+	d := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			f[y] = grid[y*w+x]
+		}
+		edt1d(f[:h], d, v, z)
+		for y := 0; y < h; y++ {
+			grid[y*w+x] = d[y]
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		copy(f, grid[y*w:(y+1)*w])
+		edt1d(f[:w], d, v, z)
+		copy(grid[y*w:(y+1)*w], d[:w])
+	}
+}
+
+// edt1d computes the squared distance transform of the samples f into d,
+// as the lower envelope of the parabolas rooted at each sample.
+func edt1d(f, d []float64, v []int, z []float64) {
+	n := len(f)
+	if n == 0 {
+		return
+	}
+
+	k := 0
+	v[0] = 0
+	z[0] = -edtInf
+	z[1] = edtInf
+
+	for q := 1; q < n; q++ {
+		fq := f[q] + float64(q*q)
+
+		s := (fq - f[v[k]] - float64(v[k]*v[k])) / float64(2*(q-v[k]))
+		for s <= z[k] {
+			k--
+			s = (fq - f[v[k]] - float64(v[k]*v[k])) / float64(2*(q-v[k]))
+		}
+
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = edtInf
+	}
+
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		dq := float64(q - v[k])
+		d[q] = dq*dq + f[v[k]]
+	}
+}
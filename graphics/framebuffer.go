@@ -33,26 +33,95 @@ import (
 	"github.com/haakenlabs/arc/system/instance"
 )
 
+// Framebuffer attachment points. Color attachments are numbered in order, so
+// AttachmentColor0+i is color attachment i. Devices translate attachment
+// points to the values of their graphics API.
+const (
+	AttachmentColor0 uint32 = iota
+	AttachmentColor1
+	AttachmentColor2
+	AttachmentColor3
+	AttachmentColor4
+	AttachmentColor5
+	AttachmentColor6
+	AttachmentColor7
+	AttachmentDepth
+	AttachmentDepthStencil
+)
+
 var (
-	framebufferStack []*Framebuffer
+	framebufferStack []*glFramebuffer
 )
 
 func init() {
-	framebufferStack = []*Framebuffer{}
+	framebufferStack = []*glFramebuffer{}
+}
+
+var _ Framebuffer = &glFramebuffer{}
+
+// Framebuffer is a set of textures and render buffers which are rendered to
+// in place of the window. Bound framebuffers form a stack, so unbinding a
+// framebuffer binds the one bound before it.
+type Framebuffer interface {
+	core.Object
+
+	// Bind pushes the framebuffer on the framebuffer stack.
+	Bind()
+
+	// Unbind pops the framebuffer from the framebuffer stack.
+	Unbind()
+
+	// RawBind binds the framebuffer without changing the stack.
+	RawBind()
+
+	// RawUnbind binds the framebuffer at the top of the stack.
+	RawUnbind()
+
+	// Validate reports if the framebuffer is complete. The framebuffer must
+	// be bound.
+	Validate() error
+
+	SetSize(size math.IVec2)
+	Size() math.IVec2
+	SetAttachment(location uint32, attachment Attachment)
+	Reattach(location uint32)
+	SetDrawBuffers(buffers []uint32)
+	ApplyDrawBuffers(buffers []uint32)
+	RemoveAttachment(location uint32)
+	RemoveAllAttachments()
+	GetAttachment(location uint32) Attachment
+	HasAttachment(location uint32) bool
+	Reference() uint32
+	Bound() bool
+	ClearBuffers()
+	ClearBufferFlags(flags ClearFlags)
+}
+
+// NewFramebuffer creates a framebuffer without attachments on the active
+// device.
+func NewFramebuffer(size math.IVec2) Framebuffer {
+	return activeDevice.NewFramebuffer(size)
+}
+
+func NewFramebufferRaw() Framebuffer {
+	return NewFramebuffer(math.IVec2{})
 }
 
-type Framebuffer struct {
+// glFramebuffer is a framebuffer object of the OpenGL device.
+type glFramebuffer struct {
 	core.BaseObject
 
 	size        math.IVec2
 	bound       bool
 	attachments map[uint32]Attachment
 	drawBuffers []uint32
+	pool        []framebufferStorage
 	reference   uint32
+	allocated   bool
 }
 
-func NewFramebuffer(size math.IVec2) *Framebuffer {
-	f := &Framebuffer{
+func newGLFramebuffer(size math.IVec2) *glFramebuffer {
+	f := &glFramebuffer{
 		size:        size,
 		attachments: make(map[uint32]Attachment),
 		drawBuffers: []uint32{},
@@ -66,10 +135,6 @@ func NewFramebuffer(size math.IVec2) *Framebuffer {
 	return f
 }
 
-func NewFramebufferRaw() *Framebuffer {
-	return NewFramebuffer(math.IVec2{})
-}
-
 func popFramebuffer() {
 	if len(framebufferStack) != 0 {
 		framebufferStack[len(framebufferStack)-1].RawUnbind()
@@ -80,7 +145,7 @@ func popFramebuffer() {
 			framebufferStack[len(framebufferStack)-1].RawBind()
 			framebufferStack[len(framebufferStack)-1].bound = true
 		} else {
-			gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+			currentState.BindFramebuffer(gl.FRAMEBUFFER, 0)
 			gl.Viewport(
 				0, 0,
 				core.GetWindowSystem().Resolution().X(),
@@ -89,7 +154,7 @@ func popFramebuffer() {
 	}
 }
 
-func pushFramebuffer(framebuffer *Framebuffer) {
+func pushFramebuffer(framebuffer *glFramebuffer) {
 	if len(framebufferStack) != 0 {
 		framebufferStack[len(framebufferStack)-1].RawUnbind()
 		framebufferStack[len(framebufferStack)-1].bound = false
@@ -104,7 +169,7 @@ func BindCurrentFramebuffer() {
 	if current := CurrentFramebuffer(); current != nil {
 		current.RawBind()
 	} else {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		currentState.BindFramebuffer(gl.FRAMEBUFFER, 0)
 	}
 }
 
@@ -112,7 +177,7 @@ func UnbindCurrentFramebuffer() {
 	popFramebuffer()
 }
 
-func CurrentFramebuffer() *Framebuffer {
+func CurrentFramebuffer() Framebuffer {
 	if len(framebufferStack) == 0 {
 		return nil
 	}
@@ -120,22 +185,51 @@ func CurrentFramebuffer() *Framebuffer {
 	return framebufferStack[len(framebufferStack)-1]
 }
 
-func BlitFramebuffers(in *Framebuffer, out *Framebuffer, location uint32) {
+func BlitFramebuffers(in Framebuffer, out Framebuffer, location uint32) {
+	dstSize := core.GetWindowSystem().Resolution()
+	if out != nil {
+		dstSize = out.Size()
+	}
+
+	BlitFramebuffersRect(in, out, location, 0, 0, dstSize.X(), dstSize.Y())
+}
+
+// BlitFramebuffersRect copies the color attachment at location of in to the
+// rectangle of out given in pixels, with the origin at the bottom left. If
+// out is nil, the window is the destination.
+func BlitFramebuffersRect(in Framebuffer, out Framebuffer, location uint32, x, y, width, height int32) {
 	src := in.Reference()
 	dst := uint32(0)
 
 	srcSize := in.Size()
-	dstSize := core.GetWindowSystem().Resolution()
 
 	if out != nil {
 		dst = out.Reference()
-		dstSize = out.Size()
 	}
 
-	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, src)
-	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dst)
-	gl.ReadBuffer(location)
-	gl.BlitFramebuffer(0, 0, srcSize.X(), srcSize.Y(), 0, 0, dstSize.X(), dstSize.Y(), gl.COLOR_BUFFER_BIT, gl.LINEAR)
+	currentState.BindFramebuffer(gl.READ_FRAMEBUFFER, src)
+	currentState.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dst)
+	gl.ReadBuffer(glAttachment(location))
+	gl.BlitFramebuffer(0, 0, srcSize.X(), srcSize.Y(), x, y, x+width, y+height, gl.COLOR_BUFFER_BIT, gl.LINEAR)
+
+	if err := gl.GetError(); err != gl.NO_ERROR {
+		panic(err)
+	}
+
+	BindCurrentFramebuffer()
+}
+
+// ResolveFramebuffer resolves a multisampled framebuffer into out. The color
+// attachment at location of in is copied to the draw buffers of out, and the
+// depth attachment to the depth attachment of out. Both framebuffers must
+// have the same size.
+func ResolveFramebuffer(in Framebuffer, out Framebuffer, location uint32) {
+	size := in.Size()
+
+	currentState.BindFramebuffer(gl.READ_FRAMEBUFFER, in.Reference())
+	currentState.BindFramebuffer(gl.DRAW_FRAMEBUFFER, out.Reference())
+	gl.ReadBuffer(glAttachment(location))
+	gl.BlitFramebuffer(0, 0, size.X(), size.Y(), 0, 0, size.X(), size.Y(), gl.COLOR_BUFFER_BIT|gl.DEPTH_BUFFER_BIT, gl.NEAREST)
 
 	if err := gl.GetError(); err != gl.NO_ERROR {
 		panic(err)
@@ -144,39 +238,46 @@ func BlitFramebuffers(in *Framebuffer, out *Framebuffer, location uint32) {
 	BindCurrentFramebuffer()
 }
 
-func (f *Framebuffer) Dealloc() {
+func (f *glFramebuffer) Dealloc() {
 	if f.reference != 0 {
+		currentState.forgetFramebuffer(f.reference)
 		gl.DeleteFramebuffers(1, &f.reference)
 		f.reference = 0
 	}
+
+	f.releasePool()
 }
 
-func (f *Framebuffer) Alloc() error {
+func (f *glFramebuffer) Alloc() error {
 	f.RawBind()
 
+	objectLabel(gl.FRAMEBUFFER, f.reference, f.Name())
+
 	for idx := range f.attachments {
 		f.attachments[idx].SetSize(f.size)
-		f.attachments[idx].Attach(idx)
+		f.attachments[idx].Attach(glAttachment(idx))
 	}
 
 	if len(f.drawBuffers) != 0 {
-		gl.DrawBuffers(int32(len(f.drawBuffers)), &f.drawBuffers[0])
+		drawBuffers(f.drawBuffers)
 	}
 
 	if err := f.Validate(); err != nil {
+		f.allocated = false
 		return err
 	}
 
 	f.RawUnbind()
+	f.allocated = true
 
 	return nil
 }
 
-func (f *Framebuffer) Bind() {
+func (f *glFramebuffer) Bind() {
 	pushFramebuffer(f)
 }
 
-func (f *Framebuffer) Unbind() {
+func (f *glFramebuffer) Unbind() {
 	if f.bound {
 		popFramebuffer()
 	} else {
@@ -188,16 +289,25 @@ func (f *Framebuffer) Unbind() {
 	}
 }
 
-func (f *Framebuffer) RawBind() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, f.reference)
+func (f *glFramebuffer) RawBind() {
+	currentState.BindFramebuffer(gl.FRAMEBUFFER, f.reference)
 	gl.Viewport(0, 0, f.size.X(), f.size.Y())
 }
 
-func (f *Framebuffer) Validate() error {
+func (f *glFramebuffer) Validate() error {
 	if f.size.X() <= 0 || f.size.Y() <= 0 {
 		return fmt.Errorf("validate: framebuffer %d has invalid size: %s", f.reference, f.size)
 	}
 
+	for location := range f.attachments {
+		if location > AttachmentColor7 {
+			continue
+		}
+		if n := int(location - AttachmentColor0); n >= activeProfile.MaxColorAttachments() {
+			return fmt.Errorf("validate: framebuffer %d: color attachment %d exceeds the %s profile limit of %d", f.reference, n, activeProfile, activeProfile.MaxColorAttachments())
+		}
+	}
+
 	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
 
 	if status != gl.FRAMEBUFFER_COMPLETE {
@@ -222,50 +332,69 @@ func (f *Framebuffer) Validate() error {
 	return nil
 }
 
-func (f *Framebuffer) RawUnbind() {
+func (f *glFramebuffer) RawUnbind() {
 	BindCurrentFramebuffer()
 }
 
-func (f *Framebuffer) SetSize(size math.IVec2) {
+// SetSize resizes the framebuffer and its attachments. Resizing to the
+// current size does nothing. The storage of texture attachments is kept for
+// the last sizes, so switching back to one of them reuses it instead of
+// allocating new storage.
+func (f *glFramebuffer) SetSize(size math.IVec2) {
 	if size.X() <= 0 || size.Y() <= 0 {
 		return
 	}
+	if size == f.size && f.allocated {
+		return
+	}
 
+	f.swapStorage(size)
 	f.size = size
 	f.Alloc()
 }
 
-func (f *Framebuffer) SetAttachment(location uint32, attachment Attachment) {
+func (f *glFramebuffer) SetAttachment(location uint32, attachment Attachment) {
 	f.attachments[location] = attachment
+	f.allocated = false
 }
 
-func (f *Framebuffer) SetDrawBuffers(buffers []uint32) {
+// Reattach attaches the attachment at location again, after its face, layer
+// or mip level changed, without resizing it. The framebuffer must be bound.
+func (f *glFramebuffer) Reattach(location uint32) {
+	if a, ok := f.attachments[location]; ok {
+		a.Attach(glAttachment(location))
+	}
+}
+
+func (f *glFramebuffer) SetDrawBuffers(buffers []uint32) {
 	f.drawBuffers = buffers
 }
 
-func (f *Framebuffer) ApplyDrawBuffers(buffers []uint32) {
+func (f *glFramebuffer) ApplyDrawBuffers(buffers []uint32) {
 	f.SetDrawBuffers(buffers)
 
 	if len(f.drawBuffers) != 0 {
-		gl.DrawBuffers(int32(len(f.drawBuffers)), &f.drawBuffers[0])
+		drawBuffers(f.drawBuffers)
 	} else {
 		gl.DrawBuffers(1, nil)
 	}
 }
 
-func (f *Framebuffer) RemoveAttachment(location uint32) {
+func (f *glFramebuffer) RemoveAttachment(location uint32) {
 	if f.HasAttachment(location) {
 		delete(f.attachments, location)
+		f.allocated = false
 	}
 }
 
-func (f *Framebuffer) RemoveAllAttachments() {
+func (f *glFramebuffer) RemoveAllAttachments() {
 	for idx := range f.attachments {
 		delete(f.attachments, idx)
 	}
+	f.allocated = false
 }
 
-func (f *Framebuffer) GetAttachment(location uint32) Attachment {
+func (f *glFramebuffer) GetAttachment(location uint32) Attachment {
 	a, ok := f.attachments[location]
 
 	if !ok {
@@ -275,28 +404,75 @@ func (f *Framebuffer) GetAttachment(location uint32) Attachment {
 	return a
 }
 
-func (f *Framebuffer) HasAttachment(location uint32) bool {
+func (f *glFramebuffer) HasAttachment(location uint32) bool {
 	_, ok := f.attachments[location]
 
 	return ok
 }
 
-func (f *Framebuffer) Size() math.IVec2 {
+func (f *glFramebuffer) Size() math.IVec2 {
 	return f.size
 }
 
-func (f *Framebuffer) Reference() uint32 {
+func (f *glFramebuffer) Reference() uint32 {
 	return f.reference
 }
 
-func (f *Framebuffer) Bound() bool {
+func (f *glFramebuffer) Bound() bool {
 	return f.bound
 }
 
-func (f *Framebuffer) ClearBuffers() {
-	f.ClearBufferFlags(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+func (f *glFramebuffer) ClearBuffers() {
+	f.ClearBufferFlags(ClearAll)
+}
+
+func (f *glFramebuffer) ClearBufferFlags(flags ClearFlags) {
+	activeDevice.Clear(flags)
 }
 
-func (f *Framebuffer) ClearBufferFlags(flags uint32) {
-	gl.Clear(flags)
+// glAttachment returns the OpenGL attachment point of an attachment point.
+func glAttachment(location uint32) uint32 {
+	switch {
+	case location <= AttachmentColor7:
+		return gl.COLOR_ATTACHMENT0 + location - AttachmentColor0
+	case location == AttachmentDepthStencil:
+		return gl.DEPTH_STENCIL_ATTACHMENT
+	}
+
+	return gl.DEPTH_ATTACHMENT
+}
+
+// drawBuffers sets the draw buffers of the bound framebuffer. On the ES
+// profile each buffer must be drawn to from its own index, so the list is
+// spread out and padded with NONE.
+func drawBuffers(buffers []uint32) {
+	var out []uint32
+
+	if activeProfile == ProfileES {
+		var n uint32
+		for _, b := range buffers {
+			if b <= AttachmentColor7 && b-AttachmentColor0 >= n {
+				n = b - AttachmentColor0 + 1
+			}
+		}
+
+		out = make([]uint32, n)
+		for i := range out {
+			out[i] = gl.NONE
+		}
+		for _, b := range buffers {
+			if b <= AttachmentColor7 {
+				out[b-AttachmentColor0] = glAttachment(b)
+			}
+		}
+	} else {
+		out = make([]uint32, len(buffers))
+		for i, b := range buffers {
+			out[i] = glAttachment(b)
+		}
+	}
+
+	if len(out) != 0 {
+		gl.DrawBuffers(int32(len(out)), &out[0])
+	}
 }
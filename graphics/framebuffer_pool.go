@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package graphics
+
+import (
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+// framebufferPoolSize is the number of previous sizes a framebuffer keeps the
+// attachment storage of.
+const framebufferPoolSize = 2
+
+// framebufferStorage is the storage of the texture attachments of a
+// framebuffer at a previous size.
+type framebufferStorage struct {
+	size     math.IVec2
+	textures map[*Texture2D]uint32
+}
+
+// swapStorage moves the storage of the texture attachments to the pool and
+// takes the storage for size from the pool, if it holds any. Only textures of
+// the size of the framebuffer are pooled. Textures shared with a framebuffer
+// which was resized first are already of the new size and keep their storage.
+func (f *glFramebuffer) swapStorage(size math.IVec2) {
+	if f.size.X() <= 0 || f.size.Y() <= 0 {
+		return
+	}
+
+	var restore map[*Texture2D]uint32
+
+	for i := range f.pool {
+		if f.pool[i].size == size {
+			restore = f.pool[i].textures
+			f.pool = append(f.pool[:i], f.pool[i+1:]...)
+			break
+		}
+	}
+
+	stored := framebufferStorage{
+		size:     f.size,
+		textures: make(map[*Texture2D]uint32),
+	}
+
+	for _, a := range f.attachments {
+		at, ok := a.(*AttachmentTexture2D)
+		if !ok {
+			continue
+		}
+
+		t := at.AttachmentObject()
+		if t.reference == 0 || !t.resizable || t.size != f.size {
+			continue
+		}
+
+		stored.textures[t] = t.replaceStorage(restore[t], size)
+		delete(restore, t)
+	}
+
+	// Storage of attachments which were removed since is not needed anymore.
+	for _, reference := range restore {
+		deleteTexture(reference)
+	}
+
+	if len(stored.textures) == 0 {
+		return
+	}
+
+	f.pool = append(f.pool, stored)
+	if len(f.pool) > framebufferPoolSize {
+		for _, reference := range f.pool[0].textures {
+			deleteTexture(reference)
+		}
+		f.pool = f.pool[1:]
+	}
+}
+
+// releasePool deletes the storage held by the pool.
+func (f *glFramebuffer) releasePool() {
+	for i := range f.pool {
+		for _, reference := range f.pool[i].textures {
+			deleteTexture(reference)
+		}
+	}
+
+	f.pool = nil
+}
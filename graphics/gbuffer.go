@@ -30,9 +30,10 @@ import (
 )
 
 type GBuffer struct {
-	Framebuffer
+	glFramebuffer
 
-	hdr bool
+	attachment1Copy *Texture2D
+	hdr             bool
 }
 
 func NewGBuffer(size math.IVec2, depth *AttachmentTexture2D, hdr bool) *GBuffer {
@@ -59,15 +60,20 @@ func NewGBuffer(size math.IVec2, depth *AttachmentTexture2D, hdr bool) *GBuffer
 		attachment2 = NewAttachmentTexture2D(g.size, TextureFormatDefaultColor)
 	}
 
+	// Screen space motion of each pixel since the last frame, in texture
+	// coordinates.
+	attachment3 := NewAttachmentTexture2D(g.size, TextureFormatRG16)
+
 	attachment1.AttachmentObject().Bind()
 	attachment1.AttachmentObject().SetFilter(gl.NEAREST, gl.NEAREST)
 
-	g.SetAttachment(gl.COLOR_ATTACHMENT0, attachment0)
-	g.SetAttachment(gl.COLOR_ATTACHMENT1, attachment1)
-	g.SetAttachment(gl.COLOR_ATTACHMENT2, attachment2)
-	g.SetAttachment(gl.DEPTH_ATTACHMENT, depth)
+	g.SetAttachment(AttachmentColor0, attachment0)
+	g.SetAttachment(AttachmentColor1, attachment1)
+	g.SetAttachment(AttachmentColor2, attachment2)
+	g.SetAttachment(AttachmentColor3, attachment3)
+	g.SetAttachment(AttachmentDepth, depth)
 
-	g.SetDrawBuffers([]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1, gl.COLOR_ATTACHMENT2})
+	g.SetDrawBuffers([]uint32{AttachmentColor0, AttachmentColor1, AttachmentColor2, AttachmentColor3})
 
 	return g
 }
@@ -78,7 +84,7 @@ func (g *GBuffer) HDR() bool {
 
 func (g *GBuffer) SetHDR(enable bool) {
 	if g.hdr != enable {
-		g.RemoveAttachment(gl.COLOR_ATTACHMENT2)
+		g.RemoveAttachment(AttachmentColor2)
 
 		var attachment2 *AttachmentTexture2D
 
@@ -88,7 +94,7 @@ func (g *GBuffer) SetHDR(enable bool) {
 			attachment2 = NewAttachmentTexture2D(g.size, TextureFormatDefaultColor)
 		}
 
-		g.SetAttachment(gl.COLOR_ATTACHMENT2, attachment2)
+		g.SetAttachment(AttachmentColor2, attachment2)
 	}
 
 	// TODO: Handle error
@@ -98,7 +104,7 @@ func (g *GBuffer) SetHDR(enable bool) {
 }
 
 func (g *GBuffer) Attachment0() *Texture2D {
-	if a, ok := g.GetAttachment(gl.COLOR_ATTACHMENT0).(*AttachmentTexture2D); ok {
+	if a, ok := g.GetAttachment(AttachmentColor0).(*AttachmentTexture2D); ok {
 		return a.AttachmentObject()
 	}
 
@@ -106,7 +112,46 @@ func (g *GBuffer) Attachment0() *Texture2D {
 }
 
 func (g *GBuffer) Attachment1() *Texture2D {
-	if a, ok := g.GetAttachment(gl.COLOR_ATTACHMENT1).(*AttachmentTexture2D); ok {
+	if a, ok := g.GetAttachment(AttachmentColor1).(*AttachmentTexture2D); ok {
+		return a.AttachmentObject()
+	}
+
+	return nil
+}
+
+// Attachment2 returns the emission written by the geometry pass.
+func (g *GBuffer) Attachment2() *Texture2D {
+	if a, ok := g.GetAttachment(AttachmentColor2).(*AttachmentTexture2D); ok {
+		return a.AttachmentObject()
+	}
+
+	return nil
+}
+
+// CopyAttachment1 copies the second attachment into a texture which can be
+// read while the attachment is drawn to, and returns the copy.
+func (g *GBuffer) CopyAttachment1() *Texture2D {
+	src := g.Attachment1()
+	if src == nil {
+		return nil
+	}
+
+	if g.attachment1Copy == nil {
+		g.attachment1Copy = NewTexture2D(src.Size(), TextureFormatRGBA32UI)
+		g.attachment1Copy.Alloc()
+		g.attachment1Copy.SetFilter(gl.NEAREST, gl.NEAREST)
+	} else if g.attachment1Copy.Size() != src.Size() {
+		g.attachment1Copy.SetSize(src.Size())
+	}
+
+	CopyTexture2D(src, g.attachment1Copy)
+
+	return g.attachment1Copy
+}
+
+// AttachmentVelocity returns the screen space velocity of each pixel.
+func (g *GBuffer) AttachmentVelocity() *Texture2D {
+	if a, ok := g.GetAttachment(AttachmentColor3).(*AttachmentTexture2D); ok {
 		return a.AttachmentObject()
 	}
 
@@ -114,7 +159,7 @@ func (g *GBuffer) Attachment1() *Texture2D {
 }
 
 func (g *GBuffer) AttachmentDepth() *Texture2D {
-	if a, ok := g.GetAttachment(gl.DEPTH_ATTACHMENT).(*AttachmentTexture2D); ok {
+	if a, ok := g.GetAttachment(AttachmentDepth).(*AttachmentTexture2D); ok {
 		return a.AttachmentObject()
 	}
 
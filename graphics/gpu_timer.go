@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// gpuTimerLatency is the number of frames a timer keeps in flight before it
+// reads a result, so reading never stalls on the GPU.
+const gpuTimerLatency = 3
+
+var gpuTiming bool
+
+// SetGPUTiming enables or disables the timing of render graph passes.
+// Timestamp queries are cheap but not free, so timing is off by default.
+func SetGPUTiming(enable bool) {
+	gpuTiming = enable
+}
+
+// GPUTiming reports if render graph passes are timed.
+func GPUTiming() bool {
+	return gpuTiming
+}
+
+// GPUTimer measures the GPU time spent on the commands between Begin and
+// End with timestamp queries. Results arrive a few frames late.
+type GPUTimer struct {
+	queries [gpuTimerLatency][2]uint32
+	issued  [gpuTimerLatency]bool
+	current int
+	elapsed uint64
+}
+
+// NewGPUTimer creates a timer.
+func NewGPUTimer() *GPUTimer {
+	t := &GPUTimer{}
+
+	for i := range t.queries {
+		gl.GenQueries(2, &t.queries[i][0])
+	}
+
+	return t
+}
+
+// Begin records the start timestamp.
+func (t *GPUTimer) Begin() {
+	gl.QueryCounter(t.queries[t.current][0], gl.TIMESTAMP)
+}
+
+// End records the end timestamp and reads the oldest result, if the GPU
+// has finished it.
+func (t *GPUTimer) End() {
+	gl.QueryCounter(t.queries[t.current][1], gl.TIMESTAMP)
+	t.issued[t.current] = true
+
+	t.current = (t.current + 1) % gpuTimerLatency
+
+	if !t.issued[t.current] {
+		return
+	}
+
+	var available int32
+	gl.GetQueryObjectiv(t.queries[t.current][1], gl.QUERY_RESULT_AVAILABLE, &available)
+	if available == 0 {
+		return
+	}
+
+	var start, end uint64
+	gl.GetQueryObjectui64v(t.queries[t.current][0], gl.QUERY_RESULT, &start)
+	gl.GetQueryObjectui64v(t.queries[t.current][1], gl.QUERY_RESULT, &end)
+
+	if end > start {
+		t.elapsed = end - start
+	}
+	t.issued[t.current] = false
+}
+
+// Milliseconds returns the last measured GPU time in milliseconds.
+func (t *GPUTimer) Milliseconds() float64 {
+	return float64(t.elapsed) / 1e6
+}
+
+// Release frees the queries of the timer.
+func (t *GPUTimer) Release() {
+	for i := range t.queries {
+		gl.DeleteQueries(2, &t.queries[i][0])
+	}
+}
@@ -24,11 +24,13 @@ package graphics
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/haakenlabs/arc/core"
+	fmath "github.com/haakenlabs/arc/pkg/math"
 	"github.com/haakenlabs/arc/system/instance"
 )
 
@@ -39,11 +41,15 @@ type Mesh struct {
 	vertices       []mgl32.Vec3
 	normals        []mgl32.Vec3
 	uvs            []mgl32.Vec2
+	tangents       []mgl32.Vec4
 	triangles      []uint32
 	vao            uint32
 	vbo            uint32
 	ibo            uint32
+	tbo            uint32
 	reverseWinding bool
+	bounds         *fmath.AABB
+	sphere         *fmath.Sphere
 }
 
 type Vertex struct {
@@ -69,6 +75,7 @@ func (m *Mesh) Alloc() error {
 
 	gl.GenBuffers(1, &m.vbo)
 	gl.GenBuffers(1, &m.ibo)
+	gl.GenBuffers(1, &m.tbo)
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
 
@@ -79,13 +86,35 @@ func (m *Mesh) Alloc() error {
 	gl.EnableVertexAttribArray(2)
 	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 32, gl.PtrOffset(24))
 
+	// Tangents are optional, so they live in their own buffer. The attribute
+	// is only enabled by Upload if the mesh has tangents.
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.tbo)
+	gl.VertexAttribPointer(3, 4, gl.FLOAT, false, 16, gl.PtrOffset(0))
+
+	m.label()
+
 	return m.Upload()
 }
 
+// SetName sets the name of the mesh, which also labels its vertex array and
+// buffers in frame capture tools.
+func (m *Mesh) SetName(name string) {
+	m.BaseObject.SetName(name)
+	m.label()
+}
+
+func (m *Mesh) label() {
+	objectLabel(gl.VERTEX_ARRAY, m.vao, m.Name())
+	objectLabel(gl.BUFFER, m.vbo, m.Name()+" Vertices")
+	objectLabel(gl.BUFFER, m.ibo, m.Name()+" Indices")
+	objectLabel(gl.BUFFER, m.tbo, m.Name()+" Tangents")
+}
+
 // Dealloc releases builtin for this mesh.
 func (m *Mesh) Dealloc() {
 	gl.DeleteBuffers(1, &m.vbo)
 	gl.DeleteBuffers(1, &m.ibo)
+	gl.DeleteBuffers(1, &m.tbo)
 	gl.DeleteVertexArrays(1, &m.vao)
 }
 
@@ -102,13 +131,21 @@ func (m *Mesh) Draw() {
 		return
 	}
 
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(m.vertices)))
+	if m.Indexed() {
+		activeDevice.DrawIndexed(PrimitiveTriangles, int32(len(m.triangles)))
+		return
+	}
+
+	activeDevice.Draw(PrimitiveTriangles, 0, int32(len(m.vertices)))
 }
 
 func (m *Mesh) Clear() {
+	m.bounds = nil
+	m.sphere = nil
 	m.vertices = m.vertices[:0]
 	m.normals = m.normals[:0]
 	m.uvs = m.uvs[:0]
+	m.tangents = m.tangents[:0]
 	m.triangles = m.triangles[:0]
 }
 
@@ -121,19 +158,62 @@ func (m *Mesh) Upload() error {
 		return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: asymmetric data", m.vao)
 	}
 
+	if len(m.triangles)%3 != 0 {
+		return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: partial triangle", m.vao)
+	}
+	for _, idx := range m.triangles {
+		if int(idx) >= len(m.vertices) {
+			return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: index %d out of range", m.vao, idx)
+		}
+	}
+
 	data := make([]Vertex, len(m.vertices))
 	for idx := range m.vertices {
 		data[idx] = Vertex{m.vertices[idx], m.normals[idx], m.uvs[idx]}
 	}
 
+	activeDevice.PushDebugGroup(m.Name())
 	m.Bind()
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
 	gl.BufferData(gl.ARRAY_BUFFER, len(data)*32, gl.Ptr(data), gl.STATIC_DRAW)
+
+	// The index buffer is bound to the vertex array, so it is only written
+	// while the array is bound.
+	if m.Indexed() {
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(m.triangles)*4, gl.Ptr(m.triangles), gl.STATIC_DRAW)
+	}
+
+	// Without tangents the attribute reads as zero and shaders fall back to
+	// a tangent frame built from screen space derivatives.
+	if len(m.tangents) == len(m.vertices) {
+		gl.BindBuffer(gl.ARRAY_BUFFER, m.tbo)
+		gl.BufferData(gl.ARRAY_BUFFER, len(m.tangents)*16, gl.Ptr(m.tangents), gl.STATIC_DRAW)
+		gl.EnableVertexAttribArray(3)
+	} else {
+		gl.DisableVertexAttribArray(3)
+	}
 	m.Unbind()
+	activeDevice.PopDebugGroup()
 
 	return nil
 }
 
+// Replace replaces the geometry of an allocated mesh with that of src and
+// uploads it. The vertex array is kept, so users of the mesh draw the new
+// geometry.
+func (m *Mesh) Replace(src *Mesh) error {
+	m.vertices = src.vertices
+	m.normals = src.normals
+	m.uvs = src.uvs
+	m.tangents = src.tangents
+	m.triangles = src.triangles
+	m.bounds = nil
+	m.sphere = nil
+
+	return m.Upload()
+}
+
 func (m *Mesh) Vertices() []mgl32.Vec3 {
 	return m.vertices
 }
@@ -146,6 +226,12 @@ func (m *Mesh) Uvs() []mgl32.Vec2 {
 	return m.uvs
 }
 
+// Tangents returns the tangents of the vertices. The w component holds the
+// handedness of the bitangent.
+func (m *Mesh) Tangents() []mgl32.Vec4 {
+	return m.tangents
+}
+
 func (m *Mesh) Triangles() []uint32 {
 	return m.triangles
 }
@@ -158,8 +244,38 @@ func (m *Mesh) ReversedWinding() bool {
 	return m.reverseWinding
 }
 
+// Bounds returns the bounding box of the vertices of the mesh.
+func (m *Mesh) Bounds() fmath.AABB {
+	if m.bounds == nil {
+		b := fmath.NewAABB(m.vertices...)
+		m.bounds = &b
+	}
+
+	return *m.bounds
+}
+
+// BoundingSphere returns a sphere containing the vertices of the mesh.
+func (m *Mesh) BoundingSphere() fmath.Sphere {
+	if m.sphere == nil {
+		s := fmath.NewSphere(m.vertices...)
+		m.sphere = &s
+	}
+
+	return *m.sphere
+}
+
+// RecalculateBounds recomputes the bounding box and sphere of the mesh. It
+// only needs to be called after the slice returned by Vertices is modified
+// in place, as SetVertices does it already.
+func (m *Mesh) RecalculateBounds() {
+	m.bounds = nil
+	m.sphere = nil
+}
+
 func (m *Mesh) SetVertices(vertices []mgl32.Vec3) {
 	m.vertices = vertices
+	m.bounds = nil
+	m.sphere = nil
 }
 
 func (m *Mesh) SetNormals(normals []mgl32.Vec3) {
@@ -170,6 +286,16 @@ func (m *Mesh) SetUvs(uvs []mgl32.Vec2) {
 	m.uvs = uvs
 }
 
+func (m *Mesh) SetTangents(tangents []mgl32.Vec4) {
+	m.tangents = tangents
+}
+
+// SetTriangles sets the vertex indices of the triangles of the mesh. A mesh
+// without indices draws its vertices as a list of triangles.
+func (m *Mesh) SetTriangles(triangles []uint32) {
+	m.triangles = triangles
+}
+
 func (m *Mesh) SetReversedWinding(reverse bool) {
 	m.reverseWinding = reverse
 }
@@ -253,3 +379,170 @@ func NewMeshQuadBack() *Mesh {
 
 	return m
 }
+
+// NewMeshCube creates a cube from -1 to 1 on each axis.
+func NewMeshCube() *Mesh {
+	m := NewMesh()
+
+	v := cubeVertices()
+	n := make([]mgl32.Vec3, len(v))
+	for i := 0; i < len(v); i += 3 {
+		normal := v[i+1].Sub(v[i]).Cross(v[i+2].Sub(v[i])).Normalize()
+		n[i], n[i+1], n[i+2] = normal, normal, normal
+	}
+
+	m.SetVertices(v)
+	m.SetNormals(n)
+	m.SetUvs(make([]mgl32.Vec2, len(v)))
+
+	m.Alloc()
+
+	m.Upload()
+
+	return m
+}
+
+// NewMeshSphere creates a unit sphere with segments divisions around its
+// equator and rings divisions from pole to pole.
+func NewMeshSphere(segments, rings int) *Mesh {
+	m := NewMesh()
+
+	v := sphereVertices(segments, rings)
+	n := make([]mgl32.Vec3, len(v))
+	for i := range v {
+		n[i] = v[i].Normalize()
+	}
+
+	m.SetVertices(v)
+	m.SetNormals(n)
+	m.SetUvs(make([]mgl32.Vec2, len(v)))
+
+	m.Alloc()
+
+	m.Upload()
+
+	return m
+}
+
+// NewMeshCone creates a closed cone with its apex at the origin, opening
+// along -Z. The base has a radius of one and is at a distance of one.
+func NewMeshCone(segments int) *Mesh {
+	m := NewMesh()
+
+	v := coneVertices(segments)
+	n := make([]mgl32.Vec3, len(v))
+	for i := 0; i < len(v); i += 3 {
+		normal := v[i+1].Sub(v[i]).Cross(v[i+2].Sub(v[i])).Normalize()
+		n[i], n[i+1], n[i+2] = normal, normal, normal
+	}
+
+	m.SetVertices(v)
+	m.SetNormals(n)
+	m.SetUvs(make([]mgl32.Vec2, len(v)))
+
+	m.Alloc()
+
+	m.Upload()
+
+	return m
+}
+
+// cubeVertices returns the triangles of a cube from -1 to 1 on each axis,
+// counter-clockwise when seen from outside.
+func cubeVertices() []mgl32.Vec3 {
+	corner := func(i int) mgl32.Vec3 {
+		return mgl32.Vec3{
+			float32(i&1)*2 - 1,
+			float32(i>>1&1)*2 - 1,
+			float32(i>>2&1)*2 - 1,
+		}
+	}
+
+	// Corners of each face, counter-clockwise when seen from outside.
+	faces := [6][4]int{
+		{1, 3, 7, 5}, // +X
+		{0, 4, 6, 2}, // -X
+		{2, 6, 7, 3}, // +Y
+		{0, 1, 5, 4}, // -Y
+		{4, 5, 7, 6}, // +Z
+		{0, 2, 3, 1}, // -Z
+	}
+
+	v := make([]mgl32.Vec3, 0, 36)
+	for _, f := range faces {
+		v = append(v,
+			corner(f[0]), corner(f[1]), corner(f[2]),
+			corner(f[0]), corner(f[2]), corner(f[3]))
+	}
+
+	return v
+}
+
+// sphereVertices returns the triangles of a unit sphere, counter-clockwise
+// when seen from outside.
+func sphereVertices(segments, rings int) []mgl32.Vec3 {
+	if segments < 3 {
+		segments = 3
+	}
+	if rings < 2 {
+		rings = 2
+	}
+
+	point := func(ring, segment int) mgl32.Vec3 {
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := 2 * math.Pi * float64(segment) / float64(segments)
+
+		return mgl32.Vec3{
+			float32(math.Sin(theta) * math.Cos(phi)),
+			float32(math.Cos(theta)),
+			float32(math.Sin(theta) * math.Sin(phi)),
+		}
+	}
+
+	v := make([]mgl32.Vec3, 0, segments*rings*6)
+	for r := 0; r < rings; r++ {
+		for s := 0; s < segments; s++ {
+			a := point(r, s)
+			b := point(r+1, s)
+			c := point(r+1, s+1)
+			d := point(r, s+1)
+
+			if r != rings-1 {
+				v = append(v, a, c, b)
+			}
+			if r != 0 {
+				v = append(v, a, d, c)
+			}
+		}
+	}
+
+	return v
+}
+
+// coneVertices returns the triangles of a closed cone along -Z,
+// counter-clockwise when seen from outside.
+func coneVertices(segments int) []mgl32.Vec3 {
+	if segments < 3 {
+		segments = 3
+	}
+
+	point := func(segment int) mgl32.Vec3 {
+		phi := 2 * math.Pi * float64(segment) / float64(segments)
+
+		return mgl32.Vec3{float32(math.Cos(phi)), float32(math.Sin(phi)), -1}
+	}
+
+	apex := mgl32.Vec3{}
+	center := mgl32.Vec3{0, 0, -1}
+
+	v := make([]mgl32.Vec3, 0, segments*6)
+	for s := 0; s < segments; s++ {
+		a := point(s)
+		b := point(s + 1)
+
+		v = append(v, apex, a, b)
+		v = append(v, center, b, a)
+	}
+
+	return v
+}
@@ -0,0 +1,306 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package graphics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// corners returns the vertex index of each triangle corner of the mesh.
+func (m *Mesh) corners() []uint32 {
+	if m.Indexed() {
+		return m.triangles
+	}
+
+	c := make([]uint32, len(m.vertices))
+	for i := range c {
+		c[i] = uint32(i)
+	}
+
+	return c
+}
+
+// GenerateNormals sets smooth normals for the triangles of the mesh. The
+// normals of triangles sharing a position are averaged, weighted by the area
+// of each triangle.
+func (m *Mesh) GenerateNormals() {
+	v := m.vertices
+	c := m.corners()
+	sums := make(map[mgl32.Vec3]mgl32.Vec3, len(v)/3)
+
+	for i := 0; i+2 < len(c); i += 3 {
+		p0, p1, p2 := v[c[i]], v[c[i+1]], v[c[i+2]]
+
+		// The cross product is twice the area of the triangle.
+		face := p1.Sub(p0).Cross(p2.Sub(p0))
+		if m.reverseWinding {
+			face = face.Mul(-1)
+		}
+
+		for _, k := range c[i : i+3] {
+			sums[v[k]] = sums[v[k]].Add(face)
+		}
+	}
+
+	normals := make([]mgl32.Vec3, len(v))
+	for i := range v {
+		if n := sums[v[i]]; n.Len() > 0 {
+			normals[i] = n.Normalize()
+		}
+	}
+
+	m.normals = normals
+}
+
+// GenerateFlatNormals sets the normal of each vertex to that of its
+// triangle. Indexed meshes are unwelded first, as a vertex shared by
+// triangles can only have one normal.
+func (m *Mesh) GenerateFlatNormals() {
+	m.Unweld()
+
+	v := m.vertices
+	normals := make([]mgl32.Vec3, len(v))
+
+	for i := 0; i+2 < len(v); i += 3 {
+		face := v[i+1].Sub(v[i]).Cross(v[i+2].Sub(v[i]))
+		if m.reverseWinding {
+			face = face.Mul(-1)
+		}
+		if face.Len() > 0 {
+			face = face.Normalize()
+		}
+
+		normals[i], normals[i+1], normals[i+2] = face, face, face
+	}
+
+	m.normals = normals
+}
+
+// GenerateTangents sets tangents for the triangles of the mesh from its
+// normals and uvs. The tangent of each triangle corner is projected onto the
+// plane of the vertex normal and weighted by the angle of the corner, then
+// averaged over vertices which share a position, normal and uv, as in
+// MikkTSpace. Normal maps baked against MikkTSpace tangents therefore look
+// right on most meshes. The w component is the handedness of the bitangent.
+// The mesh must have normals and uvs.
+func (m *Mesh) GenerateTangents() {
+	v, n, uv := m.vertices, m.normals, m.uvs
+	if len(n) != len(v) || len(uv) != len(v) {
+		return
+	}
+
+	type key struct {
+		v  mgl32.Vec3
+		n  mgl32.Vec3
+		uv mgl32.Vec2
+	}
+
+	c := m.corners()
+	tangents := make(map[key]mgl32.Vec3, len(v)/3)
+	bitangents := make(map[key]mgl32.Vec3, len(v)/3)
+
+	for i := 0; i+2 < len(c); i += 3 {
+		i0, i1, i2 := c[i], c[i+1], c[i+2]
+
+		e1 := v[i1].Sub(v[i0])
+		e2 := v[i2].Sub(v[i0])
+		d1 := uv[i1].Sub(uv[i0])
+		d2 := uv[i2].Sub(uv[i0])
+
+		det := d1[0]*d2[1] - d2[0]*d1[1]
+		if det == 0 {
+			continue
+		}
+
+		r := 1 / det
+		t := e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(r)
+		b := e2.Mul(d1[0]).Sub(e1.Mul(d2[0])).Mul(r)
+
+		for j := 0; j < 3; j++ {
+			k := c[i+j]
+			prev := v[c[i+(j+2)%3]].Sub(v[k])
+			next := v[c[i+(j+1)%3]].Sub(v[k])
+
+			weight := cornerAngle(prev, next)
+			if weight == 0 {
+				continue
+			}
+
+			id := key{v[k], n[k], uv[k]}
+			tangents[id] = tangents[id].Add(projectNormalized(t, n[k]).Mul(weight))
+			bitangents[id] = bitangents[id].Add(projectNormalized(b, n[k]).Mul(weight))
+		}
+	}
+
+	m.tangents = make([]mgl32.Vec4, len(v))
+	for i := range v {
+		k := key{v[i], n[i], uv[i]}
+
+		// Gram-Schmidt orthogonalize the tangent against the normal.
+		t := tangents[k]
+		t = t.Sub(n[i].Mul(n[i].Dot(t)))
+		if t.Len() == 0 {
+			t = orthogonal(n[i])
+		}
+		if t.Len() == 0 {
+			t = mgl32.Vec3{1, 0, 0}
+		}
+		t = t.Normalize()
+
+		w := float32(1)
+		if n[i].Cross(t).Dot(bitangents[k]) < 0 {
+			w = -1
+		}
+
+		m.tangents[i] = t.Vec4(w)
+	}
+}
+
+// Weld merges vertices with equal positions, normals, uvs and tangents, and
+// indexes the triangles of the mesh with the remaining vertices. Data kept
+// alongside the vertices, such as skinning weights, no longer lines up with
+// them afterwards.
+func (m *Mesh) Weld() {
+	type key struct {
+		v  mgl32.Vec3
+		n  mgl32.Vec3
+		uv mgl32.Vec2
+		t  mgl32.Vec4
+	}
+
+	hasNormals := len(m.normals) == len(m.vertices)
+	hasUvs := len(m.uvs) == len(m.vertices)
+	hasTangents := len(m.tangents) == len(m.vertices)
+
+	var vertices []mgl32.Vec3
+	var normals []mgl32.Vec3
+	var uvs []mgl32.Vec2
+	var tangents []mgl32.Vec4
+
+	c := m.corners()
+	index := make(map[key]uint32, len(m.vertices))
+	triangles := make([]uint32, len(c))
+
+	for i, old := range c {
+		k := key{v: m.vertices[old]}
+		if hasNormals {
+			k.n = m.normals[old]
+		}
+		if hasUvs {
+			k.uv = m.uvs[old]
+		}
+		if hasTangents {
+			k.t = m.tangents[old]
+		}
+
+		idx, ok := index[k]
+		if !ok {
+			idx = uint32(len(vertices))
+			index[k] = idx
+
+			vertices = append(vertices, k.v)
+			normals = append(normals, k.n)
+			uvs = append(uvs, k.uv)
+			tangents = append(tangents, k.t)
+		}
+
+		triangles[i] = idx
+	}
+
+	m.vertices = vertices
+	m.triangles = triangles
+	if hasNormals {
+		m.normals = normals
+	}
+	if hasUvs {
+		m.uvs = uvs
+	}
+	if hasTangents {
+		m.tangents = tangents
+	}
+}
+
+// Unweld expands an indexed mesh into a list of triangles, so no vertex is
+// shared between triangles.
+func (m *Mesh) Unweld() {
+	if !m.Indexed() {
+		return
+	}
+
+	c := m.triangles
+	m.vertices = unweld(m.vertices, c)
+	m.normals = unweld(m.normals, c)
+	m.uvs = unweld(m.uvs, c)
+	m.tangents = unweld(m.tangents, c)
+	m.triangles = nil
+}
+
+// unweld returns the values of the vertices at indices, or s if it does not
+// hold a value for each vertex.
+func unweld[T any](s []T, indices []uint32) []T {
+	if len(s) == 0 {
+		return s
+	}
+
+	r := make([]T, len(indices))
+	for i, idx := range indices {
+		if int(idx) < len(s) {
+			r[i] = s[idx]
+		}
+	}
+
+	return r
+}
+
+// cornerAngle returns the angle between two edges of a triangle.
+func cornerAngle(a, b mgl32.Vec3) float32 {
+	la, lb := a.Len(), b.Len()
+	if la == 0 || lb == 0 {
+		return 0
+	}
+
+	cos := mgl32.Clamp(a.Dot(b)/(la*lb), -1, 1)
+
+	return float32(math.Acos(float64(cos)))
+}
+
+// projectNormalized returns v projected onto the plane with normal n, and
+// normalized.
+func projectNormalized(v, n mgl32.Vec3) mgl32.Vec3 {
+	p := v.Sub(n.Mul(n.Dot(v)))
+	if p.Len() == 0 {
+		return p
+	}
+
+	return p.Normalize()
+}
+
+// orthogonal returns a vector orthogonal to v.
+func orthogonal(v mgl32.Vec3) mgl32.Vec3 {
+	if mgl32.Abs(v[0]) < 0.9 {
+		return v.Cross(mgl32.Vec3{1, 0, 0})
+	}
+
+	return v.Cross(mgl32.Vec3{0, 1, 0})
+}
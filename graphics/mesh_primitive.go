@@ -0,0 +1,439 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package graphics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// meshVertex is a vertex of a procedural mesh.
+type meshVertex struct {
+	v  mgl32.Vec3
+	n  mgl32.Vec3
+	uv mgl32.Vec2
+}
+
+// meshBuilder collects the triangles of a procedural mesh.
+type meshBuilder struct {
+	vertices []mgl32.Vec3
+	normals  []mgl32.Vec3
+	uvs      []mgl32.Vec2
+}
+
+// tri adds a triangle, winding it counter-clockwise when seen from the side
+// its normals face. Degenerate triangles, such as those at the poles of a
+// sphere, are dropped.
+func (b *meshBuilder) tri(p0, p1, p2 meshVertex) {
+	face := p1.v.Sub(p0.v).Cross(p2.v.Sub(p0.v))
+	if face.Len() < 1e-9 {
+		return
+	}
+	if face.Dot(p0.n.Add(p1.n).Add(p2.n)) < 0 {
+		p1, p2 = p2, p1
+	}
+
+	for _, p := range [3]meshVertex{p0, p1, p2} {
+		b.vertices = append(b.vertices, p.v)
+		b.normals = append(b.normals, p.n)
+		b.uvs = append(b.uvs, p.uv)
+	}
+}
+
+// grid adds a surface of cols by rows quads. The vertex function is called
+// for each of the (cols+1) * (rows+1) corners.
+func (b *meshBuilder) grid(cols, rows int, vertex func(col, row int) meshVertex) {
+	corners := make([]meshVertex, (cols+1)*(rows+1))
+	for row := 0; row <= rows; row++ {
+		for col := 0; col <= cols; col++ {
+			corners[row*(cols+1)+col] = vertex(col, row)
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			c0 := corners[row*(cols+1)+col]
+			c1 := corners[row*(cols+1)+col+1]
+			c2 := corners[(row+1)*(cols+1)+col+1]
+			c3 := corners[(row+1)*(cols+1)+col]
+
+			b.tri(c0, c1, c2)
+			b.tri(c0, c2, c3)
+		}
+	}
+}
+
+// disc adds a flat disc facing normal, with its uvs mapped to the unit
+// square.
+func (b *meshBuilder) disc(center mgl32.Vec3, radius float32, normal mgl32.Vec3, segments int) {
+	mid := meshVertex{center, normal, mgl32.Vec2{0.5, 0.5}}
+
+	for s := 0; s < segments; s++ {
+		p0 := discVertex(center, radius, normal, s, segments)
+		p1 := discVertex(center, radius, normal, s+1, segments)
+
+		b.tri(mid, p0, p1)
+	}
+}
+
+// mesh creates and uploads a mesh from the triangles, with tangents
+// generated from the uvs.
+func (b *meshBuilder) mesh(name string) *Mesh {
+	m := NewMesh()
+	m.SetName(name)
+
+	m.SetVertices(b.vertices)
+	m.SetNormals(b.normals)
+	m.SetUvs(b.uvs)
+	m.GenerateTangents()
+
+	m.Alloc()
+
+	return m
+}
+
+func discVertex(center mgl32.Vec3, radius float32, normal mgl32.Vec3, segment, segments int) meshVertex {
+	c, s := angle(segment, segments)
+	// The disc lies in the XZ plane, with v running along -Z on top and +Z
+	// on the bottom, so the texture is not mirrored from either side.
+	z := -s
+	if normal.Y() < 0 {
+		z = s
+	}
+
+	return meshVertex{
+		v:  center.Add(mgl32.Vec3{c * radius, 0, z * radius}),
+		n:  normal,
+		uv: mgl32.Vec2{0.5 + 0.5*c, 0.5 + 0.5*s},
+	}
+}
+
+// angle returns the cosine and sine of step steps of a full turn.
+func angle(step, steps int) (float32, float32) {
+	phi := 2 * math.Pi * float64(step) / float64(steps)
+
+	return float32(math.Cos(phi)), float32(math.Sin(phi))
+}
+
+// NewMeshPlane creates a plane in the XZ plane facing +Y, centered on the
+// origin. Each side is divided into subdivisions quads.
+func NewMeshPlane(width, depth float32, subdivisions int) *Mesh {
+	if subdivisions < 1 {
+		subdivisions = 1
+	}
+
+	b := &meshBuilder{}
+	b.grid(subdivisions, subdivisions, func(col, row int) meshVertex {
+		u := float32(col) / float32(subdivisions)
+		v := float32(row) / float32(subdivisions)
+
+		return meshVertex{
+			v:  mgl32.Vec3{(u - 0.5) * width, 0, (0.5 - v) * depth},
+			n:  mgl32.Vec3{0, 1, 0},
+			uv: mgl32.Vec2{u, v},
+		}
+	})
+
+	return b.mesh("Plane")
+}
+
+// NewMeshBox creates a box of the given size centered on the origin. Each
+// face is mapped to the whole texture.
+func NewMeshBox(size mgl32.Vec3) *Mesh {
+	half := size.Mul(0.5)
+
+	// The normal and up axis of each face. The right axis follows from them.
+	faces := [6][2]mgl32.Vec3{
+		{{1, 0, 0}, {0, 1, 0}},
+		{{-1, 0, 0}, {0, 1, 0}},
+		{{0, 1, 0}, {0, 0, -1}},
+		{{0, -1, 0}, {0, 0, 1}},
+		{{0, 0, 1}, {0, 1, 0}},
+		{{0, 0, -1}, {0, 1, 0}},
+	}
+
+	b := &meshBuilder{}
+	for _, f := range faces {
+		normal, up := f[0], f[1]
+		right := up.Cross(normal)
+
+		b.grid(1, 1, func(col, row int) meshVertex {
+			u, v := float32(col), float32(row)
+			p := normal.Add(right.Mul(2*u - 1)).Add(up.Mul(2*v - 1))
+
+			return meshVertex{
+				v:  mgl32.Vec3{p[0] * half[0], p[1] * half[1], p[2] * half[2]},
+				n:  normal,
+				uv: mgl32.Vec2{u, v},
+			}
+		})
+	}
+
+	return b.mesh("Box")
+}
+
+// sphereNormal returns the point of the unit sphere at a longitude of
+// segment of segments, and a polar angle of theta from +Y.
+func sphereNormal(segment, segments int, theta float64) mgl32.Vec3 {
+	c, s := angle(segment, segments)
+	st, ct := float32(math.Sin(theta)), float32(math.Cos(theta))
+
+	return mgl32.Vec3{st * c, ct, -st * s}
+}
+
+// NewMeshUVSphere creates a sphere centered on the origin, with segments
+// divisions around its equator and rings divisions from pole to pole. The
+// texture is wrapped around it by longitude and latitude.
+func NewMeshUVSphere(radius float32, segments, rings int) *Mesh {
+	if segments < 3 {
+		segments = 3
+	}
+	if rings < 2 {
+		rings = 2
+	}
+
+	b := &meshBuilder{}
+	b.grid(segments, rings, func(col, row int) meshVertex {
+		theta := math.Pi * (1 - float64(row)/float64(rings))
+		n := sphereNormal(col, segments, theta)
+
+		return meshVertex{
+			v:  n.Mul(radius),
+			n:  n,
+			uv: mgl32.Vec2{float32(col) / float32(segments), float32(row) / float32(rings)},
+		}
+	})
+
+	return b.mesh("UV Sphere")
+}
+
+// NewMeshIcosphere creates a sphere centered on the origin by subdividing
+// the faces of an icosahedron. Its triangles are of nearly equal size,
+// unlike those of a UV sphere, at the cost of a less regular texture
+// mapping.
+func NewMeshIcosphere(radius float32, subdivisions int) *Mesh {
+	t := float32((1 + math.Sqrt(5)) / 2)
+	corners := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	tris := make([][3]mgl32.Vec3, len(faces))
+	for i, f := range faces {
+		tris[i] = [3]mgl32.Vec3{
+			corners[f[0]].Normalize(),
+			corners[f[1]].Normalize(),
+			corners[f[2]].Normalize(),
+		}
+	}
+
+	for i := 0; i < subdivisions; i++ {
+		next := make([][3]mgl32.Vec3, 0, len(tris)*4)
+		for _, f := range tris {
+			a := f[0].Add(f[1]).Normalize()
+			b := f[1].Add(f[2]).Normalize()
+			c := f[2].Add(f[0]).Normalize()
+
+			next = append(next,
+				[3]mgl32.Vec3{f[0], a, c},
+				[3]mgl32.Vec3{f[1], b, a},
+				[3]mgl32.Vec3{f[2], c, b},
+				[3]mgl32.Vec3{a, b, c})
+		}
+		tris = next
+	}
+
+	b := &meshBuilder{}
+	for _, f := range tris {
+		var p [3]meshVertex
+		for j, n := range f {
+			u := float32(math.Atan2(float64(-n[2]), float64(n[0])) / (2 * math.Pi))
+			if u < 0 {
+				u++
+			}
+			v := 1 - float32(math.Acos(float64(mgl32.Clamp(n[1], -1, 1)))/math.Pi)
+
+			p[j] = meshVertex{n.Mul(radius), n, mgl32.Vec2{u, v}}
+		}
+		fixSphereSeam(&p)
+
+		b.tri(p[0], p[1], p[2])
+	}
+
+	return b.mesh("Icosphere")
+}
+
+// fixSphereSeam fixes the uvs of a sphere triangle crossing the seam where
+// u wraps from one to zero, and of vertices on a pole, where u is undefined.
+func fixSphereSeam(p *[3]meshVertex) {
+	minU, maxU := float32(1), float32(0)
+	for _, q := range p {
+		if q.uv[0] < minU {
+			minU = q.uv[0]
+		}
+		if q.uv[0] > maxU {
+			maxU = q.uv[0]
+		}
+	}
+	if maxU-minU > 0.5 {
+		for j := range p {
+			if p[j].uv[0] < 0.5 {
+				p[j].uv[0]++
+			}
+		}
+	}
+
+	for j := range p {
+		if mgl32.Abs(p[j].n[1]) > 0.9999 {
+			a, b := p[(j+1)%3].uv[0], p[(j+2)%3].uv[0]
+			p[j].uv[0] = (a + b) / 2
+		}
+	}
+}
+
+// NewMeshCylinder creates a closed cylinder along the Y axis, centered on
+// the origin.
+func NewMeshCylinder(radius, height float32, segments int) *Mesh {
+	m := NewMeshTruncatedCone(radius, radius, height, segments)
+	m.SetName("Cylinder")
+
+	return m
+}
+
+// NewMeshConeY creates a closed cone along the Y axis, centered on the
+// origin with its apex at the top. Unlike the light volume made by
+// NewMeshCone, it has uvs and smooth normals.
+func NewMeshConeY(radius, height float32, segments int) *Mesh {
+	m := NewMeshTruncatedCone(radius, 0, height, segments)
+	m.SetName("Cone")
+
+	return m
+}
+
+// NewMeshTruncatedCone creates a closed cone along the Y axis, centered on
+// the origin, cut off where its radius is top. The texture is wrapped around
+// the side, and each cap is mapped to the whole texture.
+func NewMeshTruncatedCone(bottom, top, height float32, segments int) *Mesh {
+	if segments < 3 {
+		segments = 3
+	}
+
+	b := &meshBuilder{}
+	b.grid(segments, 1, func(col, row int) meshVertex {
+		c, s := angle(col, segments)
+		r := bottom + (top-bottom)*float32(row)
+
+		return meshVertex{
+			v:  mgl32.Vec3{c * r, (float32(row) - 0.5) * height, -s * r},
+			n:  mgl32.Vec3{c * height, bottom - top, -s * height}.Normalize(),
+			uv: mgl32.Vec2{float32(col) / float32(segments), float32(row)},
+		}
+	})
+
+	if bottom > 0 {
+		b.disc(mgl32.Vec3{0, -height / 2, 0}, bottom, mgl32.Vec3{0, -1, 0}, segments)
+	}
+	if top > 0 {
+		b.disc(mgl32.Vec3{0, height / 2, 0}, top, mgl32.Vec3{0, 1, 0}, segments)
+	}
+
+	return b.mesh("Truncated Cone")
+}
+
+// NewMeshCapsule creates a capsule along the Y axis, centered on the origin.
+// Height includes the hemispherical caps, which have rings divisions each.
+func NewMeshCapsule(radius, height float32, segments, rings int) *Mesh {
+	if segments < 3 {
+		segments = 3
+	}
+	if rings < 1 {
+		rings = 1
+	}
+	if height < 2*radius {
+		height = 2 * radius
+	}
+
+	// Rows 0 to rings are the bottom cap, and rows rings+1 to 2*rings+1 the
+	// top cap. The quads between them form the cylinder.
+	offset := height/2 - radius
+
+	b := &meshBuilder{}
+	b.grid(segments, 2*rings+1, func(col, row int) meshVertex {
+		var theta float64
+		var center mgl32.Vec3
+		if row <= rings {
+			theta = math.Pi * (1 - 0.5*float64(row)/float64(rings))
+			center = mgl32.Vec3{0, -offset, 0}
+		} else {
+			theta = math.Pi * 0.5 * (1 - float64(row-rings-1)/float64(rings))
+			center = mgl32.Vec3{0, offset, 0}
+		}
+
+		n := sphereNormal(col, segments, theta)
+		v := center.Add(n.Mul(radius))
+
+		return meshVertex{
+			v:  v,
+			n:  n,
+			uv: mgl32.Vec2{float32(col) / float32(segments), v[1]/height + 0.5},
+		}
+	})
+
+	return b.mesh("Capsule")
+}
+
+// NewMeshTorus creates a torus around the Y axis, centered on the origin.
+// Radius is the distance from the center to the middle of the tube.
+// Segments divide the ring, and sides divide the tube.
+func NewMeshTorus(radius, tube float32, segments, sides int) *Mesh {
+	if segments < 3 {
+		segments = 3
+	}
+	if sides < 3 {
+		sides = 3
+	}
+
+	b := &meshBuilder{}
+	b.grid(segments, sides, func(col, row int) meshVertex {
+		c, s := angle(col, segments)
+		tc, ts := angle(row, sides)
+
+		n := mgl32.Vec3{tc * c, ts, -tc * s}
+		center := mgl32.Vec3{c * radius, 0, -s * radius}
+
+		return meshVertex{
+			v:  center.Add(n.Mul(tube)),
+			n:  n,
+			uv: mgl32.Vec2{float32(col) / float32(segments), float32(row) / float32(sides)},
+		}
+	})
+
+	return b.mesh("Torus")
+}
@@ -0,0 +1,187 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"strings"
+)
+
+// Profile is a rendering feature profile. The profile is selected at startup
+// and decides which shader language version and features are used.
+type Profile uint8
+
+const (
+	// ProfileCore is the full OpenGL 4.3 core profile.
+	ProfileCore Profile = iota
+
+	// ProfileES is a reduced profile compatible with OpenGL ES 3.0 and
+	// WebGL2. Shaders are compiled as GLSL ES 3.00, subroutines are emulated
+	// with uniforms, sampler and block bindings and uniform initializers are
+	// assigned after linking, and only four color attachments may be used.
+	ProfileES
+)
+
+// Feature is an optional rendering feature.
+type Feature uint8
+
+const (
+	FeatureSubroutines Feature = iota
+	FeatureGeometryShaders
+	FeatureTessellation
+	FeatureCompute
+	FeatureWireframe
+	FeatureDeferred
+	FeatureStorageBuffers
+	FeatureImageStore
+	FeatureBindingLayouts
+)
+
+// ErrProfileUnknown is returned when parsing an unknown profile name.
+type ErrProfileUnknown string
+
+func (e ErrProfileUnknown) Error() string {
+	return "graphics: unknown profile: " + string(e)
+}
+
+// ErrProfileUnsupported is returned when using a feature which the active
+// profile does not support.
+type ErrProfileUnsupported string
+
+func (e ErrProfileUnsupported) Error() string {
+	if capabilities != nil {
+		return "graphics: not supported by the " + activeProfile.String() + " profile on " + capabilities.Version() + ": " + string(e)
+	}
+
+	return "graphics: not supported by the " + activeProfile.String() + " profile: " + string(e)
+}
+
+// ErrProfileUnavailable is returned when parsing a profile which this build
+// cannot use.
+type ErrProfileUnavailable string
+
+func (e ErrProfileUnavailable) Error() string {
+	return "graphics: profile not available in this build: " + string(e)
+}
+
+var activeProfile = defaultProfile
+
+// ActiveProfile returns the active rendering profile.
+func ActiveProfile() Profile {
+	return activeProfile
+}
+
+// SetActiveProfile sets the active rendering profile. It must be set before
+// any graphics resources are created.
+func SetActiveProfile(profile Profile) {
+	activeProfile = profile
+}
+
+// ParseProfile parses a profile name, as used in the graphics.profile
+// setting. An empty name selects the default profile of the build.
+func ParseProfile(name string) (Profile, error) {
+	var p Profile
+
+	switch strings.ToLower(name) {
+	case "":
+		return defaultProfile, nil
+	case "core", "gl43":
+		p = ProfileCore
+	case "es", "gles3", "webgl2":
+		p = ProfileES
+	default:
+		return defaultProfile, ErrProfileUnknown(name)
+	}
+
+	if !profileAvailable(p) {
+		return defaultProfile, ErrProfileUnavailable(name)
+	}
+
+	return p, nil
+}
+
+func (p Profile) String() string {
+	switch p {
+	case ProfileES:
+		return "es"
+	}
+
+	return "core"
+}
+
+// Supports reports if the profile supports a feature. The ES profile
+// supports none of the optional features.
+func (p Profile) Supports(feature Feature) bool {
+	return p == ProfileCore
+}
+
+// MaxColorAttachments returns the number of color attachments a framebuffer
+// may use.
+func (p Profile) MaxColorAttachments() int {
+	if p == ProfileES {
+		return 4
+	}
+
+	return 8
+}
+
+// esPackFunctions implements the packing functions GLSL ES 3.00 lacks.
+const esPackFunctions = `uint packUnorm4x8(vec4 v) {
+    uvec4 b = uvec4(round(clamp(v, 0.0, 1.0) * 255.0));
+    return b.x | (b.y << 8) | (b.z << 16) | (b.w << 24);
+}
+vec4 unpackUnorm4x8(uint p) {
+    return vec4(uvec4(p, p >> 8, p >> 16, p >> 24) & 0xFFu) / 255.0;
+}
+`
+
+// ShaderHeader returns the source prepended to every shader component.
+func (p Profile) ShaderHeader() string {
+	if p == ProfileES {
+		return "#version 300 es\n" +
+			"#define ARC_PROFILE_ES\n" +
+			"precision highp float;\n" +
+			"precision highp int;\n" +
+			"precision highp sampler2D;\n" +
+			"precision highp sampler3D;\n" +
+			"precision highp samplerCube;\n" +
+			"precision highp sampler2DArray;\n" +
+			"precision highp sampler2DShadow;\n" +
+			"precision highp samplerCubeShadow;\n" +
+			"precision highp sampler2DArrayShadow;\n" +
+			"precision highp isampler2D;\n" +
+			"precision highp isampler3D;\n" +
+			"precision highp isamplerCube;\n" +
+			"precision highp isampler2DArray;\n" +
+			"precision highp usampler2D;\n" +
+			"precision highp usampler3D;\n" +
+			"precision highp usamplerCube;\n" +
+			"precision highp usampler2DArray;\n" +
+			esPackFunctions
+	}
+
+	if capabilities != nil {
+		return capabilities.shaderHeader()
+	}
+
+	return "#version 430\n"
+}
@@ -0,0 +1,231 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/arc/core"
+	fmath "github.com/haakenlabs/arc/pkg/math"
+)
+
+// CaptureFramebuffer reads the color attachment at location of a framebuffer
+// into an image. If fb is nil, the back buffer of the window is read and
+// location is ignored.
+func CaptureFramebuffer(fb Framebuffer, location uint32) *image.RGBA {
+	size := readFramebuffer(fb, location)
+	img := image.NewRGBA(image.Rect(0, 0, int(size.X()), int(size.Y())))
+
+	gl.ReadPixels(0, 0, size.X(), size.Y(), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	BindCurrentFramebuffer()
+
+	flipRows(img.Pix, img.Stride)
+
+	return img
+}
+
+// CaptureFramebufferHDR reads the color attachment at location of a
+// framebuffer as RGBA float values, row by row from the top. If fb is nil, the
+// back buffer of the window is read and location is ignored.
+func CaptureFramebufferHDR(fb Framebuffer, location uint32) ([]float32, fmath.IVec2) {
+	size := readFramebuffer(fb, location)
+	pixels := make([]float32, size.X()*size.Y()*4)
+
+	gl.ReadPixels(0, 0, size.X(), size.Y(), gl.RGBA, gl.FLOAT, gl.Ptr(pixels))
+	BindCurrentFramebuffer()
+
+	row := int(size.X()) * 4
+	for top, bottom := 0, len(pixels)-row; top < bottom; top, bottom = top+row, bottom-row {
+		for i := 0; i < row; i++ {
+			pixels[top+i], pixels[bottom+i] = pixels[bottom+i], pixels[top+i]
+		}
+	}
+
+	return pixels, size
+}
+
+// readFramebuffer binds a framebuffer for reading and returns its size.
+func readFramebuffer(fb Framebuffer, location uint32) fmath.IVec2 {
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+
+	if fb == nil {
+		currentState.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		gl.ReadBuffer(gl.BACK)
+
+		return core.GetWindowSystem().Resolution()
+	}
+
+	currentState.BindFramebuffer(gl.READ_FRAMEBUFFER, fb.Reference())
+	gl.ReadBuffer(glAttachment(location))
+
+	return fb.Size()
+}
+
+// flipRows reverses the order of the rows of an image, as OpenGL returns the
+// bottom row first.
+func flipRows(pix []uint8, stride int) {
+	tmp := make([]uint8, stride)
+
+	for top, bottom := 0, len(pix)-stride; top < bottom; top, bottom = top+stride, bottom-stride {
+		copy(tmp, pix[top:top+stride])
+		copy(pix[top:top+stride], pix[bottom:bottom+stride])
+		copy(pix[bottom:bottom+stride], tmp)
+	}
+}
+
+// SavePNG writes an image to a PNG file.
+func SavePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// SaveEXR writes RGBA float values, row by row from the top, to an OpenEXR
+// file.
+func SaveEXR(filename string, pixels []float32, size fmath.IVec2) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteEXR(f, pixels, size); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// WriteEXR writes RGBA float values, row by row from the top, as an
+// uncompressed single part OpenEXR image.
+func WriteEXR(w io.Writer, pixels []float32, size fmath.IVec2) error {
+	width, height := int(size.X()), int(size.Y())
+	if width <= 0 || height <= 0 || len(pixels) < width*height*4 {
+		return io.ErrShortBuffer
+	}
+
+	// Channels are stored in alphabetical order.
+	channels := []struct {
+		name   string
+		offset int
+	}{{"A", 3}, {"B", 2}, {"G", 1}, {"R", 0}}
+
+	var header bytes.Buffer
+	le := binary.LittleEndian
+
+	attribute := func(name, kind string, value []byte) {
+		header.WriteString(name + "\x00" + kind + "\x00")
+		binary.Write(&header, le, int32(len(value)))
+		header.Write(value)
+	}
+	ints := func(values ...int32) []byte {
+		b := make([]byte, 4*len(values))
+		for i, v := range values {
+			le.PutUint32(b[4*i:], uint32(v))
+		}
+		return b
+	}
+	floats := func(values ...float32) []byte {
+		b := make([]byte, 4*len(values))
+		for i, v := range values {
+			le.PutUint32(b[4*i:], math.Float32bits(v))
+		}
+		return b
+	}
+
+	var chlist bytes.Buffer
+	for _, c := range channels {
+		// Pixel type 2 is FLOAT, followed by pLinear, three reserved bytes
+		// and the sampling rates.
+		chlist.WriteString(c.name + "\x00")
+		chlist.Write(ints(2))
+		chlist.Write([]byte{0, 0, 0, 0})
+		chlist.Write(ints(1, 1))
+	}
+	chlist.WriteByte(0)
+
+	window := ints(0, 0, int32(width-1), int32(height-1))
+
+	header.Write([]byte{0x76, 0x2f, 0x31, 0x01, 2, 0, 0, 0})
+	attribute("channels", "chlist", chlist.Bytes())
+	attribute("compression", "compression", []byte{0})
+	attribute("dataWindow", "box2i", window)
+	attribute("displayWindow", "box2i", window)
+	attribute("lineOrder", "lineOrder", []byte{0})
+	attribute("pixelAspectRatio", "float", floats(1))
+	attribute("screenWindowCenter", "v2f", floats(0, 0))
+	attribute("screenWindowWidth", "float", floats(1))
+	header.WriteByte(0)
+
+	// Each scan line is a chunk holding its y coordinate, the size of the
+	// data, and the values of every channel in turn.
+	lineSize := width * 4 * len(channels)
+	chunkSize := 8 + lineSize
+	start := header.Len() + 8*height
+
+	for y := 0; y < height; y++ {
+		var offset [8]byte
+		le.PutUint64(offset[:], uint64(start+y*chunkSize))
+		header.Write(offset[:])
+	}
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, chunkSize)
+	for y := 0; y < height; y++ {
+		le.PutUint32(chunk[0:], uint32(y))
+		le.PutUint32(chunk[4:], uint32(lineSize))
+
+		i := 8
+		for _, c := range channels {
+			for x := 0; x < width; x++ {
+				le.PutUint32(chunk[i:], math.Float32bits(pixels[(y*width+x)*4+c.offset]))
+				i += 4
+			}
+		}
+
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
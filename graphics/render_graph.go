@@ -0,0 +1,363 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+// RenderGraphResource names a resource read or written by the passes of a
+// render graph.
+type RenderGraphResource string
+
+// RenderGraphTextureDesc describes a transient texture of a render graph.
+type RenderGraphTextureDesc struct {
+	Size   math.IVec2
+	Format TextureFormat
+}
+
+// ErrRenderGraphResource is returned when a pass uses a resource which was
+// neither imported nor created.
+type ErrRenderGraphResource string
+
+func (e ErrRenderGraphResource) Error() string {
+	return "render graph: unknown resource: " + string(e)
+}
+
+// RenderGraphTiming is the GPU time of a pass.
+type RenderGraphTiming struct {
+	Name         string
+	Milliseconds float64
+}
+
+// RenderGraphPass is a pass of a render graph. Reads and Writes declare the
+// resources the pass uses, a resource both read and written is listed in
+// both.
+type RenderGraphPass struct {
+	Name   string
+	Reads  []RenderGraphResource
+	Writes []RenderGraphResource
+
+	// Storage reports that the pass writes through image or buffer stores,
+	// which need a memory barrier before they are read.
+	Storage bool
+
+	// Enabled is checked every frame. Disabled passes are skipped, but keep
+	// their place in the graph. A nil Enabled is always enabled.
+	Enabled func() bool
+
+	// Execute renders the pass.
+	Execute func(g *RenderGraph)
+}
+
+// RenderGraph orders the passes of a frame by the resources they declare.
+// Passes run in the order they were added, except passes whose results are
+// never used, which are culled. Transient textures are allocated before the
+// first pass using them and returned to a pool after the last one, so passes
+// which do not overlap share textures. Memory barriers are inserted between
+// storage writes and later reads.
+type RenderGraph struct {
+	passes    []*RenderGraphPass
+	order     []*RenderGraphPass
+	barriers  []bool
+	allocs    [][]RenderGraphResource
+	releases  [][]RenderGraphResource
+	imported  map[RenderGraphResource]Texture
+	transient map[RenderGraphResource]RenderGraphTextureDesc
+	outputs   map[RenderGraphResource]bool
+	textures  map[RenderGraphResource]*Texture2D
+	pool      map[RenderGraphTextureDesc][]*Texture2D
+	timers    map[*RenderGraphPass]*GPUTimer
+	executed  map[*RenderGraphPass]bool
+	compiled  bool
+}
+
+// NewRenderGraph creates an empty render graph.
+func NewRenderGraph() *RenderGraph {
+	return &RenderGraph{
+		imported:  make(map[RenderGraphResource]Texture),
+		transient: make(map[RenderGraphResource]RenderGraphTextureDesc),
+		outputs:   make(map[RenderGraphResource]bool),
+		textures:  make(map[RenderGraphResource]*Texture2D),
+		pool:      make(map[RenderGraphTextureDesc][]*Texture2D),
+		timers:    make(map[*RenderGraphPass]*GPUTimer),
+		executed:  make(map[*RenderGraphPass]bool),
+	}
+}
+
+// AddPass appends a pass to the graph.
+func (g *RenderGraph) AddPass(pass *RenderGraphPass) {
+	g.passes = append(g.passes, pass)
+	g.compiled = false
+}
+
+// Import adds a resource owned outside of the graph. The texture may be nil
+// for resources which are not a single texture, such as a GBuffer.
+func (g *RenderGraph) Import(resource RenderGraphResource, texture Texture) {
+	g.imported[resource] = texture
+	g.compiled = false
+}
+
+// Create adds a transient texture owned by the graph.
+func (g *RenderGraph) Create(resource RenderGraphResource, desc RenderGraphTextureDesc) {
+	g.transient[resource] = desc
+	g.compiled = false
+}
+
+// SetOutput marks a resource as a result of the graph. Passes which do not
+// contribute to an output are culled.
+func (g *RenderGraph) SetOutput(resource RenderGraphResource) {
+	g.outputs[resource] = true
+	g.compiled = false
+}
+
+// Texture returns the texture of a resource, or nil if it has none. Transient
+// textures are only available while the passes using them execute.
+func (g *RenderGraph) Texture(resource RenderGraphResource) Texture {
+	if t, ok := g.imported[resource]; ok {
+		return t
+	}
+	if t, ok := g.textures[resource]; ok {
+		return t
+	}
+
+	return nil
+}
+
+// Passes returns the passes which are executed, in order. The graph must be
+// compiled.
+func (g *RenderGraph) Passes() []*RenderGraphPass {
+	return g.order
+}
+
+// Compile culls unused passes and computes the lifetimes of transient
+// textures and the barriers between passes.
+func (g *RenderGraph) Compile() error {
+	for _, p := range g.passes {
+		for _, r := range p.Reads {
+			if !g.known(r) {
+				return ErrRenderGraphResource(r)
+			}
+		}
+		for _, r := range p.Writes {
+			if !g.known(r) {
+				return ErrRenderGraphResource(r)
+			}
+		}
+	}
+
+	// Walk backwards from the outputs. A pass is live if it has no writes,
+	// or writes an output or a resource read by a live pass.
+	live := make([]bool, len(g.passes))
+	needed := make(map[RenderGraphResource]bool)
+	for r := range g.outputs {
+		needed[r] = true
+	}
+
+	for i := len(g.passes) - 1; i >= 0; i-- {
+		p := g.passes[i]
+
+		live[i] = len(p.Writes) == 0
+		for _, r := range p.Writes {
+			if needed[r] {
+				live[i] = true
+				break
+			}
+		}
+
+		if live[i] {
+			for _, r := range p.Reads {
+				needed[r] = true
+			}
+		}
+	}
+
+	g.order = g.order[:0]
+	for i := range g.passes {
+		if live[i] {
+			g.order = append(g.order, g.passes[i])
+		}
+	}
+
+	first := make(map[RenderGraphResource]int)
+	last := make(map[RenderGraphResource]int)
+	storage := make(map[RenderGraphResource]bool)
+
+	g.barriers = make([]bool, len(g.order))
+	g.allocs = make([][]RenderGraphResource, len(g.order))
+	g.releases = make([][]RenderGraphResource, len(g.order))
+
+	for i, p := range g.order {
+		for _, r := range p.Reads {
+			if storage[r] {
+				g.barriers[i] = true
+				storage[r] = false
+			}
+		}
+		for _, r := range p.Writes {
+			storage[r] = p.Storage
+		}
+
+		for _, r := range p.Reads {
+			g.extend(r, i, first, last)
+		}
+		for _, r := range p.Writes {
+			g.extend(r, i, first, last)
+		}
+	}
+
+	for r, i := range first {
+		g.allocs[i] = append(g.allocs[i], r)
+	}
+	for r, i := range last {
+		g.releases[i] = append(g.releases[i], r)
+	}
+
+	g.compiled = true
+
+	return nil
+}
+
+// Execute runs the passes of the graph, compiling it first if it changed.
+func (g *RenderGraph) Execute() error {
+	if !g.compiled {
+		if err := g.Compile(); err != nil {
+			return err
+		}
+	}
+
+	device := ActiveDevice()
+
+	for i, p := range g.order {
+		for _, r := range g.allocs[i] {
+			g.textures[r] = g.acquire(g.transient[r])
+		}
+
+		g.executed[p] = p.Enabled == nil || p.Enabled()
+
+		if g.executed[p] {
+			if g.barriers[i] {
+				device.Barrier(BarrierAll)
+			}
+
+			timer := g.timer(p)
+			if timer != nil {
+				timer.Begin()
+			}
+
+			device.PushDebugGroup(p.Name)
+			p.Execute(g)
+			device.PopDebugGroup()
+
+			if timer != nil {
+				timer.End()
+			}
+		}
+
+		for _, r := range g.releases[i] {
+			desc := g.transient[r]
+			g.pool[desc] = append(g.pool[desc], g.textures[r])
+			delete(g.textures, r)
+		}
+	}
+
+	return nil
+}
+
+// Timings returns the GPU time of the passes executed in the last frame.
+// It is empty unless GPU timing is enabled.
+func (g *RenderGraph) Timings() []RenderGraphTiming {
+	var timings []RenderGraphTiming
+
+	for _, p := range g.order {
+		if t, ok := g.timers[p]; ok && g.executed[p] {
+			timings = append(timings, RenderGraphTiming{Name: p.Name, Milliseconds: t.Milliseconds()})
+		}
+	}
+
+	return timings
+}
+
+// Release frees the pooled transient textures and the pass timers.
+func (g *RenderGraph) Release() {
+	for desc, textures := range g.pool {
+		for _, t := range textures {
+			t.Dealloc()
+		}
+		delete(g.pool, desc)
+	}
+	for p, t := range g.timers {
+		t.Release()
+		delete(g.timers, p)
+	}
+}
+
+// timer returns the timer of a pass, or nil if GPU timing is disabled or
+// the context has no timer queries.
+func (g *RenderGraph) timer(p *RenderGraphPass) *GPUTimer {
+	if !gpuTiming || capabilities == nil || !capabilities.TimerQuery {
+		return nil
+	}
+
+	t, ok := g.timers[p]
+	if !ok {
+		t = NewGPUTimer()
+		g.timers[p] = t
+	}
+
+	return t
+}
+
+func (g *RenderGraph) known(r RenderGraphResource) bool {
+	if _, ok := g.imported[r]; ok {
+		return true
+	}
+	_, ok := g.transient[r]
+
+	return ok
+}
+
+// extend extends the lifetime of a transient texture to pass i.
+func (g *RenderGraph) extend(r RenderGraphResource, i int, first, last map[RenderGraphResource]int) {
+	if _, ok := g.transient[r]; !ok {
+		return
+	}
+	if _, ok := first[r]; !ok {
+		first[r] = i
+	}
+	last[r] = i
+}
+
+func (g *RenderGraph) acquire(desc RenderGraphTextureDesc) *Texture2D {
+	if textures := g.pool[desc]; len(textures) != 0 {
+		t := textures[len(textures)-1]
+		g.pool[desc] = textures[:len(textures)-1]
+
+		return t
+	}
+
+	t := NewTexture2D(desc.Size, desc.Format)
+	t.Alloc()
+
+	return t
+}
@@ -36,16 +36,28 @@ type RenderBuffer struct {
 	size           math.IVec2
 	reference      uint32
 	internalFormat uint32
+	samples        int32
 }
 
 func NewRenderBuffer(size math.IVec2, format TextureFormat) *RenderBuffer {
 	return NewRenderBufferIntFmt(size, uint32(TextureFormatToInternal(format)))
 }
 
+// NewRenderBufferMultisample creates a multisampled render buffer. Sample
+// counts below two create a regular render buffer.
+func NewRenderBufferMultisample(size math.IVec2, format TextureFormat, samples int32) *RenderBuffer {
+	return newRenderBuffer(size, uint32(TextureFormatToInternal(format)), ClampSamples(samples))
+}
+
 func NewRenderBufferIntFmt(size math.IVec2, internalFormat uint32) *RenderBuffer {
+	return newRenderBuffer(size, internalFormat, 0)
+}
+
+func newRenderBuffer(size math.IVec2, internalFormat uint32, samples int32) *RenderBuffer {
 	r := &RenderBuffer{
 		size:           size,
 		internalFormat: internalFormat,
+		samples:        samples,
 	}
 
 	r.SetName("RenderBuffer")
@@ -71,7 +83,11 @@ func (r *RenderBuffer) Reference() uint32 {
 
 func (r *RenderBuffer) Allocate() {
 	gl.BindRenderbuffer(gl.RENDERBUFFER, r.reference)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, r.internalFormat, r.size.X(), r.size.Y())
+	if r.samples > 1 {
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, r.samples, r.internalFormat, r.size.X(), r.size.Y())
+	} else {
+		gl.RenderbufferStorage(gl.RENDERBUFFER, r.internalFormat, r.size.X(), r.size.Y())
+	}
 }
 
 func (r *RenderBuffer) Attach(location uint32) {
@@ -83,6 +99,12 @@ func (r *RenderBuffer) SetSize(size math.IVec2) {
 	r.Allocate()
 }
 
+// Samples returns the number of samples per pixel, or zero if the render
+// buffer is not multisampled.
+func (r *RenderBuffer) Samples() int32 {
+	return r.samples
+}
+
 func (r *RenderBuffer) Size() math.IVec2 {
 	return r.size
 }
@@ -0,0 +1,142 @@
+//go:build linux && cgo
+// +build linux,cgo
+
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+/*
+#cgo LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdint.h>
+#include <stddef.h>
+#include <stdlib.h>
+
+// RENDERDOC_API_1_1_2, only the entries used are typed.
+typedef struct {
+	void *GetAPIVersion;
+	void *SetCaptureOptionU32;
+	void *SetCaptureOptionF32;
+	void *GetCaptureOptionU32;
+	void *GetCaptureOptionF32;
+	void *SetFocusToggleKeys;
+	void *SetCaptureKeys;
+	void *GetOverlayBits;
+	void *MaskOverlayBits;
+	void *Shutdown;
+	void *UnloadCrashHandler;
+	void (*SetCaptureFilePathTemplate)(const char *pathtemplate);
+	void *GetCaptureFilePathTemplate;
+	uint32_t (*GetNumCaptures)(void);
+	void *GetCapture;
+	void *TriggerCapture;
+	void *IsTargetControlConnected;
+	void *LaunchReplayUI;
+	void *SetActiveWindow;
+	void (*StartFrameCapture)(void *device, void *window);
+	void *IsFrameCapturing;
+	uint32_t (*EndFrameCapture)(void *device, void *window);
+	void *TriggerMultiFrameCapture;
+} arc_renderdoc_api;
+
+typedef int (*arc_renderdoc_get_api)(int version, void **api);
+
+static arc_renderdoc_api *arc_renderdoc_load(void) {
+	void *lib = dlopen("librenderdoc.so", RTLD_NOW | RTLD_NOLOAD);
+	if (lib == NULL) {
+		return NULL;
+	}
+
+	arc_renderdoc_get_api get = (arc_renderdoc_get_api)dlsym(lib, "RENDERDOC_GetAPI");
+	if (get == NULL) {
+		return NULL;
+	}
+
+	void *api = NULL;
+	if (get(10102, &api) != 1) {
+		return NULL;
+	}
+
+	return (arc_renderdoc_api *)api;
+}
+
+static void arc_renderdoc_start(arc_renderdoc_api *api) {
+	api->StartFrameCapture(NULL, NULL);
+}
+
+static uint32_t arc_renderdoc_end(arc_renderdoc_api *api) {
+	return api->EndFrameCapture(NULL, NULL);
+}
+
+static void arc_renderdoc_set_path(arc_renderdoc_api *api, const char *path) {
+	api->SetCaptureFilePathTemplate(path);
+}
+
+static uint32_t arc_renderdoc_num_captures(arc_renderdoc_api *api) {
+	return api->GetNumCaptures();
+}
+*/
+import "C"
+
+import "unsafe"
+
+var _ CaptureTool = &renderDoc{}
+
+// renderDoc uses the in-application API of RenderDoc. The library is only
+// used if it was injected into the process by RenderDoc.
+type renderDoc struct {
+	api *C.arc_renderdoc_api
+}
+
+func loadRenderDoc() CaptureTool {
+	api := C.arc_renderdoc_load()
+	if api == nil {
+		return nil
+	}
+
+	return &renderDoc{api: api}
+}
+
+func (r *renderDoc) Name() string {
+	return "RenderDoc"
+}
+
+func (r *renderDoc) StartFrameCapture() {
+	C.arc_renderdoc_start(r.api)
+}
+
+func (r *renderDoc) EndFrameCapture() bool {
+	return C.arc_renderdoc_end(r.api) == 1
+}
+
+func (r *renderDoc) SetCapturePath(template string) {
+	path := C.CString(template)
+	defer C.free(unsafe.Pointer(path))
+
+	C.arc_renderdoc_set_path(r.api, path)
+}
+
+func (r *renderDoc) NumCaptures() int {
+	return int(C.arc_renderdoc_num_captures(r.api))
+}
@@ -0,0 +1,227 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// FilterMode selects how textures are filtered when sampled.
+type FilterMode uint8
+
+const (
+	// FilterNearest samples the nearest texel of the nearest mip level.
+	FilterNearest FilterMode = iota
+
+	// FilterBilinear interpolates texels within the nearest mip level.
+	FilterBilinear
+
+	// FilterTrilinear interpolates texels and mip levels.
+	FilterTrilinear
+)
+
+// WrapMode selects how texture coordinates outside [0, 1] are handled.
+type WrapMode uint8
+
+const (
+	WrapRepeat WrapMode = iota
+	WrapClamp
+	WrapMirror
+	WrapBorder
+)
+
+var _ Sampler = &glSampler{}
+
+// maxAnisotropy caches the anisotropy limit of the driver.
+var maxAnisotropy float32
+
+// MaxAnisotropy returns the highest anisotropy level supported by the driver.
+func MaxAnisotropy() float32 {
+	if maxAnisotropy == 0 {
+		gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &maxAnisotropy)
+		if maxAnisotropy < 1 {
+			maxAnisotropy = 1
+		}
+	}
+
+	return maxAnisotropy
+}
+
+// Sampler holds sampling state separately from textures. A sampler bound to
+// a texture unit overrides the sampling state of the texture bound to it, so
+// one texture can be sampled in different ways.
+type Sampler interface {
+	core.Object
+
+	Reference() uint32
+
+	// Bind binds the sampler to a texture unit.
+	Bind(unit uint32)
+
+	// Unbind restores the sampling state of the texture bound to a unit.
+	Unbind(unit uint32)
+
+	FilterMode() FilterMode
+	SetFilterMode(mode FilterMode)
+	WrapMode() WrapMode
+	SetWrapMode(mode WrapMode)
+	Anisotropy() float32
+
+	// SetAnisotropy sets the anisotropic filtering level, clamped between 1
+	// (off) and MaxAnisotropy.
+	SetAnisotropy(level float32)
+}
+
+// NewSampler creates a sampler on the active device, with trilinear
+// filtering and repeating coordinates.
+func NewSampler() Sampler {
+	return activeDevice.NewSampler()
+}
+
+// glSampler is a sampler object of the OpenGL device.
+type glSampler struct {
+	core.BaseObject
+
+	reference  uint32
+	filter     FilterMode
+	wrap       WrapMode
+	anisotropy float32
+}
+
+func newGLSampler() *glSampler {
+	s := &glSampler{
+		filter:     FilterTrilinear,
+		wrap:       WrapRepeat,
+		anisotropy: 1,
+	}
+
+	s.SetName("Sampler")
+	instance.MustAssign(s)
+
+	return s
+}
+
+func (s *glSampler) Alloc() error {
+	if s.reference != 0 {
+		return nil
+	}
+
+	gl.GenSamplers(1, &s.reference)
+
+	s.SetFilterMode(s.filter)
+	s.SetWrapMode(s.wrap)
+	s.SetAnisotropy(s.anisotropy)
+
+	return nil
+}
+
+func (s *glSampler) Dealloc() {
+	if s.reference != 0 {
+		gl.DeleteSamplers(1, &s.reference)
+		s.reference = 0
+	}
+}
+
+func (s *glSampler) Reference() uint32 {
+	return s.reference
+}
+
+func (s *glSampler) Bind(unit uint32) {
+	gl.BindSampler(unit, s.reference)
+}
+
+func (s *glSampler) Unbind(unit uint32) {
+	gl.BindSampler(unit, 0)
+}
+
+func (s *glSampler) FilterMode() FilterMode {
+	return s.filter
+}
+
+func (s *glSampler) SetFilterMode(mode FilterMode) {
+	s.filter = mode
+
+	mag, min := glFilter(mode)
+	gl.SamplerParameteri(s.reference, gl.TEXTURE_MAG_FILTER, mag)
+	gl.SamplerParameteri(s.reference, gl.TEXTURE_MIN_FILTER, min)
+}
+
+func (s *glSampler) WrapMode() WrapMode {
+	return s.wrap
+}
+
+func (s *glSampler) SetWrapMode(mode WrapMode) {
+	s.wrap = mode
+
+	wrap := glWrap(mode)
+	gl.SamplerParameteri(s.reference, gl.TEXTURE_WRAP_R, wrap)
+	gl.SamplerParameteri(s.reference, gl.TEXTURE_WRAP_S, wrap)
+	gl.SamplerParameteri(s.reference, gl.TEXTURE_WRAP_T, wrap)
+}
+
+func (s *glSampler) Anisotropy() float32 {
+	return s.anisotropy
+}
+
+func (s *glSampler) SetAnisotropy(level float32) {
+	s.anisotropy = clampAnisotropy(level)
+	gl.SamplerParameterf(s.reference, gl.TEXTURE_MAX_ANISOTROPY, s.anisotropy)
+}
+
+func clampAnisotropy(level float32) float32 {
+	if level < 1 {
+		return 1
+	}
+	if max := MaxAnisotropy(); level > max {
+		return max
+	}
+
+	return level
+}
+
+func glFilter(mode FilterMode) (mag, min int32) {
+	switch mode {
+	case FilterNearest:
+		return gl.NEAREST, gl.NEAREST_MIPMAP_NEAREST
+	case FilterBilinear:
+		return gl.LINEAR, gl.LINEAR_MIPMAP_NEAREST
+	}
+
+	return gl.LINEAR, gl.LINEAR_MIPMAP_LINEAR
+}
+
+func glWrap(mode WrapMode) int32 {
+	switch mode {
+	case WrapClamp:
+		return gl.CLAMP_TO_EDGE
+	case WrapMirror:
+		return gl.MIRRORED_REPEAT
+	case WrapBorder:
+		return gl.CLAMP_TO_BORDER
+	}
+
+	return gl.REPEAT
+}
@@ -35,91 +35,195 @@ import (
 	"github.com/haakenlabs/arc/system/instance"
 )
 
+// ShaderComponent is a stage of a shader program.
 type ShaderComponent uint32
 
 const (
-	ShaderComponentVertex         ShaderComponent = gl.VERTEX_SHADER
-	ShaderComponentGeometry                       = gl.GEOMETRY_SHADER
-	ShaderComponentFragment                       = gl.FRAGMENT_SHADER
-	ShaderComponentCompute                        = gl.COMPUTE_SHADER
-	ShaderComponentTessControl                    = gl.TESS_CONTROL_SHADER
-	ShaderComponentTessEvaluation                 = gl.TESS_EVALUATION_SHADER
+	ShaderComponentVertex ShaderComponent = iota
+	ShaderComponentGeometry
+	ShaderComponentFragment
+	ShaderComponentCompute
+	ShaderComponentTessControl
+	ShaderComponentTessEvaluation
 )
 
-var _ core.Object = &Shader{}
+var _ Shader = &glShader{}
 
-type Shader struct {
+// Shader is a shader program. The source of every stage is held in one file,
+// with each stage enclosed in an #ifdef of its own define.
+type Shader interface {
+	core.Object
+
+	// AddData appends source to the shader. It takes effect on Build.
+	AddData(data []byte)
+
+	// Build compiles and links the program.
+	Build() error
+
+	// Reload replaces the source of the shader and builds it again.
+	Reload(data []byte) error
+
+	Reference() uint32
+	Bind()
+	Unbind()
+
+	// SetSubroutine selects the function called through the subroutine
+	// uniform of a shader component.
+	SetSubroutine(componentType ShaderComponent, subroutineName string)
+
+	// SetPass selects the pass function of the fragment shader.
+	SetPass(pass string)
+
+	SetUniform(uniformName string, value interface{})
+
+	// HasUniform reports if the program has an active uniform with the
+	// given name.
+	HasUniform(uniformName string) bool
+
+	// Compute reports if the shader is a compute program.
+	Compute() bool
+
+	// WorkGroupSize returns the local work group size declared by a compute
+	// shader.
+	WorkGroupSize() [3]int32
+
+	// Dispatch runs a compute shader over the given number of work groups.
+	Dispatch(x, y, z uint32)
+
+	// DispatchInvocations runs a compute shader over at least the given
+	// number of invocations.
+	DispatchInvocations(x, y, z uint32)
+
+	DeferredCapable() bool
+}
+
+// NewShader creates an empty shader program on the active device.
+func NewShader(deferred bool) Shader {
+	return activeDevice.NewShader(deferred)
+}
+
+// glShader is a program object of the OpenGL device.
+type glShader struct {
 	core.BaseObject
 
 	programId       uint32
 	components      map[ShaderComponent]uint32
+	subroutines     map[string]subroutineBinding
+	bindings        []layoutBinding
+	defaults        []uniformDefault
 	data            []byte
 	deferredCapable bool
+	compute         bool
 }
 
-func (s *Shader) Alloc() error {
+func (s *glShader) Alloc() error {
 	return s.Build()
 }
 
 // Dealloc releases builtin for this shader.
-func (s *Shader) Dealloc() {
+func (s *glShader) Dealloc() {
 	if s.programId != 0 {
 		for k := range s.components {
 			destroyComponent(s.components[k], s.programId)
 			delete(s.components, k)
 		}
 
+		currentState.forgetProgram(s.programId)
 		gl.DeleteProgram(s.programId)
 
 		s.programId = 0
 	}
 }
 
-func (s *Shader) AddData(newData []byte) {
+func (s *glShader) AddData(newData []byte) {
 	s.data = append(s.data, newData...)
 }
 
-func (s *Shader) Build() error {
+func (s *glShader) Build() error {
+	activeDevice.PushDebugGroup(s.Name())
+	defer activeDevice.PopDebugGroup()
+
+	data := s.data
+
+	if !Supported(FeatureSubroutines) {
+		var err error
+		if data, s.subroutines, err = lowerSubroutines(s.data); err != nil {
+			return err
+		}
+	}
+	if containsShaderType(ShaderComponentGeometry, data) && !Supported(FeatureGeometryShaders) {
+		return ErrProfileUnsupported("geometry shaders")
+	}
+	if containsShaderType(ShaderComponentCompute, data) && !Supported(FeatureCompute) {
+		return ErrProfileUnsupported("compute shaders")
+	}
+	if bytes.Contains(data, []byte("std430")) && !Supported(FeatureStorageBuffers) {
+		return ErrProfileUnsupported("storage buffers")
+	}
+	if (containsShaderType(ShaderComponentTessControl, data) || containsShaderType(ShaderComponentTessEvaluation, data)) && !Supported(FeatureTessellation) {
+		return ErrProfileUnsupported("tessellation shaders")
+	}
+	if !Supported(FeatureBindingLayouts) {
+		var err error
+		if data, s.bindings, s.defaults, err = lowerBindings(data); err != nil {
+			return err
+		}
+	}
+
+	// Programs loaded from the cache have no components, so the stages
+	// are taken from the source.
+	s.compute = containsShaderType(ShaderComponentCompute, data)
+
+	key := programCacheKey(data)
+	if s.loadProgramBinary(key) {
+		s.applyBindings()
+		objectLabel(gl.PROGRAM, s.programId, s.Name())
+		return nil
+	}
+
 	// Create Program ID
 	s.programId = gl.CreateProgram()
+	if key != "" {
+		gl.ProgramParameteri(s.programId, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
 
-	if containsShaderType(ShaderComponentVertex, s.data) {
-		componentId, err := loadComponent(s.programId, ShaderComponentVertex, s.data)
+	if containsShaderType(ShaderComponentVertex, data) {
+		componentId, err := loadComponent(s.programId, ShaderComponentVertex, data)
 		if err != nil {
 			return err
 		}
 		s.components[ShaderComponentVertex] = componentId
 	}
-	if containsShaderType(ShaderComponentGeometry, s.data) {
-		componentId, err := loadComponent(s.programId, ShaderComponentGeometry, s.data)
+	if containsShaderType(ShaderComponentGeometry, data) {
+		componentId, err := loadComponent(s.programId, ShaderComponentGeometry, data)
 		if err != nil {
 			return err
 		}
 		s.components[ShaderComponentGeometry] = componentId
 	}
-	if containsShaderType(ShaderComponentFragment, s.data) {
-		componentId, err := loadComponent(s.programId, ShaderComponentFragment, s.data)
+	if containsShaderType(ShaderComponentFragment, data) {
+		componentId, err := loadComponent(s.programId, ShaderComponentFragment, data)
 		if err != nil {
 			return err
 		}
 		s.components[ShaderComponentFragment] = componentId
 	}
-	if containsShaderType(ShaderComponentCompute, s.data) {
-		componentId, err := loadComponent(s.programId, ShaderComponentCompute, s.data)
+	if containsShaderType(ShaderComponentCompute, data) {
+		componentId, err := loadComponent(s.programId, ShaderComponentCompute, data)
 		if err != nil {
 			return err
 		}
 		s.components[ShaderComponentCompute] = componentId
 	}
-	if containsShaderType(ShaderComponentTessControl, s.data) {
-		componentId, err := loadComponent(s.programId, ShaderComponentTessControl, s.data)
+	if containsShaderType(ShaderComponentTessControl, data) {
+		componentId, err := loadComponent(s.programId, ShaderComponentTessControl, data)
 		if err != nil {
 			return err
 		}
 		s.components[ShaderComponentTessControl] = componentId
 	}
-	if containsShaderType(ShaderComponentTessEvaluation, s.data) {
-		componentId, err := loadComponent(s.programId, ShaderComponentTessEvaluation, s.data)
+	if containsShaderType(ShaderComponentTessEvaluation, data) {
+		componentId, err := loadComponent(s.programId, ShaderComponentTessEvaluation, data)
 		if err != nil {
 			return err
 		}
@@ -130,33 +234,107 @@ func (s *Shader) Build() error {
 	// TODO: Implement this
 
 	// Validate and link
-	err := Link(s.programId)
+	if err := Link(s.programId); err != nil {
+		return err
+	}
+
+	s.saveProgramBinary(key)
+	s.applyBindings()
 
-	return err
+	objectLabel(gl.PROGRAM, s.programId, s.Name())
+
+	return nil
 }
 
-func (s *Shader) ProgramId() uint32 {
+// applyBindings assigns the units of the samplers and uniform blocks, and
+// the initial values of the uniforms, which were removed from the source.
+func (s *glShader) applyBindings() {
+	if len(s.bindings) == 0 && len(s.defaults) == 0 {
+		return
+	}
+
+	prev := currentState.program
+	BindShader(s.programId)
+
+	for _, b := range s.bindings {
+		name := gl.Str(b.name + "\x00")
+
+		if b.block {
+			if index := gl.GetUniformBlockIndex(s.programId, name); index != gl.INVALID_INDEX {
+				gl.UniformBlockBinding(s.programId, index, uint32(b.unit))
+			}
+			continue
+		}
+
+		location := gl.GetUniformLocation(s.programId, name)
+		if location < 0 {
+			continue
+		}
+
+		units := make([]int32, b.count)
+		for i := range units {
+			units[i] = b.unit + int32(i)
+		}
+		gl.Uniform1iv(location, b.count, &units[0])
+	}
+
+	for _, d := range s.defaults {
+		if location := gl.GetUniformLocation(s.programId, gl.Str(d.name+"\x00")); location >= 0 {
+			d.apply(location)
+		}
+	}
+
+	BindShader(prev)
+}
+
+// SetName sets the name of the shader, which also labels its program in
+// frame capture tools.
+func (s *glShader) SetName(name string) {
+	s.BaseObject.SetName(name)
+	objectLabel(gl.PROGRAM, s.programId, name)
+}
+
+func (s *glShader) ProgramId() uint32 {
 	return s.programId
 }
 
-func (s *Shader) Reference() uint32 {
+func (s *glShader) Reference() uint32 {
 	return s.programId
 }
 
-func (s *Shader) Bind() {
+func (s *glShader) Bind() {
 	BindShader(s.programId)
 }
 
-func (s *Shader) Unbind() {
+func (s *glShader) Unbind() {
 	UnbindShader()
 }
 
-func (s *Shader) SetSubroutine(componentType ShaderComponent, subroutineName string) {
-	idx := gl.GetSubroutineIndex(s.programId, uint32(componentType), gl.Str(subroutineName+"\x00"))
-	gl.UniformSubroutinesuiv(uint32(componentType), 1, &idx)
+// SetSubroutine selects the function called through the subroutine uniform of
+// a shader component. Profiles without subroutine support select the function
+// through the uniforms generated when the shader was built.
+func (s *glShader) SetSubroutine(componentType ShaderComponent, subroutineName string) {
+	if !Supported(FeatureSubroutines) {
+		if b, ok := s.subroutines[subroutineName]; ok {
+			for _, u := range b.uniforms {
+				s.SetUniform(subroutineSelector(u), b.index)
+			}
+		}
+		return
+	}
+
+	idx := gl.GetSubroutineIndex(s.programId, glShaderType(componentType), gl.Str(subroutineName+"\x00"))
+	gl.UniformSubroutinesuiv(glShaderType(componentType), 1, &idx)
 }
 
-func (s *Shader) SetUniform(uniformName string, value interface{}) {
+// SetPass selects the pass function of the fragment shader. Pass functions
+// are declared as subroutines of a single subroutine uniform; profiles
+// without subroutine support select them through generated uniforms.
+func (s *glShader) SetPass(pass string) {
+	s.SetSubroutine(ShaderComponentFragment, pass)
+}
+
+func (s *glShader) SetUniform(uniformName string, value interface{}) {
 	switch v := value.(type) {
 	case bool:
 		var val int32
@@ -185,7 +363,57 @@ func (s *Shader) SetUniform(uniformName string, value interface{}) {
 	}
 }
 
-func (s *Shader) DeferredCapable() bool {
+// Compute reports if the shader is a compute program.
+func (s *glShader) Compute() bool {
+	return s.compute
+}
+
+// WorkGroupSize returns the local work group size declared by a compute
+// shader.
+func (s *glShader) WorkGroupSize() [3]int32 {
+	var size [3]int32
+
+	if s.Compute() {
+		gl.GetProgramiv(s.programId, gl.COMPUTE_WORK_GROUP_SIZE, &size[0])
+	}
+
+	return size
+}
+
+// Dispatch runs the bound compute shader with x, y and z work groups. Use
+// MemoryBarrier before reading the results of image or buffer stores.
+func (s *glShader) Dispatch(x, y, z uint32) {
+	if !s.Compute() {
+		logrus.Errorf("shader %d: dispatch of a shader without a compute stage", s.programId)
+		return
+	}
+
+	gl.DispatchCompute(x, y, z)
+}
+
+// DispatchInvocations runs the bound compute shader with enough work groups
+// for x, y and z invocations. Shaders must skip the invocations beyond the
+// requested counts.
+func (s *glShader) DispatchInvocations(x, y, z uint32) {
+	size := s.WorkGroupSize()
+
+	groups := func(n uint32, size int32) uint32 {
+		if size <= 0 {
+			return n
+		}
+		return (n + uint32(size) - 1) / uint32(size)
+	}
+
+	s.Dispatch(groups(x, size[0]), groups(y, size[1]), groups(z, size[2]))
+}
+
+// HasUniform reports if the shader program has an active uniform with the
+// given name.
+func (s *glShader) HasUniform(uniformName string) bool {
+	return gl.GetUniformLocation(s.programId, gl.Str(uniformName+"\x00")) >= 0
+}
+
+func (s *glShader) DeferredCapable() bool {
 	return s.deferredCapable
 }
 
@@ -229,11 +457,11 @@ func ValidateProgram(programId uint32) error {
 }
 
 func BindShader(programId uint32) {
-	gl.UseProgram(programId)
+	currentState.UseProgram(programId)
 }
 
 func UnbindShader() {
-	gl.UseProgram(0)
+	currentState.UseProgram(0)
 }
 
 func destroyComponent(componentId uint32, programId uint32) {
@@ -261,7 +489,36 @@ func containsShaderType(shaderType ShaderComponent, data []byte) bool {
 }
 
 func loadComponent(programId uint32, componentType ShaderComponent, data []byte) (uint32, error) {
-	header := []byte("#version 430\n")
+	data, err := componentSource(activeProfile, componentType, data)
+	if err != nil {
+		return 0, err
+	}
+
+	componentId := gl.CreateShader(glShaderType(componentType))
+
+	csrc, free := gl.Strs(string(data))
+	srcLength := int32(len(data))
+	gl.ShaderSource(componentId, 1, csrc, &srcLength)
+	free()
+	gl.CompileShader(componentId)
+
+	err = ValidateComponent(componentId)
+	if err != nil {
+		fmt.Println(string(data))
+		return 0, err
+	}
+
+	gl.AttachShader(programId, componentId)
+
+	logrus.Debugf("Loaded component(%s) %d for program %d", ShaderComponentToString(componentType), componentId, programId)
+
+	return componentId, nil
+}
+
+// componentSource returns the source of one component of a shader, with the
+// header of the profile.
+func componentSource(profile Profile, componentType ShaderComponent, data []byte) ([]byte, error) {
+	header := []byte(profile.ShaderHeader())
 
 	switch componentType {
 	case ShaderComponentVertex:
@@ -277,30 +534,28 @@ func loadComponent(programId uint32, componentType ShaderComponent, data []byte)
 	case ShaderComponentTessEvaluation:
 		header = append(header, []byte("#define _TESSEVAL_\n")...)
 	default:
-		return 0, fmt.Errorf("loadComponent failed: unknown component type: %d", componentType)
+		return nil, fmt.Errorf("loadComponent failed: unknown component type: %d", componentType)
 	}
 
-	data = append(header, data...)
-
-	componentId := gl.CreateShader(uint32(componentType))
-
-	csrc, free := gl.Strs(string(data))
-	srcLength := int32(len(data))
-	gl.ShaderSource(componentId, 1, csrc, &srcLength)
-	free()
-	gl.CompileShader(componentId)
+	return append(header, data...), nil
+}
 
-	err := ValidateComponent(componentId)
-	if err != nil {
-		fmt.Println(string(data))
-		return 0, err
+// glShaderType returns the OpenGL shader type of a shader component.
+func glShaderType(component ShaderComponent) uint32 {
+	switch component {
+	case ShaderComponentGeometry:
+		return gl.GEOMETRY_SHADER
+	case ShaderComponentFragment:
+		return gl.FRAGMENT_SHADER
+	case ShaderComponentCompute:
+		return gl.COMPUTE_SHADER
+	case ShaderComponentTessControl:
+		return gl.TESS_CONTROL_SHADER
+	case ShaderComponentTessEvaluation:
+		return gl.TESS_EVALUATION_SHADER
 	}
 
-	gl.AttachShader(programId, componentId)
-
-	logrus.Debugf("Loaded component(%s) %d for program %d", ShaderComponentToString(componentType), componentId, programId)
-
-	return componentId, nil
+	return gl.VERTEX_SHADER
 }
 
 // ShaderComponentToString returns the string representation of a core.ShaderComponent.
@@ -323,8 +578,8 @@ func ShaderComponentToString(component ShaderComponent) string {
 	return "INVALID"
 }
 
-func NewShader(deferred bool) *Shader {
-	s := &Shader{
+func newGLShader(deferred bool) *glShader {
+	s := &glShader{
 		components:      make(map[ShaderComponent]uint32),
 		deferredCapable: deferred,
 	}
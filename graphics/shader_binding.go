@@ -0,0 +1,223 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+var (
+	reLayoutBinding  = regexp.MustCompile(`layout\s*\(([^)]*)\)\s*(uniform\s+(?:(?:lowp|mediump|highp)\s+)?(\w+)(?:\s+(\w+)\s*(?:\[\s*(\d+)\s*\])?)?)`)
+	reUniformDefault = regexp.MustCompile(`(uniform\s+(?:(?:lowp|mediump|highp)\s+)?(\w+)\s+(\w+))\s*=\s*([^;]+);`)
+	reUniformType    = regexp.MustCompile(`^(?:float|int|uint|bool|[iub]?vec[234])$`)
+)
+
+// layoutBinding is a sampler or uniform block whose unit was assigned by a
+// layout qualifier in the source.
+type layoutBinding struct {
+	name  string
+	unit  int32
+	count int32
+	block bool
+}
+
+// uniformDefault is the initial value a uniform was given in the source.
+type uniformDefault struct {
+	name   string
+	kind   string
+	values []float64
+}
+
+// lowerBindings removes binding layout qualifiers from samplers and uniform
+// blocks, and initializers from uniforms, for profiles which cannot declare
+// them in the source. The returned bindings and defaults are assigned to the
+// program once it is linked.
+func lowerBindings(data []byte) ([]byte, []layoutBinding, []uniformDefault, error) {
+	src := string(data)
+
+	var bindings []layoutBinding
+	var defaults []uniformDefault
+	var edits []sourceEdit
+
+	for _, m := range reLayoutBinding.FindAllStringSubmatchIndex(src, -1) {
+		var unit int64 = -1
+		var keep []string
+
+		for _, q := range strings.Split(src[m[2]:m[3]], ",") {
+			kv := strings.SplitN(q, "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "binding" {
+				if v, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 32); err == nil {
+					unit = v
+					continue
+				}
+			}
+			keep = append(keep, strings.TrimSpace(q))
+		}
+		if unit < 0 {
+			continue
+		}
+
+		b := layoutBinding{unit: int32(unit), count: 1}
+		if m[8] < 0 {
+			// A uniform block, named by the first word.
+			b.name = src[m[6]:m[7]]
+			b.block = true
+		} else {
+			b.name = src[m[8]:m[9]]
+			if m[10] >= 0 {
+				count, _ := strconv.ParseInt(src[m[10]:m[11]], 10, 32)
+				b.count = int32(count)
+			}
+		}
+		bindings = append(bindings, b)
+
+		layout := ""
+		if len(keep) > 0 {
+			layout = "layout(" + strings.Join(keep, ", ") + ") "
+		}
+		edits = append(edits, sourceEdit{m[0], m[4], layout})
+	}
+
+	for _, m := range reUniformDefault.FindAllStringSubmatchIndex(src, -1) {
+		d, err := parseUniformDefault(src[m[4]:m[5]], src[m[6]:m[7]], src[m[8]:m[9]])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		defaults = append(defaults, d)
+
+		edits = append(edits, sourceEdit{m[3], m[1], ";"})
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+
+	return []byte(src), bindings, defaults, nil
+}
+
+// parseUniformDefault parses the initializer of a scalar or vector uniform,
+// which must be a literal or a constructor of literals.
+func parseUniformDefault(kind, name, value string) (uniformDefault, error) {
+	d := uniformDefault{name: name, kind: kind}
+
+	if !reUniformType.MatchString(kind) {
+		return d, fmt.Errorf("lowerBindings failed: unsupported uniform type: %s %s", kind, name)
+	}
+
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, kind+"(") && strings.HasSuffix(value, ")") {
+		value = value[len(kind)+1 : len(value)-1]
+	}
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimRight(strings.TrimSpace(v), "fFuU")
+
+		switch v {
+		case "true":
+			d.values = append(d.values, 1)
+		case "false":
+			d.values = append(d.values, 0)
+		default:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return d, fmt.Errorf("lowerBindings failed: unsupported initializer of uniform: %s", name)
+			}
+			d.values = append(d.values, f)
+		}
+	}
+
+	size := 1
+	if strings.Contains(kind, "vec") {
+		size = int(kind[len(kind)-1] - '0')
+	}
+	if len(d.values) == 1 {
+		for len(d.values) < size {
+			d.values = append(d.values, d.values[0])
+		}
+	}
+	if len(d.values) != size {
+		return d, fmt.Errorf("lowerBindings failed: unsupported initializer of uniform: %s", name)
+	}
+
+	return d, nil
+}
+
+// apply sets the uniform at location of the bound program to the default.
+func (d uniformDefault) apply(location int32) {
+	switch d.kind[0] {
+	case 'u':
+		v := make([]uint32, len(d.values))
+		for i := range v {
+			v[i] = uint32(d.values[i])
+		}
+		switch len(v) {
+		case 1:
+			gl.Uniform1uiv(location, 1, &v[0])
+		case 2:
+			gl.Uniform2uiv(location, 1, &v[0])
+		case 3:
+			gl.Uniform3uiv(location, 1, &v[0])
+		case 4:
+			gl.Uniform4uiv(location, 1, &v[0])
+		}
+	case 'i', 'b':
+		v := make([]int32, len(d.values))
+		for i := range v {
+			v[i] = int32(d.values[i])
+		}
+		switch len(v) {
+		case 1:
+			gl.Uniform1iv(location, 1, &v[0])
+		case 2:
+			gl.Uniform2iv(location, 1, &v[0])
+		case 3:
+			gl.Uniform3iv(location, 1, &v[0])
+		case 4:
+			gl.Uniform4iv(location, 1, &v[0])
+		}
+	default:
+		v := make([]float32, len(d.values))
+		for i := range v {
+			v[i] = float32(d.values[i])
+		}
+		switch len(v) {
+		case 1:
+			gl.Uniform1fv(location, 1, &v[0])
+		case 2:
+			gl.Uniform2fv(location, 1, &v[0])
+		case 3:
+			gl.Uniform3fv(location, 1, &v[0])
+		case 4:
+			gl.Uniform4fv(location, 1, &v[0])
+		}
+	}
+}
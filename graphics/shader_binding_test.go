@@ -0,0 +1,212 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLowerBindings(t *testing.T) {
+	tests := []struct {
+		src      string
+		out      string
+		bindings []layoutBinding
+		defaults []uniformDefault
+	}{
+		{
+			src: "layout(binding = 3) uniform sampler2D tex;",
+			out: "uniform sampler2D tex;",
+			bindings: []layoutBinding{
+				{name: "tex", unit: 3, count: 1},
+			},
+		},
+		{
+			src: "layout(binding=1) uniform highp usampler2D ids[4];",
+			out: "uniform highp usampler2D ids[4];",
+			bindings: []layoutBinding{
+				{name: "ids", unit: 1, count: 4},
+			},
+		},
+		{
+			src: "layout(std140, binding = 2) uniform CameraBlock {",
+			out: "layout(std140) uniform CameraBlock {",
+			bindings: []layoutBinding{
+				{name: "CameraBlock", unit: 2, count: 1, block: true},
+			},
+		},
+		{
+			src: "layout(location = 0) out vec4 color;",
+			out: "layout(location = 0) out vec4 color;",
+		},
+		{
+			src: "layout(std140) uniform LightBlock {",
+			out: "layout(std140) uniform LightBlock {",
+		},
+		{
+			src: "uniform float f_knee = 0.5;\nuniform uint f_samples = 1024u;",
+			out: "uniform float f_knee;\nuniform uint f_samples;",
+			defaults: []uniformDefault{
+				{name: "f_knee", kind: "float", values: []float64{0.5}},
+				{name: "f_samples", kind: "uint", values: []float64{1024}},
+			},
+		},
+		{
+			src: "uniform vec3 lum_factor = vec3(0.2126, 0.7152, 0.0722);",
+			out: "uniform vec3 lum_factor;",
+			defaults: []uniformDefault{
+				{name: "lum_factor", kind: "vec3", values: []float64{0.2126, 0.7152, 0.0722}},
+			},
+		},
+		{
+			src: "uniform highp vec2 scale = vec2(2.0f);",
+			out: "uniform highp vec2 scale;",
+			defaults: []uniformDefault{
+				{name: "scale", kind: "vec2", values: []float64{2, 2}},
+			},
+		},
+	}
+
+	for i, v := range tests {
+		out, bindings, defaults, err := lowerBindings([]byte(v.src))
+		if err != nil {
+			t.Errorf("lowerBindings case %d failed. error: %v", i, err)
+			continue
+		}
+
+		if string(out) != v.out {
+			t.Errorf("lowerBindings case %d failed. want: %q got: %q", i, v.out, out)
+		}
+		if !reflect.DeepEqual(bindings, v.bindings) {
+			t.Errorf("lowerBindings case %d failed. want: %v got: %v", i, v.bindings, bindings)
+		}
+		if !reflect.DeepEqual(defaults, v.defaults) {
+			t.Errorf("lowerBindings case %d failed. want: %v got: %v", i, v.defaults, defaults)
+		}
+	}
+}
+
+func TestLowerBindings_Unsupported(t *testing.T) {
+	tests := []string{
+		"uniform float c_particle_inv_mass = 1.0 / 0.1;",
+		"uniform mat4 m = mat4(1.0);",
+		"uniform vec3 v = vec3(1.0, 2.0);",
+	}
+
+	for i, v := range tests {
+		if _, _, _, err := lowerBindings([]byte(v)); err == nil {
+			t.Errorf("lowerBindings case %d failed. want: error got: nil", i)
+		}
+	}
+}
+
+var (
+	reBindingQualifier = regexp.MustCompile(`layout\s*\([^)]*binding`)
+	reSamplerType      = regexp.MustCompile(`\b[iu]?sampler\w+`)
+)
+
+// TestBuiltinShaders_ES prepares the sources of the builtin shaders the ES
+// profile builds, and checks they use no qualifiers or sampler types GLSL ES
+// 3.00 rejects.
+func TestBuiltinShaders_ES(t *testing.T) {
+	root := filepath.Join("..", "internal", "builtin", "assets", "shaders")
+	header := ProfileES.ShaderHeader()
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || filepath.Ext(path) != ".shader" {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var m struct {
+			Files []string `json:"files"`
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+
+		var src []byte
+		for _, f := range m.Files {
+			b, err := ioutil.ReadFile(filepath.Join(filepath.Dir(path), f))
+			if err != nil {
+				return err
+			}
+			src = append(src, b...)
+		}
+
+		if containsShaderType(ShaderComponentGeometry, src) ||
+			containsShaderType(ShaderComponentCompute, src) ||
+			containsShaderType(ShaderComponentTessControl, src) ||
+			containsShaderType(ShaderComponentTessEvaluation, src) ||
+			bytes.Contains(src, []byte("std430")) {
+			return nil
+		}
+
+		if src, _, err = lowerSubroutines(src); err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		if src, _, _, err = lowerBindings(src); err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+
+		for _, c := range []ShaderComponent{ShaderComponentVertex, ShaderComponentFragment} {
+			if !containsShaderType(c, src) {
+				continue
+			}
+
+			out, err := componentSource(ProfileES, c, src)
+			if err != nil {
+				return err
+			}
+			if reBindingQualifier.Match(out) {
+				t.Errorf("%s: %s component has binding qualifiers", path, ShaderComponentToString(c))
+			}
+			if reUniformDefault.Match(out) {
+				t.Errorf("%s: %s component has uniform initializers", path, ShaderComponentToString(c))
+			}
+			for _, s := range reSamplerType.FindAllString(string(out), -1) {
+				if !strings.Contains(header, "precision highp "+s+";") {
+					t.Errorf("%s: %s component uses %s, which has no precision", path, ShaderComponentToString(c), s)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
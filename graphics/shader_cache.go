@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/sirupsen/logrus"
+)
+
+// programCacheDir is the directory compiled program binaries are cached in.
+// The cache is disabled if it is empty.
+var programCacheDir string
+
+// driverString identifies the driver program binaries were created with.
+var driverString string
+
+// SetProgramCache sets the directory compiled shader programs are cached in,
+// so later runs load the binaries instead of compiling the sources. Binaries
+// are keyed by their source and the driver, so updating either invalidates
+// them. An empty directory disables the cache.
+func SetProgramCache(dir string) {
+	programCacheDir = dir
+}
+
+// ProgramCache returns the directory compiled shader programs are cached in.
+func ProgramCache() string {
+	return programCacheDir
+}
+
+// programCacheKey returns the key of a program binary built from source, or
+// an empty string if binaries cannot be cached.
+func programCacheKey(data []byte) string {
+	if programCacheDir == "" {
+		return ""
+	}
+	if capabilities != nil && !capabilities.ProgramBinary {
+		return ""
+	}
+
+	if driverString == "" {
+		var formats int32
+		gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &formats)
+		if formats == 0 {
+			logrus.Warn("graphics: driver supports no program binary formats, disabling program cache")
+			programCacheDir = ""
+			return ""
+		}
+
+		driverString = gl.GoStr(gl.GetString(gl.VENDOR)) + "\n" +
+			gl.GoStr(gl.GetString(gl.RENDERER)) + "\n" +
+			gl.GoStr(gl.GetString(gl.VERSION))
+	}
+
+	h := sha256.New()
+	h.Write([]byte(driverString))
+	h.Write([]byte(activeProfile.ShaderHeader()))
+	h.Write(data)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func programCachePath(key string) string {
+	return filepath.Join(programCacheDir, key+".bin")
+}
+
+// loadProgramBinary creates the program of the shader from a cached binary.
+// It returns false if there is no usable binary for the key.
+func (s *glShader) loadProgramBinary(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	data, err := ioutil.ReadFile(programCachePath(key))
+	if err != nil || len(data) <= 4 {
+		return false
+	}
+
+	format := binary.LittleEndian.Uint32(data)
+	binaryData := data[4:]
+
+	program := gl.CreateProgram()
+	gl.ProgramBinary(program, format, gl.Ptr(binaryData), int32(len(binaryData)))
+
+	// Drivers reject binaries they no longer understand, the program is
+	// then compiled from source and the binary replaced.
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		gl.DeleteProgram(program)
+		return false
+	}
+
+	s.programId = program
+
+	return true
+}
+
+// saveProgramBinary writes the binary of the linked program of the shader to
+// the cache.
+func (s *glShader) saveProgramBinary(key string) {
+	if key == "" {
+		return
+	}
+
+	var length int32
+	gl.GetProgramiv(s.programId, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length == 0 {
+		return
+	}
+
+	data := make([]byte, 4+length)
+
+	var format uint32
+	gl.GetProgramBinary(s.programId, length, nil, &format, gl.Ptr(data[4:]))
+	binary.LittleEndian.PutUint32(data, format)
+
+	if err := os.MkdirAll(programCacheDir, 0755); err != nil {
+		logrus.Warnf("graphics: program cache: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(programCachePath(key), data, 0644); err != nil {
+		logrus.Warnf("graphics: program cache: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// uniformKind is the base type of a uniform which can be carried over when a
+// shader is reloaded.
+type uniformKind uint8
+
+const (
+	uniformOther uniformKind = iota
+	uniformFloat
+	uniformInt
+	uniformUint
+)
+
+type uniformInfo struct {
+	xtype uint32
+	size  int32
+}
+
+// Reload replaces the source of the shader and builds it again. The program
+// is only swapped when the new source builds, so a broken edit keeps the old
+// program running. Values of uniforms which exist in both programs with the
+// same type are carried over.
+func (s *glShader) Reload(data []byte) error {
+	program, components := s.programId, s.components
+	oldData, subroutines, compute := s.data, s.subroutines, s.compute
+	bindings, defaults := s.bindings, s.defaults
+
+	s.data = data
+	s.programId = 0
+	s.components = make(map[ShaderComponent]uint32)
+
+	if err := s.Build(); err != nil {
+		s.Dealloc()
+
+		s.programId, s.components = program, components
+		s.data, s.subroutines, s.compute = oldData, subroutines, compute
+		s.bindings, s.defaults = bindings, defaults
+
+		return err
+	}
+
+	// Uniforms are copied with glProgramUniform, which needs OpenGL 4.1.
+	if capabilities == nil || capabilities.AtLeast(4, 1) {
+		copyUniforms(program, s.programId)
+	}
+
+	for k := range components {
+		destroyComponent(components[k], program)
+	}
+	currentState.forgetProgram(program)
+	gl.DeleteProgram(program)
+
+	return nil
+}
+
+// copyUniforms copies the values of the default block uniforms of one
+// program to the uniforms of the same name and type of another.
+func copyUniforms(from, to uint32) {
+	dst := activeUniforms(to)
+
+	for name, u := range activeUniforms(from) {
+		if d, ok := dst[name]; !ok || d.xtype != u.xtype {
+			continue
+		}
+
+		kind, count := uniformLayout(u.xtype)
+		if kind == uniformOther {
+			continue
+		}
+
+		if u.size == 1 {
+			copyUniform(from, to, name, u.xtype, kind, count)
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "[0]")
+		for i := int32(0); i < u.size; i++ {
+			copyUniform(from, to, fmt.Sprintf("%s[%d]", base, i), u.xtype, kind, count)
+		}
+	}
+}
+
+func copyUniform(from, to uint32, name string, xtype uint32, kind uniformKind, count int) {
+	src := gl.GetUniformLocation(from, gl.Str(name+"\x00"))
+	dst := gl.GetUniformLocation(to, gl.Str(name+"\x00"))
+	if src < 0 || dst < 0 {
+		return
+	}
+
+	switch kind {
+	case uniformFloat:
+		var v [16]float32
+		gl.GetUniformfv(from, src, &v[0])
+
+		switch xtype {
+		case gl.FLOAT_MAT2:
+			gl.ProgramUniformMatrix2fv(to, dst, 1, false, &v[0])
+		case gl.FLOAT_MAT3:
+			gl.ProgramUniformMatrix3fv(to, dst, 1, false, &v[0])
+		case gl.FLOAT_MAT4:
+			gl.ProgramUniformMatrix4fv(to, dst, 1, false, &v[0])
+		default:
+			programUniformfv(to, dst, count, v[:])
+		}
+	case uniformInt:
+		var v [4]int32
+		gl.GetUniformiv(from, src, &v[0])
+
+		switch count {
+		case 1:
+			gl.ProgramUniform1iv(to, dst, 1, &v[0])
+		case 2:
+			gl.ProgramUniform2iv(to, dst, 1, &v[0])
+		case 3:
+			gl.ProgramUniform3iv(to, dst, 1, &v[0])
+		default:
+			gl.ProgramUniform4iv(to, dst, 1, &v[0])
+		}
+	case uniformUint:
+		var v [4]uint32
+		gl.GetUniformuiv(from, src, &v[0])
+
+		switch count {
+		case 1:
+			gl.ProgramUniform1uiv(to, dst, 1, &v[0])
+		case 2:
+			gl.ProgramUniform2uiv(to, dst, 1, &v[0])
+		case 3:
+			gl.ProgramUniform3uiv(to, dst, 1, &v[0])
+		default:
+			gl.ProgramUniform4uiv(to, dst, 1, &v[0])
+		}
+	}
+}
+
+func programUniformfv(program uint32, location int32, count int, v []float32) {
+	switch count {
+	case 1:
+		gl.ProgramUniform1fv(program, location, 1, &v[0])
+	case 2:
+		gl.ProgramUniform2fv(program, location, 1, &v[0])
+	case 3:
+		gl.ProgramUniform3fv(program, location, 1, &v[0])
+	default:
+		gl.ProgramUniform4fv(program, location, 1, &v[0])
+	}
+}
+
+// activeUniforms returns the active uniforms of a program by name. Array
+// uniforms are named after their first element.
+func activeUniforms(program uint32) map[string]uniformInfo {
+	var count, maxLength int32
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+
+	uniforms := make(map[string]uniformInfo, count)
+	if maxLength == 0 {
+		return uniforms
+	}
+
+	buf := make([]uint8, maxLength)
+
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+
+		gl.GetActiveUniform(program, uint32(i), maxLength, &length, &size, &xtype, &buf[0])
+		uniforms[string(buf[:length])] = uniformInfo{xtype: xtype, size: size}
+	}
+
+	return uniforms
+}
+
+// uniformLayout returns the base type and component count of a uniform type.
+// Samplers and images are not carried over, their units are set through
+// layout qualifiers.
+func uniformLayout(xtype uint32) (uniformKind, int) {
+	switch xtype {
+	case gl.FLOAT:
+		return uniformFloat, 1
+	case gl.FLOAT_VEC2:
+		return uniformFloat, 2
+	case gl.FLOAT_VEC3:
+		return uniformFloat, 3
+	case gl.FLOAT_VEC4, gl.FLOAT_MAT2:
+		return uniformFloat, 4
+	case gl.FLOAT_MAT3:
+		return uniformFloat, 9
+	case gl.FLOAT_MAT4:
+		return uniformFloat, 16
+	case gl.INT, gl.BOOL:
+		return uniformInt, 1
+	case gl.INT_VEC2, gl.BOOL_VEC2:
+		return uniformInt, 2
+	case gl.INT_VEC3, gl.BOOL_VEC3:
+		return uniformInt, 3
+	case gl.INT_VEC4, gl.BOOL_VEC4:
+		return uniformInt, 4
+	case gl.UNSIGNED_INT:
+		return uniformUint, 1
+	case gl.UNSIGNED_INT_VEC2:
+		return uniformUint, 2
+	case gl.UNSIGNED_INT_VEC3:
+		return uniformUint, 3
+	case gl.UNSIGNED_INT_VEC4:
+		return uniformUint, 4
+	}
+
+	return uniformOther, 0
+}
@@ -0,0 +1,192 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	reSubroutineType    = regexp.MustCompile(`(?m)^[ \t]*subroutine[ \t]+(\w+)[ \t]+(\w+)[ \t]*\(([^)]*)\)[ \t]*;`)
+	reSubroutineUniform = regexp.MustCompile(`(?m)^[ \t]*subroutine[ \t]+uniform[ \t]+(\w+)[ \t]+(\w+)[ \t]*;`)
+	reSubroutineImpl    = regexp.MustCompile(`subroutine\s*\(\s*(\w+)\s*\)\s*\w+\s+(\w+)\s*\(`)
+)
+
+// subroutineBinding describes how to select a subroutine function when
+// subroutines are emulated.
+type subroutineBinding struct {
+	index    int32
+	uniforms []string
+}
+
+type subroutineType struct {
+	ret    string
+	params string
+	impls  []string
+	end    int
+}
+
+type sourceEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// subroutineSelector returns the name of the uniform selecting the function
+// called through an emulated subroutine uniform.
+func subroutineSelector(uniform string) string {
+	return "_arc_sub_" + uniform
+}
+
+// lowerSubroutines rewrites GLSL subroutines for profiles without
+// subroutine support. Each subroutine uniform becomes an int uniform and a
+// function of the same name which dispatches to the selected implementation.
+// The returned bindings map implementation names to their selector values.
+func lowerSubroutines(data []byte) ([]byte, map[string]subroutineBinding, error) {
+	src := string(data)
+	types := make(map[string]*subroutineType)
+	uniforms := make(map[string][]string)
+	bindings := make(map[string]subroutineBinding)
+
+	var edits []sourceEdit
+
+	for _, m := range reSubroutineType.FindAllStringSubmatchIndex(src, -1) {
+		types[src[m[4]:m[5]]] = &subroutineType{
+			ret:    src[m[2]:m[3]],
+			params: src[m[6]:m[7]],
+		}
+		edits = append(edits, sourceEdit{m[0], m[1], ""})
+	}
+
+	for _, m := range reSubroutineUniform.FindAllStringSubmatchIndex(src, -1) {
+		typeName, name := src[m[2]:m[3]], src[m[4]:m[5]]
+
+		t, ok := types[typeName]
+		if !ok {
+			return nil, nil, fmt.Errorf("lowerSubroutines failed: unknown subroutine type: %s", typeName)
+		}
+
+		uniforms[typeName] = append(uniforms[typeName], name)
+		edits = append(edits, sourceEdit{m[0], m[1], fmt.Sprintf("uniform int %s;\n%s %s(%s);", subroutineSelector(name), t.ret, name, t.params)})
+	}
+
+	for _, m := range reSubroutineImpl.FindAllStringSubmatchIndex(src, -1) {
+		typeName, name := src[m[2]:m[3]], src[m[4]:m[5]]
+
+		t, ok := types[typeName]
+		if !ok {
+			return nil, nil, fmt.Errorf("lowerSubroutines failed: unknown subroutine type: %s", typeName)
+		}
+
+		end := functionEnd(src, m[1])
+		if end < 0 {
+			return nil, nil, fmt.Errorf("lowerSubroutines failed: unterminated function: %s", name)
+		}
+
+		bindings[name] = subroutineBinding{index: int32(len(t.impls))}
+		t.impls = append(t.impls, name)
+		t.end = end
+
+		// Strip the subroutine qualifier, keeping the function.
+		qualifier := src[m[0]:m[1]]
+		edits = append(edits, sourceEdit{m[0], m[0] + strings.Index(qualifier, ")") + 1, ""})
+	}
+
+	for typeName, t := range types {
+		if len(t.impls) == 0 {
+			continue
+		}
+
+		var b bytes.Buffer
+		for _, u := range uniforms[typeName] {
+			writeDispatcher(&b, t, u)
+		}
+		edits = append(edits, sourceEdit{t.end, t.end, b.String()})
+
+		for _, name := range t.impls {
+			binding := bindings[name]
+			binding.uniforms = uniforms[typeName]
+			bindings[name] = binding
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+
+	return []byte(src), bindings, nil
+}
+
+// functionEnd returns the offset following the closing brace of the function
+// whose body follows offset.
+func functionEnd(src string, offset int) int {
+	depth := 0
+
+	for i := offset; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
+
+func writeDispatcher(b *bytes.Buffer, t *subroutineType, uniform string) {
+	var args []string
+	for _, p := range strings.Split(t.params, ",") {
+		fields := strings.Fields(p)
+		if len(fields) == 0 || fields[0] == "void" {
+			continue
+		}
+		args = append(args, fields[len(fields)-1])
+	}
+	call := "(" + strings.Join(args, ", ") + ")"
+	selector := subroutineSelector(uniform)
+
+	fmt.Fprintf(b, "\n\n%s %s(%s)\n{\n", t.ret, uniform, t.params)
+	for i := len(t.impls) - 1; i > 0; i-- {
+		if t.ret == "void" {
+			fmt.Fprintf(b, "    if (%s == %d) { %s%s; return; }\n", selector, i, t.impls[i], call)
+		} else {
+			fmt.Fprintf(b, "    if (%s == %d) { return %s%s; }\n", selector, i, t.impls[i], call)
+		}
+	}
+	if t.ret == "void" {
+		fmt.Fprintf(b, "    %s%s;\n}\n", t.impls[0], call)
+	} else {
+		fmt.Fprintf(b, "    return %s%s;\n}\n", t.impls[0], call)
+	}
+}
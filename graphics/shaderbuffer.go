@@ -21,3 +21,69 @@ SOFTWARE.
 */
 
 package graphics
+
+// StorageBuffer is a shader storage buffer. It holds large structured data,
+// such as light lists or particle state, which shaders read and write
+// through a buffer block declared with the same binding point.
+type StorageBuffer struct {
+	buffer Buffer
+}
+
+// NewStorageBuffer creates a storage buffer of size bytes. The contents are
+// undefined until written.
+func NewStorageBuffer(size int, usage BufferUsage) *StorageBuffer {
+	s := &StorageBuffer{
+		buffer: activeDevice.NewBuffer(BufferStorage, usage),
+	}
+
+	s.buffer.Upload(size, nil)
+
+	return s
+}
+
+// Size returns the size of the buffer in bytes.
+func (s *StorageBuffer) Size() int {
+	return s.buffer.Size()
+}
+
+// Resize reallocates the buffer with a new size. The contents are lost.
+func (s *StorageBuffer) Resize(size int) {
+	s.buffer.Upload(size, nil)
+}
+
+// Upload replaces the contents and size of the buffer. Data must be a
+// pointer or slice of fixed size values.
+func (s *StorageBuffer) Upload(size int, data interface{}) {
+	s.buffer.Upload(size, data)
+}
+
+// Write replaces size bytes of the buffer starting at offset.
+func (s *StorageBuffer) Write(offset, size int, data interface{}) {
+	if offset < 0 || size <= 0 || offset+size > s.Size() {
+		return
+	}
+
+	s.buffer.Write(offset, size, data)
+}
+
+// Map maps the buffer into client memory. The slice must not be used after
+// Unmap. Map returns nil if the buffer could not be mapped.
+func (s *StorageBuffer) Map(access BufferAccess) []byte {
+	return s.buffer.Map(access)
+}
+
+// Unmap releases the mapping made by Map. It returns false if the contents
+// were corrupted while mapped and must be written again.
+func (s *StorageBuffer) Unmap() bool {
+	return s.buffer.Unmap()
+}
+
+// BindBase binds the buffer to a binding point.
+func (s *StorageBuffer) BindBase(index uint32) {
+	s.buffer.BindBase(index)
+}
+
+// Release frees the buffer.
+func (s *StorageBuffer) Release() {
+	s.buffer.Release()
+}
@@ -0,0 +1,213 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// ShadowFilter selects how shadow maps are sampled.
+type ShadowFilter int32
+
+const (
+	// ShadowFilterHard takes a single sample without filtering.
+	ShadowFilterHard ShadowFilter = iota
+
+	// ShadowFilterPCF takes a single hardware filtered (2x2) sample.
+	ShadowFilterPCF
+
+	// ShadowFilterPCF3x3 averages a 3x3 grid of filtered samples.
+	ShadowFilterPCF3x3
+
+	// ShadowFilterPCF5x5 averages a 5x5 grid of filtered samples.
+	ShadowFilterPCF5x5
+)
+
+// DefaultShadowResolution is the default width and height of a shadow map.
+const DefaultShadowResolution int32 = 2048
+
+// MaxShadowCascades is the maximum number of cascades of a shadow map.
+const MaxShadowCascades = 4
+
+// KernelRadius returns the radius of the sample grid of the filter.
+func (f ShadowFilter) KernelRadius() int32 {
+	switch f {
+	case ShadowFilterPCF3x3:
+		return 1
+	case ShadowFilterPCF5x5:
+		return 2
+	}
+
+	return 0
+}
+
+// ShadowMap is a depth only framebuffer which holds the depth of a scene as
+// seen from a light. Each cascade is rendered to its own layer of a texture
+// array. The texture is set up for depth comparison, so it must be sampled
+// with an array shadow sampler.
+type ShadowMap struct {
+	glFramebuffer
+
+	depth  *Texture2DArray
+	filter ShadowFilter
+	bias   float32
+}
+
+func NewShadowMap(resolution, cascades int32) *ShadowMap {
+	s := &ShadowMap{
+		filter: ShadowFilterPCF3x3,
+		bias:   0.002,
+	}
+
+	s.size = math.IVec2{resolution, resolution}
+	s.attachments = make(map[uint32]Attachment)
+	s.drawBuffers = []uint32{}
+
+	s.SetName("ShadowMap")
+	instance.MustAssign(s)
+
+	gl.GenFramebuffers(1, &s.reference)
+
+	s.depth = NewTexture2DArray(s.size, clampCascades(cascades), TextureFormatDepth24)
+	s.depth.Alloc()
+
+	s.depth.Bind()
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	s.applyFilter()
+
+	return s
+}
+
+// Alloc attaches the first cascade. The shadow map has no color buffers.
+func (s *ShadowMap) Alloc() error {
+	s.RawBind()
+
+	s.attachCascade(0)
+
+	none := uint32(gl.NONE)
+	gl.DrawBuffers(1, &none)
+	gl.ReadBuffer(gl.NONE)
+
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	s.RawUnbind()
+
+	return nil
+}
+
+// Begin binds a cascade of the shadow map for rendering and clears it. Depth
+// writes must be enabled.
+func (s *ShadowMap) Begin(cascade int32) {
+	s.Bind()
+	s.attachCascade(cascade)
+	activeDevice.Clear(ClearDepth)
+}
+
+// End unbinds the shadow map.
+func (s *ShadowMap) End() {
+	s.Unbind()
+}
+
+func (s *ShadowMap) Depth() *Texture2DArray {
+	return s.depth
+}
+
+// Cascades returns the number of cascades.
+func (s *ShadowMap) Cascades() int32 {
+	return s.depth.Layers()
+}
+
+// SetCascades sets the number of cascades, between 1 and MaxShadowCascades.
+func (s *ShadowMap) SetCascades(cascades int32) {
+	s.depth.SetLayers(clampCascades(cascades))
+}
+
+func (s *ShadowMap) Resolution() int32 {
+	return s.size.X()
+}
+
+func (s *ShadowMap) SetResolution(resolution int32) error {
+	size := math.IVec2{resolution, resolution}
+
+	if err := s.depth.SetSize(size); err != nil {
+		return err
+	}
+
+	s.size = size
+
+	return nil
+}
+
+func (s *ShadowMap) Filter() ShadowFilter {
+	return s.filter
+}
+
+func (s *ShadowMap) SetFilter(filter ShadowFilter) {
+	s.filter = filter
+	s.applyFilter()
+}
+
+// Bias returns the depth bias applied when sampling, which hides shadow acne.
+func (s *ShadowMap) Bias() float32 {
+	return s.bias
+}
+
+func (s *ShadowMap) SetBias(bias float32) {
+	s.bias = bias
+}
+
+// TexelSize returns the size of a texel in texture coordinates.
+func (s *ShadowMap) TexelSize() float32 {
+	return 1.0 / float32(s.Resolution())
+}
+
+func (s *ShadowMap) attachCascade(cascade int32) {
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.depth.Reference(), 0, cascade)
+}
+
+func (s *ShadowMap) applyFilter() {
+	s.depth.Bind()
+
+	if s.filter == ShadowFilterHard {
+		s.depth.SetFilter(gl.NEAREST, gl.NEAREST)
+	} else {
+		s.depth.SetFilter(gl.LINEAR, gl.LINEAR)
+	}
+}
+
+func clampCascades(cascades int32) int32 {
+	if cascades < 1 {
+		return 1
+	}
+	if cascades > MaxShadowCascades {
+		return MaxShadowCascades
+	}
+
+	return cascades
+}
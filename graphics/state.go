@@ -0,0 +1,293 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+// Tracked values of State which are only known once they have been set.
+const (
+	stateProgram uint32 = 1 << iota
+	stateDrawFramebuffer
+	stateReadFramebuffer
+	stateActiveUnit
+	stateDepthMask
+	stateDepthFunc
+	stateBlendFunc
+	stateCullFace
+	statePolygonMode
+	stateColorMask
+)
+
+// textureBinding is a texture target of a texture unit.
+type textureBinding struct {
+	unit   uint32
+	target uint32
+}
+
+// blendFunc holds the arguments of glBlendFuncSeparate.
+type blendFunc struct {
+	srcRGB, dstRGB, srcAlpha, dstAlpha uint32
+}
+
+// State caches the GL bindings and fixed function state of the context, so
+// calls which would not change anything are skipped. Code which changes
+// tracked state must go through State, otherwise the cache goes stale. If
+// the context was touched behind its back, call Invalidate.
+type State struct {
+	known uint32
+
+	program         uint32
+	drawFramebuffer uint32
+	readFramebuffer uint32
+	activeUnit      uint32
+	textures        map[textureBinding]uint32
+	caps            map[uint32]bool
+	depthMask       bool
+	depthFunc       uint32
+	blendFunc       blendFunc
+	cullFace        uint32
+	polygonMode     uint32
+	colorMask       bool
+
+	calls   int
+	skipped int
+}
+
+var currentState = NewState()
+
+// NewState creates a state cache which knows nothing about the context.
+func NewState() *State {
+	return &State{
+		textures: make(map[textureBinding]uint32),
+		caps:     make(map[uint32]bool),
+	}
+}
+
+// CurrentState returns the state cache of the GL context.
+func CurrentState() *State {
+	return currentState
+}
+
+// Invalidate forgets all cached state. The next call of each setter is
+// passed to GL.
+func (s *State) Invalidate() {
+	s.known = 0
+	s.textures = make(map[textureBinding]uint32)
+	s.caps = make(map[uint32]bool)
+}
+
+// Stats returns the number of calls made through the cache and how many of
+// them were skipped since the last ResetStats.
+func (s *State) Stats() (calls, skipped int) {
+	return s.calls, s.skipped
+}
+
+// ResetStats resets the call counters, usually once per frame.
+func (s *State) ResetStats() {
+	s.calls = 0
+	s.skipped = 0
+}
+
+// UseProgram makes a program current.
+func (s *State) UseProgram(program uint32) {
+	if s.cached(stateProgram, s.program == program) {
+		return
+	}
+
+	s.program = program
+	gl.UseProgram(program)
+}
+
+// BindFramebuffer binds a framebuffer to gl.FRAMEBUFFER,
+// gl.DRAW_FRAMEBUFFER or gl.READ_FRAMEBUFFER.
+func (s *State) BindFramebuffer(target, framebuffer uint32) {
+	switch target {
+	case gl.DRAW_FRAMEBUFFER:
+		if s.cached(stateDrawFramebuffer, s.drawFramebuffer == framebuffer) {
+			return
+		}
+		s.drawFramebuffer = framebuffer
+	case gl.READ_FRAMEBUFFER:
+		if s.cached(stateReadFramebuffer, s.readFramebuffer == framebuffer) {
+			return
+		}
+		s.readFramebuffer = framebuffer
+	default:
+		both := stateDrawFramebuffer | stateReadFramebuffer
+		if s.cached(both, s.drawFramebuffer == framebuffer && s.readFramebuffer == framebuffer) {
+			return
+		}
+		s.drawFramebuffer = framebuffer
+		s.readFramebuffer = framebuffer
+	}
+
+	gl.BindFramebuffer(target, framebuffer)
+}
+
+// ActiveTexture selects the texture unit used by BindTexture. Unit is the
+// GL enum, starting at gl.TEXTURE0.
+func (s *State) ActiveTexture(unit uint32) {
+	if s.cached(stateActiveUnit, s.activeUnit == unit) {
+		return
+	}
+
+	s.activeUnit = unit
+	gl.ActiveTexture(unit)
+}
+
+// BindTexture binds a texture to a target of the active texture unit.
+func (s *State) BindTexture(target, texture uint32) {
+	key := textureBinding{unit: s.activeUnit, target: target}
+	if s.known&stateActiveUnit != 0 {
+		bound, ok := s.textures[key]
+		if s.cached(0, ok && bound == texture) {
+			return
+		}
+		s.textures[key] = texture
+	}
+
+	gl.BindTexture(target, texture)
+}
+
+// Enable enables or disables a capability such as gl.BLEND.
+func (s *State) Enable(capability uint32, enable bool) {
+	enabled, ok := s.caps[capability]
+	if s.cached(0, ok && enabled == enable) {
+		return
+	}
+
+	s.caps[capability] = enable
+	glToggle(capability, enable)
+}
+
+// DepthMask enables or disables writes to the depth buffer.
+func (s *State) DepthMask(enable bool) {
+	if s.cached(stateDepthMask, s.depthMask == enable) {
+		return
+	}
+
+	s.depthMask = enable
+	gl.DepthMask(enable)
+}
+
+// DepthFunc sets the depth comparison function.
+func (s *State) DepthFunc(fn uint32) {
+	if s.cached(stateDepthFunc, s.depthFunc == fn) {
+		return
+	}
+
+	s.depthFunc = fn
+	gl.DepthFunc(fn)
+}
+
+// BlendFunc sets the blend factors of color and alpha.
+func (s *State) BlendFunc(src, dst uint32) {
+	s.BlendFuncSeparate(src, dst, src, dst)
+}
+
+// BlendFuncSeparate sets the blend factors of color and alpha separately.
+func (s *State) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	fn := blendFunc{srcRGB, dstRGB, srcAlpha, dstAlpha}
+	if s.cached(stateBlendFunc, s.blendFunc == fn) {
+		return
+	}
+
+	s.blendFunc = fn
+	gl.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
+}
+
+// CullFace selects the faces which are culled when gl.CULL_FACE is enabled.
+func (s *State) CullFace(mode uint32) {
+	if s.cached(stateCullFace, s.cullFace == mode) {
+		return
+	}
+
+	s.cullFace = mode
+	gl.CullFace(mode)
+}
+
+// PolygonMode sets how polygons are rasterized.
+func (s *State) PolygonMode(mode uint32) {
+	if s.cached(statePolygonMode, s.polygonMode == mode) {
+		return
+	}
+
+	s.polygonMode = mode
+	gl.PolygonMode(gl.FRONT_AND_BACK, mode)
+}
+
+// ColorMask enables or disables writes to all color channels.
+func (s *State) ColorMask(enable bool) {
+	if s.cached(stateColorMask, s.colorMask == enable) {
+		return
+	}
+
+	s.colorMask = enable
+	gl.ColorMask(enable, enable, enable, enable)
+}
+
+// forgetProgram is called when a program is deleted, as GL may reuse its
+// name.
+func (s *State) forgetProgram(program uint32) {
+	if s.program == program {
+		s.known &^= stateProgram
+	}
+}
+
+// forgetFramebuffer is called when a framebuffer is deleted. Deleting a
+// bound framebuffer binds the default framebuffer.
+func (s *State) forgetFramebuffer(framebuffer uint32) {
+	if s.drawFramebuffer == framebuffer {
+		s.drawFramebuffer = 0
+	}
+	if s.readFramebuffer == framebuffer {
+		s.readFramebuffer = 0
+	}
+}
+
+// forgetTexture is called when a texture is deleted. Deleting a bound
+// texture binds texture zero in its place.
+func (s *State) forgetTexture(texture uint32) {
+	for k, v := range s.textures {
+		if v == texture {
+			s.textures[k] = 0
+		}
+	}
+}
+
+// cached counts a call and reports if it can be skipped. A call is skipped
+// if the values in flags are known and match is true.
+func (s *State) cached(flags uint32, match bool) bool {
+	s.calls++
+
+	if s.known&flags == flags && match {
+		s.skipped++
+		return true
+	}
+
+	s.known |= flags
+
+	return false
+}
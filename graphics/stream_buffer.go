@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+)
+
+const (
+	// streamRegions is the number of regions of a StreamBuffer. The CPU
+	// writes one region while the GPU may still read the others.
+	streamRegions = 3
+
+	// streamAlignment is the alignment of region sizes, a multiple of the
+	// vertex sizes used with stream buffers.
+	streamAlignment = 256
+
+	// streamTimeout is how long Map waits for the GPU per attempt, in
+	// nanoseconds.
+	streamTimeout = 1000000000
+)
+
+// StreamBuffer is a vertex or index buffer for geometry which is written
+// every frame. With buffer storage support the buffer is mapped once, and
+// split into regions guarded by fences, so writing never waits for draws of
+// earlier frames. Without it, the buffer is orphaned on every write.
+type StreamBuffer struct {
+	reference  uint32
+	target     uint32
+	size       int
+	region     int
+	written    bool
+	persistent bool
+	mapped     []byte
+	staging    []byte
+	fences     [streamRegions]uintptr
+}
+
+// NewStreamBuffer creates a stream buffer with regions of at least size
+// bytes.
+func NewStreamBuffer(bufferType BufferType, size int) *StreamBuffer {
+	b := &StreamBuffer{
+		target:     glBufferTarget(bufferType),
+		persistent: capabilities != nil && capabilities.BufferStorage,
+	}
+
+	b.alloc(size)
+
+	return b
+}
+
+// Reference returns the GL name of the buffer.
+func (b *StreamBuffer) Reference() uint32 {
+	return b.reference
+}
+
+// Size returns the size of a region in bytes.
+func (b *StreamBuffer) Size() int {
+	return b.size
+}
+
+// Reserve grows the regions to hold at least size bytes. It returns true if
+// the buffer was recreated, in which case vertex arrays using it must be set
+// up again.
+func (b *StreamBuffer) Reserve(size int) bool {
+	if size <= b.size {
+		return false
+	}
+
+	b.Release()
+	b.alloc(size)
+
+	return true
+}
+
+// Map returns the memory of the next region. It waits if the GPU still
+// reads the region. The memory is only valid until Unmap.
+func (b *StreamBuffer) Map() []byte {
+	if !b.persistent {
+		return b.staging
+	}
+
+	// Fence the draws made from the current region since the last write,
+	// then move on to the oldest region.
+	if b.written {
+		b.fences[b.region] = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		b.region = (b.region + 1) % streamRegions
+	}
+
+	if fence := b.fences[b.region]; fence != 0 {
+		for gl.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, streamTimeout) == gl.TIMEOUT_EXPIRED {
+		}
+		gl.DeleteSync(fence)
+		b.fences[b.region] = 0
+	}
+
+	offset := b.region * b.size
+
+	return b.mapped[offset : offset+b.size]
+}
+
+// Unmap makes the first n bytes written since Map visible to the GPU. It
+// returns the byte offset of the region in the buffer, which draw calls add
+// to their offsets.
+func (b *StreamBuffer) Unmap(n int) int {
+	b.written = true
+
+	if b.persistent {
+		return b.region * b.size
+	}
+
+	gl.BindBuffer(b.target, b.reference)
+	gl.BufferData(b.target, b.size, nil, gl.STREAM_DRAW)
+	if n > 0 {
+		gl.BufferSubData(b.target, 0, n, gl.Ptr(b.staging))
+	}
+
+	return 0
+}
+
+// Bind binds the buffer to its target.
+func (b *StreamBuffer) Bind() {
+	gl.BindBuffer(b.target, b.reference)
+}
+
+// Release frees the buffer.
+func (b *StreamBuffer) Release() {
+	for i, fence := range b.fences {
+		if fence != 0 {
+			gl.DeleteSync(fence)
+			b.fences[i] = 0
+		}
+	}
+
+	if b.reference != 0 {
+		if b.persistent {
+			gl.BindBuffer(b.target, b.reference)
+			gl.UnmapBuffer(b.target)
+		}
+		gl.DeleteBuffers(1, &b.reference)
+		b.reference = 0
+	}
+
+	b.mapped = nil
+	b.staging = nil
+}
+
+func (b *StreamBuffer) alloc(size int) {
+	b.size = (size + streamAlignment - 1) / streamAlignment * streamAlignment
+	if b.size == 0 {
+		b.size = streamAlignment
+	}
+	b.region = 0
+	b.written = false
+
+	gl.GenBuffers(1, &b.reference)
+	gl.BindBuffer(b.target, b.reference)
+
+	if !b.persistent {
+		gl.BufferData(b.target, b.size, nil, gl.STREAM_DRAW)
+		b.staging = make([]byte, b.size)
+		return
+	}
+
+	total := b.size * streamRegions
+	flags := uint32(gl.MAP_WRITE_BIT | gl.MAP_PERSISTENT_BIT | gl.MAP_COHERENT_BIT)
+
+	gl.BufferStorage(b.target, total, nil, flags)
+	ptr := gl.MapBufferRange(b.target, 0, total, flags)
+	b.mapped = (*[1 << 30]byte)(ptr)[:total:total]
+}
@@ -56,12 +56,49 @@ const (
 	TextureFormatDepth24
 	TextureFormatDepth24Stencil8
 	TextureFormatStencil8
+	TextureFormatBC1
+	TextureFormatBC3
+	TextureFormatBC5
+	TextureFormatBC7
 )
 
+// TextureFormatSupported reports if the context can sample textures of a
+// format. Only compressed formats depend on the context.
+func TextureFormatSupported(format TextureFormat) bool {
+	if capabilities == nil {
+		return true
+	}
+
+	switch format {
+	case TextureFormatBC1, TextureFormatBC3:
+		return capabilities.S3TC
+	case TextureFormatBC7:
+		return capabilities.BPTC
+	}
+
+	return true
+}
+
+// TextureFormatCompressed reports if format is a block compressed format.
+// Compressed textures are uploaded with their mip levels and cannot
+// generate mipmaps.
+func TextureFormatCompressed(format TextureFormat) bool {
+	switch format {
+	case TextureFormatBC1, TextureFormatBC3, TextureFormatBC5, TextureFormatBC7:
+		return true
+	}
+
+	return false
+}
+
+// Texture is a texture created by a Device. Texture2D, Texture2DArray,
+// Texture3D, TextureCubemap and Texture2DMultisample are the textures of the
+// OpenGL device.
 type Texture interface {
 	core.Object
 
 	ActivateTexture(textureUnit uint32)
+	Anisotropy() float32
 	Bind()
 	FilterMag() int32
 	FilterMin() int32
@@ -74,7 +111,9 @@ type Texture interface {
 	Layers() int32
 	MipLevels() uint32
 	Resizable() bool
+	SetAnisotropy(level float32)
 	SetFilter(magFilter, minFilter int32)
+	SetFilterMode(mode FilterMode)
 	SetGLFormats(internalFormat int32, format uint32, storageFormat uint32)
 	SetLayers(int32)
 	SetMagFilter(magFilter int32)
@@ -83,6 +122,7 @@ type Texture interface {
 	SetSize(size math.IVec2) error
 	SetTexFormat(format TextureFormat)
 	SetWrapR(wrapR int32)
+	SetWrapMode(mode WrapMode)
 	SetWrapRST(wrapR, wrapS, wrapT int32)
 	SetWrapS(wrapS int32)
 	SetWrapST(wrapS, wrapT int32)
@@ -113,6 +153,8 @@ type BaseTexture struct {
 	wrapS          int32
 	wrapT          int32
 	layers         int32
+	mipLevels      uint32
+	anisotropy     float32
 	reference      uint32
 	textureFormat  TextureFormat
 	size           math.IVec2
@@ -166,6 +208,14 @@ func TextureFormatToInternal(format TextureFormat) int32 {
 		return gl.STENCIL_INDEX8
 	case TextureFormatRGBA16UI:
 		return gl.RGBA16UI
+	case TextureFormatBC1:
+		return gl.COMPRESSED_RGBA_S3TC_DXT1_EXT
+	case TextureFormatBC3:
+		return gl.COMPRESSED_RGBA_S3TC_DXT5_EXT
+	case TextureFormatBC5:
+		return gl.COMPRESSED_RG_RGTC2
+	case TextureFormatBC7:
+		return gl.COMPRESSED_RGBA_BPTC_UNORM
 	}
 
 	return 0
@@ -288,7 +338,11 @@ func (t *BaseTexture) Alloc() error {
 	t.resizable = true
 	t.layers = 1
 
+	activeDevice.PushDebugGroup(t.Name())
 	t.uploadFunc()
+	activeDevice.PopDebugGroup()
+
+	objectLabel(gl.TEXTURE, t.reference, t.Name())
 
 	t.SetFilter(t.filterMag, t.filterMin)
 	t.SetWrapRST(t.wrapR, t.wrapS, t.wrapT)
@@ -296,9 +350,44 @@ func (t *BaseTexture) Alloc() error {
 	return nil
 }
 
+// replaceStorage replaces the texture object of the texture with reference,
+// which holds storage of the given size, and returns the previous texture
+// object. A zero reference allocates new storage with the current sampling
+// state.
+func (t *BaseTexture) replaceStorage(reference uint32, size math.IVec2) uint32 {
+	prev := t.reference
+
+	t.size = size
+	t.reference = reference
+
+	if reference == 0 {
+		gl.GenTextures(1, &t.reference)
+		t.uploadFunc()
+		t.SetFilter(t.filterMag, t.filterMin)
+		t.SetWrapRST(t.wrapR, t.wrapS, t.wrapT)
+		objectLabel(gl.TEXTURE, t.reference, t.Name())
+	}
+
+	return prev
+}
+
+// deleteTexture deletes a texture object which is not owned by a texture.
+func deleteTexture(reference uint32) {
+	currentState.forgetTexture(reference)
+	gl.DeleteTextures(1, &reference)
+}
+
+// SetName sets the name of the texture, which also labels it in frame capture
+// tools.
+func (t *BaseTexture) SetName(name string) {
+	t.BaseObject.SetName(name)
+	objectLabel(gl.TEXTURE, t.reference, name)
+}
+
 // Release
 func (t *BaseTexture) Dealloc() {
 	if t.reference != 0 {
+		currentState.forgetTexture(t.reference)
 		gl.DeleteTextures(1, &t.reference)
 		t.reference = 0
 	}
@@ -310,13 +399,13 @@ func (t *BaseTexture) TextureType() uint32 {
 
 // ActivateTexture
 func (t *BaseTexture) ActivateTexture(textureUnit uint32) {
-	gl.ActiveTexture(textureUnit)
+	currentState.ActiveTexture(textureUnit)
 	t.Bind()
 }
 
 // Bind
 func (t *BaseTexture) Bind() {
-	gl.BindTexture(t.textureType, t.reference)
+	currentState.BindTexture(t.textureType, t.reference)
 }
 
 // FilterMag
@@ -329,9 +418,61 @@ func (t *BaseTexture) FilterMin() int32 {
 	return t.filterMin
 }
 
-// GenerateMipmaps
+// Anisotropy returns the anisotropic filtering level of the texture.
+func (t *BaseTexture) Anisotropy() float32 {
+	if t.anisotropy == 0 {
+		return 1
+	}
+
+	return t.anisotropy
+}
+
+// SetAnisotropy sets the anisotropic filtering level, clamped between 1 (off)
+// and MaxAnisotropy. It sharpens textures viewed at grazing angles.
+func (t *BaseTexture) SetAnisotropy(level float32) {
+	t.anisotropy = clampAnisotropy(level)
+
+	t.Bind()
+	gl.TexParameterf(t.textureType, gl.TEXTURE_MAX_ANISOTROPY, t.anisotropy)
+}
+
+// SetFilterMode sets the filtering of the texture. Mip levels are only
+// filtered once the texture has mipmaps.
+func (t *BaseTexture) SetFilterMode(mode FilterMode) {
+	mag, min := glFilter(mode)
+	if t.MipLevels() == 1 {
+		min = mag
+	}
+
+	t.Bind()
+	t.SetFilter(mag, min)
+}
+
+// SetWrapMode sets the wrapping of all texture coordinates.
+func (t *BaseTexture) SetWrapMode(mode WrapMode) {
+	wrap := glWrap(mode)
+
+	t.Bind()
+	t.SetWrapRST(wrap, wrap, wrap)
+}
+
+// GenerateMipmaps generates the mip chain of the texture from its base level
+// and enables trilinear filtering.
 func (t *BaseTexture) GenerateMipmaps() {
+	t.Bind()
+	gl.GenerateMipmap(t.textureType)
+
+	size := t.size.X()
+	if t.size.Y() > size {
+		size = t.size.Y()
+	}
 
+	t.mipLevels = 1
+	for ; size > 1; size >>= 1 {
+		t.mipLevels++
+	}
+
+	t.SetMinFilter(gl.LINEAR_MIPMAP_LINEAR)
 }
 
 // GLFormat
@@ -368,9 +509,13 @@ func (t *BaseTexture) SetLayers(layers int32) {
 	t.layers = layers
 }
 
-// MipLevels
+// MipLevels returns the number of mip levels of the texture.
 func (t *BaseTexture) MipLevels() uint32 {
-	return 1
+	if t.mipLevels == 0 {
+		return 1
+	}
+
+	return t.mipLevels
 }
 
 // Resizable
@@ -419,6 +564,9 @@ func (t *BaseTexture) SetSize(size math.IVec2) error {
 	if size.X() <= 0 || size.Y() <= 0 {
 		return fmt.Errorf("texture setSize error: invalid size: %s", size)
 	}
+	if size == t.size && t.reference != 0 {
+		return nil
+	}
 
 	t.size = size
 	t.uploadFunc()
@@ -496,7 +644,7 @@ func (t *BaseTexture) Upload() {
 
 // Unbind
 func (t *BaseTexture) Unbind() {
-	gl.BindTexture(t.textureType, 0)
+	currentState.BindTexture(t.textureType, 0)
 }
 
 // Width
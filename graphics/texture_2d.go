@@ -34,8 +34,9 @@ import (
 type Texture2D struct {
 	BaseTexture
 
-	data    []uint8
-	hdrData []float32
+	data       []uint8
+	hdrData    []float32
+	compressed [][]byte
 }
 
 // Texture2D Methods
@@ -79,6 +80,11 @@ func NewTexture2DFrom(texture Texture2D) *Texture2D {
 func (t *Texture2D) Upload() {
 	t.Bind()
 
+	if len(t.compressed) > 0 {
+		t.uploadCompressed()
+		return
+	}
+
 	var ptr unsafe.Pointer
 
 	if t.hdrData != nil && len(t.hdrData) > 0 {
@@ -88,6 +94,7 @@ func (t *Texture2D) Upload() {
 	}
 
 	gl.TexImage2D(gl.TEXTURE_2D, 0, t.internalFormat, t.size.X(), t.size.Y(), 0, t.glFormat, t.storageFormat, ptr)
+	checkError("texture upload")
 }
 
 func (t *Texture2D) SetData(data []uint8) {
@@ -97,3 +104,84 @@ func (t *Texture2D) SetData(data []uint8) {
 func (t *Texture2D) SetHDRData(data []float32) {
 	t.hdrData = data
 }
+
+// SetCompressedData sets the block compressed data of each mip level,
+// starting with the full size level. The texture must have been created
+// with a compressed format.
+func (t *Texture2D) SetCompressedData(levels [][]byte) {
+	t.compressed = levels
+}
+
+// uploadCompressed uploads the compressed mip levels. The mip chain may be
+// shorter than a full chain, so the max level is limited to the levels
+// which were uploaded.
+func (t *Texture2D) uploadCompressed() {
+	for i, level := range t.compressed {
+		w := t.size.X() >> uint(i)
+		h := t.size.Y() >> uint(i)
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+
+		gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(i), uint32(t.internalFormat), w, h, 0, int32(len(level)), gl.Ptr(level))
+	}
+
+	t.mipLevels = uint32(len(t.compressed))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, int32(t.mipLevels-1))
+	checkError("compressed texture upload")
+}
+
+// Replace replaces the contents of an allocated texture with those of an
+// unallocated one, such as a texture decoded from a changed file. The GL
+// texture object is kept, so users of the texture see the new contents.
+func (t *Texture2D) Replace(src *Texture2D) {
+	t.size = src.size
+	t.internalFormat = src.internalFormat
+	t.glFormat = src.glFormat
+	t.storageFormat = src.storageFormat
+	t.data = src.data
+	t.hdrData = src.hdrData
+	t.compressed = src.compressed
+
+	activeDevice.PushDebugGroup(t.Name())
+	t.Upload()
+	activeDevice.PopDebugGroup()
+
+	if len(t.compressed) == 0 && t.mipLevels > 1 {
+		t.GenerateMipmaps()
+	}
+}
+
+// CopyTexture2D copies the first mip level of src into dst. Both textures
+// must have the same size and compatible formats. Contexts without
+// glCopyImageSubData blit between two framebuffers instead, which needs both
+// formats to be renderable.
+func CopyTexture2D(src, dst *Texture2D) {
+	size := src.Size()
+
+	if capabilities == nil || capabilities.CopyImage {
+		gl.CopyImageSubData(src.Reference(), gl.TEXTURE_2D, 0, 0, 0, 0,
+			dst.Reference(), gl.TEXTURE_2D, 0, 0, 0, 0, size.X(), size.Y(), 1)
+		return
+	}
+
+	var fbo [2]uint32
+	gl.GenFramebuffers(2, &fbo[0])
+
+	currentState.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo[0])
+	gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, src.Reference(), 0)
+	currentState.BindFramebuffer(gl.DRAW_FRAMEBUFFER, fbo[1])
+	gl.FramebufferTexture2D(gl.DRAW_FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, dst.Reference(), 0)
+
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.BlitFramebuffer(0, 0, size.X(), size.Y(), 0, 0, size.X(), size.Y(), gl.COLOR_BUFFER_BIT, gl.NEAREST)
+
+	currentState.forgetFramebuffer(fbo[0])
+	currentState.forgetFramebuffer(fbo[1])
+	gl.DeleteFramebuffers(2, &fbo[0])
+
+	BindCurrentFramebuffer()
+}
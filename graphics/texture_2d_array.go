@@ -0,0 +1,120 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// Texture2DArray is an array of 2D textures of the same size and format.
+// Shaders sample it with a layer index, so it suits shadow cascades and
+// terrain splat maps.
+type Texture2DArray struct {
+	BaseTexture
+
+	data    []uint8
+	hdrData []float32
+}
+
+func NewTexture2DArray(size math.IVec2, layers int32, format TextureFormat) *Texture2DArray {
+	t := &Texture2DArray{}
+
+	t.textureType = gl.TEXTURE_2D_ARRAY
+
+	t.SetName("Texture2DArray")
+	instance.MustAssign(t)
+
+	t.size = size
+	t.layers = layers
+	t.uploadFunc = t.Upload
+
+	t.internalFormat = TextureFormatToInternal(format)
+	t.glFormat = TextureFormatToFormat(format)
+	t.storageFormat = TextureFormatToStorage(format)
+
+	return t
+}
+
+// Alloc allocates the texture with the number of layers it was created with.
+func (t *Texture2DArray) Alloc() error {
+	layers := t.layers
+
+	if err := t.BaseTexture.Alloc(); err != nil {
+		return err
+	}
+
+	t.SetLayers(layers)
+
+	return nil
+}
+
+func (t *Texture2DArray) Upload() {
+	t.Bind()
+
+	var ptr unsafe.Pointer
+
+	if len(t.hdrData) > 0 {
+		ptr = gl.Ptr(t.hdrData)
+	} else if len(t.data) > 0 {
+		ptr = gl.Ptr(t.data)
+	}
+
+	gl.TexImage3D(t.textureType, 0, t.internalFormat, t.size.X(), t.size.Y(), t.layers, 0, t.glFormat, t.storageFormat, ptr)
+}
+
+// SetData sets the data of all layers, uploaded by the next Upload. Layers
+// are stored one after the other.
+func (t *Texture2DArray) SetData(data []uint8) {
+	t.data = data
+}
+
+func (t *Texture2DArray) SetHDRData(data []float32) {
+	t.hdrData = data
+}
+
+// UploadLayer replaces the first mip level of one layer of an allocated
+// texture. Data must be a slice of uint8 or float32 matching the format.
+func (t *Texture2DArray) UploadLayer(layer int32, data interface{}) {
+	if layer < 0 || layer >= t.layers {
+		return
+	}
+
+	t.Bind()
+
+	gl.TexSubImage3D(t.textureType, 0, 0, 0, layer, t.size.X(), t.size.Y(), 1, t.glFormat, t.storageFormat, gl.Ptr(data))
+}
+
+// SetLayers sets the number of layers, reallocating the texture if it has
+// been allocated.
+func (t *Texture2DArray) SetLayers(layers int32) {
+	t.layers = layers
+
+	if t.reference != 0 {
+		t.Upload()
+	}
+}
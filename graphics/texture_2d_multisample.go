@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// Texture2DMultisample is a multisampled 2D texture. It can not be sampled
+// with filtering, so it is usually resolved into a Texture2D with
+// ResolveFramebuffer before use.
+type Texture2DMultisample struct {
+	BaseTexture
+
+	samples int32
+}
+
+func NewTexture2DMultisample(size math.IVec2, samples int32, format TextureFormat) *Texture2DMultisample {
+	t := &Texture2DMultisample{
+		samples: ClampSamples(samples),
+	}
+
+	t.textureType = gl.TEXTURE_2D_MULTISAMPLE
+
+	t.SetName("Texture2DMultisample")
+	instance.MustAssign(t)
+
+	t.size = size
+	t.uploadFunc = t.Upload
+
+	t.internalFormat = TextureFormatToInternal(format)
+	t.glFormat = TextureFormatToFormat(format)
+	t.storageFormat = TextureFormatToStorage(format)
+
+	return t
+}
+
+// Alloc allocates the texture. Multisampled textures have no sampler state,
+// so filter and wrap modes are not set.
+func (t *Texture2DMultisample) Alloc() error {
+	if t.reference != 0 {
+		return nil
+	}
+
+	gl.GenTextures(1, &t.reference)
+
+	t.resizable = true
+	t.layers = 1
+
+	t.uploadFunc()
+
+	return nil
+}
+
+func (t *Texture2DMultisample) Upload() {
+	t.Bind()
+
+	gl.TexImage2DMultisample(t.textureType, t.samples, uint32(t.internalFormat), t.size.X(), t.size.Y(), true)
+}
+
+// Samples returns the number of samples per pixel.
+func (t *Texture2DMultisample) Samples() int32 {
+	return t.samples
+}
+
+// MaxSamples returns the largest number of samples supported for
+// multisampled attachments.
+func MaxSamples() int32 {
+	var samples int32
+
+	gl.GetIntegerv(gl.MAX_SAMPLES, &samples)
+	if activeProfile == ProfileES && samples > 4 {
+		samples = 4
+	}
+
+	return samples
+}
+
+// ClampSamples limits a sample count to the range supported by the driver.
+// Counts below two disable multisampling and return zero.
+func ClampSamples(samples int32) int32 {
+	if samples < 2 {
+		return 0
+	}
+	if max := MaxSamples(); samples > max {
+		return max
+	}
+
+	return samples
+}
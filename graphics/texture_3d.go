@@ -23,14 +23,22 @@ SOFTWARE.
 package graphics
 
 import (
+	"unsafe"
+
 	"github.com/go-gl/gl/v4.3-core/gl"
 
 	"github.com/haakenlabs/arc/pkg/math"
 	"github.com/haakenlabs/arc/system/instance"
 )
 
+// Texture3D is a volume texture. Its depth is the number of layers, so
+// shaders sample it with a third, filtered coordinate. It suits color grading
+// lookup tables and light grids.
 type Texture3D struct {
 	BaseTexture
+
+	data    []uint8
+	hdrData []float32
 }
 
 func NewTexture3D(size math.IVec2, layers int32, format TextureFormat) *Texture3D {
@@ -42,6 +50,7 @@ func NewTexture3D(size math.IVec2, layers int32, format TextureFormat) *Texture3
 	instance.MustAssign(t)
 
 	t.size = size
+	t.layers = layers
 	t.uploadFunc = t.Upload
 
 	t.internalFormat = TextureFormatToInternal(format)
@@ -60,6 +69,7 @@ func NewTexture3DFrom(texture Texture3D) *Texture3D {
 	instance.MustAssign(t)
 
 	t.size = texture.Size()
+	t.layers = texture.Layers()
 	t.uploadFunc = t.Upload
 
 	t.internalFormat = texture.GLInternalFormat()
@@ -69,8 +79,70 @@ func NewTexture3DFrom(texture Texture3D) *Texture3D {
 	return t
 }
 
+// Alloc allocates the texture with the depth it was created with.
+func (t *Texture3D) Alloc() error {
+	layers := t.layers
+
+	if err := t.BaseTexture.Alloc(); err != nil {
+		return err
+	}
+
+	t.layers = layers
+	t.Upload()
+
+	return nil
+}
+
 func (t *Texture3D) Upload() {
 	t.Bind()
 
-	gl.TexImage3D(t.textureType, 0, t.internalFormat, t.size.X(), t.size.Y(), t.layers, 0, t.glFormat, t.storageFormat, nil)
+	var ptr unsafe.Pointer
+
+	if len(t.hdrData) > 0 {
+		ptr = gl.Ptr(t.hdrData)
+	} else if len(t.data) > 0 {
+		ptr = gl.Ptr(t.data)
+	}
+
+	gl.TexImage3D(t.textureType, 0, t.internalFormat, t.size.X(), t.size.Y(), t.layers, 0, t.glFormat, t.storageFormat, ptr)
+}
+
+// Depth returns the depth of the texture.
+func (t *Texture3D) Depth() int32 {
+	return t.layers
+}
+
+// GenerateMipmaps generates the mip chain of the texture from its base level.
+// Unlike 2D textures the chain also halves the depth.
+func (t *Texture3D) GenerateMipmaps() {
+	t.BaseTexture.GenerateMipmaps()
+
+	size := t.size.X()
+	if t.size.Y() > size {
+		size = t.size.Y()
+	}
+	if t.layers > size {
+		size = t.layers
+	}
+
+	t.mipLevels = 1
+	for ; size > 1; size >>= 1 {
+		t.mipLevels++
+	}
+}
+
+// UploadRegion replaces a box of the first mip level of an allocated texture.
+// Data must be a slice of uint8 or float32 matching the format.
+func (t *Texture3D) UploadRegion(offset, size [3]int32, data interface{}) {
+	t.Bind()
+
+	gl.TexSubImage3D(t.textureType, 0, offset[0], offset[1], offset[2], size[0], size[1], size[2], t.glFormat, t.storageFormat, gl.Ptr(data))
+}
+
+func (t *Texture3D) SetData(data []uint8) {
+	t.data = data
+}
+
+func (t *Texture3D) SetHDRData(data []float32) {
+	t.hdrData = data
 }
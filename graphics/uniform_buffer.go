@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package graphics
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Binding points of the uniform blocks shared by the built-in shaders. They
+// must match the bindings declared in utils/uniforms.glsl.
+const (
+	UniformBindingCamera uint32 = iota
+	UniformBindingLights
+)
+
+// Std140 packs values following the std140 layout rules of uniform blocks.
+// Values must be written in the order they are declared in the block.
+type Std140 struct {
+	data []byte
+}
+
+// Bytes returns the packed data.
+func (s *Std140) Bytes() []byte {
+	return s.data
+}
+
+// Len returns the size of the packed data in bytes.
+func (s *Std140) Len() int {
+	return len(s.data)
+}
+
+// Reset clears the packed data, keeping the allocated memory.
+func (s *Std140) Reset() {
+	s.data = s.data[:0]
+}
+
+// Pad aligns the data to 16 bytes. std140 requires it after each struct and
+// after each element of an array.
+func (s *Std140) Pad() {
+	s.align(16)
+}
+
+func (s *Std140) Float(v float32) {
+	s.align(4)
+	s.word(math.Float32bits(v))
+}
+
+func (s *Std140) Int(v int32) {
+	s.align(4)
+	s.word(uint32(v))
+}
+
+func (s *Std140) Uint(v uint32) {
+	s.align(4)
+	s.word(v)
+}
+
+func (s *Std140) Bool(v bool) {
+	if v {
+		s.Uint(1)
+	} else {
+		s.Uint(0)
+	}
+}
+
+func (s *Std140) Vec2(v mgl32.Vec2) {
+	s.align(8)
+	s.floats(v[:])
+}
+
+func (s *Std140) Vec3(v mgl32.Vec3) {
+	s.align(16)
+	s.floats(v[:])
+}
+
+func (s *Std140) Vec4(v mgl32.Vec4) {
+	s.align(16)
+	s.floats(v[:])
+}
+
+// Mat3 writes a 3x3 matrix. Each column takes the space of a vec4.
+func (s *Std140) Mat3(v mgl32.Mat3) {
+	for i := 0; i < 3; i++ {
+		s.Vec3(v.Col(i))
+		s.Pad()
+	}
+}
+
+func (s *Std140) Mat4(v mgl32.Mat4) {
+	s.align(16)
+	s.floats(v[:])
+}
+
+func (s *Std140) align(n int) {
+	for len(s.data)%n != 0 {
+		s.data = append(s.data, 0)
+	}
+}
+
+func (s *Std140) word(v uint32) {
+	var b [4]byte
+
+	binary.LittleEndian.PutUint32(b[:], v)
+	s.data = append(s.data, b[:]...)
+}
+
+func (s *Std140) floats(v []float32) {
+	for i := range v {
+		s.word(math.Float32bits(v[i]))
+	}
+}
+
+// UniformBuffer is a buffer backing a uniform block. Shaders read it through
+// the block declared with the same binding point.
+type UniformBuffer struct {
+	buffer  Buffer
+	binding uint32
+}
+
+// NewUniformBuffer creates a uniform buffer for a binding point.
+func NewUniformBuffer(binding uint32) *UniformBuffer {
+	return &UniformBuffer{
+		buffer:  activeDevice.NewBuffer(BufferUniform, BufferUsageDynamic),
+		binding: binding,
+	}
+}
+
+// Binding returns the binding point of the buffer.
+func (u *UniformBuffer) Binding() uint32 {
+	return u.binding
+}
+
+// Size returns the size of the buffer in bytes.
+func (u *UniformBuffer) Size() int {
+	return u.buffer.Size()
+}
+
+// Upload replaces the contents of the buffer with packed data.
+func (u *UniformBuffer) Upload(data *Std140) {
+	if data.Len() == 0 {
+		return
+	}
+
+	u.buffer.Upload(data.Len(), data.Bytes())
+}
+
+// Bind binds the buffer to its binding point.
+func (u *UniformBuffer) Bind() {
+	u.buffer.BindBase(u.binding)
+}
+
+// Release frees the buffer.
+func (u *UniformBuffer) Release() {
+	u.buffer.Release()
+}
@@ -0,0 +1,543 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ scene.ScriptComponent = &Inspector{}
+
+const (
+	// DefaultAddr is the address the inspector listens on if none is given.
+	DefaultAddr = "127.0.0.1:8088"
+
+	// DefaultInterval is the default time in seconds between scene updates
+	// sent to clients.
+	DefaultInterval = 0.25
+
+	// clientQueueSize is the number of messages buffered for a client before
+	// further messages are dropped.
+	clientQueueSize = 16
+
+	// requestQueueSize is the number of client requests buffered until the
+	// next frame.
+	requestQueueSize = 64
+)
+
+// ErrInspectorListening is returned when starting an inspector which is
+// already listening.
+type ErrInspectorListening string
+
+func (e ErrInspectorListening) Error() string {
+	return "inspector: already listening: " + string(e)
+}
+
+// ErrObjectType is returned when an edit targets an object of the wrong
+// type.
+type ErrObjectType int32
+
+func (e ErrObjectType) Error() string {
+	return fmt.Sprintf("inspector: object %d has the wrong type", int32(e))
+}
+
+type client struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+type request struct {
+	client *client
+
+	Type      string          `json:"type"`
+	Object    int32           `json:"object"`
+	Component int32           `json:"component"`
+	Property  string          `json:"property"`
+	Value     json.RawMessage `json:"value"`
+}
+
+type componentInfo struct {
+	ID         int32                  `json:"id"`
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type objectInfo struct {
+	ID         int32           `json:"id"`
+	Name       string          `json:"name"`
+	Parent     int32           `json:"parent"`
+	Active     bool            `json:"active"`
+	Components []componentInfo `json:"components"`
+}
+
+type instanceInfo struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type sceneMsg struct {
+	Type    string       `json:"type"`
+	Scene   string       `json:"scene"`
+	Objects []objectInfo `json:"objects"`
+}
+
+type instancesMsg struct {
+	Type      string         `json:"type"`
+	Instances []instanceInfo `json:"instances"`
+}
+
+type errorMsg struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// Inspector is a debug server which streams the scene of its object to
+// browser clients over a WebSocket, and applies edits made by the clients.
+// Open the address it listens on in a browser to use the bundled client.
+//
+// Clients are served from their own goroutines, but the scene is only read
+// and modified from Update.
+type Inspector struct {
+	scene.BaseScriptComponent
+
+	addr     string
+	local    string
+	server   *http.Server
+	upgrader websocket.Upgrader
+	clients  map[*client]bool
+	requests chan request
+	interval float64
+	elapsed  float64
+	mu       sync.Mutex
+}
+
+// NewInspector creates a new inspector which listens on addr. An empty
+// address listens on DefaultAddr, and an address without a host listens on
+// the loopback interface only, so the game is not exposed to the network.
+func NewInspector(addr string) *Inspector {
+	i := &Inspector{
+		addr:     listenAddr(addr),
+		clients:  make(map[*client]bool),
+		requests: make(chan request, requestQueueSize),
+		interval: DefaultInterval,
+	}
+
+	// Clients can edit the running game, so pages served from other sites
+	// must not be able to connect from the browser of the developer.
+	i.upgrader.CheckOrigin = func(r *http.Request) bool {
+		return checkOrigin(r, i.local)
+	}
+
+	i.SetName("Inspector")
+	instance.MustAssign(i)
+
+	return i
+}
+
+// Listen starts the HTTP server.
+func (i *Inspector) Listen() error {
+	if i.server != nil {
+		return ErrInspectorListening(i.addr)
+	}
+
+	ln, err := net.Listen("tcp", i.addr)
+	if err != nil {
+		return err
+	}
+
+	i.local = ln.Addr().String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", i.servePage)
+	mux.HandleFunc("/ws", i.serveWS)
+
+	i.server = &http.Server{Handler: mux}
+
+	go func(server *http.Server) {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logrus.Error("[Inspector] ", err)
+		}
+	}(i.server)
+
+	logrus.Info("[Inspector] Listening on http://", ln.Addr())
+
+	return nil
+}
+
+// Close stops the HTTP server and disconnects all clients.
+func (i *Inspector) Close() {
+	if i.server == nil {
+		return
+	}
+
+	if err := i.server.Close(); err != nil {
+		logrus.Warn("[Inspector] ", err)
+	}
+	i.server = nil
+
+	i.mu.Lock()
+	for c := range i.clients {
+		c.conn.Close()
+	}
+	i.mu.Unlock()
+}
+
+// Listening reports if the HTTP server is running.
+func (i *Inspector) Listening() bool {
+	return i.server != nil
+}
+
+// Interval returns the time in seconds between scene updates.
+func (i *Inspector) Interval() float64 {
+	return i.interval
+}
+
+// SetInterval sets the time in seconds between scene updates.
+func (i *Inspector) SetInterval(interval float64) {
+	i.interval = interval
+}
+
+// Update applies requests received from clients, and periodically sends the
+// scene to them.
+func (i *Inspector) Update() {
+	if i.server == nil {
+		return
+	}
+
+	for n := len(i.requests); n > 0; n-- {
+		i.handle(<-i.requests)
+	}
+
+	i.elapsed += time.Delta()
+	if i.elapsed >= i.interval {
+		i.elapsed = 0
+		i.broadcast(i.sceneMsg())
+	}
+}
+
+// Dealloc stops the HTTP server.
+func (i *Inspector) Dealloc() {
+	i.Close()
+}
+
+func (i *Inspector) handle(r request) {
+	var err error
+
+	switch r.Type {
+	case "set":
+		err = i.set(r.Component, r.Property, r.Value)
+	case "set_active":
+		err = i.setActive(r.Object, r.Value)
+	case "instances":
+		i.sendTo(r.client, i.instancesMsg())
+	case "refresh":
+		i.sendTo(r.client, i.sceneMsg())
+	}
+
+	if err != nil {
+		i.sendTo(r.client, &errorMsg{Type: "error", Error: err.Error()})
+	}
+}
+
+func (i *Inspector) set(id int32, property string, value json.RawMessage) error {
+	o, err := instance.Get(id)
+	if err != nil {
+		return err
+	}
+
+	c, ok := o.(scene.Component)
+	if !ok {
+		return ErrObjectType(id)
+	}
+
+	return setProperty(c, property, value)
+}
+
+func (i *Inspector) setActive(id int32, value json.RawMessage) error {
+	o, err := instance.Get(id)
+	if err != nil {
+		return err
+	}
+
+	g, ok := o.(*scene.GameObject)
+	if !ok {
+		return ErrObjectType(id)
+	}
+
+	var active bool
+	if err := json.Unmarshal(value, &active); err != nil {
+		return err
+	}
+	g.SetActive(active)
+
+	return nil
+}
+
+func (i *Inspector) sceneMsg() *sceneMsg {
+	msg := &sceneMsg{
+		Type:    "scene",
+		Objects: []objectInfo{},
+	}
+
+	s := i.GameObject().Scene()
+	if s == nil {
+		return msg
+	}
+	msg.Scene = s.Name()
+
+	for _, o := range s.Objects() {
+		// Skip the root node of the scene graph.
+		if o.Parent() == nil {
+			continue
+		}
+
+		info := objectInfo{
+			ID:     o.ID(),
+			Name:   o.Name(),
+			Active: o.Active(),
+		}
+		if p := o.Parent(); p.Parent() != nil {
+			info.Parent = p.ID()
+		}
+
+		for _, c := range o.Components() {
+			info.Components = append(info.Components, componentInfo{
+				ID:         c.ID(),
+				Type:       typeName(c),
+				Name:       c.Name(),
+				Properties: properties(c),
+			})
+		}
+
+		msg.Objects = append(msg.Objects, info)
+	}
+
+	return msg
+}
+
+func (i *Inspector) instancesMsg() *instancesMsg {
+	msg := &instancesMsg{
+		Type:      "instances",
+		Instances: []instanceInfo{},
+	}
+
+	for _, o := range instance.Objects() {
+		msg.Instances = append(msg.Instances, instanceInfo{
+			ID:   o.ID(),
+			Name: o.Name(),
+			Type: typeName(o),
+		})
+	}
+
+	return msg
+}
+
+func (i *Inspector) broadcast(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Warn("[Inspector] ", err)
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for c := range i.clients {
+		i.queue(c, data)
+	}
+}
+
+func (i *Inspector) sendTo(c *client, msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Warn("[Inspector] ", err)
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.clients[c] {
+		i.queue(c, data)
+	}
+}
+
+// queue queues a message for a client, dropping it if the client is not
+// keeping up. Must be called with mu held.
+func (i *Inspector) queue(c *client, data []byte) {
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+func (i *Inspector) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
+
+func (i *Inspector) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := i.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Warn("[Inspector] ", err)
+		return
+	}
+
+	c := &client{
+		conn: conn,
+		send: make(chan []byte, clientQueueSize),
+	}
+
+	i.mu.Lock()
+	i.clients[c] = true
+	i.mu.Unlock()
+
+	go i.write(c)
+	i.read(c)
+}
+
+func (i *Inspector) read(c *client) {
+	defer func() {
+		i.mu.Lock()
+		delete(i.clients, c)
+		close(c.send)
+		i.mu.Unlock()
+
+		c.conn.Close()
+	}()
+
+	for {
+		var r request
+		if err := c.conn.ReadJSON(&r); err != nil {
+			return
+		}
+		r.client = c
+
+		select {
+		case i.requests <- r:
+		default:
+			logrus.Warn("[Inspector] Request queue full, dropping ", r.Type)
+		}
+	}
+}
+
+func (i *Inspector) write(c *client) {
+	for data := range c.send {
+		if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+// listenAddr returns addr, defaulting to DefaultAddr and to the loopback
+// interface if it has no host.
+func listenAddr(addr string) string {
+	if addr == "" {
+		return DefaultAddr
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// checkOrigin accepts WebSocket connections addressed to a loopback host,
+// so a hostname rebound to the loopback address cannot reach the inspector.
+// Connections without an origin, such as from tools, are accepted, as are
+// pages served from a loopback host on the port the inspector listens on.
+func checkOrigin(r *http.Request, listen string) bool {
+	if !loopbackHost(hostname(r.Host)) {
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || !loopbackHost(u.Hostname()) {
+		return false
+	}
+
+	_, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+
+	originPort := u.Port()
+	if originPort == "" {
+		switch u.Scheme {
+		case "http":
+			originPort = "80"
+		case "https":
+			originPort = "443"
+		}
+	}
+
+	return originPort == port
+}
+
+// hostname returns the host of a host and optional port.
+func hostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+
+	return strings.Trim(hostport, "[]")
+}
+
+// loopbackHost reports if host is localhost or a loopback address.
+func loopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package inspector
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		host   string
+		origin string
+		want   bool
+	}{
+		{host: "127.0.0.1:8088", origin: "", want: true},
+		{host: "localhost:8088", origin: "http://localhost:8088", want: true},
+		{host: "127.0.0.1:8088", origin: "http://127.0.0.1:8088", want: true},
+		{host: "[::1]:8088", origin: "http://[::1]:8088", want: true},
+		{host: "127.0.0.1:8088", origin: "http://localhost:8088", want: true},
+
+		// Pages from other sites, or other ports of localhost.
+		{host: "127.0.0.1:8088", origin: "http://example.com", want: false},
+		{host: "127.0.0.1:8088", origin: "http://localhost:3000", want: false},
+		{host: "127.0.0.1:8088", origin: "http://localhost", want: false},
+		{host: "127.0.0.1:8088", origin: "null", want: false},
+
+		// A hostname rebound to the loopback address.
+		{host: "attacker.example:8088", origin: "http://attacker.example:8088", want: false},
+		{host: "attacker.example:8088", origin: "", want: false},
+		{host: "192.168.1.2:8088", origin: "http://192.168.1.2:8088", want: false},
+	}
+
+	for i, v := range tests {
+		r := httptest.NewRequest("GET", "/ws", nil)
+		r.Host = v.host
+		if v.origin != "" {
+			r.Header.Set("Origin", v.origin)
+		}
+
+		if got := checkOrigin(r, "127.0.0.1:8088"); got != v.want {
+			t.Errorf("CheckOrigin case %d failed. want: %v got: %v", i, v.want, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package inspector
+
+// page is the browser client served by the inspector.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Arc Inspector</title>
+<style>
+body { margin: 0; display: flex; height: 100vh; font: 13px monospace; background: #1e1e1e; color: #ddd; }
+#tree, #detail { overflow: auto; padding: 8px; }
+#tree { width: 35%; border-right: 1px solid #444; }
+#detail { flex: 1; }
+ul { list-style: none; margin: 0; padding-left: 14px; }
+li > span { cursor: pointer; }
+li > span.selected { background: #264f78; }
+li > span.inactive { color: #777; }
+fieldset { border: 1px solid #444; margin-bottom: 8px; }
+input { background: #2d2d2d; color: #ddd; border: 1px solid #555; width: 70px; }
+#status { color: #e88; }
+</style>
+</head>
+<body>
+<div id="tree"><div id="scene"></div><ul id="root"></ul></div>
+<div id="detail">
+<button id="instances">Instances</button> <span id="status"></span>
+<div id="content"></div>
+</div>
+<script>
+var ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/ws");
+var objects = {};
+var selected = 0;
+var editing = false;
+
+function send(msg) { ws.send(JSON.stringify(msg)); }
+
+ws.onclose = function() { document.getElementById("status").textContent = "disconnected"; };
+ws.onmessage = function(e) {
+	var msg = JSON.parse(e.data);
+	if (msg.type === "scene") {
+		objects = {};
+		msg.objects.forEach(function(o) { objects[o.id] = o; });
+		document.getElementById("scene").textContent = "Scene: " + msg.scene;
+		renderTree(msg.objects);
+		if (!editing && selected && objects[selected]) renderObject(objects[selected]);
+	} else if (msg.type === "instances") {
+		renderInstances(msg.instances);
+	} else if (msg.type === "error") {
+		document.getElementById("status").textContent = msg.error;
+	}
+};
+
+function renderTree(list) {
+	var nodes = {};
+	var root = document.getElementById("root");
+	root.innerHTML = "";
+	list.forEach(function(o) {
+		var li = document.createElement("li");
+		var span = document.createElement("span");
+		span.textContent = o.name + " [" + o.id + "]";
+		if (!o.active) span.className = "inactive";
+		if (o.id === selected) span.className += " selected";
+		span.onclick = function() { selected = o.id; editing = false; renderTree(list); renderObject(o); };
+		li.appendChild(span);
+		li.appendChild(document.createElement("ul"));
+		nodes[o.id] = li;
+		(nodes[o.parent] ? nodes[o.parent].lastChild : root).appendChild(li);
+	});
+}
+
+function renderObject(o) {
+	var content = document.getElementById("content");
+	content.innerHTML = "";
+	var active = document.createElement("label");
+	var box = document.createElement("input");
+	box.type = "checkbox";
+	box.checked = o.active;
+	box.onchange = function() { send({type: "set_active", object: o.id, value: box.checked}); };
+	active.appendChild(box);
+	active.appendChild(document.createTextNode(" " + o.name));
+	content.appendChild(active);
+	(o.components || []).forEach(function(c) {
+		var fs = document.createElement("fieldset");
+		var legend = document.createElement("legend");
+		legend.textContent = c.type + " [" + c.id + "]";
+		fs.appendChild(legend);
+		Object.keys(c.properties).sort().forEach(function(name) {
+			fs.appendChild(renderProperty(c, name, c.properties[name]));
+		});
+		content.appendChild(fs);
+	});
+}
+
+function renderProperty(c, name, value) {
+	var div = document.createElement("div");
+	div.appendChild(document.createTextNode(name + ": "));
+	function input(v, update) {
+		var el = document.createElement("input");
+		if (typeof v === "boolean") {
+			el.type = "checkbox";
+			el.checked = v;
+			el.onchange = function() { update(el.checked); };
+		} else if (typeof v === "number") {
+			el.type = "number";
+			el.step = "any";
+			el.value = v;
+			el.onfocus = function() { editing = true; };
+			el.onblur = function() { editing = false; };
+			el.onchange = function() { update(parseFloat(el.value)); };
+		} else {
+			el.value = JSON.stringify(v);
+			el.disabled = true;
+		}
+		return el;
+	}
+	function set(v) { send({type: "set", component: c.id, property: name, value: v}); }
+	if (Array.isArray(value)) {
+		var current = value.slice();
+		current.forEach(function(v, i) {
+			div.appendChild(input(v, function(x) { current[i] = x; set(current); }));
+		});
+	} else {
+		div.appendChild(input(value, set));
+	}
+	return div;
+}
+
+function renderInstances(list) {
+	selected = 0;
+	var content = document.getElementById("content");
+	content.innerHTML = "";
+	var table = document.createElement("table");
+	list.forEach(function(o) {
+		var row = table.insertRow();
+		row.insertCell().textContent = o.id;
+		row.insertCell().textContent = o.type;
+		row.insertCell().textContent = o.name;
+	});
+	content.appendChild(table);
+}
+
+document.getElementById("instances").onclick = function() { send({type: "instances"}); };
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/scene"
+)
+
+// Inspectable is implemented by components which expose properties to the
+// inspector. Property values must be encodable as JSON.
+type Inspectable interface {
+	// InspectProperties returns the current values of the properties.
+	InspectProperties() map[string]interface{}
+
+	// SetInspectProperty sets a property from a JSON value sent by the
+	// client.
+	SetInspectProperty(name string, value json.RawMessage) error
+}
+
+// ErrPropertyUnknown is returned when editing a property a component does
+// not have.
+type ErrPropertyUnknown string
+
+func (e ErrPropertyUnknown) Error() string {
+	return "inspector: unknown property: " + string(e)
+}
+
+// typeName returns the type name of an object, such as "scene.Camera".
+func typeName(o interface{}) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", o), "*")
+}
+
+// properties returns the inspectable properties of a component.
+func properties(c scene.Component) map[string]interface{} {
+	p := make(map[string]interface{})
+
+	switch v := c.(type) {
+	case scene.Transform:
+		q := v.Rotation()
+		p["position"] = v.Position()
+		p["rotation"] = [4]float32{q.W, q.V[0], q.V[1], q.V[2]}
+		p["scale"] = v.Scale()
+	case *scene.Camera:
+		p["fov"] = v.Fov()
+		p["near"] = v.NearClip()
+		p["far"] = v.FarClip()
+		p["hdr"] = v.HDR()
+	case *scene.Light:
+		p["intensity"] = v.Intensity()
+		p["range"] = v.Range()
+		p["spot_angle"] = v.SpotAngle()
+	}
+
+	if v, ok := c.(scene.ScriptComponent); ok {
+		p["active"] = v.Active()
+	}
+	if v, ok := c.(Inspectable); ok {
+		for name, value := range v.InspectProperties() {
+			p[name] = value
+		}
+	}
+
+	return p
+}
+
+// setProperty sets a property of a component. Properties of Inspectable
+// components take precedence over the built in ones.
+func setProperty(c scene.Component, name string, value json.RawMessage) error {
+	if v, ok := c.(Inspectable); ok {
+		if _, ok := v.InspectProperties()[name]; ok {
+			return v.SetInspectProperty(name, value)
+		}
+	}
+
+	switch v := c.(type) {
+	case scene.Transform:
+		switch name {
+		case "position":
+			var p mgl32.Vec3
+			if err := json.Unmarshal(value, &p); err != nil {
+				return err
+			}
+			v.SetPosition(p)
+			return nil
+		case "rotation":
+			var q [4]float32
+			if err := json.Unmarshal(value, &q); err != nil {
+				return err
+			}
+			v.SetRotation(mgl32.Quat{W: q[0], V: mgl32.Vec3{q[1], q[2], q[3]}}.Normalize())
+			return nil
+		case "scale":
+			var s mgl32.Vec3
+			if err := json.Unmarshal(value, &s); err != nil {
+				return err
+			}
+			v.SetScale(s)
+			return nil
+		}
+	case *scene.Camera:
+		if name == "fov" {
+			var fov float32
+			if err := json.Unmarshal(value, &fov); err != nil {
+				return err
+			}
+			v.SetFov(fov)
+			v.UpdateMatrices()
+			return nil
+		}
+	case *scene.Light:
+		var f float32
+		switch name {
+		case "intensity", "range", "spot_angle":
+			if err := json.Unmarshal(value, &f); err != nil {
+				return err
+			}
+		}
+		switch name {
+		case "intensity":
+			v.SetIntensity(f)
+			return nil
+		case "range":
+			v.SetRange(f)
+			return nil
+		case "spot_angle":
+			v.SetSpotAngle(f)
+			return nil
+		}
+	}
+
+	if v, ok := c.(scene.ScriptComponent); ok && name == "active" {
+		var active bool
+		if err := json.Unmarshal(value, &active); err != nil {
+			return err
+		}
+		v.SetActive(active)
+		return nil
+	}
+
+	return ErrPropertyUnknown(name)
+}
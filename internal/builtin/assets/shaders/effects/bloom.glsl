@@ -0,0 +1,52 @@
+#ifdef _FRAGMENT_
+
+layout(binding = 2) uniform sampler2D u_bloom;
+
+uniform float f_threshold = 1.0;
+uniform float f_knee = 0.5;
+uniform float f_intensity = 1.0;
+uniform vec2 f_direction;
+
+const float weights[5] = float[](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+
+// Keeps the parts of the image brighter than the threshold. The knee softens
+// the cut off so bloom fades in instead of popping.
+subroutine(RenderPassType)
+vec4 pass_bright()
+{
+    vec3 color = texture(u_source, vo_texture).rgb;
+    float brightness = max(color.r, max(color.g, color.b));
+
+    float soft = clamp(brightness - f_threshold + f_knee, 0.0, 2.0 * f_knee);
+    soft = soft * soft / (4.0 * f_knee + 0.00001);
+
+    float contribution = max(soft, brightness - f_threshold) / max(brightness, 0.00001);
+
+    return vec4(color * contribution, 1.0);
+}
+
+// One direction of a separable 9 tap gaussian blur. f_direction is the size of
+// a texel along the blur axis.
+subroutine(RenderPassType)
+vec4 pass_blur()
+{
+    vec3 color = texture(u_source, vo_texture).rgb * weights[0];
+
+    for (int i = 1; i < 5; i++) {
+        vec2 offset = f_direction * float(i);
+        color += texture(u_source, vo_texture + offset).rgb * weights[i];
+        color += texture(u_source, vo_texture - offset).rgb * weights[i];
+    }
+
+    return vec4(color, 1.0);
+}
+
+subroutine(RenderPassType)
+vec4 pass_composite()
+{
+    vec4 color = texture(u_source, vo_texture);
+
+    return vec4(color.rgb + texture(u_bloom, vo_texture).rgb * f_intensity, color.a);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "effect/bloom",
+    "files": [
+        "../utils/base.glsl",
+        "bloom.glsl"
+    ]
+}
@@ -0,0 +1,21 @@
+#ifdef _FRAGMENT_
+
+layout(binding = 2) uniform sampler3D u_lut;
+
+uniform float f_lut_size = 16.0;
+uniform float f_contribution = 1.0;
+
+// Looks the color up in the LUT. Coordinates are remapped to texel centers so
+// the ends of the table are not blended with the border.
+subroutine(RenderPassType)
+vec4 pass_0()
+{
+    vec4 color = texture(u_source, vo_texture);
+
+    vec3 uvw = clamp(color.rgb, 0.0, 1.0) * ((f_lut_size - 1.0) / f_lut_size) + 0.5 / f_lut_size;
+    vec3 graded = texture(u_lut, uvw).rgb;
+
+    return vec4(mix(color.rgb, graded, f_contribution), color.a);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "effect/color_grading",
+    "files": [
+        "../utils/base.glsl",
+        "color_grading.glsl"
+    ]
+}
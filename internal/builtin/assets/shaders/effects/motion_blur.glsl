@@ -0,0 +1,32 @@
+#ifdef _FRAGMENT_
+
+layout(binding = 2) uniform sampler2D u_velocity;
+
+uniform int f_samples = 8;
+uniform float f_scale = 1.0;
+uniform float f_max_length = 0.05;
+
+// Averages samples along the motion of the pixel since the last frame,
+// centered on the pixel.
+subroutine(RenderPassType)
+vec4 pass_0()
+{
+    vec2 velocity = texture(u_velocity, vo_texture).xy * f_scale;
+
+    float len = length(velocity);
+    if (len > f_max_length)
+        velocity *= f_max_length / len;
+
+    vec4 color = texture(u_source, vo_texture);
+    if (len < 0.0001 || f_samples < 2)
+        return color;
+
+    for (int i = 1; i < f_samples; i++) {
+        float t = float(i) / float(f_samples - 1) - 0.5;
+        color += texture(u_source, vo_texture - velocity * t);
+    }
+
+    return color / float(f_samples);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "effect/motion_blur",
+    "files": [
+        "../utils/base.glsl",
+        "motion_blur.glsl"
+    ]
+}
@@ -0,0 +1,102 @@
+#ifdef _FRAGMENT_
+
+layout(binding = 2) uniform sampler2D u_position;
+layout(binding = 3) uniform usampler2D u_material;
+
+uniform mat4 f_view;
+uniform mat4 f_projection;
+uniform vec3 f_camera;
+
+uniform int f_steps = 32;
+uniform int f_refine = 5;
+uniform float f_max_distance = 25.0;
+uniform float f_thickness = 0.5;
+uniform float f_intensity = 1.0;
+
+vec2 project(vec3 p)
+{
+    vec4 clip = f_projection * vec4(p, 1.0);
+    return clip.xy / clip.w * 0.5 + 0.5;
+}
+
+float scene_depth(vec2 uv)
+{
+    return (f_view * vec4(texture(u_position, uv).xyz, 1.0)).z;
+}
+
+// Marches the reflected view ray through the depth of the GBuffer and blends
+// the color at the hit point into the lit image.
+subroutine(RenderPassType)
+vec4 pass_0()
+{
+    vec4 color = texture(u_source, vo_texture);
+
+    if (texture(u_depth, vo_texture).r == 1.0)
+        return color;
+
+    uvec4 data = texture(u_material, vo_texture);
+    vec3 P = texture(u_position, vo_texture).xyz;
+    vec3 N = normalize(vec3(unpackHalf2x16(data.x), unpackHalf2x16(data.y).x));
+    vec3 albedo = unpackUnorm4x8(data.z).rgb;
+    vec2 rm = unpackHalf2x16(data.w);
+
+    float gloss = 1.0 - rm.x;
+    if (gloss <= 0.0)
+        return color;
+
+    vec3 V = normalize(P - f_camera);
+    vec3 R = reflect(V, N);
+
+    vec3 origin = (f_view * vec4(P, 1.0)).xyz;
+    vec3 dir = normalize(mat3(f_view) * R);
+
+    // Rays towards the camera leave the depth buffer almost at once.
+    if (dir.z > 0.0)
+        return color;
+
+    vec3 step = dir * (f_max_distance / float(max(f_steps, 1)));
+    vec3 pos = origin;
+    vec2 uv = vec2(0.0);
+    bool hit = false;
+
+    for (int i = 0; i < f_steps; i++) {
+        pos += step;
+        uv = project(pos);
+
+        if (any(lessThan(uv, vec2(0.0))) || any(greaterThan(uv, vec2(1.0))))
+            break;
+
+        float delta = scene_depth(uv) - pos.z;
+        if (delta > 0.0 && delta < f_thickness) {
+            hit = true;
+            break;
+        }
+    }
+
+    if (!hit)
+        return color;
+
+    for (int i = 0; i < f_refine; i++) {
+        step *= 0.5;
+        uv = project(pos);
+
+        if (scene_depth(uv) - pos.z > 0.0)
+            pos -= step;
+        else
+            pos += step;
+    }
+    uv = project(pos);
+
+    vec2 edge = smoothstep(vec2(0.0), vec2(0.1), uv) * (1.0 - smoothstep(vec2(0.9), vec2(1.0), uv));
+    float fade = edge.x * edge.y * (1.0 - clamp(distance(pos, origin) / f_max_distance, 0.0, 1.0));
+
+    vec3 F0 = mix(vec3(0.04), albedo, rm.y);
+    vec3 F = F0 + (1.0 - F0) * pow(1.0 - max(dot(N, -V), 0.0), 5.0);
+
+    vec3 reflection = texture(u_source, uv).rgb;
+    color.rgb += reflection * F * gloss * fade * f_intensity;
+
+    return color;
+}
+
+#endif
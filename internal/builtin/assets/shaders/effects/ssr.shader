@@ -0,0 +1,7 @@
+{
+    "name": "effect/ssr",
+    "files": [
+        "../utils/base.glsl",
+        "ssr.glsl"
+    ]
+}
@@ -1,79 +1,115 @@
 #ifdef _FRAGMENT_
 
-uniform mat3 rgb2xyz = mat3(
-  0.4124564, 0.2126729, 0.0193339,
-  0.3575761, 0.7151522, 0.1191920,
-  0.1804375, 0.0721750, 0.9503041 );
-
-uniform mat3 xyz2rgb = mat3(
-  3.2404542, -0.9692660, 0.0556434,
-  -1.5371385, 1.8760108, -0.2040259,
-  -0.4985314, 0.0415560, 1.0572252 );
+// Operators: 0 Reinhard, 1 extended Reinhard, 2 ACES, 3 Uncharted 2.
+uniform int f_operator;
+
+uniform float f_exposure = 1.0;
+uniform bool f_auto_exposure;
+uniform float f_key = 0.18;
+uniform float f_min_exposure = 0.1;
+uniform float f_max_exposure = 10.0;
+uniform float f_white = 4.0;
+uniform float f_adaptation = 1.0;
+uniform vec2 f_texel;
 
 uniform vec3 lum_factor = vec3(0.2126, 0.7152, 0.0722);
 
-uniform float f_exposure = 0.35;
-uniform float f_average_gray;
-uniform float f_white = 0.928;
-uniform float f_adaptation_speed;
-uniform vec2 f_image_size = vec2(1280, 720);
-
 layout(binding = 2) uniform sampler2D u_avg_luminance;
 
+vec3 reinhard(vec3 c)
+{
+    return c / (c + vec3(1.0));
+}
 
-vec3 gamma_correct(vec3 c) {
-    vec3 color = c / (c + vec3(1.0));
-    color = pow(color, vec3(1.0/2.2));
+vec3 reinhard_extended(vec3 c)
+{
+    return c * (vec3(1.0) + c / (f_white * f_white)) / (c + vec3(1.0));
+}
+
+vec3 aces(vec3 c)
+{
+    const float a = 2.51;
+    const float b = 0.03;
+    const float d = 0.59;
+    const float e = 0.14;
 
-    return color;
+    return clamp((c * (a * c + b)) / (c * (2.43 * c + d) + e), 0.0, 1.0);
 }
 
-subroutine(RenderPassType)
-vec4 pass_avg_luminance()
+vec3 uncharted2_curve(vec3 x)
 {
-    float lum = dot(texture(u_source, vo_texture).rgb, lum_factor);
+    const float A = 0.15;
+    const float B = 0.50;
+    const float C = 0.10;
+    const float D = 0.20;
+    const float E = 0.02;
+    const float F = 0.30;
+
+    return ((x * (A * x + C * B) + D * E) / (x * (A * x + B) + D * F)) - E / F;
+}
 
-    return vec4(log(lum + 0.00001));
+vec3 uncharted2(vec3 c)
+{
+    return uncharted2_curve(c * 2.0) / uncharted2_curve(vec3(f_white));
 }
 
+// Log luminance of the source, averaged by the downsample passes.
 subroutine(RenderPassType)
-vec4 pass_basic()
+vec4 pass_luminance()
 {
-    vec4 color = texture(u_source, vo_texture);
-    float average_luminance = textureLod(u_avg_luminance, vo_texture, 8).r;
-    average_luminance = exp(average_luminance / (f_image_size.x * f_image_size.y));
-
-    // Convert to XYZ
-    vec3 xyzCol = rgb2xyz * color.rgb;
+    float lum = dot(texture(u_source, vo_texture).rgb, lum_factor);
 
-    // Convert to xyY
-    float xyzSum = xyzCol.x + xyzCol.y + xyzCol.z;
-    vec3 xyYCol = vec3( xyzCol.x / xyzSum, xyzCol.y / xyzSum, xyzCol.y);
+    return vec4(log(max(lum, 0.0001)));
+}
 
-    // Apply the tone mapping operation to the luminance (xyYCol.z or xyzCol.y)
-    float L = (f_exposure * xyYCol.z) / average_luminance;
-    L = (L * (1 + L / (f_white * f_white) )) / (1 + L);
+// Averages a 4x4 block of source texels.
+subroutine(RenderPassType)
+vec4 pass_downsample()
+{
+    float sum = 0.0;
 
-    // Using the new luminance, convert back to XYZ
-    xyzCol.x = (L * xyYCol.x) / (xyYCol.y);
-    xyzCol.y = L;
-    xyzCol.z = (L * (1 - xyYCol.x - xyYCol.y))/xyYCol.y;
+    for (int x = 0; x < 4; x++) {
+        for (int y = 0; y < 4; y++) {
+            sum += texture(u_source, vo_texture + (vec2(x, y) - 1.5) * f_texel).r;
+        }
+    }
 
-    return vec4(gamma_correct(xyz2rgb * xyzCol), 1.0);
+    return vec4(sum / 16.0);
 }
 
+// Moves the adapted luminance towards the average luminance of this frame.
 subroutine(RenderPassType)
-vec4 pass_basic_reinhard()
+vec4 pass_adapt()
 {
-    // Not yet implemented
-    return texture(u_source, vo_texture);
+    float current = exp(texture(u_source, vec2(0.5)).r);
+    float previous = texture(u_avg_luminance, vec2(0.5)).r;
+
+    return vec4(mix(previous, current, f_adaptation));
 }
 
 subroutine(RenderPassType)
-vec4 pass_adaptive_reinhard()
+vec4 pass_tonemap()
 {
-    // Not yet implemented
-    return texture(u_source, vo_texture);
+    vec3 color = texture(u_source, vo_texture).rgb;
+
+    float exposure = f_exposure;
+    if (f_auto_exposure) {
+        float average = max(texture(u_avg_luminance, vec2(0.5)).r, 0.0001);
+        exposure = clamp(f_key / average, f_min_exposure, f_max_exposure);
+    }
+
+    color *= exposure;
+
+    if (f_operator == 1)
+        color = reinhard_extended(color);
+    else if (f_operator == 2)
+        color = aces(color);
+    else if (f_operator == 3)
+        color = uncharted2(color);
+    else
+        color = reinhard(color);
+
+    return vec4(pow(color, vec3(1.0 / 2.2)), 1.0);
 }
 
 #endif
@@ -1,5 +1,4 @@
 #ifdef _VERTEX_
-uniform mat4 v_view_matrix;
 uniform mat4 v_model_matrix;
 uniform uint v_offset;
 
@@ -43,7 +42,6 @@ out vec2 go_texture;
 in flat uint vo_index[];
 out flat uint go_index;
 
-uniform mat4 v_projection_matrix;
 uniform float g_quad_length = 0.02f;
 
 void main()
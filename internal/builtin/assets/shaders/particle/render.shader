@@ -1,6 +1,7 @@
 {
     "name": "particle/render",
     "files": [
+        "../utils/uniforms.glsl",
         "render.glsl"
     ]
 }
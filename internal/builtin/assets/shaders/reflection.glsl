@@ -8,8 +8,6 @@ out vec3 vo_normal;
 out vec2 vo_texture;
 
 uniform mat4 v_mvp_matrix;
-uniform mat4 v_projection_matrix;
-uniform mat4 v_view_matrix;
 uniform mat4 v_model_matrix;
 uniform mat3 v_normal_matrix;
 
@@ -31,8 +29,6 @@ in vec2 vo_texture;
 
 out vec4 fo_color;
 
-uniform vec3 f_camera;
-
 layout(binding = 0) uniform samplerCube f_environment;
 
 void main()
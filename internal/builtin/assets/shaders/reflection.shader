@@ -1,6 +1,7 @@
 {
     "name": "reflection",
     "files": [
+        "utils/uniforms.glsl",
         "reflection.glsl"
     ]
 }
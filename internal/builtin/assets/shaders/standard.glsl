@@ -2,19 +2,24 @@
 layout(location = 0) in vec3 vertex;
 layout(location = 1) in vec3 normal;
 layout(location = 2) in vec2 uv;
+layout(location = 3) in vec4 tangent;
 
 out vec3 vo_position;
 out vec3 vo_normal;
 out vec3 vo_eye;
 out vec3 vo_ws_position;
 out vec3 vo_ws_normal;
+out vec4 vo_ws_tangent;
 out vec2 vo_texture;
+out vec4 vo_clip;
+out vec4 vo_prev_clip;
 
-uniform mat4 v_mvp_matrix;
-uniform mat4 v_projection_matrix;
-uniform mat4 v_view_matrix;
 uniform mat4 v_model_matrix;
 uniform mat3 v_normal_matrix;
+uniform mat4 v_prev_model_matrix;
+
+// Full screen passes draw a quad which is already in clip space.
+uniform bool v_screen_space;
 
 void main()
 {
@@ -22,9 +27,16 @@ void main()
     vo_normal = normal;// normalize(v_normal_matrix * normal);
     vo_position = vertex;
     vo_ws_position = vec3(v_model_matrix * vec4(vertex, 1.0));
-    vo_ws_normal = vec3(v_model_matrix * vec4(normal, 1.0));
+    vo_ws_normal = mat3(v_model_matrix) * normal;
+    vo_ws_tangent = vec4(mat3(v_model_matrix) * tangent.xyz, tangent.w);
+
+    if (v_screen_space)
+        gl_Position = vec4(vertex, 1.0);
+    else
+        gl_Position = v_projection_matrix * v_view_matrix * v_model_matrix * vec4(vertex, 1.0);
 
-    gl_Position = v_projection_matrix * v_view_matrix * v_model_matrix * vec4(vertex, 1.0);
+    vo_clip = gl_Position;
+    vo_prev_clip = v_prev_view_projection_matrix * v_prev_model_matrix * vec4(vertex, 1.0);
 }
 
 #endif
@@ -38,24 +50,74 @@ in vec3 vo_normal;
 in vec3 vo_eye;
 in vec3 vo_ws_position;
 in vec3 vo_ws_normal;
+in vec4 vo_ws_tangent;
 in vec2 vo_texture;
+in vec4 vo_clip;
+in vec4 vo_prev_clip;
 
 layout(location = 0) out vec4 fo_attachment0;
 layout(location = 1) out uvec4 fo_attachment1;
+layout(location = 2) out vec4 fo_emissive;
+layout(location = 3) out vec2 fo_velocity;
 
 layout(binding = 0) uniform sampler2D f_attachment0;
 layout(binding = 1) uniform usampler2D f_attachment1;
 layout(binding = 2) uniform sampler2D f_depth;
 layout(binding = 3) uniform samplerCube f_environment;
+#ifdef ARC_PROFILE_ES
+// GLSL ES 3.00 cannot query the mip levels of a texture.
+uniform int f_environment_levels;
+#define textureQueryLevels(s) f_environment_levels
+#endif
 layout(binding = 4) uniform samplerCube f_irradiance;
 layout(binding = 5) uniform sampler2D f_albedo_map;
-layout(binding = 6) uniform sampler2D f_metallic_map;
-layout(binding = 7) uniform sampler2D f_normal_map;
+layout(binding = 6) uniform sampler2D f_normal_map;
+layout(binding = 7) uniform sampler2D f_metallic_map;
+layout(binding = 8) uniform sampler2DArrayShadow f_shadow_map;
+layout(binding = 9) uniform sampler2D f_ambient_occlusion;
+layout(binding = 10) uniform sampler2D f_brdf;
+layout(binding = 11) uniform sampler2D f_occlusion_map;
+layout(binding = 12) uniform sampler2D f_emissive_map;
+layout(binding = 13) uniform sampler2D f_attachment2;
 
-uniform vec3 f_camera;
 uniform vec3 f_albedo;
 uniform float f_roughness;
 uniform float f_metallic;
+uniform vec3 f_emissive;
+
+uniform bool f_albedo_map_enabled;
+uniform bool f_normal_map_enabled;
+uniform bool f_metallic_map_enabled;
+uniform bool f_occlusion_map_enabled;
+uniform bool f_emissive_map_enabled;
+
+// Index of the light being drawn in f_lights, or -1 to draw without a light.
+uniform int f_light_index = -1;
+
+// Light types: -1 none, 0 directional, 1 point, 2 spot.
+struct Light
+{
+    int type;
+    vec3 position;
+    vec3 direction;
+    vec3 color;
+    float range;
+    vec2 spot;
+};
+
+Light light;
+
+uniform bool f_shadow_enabled;
+uniform mat4 f_shadow_matrices[4];
+uniform vec4 f_shadow_splits;
+uniform int f_shadow_cascades;
+uniform mat4 f_shadow_view;
+uniform float f_shadow_bias;
+uniform int f_shadow_kernel;
+uniform float f_shadow_texel;
+
+uniform bool f_ao_enabled;
+uniform bool f_ibl_enabled;
 
 #define PI   3.1415926535897932384626433832795
 #define PI2  6.2831853071795864769252867665590
@@ -80,6 +142,11 @@ vec3 get_albedo(uvec4 data)
     return unpackUnorm4x8(data.z).rgb;
 }
 
+float get_occlusion(uvec4 data)
+{
+    return unpackUnorm4x8(data.z).a;
+}
+
 vec3 get_reflection(vec3 dir)
 {
     return texture(f_environment, dir).rgb;
@@ -131,23 +198,248 @@ vec3 fresnelSchlick(float cosTheta, vec3 F0)
     return F0 + (1.0 - F0) * pow(1.0 - cosTheta, 5.0);
 }
 
+vec3 fresnelSchlickRoughness(float cosTheta, vec3 F0, float roughness)
+{
+    return F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(1.0 - cosTheta, 5.0);
+}
+
+// Image based lighting with the split sum approximation. The specular map
+// holds the environment prefiltered for increasing roughness in its mip
+// levels, and f_brdf the scale and bias applied to F0.
+vec3 ambient(vec3 N, vec3 V, vec3 albedo, float metallic, float roughness)
+{
+    float NdotV = max(dot(N, V), 0.0);
+
+    vec3 F0 = mix(vec3(0.04), albedo, metallic);
+    vec3 F = fresnelSchlickRoughness(NdotV, F0, roughness);
+    vec3 kD = (vec3(1.0) - F) * (1.0 - metallic);
+
+    vec3 diffuse = texture(f_irradiance, N).rgb * albedo;
+
+    float lod = roughness * float(textureQueryLevels(f_environment) - 1);
+    vec3 prefiltered = textureLod(f_environment, reflect(-V, N), lod).rgb;
+    vec2 brdf = texture(f_brdf, vec2(NdotV, roughness)).rg;
+    vec3 specular = prefiltered * (F * brdf.x + brdf.y);
+
+    return kD * diffuse + specular;
+}
+
+float shadow(vec3 P, float NdotL)
+{
+    float depth = -(f_shadow_view * vec4(P, 1.0)).z;
+
+    int cascade = 0;
+    for (int i = 0; i < f_shadow_cascades - 1; i++) {
+        if (depth > f_shadow_splits[i])
+            cascade = i + 1;
+    }
+
+    if (depth > f_shadow_splits[f_shadow_cascades - 1])
+        return 1.0;
+
+    vec4 projected = f_shadow_matrices[cascade] * vec4(P, 1.0);
+    vec3 coords = projected.xyz / projected.w * 0.5 + 0.5;
+
+    if (any(lessThan(coords, vec3(0.0))) || any(greaterThan(coords, vec3(1.0))))
+        return 1.0;
+
+    float bias = max(f_shadow_bias * (1.0 - NdotL), f_shadow_bias * 0.1) * float(cascade + 1);
+    float lit = 0.0;
+
+    for (int x = -f_shadow_kernel; x <= f_shadow_kernel; x++) {
+        for (int y = -f_shadow_kernel; y <= f_shadow_kernel; y++) {
+            vec2 offset = vec2(x, y) * f_shadow_texel;
+            lit += texture(f_shadow_map, vec4(coords.xy + offset, float(cascade), coords.z - bias));
+        }
+    }
+
+    float samples = float((2 * f_shadow_kernel + 1) * (2 * f_shadow_kernel + 1));
+
+    return lit / samples;
+}
+
+vec3 light_radiance(vec3 P, out vec3 L)
+{
+    if (light.type == 0) {
+        L = normalize(-light.direction);
+        return light.color;
+    }
+
+    vec3 D = light.position - P;
+    float distance = length(D);
+    L = D / distance;
+
+    float window = clamp(1.0 - pow(distance / light.range, 4.0), 0.0, 1.0);
+    float attenuation = window * window / (distance * distance + 1.0);
+
+    if (light.type == 2) {
+        float theta = dot(-L, normalize(light.direction));
+        attenuation *= smoothstep(light.spot.y, light.spot.x, theta);
+    }
+
+    return light.color * attenuation;
+}
+
+vec3 shade(vec3 P, vec3 N, vec3 V, vec3 albedo, float metallic, float roughness)
+{
+    vec3 L;
+    vec3 radiance = light_radiance(P, L);
+    vec3 H = normalize(V + L);
+
+    vec3 F0 = mix(vec3(0.04), albedo, metallic);
+    float k = (roughness + 1.0) * (roughness + 1.0) / 8.0;
+
+    float NDF = DistributionGGX(N, H, roughness * roughness);
+    float G = GeometrySmith(N, V, L, k);
+    vec3 F = fresnelSchlick(max(dot(H, V), 0.0), F0);
+
+    float NdotL = max(dot(N, L), 0.0);
+    float NdotV = max(dot(N, V), 0.0);
+
+    if (light.type == 0 && f_shadow_enabled)
+        radiance *= shadow(P, NdotL);
+
+    vec3 specular = (NDF * G * F) / max(4.0 * NdotV * NdotL, 0.001);
+    vec3 kD = (vec3(1.0) - F) * (1.0 - metallic);
+
+    return (kD * albedo / PI + specular) * radiance * NdotL;
+}
+
+// Surface is the material of a drawable at a fragment.
+struct Surface
+{
+    vec3 albedo;
+    vec3 N;
+    vec3 emissive;
+    float metallic;
+    float roughness;
+    float occlusion;
+};
+
+// perturb_normal applies a tangent space normal map. Meshes without
+// tangents use a tangent frame built from screen space derivatives.
+vec3 perturb_normal(vec3 N, vec3 P, vec2 uv)
+{
+    vec3 map = texture(f_normal_map, uv).xyz * 2.0 - 1.0;
+
+    if (dot(vo_ws_tangent.xyz, vo_ws_tangent.xyz) > 0.0) {
+        vec3 T = normalize(vo_ws_tangent.xyz - N * dot(N, vo_ws_tangent.xyz));
+        vec3 B = cross(N, T) * vo_ws_tangent.w;
+
+        return normalize(mat3(T, B, N) * map);
+    }
+
+    vec3 dp1 = dFdx(P);
+    vec3 dp2 = dFdy(P);
+    vec2 duv1 = dFdx(uv);
+    vec2 duv2 = dFdy(uv);
+
+    vec3 dp2perp = cross(dp2, N);
+    vec3 dp1perp = cross(N, dp1);
+    vec3 T = dp2perp * duv1.x + dp1perp * duv2.x;
+    vec3 B = dp2perp * duv1.y + dp1perp * duv2.y;
+
+    float scale = inversesqrt(max(dot(T, T), dot(B, B)));
+
+    return normalize(mat3(T * scale, B * scale, N) * map);
+}
+
+// surface combines the material factors with its maps. Color maps are sRGB,
+// metallic roughness maps hold roughness in green and metallic in blue.
+Surface surface()
+{
+    Surface s;
+
+    s.albedo = f_albedo;
+    s.metallic = f_metallic;
+    s.roughness = f_roughness;
+    s.emissive = f_emissive;
+    s.occlusion = 1.0;
+    s.N = normalize(vo_ws_normal);
+
+    if (f_albedo_map_enabled)
+        s.albedo *= pow(texture(f_albedo_map, vo_texture).rgb, vec3(2.2));
+
+    if (f_metallic_map_enabled) {
+        vec4 mr = texture(f_metallic_map, vo_texture);
+        s.roughness *= mr.g;
+        s.metallic *= mr.b;
+    }
+
+    if (f_normal_map_enabled)
+        s.N = perturb_normal(s.N, vo_ws_position, vo_texture);
+
+    if (f_occlusion_map_enabled)
+        s.occlusion = texture(f_occlusion_map, vo_texture).r;
+
+    if (f_emissive_map_enabled)
+        s.emissive *= pow(texture(f_emissive_map, vo_texture).rgb, vec3(2.2));
+
+    return s;
+}
+
+// Drawables fading between two levels of detail are dithered. A positive
+// fade keeps the pixels below it, a negative fade the pixels above it.
+uniform float f_lod_fade;
+
+void lod_dither()
+{
+    if (f_lod_fade == 0.0)
+        return;
+
+    const float bayer[16] = float[16](
+        0.0, 8.0, 2.0, 10.0,
+        12.0, 4.0, 14.0, 6.0,
+        3.0, 11.0, 1.0, 9.0,
+        15.0, 7.0, 13.0, 5.0);
+
+    ivec2 p = ivec2(gl_FragCoord.xy) & 3;
+    float d = (bayer[p.y * 4 + p.x] + 0.5) / 16.0;
+
+    if (f_lod_fade > 0.0 ? d >= f_lod_fade : d < -f_lod_fade)
+        discard;
+}
+
 subroutine(RenderPassType)
 void forward_pass()
 {
-    vec3 N = vo_ws_normal;
+    lod_dither();
+
+    Surface s = surface();
+
+    if (light.type < 0) {
+        fo_attachment0 = vec4(s.albedo + s.emissive, 1.0);
+        return;
+    }
+
+    vec3 V = normalize(f_camera - vo_ws_position);
+    vec3 color = shade(vo_ws_position, s.N, V, s.albedo, s.metallic, s.roughness);
 
-    fo_attachment0 = vec4(N, 1.0);
+    // Lights are added on top of the first one, which also adds the
+    // emission.
+    if (f_light_index == 0)
+        color += s.emissive;
+
+    fo_attachment0 = vec4(color, 1.0);
 }
 
 subroutine(RenderPassType)
 void deferred_pass_geometry()
 {
+    lod_dither();
+
+    Surface s = surface();
+
     fo_attachment0.xyz = vo_ws_position;
 
-    fo_attachment1.x = packHalf2x16(vo_normal.xy);
-    fo_attachment1.y = packHalf2x16(vec2(vo_normal.z, 0.0));
-    fo_attachment1.z = packUnorm4x8(vec4(f_albedo, 1.0));
-    fo_attachment1.w = packHalf2x16(vec2(f_roughness, f_metallic));
+    fo_attachment1.x = packHalf2x16(s.N.xy);
+    fo_attachment1.y = packHalf2x16(vec2(s.N.z, 0.0));
+    fo_attachment1.z = packUnorm4x8(vec4(s.albedo, s.occlusion));
+    fo_attachment1.w = packHalf2x16(vec2(s.roughness, s.metallic));
+
+    fo_emissive = vec4(s.emissive, 1.0);
+
+    fo_velocity = (vo_clip.xy / vo_clip.w - vo_prev_clip.xy / vo_prev_clip.w) * 0.5;
 }
 
 subroutine(RenderPassType)
@@ -166,13 +458,64 @@ void deferred_pass_ambient()
     vec3 N = get_normal(data1);
     vec3 L = normalize(-reflect(V, N));
 
-    vec3 irradiance = texture(f_irradiance, L).rgb;
+    vec3 irradiance;
+    if (f_ibl_enabled)
+        irradiance = ambient(normalize(N), V, albedo, get_metallic(data1), get_roughness(data1));
+    else
+        irradiance = texture(f_irradiance, L).rgb;
+
+    irradiance *= get_occlusion(data1);
+    if (f_ao_enabled)
+        irradiance *= texture(f_ambient_occlusion, vo_texture).r;
+
+    vec3 emissive = texture(f_attachment2, vo_texture).rgb;
+
+    fo_attachment0 = vec4(irradiance + emissive, 1.0);
+}
 
-    fo_attachment0 = vec4(irradiance, 1.0);
+// Lights are drawn as a full screen quad or as a light volume, so the GBuffer
+// is read at the fragment position instead of the texture coordinates.
+subroutine(RenderPassType)
+void deferred_pass_light()
+{
+    vec2 uv = gl_FragCoord.xy / vec2(textureSize(f_depth, 0));
+
+    float depth = texture(f_depth, uv).r;
+    if (depth == 1.0)
+        discard;
+
+    vec4 data0 = texture(f_attachment0, uv);
+    uvec4 data1 = texture(f_attachment1, uv);
+
+    vec3 P = get_position(data0);
+    vec3 N = normalize(get_normal(data1));
+    vec3 V = normalize(f_camera - P);
+
+    vec3 color = shade(P, N, V, get_albedo(data1), get_metallic(data1), get_roughness(data1));
+
+    fo_attachment0 = vec4(color, 1.0);
+}
+
+void load_light()
+{
+    if (f_light_index < 0 || f_light_index >= MAX_LIGHTS) {
+        light.type = -1;
+        return;
+    }
+
+    LightData data = f_lights[f_light_index];
+
+    light.type = int(data.position.w);
+    light.position = data.position.xyz;
+    light.direction = data.direction.xyz;
+    light.color = data.color.rgb;
+    light.range = data.direction.w;
+    light.spot = data.spot.xy;
 }
 
 void main()
 {
+    load_light();
     RenderPass();
 }
 
@@ -2,6 +2,7 @@
     "name": "standard",
     "deferred": true,
     "files": [
+        "utils/uniforms.glsl",
         "standard.glsl"
     ]
 }
@@ -36,6 +36,7 @@ uniform bool f_texture_mode;
 uniform bool f_texture_tint;
 uniform bool f_invert_x;
 uniform bool f_invert_y;
+uniform vec4 f_uv_rect;
 
 void main()
 {
@@ -46,6 +47,7 @@ void main()
             uv.x = 1.0 - uv.x;
         if (f_invert_y)
             uv.y = 1.0 - uv.y;
+        uv = f_uv_rect.xy + uv * f_uv_rect.zw;
 
         if (f_texture_tint)
         {
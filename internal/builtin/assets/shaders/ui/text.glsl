@@ -33,9 +33,48 @@ layout(binding = 0) uniform sampler2D f_source_a;
 uniform vec4 f_color;
 uniform float f_alpha;
 
+// Distance field fonts store 0.5 on the outline of a glyph, rising inside.
+// Widths are in the same units, with one unit on either side of an outline.
+uniform bool f_sdf;
+uniform vec4 f_outline_color;
+uniform float f_outline_width;
+uniform vec4 f_glow_color;
+uniform float f_glow_width;
+
+// over composites a onto b.
+vec4 over(vec4 a, vec4 b)
+{
+    float alpha = a.a + b.a * (1.0 - a.a);
+    if (alpha <= 0.0) {
+        return vec4(0.0);
+    }
+
+    return vec4((a.rgb * a.a + b.rgb * b.a * (1.0 - a.a)) / alpha, alpha);
+}
+
 void main()
 {
-    fo_color = vec4(f_color.rgb, texture(f_source_a, vo_texture).r * f_color.a * f_alpha);
+    float d = texture(f_source_a, vo_texture).r;
+
+    if (!f_sdf) {
+        fo_color = vec4(f_color.rgb, d * f_color.a * f_alpha);
+        return;
+    }
+
+    float aa = max(fwidth(d), 1e-4);
+    float edge = 0.5 - min(f_outline_width, 0.5);
+
+    float fill = smoothstep(0.5 - aa, 0.5 + aa, d);
+    float outline = smoothstep(edge - aa, edge + aa, d);
+    float glow = f_glow_width > 0.0 ? smoothstep(edge - f_glow_width, edge, d) : 0.0;
+
+    vec4 color = vec4(f_color.rgb, f_color.a * fill);
+    if (f_outline_width > 0.0) {
+        color = over(color, vec4(f_outline_color.rgb, f_outline_color.a * outline));
+    }
+    color = over(color, vec4(f_glow_color.rgb, f_glow_color.a * glow));
+
+    fo_color = vec4(color.rgb, color.a * f_alpha);
 }
 
 
@@ -0,0 +1,66 @@
+#ifdef _VERTEX_
+layout(location = 0) in vec3 vertex;
+layout(location = 1) in vec3 normal;
+layout(location = 2) in vec2 uv;
+
+out vec2 vo_texture;
+
+void main()
+{
+    vo_texture = uv;
+
+    gl_Position = vec4(vertex, 1.0);
+}
+
+#endif
+
+#ifdef _FRAGMENT_
+in vec2 vo_texture;
+
+out vec2 fo_color;
+
+const uint samples = 1024u;
+
+float geometry_schlick_ggx(float NdotV, float roughness)
+{
+    float k = roughness * roughness / 2.0;
+
+    return NdotV / (NdotV * (1.0 - k) + k);
+}
+
+// Integrates the scale and bias applied to F0 by the split sum approximation
+// for a view angle and roughness.
+void main()
+{
+    float NdotV = max(vo_texture.x, 0.001);
+    float roughness = vo_texture.y;
+
+    vec3 V = vec3(sqrt(1.0 - NdotV * NdotV), 0.0, NdotV);
+    vec3 N = vec3(0.0, 0.0, 1.0);
+
+    float A = 0.0;
+    float B = 0.0;
+
+    for (uint i = 0u; i < samples; i++) {
+        vec3 H = importance_sample_ggx(hammersley(i, samples), N, roughness);
+        vec3 L = normalize(2.0 * dot(V, H) * H - V);
+
+        float NdotL = max(L.z, 0.0);
+        if (NdotL <= 0.0)
+            continue;
+
+        float NdotH = max(H.z, 0.0);
+        float VdotH = max(dot(V, H), 0.0);
+
+        float G = geometry_schlick_ggx(NdotV, roughness) * geometry_schlick_ggx(NdotL, roughness);
+        float G_vis = G * VdotH / (NdotH * NdotV);
+        float Fc = pow(1.0 - VdotH, 5.0);
+
+        A += (1.0 - Fc) * G_vis;
+        B += Fc * G_vis;
+    }
+
+    fo_color = vec2(A, B) / float(samples);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "utils/brdf",
+    "files": [
+        "ibl.glsl",
+        "brdf.glsl"
+    ]
+}
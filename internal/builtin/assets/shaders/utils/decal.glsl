@@ -0,0 +1,54 @@
+#ifdef _VERTEX_
+layout(location = 0) in vec3 vertex;
+
+uniform mat4 v_model_matrix;
+
+void main()
+{
+    gl_Position = v_projection_matrix * v_view_matrix * v_model_matrix * vec4(vertex, 1.0);
+}
+
+#endif
+
+#ifdef _FRAGMENT_
+layout(binding = 0) uniform sampler2D f_attachment0;
+layout(binding = 1) uniform usampler2D f_attachment1;
+layout(binding = 2) uniform sampler2D f_depth;
+layout(binding = 3) uniform sampler2D f_decal;
+
+uniform mat4 f_inverse_model_matrix;
+uniform vec3 f_direction;
+uniform vec4 f_color;
+uniform float f_normal_threshold;
+
+// Only the second attachment of the GBuffer is bound for drawing.
+layout(location = 0) out uvec4 fo_attachment1;
+
+// The decal box is drawn with its back faces, so the GBuffer is read at the
+// fragment position.
+void main()
+{
+    vec2 uv = gl_FragCoord.xy / vec2(textureSize(f_depth, 0));
+
+    if (texture(f_depth, uv).r == 1.0)
+        discard;
+
+    vec3 P = texture(f_attachment0, uv).xyz;
+    vec3 local = (f_inverse_model_matrix * vec4(P, 1.0)).xyz;
+    if (any(greaterThan(abs(local), vec3(1.0))))
+        discard;
+
+    uvec4 data = texelFetch(f_attachment1, ivec2(gl_FragCoord.xy), 0);
+    vec3 N = normalize(vec3(unpackHalf2x16(data.x), unpackHalf2x16(data.y).x));
+    if (dot(N, -f_direction) < f_normal_threshold)
+        discard;
+
+    vec4 color = texture(f_decal, local.xy * 0.5 + 0.5) * f_color;
+    vec4 base = unpackUnorm4x8(data.z);
+    vec3 albedo = mix(base.rgb, color.rgb, color.a);
+
+    // The alpha channel holds the occlusion of the surface.
+    fo_attachment1 = uvec4(data.x, data.y, packUnorm4x8(vec4(albedo, base.a)), data.w);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "utils/decal",
+    "files": [
+        "uniforms.glsl",
+        "decal.glsl"
+    ]
+}
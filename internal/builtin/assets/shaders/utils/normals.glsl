@@ -0,0 +1,31 @@
+#ifdef _VERTEX_
+layout(location = 0) in vec3 vertex;
+layout(location = 1) in vec3 normal;
+
+out vec3 vo_normal;
+
+uniform mat4 v_model_matrix;
+
+void main()
+{
+    mat4 model_view = v_view_matrix * v_model_matrix;
+
+    vo_normal = transpose(inverse(mat3(model_view))) * normal;
+
+    gl_Position = v_projection_matrix * model_view * vec4(vertex, 1.0);
+}
+
+#endif
+
+#ifdef _FRAGMENT_
+in vec3 vo_normal;
+
+layout(location = 0) out vec4 fo_normal;
+
+// The alpha channel is 1 where geometry was drawn and 0 for the background.
+void main()
+{
+    fo_normal = vec4(normalize(vo_normal), 1.0);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "utils/normals",
+    "files": [
+        "uniforms.glsl",
+        "normals.glsl"
+    ]
+}
@@ -0,0 +1,19 @@
+#ifdef _VERTEX_
+layout(location = 0) in vec3 vertex;
+
+uniform mat4 v_model_matrix;
+uniform mat4 v_view_projection_matrix;
+
+void main()
+{
+    gl_Position = v_view_projection_matrix * v_model_matrix * vec4(vertex, 1.0);
+}
+
+#endif
+
+#ifdef _FRAGMENT_
+void main()
+{
+}
+
+#endif
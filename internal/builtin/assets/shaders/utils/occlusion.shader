@@ -0,0 +1,6 @@
+{
+    "name": "utils/occlusion",
+    "files": [
+        "occlusion.glsl"
+    ]
+}
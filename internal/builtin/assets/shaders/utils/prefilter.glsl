@@ -0,0 +1,99 @@
+#ifdef _VERTEX_
+layout(location = 0) in vec3 vertex;
+layout(location = 1) in vec3 normal;
+layout(location = 2) in vec2 uv;
+
+out vec3 vo_position;
+
+uniform mat4 v_projection_matrix;
+uniform mat4 v_view_matrix;
+
+void main()
+{
+    vo_position = vec3(v_projection_matrix * v_view_matrix * vec4(vertex, 1.0));
+
+    gl_Position = vec4(vec3(vertex.x * -1.0, vertex.yz), 1.0);
+}
+
+#endif
+
+#ifdef _FRAGMENT_
+subroutine vec3 PrefilterType(vec3 N);
+subroutine uniform PrefilterType Prefilter;
+
+in vec3 vo_position;
+
+out vec4 fo_color;
+
+layout(binding = 0) uniform samplerCube f_radiance;
+
+uniform float f_roughness;
+uniform float f_resolution;
+uniform uint f_samples = 1024u;
+
+// Convolves the radiance with a cosine lobe around N.
+subroutine(PrefilterType)
+vec3 pass_irradiance(vec3 N)
+{
+    vec3 up = abs(N.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(0.0, 0.0, 1.0);
+    vec3 right = normalize(cross(up, N));
+    up = cross(N, right);
+
+    const float delta = 0.025;
+
+    vec3 irradiance = vec3(0.0);
+    float count = 0.0;
+
+    for (float phi = 0.0; phi < 2.0 * M_PI; phi += delta) {
+        for (float theta = 0.0; theta < 0.5 * M_PI; theta += delta) {
+            vec3 t = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+            vec3 dir = t.x * right + t.y * up + t.z * N;
+
+            irradiance += texture(f_radiance, dir).rgb * cos(theta) * sin(theta);
+            count += 1.0;
+        }
+    }
+
+    return M_PI * irradiance / count;
+}
+
+// Convolves the radiance with the GGX distribution of f_roughness, assuming
+// the view direction equals the normal. Samples are read from the mip level
+// matching their solid angle to avoid aliasing.
+subroutine(PrefilterType)
+vec3 pass_specular(vec3 N)
+{
+    vec3 V = N;
+
+    float texel = 4.0 * M_PI / (6.0 * f_resolution * f_resolution);
+
+    vec3 color = vec3(0.0);
+    float weight = 0.0;
+
+    for (uint i = 0u; i < f_samples; i++) {
+        vec3 H = importance_sample_ggx(hammersley(i, f_samples), N, f_roughness);
+        vec3 L = normalize(2.0 * dot(V, H) * H - V);
+
+        float NdotL = dot(N, L);
+        if (NdotL <= 0.0)
+            continue;
+
+        float NdotH = max(dot(N, H), 0.0);
+        float HdotV = max(dot(H, V), 0.0);
+        float pdf = distribution_ggx(NdotH, f_roughness) * NdotH / (4.0 * HdotV) + 0.0001;
+        float solid_angle = 1.0 / (float(f_samples) * pdf + 0.0001);
+        float lod = f_roughness == 0.0 ? 0.0 : 0.5 * log2(solid_angle / texel);
+
+        color += textureLod(f_radiance, L, lod).rgb * NdotL;
+        weight += NdotL;
+    }
+
+    return color / max(weight, 0.0001);
+}
+
+void main()
+{
+    fo_color = vec4(Prefilter(normalize(vo_position)), 1.0);
+}
+
+#endif
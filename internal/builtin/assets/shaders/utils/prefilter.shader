@@ -0,0 +1,7 @@
+{
+    "name": "utils/prefilter",
+    "files": [
+        "ibl.glsl",
+        "prefilter.glsl"
+    ]
+}
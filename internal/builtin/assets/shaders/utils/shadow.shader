@@ -0,0 +1,6 @@
+{
+    "name": "utils/shadow",
+    "files": [
+        "shadow.glsl"
+    ]
+}
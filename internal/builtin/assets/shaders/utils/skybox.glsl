@@ -5,9 +5,6 @@ layout(location = 2) in vec2 uv;
 
 out vec3 vo_eye;
 
-uniform mat4 v_view_matrix;
-uniform mat4 v_projection_matrix;
-
 void main()
 {
     mat4 inverse_projection = inverse(v_projection_matrix);
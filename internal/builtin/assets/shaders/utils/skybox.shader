@@ -1,6 +1,7 @@
 {
     "name": "utils/skybox",
     "files": [
+        "uniforms.glsl",
         "skybox.glsl"
     ]
 }
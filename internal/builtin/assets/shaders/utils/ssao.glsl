@@ -0,0 +1,77 @@
+#ifdef _FRAGMENT_
+
+layout(binding = 2) uniform sampler2D f_position;
+layout(binding = 3) uniform usampler2D f_normal;
+
+uniform mat4 f_view;
+uniform mat4 f_projection;
+uniform vec3 f_kernel[64];
+uniform int f_samples = 16;
+uniform float f_radius = 0.5;
+uniform float f_bias = 0.025;
+uniform vec2 f_texel;
+
+float hash(vec2 uv, vec2 seed)
+{
+    return fract(sin(dot(uv, seed)) * 43758.5453) * 2.0 - 1.0;
+}
+
+// Per pixel random vector used to rotate the sample kernel. The noise is
+// removed by the blur pass.
+vec3 random_vector(vec2 uv)
+{
+    return vec3(hash(uv, vec2(12.9898, 78.233)), hash(uv, vec2(39.3468, 11.1353)), hash(uv, vec2(73.156, 52.235)));
+}
+
+// Samples a hemisphere around the world space position and normal in the
+// GBuffer, and counts how many samples are behind the visible surface.
+subroutine(RenderPassType)
+vec4 pass_occlusion()
+{
+    if (texture(u_depth, vo_texture).r == 1.0)
+        return vec4(1.0);
+
+    vec3 P = texture(f_position, vo_texture).xyz;
+    uvec4 data = texture(f_normal, vo_texture);
+    vec3 N = normalize(vec3(unpackHalf2x16(data.x), unpackHalf2x16(data.y).x));
+
+    vec3 R = random_vector(vo_texture);
+    vec3 T = normalize(R - N * dot(R, N));
+    vec3 B = cross(N, T);
+    mat3 TBN = mat3(T, B, N);
+
+    float depth = (f_view * vec4(P, 1.0)).z;
+    float occlusion = 0.0;
+
+    for (int i = 0; i < f_samples; i++) {
+        vec4 view = f_view * vec4(P + TBN * f_kernel[i] * f_radius, 1.0);
+        vec4 clip = f_projection * view;
+        vec2 uv = clip.xy / clip.w * 0.5 + 0.5;
+
+        if (texture(u_depth, uv).r == 1.0)
+            continue;
+
+        float scene_depth = (f_view * vec4(texture(f_position, uv).xyz, 1.0)).z;
+        float range = smoothstep(0.0, 1.0, f_radius / abs(depth - scene_depth));
+
+        occlusion += (scene_depth >= view.z + f_bias ? 1.0 : 0.0) * range;
+    }
+
+    return vec4(vec3(1.0 - occlusion / float(f_samples)), 1.0);
+}
+
+subroutine(RenderPassType)
+vec4 pass_blur()
+{
+    float result = 0.0;
+
+    for (int x = -2; x < 2; x++) {
+        for (int y = -2; y < 2; y++) {
+            result += texture(u_source, vo_texture + vec2(x, y) * f_texel).r;
+        }
+    }
+
+    return vec4(vec3(result / 16.0), 1.0);
+}
+
+#endif
@@ -0,0 +1,7 @@
+{
+    "name": "utils/ssao",
+    "files": [
+        "base.glsl",
+        "ssao.glsl"
+    ]
+}
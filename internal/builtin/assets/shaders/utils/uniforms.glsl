@@ -0,0 +1,28 @@
+// Uniform blocks shared by the built-in shaders. The bindings match
+// graphics.UniformBindingCamera and graphics.UniformBindingLights.
+
+#define MAX_LIGHTS 32
+
+layout(std140, binding = 0) uniform CameraBlock
+{
+    mat4 v_view_matrix;
+    mat4 v_projection_matrix;
+    mat4 v_prev_view_projection_matrix;
+    vec3 f_camera;
+};
+
+// position.w holds the light type, direction.w the range, and spot.xy the
+// cosines of the inner and outer spot angles.
+struct LightData
+{
+    vec4 position;
+    vec4 direction;
+    vec4 color;
+    vec4 spot;
+};
+
+layout(std140, binding = 1) uniform LightBlock
+{
+    LightData f_lights[MAX_LIGHTS];
+};
+
@@ -49,8 +49,8 @@ type ModuleCore struct {
 	emit            uint32
 	maxParticles    uint32
 	particleBuffer  *buffer
-	lifecycleShader *graphics.Shader
-	simulateShader  *graphics.Shader
+	lifecycleShader graphics.Shader
+	simulateShader  graphics.Shader
 }
 
 const (
@@ -103,8 +103,12 @@ func NewModuleCore(maxParticles uint32) *ModuleCore {
 		Looping:       true,
 	}
 
-	m.lifecycleShader = shader.MustGet("particle/lifecycle")
-	m.simulateShader = shader.MustGet("particle/simulate")
+	// Particles are simulated with compute shaders. Without them the system
+	// is never simulated or drawn.
+	if graphics.Supported(graphics.FeatureCompute) {
+		m.lifecycleShader = shader.MustGet("particle/lifecycle")
+		m.simulateShader = shader.MustGet("particle/simulate")
+	}
 
 	m.particleBuffer = newBuffer(m.maxParticles)
 	m.particleBuffer.Alloc()
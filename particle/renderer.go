@@ -33,23 +33,26 @@ import (
 
 type ModuleRenderer struct {
 	system       *System
-	renderShader *graphics.Shader
+	renderShader graphics.Shader
 	sprite       *graphics.Texture2D
 }
 
 func (m *ModuleRenderer) Draw(camera *scene.Camera) {
+	if m.renderShader == nil || m.system.Core.simulateShader == nil {
+		return
+	}
+
 	m.system.Simulate()
 
 	m.renderShader.Bind()
 	m.system.Core.particleBuffer.Bind()
 
-	gl.Disable(gl.DEPTH_TEST)
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	state := graphics.CurrentState()
+	state.Enable(gl.DEPTH_TEST, false)
+	state.Enable(gl.BLEND, true)
+	state.BlendFunc(gl.SRC_ALPHA, gl.ONE)
 
 	m.renderShader.SetUniform("v_model_matrix", m.system.GetTransform().ActiveMatrix())
-	m.renderShader.SetUniform("v_view_matrix", camera.ViewMatrix())
-	m.renderShader.SetUniform("v_projection_matrix", camera.ProjectionMatrix())
 	m.renderShader.SetUniform("v_offset", m.system.inOffset)
 
 	m.sprite.ActivateTexture(gl.TEXTURE0)
@@ -58,8 +61,8 @@ func (m *ModuleRenderer) Draw(camera *scene.Camera) {
 	m.system.Core.particleBuffer.Unbind()
 	m.renderShader.Unbind()
 
-	gl.Disable(gl.BLEND)
-	gl.Enable(gl.DEPTH_TEST)
+	state.Enable(gl.BLEND, false)
+	state.Enable(gl.DEPTH_TEST, true)
 }
 
 func NewModuleRenderer(system *System) *ModuleRenderer {
@@ -67,7 +70,9 @@ func NewModuleRenderer(system *System) *ModuleRenderer {
 		system: system,
 	}
 
-	m.renderShader = shader.MustGet("particle/render")
+	if graphics.Supported(graphics.FeatureStorageBuffers) {
+		m.renderShader = shader.MustGet("particle/render")
+	}
 	m.sprite = texture.MustGet("particle.png")
 
 	return m
@@ -26,11 +26,11 @@ import (
 	"fmt"
 	"math"
 
-	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/haakenlabs/arc/graphics"
+	fmath "github.com/haakenlabs/arc/pkg/math"
 	"github.com/haakenlabs/arc/scene"
 	"github.com/haakenlabs/arc/system/input"
 	"github.com/haakenlabs/arc/system/instance"
@@ -66,7 +66,12 @@ func (s *System) SupportsDeferred() bool {
 	return false
 }
 
-func (s *System) DrawShader(shader *graphics.Shader, camera *scene.Camera) {
+// Bounds returns an infinite box, as particles are simulated on the GPU.
+func (s *System) Bounds() fmath.AABB {
+	return fmath.InfiniteAABB()
+}
+
+func (s *System) DrawShader(shader graphics.Shader, camera *scene.Camera) {
 	s.Draw(camera)
 	shader.Bind()
 }
@@ -101,8 +106,8 @@ func (s *System) Simulate() {
 		s.Core.lifecycleShader.SetSubroutine(graphics.ShaderComponentCompute, "task_lifetime")
 		s.Core.lifecycleShader.SetUniform("u_invocations", s.Core.alive)
 
-		gl.DispatchCompute((s.Core.alive/workgroupSize)+1, 1, 1)
-		gl.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		s.Core.lifecycleShader.Dispatch((s.Core.alive/workgroupSize)+1, 1, 1)
+		graphics.MemoryBarrier(graphics.BarrierStorage)
 	}
 
 	// Emit new particles
@@ -116,8 +121,8 @@ func (s *System) Simulate() {
 		s.Core.lifecycleShader.SetSubroutine(graphics.ShaderComponentCompute, "task_emit")
 		s.Core.lifecycleShader.SetUniform("u_invocations", emitNow)
 
-		gl.DispatchCompute((emitNow/workgroupSize)+1, 1, 1)
-		gl.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		s.Core.lifecycleShader.Dispatch((emitNow/workgroupSize)+1, 1, 1)
+		graphics.MemoryBarrier(graphics.BarrierStorage)
 	}
 
 	s.Core.syncCounts()
@@ -132,8 +137,8 @@ func (s *System) Simulate() {
 		s.Core.simulateShader.SetUniform("u_delta_time", deltaTime)
 		s.Core.simulateShader.SetUniform("u_attractors", s.Force.EnableAttractors)
 
-		gl.DispatchCompute((s.Core.alive/workgroupSize)+1, 1, 1)
-		gl.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		s.Core.simulateShader.Dispatch((s.Core.alive/workgroupSize)+1, 1, 1)
+		graphics.MemoryBarrier(graphics.BarrierStorage)
 	}
 
 	s.Core.simulateShader.Unbind()
@@ -0,0 +1,447 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// BodyType is the way a body moves.
+type BodyType int
+
+const (
+	// StaticBody never moves, and has infinite mass.
+	StaticBody BodyType = iota
+	// KinematicBody moves by its velocity, and is not affected by forces or
+	// collisions.
+	KinematicBody
+	// DynamicBody moves by forces, collisions and joints.
+	DynamicBody
+)
+
+// Fixture attaches a shape to a body, with the material it collides with.
+type Fixture struct {
+	body  *Body
+	shape Shape
+	id    uint32
+
+	density float32
+
+	// Friction is the friction coefficient, usually between 0 and 1. The
+	// friction of a contact is the geometric mean of its fixtures.
+	Friction float32
+	// Restitution is the elasticity, between 0 and 1. The restitution of a
+	// contact is the larger of its fixtures.
+	Restitution float32
+	// Category is the collision category bits of the fixture, and Mask the
+	// categories it collides with. Fixtures collide when each is in the
+	// mask of the other.
+	Category uint16
+	Mask     uint16
+}
+
+// Body returns the body of the fixture.
+func (f *Fixture) Body() *Body {
+	return f.body
+}
+
+// Shape returns the shape of the fixture.
+func (f *Fixture) Shape() Shape {
+	return f.shape
+}
+
+// Density returns the density of the fixture, in kilograms per square
+// meter.
+func (f *Fixture) Density() float32 {
+	return f.density
+}
+
+// SetDensity sets the density of the fixture, and updates the mass of its
+// body.
+func (f *Fixture) SetDensity(density float32) {
+	f.density = density
+	f.body.resetMass()
+}
+
+// AABB returns the world box around the fixture.
+func (f *Fixture) AABB() AABB {
+	return f.shape.computeAABB(f.body.xf)
+}
+
+// TestPoint reports if a world point is inside the fixture.
+func (f *Fixture) TestPoint(p mgl32.Vec2) bool {
+	return f.shape.TestPoint(f.body.xf.applyT(p))
+}
+
+func (f *Fixture) shouldCollide(o *Fixture) bool {
+	return f.Mask&o.Category != 0 && o.Mask&f.Category != 0
+}
+
+// Body is a rigid body of a world. It is moved by its velocity, which
+// changes with forces, impulses, collisions and joints.
+type Body struct {
+	world    *World
+	bodyType BodyType
+	fixtures []*Fixture
+	joints   []Joint
+
+	// xf is the transform of the origin of the body. The solver works on
+	// the center of mass and angle, and derives xf from them.
+	xf          xform
+	localCenter mgl32.Vec2
+	center      mgl32.Vec2
+	angle       float32
+
+	linearVelocity  mgl32.Vec2
+	angularVelocity float32
+	force           mgl32.Vec2
+	torque          float32
+
+	mass, invMass       float32
+	inertia, invInertia float32
+
+	linearDamping  float32
+	angularDamping float32
+	gravityScale   float32
+	fixedRotation  bool
+
+	// UserData holds anything the application associates with the body.
+	UserData interface{}
+}
+
+// newBody creates a body outside of any world.
+func newBody(t BodyType) *Body {
+	b := &Body{
+		bodyType:     t,
+		gravityScale: 1,
+	}
+	b.resetMass()
+
+	return b
+}
+
+// World returns the world of the body, or nil if it is in none.
+func (b *Body) World() *World {
+	return b.world
+}
+
+// Type returns the type of the body.
+func (b *Body) Type() BodyType {
+	return b.bodyType
+}
+
+// SetType changes the type of the body. Static bodies lose their velocity.
+func (b *Body) SetType(t BodyType) {
+	b.bodyType = t
+	if t == StaticBody {
+		b.linearVelocity = mgl32.Vec2{}
+		b.angularVelocity = 0
+	}
+	b.resetMass()
+}
+
+// Position returns the world position of the origin of the body.
+func (b *Body) Position() mgl32.Vec2 {
+	return b.xf.p
+}
+
+// Angle returns the angle of the body in radians.
+func (b *Body) Angle() float32 {
+	return b.angle
+}
+
+// SetTransform moves the body to a position and angle, without changing its
+// velocity.
+func (b *Body) SetTransform(position mgl32.Vec2, angle float32) {
+	b.xf = newXform(position, angle)
+	b.angle = angle
+	b.center = b.xf.apply(b.localCenter)
+}
+
+// WorldCenter returns the world position of the center of mass.
+func (b *Body) WorldCenter() mgl32.Vec2 {
+	return b.center
+}
+
+// LocalCenter returns the center of mass relative to the origin of the
+// body.
+func (b *Body) LocalCenter() mgl32.Vec2 {
+	return b.localCenter
+}
+
+// WorldPoint returns the world position of a point in the space of the
+// body.
+func (b *Body) WorldPoint(p mgl32.Vec2) mgl32.Vec2 {
+	return b.xf.apply(p)
+}
+
+// LocalPoint returns the position of a world point in the space of the
+// body.
+func (b *Body) LocalPoint(p mgl32.Vec2) mgl32.Vec2 {
+	return b.xf.applyT(p)
+}
+
+// LinearVelocity returns the velocity of the center of mass.
+func (b *Body) LinearVelocity() mgl32.Vec2 {
+	return b.linearVelocity
+}
+
+// SetLinearVelocity sets the velocity of the center of mass. Static bodies
+// do not move.
+func (b *Body) SetLinearVelocity(v mgl32.Vec2) {
+	if b.bodyType != StaticBody {
+		b.linearVelocity = v
+	}
+}
+
+// AngularVelocity returns the angular velocity in radians per second.
+func (b *Body) AngularVelocity() float32 {
+	return b.angularVelocity
+}
+
+// SetAngularVelocity sets the angular velocity in radians per second.
+func (b *Body) SetAngularVelocity(w float32) {
+	if b.bodyType != StaticBody {
+		b.angularVelocity = w
+	}
+}
+
+// VelocityAt returns the velocity of a world point attached to the body.
+func (b *Body) VelocityAt(p mgl32.Vec2) mgl32.Vec2 {
+	return b.linearVelocity.Add(crossSV(b.angularVelocity, p.Sub(b.center)))
+}
+
+// ApplyForce applies a force at a world point until the next step.
+func (b *Body) ApplyForce(force, point mgl32.Vec2) {
+	if b.bodyType != DynamicBody {
+		return
+	}
+
+	b.force = b.force.Add(force)
+	b.torque += cross(point.Sub(b.center), force)
+}
+
+// ApplyForceToCenter applies a force at the center of mass until the next
+// step.
+func (b *Body) ApplyForceToCenter(force mgl32.Vec2) {
+	if b.bodyType == DynamicBody {
+		b.force = b.force.Add(force)
+	}
+}
+
+// ApplyTorque applies a torque until the next step.
+func (b *Body) ApplyTorque(torque float32) {
+	if b.bodyType == DynamicBody {
+		b.torque += torque
+	}
+}
+
+// ApplyLinearImpulse changes the velocity of the body by an impulse at a
+// world point.
+func (b *Body) ApplyLinearImpulse(impulse, point mgl32.Vec2) {
+	if b.bodyType != DynamicBody {
+		return
+	}
+
+	b.linearVelocity = b.linearVelocity.Add(impulse.Mul(b.invMass))
+	b.angularVelocity += b.invInertia * cross(point.Sub(b.center), impulse)
+}
+
+// ApplyAngularImpulse changes the angular velocity of the body by an
+// impulse.
+func (b *Body) ApplyAngularImpulse(impulse float32) {
+	if b.bodyType == DynamicBody {
+		b.angularVelocity += b.invInertia * impulse
+	}
+}
+
+// Mass returns the mass of the body in kilograms.
+func (b *Body) Mass() float32 {
+	return b.mass
+}
+
+// Inertia returns the rotational inertia of the body about its center of
+// mass.
+func (b *Body) Inertia() float32 {
+	return b.inertia
+}
+
+// LinearDamping returns the reduction of the linear velocity per second.
+func (b *Body) LinearDamping() float32 {
+	return b.linearDamping
+}
+
+// SetLinearDamping sets the reduction of the linear velocity per second.
+func (b *Body) SetLinearDamping(damping float32) {
+	b.linearDamping = damping
+}
+
+// AngularDamping returns the reduction of the angular velocity per second.
+func (b *Body) AngularDamping() float32 {
+	return b.angularDamping
+}
+
+// SetAngularDamping sets the reduction of the angular velocity per second.
+func (b *Body) SetAngularDamping(damping float32) {
+	b.angularDamping = damping
+}
+
+// GravityScale returns the scale of the gravity of the world applied to the
+// body.
+func (b *Body) GravityScale() float32 {
+	return b.gravityScale
+}
+
+// SetGravityScale sets the scale of the gravity of the world applied to the
+// body.
+func (b *Body) SetGravityScale(scale float32) {
+	b.gravityScale = scale
+}
+
+// FixedRotation reports if the body is kept from rotating.
+func (b *Body) FixedRotation() bool {
+	return b.fixedRotation
+}
+
+// SetFixedRotation sets if the body is kept from rotating, such as for
+// characters.
+func (b *Body) SetFixedRotation(fixed bool) {
+	b.fixedRotation = fixed
+	b.angularVelocity = 0
+	b.resetMass()
+}
+
+// AddFixture attaches a shape to the body, with a density in kilograms per
+// square meter. The mass of the body is updated. The fixture collides with
+// every category, and has a friction of 0.2.
+func (b *Body) AddFixture(shape Shape, density float32) *Fixture {
+	f := &Fixture{
+		body:     b,
+		shape:    shape,
+		density:  density,
+		Friction: 0.2,
+		Category: 0x0001,
+		Mask:     0xFFFF,
+	}
+	if b.world != nil {
+		f.id = b.world.nextID()
+	}
+
+	b.fixtures = append(b.fixtures, f)
+	b.resetMass()
+
+	return f
+}
+
+// RemoveFixture detaches a fixture from the body.
+func (b *Body) RemoveFixture(f *Fixture) {
+	for i := range b.fixtures {
+		if b.fixtures[i] == f {
+			b.fixtures = append(b.fixtures[:i], b.fixtures[i+1:]...)
+			break
+		}
+	}
+
+	if b.world != nil {
+		b.world.removeContacts(func(c *contact) bool {
+			return c.fixtureA == f || c.fixtureB == f
+		})
+	}
+
+	b.resetMass()
+}
+
+// Fixtures returns the fixtures of the body.
+func (b *Body) Fixtures() []*Fixture {
+	return b.fixtures
+}
+
+// Joints returns the joints connected to the body.
+func (b *Body) Joints() []Joint {
+	return b.joints
+}
+
+// resetMass updates the mass and center of mass of the body from its
+// fixtures. Dynamic bodies without mass weigh one kilogram.
+func (b *Body) resetMass() {
+	b.mass, b.invMass = 0, 0
+	b.inertia, b.invInertia = 0, 0
+	b.localCenter = mgl32.Vec2{}
+
+	if b.bodyType != DynamicBody {
+		b.center = b.xf.p
+		return
+	}
+
+	var center mgl32.Vec2
+	for _, f := range b.fixtures {
+		if f.density == 0 {
+			continue
+		}
+
+		md := f.shape.computeMass(f.density)
+		b.mass += md.mass
+		center = center.Add(md.center.Mul(md.mass))
+		b.inertia += md.inertia
+	}
+
+	if b.mass > 0 {
+		b.invMass = 1 / b.mass
+		center = center.Mul(b.invMass)
+	} else {
+		b.mass, b.invMass = 1, 1
+	}
+
+	if b.inertia > 0 && !b.fixedRotation {
+		// The inertia is moved from the origin to the center of mass.
+		b.inertia -= b.mass * center.Dot(center)
+		b.invInertia = 1 / b.inertia
+	} else {
+		b.inertia = 0
+	}
+
+	// The velocity of the new center of mass keeps the motion of the body.
+	old := b.center
+	b.localCenter = center
+	b.center = b.xf.apply(center)
+	b.linearVelocity = b.linearVelocity.Add(crossSV(b.angularVelocity, b.center.Sub(old)))
+}
+
+// synchronize derives the transform of the origin from the center of mass
+// and angle.
+func (b *Body) synchronize() {
+	b.xf.q = newRot(b.angle)
+	b.xf.p = b.center.Sub(b.xf.q.apply(b.localCenter))
+}
+
+// connected reports if a joint which disables collision connects the
+// bodies.
+func (b *Body) connected(o *Body) bool {
+	for _, j := range b.joints {
+		if !j.CollideConnected() && (j.BodyA() == o || j.BodyB() == o) {
+			return true
+		}
+	}
+
+	return false
+}
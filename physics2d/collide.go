@@ -0,0 +1,391 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// manifoldType is the reference of the points of a manifold.
+type manifoldType int
+
+const (
+	// manifoldCircles has a point at the center of each circle.
+	manifoldCircles manifoldType = iota
+	// manifoldFaceA has points of B against a face of A.
+	manifoldFaceA
+	// manifoldFaceB has points of A against a face of B.
+	manifoldFaceB
+)
+
+// Contact feature types, which identify points across steps.
+const (
+	featureVertex = 0
+	featureFace   = 1
+)
+
+// manifoldPoint is a point of contact, in the space of the body it belongs
+// to. The impulses are kept between steps to warm start the solver.
+type manifoldPoint struct {
+	localPoint     mgl32.Vec2
+	id             uint32
+	normalImpulse  float32
+	tangentImpulse float32
+}
+
+// manifold is the contact between two shapes. Points are stored relative to
+// the bodies, so the solver can follow them as the bodies move.
+type manifold struct {
+	kind        manifoldType
+	localNormal mgl32.Vec2
+	localPoint  mgl32.Vec2
+	points      [2]manifoldPoint
+	count       int
+}
+
+// featureID packs the features of two shapes which make a point of contact.
+func featureID(indexA, indexB, typeA, typeB int) uint32 {
+	return uint32(indexA) | uint32(indexB)<<8 | uint32(typeA)<<16 | uint32(typeB)<<24
+}
+
+// flipID swaps the shapes of a feature id.
+func flipID(id uint32) uint32 {
+	return (id>>8)&0xFF | (id&0xFF)<<8 | (id>>24)&0xFF<<16 | (id>>16)&0xFF<<24
+}
+
+// collide computes the manifold of two shapes. Fixtures are ordered so a
+// polygon always comes before a circle.
+func collide(a Shape, xfA xform, b Shape, xfB xform) manifold {
+	switch sa := a.(type) {
+	case *Circle:
+		return collideCircles(sa, xfA, b.(*Circle), xfB)
+	case *Polygon:
+		switch sb := b.(type) {
+		case *Circle:
+			return collidePolygonCircle(sa, xfA, sb, xfB)
+		case *Polygon:
+			return collidePolygons(sa, xfA, sb, xfB)
+		}
+	}
+
+	return manifold{}
+}
+
+func collideCircles(a *Circle, xfA xform, b *Circle, xfB xform) manifold {
+	m := manifold{}
+
+	d := xfB.apply(b.Center).Sub(xfA.apply(a.Center))
+	r := a.Radius + b.Radius
+	if d.LenSqr() > r*r {
+		return m
+	}
+
+	m.kind = manifoldCircles
+	m.localPoint = a.Center
+	m.points[0].localPoint = b.Center
+	m.count = 1
+
+	return m
+}
+
+func collidePolygonCircle(a *Polygon, xfA xform, b *Circle, xfB xform) manifold {
+	m := manifold{}
+
+	// The center of the circle in the space of the polygon.
+	c := xfA.applyT(xfB.apply(b.Center))
+	r := polygonRadius + b.Radius
+
+	// The face of least penetration.
+	normalIndex := 0
+	separation := float32(-math.MaxFloat32)
+	for i := range a.vertices {
+		s := a.normals[i].Dot(c.Sub(a.vertices[i]))
+		if s > r {
+			return m
+		}
+		if s > separation {
+			separation = s
+			normalIndex = i
+		}
+	}
+
+	v1 := a.vertices[normalIndex]
+	v2 := a.vertices[(normalIndex+1)%len(a.vertices)]
+
+	m.kind = manifoldFaceA
+	m.points[0].localPoint = b.Center
+	m.count = 1
+
+	// The center is inside the polygon.
+	if separation < 1e-7 {
+		m.localNormal = a.normals[normalIndex]
+		m.localPoint = v1.Add(v2).Mul(0.5)
+		return m
+	}
+
+	// The center is beyond one of the vertices of the face, or beside the
+	// face itself.
+	u1 := c.Sub(v1).Dot(v2.Sub(v1))
+	u2 := c.Sub(v2).Dot(v1.Sub(v2))
+
+	switch {
+	case u1 <= 0:
+		if c.Sub(v1).LenSqr() > r*r {
+			return manifold{}
+		}
+		m.localNormal = c.Sub(v1).Normalize()
+		m.localPoint = v1
+	case u2 <= 0:
+		if c.Sub(v2).LenSqr() > r*r {
+			return manifold{}
+		}
+		m.localNormal = c.Sub(v2).Normalize()
+		m.localPoint = v2
+	default:
+		faceCenter := v1.Add(v2).Mul(0.5)
+		if c.Sub(faceCenter).Dot(a.normals[normalIndex]) > r {
+			return manifold{}
+		}
+		m.localNormal = a.normals[normalIndex]
+		m.localPoint = faceCenter
+	}
+
+	return m
+}
+
+// findMaxSeparation returns the edge of a with the largest separation from
+// b, and that separation.
+func findMaxSeparation(a *Polygon, xfA xform, b *Polygon, xfB xform) (int, float32) {
+	xf := relative(xfB, xfA)
+
+	best := 0
+	maxSeparation := float32(-math.MaxFloat32)
+	for i := range a.vertices {
+		n := xf.q.apply(a.normals[i])
+		v := xf.apply(a.vertices[i])
+
+		si := float32(math.MaxFloat32)
+		for _, w := range b.vertices {
+			if sij := n.Dot(w.Sub(v)); sij < si {
+				si = sij
+			}
+		}
+
+		if si > maxSeparation {
+			maxSeparation = si
+			best = i
+		}
+	}
+
+	return best, maxSeparation
+}
+
+// clipVertex is a point of an edge being clipped, with its feature id.
+type clipVertex struct {
+	v  mgl32.Vec2
+	id uint32
+}
+
+// findIncidentEdge returns the edge of b most anti-parallel to the reference
+// edge of a, in world space.
+func findIncidentEdge(a *Polygon, xfA xform, edge int, b *Polygon, xfB xform) [2]clipVertex {
+	normal := xfB.q.applyT(xfA.q.apply(a.normals[edge]))
+
+	index := 0
+	minDot := float32(math.MaxFloat32)
+	for i := range b.normals {
+		if d := normal.Dot(b.normals[i]); d < minDot {
+			minDot = d
+			index = i
+		}
+	}
+
+	next := (index + 1) % len(b.vertices)
+
+	return [2]clipVertex{
+		{v: xfB.apply(b.vertices[index]), id: featureID(edge, index, featureFace, featureVertex)},
+		{v: xfB.apply(b.vertices[next]), id: featureID(edge, next, featureFace, featureVertex)},
+	}
+}
+
+// clipSegmentToLine clips a segment to the side of a line behind its normal.
+func clipSegmentToLine(in [2]clipVertex, normal mgl32.Vec2, offset float32, vertexA int) ([2]clipVertex, int) {
+	var out [2]clipVertex
+	count := 0
+
+	d0 := normal.Dot(in[0].v) - offset
+	d1 := normal.Dot(in[1].v) - offset
+
+	if d0 <= 0 {
+		out[count] = in[0]
+		count++
+	}
+	if d1 <= 0 {
+		out[count] = in[1]
+		count++
+	}
+
+	// The segment crosses the line, so the crossing point is kept.
+	if d0*d1 < 0 {
+		t := d0 / (d0 - d1)
+		out[count] = clipVertex{
+			v:  in[0].v.Add(in[1].v.Sub(in[0].v).Mul(t)),
+			id: featureID(vertexA, int(in[0].id>>8&0xFF), featureVertex, featureFace),
+		}
+		count++
+	}
+
+	return out, count
+}
+
+// collidePolygons finds the reference face with the least penetration, and
+// clips the incident edge of the other polygon to it.
+func collidePolygons(a *Polygon, xfA xform, b *Polygon, xfB xform) manifold {
+	m := manifold{}
+	totalRadius := float32(2 * polygonRadius)
+
+	edgeA, separationA := findMaxSeparation(a, xfA, b, xfB)
+	if separationA > totalRadius {
+		return m
+	}
+
+	edgeB, separationB := findMaxSeparation(b, xfB, a, xfA)
+	if separationB > totalRadius {
+		return m
+	}
+
+	poly1, xf1, edge1 := a, xfA, edgeA
+	poly2, xf2 := b, xfB
+	flip := false
+	m.kind = manifoldFaceA
+
+	// Face A is preferred, so the reference face does not flip between
+	// steps when the separations are close.
+	if separationB > separationA+0.1*linearSlop {
+		poly1, xf1, edge1 = b, xfB, edgeB
+		poly2, xf2 = a, xfA
+		flip = true
+		m.kind = manifoldFaceB
+	}
+
+	incident := findIncidentEdge(poly1, xf1, edge1, poly2, xf2)
+
+	iv1 := edge1
+	iv2 := (edge1 + 1) % len(poly1.vertices)
+	v11 := poly1.vertices[iv1]
+	v12 := poly1.vertices[iv2]
+
+	localTangent := v12.Sub(v11).Normalize()
+	localNormal := crossVS(localTangent, 1)
+	planePoint := v11.Add(v12).Mul(0.5)
+
+	tangent := xf1.q.apply(localTangent)
+	normal := crossVS(tangent, 1)
+
+	v11 = xf1.apply(v11)
+	v12 = xf1.apply(v12)
+
+	frontOffset := normal.Dot(v11)
+	sideOffset1 := -tangent.Dot(v11) + totalRadius
+	sideOffset2 := tangent.Dot(v12) + totalRadius
+
+	clip1, n := clipSegmentToLine(incident, tangent.Mul(-1), sideOffset1, iv1)
+	if n < 2 {
+		return manifold{}
+	}
+
+	clip2, n := clipSegmentToLine(clip1, tangent, sideOffset2, iv2)
+	if n < 2 {
+		return manifold{}
+	}
+
+	m.localNormal = localNormal
+	m.localPoint = planePoint
+
+	for i := 0; i < 2; i++ {
+		if normal.Dot(clip2[i].v)-frontOffset > totalRadius {
+			continue
+		}
+
+		p := &m.points[m.count]
+		p.localPoint = xf2.applyT(clip2[i].v)
+		p.id = clip2[i].id
+		if flip {
+			p.id = flipID(p.id)
+		}
+		m.count++
+	}
+
+	return m
+}
+
+// worldManifold returns the world normal and points of a manifold. The
+// normal points from A to B, and the points are midway between the surfaces
+// of the shapes.
+func worldManifold(m *manifold, xfA xform, radiusA float32, xfB xform, radiusB float32) (mgl32.Vec2, [2]mgl32.Vec2) {
+	var normal mgl32.Vec2
+	var points [2]mgl32.Vec2
+
+	switch m.kind {
+	case manifoldCircles:
+		pointA := xfA.apply(m.localPoint)
+		pointB := xfB.apply(m.points[0].localPoint)
+
+		normal = mgl32.Vec2{1, 0}
+		if pointB.Sub(pointA).LenSqr() > 1e-12 {
+			normal = pointB.Sub(pointA).Normalize()
+		}
+
+		cA := pointA.Add(normal.Mul(radiusA))
+		cB := pointB.Sub(normal.Mul(radiusB))
+		points[0] = cA.Add(cB).Mul(0.5)
+
+	case manifoldFaceA:
+		normal = xfA.q.apply(m.localNormal)
+		planePoint := xfA.apply(m.localPoint)
+
+		for i := 0; i < m.count; i++ {
+			clipPoint := xfB.apply(m.points[i].localPoint)
+			cA := clipPoint.Add(normal.Mul(radiusA - clipPoint.Sub(planePoint).Dot(normal)))
+			cB := clipPoint.Sub(normal.Mul(radiusB))
+			points[i] = cA.Add(cB).Mul(0.5)
+		}
+
+	case manifoldFaceB:
+		normal = xfB.q.apply(m.localNormal)
+		planePoint := xfB.apply(m.localPoint)
+
+		for i := 0; i < m.count; i++ {
+			clipPoint := xfA.apply(m.points[i].localPoint)
+			cB := clipPoint.Add(normal.Mul(radiusB - clipPoint.Sub(planePoint).Dot(normal)))
+			cA := clipPoint.Sub(normal.Mul(radiusA))
+			points[i] = cA.Add(cB).Mul(0.5)
+		}
+
+		normal = normal.Mul(-1)
+	}
+
+	return normal, points
+}
@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func mustPolygon(t *testing.T, vertices []mgl32.Vec2) *Polygon {
+	p, err := NewPolygon(vertices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestCollide(t *testing.T) {
+	triangle := mustPolygon(t, []mgl32.Vec2{{-0.5, 0}, {0.5, 0}, {0, 1}})
+
+	tests := []struct {
+		a      Shape
+		xfA    xform
+		b      Shape
+		xfB    xform
+		count  int
+		kind   manifoldType
+		normal mgl32.Vec2
+		points []mgl32.Vec2
+	}{
+		// Circles.
+		{
+			a: NewCircle(mgl32.Vec2{}, 1), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{}, 1), xfB: newXform(mgl32.Vec2{1.5, 0}, 0),
+			count: 1, kind: manifoldCircles,
+			normal: mgl32.Vec2{1, 0},
+			points: []mgl32.Vec2{{0.75, 0}},
+		},
+		{
+			a: NewCircle(mgl32.Vec2{}, 1), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{0, 1}, 1), xfB: newXform(mgl32.Vec2{0, 0.5}, 0),
+			count: 1, kind: manifoldCircles,
+			normal: mgl32.Vec2{0, 1},
+			points: []mgl32.Vec2{{0, 0.75}},
+		},
+		{
+			a: NewCircle(mgl32.Vec2{}, 1), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{}, 1), xfB: newXform(mgl32.Vec2{2.5, 0}, 0),
+			count: 0,
+		},
+		// Box and circle, against a face and against a vertex.
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{}, 0.5), xfB: newXform(mgl32.Vec2{0, 0.75}, 0),
+			count: 1, kind: manifoldFaceA,
+			normal: mgl32.Vec2{0, 1},
+			points: []mgl32.Vec2{{0, 0.38}},
+		},
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{}, 0.5), xfB: newXform(mgl32.Vec2{0.8, 0.8}, 0),
+			count: 1, kind: manifoldFaceA,
+			normal: mgl32.Vec2{1, 1}.Normalize(),
+			points: []mgl32.Vec2{{0.4768, 0.4768}},
+		},
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{}, 0.5), xfB: newXform(mgl32.Vec2{0, 1.1}, 0),
+			count: 0,
+		},
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewCircle(mgl32.Vec2{}, 0.5), xfB: newXform(mgl32.Vec2{0.9, 0.9}, 0),
+			count: 0,
+		},
+		// Boxes, face to face and vertex to face.
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewBox(0.5, 0.5), xfB: newXform(mgl32.Vec2{0, 0.9}, 0),
+			count: 2, kind: manifoldFaceA,
+			normal: mgl32.Vec2{0, 1},
+			points: []mgl32.Vec2{{-0.5, 0.45}, {0.5, 0.45}},
+		},
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewBox(0.5, 0.5), xfB: newXform(mgl32.Vec2{0, 1.2}, math.Pi/4),
+			count: 1, kind: manifoldFaceA,
+			normal: mgl32.Vec2{0, 1},
+			points: []mgl32.Vec2{{0, 0.4964}},
+		},
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{0, 1.2}, math.Pi/4),
+			b: NewBox(0.5, 0.5), xfB: newXform(mgl32.Vec2{}, 0),
+			count: 1, kind: manifoldFaceB,
+			normal: mgl32.Vec2{0, -1},
+			points: []mgl32.Vec2{{0, 0.4964}},
+		},
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: NewBox(0.5, 0.5), xfB: newXform(mgl32.Vec2{1.1, 0}, 0),
+			count: 0,
+		},
+		// Polygons.
+		{
+			a: NewBox(0.5, 0.5), xfA: newXform(mgl32.Vec2{}, 0),
+			b: triangle, xfB: newXform(mgl32.Vec2{0, 0.45}, 0),
+			count: 2, kind: manifoldFaceA,
+			normal: mgl32.Vec2{0, 1},
+			points: []mgl32.Vec2{{-0.5, 0.475}, {0.5, 0.475}},
+		},
+		{
+			a: triangle, xfA: newXform(mgl32.Vec2{0, 1.48}, math.Pi),
+			b: NewBox(0.5, 0.5), xfB: newXform(mgl32.Vec2{}, 0),
+			count: 1, kind: manifoldFaceB,
+			normal: mgl32.Vec2{0, -1},
+			points: []mgl32.Vec2{{0, 0.49}},
+		},
+		{
+			a: triangle, xfA: newXform(mgl32.Vec2{}, 0),
+			b: triangle, xfB: newXform(mgl32.Vec2{0, 1.1}, 0),
+			count: 0,
+		},
+	}
+
+	for i, v := range tests {
+		m := collide(v.a, v.xfA, v.b, v.xfB)
+		if m.count != v.count {
+			t.Errorf("Collide case %d failed. want: %d points got: %d", i, v.count, m.count)
+			continue
+		}
+		if m.count == 0 {
+			continue
+		}
+		if m.kind != v.kind {
+			t.Errorf("Collide case %d failed. want: kind %d got: %d", i, v.kind, m.kind)
+		}
+
+		normal, points := worldManifold(&m, v.xfA, v.a.radius(), v.xfB, v.b.radius())
+		if !normal.ApproxEqualThreshold(v.normal, 1e-4) {
+			t.Errorf("Collide case %d failed. want: normal %v got: %v", i, v.normal, normal)
+		}
+
+	want:
+		for _, w := range v.points {
+			for _, p := range points[:m.count] {
+				if p.ApproxEqualThreshold(w, 1e-3) {
+					continue want
+				}
+			}
+			t.Errorf("Collide case %d failed. want: point %v got: %v", i, w, points[:m.count])
+		}
+	}
+}
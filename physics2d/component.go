@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ scene.ScriptComponent = &Simulator{}
+var _ scene.ScriptComponent = &RigidBody{}
+var _ scene.DestroyListener = &RigidBody{}
+
+// Simulator is a component which steps a world on the fixed update of its
+// scene. The bodies of RigidBody components move their objects in the XY
+// plane, and static bodies follow their objects.
+type Simulator struct {
+	scene.BaseScriptComponent
+
+	world  *World
+	ground *Body
+}
+
+// NewSimulator creates a new simulator with a world of the given gravity.
+func NewSimulator(gravity mgl32.Vec2) *Simulator {
+	s := &Simulator{
+		world: NewWorld(gravity),
+	}
+
+	s.SetName("Simulator")
+	instance.MustAssign(s)
+
+	return s
+}
+
+// World returns the world stepped by the simulator.
+func (s *Simulator) World() *World {
+	return s.world
+}
+
+// Ground returns a static body at the origin of the world, without
+// fixtures. Joint components without a connected body are attached to it.
+func (s *Simulator) Ground() *Body {
+	if s.ground == nil {
+		s.ground = s.world.NewBody(StaticBody, mgl32.Vec2{}, 0)
+	}
+
+	return s.ground
+}
+
+// FixedUpdate steps the world by the fixed time step, scaled by the time
+// scale.
+func (s *Simulator) FixedUpdate() {
+	dt := float32(time.FixedTime() * time.TimeScale())
+	if dt <= 0 {
+		return
+	}
+
+	for _, b := range s.world.bodies {
+		if rb, ok := b.UserData.(*RigidBody); ok && b.bodyType == StaticBody {
+			rb.pull()
+		}
+	}
+
+	s.world.Step(dt)
+
+	for _, b := range s.world.bodies {
+		if rb, ok := b.UserData.(*RigidBody); ok && b.bodyType != StaticBody {
+			rb.push()
+		}
+	}
+}
+
+// RigidBody is a component which moves its object by a body of a simulator.
+type RigidBody struct {
+	scene.BaseScriptComponent
+
+	simulator *Simulator
+	body      *Body
+}
+
+// NewRigidBody creates a new rigid body component with a body of type t in
+// the world of sim. If sim is nil, the body is added to the first simulator
+// of the scene when the component starts. Fixtures are added to the body
+// with Body().AddFixture.
+func NewRigidBody(sim *Simulator, t BodyType) *RigidBody {
+	r := &RigidBody{
+		body: newBody(t),
+	}
+	r.body.UserData = r
+
+	if sim != nil {
+		r.simulator = sim
+		sim.world.addBody(r.body)
+	}
+
+	r.SetName("RigidBody")
+	instance.MustAssign(r)
+
+	return r
+}
+
+// Body returns the body of the component.
+func (r *RigidBody) Body() *Body {
+	return r.body
+}
+
+// Simulator returns the simulator of the body, or nil if the component has
+// not started and was created without one.
+func (r *RigidBody) Simulator() *Simulator {
+	return r.simulator
+}
+
+// Start places the body at the position of its object.
+func (r *RigidBody) Start() {
+	r.prepare()
+}
+
+// OnDestroy removes the body from the world.
+func (r *RigidBody) OnDestroy() {
+	if r.simulator != nil {
+		r.simulator.world.RemoveBody(r.body)
+	}
+}
+
+// prepare adds the body to a simulator if it has none, and places it at the
+// position of its object. It reports if the body is in a simulator.
+func (r *RigidBody) prepare() bool {
+	if r.simulator == nil {
+		g := r.GameObject()
+		if g == nil || g.Scene() == nil {
+			return false
+		}
+
+		sim, ok := scene.FindObjectOfType[*Simulator](g.Scene())
+		if !ok {
+			logrus.Error("[Physics2D] no simulator in scene for rigid body of ", g.Name())
+			return false
+		}
+
+		r.simulator = sim
+		sim.world.addBody(r.body)
+	}
+
+	r.pull()
+
+	return true
+}
+
+// pull moves the body to the position and angle of its object.
+func (r *RigidBody) pull() {
+	t := r.GetTransform()
+	if t == nil {
+		return
+	}
+
+	m := t.ActiveMatrix()
+	x := m.Col(0)
+	p := m.Col(3)
+
+	r.body.SetTransform(mgl32.Vec2{p[0], p[1]}, float32(math.Atan2(float64(x[1]), float64(x[0]))))
+}
+
+// push moves the object to the position and angle of the body. The depth of
+// the object is kept.
+func (r *RigidBody) push() {
+	t := r.GetTransform()
+	if t == nil {
+		return
+	}
+
+	p := r.body.Position()
+	z := t.ActiveMatrix().Col(3)[2]
+
+	t.SetWorldPosition(mgl32.Vec3{p[0], p[1], z})
+	t.SetWorldRotation(mgl32.QuatRotate(r.body.Angle(), mgl32.Vec3{0, 0, 1}))
+}
@@ -0,0 +1,271 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	fmath "github.com/haakenlabs/arc/pkg/math"
+)
+
+// contactPoint is the solver state of a point of contact.
+type contactPoint struct {
+	rA, rB         mgl32.Vec2
+	normalMass     float32
+	tangentMass    float32
+	velocityBias   float32
+	normalImpulse  float32
+	tangentImpulse float32
+}
+
+// contact is a pair of touching fixtures. Contacts last while the fixtures
+// touch, so the impulses of one step warm start the next.
+type contact struct {
+	fixtureA *Fixture
+	fixtureB *Fixture
+	manifold manifold
+	touching bool
+
+	friction    float32
+	restitution float32
+	normal      mgl32.Vec2
+	points      [2]contactPoint
+}
+
+// contactKey identifies a contact by its fixtures.
+type contactKey struct {
+	a, b uint32
+}
+
+func newContact(a, b *Fixture) *contact {
+	return &contact{
+		fixtureA:    a,
+		fixtureB:    b,
+		friction:    float32(math.Sqrt(float64(a.Friction * b.Friction))),
+		restitution: fmath.Max32(a.Restitution, b.Restitution),
+	}
+}
+
+// update computes the manifold of the contact for the current positions of
+// the bodies, and carries over the impulses of points which persist.
+func (c *contact) update() {
+	old := c.manifold
+
+	c.manifold = collide(c.fixtureA.shape, c.fixtureA.body.xf, c.fixtureB.shape, c.fixtureB.body.xf)
+	c.touching = c.manifold.count > 0
+
+	for i := 0; i < c.manifold.count; i++ {
+		p := &c.manifold.points[i]
+		for j := 0; j < old.count; j++ {
+			if old.points[j].id == p.id {
+				p.normalImpulse = old.points[j].normalImpulse
+				p.tangentImpulse = old.points[j].tangentImpulse
+				break
+			}
+		}
+	}
+}
+
+// initVelocity prepares the contact for the velocity iterations, and applies
+// the impulses of the last step.
+func (c *contact) initVelocity() {
+	bA, bB := c.fixtureA.body, c.fixtureB.body
+	mA, mB := bA.invMass, bB.invMass
+	iA, iB := bA.invInertia, bB.invInertia
+
+	normal, points := worldManifold(&c.manifold, bA.xf, c.fixtureA.shape.radius(), bB.xf, c.fixtureB.shape.radius())
+	c.normal = normal
+	tangent := crossVS(normal, 1)
+
+	for i := 0; i < c.manifold.count; i++ {
+		cp := &c.points[i]
+		mp := &c.manifold.points[i]
+
+		cp.rA = points[i].Sub(bA.center)
+		cp.rB = points[i].Sub(bB.center)
+		cp.normalImpulse = mp.normalImpulse
+		cp.tangentImpulse = mp.tangentImpulse
+
+		rnA := cross(cp.rA, normal)
+		rnB := cross(cp.rB, normal)
+		if k := mA + mB + iA*rnA*rnA + iB*rnB*rnB; k > 0 {
+			cp.normalMass = 1 / k
+		} else {
+			cp.normalMass = 0
+		}
+
+		rtA := cross(cp.rA, tangent)
+		rtB := cross(cp.rB, tangent)
+		if k := mA + mB + iA*rtA*rtA + iB*rtB*rtB; k > 0 {
+			cp.tangentMass = 1 / k
+		} else {
+			cp.tangentMass = 0
+		}
+
+		// Fast collisions bounce, slow ones come to rest.
+		cp.velocityBias = 0
+		if vRel := normal.Dot(bB.VelocityAt(points[i]).Sub(bA.VelocityAt(points[i]))); vRel < -velocityThreshold {
+			cp.velocityBias = -c.restitution * vRel
+		}
+
+		p := normal.Mul(cp.normalImpulse).Add(tangent.Mul(cp.tangentImpulse))
+		c.apply(cp, p)
+	}
+}
+
+// apply applies an impulse at a point of the contact, from A to B.
+func (c *contact) apply(cp *contactPoint, p mgl32.Vec2) {
+	bA, bB := c.fixtureA.body, c.fixtureB.body
+
+	bA.linearVelocity = bA.linearVelocity.Sub(p.Mul(bA.invMass))
+	bA.angularVelocity -= bA.invInertia * cross(cp.rA, p)
+	bB.linearVelocity = bB.linearVelocity.Add(p.Mul(bB.invMass))
+	bB.angularVelocity += bB.invInertia * cross(cp.rB, p)
+}
+
+// relativeVelocity returns the velocity of B relative to A at a point.
+func (c *contact) relativeVelocity(cp *contactPoint) mgl32.Vec2 {
+	bA, bB := c.fixtureA.body, c.fixtureB.body
+
+	vA := bA.linearVelocity.Add(crossSV(bA.angularVelocity, cp.rA))
+	vB := bB.linearVelocity.Add(crossSV(bB.angularVelocity, cp.rB))
+
+	return vB.Sub(vA)
+}
+
+// solveVelocity applies the friction and normal impulses of the contact.
+// Friction is solved first, as it is limited by the normal impulse.
+func (c *contact) solveVelocity() {
+	tangent := crossVS(c.normal, 1)
+
+	for i := 0; i < c.manifold.count; i++ {
+		cp := &c.points[i]
+
+		vt := c.relativeVelocity(cp).Dot(tangent)
+		maxFriction := c.friction * cp.normalImpulse
+		impulse := fmath.Clamp32(cp.tangentImpulse-cp.tangentMass*vt, -maxFriction, maxFriction)
+		lambda := impulse - cp.tangentImpulse
+		cp.tangentImpulse = impulse
+
+		c.apply(cp, tangent.Mul(lambda))
+	}
+
+	for i := 0; i < c.manifold.count; i++ {
+		cp := &c.points[i]
+
+		vn := c.relativeVelocity(cp).Dot(c.normal)
+		impulse := fmath.Max32(cp.normalImpulse-cp.normalMass*(vn-cp.velocityBias), 0)
+		lambda := impulse - cp.normalImpulse
+		cp.normalImpulse = impulse
+
+		c.apply(cp, c.normal.Mul(lambda))
+	}
+}
+
+// storeImpulses keeps the impulses of the step to warm start the next.
+func (c *contact) storeImpulses() {
+	for i := 0; i < c.manifold.count; i++ {
+		c.manifold.points[i].normalImpulse = c.points[i].normalImpulse
+		c.manifold.points[i].tangentImpulse = c.points[i].tangentImpulse
+	}
+}
+
+// solvePosition pushes the bodies apart along the normal of the contact. It
+// reports if the overlap is within the tolerance.
+func (c *contact) solvePosition() bool {
+	bA, bB := c.fixtureA.body, c.fixtureB.body
+	mA, mB := bA.invMass, bB.invMass
+	iA, iB := bA.invInertia, bB.invInertia
+	rA, rB := c.fixtureA.shape.radius(), c.fixtureB.shape.radius()
+
+	minSeparation := float32(0)
+
+	for i := 0; i < c.manifold.count; i++ {
+		bA.synchronize()
+		bB.synchronize()
+
+		normal, point, separation := c.positionManifold(i, rA, rB)
+
+		ra := point.Sub(bA.center)
+		rb := point.Sub(bB.center)
+		minSeparation = fmath.Min32(minSeparation, separation)
+
+		// Only part of the overlap is resolved in each iteration, which
+		// avoids overshoot.
+		C := fmath.Clamp32(baumgarte*(separation+linearSlop), -maxLinearCorrection, 0)
+
+		rnA := cross(ra, normal)
+		rnB := cross(rb, normal)
+		k := mA + mB + iA*rnA*rnA + iB*rnB*rnB
+		if k <= 0 {
+			continue
+		}
+
+		p := normal.Mul(-C / k)
+
+		bA.center = bA.center.Sub(p.Mul(mA))
+		bA.angle -= iA * cross(ra, p)
+		bB.center = bB.center.Add(p.Mul(mB))
+		bB.angle += iB * cross(rb, p)
+	}
+
+	bA.synchronize()
+	bB.synchronize()
+
+	return minSeparation >= -3*linearSlop
+}
+
+// positionManifold returns the normal, point and separation of a point of
+// the manifold for the current positions of the bodies.
+func (c *contact) positionManifold(i int, radiusA, radiusB float32) (mgl32.Vec2, mgl32.Vec2, float32) {
+	xfA, xfB := c.fixtureA.body.xf, c.fixtureB.body.xf
+	m := &c.manifold
+
+	switch m.kind {
+	case manifoldCircles:
+		pointA := xfA.apply(m.localPoint)
+		pointB := xfB.apply(m.points[0].localPoint)
+
+		normal := mgl32.Vec2{1, 0}
+		if pointB.Sub(pointA).LenSqr() > 1e-12 {
+			normal = pointB.Sub(pointA).Normalize()
+		}
+
+		return normal, pointA.Add(pointB).Mul(0.5), pointB.Sub(pointA).Dot(normal) - radiusA - radiusB
+
+	case manifoldFaceA:
+		normal := xfA.q.apply(m.localNormal)
+		planePoint := xfA.apply(m.localPoint)
+		clipPoint := xfB.apply(m.points[i].localPoint)
+
+		return normal, clipPoint, clipPoint.Sub(planePoint).Dot(normal) - radiusA - radiusB
+	}
+
+	normal := xfB.q.apply(m.localNormal)
+	planePoint := xfB.apply(m.localPoint)
+	clipPoint := xfA.apply(m.points[i].localPoint)
+
+	return normal.Mul(-1), clipPoint, clipPoint.Sub(planePoint).Dot(normal) - radiusA - radiusB
+}
@@ -0,0 +1,561 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	fmath "github.com/haakenlabs/arc/pkg/math"
+)
+
+var _ Joint = &DistanceJoint{}
+var _ Joint = &RevoluteJoint{}
+var _ Joint = &WeldJoint{}
+
+// timeStep is the time step being solved.
+type timeStep struct {
+	dt    float32
+	invDt float32
+}
+
+// Joint constrains the motion of two bodies relative to each other. Joints
+// are added to the world of their bodies with World.AddJoint.
+type Joint interface {
+	// BodyA returns the first body of the joint.
+	BodyA() *Body
+
+	// BodyB returns the second body of the joint.
+	BodyB() *Body
+
+	// CollideConnected reports if the bodies of the joint collide with each
+	// other.
+	CollideConnected() bool
+
+	// SetCollideConnected sets if the bodies of the joint collide with each
+	// other.
+	SetCollideConnected(collide bool)
+
+	initVelocity(step timeStep)
+	solveVelocity(step timeStep)
+	solvePosition() bool
+}
+
+// jointBase holds the bodies of a joint.
+type jointBase struct {
+	bodyA            *Body
+	bodyB            *Body
+	collideConnected bool
+}
+
+// BodyA returns the first body of the joint.
+func (j *jointBase) BodyA() *Body {
+	return j.bodyA
+}
+
+// BodyB returns the second body of the joint.
+func (j *jointBase) BodyB() *Body {
+	return j.bodyB
+}
+
+// CollideConnected reports if the bodies of the joint collide with each
+// other.
+func (j *jointBase) CollideConnected() bool {
+	return j.collideConnected
+}
+
+// SetCollideConnected sets if the bodies of the joint collide with each
+// other. By default they do not.
+func (j *jointBase) SetCollideConnected(collide bool) {
+	j.collideConnected = collide
+}
+
+// anchors returns the offsets of the local anchors from the centers of mass
+// of the bodies, rotated into world space.
+func (j *jointBase) anchors(localA, localB mgl32.Vec2) (mgl32.Vec2, mgl32.Vec2) {
+	rA := newRot(j.bodyA.angle).apply(localA.Sub(j.bodyA.localCenter))
+	rB := newRot(j.bodyB.angle).apply(localB.Sub(j.bodyB.localCenter))
+
+	return rA, rB
+}
+
+// applyVelocity applies a linear impulse at the anchors and an angular
+// impulse, from A to B.
+func (j *jointBase) applyVelocity(rA, rB, p mgl32.Vec2, angular float32) {
+	a, b := j.bodyA, j.bodyB
+
+	a.linearVelocity = a.linearVelocity.Sub(p.Mul(a.invMass))
+	a.angularVelocity -= a.invInertia * (cross(rA, p) + angular)
+	b.linearVelocity = b.linearVelocity.Add(p.Mul(b.invMass))
+	b.angularVelocity += b.invInertia * (cross(rB, p) + angular)
+}
+
+// applyPosition moves the bodies by a linear impulse at the anchors and an
+// angular impulse, from A to B.
+func (j *jointBase) applyPosition(rA, rB, p mgl32.Vec2, angular float32) {
+	a, b := j.bodyA, j.bodyB
+
+	a.center = a.center.Sub(p.Mul(a.invMass))
+	a.angle -= a.invInertia * (cross(rA, p) + angular)
+	b.center = b.center.Add(p.Mul(b.invMass))
+	b.angle += b.invInertia * (cross(rB, p) + angular)
+
+	a.synchronize()
+	b.synchronize()
+}
+
+// pointMass returns the effective mass matrix of a point constraint at the
+// anchors.
+func (j *jointBase) pointMass(rA, rB mgl32.Vec2) mgl32.Mat2 {
+	mA, mB := j.bodyA.invMass, j.bodyB.invMass
+	iA, iB := j.bodyA.invInertia, j.bodyB.invInertia
+
+	k11 := mA + mB + rA[1]*rA[1]*iA + rB[1]*rB[1]*iB
+	k12 := -rA[1]*rA[0]*iA - rB[1]*rB[0]*iB
+	k22 := mA + mB + rA[0]*rA[0]*iA + rB[0]*rB[0]*iB
+
+	return mgl32.Mat2{k11, k12, k12, k22}
+}
+
+// pointVelocity returns the velocity of the anchor of B relative to the
+// anchor of A.
+func (j *jointBase) pointVelocity(rA, rB mgl32.Vec2) mgl32.Vec2 {
+	a, b := j.bodyA, j.bodyB
+
+	vA := a.linearVelocity.Add(crossSV(a.angularVelocity, rA))
+	vB := b.linearVelocity.Add(crossSV(b.angularVelocity, rB))
+
+	return vB.Sub(vA)
+}
+
+// DistanceJoint keeps a point of each body at a distance from each other,
+// like a rod. With a frequency it acts as a spring instead.
+type DistanceJoint struct {
+	jointBase
+
+	localAnchorA mgl32.Vec2
+	localAnchorB mgl32.Vec2
+	length       float32
+	frequency    float32
+	dampingRatio float32
+
+	impulse float32
+	u       mgl32.Vec2
+	rA, rB  mgl32.Vec2
+	mass    float32
+	gamma   float32
+	bias    float32
+}
+
+// NewDistanceJoint creates a joint which keeps the world points anchorA of a
+// and anchorB of b at their current distance.
+func NewDistanceJoint(a, b *Body, anchorA, anchorB mgl32.Vec2) *DistanceJoint {
+	return &DistanceJoint{
+		jointBase:    jointBase{bodyA: a, bodyB: b},
+		localAnchorA: a.LocalPoint(anchorA),
+		localAnchorB: b.LocalPoint(anchorB),
+		length:       fmath.Max32(anchorB.Sub(anchorA).Len(), linearSlop),
+	}
+}
+
+// Length returns the distance kept between the anchors.
+func (j *DistanceJoint) Length() float32 {
+	return j.length
+}
+
+// SetLength sets the distance kept between the anchors.
+func (j *DistanceJoint) SetLength(length float32) {
+	j.length = fmath.Max32(length, linearSlop)
+}
+
+// Frequency returns the frequency of the spring in hertz, or zero if the
+// joint is rigid.
+func (j *DistanceJoint) Frequency() float32 {
+	return j.frequency
+}
+
+// SetFrequency sets the frequency of the spring in hertz. A frequency of
+// zero makes the joint rigid. Frequencies above half the step rate act as
+// rigid joints.
+func (j *DistanceJoint) SetFrequency(hz float32) {
+	j.frequency = hz
+}
+
+// DampingRatio returns the damping of the spring, where one is critical
+// damping.
+func (j *DistanceJoint) DampingRatio() float32 {
+	return j.dampingRatio
+}
+
+// SetDampingRatio sets the damping of the spring, where one is critical
+// damping.
+func (j *DistanceJoint) SetDampingRatio(ratio float32) {
+	j.dampingRatio = ratio
+}
+
+func (j *DistanceJoint) initVelocity(step timeStep) {
+	a, b := j.bodyA, j.bodyB
+
+	j.rA, j.rB = j.anchors(j.localAnchorA, j.localAnchorB)
+	j.u = b.center.Add(j.rB).Sub(a.center).Sub(j.rA)
+
+	length := j.u.Len()
+	if length > linearSlop {
+		j.u = j.u.Mul(1 / length)
+	} else {
+		j.u = mgl32.Vec2{}
+	}
+
+	crA := cross(j.rA, j.u)
+	crB := cross(j.rB, j.u)
+	invMass := a.invMass + a.invInertia*crA*crA + b.invMass + b.invInertia*crB*crB
+
+	j.mass = 0
+	if invMass != 0 {
+		j.mass = 1 / invMass
+	}
+
+	// Springs are soft constraints: the stiffness and damping become a
+	// softness gamma and a bias velocity.
+	j.gamma, j.bias = 0, 0
+	if j.frequency > 0 {
+		omega := 2 * math.Pi * j.frequency
+		d := 2 * j.mass * j.dampingRatio * float32(omega)
+		k := j.mass * float32(omega*omega)
+
+		j.gamma = step.dt * (d + step.dt*k)
+		if j.gamma != 0 {
+			j.gamma = 1 / j.gamma
+		}
+		j.bias = (length - j.length) * step.dt * k * j.gamma
+
+		invMass += j.gamma
+		j.mass = 0
+		if invMass != 0 {
+			j.mass = 1 / invMass
+		}
+	}
+
+	j.applyVelocity(j.rA, j.rB, j.u.Mul(j.impulse), 0)
+}
+
+func (j *DistanceJoint) solveVelocity(timeStep) {
+	cdot := j.u.Dot(j.pointVelocity(j.rA, j.rB))
+
+	impulse := -j.mass * (cdot + j.bias + j.gamma*j.impulse)
+	j.impulse += impulse
+
+	j.applyVelocity(j.rA, j.rB, j.u.Mul(impulse), 0)
+}
+
+func (j *DistanceJoint) solvePosition() bool {
+	if j.frequency > 0 {
+		return true
+	}
+
+	a, b := j.bodyA, j.bodyB
+
+	rA, rB := j.anchors(j.localAnchorA, j.localAnchorB)
+	u := b.center.Add(rB).Sub(a.center).Sub(rA)
+
+	length := u.Len()
+	if length > 0 {
+		u = u.Mul(1 / length)
+	}
+	c := fmath.Clamp32(length-j.length, -maxLinearCorrection, maxLinearCorrection)
+
+	j.applyPosition(rA, rB, u.Mul(-j.mass*c), 0)
+
+	return abs(c) < linearSlop
+}
+
+// RevoluteJoint pins two bodies together at a point they rotate around, like
+// a hinge. It can drive the rotation with a motor, and limit its angle.
+type RevoluteJoint struct {
+	jointBase
+
+	localAnchorA   mgl32.Vec2
+	localAnchorB   mgl32.Vec2
+	referenceAngle float32
+
+	enableMotor    bool
+	motorSpeed     float32
+	maxMotorTorque float32
+	enableLimit    bool
+	lowerAngle     float32
+	upperAngle     float32
+
+	impulse      mgl32.Vec2
+	motorImpulse float32
+	lowerImpulse float32
+	upperImpulse float32
+	rA, rB       mgl32.Vec2
+	k            mgl32.Mat2
+	axialMass    float32
+	angle        float32
+}
+
+// NewRevoluteJoint creates a joint which pins a and b together at a world
+// point.
+func NewRevoluteJoint(a, b *Body, anchor mgl32.Vec2) *RevoluteJoint {
+	return &RevoluteJoint{
+		jointBase:      jointBase{bodyA: a, bodyB: b},
+		localAnchorA:   a.LocalPoint(anchor),
+		localAnchorB:   b.LocalPoint(anchor),
+		referenceAngle: b.angle - a.angle,
+	}
+}
+
+// Anchor returns the world position of the pin on body A.
+func (j *RevoluteJoint) Anchor() mgl32.Vec2 {
+	return j.bodyA.WorldPoint(j.localAnchorA)
+}
+
+// JointAngle returns the angle of B relative to A, from when the joint was
+// made.
+func (j *RevoluteJoint) JointAngle() float32 {
+	return j.bodyB.angle - j.bodyA.angle - j.referenceAngle
+}
+
+// JointSpeed returns the angular velocity of B relative to A.
+func (j *RevoluteJoint) JointSpeed() float32 {
+	return j.bodyB.angularVelocity - j.bodyA.angularVelocity
+}
+
+// MotorEnabled reports if the motor drives the joint.
+func (j *RevoluteJoint) MotorEnabled() bool {
+	return j.enableMotor
+}
+
+// EnableMotor sets if the motor drives the joint.
+func (j *RevoluteJoint) EnableMotor(enable bool) {
+	j.enableMotor = enable
+}
+
+// MotorSpeed returns the speed the motor drives the joint at, in radians
+// per second.
+func (j *RevoluteJoint) MotorSpeed() float32 {
+	return j.motorSpeed
+}
+
+// SetMotorSpeed sets the speed the motor drives the joint at, in radians per
+// second.
+func (j *RevoluteJoint) SetMotorSpeed(speed float32) {
+	j.motorSpeed = speed
+}
+
+// MaxMotorTorque returns the largest torque the motor applies.
+func (j *RevoluteJoint) MaxMotorTorque() float32 {
+	return j.maxMotorTorque
+}
+
+// SetMaxMotorTorque sets the largest torque the motor applies.
+func (j *RevoluteJoint) SetMaxMotorTorque(torque float32) {
+	j.maxMotorTorque = torque
+}
+
+// LimitEnabled reports if the angle of the joint is limited.
+func (j *RevoluteJoint) LimitEnabled() bool {
+	return j.enableLimit
+}
+
+// EnableLimit sets if the angle of the joint is limited.
+func (j *RevoluteJoint) EnableLimit(enable bool) {
+	j.enableLimit = enable
+	j.lowerImpulse, j.upperImpulse = 0, 0
+}
+
+// Limits returns the lower and upper angle of the joint, in radians.
+func (j *RevoluteJoint) Limits() (float32, float32) {
+	return j.lowerAngle, j.upperAngle
+}
+
+// SetLimits sets the lower and upper angle of the joint, in radians.
+func (j *RevoluteJoint) SetLimits(lower, upper float32) {
+	j.lowerAngle = fmath.Min32(lower, upper)
+	j.upperAngle = fmath.Max32(lower, upper)
+	j.lowerImpulse, j.upperImpulse = 0, 0
+}
+
+func (j *RevoluteJoint) initVelocity(timeStep) {
+	a, b := j.bodyA, j.bodyB
+
+	j.rA, j.rB = j.anchors(j.localAnchorA, j.localAnchorB)
+	j.k = j.pointMass(j.rA, j.rB)
+
+	j.axialMass = a.invInertia + b.invInertia
+	if j.axialMass > 0 {
+		j.axialMass = 1 / j.axialMass
+	}
+	j.angle = j.JointAngle()
+
+	if !j.enableMotor {
+		j.motorImpulse = 0
+	}
+	if !j.enableLimit {
+		j.lowerImpulse, j.upperImpulse = 0, 0
+	}
+
+	axial := j.motorImpulse + j.lowerImpulse - j.upperImpulse
+	j.applyVelocity(j.rA, j.rB, j.impulse, axial)
+}
+
+func (j *RevoluteJoint) solveVelocity(step timeStep) {
+	a, b := j.bodyA, j.bodyB
+
+	if j.enableMotor {
+		cdot := b.angularVelocity - a.angularVelocity - j.motorSpeed
+		maxImpulse := step.dt * j.maxMotorTorque
+
+		old := j.motorImpulse
+		j.motorImpulse = fmath.Clamp32(old-j.axialMass*cdot, -maxImpulse, maxImpulse)
+		j.applyVelocity(j.rA, j.rB, mgl32.Vec2{}, j.motorImpulse-old)
+	}
+
+	if j.enableLimit {
+		// The limits only push, and let the joint close in on them at the
+		// speed which reaches them by the end of the step.
+		c := j.angle - j.lowerAngle
+		cdot := b.angularVelocity - a.angularVelocity
+		old := j.lowerImpulse
+		j.lowerImpulse = fmath.Max32(old-j.axialMass*(cdot+fmath.Max32(c, 0)*step.invDt), 0)
+		j.applyVelocity(j.rA, j.rB, mgl32.Vec2{}, j.lowerImpulse-old)
+
+		c = j.upperAngle - j.angle
+		cdot = a.angularVelocity - b.angularVelocity
+		old = j.upperImpulse
+		j.upperImpulse = fmath.Max32(old-j.axialMass*(cdot+fmath.Max32(c, 0)*step.invDt), 0)
+		j.applyVelocity(j.rA, j.rB, mgl32.Vec2{}, -(j.upperImpulse - old))
+	}
+
+	impulse := j.k.Inv().Mul2x1(j.pointVelocity(j.rA, j.rB).Mul(-1))
+	j.impulse = j.impulse.Add(impulse)
+	j.applyVelocity(j.rA, j.rB, impulse, 0)
+}
+
+func (j *RevoluteJoint) solvePosition() bool {
+	a, b := j.bodyA, j.bodyB
+
+	angularError := float32(0)
+	if j.enableLimit && j.axialMass > 0 {
+		angle := j.JointAngle()
+
+		var c float32
+		switch {
+		case abs(j.upperAngle-j.lowerAngle) < 2*angularSlop:
+			c = fmath.Clamp32(angle-j.lowerAngle, -maxAngularCorrection, maxAngularCorrection)
+		case angle <= j.lowerAngle:
+			c = fmath.Clamp32(angle-j.lowerAngle+angularSlop, -maxAngularCorrection, 0)
+		case angle >= j.upperAngle:
+			c = fmath.Clamp32(angle-j.upperAngle-angularSlop, 0, maxAngularCorrection)
+		}
+
+		j.applyPosition(mgl32.Vec2{}, mgl32.Vec2{}, mgl32.Vec2{}, -j.axialMass*c)
+		angularError = abs(c)
+	}
+
+	rA, rB := j.anchors(j.localAnchorA, j.localAnchorB)
+	c := b.center.Add(rB).Sub(a.center).Sub(rA)
+
+	impulse := j.pointMass(rA, rB).Inv().Mul2x1(c).Mul(-1)
+	j.applyPosition(rA, rB, impulse, 0)
+
+	return c.Len() <= linearSlop && angularError <= angularSlop
+}
+
+// WeldJoint holds two bodies together at a point, so they move as one.
+type WeldJoint struct {
+	jointBase
+
+	localAnchorA   mgl32.Vec2
+	localAnchorB   mgl32.Vec2
+	referenceAngle float32
+
+	impulse mgl32.Vec3
+	rA, rB  mgl32.Vec2
+	mass    mgl32.Mat3
+}
+
+// NewWeldJoint creates a joint which holds a and b together at a world
+// point, in their current orientations.
+func NewWeldJoint(a, b *Body, anchor mgl32.Vec2) *WeldJoint {
+	return &WeldJoint{
+		jointBase:      jointBase{bodyA: a, bodyB: b},
+		localAnchorA:   a.LocalPoint(anchor),
+		localAnchorB:   b.LocalPoint(anchor),
+		referenceAngle: b.angle - a.angle,
+	}
+}
+
+// Anchor returns the world position of the weld on body A.
+func (j *WeldJoint) Anchor() mgl32.Vec2 {
+	return j.bodyA.WorldPoint(j.localAnchorA)
+}
+
+// weldMass returns the effective mass matrix of the point and angle
+// constraints.
+func (j *WeldJoint) weldMass(rA, rB mgl32.Vec2) mgl32.Mat3 {
+	iA, iB := j.bodyA.invInertia, j.bodyB.invInertia
+	k := j.pointMass(rA, rB)
+
+	k13 := -rA[1]*iA - rB[1]*iB
+	k23 := rA[0]*iA + rB[0]*iB
+	k33 := iA + iB
+
+	return mgl32.Mat3{
+		k.At(0, 0), k.At(1, 0), k13,
+		k.At(0, 1), k.At(1, 1), k23,
+		k13, k23, k33,
+	}
+}
+
+func (j *WeldJoint) initVelocity(timeStep) {
+	j.rA, j.rB = j.anchors(j.localAnchorA, j.localAnchorB)
+	j.mass = j.weldMass(j.rA, j.rB).Inv()
+
+	j.applyVelocity(j.rA, j.rB, j.impulse.Vec2(), j.impulse[2])
+}
+
+func (j *WeldJoint) solveVelocity(timeStep) {
+	a, b := j.bodyA, j.bodyB
+
+	cdot := j.pointVelocity(j.rA, j.rB).Vec3(b.angularVelocity - a.angularVelocity)
+	impulse := j.mass.Mul3x1(cdot).Mul(-1)
+	j.impulse = j.impulse.Add(impulse)
+
+	j.applyVelocity(j.rA, j.rB, impulse.Vec2(), impulse[2])
+}
+
+func (j *WeldJoint) solvePosition() bool {
+	a, b := j.bodyA, j.bodyB
+
+	rA, rB := j.anchors(j.localAnchorA, j.localAnchorB)
+	c1 := b.center.Add(rB).Sub(a.center).Sub(rA)
+	c2 := b.angle - a.angle - j.referenceAngle
+
+	impulse := j.weldMass(rA, rB).Inv().Mul3x1(c1.Vec3(c2)).Mul(-1)
+	j.applyPosition(rA, rB, impulse.Vec2(), impulse[2])
+
+	return c1.Len() <= linearSlop && abs(c2) <= angularSlop
+}
@@ -0,0 +1,452 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ scene.ScriptComponent = &HingeJoint{}
+var _ scene.ScriptComponent = &FixedJoint{}
+var _ scene.ScriptComponent = &SpringJoint{}
+var _ scene.DestroyListener = &HingeJoint{}
+var _ scene.DestroyListener = &FixedJoint{}
+var _ scene.DestroyListener = &SpringJoint{}
+
+// jointComponent holds the state shared by the joint components. A joint
+// component connects the rigid body of its object to a connected rigid
+// body, or to the ground of the simulator if it has none. The joint is made
+// when the component starts.
+type jointComponent struct {
+	scene.BaseScriptComponent
+
+	connected        *RigidBody
+	connectedName    string
+	anchor           mgl32.Vec2
+	collideConnected bool
+
+	joint Joint
+	world *World
+}
+
+// ConnectedBody returns the rigid body the joint connects to, or nil if it
+// connects to the ground.
+func (j *jointComponent) ConnectedBody() *RigidBody {
+	return j.connected
+}
+
+// SetConnectedBody sets the rigid body the joint connects to. A nil body
+// connects the joint to the ground. It has no effect once the joint is
+// made.
+func (j *jointComponent) SetConnectedBody(body *RigidBody) {
+	j.connected = body
+	j.connectedName = ""
+}
+
+// Anchor returns the point of the joint in the space of the object.
+func (j *jointComponent) Anchor() mgl32.Vec2 {
+	return j.anchor
+}
+
+// SetAnchor sets the point of the joint in the space of the object. It has
+// no effect once the joint is made.
+func (j *jointComponent) SetAnchor(anchor mgl32.Vec2) {
+	j.anchor = anchor
+}
+
+// CollideConnected reports if the connected bodies collide with each other.
+func (j *jointComponent) CollideConnected() bool {
+	return j.collideConnected
+}
+
+// SetCollideConnected sets if the connected bodies collide with each other.
+// By default they do not.
+func (j *jointComponent) SetCollideConnected(collide bool) {
+	j.collideConnected = collide
+	if j.joint != nil {
+		j.joint.SetCollideConnected(collide)
+	}
+}
+
+// Joint returns the joint of the component, or nil if it is not made yet.
+func (j *jointComponent) Joint() Joint {
+	return j.joint
+}
+
+// OnDestroy removes the joint from the world.
+func (j *jointComponent) OnDestroy() {
+	if j.joint != nil {
+		j.world.RemoveJoint(j.joint)
+		j.joint = nil
+	}
+}
+
+// bodies returns the rigid body of the object and the body it connects to,
+// once both are in the same simulator.
+func (j *jointComponent) bodies() (*RigidBody, *Body, bool) {
+	g := j.GameObject()
+	if g == nil {
+		return nil, nil, false
+	}
+
+	var own *RigidBody
+	for _, c := range g.Components() {
+		if r, ok := c.(*RigidBody); ok {
+			own = r
+			break
+		}
+	}
+	if own == nil {
+		logrus.Error("[Physics2D] no rigid body for joint of ", g.Name())
+		return nil, nil, false
+	}
+	if !own.prepare() {
+		return nil, nil, false
+	}
+
+	if j.connected == nil && j.connectedName != "" {
+		for _, r := range scene.FindObjectsOfType[*RigidBody](g.Scene()) {
+			if r.GameObject().Name() == j.connectedName {
+				j.connected = r
+				break
+			}
+		}
+		if j.connected == nil {
+			logrus.Error("[Physics2D] no rigid body named ", j.connectedName, " for joint of ", g.Name())
+			return nil, nil, false
+		}
+	}
+
+	if j.connected == nil {
+		return own, own.simulator.Ground(), true
+	}
+	if !j.connected.prepare() {
+		return nil, nil, false
+	}
+	if j.connected.simulator != own.simulator {
+		logrus.Error("[Physics2D] joint of ", g.Name(), " connects bodies of different simulators")
+		return nil, nil, false
+	}
+
+	return own, j.connected.body, true
+}
+
+// connect adds a joint to the world of own.
+func (j *jointComponent) connect(own *RigidBody, joint Joint) {
+	joint.SetCollideConnected(j.collideConnected)
+
+	j.joint = joint
+	j.world = own.simulator.world
+	j.world.AddJoint(joint)
+}
+
+// connectedBodyName returns the name of the object of the connected body, for
+// scene files.
+func (j *jointComponent) connectedBodyName() string {
+	if j.connected != nil && j.connected.GameObject() != nil {
+		return j.connected.GameObject().Name()
+	}
+
+	return j.connectedName
+}
+
+// HingeJoint is a joint component which lets its object rotate around the
+// anchor, relative to the connected body. The rotation can be driven by a
+// motor and limited to a range of angles, such as for doors and wheels.
+type HingeJoint struct {
+	jointComponent
+
+	useMotor       bool
+	motorSpeed     float32
+	maxMotorTorque float32
+	useLimits      bool
+	lowerAngle     float32
+	upperAngle     float32
+
+	revolute *RevoluteJoint
+}
+
+// NewHingeJoint creates a new hinge joint to the connected body, at a point
+// in the space of the object. A nil body hinges the object to the ground.
+func NewHingeJoint(connected *RigidBody, anchor mgl32.Vec2) *HingeJoint {
+	j := &HingeJoint{}
+	j.connected = connected
+	j.anchor = anchor
+
+	j.SetName("HingeJoint")
+	instance.MustAssign(j)
+
+	return j
+}
+
+// Start makes the joint.
+func (j *HingeJoint) Start() {
+	own, other, ok := j.bodies()
+	if !ok {
+		return
+	}
+
+	j.revolute = NewRevoluteJoint(other, own.body, own.body.WorldPoint(j.anchor))
+	j.apply()
+	j.connect(own, j.revolute)
+}
+
+// Angle returns the angle of the object relative to the connected body,
+// from when the joint was made.
+func (j *HingeJoint) Angle() float32 {
+	if j.revolute == nil {
+		return 0
+	}
+
+	return j.revolute.JointAngle()
+}
+
+// UseMotor reports if the motor drives the hinge.
+func (j *HingeJoint) UseMotor() bool {
+	return j.useMotor
+}
+
+// SetUseMotor sets if the motor drives the hinge.
+func (j *HingeJoint) SetUseMotor(use bool) {
+	j.useMotor = use
+	j.apply()
+}
+
+// Motor returns the speed the motor drives the hinge at in radians per
+// second, and the largest torque it applies.
+func (j *HingeJoint) Motor() (float32, float32) {
+	return j.motorSpeed, j.maxMotorTorque
+}
+
+// SetMotor sets the speed the motor drives the hinge at in radians per
+// second, and the largest torque it applies.
+func (j *HingeJoint) SetMotor(speed, maxTorque float32) {
+	j.motorSpeed = speed
+	j.maxMotorTorque = maxTorque
+	j.apply()
+}
+
+// UseLimits reports if the angle of the hinge is limited.
+func (j *HingeJoint) UseLimits() bool {
+	return j.useLimits
+}
+
+// SetUseLimits sets if the angle of the hinge is limited.
+func (j *HingeJoint) SetUseLimits(use bool) {
+	j.useLimits = use
+	j.apply()
+}
+
+// Limits returns the lower and upper angle of the hinge, in radians.
+func (j *HingeJoint) Limits() (float32, float32) {
+	return j.lowerAngle, j.upperAngle
+}
+
+// SetLimits sets the lower and upper angle of the hinge, in radians.
+func (j *HingeJoint) SetLimits(lower, upper float32) {
+	j.lowerAngle = lower
+	j.upperAngle = upper
+	j.apply()
+}
+
+// apply sets the motor and limits of the joint, once it is made.
+func (j *HingeJoint) apply() {
+	if j.revolute == nil {
+		return
+	}
+
+	j.revolute.EnableMotor(j.useMotor)
+	j.revolute.SetMotorSpeed(j.motorSpeed)
+	j.revolute.SetMaxMotorTorque(j.maxMotorTorque)
+
+	if lower, upper := j.revolute.Limits(); lower != j.lowerAngle || upper != j.upperAngle {
+		j.revolute.SetLimits(j.lowerAngle, j.upperAngle)
+	}
+	if j.revolute.LimitEnabled() != j.useLimits {
+		j.revolute.EnableLimit(j.useLimits)
+	}
+}
+
+// OnDestroy removes the joint from the world.
+func (j *HingeJoint) OnDestroy() {
+	j.jointComponent.OnDestroy()
+	j.revolute = nil
+}
+
+// BallSocketJoint is an alias of HingeJoint. In the plane a ball and socket
+// can only rotate around its anchor, which is what a hinge does, so ragdolls
+// and chains use hinges without a motor or limits. Scene files may still
+// name it BallSocketJoint2D, and it is saved as HingeJoint2D.
+type BallSocketJoint = HingeJoint
+
+// NewBallSocketJoint creates a new hinge joint to the connected body, at a
+// point in the space of the object.
+func NewBallSocketJoint(connected *RigidBody, anchor mgl32.Vec2) *BallSocketJoint {
+	return NewHingeJoint(connected, anchor)
+}
+
+// FixedJoint is a joint component which holds its object to the connected
+// body, so they move as one. It suits objects which break apart, as
+// destroying the joint frees them.
+type FixedJoint struct {
+	jointComponent
+}
+
+// NewFixedJoint creates a new fixed joint to the connected body, at a point
+// in the space of the object. A nil body fixes the object to the ground.
+func NewFixedJoint(connected *RigidBody, anchor mgl32.Vec2) *FixedJoint {
+	j := &FixedJoint{}
+	j.connected = connected
+	j.anchor = anchor
+
+	j.SetName("FixedJoint")
+	instance.MustAssign(j)
+
+	return j
+}
+
+// Start makes the joint.
+func (j *FixedJoint) Start() {
+	if own, other, ok := j.bodies(); ok {
+		j.connect(own, NewWeldJoint(other, own.body, own.body.WorldPoint(j.anchor)))
+	}
+}
+
+// SpringJoint is a joint component which pulls the anchor of its object
+// towards a length from the connected anchor of the connected body, such as
+// for suspensions and bouncy platforms.
+type SpringJoint struct {
+	jointComponent
+
+	connectedAnchor mgl32.Vec2
+	length          float32
+	frequency       float32
+	dampingRatio    float32
+
+	distance *DistanceJoint
+}
+
+// NewSpringJoint creates a new spring joint between a point in the space of
+// the object and a point in the space of the connected body. A nil body
+// attaches the spring to the ground, and connectedAnchor is then a world
+// point. The spring has a frequency of 2 Hz and a damping ratio of 0.5, and
+// rests at the distance between the points when the joint is made.
+func NewSpringJoint(connected *RigidBody, anchor, connectedAnchor mgl32.Vec2) *SpringJoint {
+	j := &SpringJoint{
+		connectedAnchor: connectedAnchor,
+		frequency:       2,
+		dampingRatio:    0.5,
+	}
+	j.connected = connected
+	j.anchor = anchor
+
+	j.SetName("SpringJoint")
+	instance.MustAssign(j)
+
+	return j
+}
+
+// Start makes the joint.
+func (j *SpringJoint) Start() {
+	own, other, ok := j.bodies()
+	if !ok {
+		return
+	}
+
+	j.distance = NewDistanceJoint(other, own.body, other.WorldPoint(j.connectedAnchor), own.body.WorldPoint(j.anchor))
+	j.apply()
+	j.connect(own, j.distance)
+}
+
+// ConnectedAnchor returns the point of the spring in the space of the
+// connected body.
+func (j *SpringJoint) ConnectedAnchor() mgl32.Vec2 {
+	return j.connectedAnchor
+}
+
+// SetConnectedAnchor sets the point of the spring in the space of the
+// connected body. It has no effect once the joint is made.
+func (j *SpringJoint) SetConnectedAnchor(anchor mgl32.Vec2) {
+	j.connectedAnchor = anchor
+}
+
+// Length returns the rest length of the spring, or zero if it rests at the
+// distance between its points when the joint is made.
+func (j *SpringJoint) Length() float32 {
+	return j.length
+}
+
+// SetLength sets the rest length of the spring. A length of zero keeps the
+// distance between its points when the joint is made.
+func (j *SpringJoint) SetLength(length float32) {
+	j.length = length
+	j.apply()
+}
+
+// Frequency returns the frequency of the spring in hertz.
+func (j *SpringJoint) Frequency() float32 {
+	return j.frequency
+}
+
+// SetFrequency sets the frequency of the spring in hertz. Stiffer springs
+// have higher frequencies. A frequency of zero makes the spring a rigid rod.
+func (j *SpringJoint) SetFrequency(hz float32) {
+	j.frequency = hz
+	j.apply()
+}
+
+// DampingRatio returns the damping of the spring, where one is critical
+// damping.
+func (j *SpringJoint) DampingRatio() float32 {
+	return j.dampingRatio
+}
+
+// SetDampingRatio sets the damping of the spring, where one is critical
+// damping.
+func (j *SpringJoint) SetDampingRatio(ratio float32) {
+	j.dampingRatio = ratio
+	j.apply()
+}
+
+// apply sets the length and stiffness of the joint, once it is made.
+func (j *SpringJoint) apply() {
+	if j.distance == nil {
+		return
+	}
+
+	if j.length > 0 {
+		j.distance.SetLength(j.length)
+	}
+	j.distance.SetFrequency(j.frequency)
+	j.distance.SetDampingRatio(j.dampingRatio)
+}
+
+// OnDestroy removes the joint from the world.
+func (j *SpringJoint) OnDestroy() {
+	j.jointComponent.OnDestroy()
+	j.distance = nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package physics2d implements a lightweight 2D rigid body physics world in
+// the style of Box2D. Bodies have circle and convex polygon fixtures, collide
+// with each other, and can be connected by joints. Constraints are solved
+// with sequential impulses.
+//
+// A World can be stepped on its own, or by a Simulator component on the
+// fixed update of a scene. The world lies in the XY plane of the scene, and
+// bodies rotate around its Z axis. Lengths are in meters, and the solver is
+// tuned for moving objects between 0.1 and 10 meters in size.
+package physics2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// linearSlop is the collision and constraint tolerance in meters.
+	linearSlop = 0.005
+	// angularSlop is the constraint tolerance in radians.
+	angularSlop = 2.0 / 180.0 * math.Pi
+	// polygonRadius is the skin around polygons, which keeps them from
+	// resting exactly on their faces.
+	polygonRadius = 2 * linearSlop
+	// maxLinearCorrection limits position corrections to prevent
+	// overshoot.
+	maxLinearCorrection = 0.2
+	// maxAngularCorrection limits angle corrections to prevent overshoot.
+	maxAngularCorrection = 8.0 / 180.0 * math.Pi
+	// baumgarte is the fraction of overlap resolved in each position
+	// iteration.
+	baumgarte = 0.2
+	// velocityThreshold is the relative speed below which collisions are
+	// inelastic.
+	velocityThreshold = 1.0
+	// maxTranslation is the furthest a body moves in one step.
+	maxTranslation = 2.0
+	// maxRotation is the furthest a body rotates in one step.
+	maxRotation = 0.5 * math.Pi
+)
+
+// rot is a rotation by an angle, stored as its sine and cosine.
+type rot struct {
+	s, c float32
+}
+
+func newRot(angle float32) rot {
+	return rot{
+		s: float32(math.Sin(float64(angle))),
+		c: float32(math.Cos(float64(angle))),
+	}
+}
+
+// apply rotates v.
+func (q rot) apply(v mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{q.c*v[0] - q.s*v[1], q.s*v[0] + q.c*v[1]}
+}
+
+// applyT rotates v by the inverse rotation.
+func (q rot) applyT(v mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{q.c*v[0] + q.s*v[1], -q.s*v[0] + q.c*v[1]}
+}
+
+// xform is a rigid transform: a rotation followed by a translation.
+type xform struct {
+	p mgl32.Vec2
+	q rot
+}
+
+func newXform(p mgl32.Vec2, angle float32) xform {
+	return xform{p: p, q: newRot(angle)}
+}
+
+// apply transforms the point v.
+func (x xform) apply(v mgl32.Vec2) mgl32.Vec2 {
+	return x.q.apply(v).Add(x.p)
+}
+
+// applyT transforms the point v by the inverse transform.
+func (x xform) applyT(v mgl32.Vec2) mgl32.Vec2 {
+	return x.q.applyT(v.Sub(x.p))
+}
+
+// relative returns the transform from the space of b to the space of a.
+func relative(a, b xform) xform {
+	return xform{
+		p: a.q.applyT(b.p.Sub(a.p)),
+		q: rot{
+			s: a.q.c*b.q.s - a.q.s*b.q.c,
+			c: a.q.c*b.q.c + a.q.s*b.q.s,
+		},
+	}
+}
+
+// cross returns the z component of the cross product of a and b.
+func cross(a, b mgl32.Vec2) float32 {
+	return a[0]*b[1] - a[1]*b[0]
+}
+
+// crossSV returns the cross product of the z axis scaled by s with v.
+func crossSV(s float32, v mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{-s * v[1], s * v[0]}
+}
+
+// crossVS returns the cross product of v with the z axis scaled by s.
+func crossVS(v mgl32.Vec2, s float32) mgl32.Vec2 {
+	return mgl32.Vec2{s * v[1], -s * v[0]}
+}
+
+// AABB is an axis aligned box in the plane of the world.
+type AABB struct {
+	Min mgl32.Vec2
+	Max mgl32.Vec2
+}
+
+// Overlaps reports if the boxes overlap.
+func (b AABB) Overlaps(o AABB) bool {
+	return b.Min[0] <= o.Max[0] && o.Min[0] <= b.Max[0] &&
+		b.Min[1] <= o.Max[1] && o.Min[1] <= b.Max[1]
+}
+
+// Contains reports if p is inside the box.
+func (b AABB) Contains(p mgl32.Vec2) bool {
+	return p[0] >= b.Min[0] && p[0] <= b.Max[0] && p[1] >= b.Min[1] && p[1] <= b.Max[1]
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,363 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/scene"
+)
+
+func init() {
+	scene.RegisterComponent("Simulator2D", newSimulatorFromProperties)
+	scene.RegisterComponent("RigidBody2D", newRigidBodyFromProperties)
+	// BallSocketJoint is a HingeJoint, so it is registered first for the
+	// type to be saved as HingeJoint2D.
+	scene.RegisterComponent("BallSocketJoint2D", newBallSocketJointFromProperties)
+	scene.RegisterComponent("HingeJoint2D", newHingeJointFromProperties)
+	scene.RegisterComponent("FixedJoint2D", newFixedJointFromProperties)
+	scene.RegisterComponent("SpringJoint2D", newSpringJointFromProperties)
+}
+
+var bodyTypeNames = map[BodyType]string{
+	StaticBody:    "static",
+	KinematicBody: "kinematic",
+	DynamicBody:   "dynamic",
+}
+
+// decodeProperties decodes the properties of a component into v, which holds
+// the defaults of properties the file leaves out.
+func decodeProperties(properties []byte, v interface{}) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(properties, v)
+}
+
+type simulatorData struct {
+	Gravity            mgl32.Vec2 `json:"gravity"`
+	VelocityIterations int        `json:"velocity_iterations"`
+	PositionIterations int        `json:"position_iterations"`
+}
+
+// MarshalJSON encodes the properties of the simulator for scene files.
+func (s *Simulator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(simulatorData{
+		Gravity:            s.world.gravity,
+		VelocityIterations: s.world.VelocityIterations,
+		PositionIterations: s.world.PositionIterations,
+	})
+}
+
+func newSimulatorFromProperties(properties []byte) (*Simulator, error) {
+	d := simulatorData{
+		Gravity:            mgl32.Vec2{0, -9.81},
+		VelocityIterations: 8,
+		PositionIterations: 3,
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	s := NewSimulator(d.Gravity)
+	s.world.VelocityIterations = d.VelocityIterations
+	s.world.PositionIterations = d.PositionIterations
+
+	return s, nil
+}
+
+// fixtureData is a fixture of a rigid body. Its shape is a circle of radius
+// around center, a box of size rotated by angle around center, or a convex
+// polygon of vertices. Polygons are always saved as their vertices.
+type fixtureData struct {
+	Shape       string       `json:"shape"`
+	Center      mgl32.Vec2   `json:"center"`
+	Radius      float32      `json:"radius,omitempty"`
+	Size        mgl32.Vec2   `json:"size"`
+	Angle       float32      `json:"angle,omitempty"`
+	Vertices    []mgl32.Vec2 `json:"vertices,omitempty"`
+	Density     float32      `json:"density"`
+	Friction    float32      `json:"friction"`
+	Restitution float32      `json:"restitution"`
+	Category    uint16       `json:"category"`
+	Mask        uint16       `json:"mask"`
+}
+
+// UnmarshalJSON decodes a fixture, with the defaults of properties the file
+// leaves out.
+func (d *fixtureData) UnmarshalJSON(data []byte) error {
+	type plain fixtureData
+
+	p := plain{
+		Density:  1,
+		Friction: 0.2,
+		Category: 0x0001,
+		Mask:     0xFFFF,
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	*d = fixtureData(p)
+
+	return nil
+}
+
+func (d *fixtureData) shape() (Shape, error) {
+	switch d.Shape {
+	case "circle":
+		return NewCircle(d.Center, d.Radius), nil
+	case "box":
+		return NewOrientedBox(d.Size[0]/2, d.Size[1]/2, d.Center, d.Angle), nil
+	case "polygon":
+		return NewPolygon(d.Vertices)
+	}
+
+	return nil, fmt.Errorf("unknown shape: %s", d.Shape)
+}
+
+type rigidBodyData struct {
+	Type           string        `json:"type"`
+	LinearDamping  float32       `json:"linear_damping"`
+	AngularDamping float32       `json:"angular_damping"`
+	GravityScale   float32       `json:"gravity_scale"`
+	FixedRotation  bool          `json:"fixed_rotation"`
+	Fixtures       []fixtureData `json:"fixtures,omitempty"`
+}
+
+// MarshalJSON encodes the properties of the rigid body for scene files.
+func (r *RigidBody) MarshalJSON() ([]byte, error) {
+	b := r.body
+
+	d := rigidBodyData{
+		Type:           bodyTypeNames[b.bodyType],
+		LinearDamping:  b.linearDamping,
+		AngularDamping: b.angularDamping,
+		GravityScale:   b.gravityScale,
+		FixedRotation:  b.fixedRotation,
+	}
+
+	for _, f := range b.fixtures {
+		fd := fixtureData{
+			Density:     f.density,
+			Friction:    f.Friction,
+			Restitution: f.Restitution,
+			Category:    f.Category,
+			Mask:        f.Mask,
+		}
+
+		switch s := f.shape.(type) {
+		case *Circle:
+			fd.Shape = "circle"
+			fd.Center = s.Center
+			fd.Radius = s.Radius
+		case *Polygon:
+			fd.Shape = "polygon"
+			fd.Vertices = s.Vertices()
+		}
+
+		d.Fixtures = append(d.Fixtures, fd)
+	}
+
+	return json.Marshal(d)
+}
+
+func newRigidBodyFromProperties(properties []byte) (*RigidBody, error) {
+	d := rigidBodyData{
+		Type:         bodyTypeNames[DynamicBody],
+		GravityScale: 1,
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	t, ok := DynamicBody, false
+	for v, n := range bodyTypeNames {
+		if n == d.Type {
+			t, ok = v, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown value: %s", d.Type)
+	}
+
+	r := NewRigidBody(nil, t)
+	b := r.body
+	b.SetLinearDamping(d.LinearDamping)
+	b.SetAngularDamping(d.AngularDamping)
+	b.SetGravityScale(d.GravityScale)
+	b.SetFixedRotation(d.FixedRotation)
+
+	for i := range d.Fixtures {
+		fd := &d.Fixtures[i]
+
+		shape, err := fd.shape()
+		if err != nil {
+			return nil, err
+		}
+
+		f := b.AddFixture(shape, fd.Density)
+		f.Friction = fd.Friction
+		f.Restitution = fd.Restitution
+		f.Category = fd.Category
+		f.Mask = fd.Mask
+	}
+
+	return r, nil
+}
+
+// jointData holds the properties shared by the joint components. The
+// connected body is the name of its object, and is found when the joint
+// starts.
+type jointData struct {
+	ConnectedBody    string     `json:"connected_body,omitempty"`
+	Anchor           mgl32.Vec2 `json:"anchor"`
+	CollideConnected bool       `json:"collide_connected"`
+}
+
+func (j *jointComponent) data() jointData {
+	return jointData{
+		ConnectedBody:    j.connectedBodyName(),
+		Anchor:           j.anchor,
+		CollideConnected: j.collideConnected,
+	}
+}
+
+func (j *jointComponent) setData(d jointData) {
+	j.connectedName = d.ConnectedBody
+	j.anchor = d.Anchor
+	j.collideConnected = d.CollideConnected
+}
+
+type hingeJointData struct {
+	jointData
+
+	UseMotor       bool    `json:"use_motor"`
+	MotorSpeed     float32 `json:"motor_speed"`
+	MaxMotorTorque float32 `json:"max_motor_torque"`
+	UseLimits      bool    `json:"use_limits"`
+	LowerAngle     float32 `json:"lower_angle"`
+	UpperAngle     float32 `json:"upper_angle"`
+}
+
+// MarshalJSON encodes the properties of the hinge joint for scene files.
+func (j *HingeJoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hingeJointData{
+		jointData:      j.data(),
+		UseMotor:       j.useMotor,
+		MotorSpeed:     j.motorSpeed,
+		MaxMotorTorque: j.maxMotorTorque,
+		UseLimits:      j.useLimits,
+		LowerAngle:     j.lowerAngle,
+		UpperAngle:     j.upperAngle,
+	})
+}
+
+func newHingeJointFromProperties(properties []byte) (*HingeJoint, error) {
+	var d hingeJointData
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	j := NewHingeJoint(nil, d.Anchor)
+	j.setData(d.jointData)
+	j.SetUseMotor(d.UseMotor)
+	j.SetMotor(d.MotorSpeed, d.MaxMotorTorque)
+	j.SetUseLimits(d.UseLimits)
+	j.SetLimits(d.LowerAngle, d.UpperAngle)
+
+	return j, nil
+}
+
+// newBallSocketJointFromProperties creates a hinge joint without a motor or
+// limits, which scene files name BallSocketJoint2D.
+func newBallSocketJointFromProperties(properties []byte) (*BallSocketJoint, error) {
+	var d jointData
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	j := NewBallSocketJoint(nil, d.Anchor)
+	j.setData(d)
+
+	return j, nil
+}
+
+// MarshalJSON encodes the properties of the fixed joint for scene files.
+func (j *FixedJoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.data())
+}
+
+func newFixedJointFromProperties(properties []byte) (*FixedJoint, error) {
+	var d jointData
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	j := NewFixedJoint(nil, d.Anchor)
+	j.setData(d)
+
+	return j, nil
+}
+
+type springJointData struct {
+	jointData
+
+	ConnectedAnchor mgl32.Vec2 `json:"connected_anchor"`
+	Length          float32    `json:"length"`
+	Frequency       float32    `json:"frequency"`
+	DampingRatio    float32    `json:"damping_ratio"`
+}
+
+// MarshalJSON encodes the properties of the spring joint for scene files.
+func (j *SpringJoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(springJointData{
+		jointData:       j.data(),
+		ConnectedAnchor: j.connectedAnchor,
+		Length:          j.length,
+		Frequency:       j.frequency,
+		DampingRatio:    j.dampingRatio,
+	})
+}
+
+func newSpringJointFromProperties(properties []byte) (*SpringJoint, error) {
+	d := springJointData{
+		Frequency:    2,
+		DampingRatio: 0.5,
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	j := NewSpringJoint(nil, d.Anchor, d.ConnectedAnchor)
+	j.setData(d.jointData)
+	j.SetLength(d.Length)
+	j.SetFrequency(d.Frequency)
+	j.SetDampingRatio(d.DampingRatio)
+
+	return j, nil
+}
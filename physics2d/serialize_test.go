@@ -0,0 +1,60 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/scene"
+)
+
+func TestMain(m *testing.M) {
+	core.NewInstanceSystem().Setup()
+
+	os.Exit(m.Run())
+}
+
+func TestBallSocketJoint_Properties(t *testing.T) {
+	c, err := scene.NewComponent(scene.ComponentData{
+		Type:       "BallSocketJoint2D",
+		Properties: []byte(`{"anchor":[1,2]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, ok := c.(*BallSocketJoint)
+	if !ok {
+		t.Fatalf("NewComponent failed. want: %T got: %T", j, c)
+	}
+	if want := (mgl32.Vec2{1, 2}); j.Anchor() != want {
+		t.Errorf("NewComponent failed. want: %v got: %v", want, j.Anchor())
+	}
+	if j.UseMotor() || j.UseLimits() {
+		t.Errorf("NewComponent failed. want: no motor or limits got: %v %v", j.UseMotor(), j.UseLimits())
+	}
+}
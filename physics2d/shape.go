@@ -0,0 +1,280 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	fmath "github.com/haakenlabs/arc/pkg/math"
+)
+
+// MaxPolygonVertices is the largest number of vertices of a polygon.
+const MaxPolygonVertices = 8
+
+// ErrPolygon is returned for polygons which are not convex, or have too few
+// or too many vertices.
+var ErrPolygon = errors.New("physics2d: polygon must be convex with 3 to 8 vertices")
+
+// massData is the mass, center of mass and rotational inertia about the
+// origin of a shape.
+type massData struct {
+	mass    float32
+	center  mgl32.Vec2
+	inertia float32
+}
+
+// Shape is the geometry of a fixture, in the space of its body.
+type Shape interface {
+	// AABB returns the box around the shape transformed by a position and an
+	// angle.
+	AABB(position mgl32.Vec2, angle float32) AABB
+
+	// TestPoint reports if a point in the space of the body is inside the
+	// shape.
+	TestPoint(p mgl32.Vec2) bool
+
+	computeAABB(xf xform) AABB
+	computeMass(density float32) massData
+	radius() float32
+}
+
+var _ Shape = &Circle{}
+var _ Shape = &Polygon{}
+
+// Circle is a circle shape.
+type Circle struct {
+	Center mgl32.Vec2
+	Radius float32
+}
+
+// NewCircle creates a circle of radius around center.
+func NewCircle(center mgl32.Vec2, radius float32) *Circle {
+	return &Circle{
+		Center: center,
+		Radius: radius,
+	}
+}
+
+// AABB returns the box around the circle transformed by a position and an
+// angle.
+func (c *Circle) AABB(position mgl32.Vec2, angle float32) AABB {
+	return c.computeAABB(newXform(position, angle))
+}
+
+// TestPoint reports if p is inside the circle.
+func (c *Circle) TestPoint(p mgl32.Vec2) bool {
+	return p.Sub(c.Center).LenSqr() <= c.Radius*c.Radius
+}
+
+func (c *Circle) computeAABB(xf xform) AABB {
+	p := xf.apply(c.Center)
+	r := mgl32.Vec2{c.Radius, c.Radius}
+
+	return AABB{Min: p.Sub(r), Max: p.Add(r)}
+}
+
+func (c *Circle) computeMass(density float32) massData {
+	mass := density * math.Pi * c.Radius * c.Radius
+
+	return massData{
+		mass:    mass,
+		center:  c.Center,
+		inertia: mass * (0.5*c.Radius*c.Radius + c.Center.Dot(c.Center)),
+	}
+}
+
+func (c *Circle) radius() float32 {
+	return c.Radius
+}
+
+// Polygon is a convex polygon shape. Its vertices are in counter clockwise
+// order.
+type Polygon struct {
+	vertices []mgl32.Vec2
+	normals  []mgl32.Vec2
+	centroid mgl32.Vec2
+}
+
+// NewPolygon creates a polygon from the vertices of a convex polygon, in
+// either winding order.
+func NewPolygon(vertices []mgl32.Vec2) (*Polygon, error) {
+	n := len(vertices)
+	if n < 3 || n > MaxPolygonVertices {
+		return nil, ErrPolygon
+	}
+
+	p := &Polygon{
+		vertices: append([]mgl32.Vec2(nil), vertices...),
+		normals:  make([]mgl32.Vec2, n),
+	}
+
+	// Clockwise polygons are reversed, so the normals face out.
+	var area float32
+	for i := range p.vertices {
+		area += cross(p.vertices[i], p.vertices[(i+1)%n])
+	}
+	if area < 0 {
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			p.vertices[i], p.vertices[j] = p.vertices[j], p.vertices[i]
+		}
+	}
+
+	for i := range p.vertices {
+		edge := p.vertices[(i+1)%n].Sub(p.vertices[i])
+		if edge.LenSqr() <= linearSlop*linearSlop {
+			return nil, ErrPolygon
+		}
+		p.normals[i] = crossVS(edge, 1).Normalize()
+	}
+
+	// Every vertex must be behind every edge.
+	for i := range p.vertices {
+		for j := range p.vertices {
+			if j == i || j == (i+1)%n {
+				continue
+			}
+			if p.normals[i].Dot(p.vertices[j].Sub(p.vertices[i])) > 0 {
+				return nil, ErrPolygon
+			}
+		}
+	}
+
+	p.centroid = p.computeMass(1).center
+
+	return p, nil
+}
+
+// NewBox creates a box polygon with half width hx and half height hy,
+// centered on the origin.
+func NewBox(hx, hy float32) *Polygon {
+	return NewOrientedBox(hx, hy, mgl32.Vec2{}, 0)
+}
+
+// NewOrientedBox creates a box polygon with half width hx and half height
+// hy, centered on center and rotated by angle.
+func NewOrientedBox(hx, hy float32, center mgl32.Vec2, angle float32) *Polygon {
+	xf := newXform(center, angle)
+
+	p := &Polygon{
+		vertices: []mgl32.Vec2{
+			xf.apply(mgl32.Vec2{-hx, -hy}),
+			xf.apply(mgl32.Vec2{hx, -hy}),
+			xf.apply(mgl32.Vec2{hx, hy}),
+			xf.apply(mgl32.Vec2{-hx, hy}),
+		},
+		normals: []mgl32.Vec2{
+			xf.q.apply(mgl32.Vec2{0, -1}),
+			xf.q.apply(mgl32.Vec2{1, 0}),
+			xf.q.apply(mgl32.Vec2{0, 1}),
+			xf.q.apply(mgl32.Vec2{-1, 0}),
+		},
+		centroid: center,
+	}
+
+	return p
+}
+
+// Vertices returns the vertices of the polygon, in counter clockwise order.
+func (p *Polygon) Vertices() []mgl32.Vec2 {
+	return p.vertices
+}
+
+// Centroid returns the center of the area of the polygon.
+func (p *Polygon) Centroid() mgl32.Vec2 {
+	return p.centroid
+}
+
+// AABB returns the box around the polygon transformed by a position and an
+// angle.
+func (p *Polygon) AABB(position mgl32.Vec2, angle float32) AABB {
+	return p.computeAABB(newXform(position, angle))
+}
+
+// TestPoint reports if q is inside the polygon.
+func (p *Polygon) TestPoint(q mgl32.Vec2) bool {
+	for i := range p.vertices {
+		if p.normals[i].Dot(q.Sub(p.vertices[i])) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Polygon) computeAABB(xf xform) AABB {
+	b := AABB{Min: xf.apply(p.vertices[0])}
+	b.Max = b.Min
+
+	for _, v := range p.vertices[1:] {
+		v = xf.apply(v)
+		b.Min = mgl32.Vec2{fmath.Min32(b.Min[0], v[0]), fmath.Min32(b.Min[1], v[1])}
+		b.Max = mgl32.Vec2{fmath.Max32(b.Max[0], v[0]), fmath.Max32(b.Max[1], v[1])}
+	}
+
+	r := mgl32.Vec2{polygonRadius, polygonRadius}
+
+	return AABB{Min: b.Min.Sub(r), Max: b.Max.Add(r)}
+}
+
+// computeMass integrates the mass of the triangles fanning from the first
+// vertex, which keeps the products small for polygons far from the origin.
+func (p *Polygon) computeMass(density float32) massData {
+	const inv3 = 1.0 / 3.0
+
+	s := p.vertices[0]
+
+	var area, inertia float32
+	var center mgl32.Vec2
+
+	for i := 1; i < len(p.vertices)-1; i++ {
+		e1 := p.vertices[i].Sub(s)
+		e2 := p.vertices[i+1].Sub(s)
+
+		d := cross(e1, e2)
+		triangleArea := 0.5 * d
+		area += triangleArea
+		center = center.Add(e1.Add(e2).Mul(triangleArea * inv3))
+
+		intx2 := e1[0]*e1[0] + e2[0]*e1[0] + e2[0]*e2[0]
+		inty2 := e1[1]*e1[1] + e2[1]*e1[1] + e2[1]*e2[1]
+		inertia += (0.25 * inv3 * d) * (intx2 + inty2)
+	}
+
+	center = center.Mul(1 / area)
+	md := massData{
+		mass:   density * area,
+		center: center.Add(s),
+	}
+
+	// The inertia is moved from the first vertex to the origin.
+	md.inertia = density*inertia + md.mass*(md.center.Dot(md.center)-center.Dot(center))
+
+	return md
+}
+
+func (p *Polygon) radius() float32 {
+	return polygonRadius
+}
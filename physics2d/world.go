@@ -0,0 +1,383 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// aabbMargin fattens the boxes of fixtures in the broadphase, so contacts
+// are found just before the fixtures touch.
+const aabbMargin = 0.1
+
+// proxy is a fixture in the broadphase.
+type proxy struct {
+	fixture *Fixture
+	aabb    AABB
+}
+
+// World is a 2D physics simulation. It holds bodies and joints, and moves
+// them forward in time with Step.
+type World struct {
+	gravity mgl32.Vec2
+	bodies  []*Body
+	joints  []Joint
+
+	contacts     []*contact
+	contactByKey map[contactKey]*contact
+	proxies      []proxy
+	fixtureID    uint32
+
+	// VelocityIterations and PositionIterations are the number of solver
+	// iterations in each step. More iterations are more accurate, and
+	// slower.
+	VelocityIterations int
+	PositionIterations int
+}
+
+// NewWorld creates a world with a gravity in meters per second squared.
+func NewWorld(gravity mgl32.Vec2) *World {
+	return &World{
+		gravity:            gravity,
+		contactByKey:       make(map[contactKey]*contact),
+		VelocityIterations: 8,
+		PositionIterations: 3,
+	}
+}
+
+// Gravity returns the gravity of the world.
+func (w *World) Gravity() mgl32.Vec2 {
+	return w.gravity
+}
+
+// SetGravity sets the gravity of the world.
+func (w *World) SetGravity(gravity mgl32.Vec2) {
+	w.gravity = gravity
+}
+
+// NewBody creates a body in the world, with its origin at a position and an
+// angle in radians. The body has no fixtures.
+func (w *World) NewBody(t BodyType, position mgl32.Vec2, angle float32) *Body {
+	b := newBody(t)
+	b.SetTransform(position, angle)
+	w.addBody(b)
+
+	return b
+}
+
+// addBody adds a body made by newBody to the world, and gives its fixtures
+// ids.
+func (w *World) addBody(b *Body) {
+	b.world = w
+	for _, f := range b.fixtures {
+		f.id = w.nextID()
+	}
+
+	w.bodies = append(w.bodies, b)
+}
+
+// RemoveBody removes a body and its joints from the world.
+func (w *World) RemoveBody(b *Body) {
+	if b.world != w {
+		return
+	}
+
+	for len(b.joints) > 0 {
+		w.RemoveJoint(b.joints[0])
+	}
+
+	w.removeContacts(func(c *contact) bool {
+		return c.fixtureA.body == b || c.fixtureB.body == b
+	})
+
+	for i := range w.bodies {
+		if w.bodies[i] == b {
+			w.bodies = append(w.bodies[:i], w.bodies[i+1:]...)
+			break
+		}
+	}
+
+	b.world = nil
+}
+
+// AddJoint adds a joint between two bodies of the world.
+func (w *World) AddJoint(j Joint) {
+	a, b := j.BodyA(), j.BodyB()
+	if a.world != w || b.world != w {
+		return
+	}
+
+	w.joints = append(w.joints, j)
+	a.joints = append(a.joints, j)
+	b.joints = append(b.joints, j)
+}
+
+// RemoveJoint removes a joint from the world.
+func (w *World) RemoveJoint(j Joint) {
+	w.joints = removeJoint(w.joints, j)
+
+	a, b := j.BodyA(), j.BodyB()
+	a.joints = removeJoint(a.joints, j)
+	b.joints = removeJoint(b.joints, j)
+}
+
+// Bodies returns the bodies of the world.
+func (w *World) Bodies() []*Body {
+	return w.bodies
+}
+
+// Joints returns the joints of the world.
+func (w *World) Joints() []Joint {
+	return w.joints
+}
+
+// QueryAABB calls fn for every fixture whose box overlaps a world box, until
+// fn returns false.
+func (w *World) QueryAABB(box AABB, fn func(*Fixture) bool) {
+	for _, b := range w.bodies {
+		for _, f := range b.fixtures {
+			if f.AABB().Overlaps(box) && !fn(f) {
+				return
+			}
+		}
+	}
+}
+
+// QueryPoint returns the fixtures which contain a world point.
+func (w *World) QueryPoint(p mgl32.Vec2) []*Fixture {
+	var fixtures []*Fixture
+
+	w.QueryAABB(AABB{Min: p, Max: p}, func(f *Fixture) bool {
+		if f.TestPoint(p) {
+			fixtures = append(fixtures, f)
+		}
+		return true
+	})
+
+	return fixtures
+}
+
+// Step moves the world forward by dt seconds. The world is most stable when
+// stepped by a fixed dt.
+func (w *World) Step(dt float32) {
+	if dt <= 0 {
+		return
+	}
+	step := timeStep{dt: dt, invDt: 1 / dt}
+
+	w.findContacts()
+
+	var touching []*contact
+	for _, c := range w.contacts {
+		c.update()
+		if c.touching {
+			touching = append(touching, c)
+		}
+	}
+
+	// Velocities are integrated first, then corrected by the constraints.
+	for _, b := range w.bodies {
+		if b.bodyType != DynamicBody {
+			continue
+		}
+
+		b.linearVelocity = b.linearVelocity.Add(w.gravity.Mul(b.gravityScale).Add(b.force.Mul(b.invMass)).Mul(dt))
+		b.angularVelocity += dt * b.invInertia * b.torque
+
+		b.linearVelocity = b.linearVelocity.Mul(1 / (1 + dt*b.linearDamping))
+		b.angularVelocity *= 1 / (1 + dt*b.angularDamping)
+	}
+
+	for _, c := range touching {
+		c.initVelocity()
+	}
+	for _, j := range w.joints {
+		j.initVelocity(step)
+	}
+
+	for i := 0; i < w.VelocityIterations; i++ {
+		for _, j := range w.joints {
+			j.solveVelocity(step)
+		}
+		for _, c := range touching {
+			c.solveVelocity()
+		}
+	}
+
+	for _, c := range touching {
+		c.storeImpulses()
+	}
+
+	for _, b := range w.bodies {
+		if b.bodyType == StaticBody {
+			continue
+		}
+
+		// Very large velocities are clamped, so bodies do not tunnel or
+		// spin out of control.
+		if t := b.linearVelocity.Mul(dt); t.Dot(t) > maxTranslation*maxTranslation {
+			b.linearVelocity = b.linearVelocity.Mul(maxTranslation / t.Len())
+		}
+		if r := dt * b.angularVelocity; r*r > maxRotation*maxRotation {
+			b.angularVelocity *= maxRotation / abs(r)
+		}
+
+		b.center = b.center.Add(b.linearVelocity.Mul(dt))
+		b.angle += dt * b.angularVelocity
+		b.synchronize()
+	}
+
+	for i := 0; i < w.PositionIterations; i++ {
+		solved := true
+		for _, c := range touching {
+			solved = c.solvePosition() && solved
+		}
+		for _, j := range w.joints {
+			solved = j.solvePosition() && solved
+		}
+
+		if solved {
+			break
+		}
+	}
+
+	for _, b := range w.bodies {
+		b.synchronize()
+		b.force = mgl32.Vec2{}
+		b.torque = 0
+	}
+}
+
+// findContacts updates the contacts to the pairs of fixtures whose boxes
+// overlap. The boxes are sorted along the x axis, so only neighbouring boxes
+// are tested against each other.
+func (w *World) findContacts() {
+	w.proxies = w.proxies[:0]
+	for _, b := range w.bodies {
+		for _, f := range b.fixtures {
+			box := f.AABB()
+			box.Min = box.Min.Sub(mgl32.Vec2{aabbMargin, aabbMargin})
+			box.Max = box.Max.Add(mgl32.Vec2{aabbMargin, aabbMargin})
+
+			w.proxies = append(w.proxies, proxy{fixture: f, aabb: box})
+		}
+	}
+
+	sort.Slice(w.proxies, func(i, j int) bool {
+		return w.proxies[i].aabb.Min[0] < w.proxies[j].aabb.Min[0]
+	})
+
+	found := make(map[contactKey]bool, len(w.contacts))
+
+	for i := range w.proxies {
+		pi := &w.proxies[i]
+
+		for j := i + 1; j < len(w.proxies); j++ {
+			pj := &w.proxies[j]
+			if pj.aabb.Min[0] > pi.aabb.Max[0] {
+				break
+			}
+			if !pi.aabb.Overlaps(pj.aabb) {
+				continue
+			}
+
+			a, b := pi.fixture, pj.fixture
+			if !shouldContact(a, b) {
+				continue
+			}
+
+			key := newContactKey(a, b)
+			found[key] = true
+
+			if _, ok := w.contactByKey[key]; ok {
+				continue
+			}
+
+			// Polygons always come first, as that is the order the
+			// manifolds are computed in.
+			if _, ok := a.shape.(*Circle); ok {
+				a, b = b, a
+			}
+
+			c := newContact(a, b)
+			w.contactByKey[key] = c
+			w.contacts = append(w.contacts, c)
+		}
+	}
+
+	w.removeContacts(func(c *contact) bool {
+		return !found[newContactKey(c.fixtureA, c.fixtureB)]
+	})
+}
+
+// removeContacts removes the contacts that match fn.
+func (w *World) removeContacts(fn func(*contact) bool) {
+	contacts := w.contacts[:0]
+	for _, c := range w.contacts {
+		if fn(c) {
+			delete(w.contactByKey, newContactKey(c.fixtureA, c.fixtureB))
+			continue
+		}
+		contacts = append(contacts, c)
+	}
+
+	clear(w.contacts[len(contacts):])
+	w.contacts = contacts
+}
+
+// nextID returns a new fixture id.
+func (w *World) nextID() uint32 {
+	w.fixtureID++
+	return w.fixtureID
+}
+
+// shouldContact reports if contacts are made between two fixtures.
+func shouldContact(a, b *Fixture) bool {
+	bA, bB := a.body, b.body
+
+	if bA == bB || (bA.bodyType != DynamicBody && bB.bodyType != DynamicBody) {
+		return false
+	}
+
+	return a.shouldCollide(b) && !bA.connected(bB)
+}
+
+func newContactKey(a, b *Fixture) contactKey {
+	if a.id > b.id {
+		a, b = b, a
+	}
+
+	return contactKey{a: a.id, b: b.id}
+}
+
+func removeJoint(joints []Joint, j Joint) []Joint {
+	for i := range joints {
+		if joints[i] == j {
+			return append(joints[:i], joints[i+1:]...)
+		}
+	}
+
+	return joints
+}
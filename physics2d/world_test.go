@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package physics2d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const testTimeStep = 1.0 / 60
+
+func setupTestStack(n int) (*World, []*Body) {
+	w := NewWorld(mgl32.Vec2{0, -10})
+
+	ground := w.NewBody(StaticBody, mgl32.Vec2{}, 0)
+	ground.AddFixture(NewBox(20, 0.5), 0)
+
+	boxes := make([]*Body, n)
+	for i := range boxes {
+		boxes[i] = w.NewBody(DynamicBody, mgl32.Vec2{0, 1 + float32(i)*1.05}, 0)
+		boxes[i].AddFixture(NewBox(0.5, 0.5), 1)
+	}
+
+	return w, boxes
+}
+
+func TestWorld_StepStack(t *testing.T) {
+	w, boxes := setupTestStack(5)
+
+	for i := 0; i < 300; i++ {
+		w.Step(testTimeStep)
+	}
+
+	for i, b := range boxes {
+		want := mgl32.Vec2{0, 1 + float32(i)}
+		if !b.Position().ApproxEqualThreshold(want, 0.1) {
+			t.Errorf("StepStack box %d failed. want: %v got: %v", i, want, b.Position())
+		}
+		if a := b.Angle(); math.Abs(float64(a)) > 0.01 {
+			t.Errorf("StepStack box %d failed. want: angle 0 got: %v", i, a)
+		}
+		if v := b.LinearVelocity().Len(); v > 0.05 {
+			t.Errorf("StepStack box %d failed. want: at rest got: speed %v", i, v)
+		}
+	}
+
+	for i := 1; i < len(boxes); i++ {
+		if boxes[i].Position()[1] <= boxes[i-1].Position()[1] {
+			t.Errorf("StepStack box %d failed. want: above box %d", i, i-1)
+		}
+	}
+}
+
+func TestWorld_StepDeterministic(t *testing.T) {
+	w1, boxes1 := setupTestStack(3)
+	w2, boxes2 := setupTestStack(3)
+
+	for i := 0; i < 120; i++ {
+		w1.Step(testTimeStep)
+		w2.Step(testTimeStep)
+	}
+
+	for i := range boxes1 {
+		if boxes1[i].Position() != boxes2[i].Position() || boxes1[i].Angle() != boxes2[i].Angle() {
+			t.Errorf(
+				"StepDeterministic box %d failed. want: %v %v got: %v %v",
+				i, boxes1[i].Position(), boxes1[i].Angle(), boxes2[i].Position(), boxes2[i].Angle())
+		}
+	}
+}
+
+func TestRevoluteJoint_Pendulum(t *testing.T) {
+	w := NewWorld(mgl32.Vec2{0, -10})
+
+	pivot := mgl32.Vec2{0, 10}
+	anchor := w.NewBody(StaticBody, pivot, 0)
+	bob := w.NewBody(DynamicBody, mgl32.Vec2{2, 10}, 0)
+	bob.AddFixture(NewCircle(mgl32.Vec2{}, 0.2), 1)
+	w.AddJoint(NewRevoluteJoint(anchor, bob, pivot))
+
+	minY, maxY := bob.Position()[1], bob.Position()[1]
+	for i := 0; i < 240; i++ {
+		w.Step(testTimeStep)
+
+		if l := bob.Position().Sub(pivot).Len(); math.Abs(float64(l-2)) > 0.01 {
+			t.Fatalf("Pendulum step %d failed. want: length 2 got: %v", i, l)
+		}
+		minY = float32(math.Min(float64(minY), float64(bob.Position()[1])))
+		maxY = float32(math.Max(float64(maxY), float64(bob.Position()[1])))
+	}
+
+	// The bob swings through the bottom of the arc, and never above the
+	// height it was released from.
+	if minY > pivot[1]-1.99 {
+		t.Errorf("Pendulum failed. want: lowest y %v got: %v", pivot[1]-2, minY)
+	}
+	if maxY > pivot[1]+0.01 {
+		t.Errorf("Pendulum failed. want: highest y %v got: %v", pivot[1], maxY)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Package arcpak reads and writes arcpak asset bundles. A bundle is a
+// header, a zstd frame per file, a zstd compressed index of the files, and a
+// fixed size footer locating the index:
+//
+//	header  magic "ARCPAK\r\n", version uint32, flags uint32
+//	blobs   one zstd frame per file
+//	index   zstd frame holding the file count, then for each file its name,
+//	        offset, compressed size, size and CRC-32 (IEEE) of its contents
+//	footer  index offset uint64, index size uint64, magic "ARCPAK\r\n"
+//
+// Integers in the header and footer are little endian, integers in the
+// index are unsigned varints, except for the CRC-32 which is a little endian
+// uint32. Names are slash separated paths.
+package arcpak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Version is the version of the format written by Writer.
+const Version = 1
+
+const (
+	headerSize = 16
+	footerSize = 24
+
+	// maxFileSize limits the size of a file, and the memory used to
+	// decompress one, so corrupt indices cannot cause huge allocations.
+	maxFileSize = 1 << 30
+
+	// maxPrealloc limits the buffer allocated for a file before it is
+	// decompressed. Larger files grow the buffer as they are decoded.
+	maxPrealloc = 1 << 24
+)
+
+var magic = [8]byte{'A', 'R', 'C', 'P', 'A', 'K', '\r', '\n'}
+
+// FormatError reports that the input is not a valid bundle.
+type FormatError string
+
+func (e FormatError) Error() string {
+	return "arcpak: invalid format: " + string(e)
+}
+
+// ErrNotFound reports that a file is not in the bundle.
+type ErrNotFound string
+
+func (e ErrNotFound) Error() string {
+	return "arcpak: file not found: " + string(e)
+}
+
+// ErrDuplicate reports that a file was added to a bundle twice.
+type ErrDuplicate string
+
+func (e ErrDuplicate) Error() string {
+	return "arcpak: duplicate file: " + string(e)
+}
+
+// entry is a file in the index.
+type entry struct {
+	name    string
+	offset  uint64
+	size    uint64
+	rawSize uint64
+	crc     uint32
+}
+
+func appendHeader(b []byte) []byte {
+	b = append(b, magic[:]...)
+	b = appendUint32(b, Version)
+	b = appendUint32(b, 0)
+
+	return b
+}
+
+func checkHeader(b []byte) error {
+	if len(b) < headerSize || !bytes.Equal(b[:8], magic[:]) {
+		return FormatError("bad magic")
+	}
+	if v := binary.LittleEndian.Uint32(b[8:]); v != Version {
+		return FormatError(fmt.Sprintf("unsupported version %d", v))
+	}
+
+	return nil
+}
+
+func appendIndex(b []byte, entries []entry) []byte {
+	b = appendUvarint(b, uint64(len(entries)))
+	for _, e := range entries {
+		b = appendUvarint(b, uint64(len(e.name)))
+		b = append(b, e.name...)
+		b = appendUvarint(b, e.offset)
+		b = appendUvarint(b, e.size)
+		b = appendUvarint(b, e.rawSize)
+		b = appendUint32(b, e.crc)
+	}
+
+	return b
+}
+
+func parseIndex(b []byte) ([]entry, error) {
+	next := func() (uint64, error) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, FormatError("truncated index")
+		}
+		b = b[n:]
+
+		return v, nil
+	}
+
+	count, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(b)) {
+		return nil, FormatError("bad file count")
+	}
+
+	entries := make([]entry, count)
+	for i := range entries {
+		n, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(b)) {
+			return nil, FormatError("truncated name")
+		}
+
+		e := entry{name: string(b[:n])}
+		b = b[n:]
+
+		if e.offset, err = next(); err != nil {
+			return nil, err
+		}
+		if e.size, err = next(); err != nil {
+			return nil, err
+		}
+		if e.rawSize, err = next(); err != nil {
+			return nil, err
+		}
+		if e.rawSize > maxFileSize {
+			return nil, FormatError("file too large: " + e.name)
+		}
+		if len(b) < 4 {
+			return nil, FormatError("truncated checksum")
+		}
+		e.crc = binary.LittleEndian.Uint32(b)
+		b = b[4:]
+
+		entries[i] = e
+	}
+
+	return entries, nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+
+	return append(b, buf[:]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package arcpak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Reader reads the files of a bundle. It is safe for concurrent use.
+type Reader struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	dec     *zstd.Decoder
+	entries map[string]entry
+}
+
+// Open opens the bundle at path.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r, err := NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+
+	return r, nil
+}
+
+// NewReader reads the index of a bundle of the given size.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < headerSize+footerSize {
+		return nil, FormatError("file too small")
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if err := checkHeader(header); err != nil {
+		return nil, err
+	}
+
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[16:], magic[:]) {
+		return nil, FormatError("bad footer")
+	}
+
+	offset := binary.LittleEndian.Uint64(footer)
+	length := binary.LittleEndian.Uint64(footer[8:])
+	end := uint64(size - footerSize)
+	if offset < headerSize || offset > end || length > end-offset {
+		return nil, FormatError("bad index location")
+	}
+
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxFileSize))
+	if err != nil {
+		return nil, err
+	}
+
+	br := &Reader{
+		r:       r,
+		dec:     dec,
+		entries: make(map[string]entry),
+	}
+
+	index, err := br.read(offset, length, 0)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+
+	entries, err := parseIndex(index)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.offset < headerSize || e.offset > offset || e.size > offset-e.offset {
+			dec.Close()
+			return nil, FormatError("bad file location: " + e.name)
+		}
+		br.entries[e.name] = e
+	}
+
+	return br, nil
+}
+
+// Files returns the names of the files in the bundle, sorted.
+func (r *Reader) Files() []string {
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Contains reports if the bundle holds a file.
+func (r *Reader) Contains(name string) bool {
+	_, ok := r.entries[name]
+	return ok
+}
+
+// ReadFile returns the contents of a file.
+func (r *Reader) ReadFile(name string) ([]byte, error) {
+	e, ok := r.entries[name]
+	if !ok {
+		return nil, ErrNotFound(name)
+	}
+
+	data, err := r.read(e.offset, e.size, e.rawSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(data)) != e.rawSize || crc32.ChecksumIEEE(data) != e.crc {
+		return nil, FormatError("checksum mismatch: " + name)
+	}
+
+	return data, nil
+}
+
+// Close closes the bundle.
+func (r *Reader) Close() error {
+	r.dec.Close()
+
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+
+	return nil
+}
+
+// read decompresses a zstd frame of the given offset and size. The location
+// must have been checked against the size of the bundle.
+func (r *Reader) read(offset, size, rawSize uint64) ([]byte, error) {
+	blob := make([]byte, size)
+	if _, err := r.r.ReadAt(blob, int64(offset)); err != nil {
+		return nil, err
+	}
+
+	if rawSize > maxPrealloc {
+		rawSize = maxPrealloc
+	}
+
+	return r.dec.DecodeAll(blob, make([]byte, 0, rawSize))
+}
@@ -0,0 +1,169 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package arcpak
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var testFiles = map[string][]byte{
+	"a.txt":          []byte("hello"),
+	"dir/b.bin":      bytes.Repeat([]byte{1, 2, 3, 4}, 4096),
+	"dir/sub/empty":  {},
+	"shaders/x.glsl": []byte("void main() {}"),
+}
+
+func setupTestBundle(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+
+	w, err := NewWriter(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range testFiles {
+		if err := w.Add(name, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Add("a.txt", nil); err != ErrDuplicate("a.txt") {
+		t.Errorf("want: %v got: %v", ErrDuplicate("a.txt"), err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// buildTestBundle builds a bundle with an empty blob section, the given
+// index and the footer.
+func buildTestBundle(t *testing.T, entries []entry, indexOffset, indexSize uint64) []byte {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	b := appendHeader(nil)
+	index := enc.EncodeAll(appendIndex(nil, entries), nil)
+	if indexOffset == 0 {
+		indexOffset, indexSize = uint64(len(b)), uint64(len(index))
+	}
+	b = append(b, index...)
+	b = appendUint64(b, indexOffset)
+	b = appendUint64(b, indexSize)
+
+	return append(b, magic[:]...)
+}
+
+func TestReader_RoundTrip(t *testing.T) {
+	data := setupTestBundle(t)
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	want := []string{"a.txt", "dir/b.bin", "dir/sub/empty", "shaders/x.glsl"}
+	got := r.Files()
+	if len(got) != len(want) {
+		t.Fatalf("want: %v got: %v", want, got)
+	}
+
+	for i, name := range want {
+		if got[i] != name {
+			t.Errorf("Files case %d failed. want: %s got: %s", i, name, got[i])
+		}
+		if !r.Contains(name) {
+			t.Errorf("Contains case %d failed. want: true got: false", i)
+		}
+
+		b, err := r.ReadFile(name)
+		if err != nil {
+			t.Errorf("ReadFile case %d failed. error: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(testFiles[name], b) {
+			t.Errorf("ReadFile case %d failed. want: %d bytes got: %d", i, len(testFiles[name]), len(b))
+		}
+	}
+
+	if _, err := r.ReadFile("missing"); err != ErrNotFound("missing") {
+		t.Errorf("want: %v got: %v", ErrNotFound("missing"), err)
+	}
+}
+
+func TestReader_Checksum(t *testing.T) {
+	data := setupTestBundle(t)
+
+	// Corrupt the checksum recorded for a file.
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := r.entries["a.txt"]
+	e.crc++
+	r.entries["a.txt"] = e
+
+	if _, err := r.ReadFile("a.txt"); err != FormatError("checksum mismatch: a.txt") {
+		t.Errorf("want: checksum mismatch got: %v", err)
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	valid := setupTestBundle(t)
+
+	tests := []struct {
+		in   []byte
+		want error
+	}{
+		{in: valid[:headerSize], want: FormatError("file too small")},
+		{in: buildTestBundle(t, nil, headerSize, math.MaxUint64-8), want: FormatError("bad index location")},
+		{in: buildTestBundle(t, nil, math.MaxUint64-8, 16), want: FormatError("bad index location")},
+		{in: buildTestBundle(t, nil, 1, 16), want: FormatError("bad index location")},
+		{
+			in:   buildTestBundle(t, []entry{{name: "x", offset: headerSize, size: math.MaxUint64 - 8}}, 0, 0),
+			want: FormatError("bad file location: x"),
+		},
+		{
+			in:   buildTestBundle(t, []entry{{name: "x", offset: math.MaxUint64 - 8, size: 16}}, 0, 0),
+			want: FormatError("bad file location: x"),
+		},
+		{
+			in:   buildTestBundle(t, []entry{{name: "x", offset: headerSize, rawSize: maxFileSize + 1}}, 0, 0),
+			want: FormatError("file too large: x"),
+		},
+	}
+
+	for i, v := range tests {
+		_, err := NewReader(bytes.NewReader(v.in), int64(len(v.in)))
+		if err != v.want {
+			t.Errorf("NewReader case %d failed. want: %v got: %v", i, v.want, err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package arcpak
+
+import (
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Writer writes a bundle. Files are compressed as they are added and the
+// index is written by Close.
+type Writer struct {
+	w       io.Writer
+	enc     *zstd.Encoder
+	entries []entry
+	names   map[string]struct{}
+	offset  uint64
+	closed  bool
+}
+
+// NewWriter creates a writer which writes a bundle to w.
+func NewWriter(w io.Writer) (*Writer, error) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return nil, err
+	}
+
+	bw := &Writer{
+		w:     w,
+		enc:   enc,
+		names: make(map[string]struct{}),
+	}
+
+	if err := bw.write(appendHeader(nil)); err != nil {
+		return nil, err
+	}
+
+	return bw, nil
+}
+
+// Add adds a file with the given slash separated name.
+func (w *Writer) Add(name string, data []byte) error {
+	if _, dup := w.names[name]; dup {
+		return ErrDuplicate(name)
+	}
+
+	blob := w.enc.EncodeAll(data, nil)
+
+	w.entries = append(w.entries, entry{
+		name:    name,
+		offset:  w.offset,
+		size:    uint64(len(blob)),
+		rawSize: uint64(len(data)),
+		crc:     crc32.ChecksumIEEE(data),
+	})
+	w.names[name] = struct{}{}
+
+	return w.write(blob)
+}
+
+// Close writes the index and footer. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	sort.Slice(w.entries, func(i, j int) bool {
+		return w.entries[i].name < w.entries[j].name
+	})
+
+	offset := w.offset
+	index := w.enc.EncodeAll(appendIndex(nil, w.entries), nil)
+	if err := w.write(index); err != nil {
+		return err
+	}
+
+	footer := appendUint64(nil, offset)
+	footer = appendUint64(footer, uint64(len(index)))
+	footer = append(footer, magic[:]...)
+
+	return w.write(footer)
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += uint64(n)
+
+	return err
+}
+
+// PackDir writes a bundle holding every file below dir to w. Names are
+// relative to dir.
+func PackDir(w io.Writer, dir string) error {
+	bw, err := NewWriter(w)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return bw.Add(filepath.ToSlash(rel), data)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Close()
+}
+
+// PackFile writes a bundle holding every file below dir to the file at path.
+func PackFile(path, dir string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := PackDir(f, dir); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package bc decodes block compressed textures stored in DDS and KTX2
+// containers. Mip levels are returned as compressed blocks, ready to be
+// uploaded to the GPU.
+package bc
+
+import (
+	"fmt"
+)
+
+// Format is a block compression format.
+type Format uint8
+
+const (
+	// FormatBC1 stores RGB with 1-bit alpha in 8 byte blocks (DXT1).
+	FormatBC1 Format = iota
+	// FormatBC3 stores RGBA with interpolated alpha in 16 byte blocks (DXT5).
+	FormatBC3
+	// FormatBC5 stores two channels in 16 byte blocks, used for normal maps.
+	FormatBC5
+	// FormatBC7 stores high quality RGBA in 16 byte blocks.
+	FormatBC7
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatBC1:
+		return "BC1"
+	case FormatBC3:
+		return "BC3"
+	case FormatBC5:
+		return "BC5"
+	case FormatBC7:
+		return "BC7"
+	}
+
+	return fmt.Sprintf("Format(%d)", uint8(f))
+}
+
+// BlockSize returns the size of a 4x4 block in bytes.
+func (f Format) BlockSize() int {
+	if f == FormatBC1 {
+		return 8
+	}
+
+	return 16
+}
+
+// LevelSize returns the size in bytes of a mip level with the given
+// dimensions.
+func (f Format) LevelSize(width, height int) int {
+	bw := (width + 3) / 4
+	bh := (height + 3) / 4
+
+	if bw < 1 {
+		bw = 1
+	}
+	if bh < 1 {
+		bh = 1
+	}
+
+	return bw * bh * f.BlockSize()
+}
+
+// Image is a block compressed 2D image. Levels holds the compressed data of
+// each mip level, starting with the full size image.
+type Image struct {
+	Format Format
+	SRGB   bool
+	Width  int
+	Height int
+	Levels [][]byte
+}
+
+// FormatError reports that the input is not a valid container.
+type FormatError string
+
+func (e FormatError) Error() string {
+	return "bc: invalid format: " + string(e)
+}
+
+// UnsupportedError reports that the input uses a valid but unimplemented
+// feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string {
+	return "bc: unsupported feature: " + string(e)
+}
+
+// levelDim returns the size of a dimension at a mip level.
+func levelDim(size, level int) int {
+	size >>= uint(level)
+	if size < 1 {
+		return 1
+	}
+
+	return size
+}
+
+// maxLevels returns the length of a full mip chain for the given size.
+func maxLevels(width, height int) int {
+	n := 1
+	for width > 1 || height > 1 {
+		width >>= 1
+		height >>= 1
+		n++
+	}
+
+	return n
+}
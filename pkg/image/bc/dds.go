@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package bc
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+const (
+	ddsMagic      = "DDS "
+	ddsHeaderSize = 124
+	ddsDX10Size   = 20
+
+	ddsFlagMipMapCount = 0x20000
+	ddsPixelFourCC     = 0x4
+	ddsCaps2Cubemap    = 0x200
+	ddsCaps2Volume     = 0x200000
+)
+
+// DXGI formats of the DX10 header extension.
+const (
+	dxgiBC1Unorm     = 71
+	dxgiBC1UnormSRGB = 72
+	dxgiBC3Unorm     = 77
+	dxgiBC3UnormSRGB = 78
+	dxgiBC5Unorm     = 83
+	dxgiBC7Unorm     = 98
+	dxgiBC7UnormSRGB = 99
+)
+
+// DecodeDDS decodes a DirectDraw Surface. Only 2D textures compressed with
+// BC1, BC3, BC5 or BC7 are supported.
+func DecodeDDS(r io.Reader) (*Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4+ddsHeaderSize || string(data[:4]) != ddsMagic {
+		return nil, FormatError("not a DDS file")
+	}
+
+	h := data[4 : 4+ddsHeaderSize]
+	if binary.LittleEndian.Uint32(h[0:]) != ddsHeaderSize {
+		return nil, FormatError("bad DDS header size")
+	}
+
+	flags := binary.LittleEndian.Uint32(h[4:])
+	height := int(binary.LittleEndian.Uint32(h[8:]))
+	width := int(binary.LittleEndian.Uint32(h[12:]))
+	mips := int(binary.LittleEndian.Uint32(h[24:]))
+	pfFlags := binary.LittleEndian.Uint32(h[76:])
+	fourCC := string(h[80:84])
+	caps2 := binary.LittleEndian.Uint32(h[108:])
+
+	if width == 0 || height == 0 {
+		return nil, FormatError("zero sized image")
+	}
+	if caps2&(ddsCaps2Cubemap|ddsCaps2Volume) != 0 {
+		return nil, UnsupportedError("DDS cube maps and volume textures")
+	}
+	if pfFlags&ddsPixelFourCC == 0 {
+		return nil, UnsupportedError("uncompressed DDS pixel format")
+	}
+
+	img := &Image{
+		Width:  width,
+		Height: height,
+	}
+	offset := 4 + ddsHeaderSize
+
+	switch fourCC {
+	case "DXT1":
+		img.Format = FormatBC1
+	case "DXT5":
+		img.Format = FormatBC3
+	case "ATI2", "BC5U":
+		img.Format = FormatBC5
+	case "DX10":
+		if len(data) < offset+ddsDX10Size {
+			return nil, FormatError("truncated DX10 header")
+		}
+		dx10 := data[offset : offset+ddsDX10Size]
+		offset += ddsDX10Size
+
+		if arraySize := binary.LittleEndian.Uint32(dx10[12:]); arraySize > 1 {
+			return nil, UnsupportedError("DDS texture arrays")
+		}
+
+		switch dxgi := binary.LittleEndian.Uint32(dx10[0:]); dxgi {
+		case dxgiBC1Unorm, dxgiBC1UnormSRGB:
+			img.Format = FormatBC1
+			img.SRGB = dxgi == dxgiBC1UnormSRGB
+		case dxgiBC3Unorm, dxgiBC3UnormSRGB:
+			img.Format = FormatBC3
+			img.SRGB = dxgi == dxgiBC3UnormSRGB
+		case dxgiBC5Unorm:
+			img.Format = FormatBC5
+		case dxgiBC7Unorm, dxgiBC7UnormSRGB:
+			img.Format = FormatBC7
+			img.SRGB = dxgi == dxgiBC7UnormSRGB
+		default:
+			return nil, UnsupportedError("DXGI format " + strconv.Itoa(int(dxgi)))
+		}
+	default:
+		return nil, UnsupportedError("DDS FourCC " + fourCC)
+	}
+
+	if flags&ddsFlagMipMapCount == 0 || mips == 0 {
+		mips = 1
+	}
+	if max := maxLevels(width, height); mips > max {
+		return nil, FormatError("too many mip levels")
+	}
+
+	img.Levels = make([][]byte, mips)
+	for i := range img.Levels {
+		n := img.Format.LevelSize(levelDim(width, i), levelDim(height, i))
+		if len(data) < offset+n {
+			return nil, FormatError("truncated mip level " + strconv.Itoa(i))
+		}
+		img.Levels[i] = data[offset : offset+n]
+		offset += n
+	}
+
+	return img, nil
+}
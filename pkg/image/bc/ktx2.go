@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package bc
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+const (
+	ktx2Identifier = "\xabKTX 20\xbb\r\n\x1a\n"
+	ktx2HeaderSize = 80
+	ktx2LevelSize  = 24
+)
+
+// Vulkan formats used by KTX2 for block compressed data.
+const (
+	vkBC1RGBUnorm  = 131
+	vkBC1RGBSRGB   = 132
+	vkBC1RGBAUnorm = 133
+	vkBC1RGBASRGB  = 134
+	vkBC3Unorm     = 137
+	vkBC3SRGB      = 138
+	vkBC5Unorm     = 141
+	vkBC7Unorm     = 145
+	vkBC7SRGB      = 146
+)
+
+// DecodeKTX2 decodes a KTX2 texture. Only 2D textures compressed with BC1,
+// BC3, BC5 or BC7 and without supercompression are supported.
+func DecodeKTX2(r io.Reader) (*Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < ktx2HeaderSize || string(data[:12]) != ktx2Identifier {
+		return nil, FormatError("not a KTX2 file")
+	}
+
+	vkFormat := binary.LittleEndian.Uint32(data[12:])
+	width := int(binary.LittleEndian.Uint32(data[20:]))
+	height := int(binary.LittleEndian.Uint32(data[24:]))
+	depth := binary.LittleEndian.Uint32(data[28:])
+	layers := binary.LittleEndian.Uint32(data[32:])
+	faces := binary.LittleEndian.Uint32(data[36:])
+	mips := int(binary.LittleEndian.Uint32(data[40:]))
+	scheme := binary.LittleEndian.Uint32(data[44:])
+
+	if width == 0 || height == 0 {
+		return nil, FormatError("zero sized image")
+	}
+	if depth > 0 || layers > 0 || faces != 1 {
+		return nil, UnsupportedError("KTX2 cube maps, arrays and volume textures")
+	}
+	if scheme != 0 {
+		return nil, UnsupportedError("KTX2 supercompression scheme " + strconv.Itoa(int(scheme)))
+	}
+
+	img := &Image{
+		Width:  width,
+		Height: height,
+	}
+
+	switch vkFormat {
+	case vkBC1RGBUnorm, vkBC1RGBSRGB, vkBC1RGBAUnorm, vkBC1RGBASRGB:
+		img.Format = FormatBC1
+		img.SRGB = vkFormat == vkBC1RGBSRGB || vkFormat == vkBC1RGBASRGB
+	case vkBC3Unorm, vkBC3SRGB:
+		img.Format = FormatBC3
+		img.SRGB = vkFormat == vkBC3SRGB
+	case vkBC5Unorm:
+		img.Format = FormatBC5
+	case vkBC7Unorm, vkBC7SRGB:
+		img.Format = FormatBC7
+		img.SRGB = vkFormat == vkBC7SRGB
+	default:
+		return nil, UnsupportedError("Vulkan format " + strconv.Itoa(int(vkFormat)))
+	}
+
+	// A level count of zero asks the loader to generate mipmaps.
+	if mips == 0 {
+		mips = 1
+	}
+	if max := maxLevels(width, height); mips > max {
+		return nil, FormatError("too many mip levels")
+	}
+	if len(data) < ktx2HeaderSize+mips*ktx2LevelSize {
+		return nil, FormatError("truncated level index")
+	}
+
+	img.Levels = make([][]byte, mips)
+	for i := range img.Levels {
+		entry := data[ktx2HeaderSize+i*ktx2LevelSize:]
+		offset := binary.LittleEndian.Uint64(entry[0:])
+		length := binary.LittleEndian.Uint64(entry[8:])
+
+		n := img.Format.LevelSize(levelDim(width, i), levelDim(height, i))
+		if length != uint64(n) {
+			return nil, FormatError("bad size of mip level " + strconv.Itoa(i))
+		}
+		if offset > uint64(len(data)) || uint64(len(data))-offset < length {
+			return nil, FormatError("truncated mip level " + strconv.Itoa(i))
+		}
+		img.Levels[i] = data[offset : offset+length]
+	}
+
+	return img, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+)
+
+// uncompress returns the raw pixel data of a block. Blocks which would not
+// shrink are stored uncompressed regardless of the compression method.
+func uncompress(compression uint8, packed []byte, size int) ([]byte, error) {
+	if len(packed) == size || compression == compressionNone {
+		if len(packed) != size {
+			return nil, FormatError("block size mismatch")
+		}
+
+		return packed, nil
+	}
+
+	raw := make([]byte, size)
+
+	switch compression {
+	case compressionRLE:
+		if err := unpackRLE(packed, raw); err != nil {
+			return nil, err
+		}
+	case compressionZIP, compressionZIPS:
+		zr, err := zlib.NewReader(bytes.NewReader(packed))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		if _, err := io.ReadFull(zr, raw); err != nil {
+			return nil, FormatError("zip block: " + err.Error())
+		}
+	}
+
+	return interleave(predict(raw)), nil
+}
+
+// unpackRLE expands run length encoded data. A negative count is followed by
+// that many literal bytes, other counts by a byte repeated count+1 times.
+func unpackRLE(packed, raw []byte) error {
+	out := 0
+
+	for i := 0; i < len(packed); {
+		count := int(int8(packed[i]))
+		i++
+
+		if count < 0 {
+			count = -count
+			if i+count > len(packed) || out+count > len(raw) {
+				return FormatError("rle block overflows")
+			}
+
+			copy(raw[out:], packed[i:i+count])
+			i += count
+			out += count
+		} else {
+			if i >= len(packed) || out+count+1 > len(raw) {
+				return FormatError("rle block overflows")
+			}
+
+			for k := 0; k <= count; k++ {
+				raw[out+k] = packed[i]
+			}
+			i++
+			out += count + 1
+		}
+	}
+
+	if out != len(raw) {
+		return FormatError("rle block size mismatch")
+	}
+
+	return nil
+}
+
+// predict reverses the delta encoding applied before compression.
+func predict(data []byte) []byte {
+	for i := 1; i < len(data); i++ {
+		data[i] = data[i-1] + data[i] - 128
+	}
+
+	return data
+}
+
+// interleave reverses the split of the data into its even and odd bytes.
+func interleave(data []byte) []byte {
+	out := make([]byte, len(data))
+	half := (len(data) + 1) / 2
+
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = data[i/2]
+		} else {
+			out[i] = data[half+i/2]
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,462 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Package exr implements a reader for OpenEXR images. Single part scanline
+// images with uncompressed, RLE or ZIP compressed data and half, float or
+// uint channels are supported. Images are decoded to hdr.RGB96; alpha and
+// other channels are dropped, and luminance only images are decoded as grey.
+package exr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/haakenlabs/arc/pkg/image/hdr"
+)
+
+const exrMagic = "\x76\x2f\x31\x01"
+
+// maxPixels is the largest data window decoded, which bounds the memory
+// allocated for an image before its data is read.
+const maxPixels = 1 << 26
+
+// Flags of the version field.
+const (
+	flagTiled     = 0x200
+	flagDeep      = 0x800
+	flagMultipart = 0x1000
+)
+
+// Pixel types of channels.
+const (
+	pixelUint  = 0
+	pixelHalf  = 1
+	pixelFloat = 2
+)
+
+// Compression methods.
+const (
+	compressionNone = 0
+	compressionRLE  = 1
+	compressionZIPS = 2
+	compressionZIP  = 3
+)
+
+// FormatError reports that the input is not a valid EXR image.
+type FormatError string
+
+func (e FormatError) Error() string {
+	return "exr: invalid format: " + string(e)
+}
+
+// UnsupportedError reports that the input uses a valid but unimplemented EXR
+// feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string {
+	return "exr: unsupported feature: " + string(e)
+}
+
+func init() {
+	image.RegisterFormat("exr", exrMagic, Decode, DecodeConfig)
+}
+
+// channel is a channel of the image.
+type channel struct {
+	name      string
+	pixelType int32
+	xSampling int32
+	ySampling int32
+}
+
+// size returns the size of a value of the channel in bytes.
+func (c channel) size() int {
+	if c.pixelType == pixelHalf {
+		return 2
+	}
+
+	return 4
+}
+
+// header holds the attributes of an image needed to decode it.
+type header struct {
+	channels    []channel
+	compression uint8
+	dataWindow  image.Rectangle
+}
+
+// reader reads little endian values from the data of an image.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+
+	return b
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (r *reader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+// string reads a null terminated string.
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+
+	end := bytes.IndexByte(r.data[r.pos:], 0)
+	if end < 0 {
+		r.err = io.ErrUnexpectedEOF
+		return ""
+	}
+
+	s := string(r.data[r.pos : r.pos+end])
+	r.pos += end + 1
+
+	return s
+}
+
+// readHeader reads the magic number, version and header attributes.
+func readHeader(r *reader) (*header, error) {
+	if string(r.bytes(4)) != exrMagic {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, FormatError("not an EXR file")
+	}
+
+	version := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	switch {
+	case version&0xff != 2:
+		return nil, UnsupportedError(fmt.Sprintf("version %d", version&0xff))
+	case version&flagTiled != 0:
+		return nil, UnsupportedError("tiled images")
+	case version&flagDeep != 0:
+		return nil, UnsupportedError("deep images")
+	case version&flagMultipart != 0:
+		return nil, UnsupportedError("multipart images")
+	}
+
+	h := &header{}
+	var hasChannels, hasWindow bool
+
+	for {
+		name := r.string()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if name == "" {
+			break
+		}
+
+		kind := r.string()
+		size := r.int32()
+		value := &reader{data: r.bytes(int(size))}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		switch {
+		case name == "channels" && kind == "chlist":
+			h.channels = readChannels(value)
+			hasChannels = true
+		case name == "compression" && kind == "compression":
+			if b := value.bytes(1); b != nil {
+				h.compression = b[0]
+			}
+		case name == "dataWindow" && kind == "box2i":
+			xMin, yMin := value.int32(), value.int32()
+			xMax, yMax := value.int32(), value.int32()
+			h.dataWindow = image.Rect(int(xMin), int(yMin), int(xMax)+1, int(yMax)+1)
+			hasWindow = true
+		}
+
+		if value.err != nil {
+			return nil, FormatError("attribute " + name)
+		}
+	}
+
+	if !hasChannels || !hasWindow {
+		return nil, FormatError("missing required attributes")
+	}
+	if h.dataWindow.Empty() {
+		return nil, FormatError("empty data window")
+	}
+	if w, ht := h.dataWindow.Dx(), h.dataWindow.Dy(); int64(w)*int64(ht) > maxPixels {
+		return nil, UnsupportedError(fmt.Sprintf("%dx%d data window", w, ht))
+	}
+
+	for _, c := range h.channels {
+		if c.pixelType < pixelUint || c.pixelType > pixelFloat {
+			return nil, FormatError("pixel type of channel " + c.name)
+		}
+		if c.xSampling != 1 || c.ySampling != 1 {
+			return nil, UnsupportedError("subsampled channel " + c.name)
+		}
+	}
+
+	return h, nil
+}
+
+// readChannels reads a channel list attribute.
+func readChannels(r *reader) []channel {
+	var channels []channel
+
+	for {
+		name := r.string()
+		if r.err != nil || name == "" {
+			return channels
+		}
+
+		c := channel{name: name}
+		c.pixelType = r.int32()
+		r.bytes(4) // pLinear and reserved bytes.
+		c.xSampling = r.int32()
+		c.ySampling = r.int32()
+
+		channels = append(channels, c)
+	}
+}
+
+// linesPerBlock returns the number of scanlines compressed together.
+func linesPerBlock(compression uint8) (int, error) {
+	switch compression {
+	case compressionNone, compressionRLE, compressionZIPS:
+		return 1, nil
+	case compressionZIP:
+		return 16, nil
+	}
+
+	return 0, UnsupportedError(fmt.Sprintf("compression %d", compression))
+}
+
+// Decode reads an EXR image from r and returns it as an *hdr.RGB96.
+func Decode(r io.Reader) (image.Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := &reader{data: data}
+
+	h, err := readHeader(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := linesPerBlock(h.compression)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := h.dataWindow.Dx(), h.dataWindow.Dy()
+
+	// Each channel is stored in the order of the channel list; only the color
+	// channels are kept.
+	offsets := make([]int, len(h.channels))
+	slots := make([]int, len(h.channels))
+	pixelSize := 0
+	grey := true
+	found := false
+
+	for i, c := range h.channels {
+		offsets[i] = pixelSize * width
+		pixelSize += c.size()
+
+		slots[i] = -1
+		switch c.name {
+		case "R":
+			slots[i], grey, found = 0, false, true
+		case "G":
+			slots[i], grey, found = 1, false, true
+		case "B":
+			slots[i], grey, found = 2, false, true
+		case "Y":
+			slots[i], found = 3, true
+		}
+	}
+
+	if !found {
+		return nil, FormatError("no color channels")
+	}
+
+	lineSize := pixelSize * width
+	blocks := (height + lines - 1) / lines
+	if blocks > (len(data)-rd.pos)/8 {
+		return nil, FormatError("offset table out of range")
+	}
+
+	img := hdr.NewRGB96(image.Rect(0, 0, width, height))
+
+	table := make([]uint64, blocks)
+	for i := range table {
+		table[i] = rd.uint64()
+	}
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	for _, offset := range table {
+		if offset > uint64(len(data)) {
+			return nil, FormatError("block offset out of range")
+		}
+
+		block := &reader{data: data, pos: int(offset)}
+		y := int(block.int32()) - h.dataWindow.Min.Y
+		size := int(block.int32())
+		packed := block.bytes(size)
+		if block.err != nil {
+			return nil, block.err
+		}
+
+		if y < 0 || y >= height {
+			return nil, FormatError("block outside data window")
+		}
+
+		n := lines
+		if y+n > height {
+			n = height - y
+		}
+
+		raw, err := uncompress(h.compression, packed, n*lineSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for l := 0; l < n; l++ {
+			line := raw[l*lineSize : (l+1)*lineSize]
+
+			for x := 0; x < width; x++ {
+				var rgb [4]float32
+
+				for i, c := range h.channels {
+					if slots[i] < 0 {
+						continue
+					}
+
+					rgb[slots[i]] = value(c.pixelType, line[offsets[i]+x*c.size():])
+				}
+
+				if grey {
+					rgb[0], rgb[1], rgb[2] = rgb[3], rgb[3], rgb[3]
+				}
+
+				img.SetRGB96(x, y+l, hdr.RGB96Color{R: rgb[0], G: rgb[1], B: rgb[2]})
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// DecodeConfig returns the color model and dimensions of an EXR image without
+// decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	h, err := readHeader(&reader{data: data})
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: hdr.RGB96Model,
+		Width:      h.dataWindow.Dx(),
+		Height:     h.dataWindow.Dy(),
+	}, nil
+}
+
+// value converts a value of a channel to a float.
+func value(pixelType int32, b []byte) float32 {
+	switch pixelType {
+	case pixelHalf:
+		return halfToFloat(binary.LittleEndian.Uint16(b))
+	case pixelFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+
+	return float32(binary.LittleEndian.Uint32(b))
+}
+
+// halfToFloat converts an IEEE 754 half precision float.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Subnormal halfs are normal floats.
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		exp++
+		mant &= 0x3ff
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | mant<<13)
+	}
+
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"math"
+	"testing"
+
+	"github.com/haakenlabs/arc/pkg/image/hdr"
+)
+
+// buildTestEXR builds an uncompressed scanline image with a float R channel
+// holding the x coordinate of each pixel. blocks is the number of offset
+// table entries and scanlines written.
+func buildTestEXR(width, height, blocks int) []byte {
+	b := &bytes.Buffer{}
+	le := func(v interface{}) { binary.Write(b, binary.LittleEndian, v) }
+	attr := func(name, kind string, value []byte) {
+		b.WriteString(name + "\x00" + kind + "\x00")
+		le(int32(len(value)))
+		b.Write(value)
+	}
+
+	b.WriteString(exrMagic)
+	le(uint32(2))
+
+	ch := &bytes.Buffer{}
+	ch.WriteString("R\x00")
+	binary.Write(ch, binary.LittleEndian, []int32{pixelFloat, 0, 1, 1})
+	ch.WriteByte(0)
+	attr("channels", "chlist", ch.Bytes())
+	attr("compression", "compression", []byte{compressionNone})
+
+	window := &bytes.Buffer{}
+	binary.Write(window, binary.LittleEndian, []int32{0, 0, int32(width - 1), int32(height - 1)})
+	attr("dataWindow", "box2i", window.Bytes())
+	b.WriteByte(0)
+
+	lineSize := 8 + 4*width
+	start := b.Len() + 8*blocks
+	for y := 0; y < blocks; y++ {
+		le(uint64(start + y*lineSize))
+	}
+	for y := 0; y < blocks; y++ {
+		le(int32(y))
+		le(int32(4 * width))
+		for x := 0; x < width; x++ {
+			le(float32(x))
+		}
+	}
+
+	return b.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	img, err := Decode(bytes.NewReader(buildTestEXR(3, 2, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := img.Bounds(); got != image.Rect(0, 0, 3, 2) {
+		t.Fatalf("want: %v got: %v", image.Rect(0, 0, 3, 2), got)
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			if got := img.(*hdr.RGB96).RGB96At(x, y).R; got != float32(x) {
+				t.Errorf("pixel %d,%d failed. want: %v got: %v", x, y, float32(x), got)
+			}
+		}
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want error
+	}{
+		{in: buildTestEXR(200000, 200000, 0), want: UnsupportedError("200000x200000 data window")},
+		{in: buildTestEXR(1, math.MaxInt32, 0), want: UnsupportedError("1x2147483647 data window")},
+		{in: buildTestEXR(4, 4096, 1), want: FormatError("offset table out of range")},
+		{in: buildTestEXR(4, 4, 3), want: FormatError("block offset out of range")},
+	}
+
+	for i, v := range tests {
+		_, err := Decode(bytes.NewReader(v.in))
+		if err != v.want {
+			t.Errorf("Decode case %d failed. want: %v got: %v", i, v.want, err)
+		}
+	}
+}
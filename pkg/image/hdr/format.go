@@ -119,6 +119,22 @@ func (p *RGB96) SetRGB96(x, y int, c RGB96Color) {
 	p.Pix[i+11] = uint8(b)
 }
 
+// Floats returns the pixels of the image as interleaved red, green and blue
+// values, row by row from the top.
+func (p *RGB96) Floats() []float32 {
+	w, h := p.Rect.Dx(), p.Rect.Dy()
+	data := make([]float32, 0, 3*w*h)
+
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			c := p.RGB96At(x, y)
+			data = append(data, c.R, c.G, c.B)
+		}
+	}
+
+	return data
+}
+
 // PixOffset returns the index of the first element of Pix that corresponds to
 // the pixel at (x, y).
 func (p *RGB96) PixOffset(x, y int) int {
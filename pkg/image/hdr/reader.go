@@ -30,19 +30,20 @@ import (
 	"image"
 	"io"
 	"math"
+	"strings"
 )
 
 const (
 	radianceHeader = "#?RADIANCE\n"
+	rgbeHeader     = "#?RGBE\n"
+	rgbeFormat     = "32-bit_rle_rgbe"
 )
 
 type decoder struct {
 	r             io.Reader
-	img           image.Image
+	img           *RGB96
 	width, height int
-	depth         int
-	headerSize    int
-	tmp           [3 * 256]byte
+	flipY         bool
 }
 
 // FormatError reports that the input is not a valid HDR image.
@@ -61,6 +62,7 @@ func (e UnsupportedError) Error() string {
 
 func init() {
 	image.RegisterFormat("hdr", radianceHeader, Decode, DecodeConfig)
+	image.RegisterFormat("hdr", rgbeHeader, Decode, DecodeConfig)
 }
 
 func (d *decoder) parseHeader(b *bufio.Reader) error {
@@ -69,23 +71,43 @@ func (d *decoder) parseHeader(b *bufio.Reader) error {
 
 	for {
 		line, err := b.ReadString('\n')
-
 		if err != nil {
 			return err
 		}
-		if len(line) == 1 {
+
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
 			break
 		}
+
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT="+rgbeFormat {
+			return UnsupportedError(line)
+		}
 	}
 
 	line, err := b.ReadString('\n')
+	if err != nil {
+		return err
+	}
 
 	_, err = fmt.Sscanf(line, "%s %d %s %d", &y, &h, &x, &w)
 	if err != nil {
-		return err
+		return FormatError("resolution: " + strings.TrimSpace(line))
 	}
 
-	// TODO: Handle different X, Y scenarios
+	// Images are usually stored from the top down. Images stored from the
+	// bottom up are flipped; rotated and mirrored images are not supported.
+	switch {
+	case y == "-Y" && x == "+X":
+	case y == "+Y" && x == "+X":
+		d.flipY = true
+	default:
+		return UnsupportedError("orientation " + strings.TrimSpace(line))
+	}
+
+	if w <= 0 || h <= 0 {
+		return FormatError("resolution: " + strings.TrimSpace(line))
+	}
 
 	d.width = w
 	d.height = h
@@ -101,6 +123,11 @@ func (d *decoder) parseData(b *bufio.Reader) error {
 			return err
 		}
 
+		row := y
+		if d.flipY {
+			row = d.height - 1 - y
+		}
+
 		for x := 0; x < d.width; x++ {
 			i := x * 4
 
@@ -108,7 +135,7 @@ func (d *decoder) parseData(b *bufio.Reader) error {
 			g := ldexp(line[i+3], line[i+1])
 			b := ldexp(line[i+3], line[i+2])
 
-			d.img.(*RGB96).Set(x, y, RGB96Color{
+			d.img.SetRGB96(x, row, RGB96Color{
 				R: r,
 				G: g,
 				B: b,
@@ -130,7 +157,8 @@ func readLine(r *bufio.Reader, line []byte) error {
 		return err
 	}
 
-	if lineHeader[0] != 2 || lineHeader[1] != 2 || (lineHeader[2]&128) != 0 {
+	if lineLength < 8 || lineLength > 0x7fff ||
+		lineHeader[0] != 2 || lineHeader[1] != 2 || (lineHeader[2]&128) != 0 {
 		return readUncompressedData(r, line)
 	}
 
@@ -139,7 +167,7 @@ func readLine(r *bufio.Reader, line []byte) error {
 		return FormatError(fmt.Sprintf("scanline length mismatch. have: %d want: %d", hlen, lineLength))
 	}
 
-	if _, err := r.Read(lineHeader); err != nil {
+	if _, err := r.Discard(4); err != nil {
 		return err
 	}
 
@@ -157,11 +185,19 @@ func readLine(r *bufio.Reader, line []byte) error {
 					return err
 				}
 
+				if j+int(code) > lineLength {
+					return FormatError("scanline run overflows")
+				}
+
 				for k := 0; k < int(code); k++ {
 					line[j*4+i] = value
 					j++
 				}
 			} else {
+				if code == 0 || j+int(code) > lineLength {
+					return FormatError("scanline run overflows")
+				}
+
 				for k := 0; k < int(code); k++ {
 					if value, err = r.ReadByte(); err != nil {
 						return err
@@ -186,18 +222,23 @@ func readUncompressedData(r *bufio.Reader, data []byte) error {
 	l := 0
 
 	for l < length {
-		if _, err := r.Read(s); err != nil {
+		if _, err := io.ReadFull(r, s); err != nil {
 			return err
 		}
 
 		if s[0] == 1 && s[1] == 1 && s[2] == 1 {
 			// Encoded
+			if l == 0 {
+				return FormatError("run without a pixel to repeat")
+			}
+
 			count := int(s[3]) << rshift
+			if l+count > length {
+				return FormatError("scanline run overflows")
+			}
+
 			for i := 0; i < count; i++ {
-				data[(l+i)*4+0] = s[0]
-				data[(l+i)*4+1] = s[1]
-				data[(l+i)*4+2] = s[2]
-				data[(l+i)*4+3] = s[3]
+				copy(data[(l+i)*4:(l+i)*4+4], data[(l-1)*4:l*4])
 			}
 
 			l += count
@@ -215,43 +256,43 @@ func readUncompressedData(r *bufio.Reader, data []byte) error {
 	return nil
 }
 
-func (d *decoder) Read(p []byte) (int, error) {
-	return 0, nil
-}
-
-func (d *decoder) checkHeader() error {
-	_, err := io.ReadFull(d.r, d.tmp[:len(radianceHeader)])
+// checkHeader reads the magic line, which is either "#?RADIANCE" or "#?RGBE".
+func (d *decoder) checkHeader(b *bufio.Reader) error {
+	line, err := b.ReadString('\n')
 	if err != nil {
 		return err
 	}
 
-	if string(d.tmp[:len(radianceHeader)]) != radianceHeader {
+	if line != radianceHeader && line != rgbeHeader {
 		return FormatError("not an HDR file")
 	}
 
 	return nil
 }
 
-// Decode reads an HDR image from r and returns it as an image.Image.
-// The type of Image returned depends on the HDR contents.
+// decodeHeader reads the magic line and the header of the image.
+func (d *decoder) decodeHeader() (*bufio.Reader, error) {
+	b := bufio.NewReader(d.r)
+
+	err := d.checkHeader(b)
+	if err == nil {
+		err = d.parseHeader(b)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return b, err
+}
+
+// Decode reads an HDR image from r and returns it as an *RGB96.
 func Decode(r io.Reader) (image.Image, error) {
 	d := &decoder{
 		r: r,
 	}
 
-	if err := d.checkHeader(); err != nil {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
-		return nil, err
-	}
-
-	b := bufio.NewReader(d.r)
-
-	if err := d.parseHeader(b); err != nil {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
+	b, err := d.decodeHeader()
+	if err != nil {
 		return nil, err
 	}
 
@@ -262,32 +303,23 @@ func Decode(r io.Reader) (image.Image, error) {
 		})
 
 	if err := d.parseData(b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
 		return nil, err
 	}
 
 	return d.img, nil
 }
 
-// DecodeConfig returns the color model and dimensions of a PNG image without
+// DecodeConfig returns the color model and dimensions of an HDR image without
 // decoding the entire image.
 func DecodeConfig(r io.Reader) (image.Config, error) {
 	d := &decoder{
 		r: r,
 	}
 
-	if err := d.checkHeader(); err != nil {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
-		return image.Config{}, err
-	}
-
-	b := bufio.NewReader(d.r)
-
-	if err := d.parseHeader(b); err != nil {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
+	if _, err := d.decodeHeader(); err != nil {
 		return image.Config{}, err
 	}
 
@@ -298,7 +330,13 @@ func DecodeConfig(r io.Reader) (image.Config, error) {
 	}, nil
 }
 
+// ldexp decodes a mantissa of a shared exponent pixel. A zero exponent
+// encodes black.
 func ldexp(exp, val uint8) float32 {
+	if exp == 0 {
+		return 0
+	}
+
 	f := float32(math.Ldexp(1.0, int(exp)-int(128+8)))
 
 	return f * float32(val)
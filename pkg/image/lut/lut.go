@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Package lut decodes 3D color lookup tables, used for color grading.
+package lut
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxSize is the largest supported size of a lookup table.
+const MaxSize = 256
+
+// LUT is a cubic color lookup table. Data holds Size^3 RGB values, with red
+// changing fastest and blue slowest.
+type LUT struct {
+	Size int
+	Data []float32
+}
+
+// At returns the color stored for the given red, green and blue indices.
+func (l *LUT) At(r, g, b int) (float32, float32, float32) {
+	i := ((b*l.Size+g)*l.Size + r) * 3
+
+	return l.Data[i], l.Data[i+1], l.Data[i+2]
+}
+
+// DecodeCube decodes a lookup table in the Adobe .cube format. Values are
+// remapped from the domain of the table to [0, 1]. Reading stops once the
+// table is full.
+func DecodeCube(r io.Reader) (*LUT, error) {
+	l := &LUT{}
+
+	domainMin := [3]float32{0, 0, 0}
+	domainMax := [3]float32{1, 1, 1}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for (l.Size == 0 || len(l.Data) < l.Size*l.Size*l.Size*3) && scanner.Scan() {
+		line++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "TITLE":
+			continue
+		case "LUT_1D_SIZE":
+			return nil, fmt.Errorf("lut: line %d: 1D lookup tables are not supported", line)
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 || l.Size != 0 {
+				return nil, fmt.Errorf("lut: line %d: invalid size", line)
+			}
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 2 || size > MaxSize {
+				return nil, fmt.Errorf("lut: line %d: invalid size: %s", line, fields[1])
+			}
+			l.Size = size
+			l.Data = make([]float32, 0, size*size*size*3)
+			continue
+		case "DOMAIN_MIN":
+			if len(l.Data) != 0 {
+				return nil, fmt.Errorf("lut: line %d: domain after data", line)
+			}
+			if err := parseTriple(fields[1:], &domainMin); err != nil {
+				return nil, fmt.Errorf("lut: line %d: %v", line, err)
+			}
+			continue
+		case "DOMAIN_MAX":
+			if len(l.Data) != 0 {
+				return nil, fmt.Errorf("lut: line %d: domain after data", line)
+			}
+			if err := parseTriple(fields[1:], &domainMax); err != nil {
+				return nil, fmt.Errorf("lut: line %d: %v", line, err)
+			}
+			continue
+		}
+
+		if l.Size == 0 {
+			return nil, fmt.Errorf("lut: line %d: data before LUT_3D_SIZE", line)
+		}
+		if len(l.Data) == 0 {
+			for i := range domainMin {
+				if !(domainMin[i] < domainMax[i]) {
+					return nil, fmt.Errorf("lut: line %d: invalid domain: %v to %v", line, domainMin, domainMax)
+				}
+			}
+		}
+
+		var v [3]float32
+		if err := parseTriple(fields, &v); err != nil {
+			return nil, fmt.Errorf("lut: line %d: %v", line, err)
+		}
+
+		for i := range v {
+			l.Data = append(l.Data, (v[i]-domainMin[i])/(domainMax[i]-domainMin[i]))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if l.Size == 0 {
+		return nil, fmt.Errorf("lut: missing LUT_3D_SIZE")
+	}
+	if n := l.Size * l.Size * l.Size * 3; len(l.Data) != n {
+		return nil, fmt.Errorf("lut: expected %d values, got %d", n/3, len(l.Data)/3)
+	}
+
+	return l, nil
+}
+
+// FromStrip converts a strip image to a lookup table. A strip of size N is N*N
+// pixels wide and N pixels high, made of N squares side by side. Blue
+// increases from square to square, red from left to right inside a square,
+// and green from the top row to the bottom row.
+func FromStrip(img image.Image) (*LUT, error) {
+	b := img.Bounds()
+	size := b.Dy()
+
+	if size < 2 || size > MaxSize || b.Dx() != size*size {
+		return nil, fmt.Errorf("lut: invalid strip size: %dx%d", b.Dx(), b.Dy())
+	}
+
+	l := &LUT{
+		Size: size,
+		Data: make([]float32, 0, size*size*size*3),
+	}
+
+	for blue := 0; blue < size; blue++ {
+		for green := 0; green < size; green++ {
+			for red := 0; red < size; red++ {
+				cr, cg, cb, _ := img.At(b.Min.X+blue*size+red, b.Min.Y+green).RGBA()
+				l.Data = append(l.Data, float32(cr)/0xffff, float32(cg)/0xffff, float32(cb)/0xffff)
+			}
+		}
+	}
+
+	return l, nil
+}
+
+func parseTriple(fields []string, v *[3]float32) error {
+	if len(fields) != 3 {
+		return fmt.Errorf("expected 3 values, got %d", len(fields))
+	}
+
+	for i := range fields {
+		f, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return fmt.Errorf("invalid value: %s", fields[i])
+		}
+		v[i] = float32(f)
+	}
+
+	return nil
+}
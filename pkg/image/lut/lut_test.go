@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package lut
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testCube returns a .cube file of size 2 with the given header lines and
+// the identity table, followed by extra lines.
+func testCube(header, extra string) string {
+	var b strings.Builder
+
+	b.WriteString(header)
+	b.WriteString("LUT_3D_SIZE 2\n")
+	for i := 0; i < 8; i++ {
+		fmt.Fprintf(&b, "%d %d %d\n", i&1, (i>>1)&1, (i>>2)&1)
+	}
+	b.WriteString(extra)
+
+	return b.String()
+}
+
+func TestDecodeCube(t *testing.T) {
+	tests := []struct {
+		src  string
+		fail bool
+	}{
+		{src: testCube("", "")},
+		{src: testCube("TITLE \"test\"\n# comment\n", "")},
+		{src: testCube("DOMAIN_MIN 0 0 0\nDOMAIN_MAX 1 1 1\n", "")},
+		// Lines after a full table are not read.
+		{src: testCube("", "0 0 0\nnot a value\n")},
+		{src: testCube("DOMAIN_MIN 0 1 0\nDOMAIN_MAX 1 1 1\n", ""), fail: true},
+		{src: testCube("DOMAIN_MIN 1 0 0\nDOMAIN_MAX 0 1 1\n", ""), fail: true},
+		{src: testCube("DOMAIN_MAX NaN 1 1\n", ""), fail: true},
+		{src: testCube("LUT_3D_SIZE 3\n", ""), fail: true},
+		{src: "LUT_3D_SIZE 2\n0 0 0\n", fail: true},
+		{src: "0 0 0\n", fail: true},
+		{src: "LUT_1D_SIZE 2\n", fail: true},
+	}
+
+	for i, v := range tests {
+		l, err := DecodeCube(strings.NewReader(v.src))
+		if (err != nil) != v.fail {
+			t.Errorf("DecodeCube case %d failed. want: %v got: %v", i, v.fail, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if len(l.Data) != 8*3 {
+			t.Errorf("DecodeCube case %d failed. want: %d got: %d", i, 8*3, len(l.Data))
+		}
+		if r, g, b := l.At(1, 0, 1); r != 1 || g != 0 || b != 1 {
+			t.Errorf("DecodeCube case %d failed. want: %v got: %v", i, []float32{1, 0, 1}, []float32{r, g, b})
+		}
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package math
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AABB is an axis aligned bounding box. A box with Min greater than Max on any
+// axis is empty.
+type AABB struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// Frustum holds the six planes of a view frustum. Each plane is stored as
+// (a, b, c, d) with the normal pointing into the frustum.
+type Frustum [6]mgl32.Vec4
+
+// EmptyAABB returns a box which contains nothing. Extending it with a point
+// gives a box around that point.
+func EmptyAABB() AABB {
+	return AABB{
+		Min: mgl32.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32},
+		Max: mgl32.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32},
+	}
+}
+
+// InfiniteAABB returns a box which contains everything. Use it for objects
+// whose bounds are unknown, so they are never culled.
+func InfiniteAABB() AABB {
+	return AABB{
+		Min: mgl32.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32},
+		Max: mgl32.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32},
+	}
+}
+
+// NewAABB returns the smallest box containing points.
+func NewAABB(points ...mgl32.Vec3) AABB {
+	b := EmptyAABB()
+	for i := range points {
+		b = b.Extend(points[i])
+	}
+
+	return b
+}
+
+func (b AABB) Empty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1] || b.Min[2] > b.Max[2]
+}
+
+func (b AABB) Infinite() bool {
+	return b.Min[0] == -math.MaxFloat32 || b.Max[0] == math.MaxFloat32
+}
+
+func (b AABB) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Extents returns the half size of the box.
+func (b AABB) Extents() mgl32.Vec3 {
+	return b.Max.Sub(b.Min).Mul(0.5)
+}
+
+// Extend returns the box grown to contain p.
+func (b AABB) Extend(p mgl32.Vec3) AABB {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = Min32(b.Min[i], p[i])
+		b.Max[i] = Max32(b.Max[i], p[i])
+	}
+
+	return b
+}
+
+// Union returns the smallest box containing both boxes.
+func (b AABB) Union(o AABB) AABB {
+	if o.Empty() {
+		return b
+	}
+
+	return b.Extend(o.Min).Extend(o.Max)
+}
+
+// Intersects reports if the boxes overlap. Boxes which only touch overlap.
+func (b AABB) Intersects(o AABB) bool {
+	for i := 0; i < 3; i++ {
+		if b.Min[i] > o.Max[i] || o.Min[i] > b.Max[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Contains reports if p is inside the box.
+func (b AABB) Contains(p mgl32.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContainsAABB reports if o is entirely inside the box.
+func (b AABB) ContainsAABB(o AABB) bool {
+	return b.Contains(o.Min) && b.Contains(o.Max)
+}
+
+// Expand returns the box grown by margin on every side.
+func (b AABB) Expand(margin float32) AABB {
+	if b.Empty() || b.Infinite() {
+		return b
+	}
+
+	m := mgl32.Vec3{margin, margin, margin}
+
+	return AABB{Min: b.Min.Sub(m), Max: b.Max.Add(m)}
+}
+
+// SurfaceArea returns the area of the faces of the box.
+func (b AABB) SurfaceArea() float32 {
+	if b.Empty() {
+		return 0
+	}
+
+	d := b.Max.Sub(b.Min)
+
+	return 2 * (d[0]*d[1] + d[1]*d[2] + d[2]*d[0])
+}
+
+// Transform returns the box around b after it is transformed by m.
+func (b AABB) Transform(m mgl32.Mat4) AABB {
+	if b.Empty() || b.Infinite() {
+		return b
+	}
+
+	t := m.Col(3).Vec3()
+	r := AABB{Min: t, Max: t}
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			e := m.At(i, j) * b.Min[j]
+			f := m.At(i, j) * b.Max[j]
+			r.Min[i] += Min32(e, f)
+			r.Max[i] += Max32(e, f)
+		}
+	}
+
+	return r
+}
+
+// NewFrustum extracts the frustum planes of a view projection matrix.
+func NewFrustum(m mgl32.Mat4) Frustum {
+	r0 := m.Row(0)
+	r1 := m.Row(1)
+	r2 := m.Row(2)
+	r3 := m.Row(3)
+
+	f := Frustum{
+		r3.Add(r0),
+		r3.Sub(r0),
+		r3.Add(r1),
+		r3.Sub(r1),
+		r3.Add(r2),
+		r3.Sub(r2),
+	}
+
+	for i := range f {
+		if l := f[i].Vec3().Len(); l != 0 {
+			f[i] = f[i].Mul(1 / l)
+		}
+	}
+
+	return f
+}
+
+// IntersectsAABB reports if any part of b is inside the frustum.
+func (f Frustum) IntersectsAABB(b AABB) bool {
+	if b.Empty() {
+		return false
+	}
+	if b.Infinite() {
+		return true
+	}
+
+	for i := range f {
+		// Test the corner of the box furthest along the plane normal.
+		p := b.Min
+		for j := 0; j < 3; j++ {
+			if f[i][j] >= 0 {
+				p[j] = b.Max[j]
+			}
+		}
+
+		if f[i].Vec3().Dot(p)+f[i][3] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package math
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Capsule is the set of points within Radius of the segment from A to B. A
+// capsule whose ends are the same point is a sphere.
+type Capsule struct {
+	A      mgl32.Vec3
+	B      mgl32.Vec3
+	Radius float32
+}
+
+// AABB returns the axis aligned box around the capsule.
+func (c Capsule) AABB() AABB {
+	return NewAABB(c.A, c.B).Expand(c.Radius)
+}
+
+// Contains reports if p is inside the capsule.
+func (c Capsule) Contains(p mgl32.Vec3) bool {
+	return p.Sub(ClosestPointSegment(c.A, c.B, p)).LenSqr() <= c.Radius*c.Radius
+}
+
+// ClosestPoint returns the point of the capsule closest to p. Points inside
+// the capsule are returned as they are.
+func (c Capsule) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	s := ClosestPointSegment(c.A, c.B, p)
+	d := p.Sub(s)
+
+	l := d.Len()
+	if l <= c.Radius {
+		return p
+	}
+
+	return s.Add(d.Mul(c.Radius / l))
+}
+
+// ClosestPointSegment returns the closest points of the capsule and of the
+// segment from a to b. If the segment passes through the capsule, both are
+// the same point inside of it.
+func (c Capsule) ClosestPointSegment(a, b mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	s, p := ClosestPointsSegments(c.A, c.B, a, b)
+	d := p.Sub(s)
+
+	l := d.Len()
+	if l <= c.Radius {
+		return p, p
+	}
+
+	return s.Add(d.Mul(c.Radius / l)), p
+}
+
+// Normal returns the outward normal of the capsule at the surface point
+// nearest to p. Points on the segment of the capsule have no normal, and
+// return up.
+func (c Capsule) Normal(p mgl32.Vec3) mgl32.Vec3 {
+	d := p.Sub(ClosestPointSegment(c.A, c.B, p))
+	if d.LenSqr() == 0 {
+		return mgl32.Vec3{0, 1, 0}
+	}
+
+	return d.Normalize()
+}
+
+// Intersects reports if the capsules overlap.
+func (c Capsule) Intersects(o Capsule) bool {
+	s, t := ClosestPointsSegments(c.A, c.B, o.A, o.B)
+	r := c.Radius + o.Radius
+
+	return t.Sub(s).LenSqr() <= r*r
+}
+
+// IntersectsOBB reports if the capsule and the box overlap.
+func (c Capsule) IntersectsOBB(o OBB) bool {
+	b, s := o.ClosestPointSegment(c.A, c.B)
+
+	return s.Sub(b).LenSqr() <= c.Radius*c.Radius
+}
+
+// ClosestPointSegment returns the point of the segment from a to b closest to
+// p.
+func ClosestPointSegment(a, b, p mgl32.Vec3) mgl32.Vec3 {
+	ab := b.Sub(a)
+
+	l := ab.LenSqr()
+	if l == 0 {
+		return a
+	}
+
+	return a.Add(ab.Mul(Clamp32(p.Sub(a).Dot(ab)/l, 0, 1)))
+}
+
+// ClosestPointsSegments returns the closest points of the segments from p1
+// to q1 and from p2 to q2.
+func ClosestPointsSegments(p1, q1, p2, q2 mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	const epsilon = 1e-12
+
+	d1 := q1.Sub(p1)
+	d2 := q2.Sub(p2)
+	r := p1.Sub(p2)
+	a := d1.Dot(d1)
+	e := d2.Dot(d2)
+	f := d2.Dot(r)
+
+	var s, t float32
+
+	switch {
+	case a <= epsilon && e <= epsilon:
+		return p1, p2
+	case a <= epsilon:
+		t = Clamp32(f/e, 0, 1)
+	case e <= epsilon:
+		s = Clamp32(-d1.Dot(r)/a, 0, 1)
+	default:
+		b := d1.Dot(d2)
+		c := d1.Dot(r)
+
+		// Parallel segments have no single closest pair, so any point of
+		// the first is taken.
+		if denom := a*e - b*b; denom != 0 {
+			s = Clamp32((b*f-c*e)/denom, 0, 1)
+		}
+
+		t = (b*s + f) / e
+		if t < 0 {
+			t = 0
+			s = Clamp32(-c/a, 0, 1)
+		} else if t > 1 {
+			t = 1
+			s = Clamp32((b-c)/a, 0, 1)
+		}
+	}
+
+	return p1.Add(d1.Mul(s)), p2.Add(d2.Mul(t))
+}
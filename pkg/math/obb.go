@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package math
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// OBB is an oriented box. Axes are the unit directions of its edges, and
+// HalfExtents the distances from its center to its faces along them.
+type OBB struct {
+	Center      mgl32.Vec3
+	Axes        [3]mgl32.Vec3
+	HalfExtents mgl32.Vec3
+}
+
+// NewOBB returns the box of size around center, transformed by m. The scale
+// of m is applied to the extents, and shear is ignored.
+func NewOBB(m mgl32.Mat4, center, size mgl32.Vec3) OBB {
+	o := OBB{
+		Center: mgl32.TransformCoordinate(center, m),
+		Axes:   [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+
+	for i := 0; i < 3; i++ {
+		axis := m.Col(i).Vec3()
+		l := axis.Len()
+		if l == 0 {
+			continue
+		}
+
+		o.Axes[i] = axis.Mul(1 / l)
+		o.HalfExtents[i] = mgl32.Abs(size[i]) * l / 2
+	}
+
+	return o
+}
+
+// NewOBBFromAABB returns the oriented box equal to b.
+func NewOBBFromAABB(b AABB) OBB {
+	return OBB{
+		Center:      b.Center(),
+		Axes:        [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		HalfExtents: b.Extents(),
+	}
+}
+
+// AABB returns the axis aligned box around the box.
+func (o OBB) AABB() AABB {
+	var e mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			e[j] += mgl32.Abs(o.Axes[i][j]) * o.HalfExtents[i]
+		}
+	}
+
+	return AABB{Min: o.Center.Sub(e), Max: o.Center.Add(e)}
+}
+
+// Contains reports if p is inside the box.
+func (o OBB) Contains(p mgl32.Vec3) bool {
+	d := p.Sub(o.Center)
+	for i := 0; i < 3; i++ {
+		if mgl32.Abs(d.Dot(o.Axes[i])) > o.HalfExtents[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ClosestPoint returns the point of the box closest to p. Points inside the
+// box are returned as they are.
+func (o OBB) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	d := p.Sub(o.Center)
+	q := o.Center
+
+	for i := 0; i < 3; i++ {
+		dist := Clamp32(d.Dot(o.Axes[i]), -o.HalfExtents[i], o.HalfExtents[i])
+		q = q.Add(o.Axes[i].Mul(dist))
+	}
+
+	return q
+}
+
+// ClosestPointSegment returns the closest points of the box and of the
+// segment from a to b. If the segment passes through the box, both are the
+// same point inside of it.
+func (o OBB) ClosestPointSegment(a, b mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	ab := b.Sub(a)
+	if ab.LenSqr() == 0 {
+		return o.ClosestPoint(a), a
+	}
+
+	// The distance to a convex set is convex along the segment, so its
+	// minimum is found by a golden section search.
+	f := func(t float32) float32 {
+		p := a.Add(ab.Mul(t))
+		return p.Sub(o.ClosestPoint(p)).LenSqr()
+	}
+
+	const invPhi = 0.618034
+
+	lo, hi := float32(0), float32(1)
+	c := hi - (hi-lo)*invPhi
+	d := lo + (hi-lo)*invPhi
+	fc, fd := f(c), f(d)
+
+	for i := 0; i < 32; i++ {
+		if fc < fd {
+			hi, d, fd = d, c, fc
+			c = hi - (hi-lo)*invPhi
+			fc = f(c)
+		} else {
+			lo, c, fc = c, d, fd
+			d = lo + (hi-lo)*invPhi
+			fd = f(d)
+		}
+	}
+
+	// The ends are checked, as the search never evaluates them.
+	t := (lo + hi) / 2
+	if f(0) <= f(t) {
+		t = 0
+	} else if f(1) < f(t) {
+		t = 1
+	}
+
+	p := a.Add(ab.Mul(t))
+
+	return o.ClosestPoint(p), p
+}
+
+// Normal returns the outward normal of the face of the box nearest to p.
+func (o OBB) Normal(p mgl32.Vec3) mgl32.Vec3 {
+	d := p.Sub(o.Center)
+
+	axis := 0
+	best := float32(-1)
+	for i := 0; i < 3; i++ {
+		dist := d.Dot(o.Axes[i])
+
+		// Distances are relative to the extents, so the nearest face is
+		// found in boxes which are not cubes.
+		rel := mgl32.Abs(dist)
+		if o.HalfExtents[i] > 0 {
+			rel /= o.HalfExtents[i]
+		}
+		if rel > best {
+			axis, best = i, rel
+		}
+	}
+
+	if d.Dot(o.Axes[axis]) < 0 {
+		return o.Axes[axis].Mul(-1)
+	}
+
+	return o.Axes[axis]
+}
+
+// Intersects reports if the boxes overlap, by the separating axis test.
+// Boxes which only touch overlap.
+func (o OBB) Intersects(b OBB) bool {
+	// A small epsilon keeps the cross products of nearly parallel edges
+	// from separating overlapping boxes.
+	const epsilon = 1e-6
+
+	var r, abs [3][3]float32
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] = o.Axes[i].Dot(b.Axes[j])
+			abs[i][j] = mgl32.Abs(r[i][j]) + epsilon
+		}
+	}
+
+	d := b.Center.Sub(o.Center)
+	t := mgl32.Vec3{d.Dot(o.Axes[0]), d.Dot(o.Axes[1]), d.Dot(o.Axes[2])}
+	ea, eb := o.HalfExtents, b.HalfExtents
+
+	for i := 0; i < 3; i++ {
+		rb := eb[0]*abs[i][0] + eb[1]*abs[i][1] + eb[2]*abs[i][2]
+		if mgl32.Abs(t[i]) > ea[i]+rb {
+			return false
+		}
+	}
+
+	for j := 0; j < 3; j++ {
+		ra := ea[0]*abs[0][j] + ea[1]*abs[1][j] + ea[2]*abs[2][j]
+		if mgl32.Abs(t[0]*r[0][j]+t[1]*r[1][j]+t[2]*r[2][j]) > ra+eb[j] {
+			return false
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		i1, i2 := (i+1)%3, (i+2)%3
+		for j := 0; j < 3; j++ {
+			j1, j2 := (j+1)%3, (j+2)%3
+
+			ra := ea[i1]*abs[i2][j] + ea[i2]*abs[i1][j]
+			rb := eb[j1]*abs[i][j2] + eb[j2]*abs[i][j1]
+			if mgl32.Abs(t[i2]*r[i1][j]-t[i1]*r[i2][j]) > ra+rb {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,169 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package math
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Ray is a half line starting at Origin and extending along Direction.
+type Ray struct {
+	Origin    mgl32.Vec3
+	Direction mgl32.Vec3
+}
+
+// NewRay returns a ray with a normalized direction.
+func NewRay(origin, direction mgl32.Vec3) Ray {
+	return Ray{
+		Origin:    origin,
+		Direction: direction.Normalize(),
+	}
+}
+
+// Point returns the point at distance t along the ray.
+func (r Ray) Point(t float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Direction.Mul(t))
+}
+
+// IntersectAABB returns the distance along the ray to the first intersection
+// with the box. If the origin is inside the box the distance is zero.
+func (r Ray) IntersectAABB(b AABB) (float32, bool) {
+	tMin := float32(0)
+	tMax := float32(math.MaxFloat32)
+
+	for i := 0; i < 3; i++ {
+		if r.Direction[i] == 0 {
+			if r.Origin[i] < b.Min[i] || r.Origin[i] > b.Max[i] {
+				return 0, false
+			}
+			continue
+		}
+
+		inv := 1 / r.Direction[i]
+		t0 := (b.Min[i] - r.Origin[i]) * inv
+		t1 := (b.Max[i] - r.Origin[i]) * inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+
+		tMin = Max32(tMin, t0)
+		tMax = Min32(tMax, t1)
+
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+
+	return tMin, true
+}
+
+// IntersectOBB returns the distance along the ray to the first intersection
+// with the box. If the origin is inside the box the distance is zero.
+func (r Ray) IntersectOBB(o OBB) (float32, bool) {
+	var local Ray
+
+	d := r.Origin.Sub(o.Center)
+	for i := 0; i < 3; i++ {
+		local.Origin[i] = d.Dot(o.Axes[i])
+		local.Direction[i] = r.Direction.Dot(o.Axes[i])
+	}
+
+	return local.IntersectAABB(AABB{Min: o.HalfExtents.Mul(-1), Max: o.HalfExtents})
+}
+
+// IntersectSphere returns the distance along the ray to the first
+// intersection with the sphere. If the origin is inside the sphere the
+// distance is zero. The direction of the ray must be normalized.
+func (r Ray) IntersectSphere(s Sphere) (float32, bool) {
+	m := r.Origin.Sub(s.Center)
+	c := m.LenSqr() - s.Radius*s.Radius
+	if c <= 0 {
+		return 0, true
+	}
+
+	b := m.Dot(r.Direction)
+	if b > 0 {
+		return 0, false
+	}
+
+	disc := b*b - c
+	if disc < 0 {
+		return 0, false
+	}
+
+	return -b - float32(math.Sqrt(float64(disc))), true
+}
+
+// IntersectCapsule returns the distance along the ray to the first
+// intersection with the capsule. If the origin is inside the capsule the
+// distance is zero. The direction of the ray must be normalized.
+func (r Ray) IntersectCapsule(c Capsule) (float32, bool) {
+	if c.Contains(r.Origin) {
+		return 0, true
+	}
+
+	ba := c.B.Sub(c.A)
+	oa := r.Origin.Sub(c.A)
+	baba := ba.Dot(ba)
+	bard := ba.Dot(r.Direction)
+	baoa := ba.Dot(oa)
+
+	// The side of the capsule is tested first, unless the ray runs along
+	// its axis.
+	if a := baba - bard*bard; a > 1e-6*baba {
+		b := baba*r.Direction.Dot(oa) - baoa*bard
+		k := baba*oa.Dot(oa) - baoa*baoa - c.Radius*c.Radius*baba
+
+		if h := b*b - a*k; h >= 0 {
+			t := (-b - float32(math.Sqrt(float64(h)))) / a
+			if y := baoa + t*bard; t >= 0 && y > 0 && y < baba {
+				return t, true
+			}
+		}
+	}
+
+	// Otherwise the ray can only enter through the ends.
+	t, hit := r.IntersectSphere(Sphere{Center: c.A, Radius: c.Radius})
+	if u, ok := r.IntersectSphere(Sphere{Center: c.B, Radius: c.Radius}); ok && (!hit || u < t) {
+		t, hit = u, true
+	}
+
+	return t, hit
+}
+
+// IntersectPlane returns the distance along the ray to the plane through
+// point with the given normal.
+func (r Ray) IntersectPlane(point, normal mgl32.Vec3) (float32, bool) {
+	d := normal.Dot(r.Direction)
+	if d > -1e-6 && d < 1e-6 {
+		return 0, false
+	}
+
+	t := point.Sub(r.Origin).Dot(normal) / d
+	if t < 0 {
+		return 0, false
+	}
+
+	return t, true
+}
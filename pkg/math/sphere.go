@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package math
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Sphere is a bounding sphere. A sphere with a negative radius is empty.
+type Sphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+// NewSphere returns a sphere containing points. It is found with Ritter's
+// algorithm, and is usually a few percent larger than the smallest one.
+func NewSphere(points ...mgl32.Vec3) Sphere {
+	if len(points) == 0 {
+		return Sphere{Radius: -1}
+	}
+
+	// Start from the two points farthest apart along a rough diameter.
+	x := points[0]
+	y := farthest(points, x)
+	z := farthest(points, y)
+
+	s := Sphere{
+		Center: y.Add(z).Mul(0.5),
+		Radius: z.Sub(y).Len() / 2,
+	}
+
+	for _, p := range points {
+		s = s.Extend(p)
+	}
+
+	// The sphere around the center of the bounding box is sometimes
+	// smaller, such as for boxes.
+	c := NewAABB(points...).Center()
+	b := Sphere{Center: c, Radius: c.Sub(farthest(points, c)).Len()}
+	if b.Radius < s.Radius {
+		return b
+	}
+
+	return s
+}
+
+// farthest returns the point farthest from p.
+func farthest(points []mgl32.Vec3, p mgl32.Vec3) mgl32.Vec3 {
+	var best mgl32.Vec3
+	var dist float32 = -1
+
+	for _, q := range points {
+		if d := q.Sub(p).LenSqr(); d > dist {
+			best, dist = q, d
+		}
+	}
+
+	return best
+}
+
+func (s Sphere) Empty() bool {
+	return s.Radius < 0
+}
+
+// Contains reports if p is inside the sphere.
+func (s Sphere) Contains(p mgl32.Vec3) bool {
+	return p.Sub(s.Center).LenSqr() <= s.Radius*s.Radius
+}
+
+// IntersectsAABB reports if any part of the box is inside the sphere.
+func (s Sphere) IntersectsAABB(b AABB) bool {
+	if s.Empty() || b.Empty() {
+		return false
+	}
+
+	var d float32
+	for i := 0; i < 3; i++ {
+		c := Clamp32(s.Center[i], b.Min[i], b.Max[i]) - s.Center[i]
+		d += c * c
+	}
+
+	return d <= s.Radius*s.Radius
+}
+
+// Extend returns the smallest sphere containing s and p which keeps the far
+// side of s.
+func (s Sphere) Extend(p mgl32.Vec3) Sphere {
+	if s.Empty() {
+		return Sphere{Center: p}
+	}
+
+	d := p.Sub(s.Center)
+	dist := d.Len()
+	if dist <= s.Radius {
+		return s
+	}
+
+	r := (s.Radius + dist) / 2
+
+	return Sphere{
+		Center: s.Center.Add(d.Mul((r - s.Radius) / dist)),
+		Radius: r,
+	}
+}
+
+// Transform returns the sphere moved by m. The radius is scaled by the
+// largest scale of m, so the result contains the transformed sphere.
+func (s Sphere) Transform(m mgl32.Mat4) Sphere {
+	if s.Empty() {
+		return s
+	}
+
+	scale := Max32(m.Col(0).Vec3().Len(), Max32(m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()))
+
+	return Sphere{
+		Center: mgl32.TransformCoordinate(s.Center, m),
+		Radius: s.Radius * scale,
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package replication
+
+import (
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ scene.Component = &NetworkIdentity{}
+
+// Replicated is implemented by components whose state is replicated from the
+// server to clients. Components are matched by their order on the object, so
+// the server and clients must build objects of a prefab the same way.
+type Replicated interface {
+	scene.Component
+
+	// WriteState writes the current state of the component. The encoding
+	// must be deterministic, as it is compared against previous snapshots to
+	// decide if the component changed.
+	WriteState(w *core.NetWriter)
+
+	// ReadState reads a state written by WriteState on the server. Tick is
+	// the server tick the state was captured at.
+	ReadState(r *core.NetReader, tick uint32)
+}
+
+// CommandReceiver is implemented by components which accept commands sent by
+// clients. Commands are only delivered on the server, and only if the sender
+// has authority over the object.
+type CommandReceiver interface {
+	OnCommand(peer core.PeerID, r *core.NetReader)
+}
+
+// SpawnReceiver is implemented by components which want to be notified when
+// their object is spawned or despawned by a Replicator.
+type SpawnReceiver interface {
+	OnNetworkSpawn(identity *NetworkIdentity)
+	OnNetworkDespawn(identity *NetworkIdentity)
+}
+
+// NetworkIdentity marks an object as replicated. It must be attached to the
+// objects created by prefab factories registered with a Replicator.
+type NetworkIdentity struct {
+	scene.BaseComponent
+
+	netID      uint32
+	prefab     string
+	owner      core.PeerID
+	replicator *Replicator
+	replicated []Replicated
+}
+
+func NewNetworkIdentity() *NetworkIdentity {
+	n := &NetworkIdentity{}
+
+	n.SetName("NetworkIdentity")
+	instance.MustAssign(n)
+
+	return n
+}
+
+// NetID returns the network ID of the object. It is zero until the object is
+// spawned.
+func (n *NetworkIdentity) NetID() uint32 {
+	return n.netID
+}
+
+// Prefab returns the name of the prefab the object was created from.
+func (n *NetworkIdentity) Prefab() string {
+	return n.prefab
+}
+
+// Owner returns the peer owning the object. Objects owned by the server have
+// no owner.
+func (n *NetworkIdentity) Owner() core.PeerID {
+	return n.owner
+}
+
+// Spawned reports if the object has been spawned by a Replicator.
+func (n *NetworkIdentity) Spawned() bool {
+	return n.replicator != nil
+}
+
+// Replicator returns the replicator which spawned the object.
+func (n *NetworkIdentity) Replicator() *Replicator {
+	return n.replicator
+}
+
+// IsServer reports if this is the server copy of the object.
+func (n *NetworkIdentity) IsServer() bool {
+	return n.replicator != nil && n.replicator.server
+}
+
+// IsOwner reports if the local peer owns the object. On the server this is
+// true for objects without an owner.
+func (n *NetworkIdentity) IsOwner() bool {
+	if n.replicator == nil {
+		return false
+	}
+	if n.replicator.server {
+		return n.owner == 0
+	}
+
+	return n.owner != 0 && n.owner == n.replicator.localPeer
+}
+
+// SendCommand sends a command for the object to the server. The command is
+// handed to the CommandReceiver components of the server copy of the object.
+func (n *NetworkIdentity) SendCommand(write func(w *core.NetWriter)) error {
+	if n.replicator == nil {
+		return ErrNotSpawned(n.Name())
+	}
+
+	return n.replicator.sendCommand(n, write)
+}
+
+// Replicated returns the replicated components of the object.
+func (n *NetworkIdentity) Replicated() []Replicated {
+	if n.replicated == nil && n.GameObject() != nil {
+		for _, c := range n.GameObject().Components() {
+			if r, ok := c.(Replicated); ok {
+				n.replicated = append(n.replicated, r)
+			}
+		}
+	}
+
+	return n.replicated
+}
+
+func (n *NetworkIdentity) writeStates() [][]byte {
+	components := n.Replicated()
+	states := make([][]byte, len(components))
+
+	for i, c := range components {
+		w := core.NewNetWriter()
+		c.WriteState(w)
+		states[i] = append([]byte{}, w.Bytes()...)
+	}
+
+	return states
+}
+
+func (n *NetworkIdentity) readStates(states [][]byte, tick uint32) {
+	components := n.Replicated()
+
+	for i, s := range states {
+		if s == nil || i >= len(components) {
+			continue
+		}
+
+		components[i].ReadState(core.NewNetReader(s), tick)
+	}
+}
+
+func identityOf(object *scene.GameObject) *NetworkIdentity {
+	if object == nil {
+		return nil
+	}
+
+	for _, c := range object.Components() {
+		if n, ok := c.(*NetworkIdentity); ok {
+			return n
+		}
+	}
+
+	return nil
+}
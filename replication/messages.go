@@ -0,0 +1,392 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package replication
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/haakenlabs/arc/core"
+)
+
+// Message IDs used by the Replicator. Applications must not register other
+// messages under these IDs.
+const (
+	MsgWelcome uint16 = 0xff00 + iota
+	MsgSpawn
+	MsgDespawn
+	MsgSnapshot
+	MsgAck
+	MsgCommand
+)
+
+// maxComponents is the number of replicated components an object can have,
+// limited by the change mask of a snapshot.
+const maxComponents = 32
+
+// ErrNotSpawned is returned when an operation requires a spawned object.
+type ErrNotSpawned string
+
+func (e ErrNotSpawned) Error() string {
+	return "replication: object not spawned: " + string(e)
+}
+
+// ErrPrefabUnknown is returned when spawning a prefab which was not
+// registered.
+type ErrPrefabUnknown string
+
+func (e ErrPrefabUnknown) Error() string {
+	return "replication: unknown prefab: " + string(e)
+}
+
+// ErrPrefabIdentity is returned when a prefab factory creates an object
+// without a NetworkIdentity.
+type ErrPrefabIdentity string
+
+func (e ErrPrefabIdentity) Error() string {
+	return "replication: prefab has no NetworkIdentity: " + string(e)
+}
+
+// ErrTooManyComponents is returned when an object has more replicated
+// components than can be described by a snapshot.
+type ErrTooManyComponents int
+
+func (e ErrTooManyComponents) Error() string {
+	return fmt.Sprintf("replication: %d replicated components, max is %d", int(e), maxComponents)
+}
+
+// ErrSnapshotPart is returned when decoding a snapshot part which is out of
+// range.
+type ErrSnapshotPart uint16
+
+func (e ErrSnapshotPart) Error() string {
+	return fmt.Sprintf("replication: snapshot part out of range: %d", uint16(e))
+}
+
+type welcomeMsg struct {
+	peer     core.PeerID
+	tickRate float64
+}
+
+func (m *welcomeMsg) NetID() uint16 {
+	return MsgWelcome
+}
+
+func (m *welcomeMsg) Encode(w *core.NetWriter) {
+	w.WriteUint32(uint32(m.peer))
+	w.WriteFloat64(m.tickRate)
+}
+
+func (m *welcomeMsg) Decode(r *core.NetReader) error {
+	m.peer = core.PeerID(r.ReadUint32())
+	m.tickRate = r.ReadFloat64()
+
+	return nil
+}
+
+type spawnMsg struct {
+	netID  uint32
+	prefab string
+	owner  core.PeerID
+	tick   uint32
+	states [][]byte
+}
+
+func (m *spawnMsg) NetID() uint16 {
+	return MsgSpawn
+}
+
+func (m *spawnMsg) Encode(w *core.NetWriter) {
+	w.WriteUint32(m.netID)
+	w.WriteString(m.prefab)
+	w.WriteUint32(uint32(m.owner))
+	w.WriteUint32(m.tick)
+	w.WriteUint8(uint8(len(m.states)))
+	for _, s := range m.states {
+		w.WriteBytes(s)
+	}
+}
+
+func (m *spawnMsg) Decode(r *core.NetReader) error {
+	m.netID = r.ReadUint32()
+	m.prefab = r.ReadString()
+	m.owner = core.PeerID(r.ReadUint32())
+	m.tick = r.ReadUint32()
+
+	n := int(r.ReadUint8())
+	if n > maxComponents {
+		return ErrTooManyComponents(n)
+	}
+	m.states = make([][]byte, n)
+	for i := range m.states {
+		m.states[i] = readState(r)
+	}
+
+	return nil
+}
+
+type despawnMsg struct {
+	netID uint32
+}
+
+func (m *despawnMsg) NetID() uint16 {
+	return MsgDespawn
+}
+
+func (m *despawnMsg) Encode(w *core.NetWriter) {
+	w.WriteUint32(m.netID)
+}
+
+func (m *despawnMsg) Decode(r *core.NetReader) error {
+	m.netID = r.ReadUint32()
+
+	return nil
+}
+
+// entityDelta holds the components of an object which changed relative to
+// the baseline of a snapshot. Unchanged components are nil.
+type entityDelta struct {
+	netID  uint32
+	states [][]byte
+}
+
+// snapshotMsg is one part of a snapshot. Snapshots are split into parts
+// which each fit in a packet, and applied once every part has arrived.
+type snapshotMsg struct {
+	tick     uint32
+	baseline uint32
+	part     uint16
+	parts    uint16
+	entities []entityDelta
+}
+
+func (m *snapshotMsg) NetID() uint16 {
+	return MsgSnapshot
+}
+
+func (m *snapshotMsg) Encode(w *core.NetWriter) {
+	w.WriteUint32(m.tick)
+	w.WriteUint32(m.baseline)
+	w.WriteUint16(m.part)
+	w.WriteUint16(m.parts)
+	w.WriteUint16(uint16(len(m.entities)))
+
+	for _, e := range m.entities {
+		var mask uint32
+		for i, s := range e.states {
+			if s != nil {
+				mask |= 1 << uint(i)
+			}
+		}
+
+		w.WriteUint32(e.netID)
+		w.WriteUint8(uint8(len(e.states)))
+		w.WriteUint32(mask)
+		for _, s := range e.states {
+			if s != nil {
+				w.WriteBytes(s)
+			}
+		}
+	}
+}
+
+func (m *snapshotMsg) Decode(r *core.NetReader) error {
+	m.tick = r.ReadUint32()
+	m.baseline = r.ReadUint32()
+	m.part = r.ReadUint16()
+	m.parts = r.ReadUint16()
+	if r.Err() != nil {
+		return r.Err()
+	}
+	if m.part >= m.parts {
+		return ErrSnapshotPart(m.part)
+	}
+
+	m.entities = make([]entityDelta, r.ReadUint16())
+	for i := range m.entities {
+		e := &m.entities[i]
+		e.netID = r.ReadUint32()
+
+		n := int(r.ReadUint8())
+		if n > maxComponents {
+			return ErrTooManyComponents(n)
+		}
+		e.states = make([][]byte, n)
+
+		mask := r.ReadUint32()
+		for j := range e.states {
+			if mask&(1<<uint(j)) != 0 {
+				e.states[j] = readState(r)
+			}
+		}
+
+		if r.Err() != nil {
+			return r.Err()
+		}
+	}
+
+	return nil
+}
+
+// snapshotHeaderSize is the encoded size of a snapshot part without its
+// entities, including the message ID.
+const snapshotHeaderSize = 16
+
+// size returns the encoded size of the entity delta.
+func (e *entityDelta) size() int {
+	n := 9
+	for _, s := range e.states {
+		if s != nil {
+			n += 4 + len(s)
+		}
+	}
+
+	return n
+}
+
+// splitSnapshot splits a snapshot into parts no larger than size when
+// encoded. An entity larger than size is sent in a part of its own.
+func splitSnapshot(m *snapshotMsg, size int) []*snapshotMsg {
+	newPart := func() *snapshotMsg {
+		return &snapshotMsg{tick: m.tick, baseline: m.baseline}
+	}
+
+	part := newPart()
+	parts := []*snapshotMsg{part}
+	n := snapshotHeaderSize
+
+	for _, e := range m.entities {
+		es := e.size()
+		if len(part.entities) > 0 && (n+es > size || len(part.entities) == math.MaxUint16) {
+			part = newPart()
+			parts = append(parts, part)
+			n = snapshotHeaderSize
+		}
+
+		part.entities = append(part.entities, e)
+		n += es
+	}
+
+	for i := range parts {
+		parts[i].part = uint16(i)
+		parts[i].parts = uint16(len(parts))
+	}
+
+	return parts
+}
+
+// snapshotAssembly collects the parts of the latest snapshot received.
+type snapshotAssembly struct {
+	msg      *snapshotMsg
+	received []bool
+	count    int
+}
+
+// add adds a part, and returns the snapshot once all of its parts have
+// arrived. Parts of a snapshot older than the one being collected are
+// dropped, and a newer snapshot replaces it.
+func (a *snapshotAssembly) add(m *snapshotMsg) *snapshotMsg {
+	if m.parts <= 1 {
+		return m
+	}
+
+	if a.msg == nil || m.tick > a.msg.tick {
+		a.msg = &snapshotMsg{tick: m.tick, baseline: m.baseline, parts: 1}
+		a.received = make([]bool, m.parts)
+		a.count = 0
+	}
+	if m.tick != a.msg.tick || m.baseline != a.msg.baseline || int(m.parts) != len(a.received) {
+		return nil
+	}
+	if a.received[m.part] {
+		return nil
+	}
+
+	a.received[m.part] = true
+	a.msg.entities = append(a.msg.entities, m.entities...)
+	a.count++
+
+	if a.count < len(a.received) {
+		return nil
+	}
+
+	msg := a.msg
+	a.reset()
+
+	return msg
+}
+
+func (a *snapshotAssembly) reset() {
+	a.msg = nil
+	a.received = nil
+	a.count = 0
+}
+
+type ackMsg struct {
+	tick uint32
+}
+
+func (m *ackMsg) NetID() uint16 {
+	return MsgAck
+}
+
+func (m *ackMsg) Encode(w *core.NetWriter) {
+	w.WriteUint32(m.tick)
+}
+
+func (m *ackMsg) Decode(r *core.NetReader) error {
+	m.tick = r.ReadUint32()
+
+	return nil
+}
+
+type commandMsg struct {
+	netID uint32
+	data  []byte
+}
+
+func (m *commandMsg) NetID() uint16 {
+	return MsgCommand
+}
+
+func (m *commandMsg) Encode(w *core.NetWriter) {
+	w.WriteUint32(m.netID)
+	w.WriteBytes(m.data)
+}
+
+func (m *commandMsg) Decode(r *core.NetReader) error {
+	m.netID = r.ReadUint32()
+	m.data = r.ReadBytes()
+
+	return nil
+}
+
+// readState reads a component state. Empty states are returned as an empty
+// slice, as nil marks an unchanged component.
+func readState(r *core.NetReader) []byte {
+	if b := r.ReadBytes(); b != nil {
+		return b
+	}
+
+	return []byte{}
+}
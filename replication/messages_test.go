@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/haakenlabs/arc/core"
+)
+
+func setupTestSnapshot(tick uint32, objects int) *snapshot {
+	s := &snapshot{
+		tick:   tick,
+		states: make(map[uint32][][]byte, objects),
+	}
+
+	for id := uint32(1); id <= uint32(objects); id++ {
+		w := core.NewNetWriter()
+		w.WriteUint32(id)
+		w.WriteUint32(tick)
+		w.WriteFloat64(float64(id) * 0.5)
+
+		s.states[id] = [][]byte{w.Bytes(), w.Bytes(), {byte(id)}}
+	}
+
+	return s
+}
+
+// sendSnapshot encodes and decodes the parts of a snapshot, as they would be
+// sent over the network.
+func sendSnapshot(t *testing.T, msg *snapshotMsg) []*snapshotMsg {
+	var parts []*snapshotMsg
+
+	for i, part := range splitSnapshot(msg, core.UDPMaxPayload) {
+		data := core.EncodeNetMessage(part)
+		if len(data) > core.UDPMaxPayload {
+			t.Errorf("part %d failed. want: <= %d bytes got: %d", i, core.UDPMaxPayload, len(data))
+		}
+
+		r := core.NewNetReader(data)
+		if id := r.ReadUint16(); id != MsgSnapshot {
+			t.Fatalf("part %d failed. want: id %d got: %d", i, MsgSnapshot, id)
+		}
+
+		m := &snapshotMsg{}
+		if err := m.Decode(r); err != nil {
+			t.Fatalf("part %d failed. error: %v", i, err)
+		}
+		parts = append(parts, m)
+	}
+
+	return parts
+}
+
+func TestSplitSnapshot(t *testing.T) {
+	r := &Replicator{}
+
+	tests := []struct {
+		objects int
+		minimum int
+	}{
+		{objects: 0, minimum: 1},
+		{objects: 1, minimum: 1},
+		{objects: 100, minimum: 2},
+		{objects: 500, minimum: 10},
+	}
+
+	for i, v := range tests {
+		s := setupTestSnapshot(7, v.objects)
+		parts := sendSnapshot(t, r.delta(s, nil))
+
+		if len(parts) < v.minimum {
+			t.Errorf("Split case %d failed. want: >= %d parts got: %d", i, v.minimum, len(parts))
+		}
+
+		a := snapshotAssembly{}
+		var got *snapshotMsg
+		for j := len(parts) - 1; j >= 0; j-- {
+			if got != nil {
+				t.Fatalf("Split case %d failed. assembled before the last part", i)
+			}
+			got = a.add(parts[j])
+		}
+		if got == nil {
+			t.Fatalf("Split case %d failed. want: snapshot got: nil", i)
+		}
+
+		if got.tick != 7 || len(got.entities) != v.objects {
+			t.Errorf(
+				"Split case %d failed. want: tick 7, %d entities got: tick %d, %d entities",
+				i, v.objects, got.tick, len(got.entities))
+		}
+		for _, e := range got.entities {
+			want := s.states[e.netID]
+			for k := range want {
+				if !bytes.Equal(want[k], e.states[k]) {
+					t.Errorf("Split case %d failed. entity %d component %d differs", i, e.netID, k)
+				}
+			}
+		}
+	}
+}
+
+func TestSplitSnapshot_Oversized(t *testing.T) {
+	msg := &snapshotMsg{
+		tick: 1,
+		entities: []entityDelta{
+			{netID: 1, states: [][]byte{make([]byte, 10)}},
+			{netID: 2, states: [][]byte{make([]byte, 2*core.UDPMaxPayload)}},
+			{netID: 3, states: [][]byte{make([]byte, 10)}},
+		},
+	}
+
+	parts := splitSnapshot(msg, core.UDPMaxPayload)
+	if len(parts) != 3 {
+		t.Fatalf("want: 3 parts got: %d", len(parts))
+	}
+	if len(parts[1].entities) != 1 || parts[1].entities[0].netID != 2 {
+		t.Errorf("want: entity 2 alone got: %v", parts[1].entities)
+	}
+}
+
+func TestSnapshotAssembly_Add(t *testing.T) {
+	r := &Replicator{}
+
+	old := sendSnapshot(t, r.delta(setupTestSnapshot(1, 100), nil))
+	cur := sendSnapshot(t, r.delta(setupTestSnapshot(2, 100), nil))
+
+	a := snapshotAssembly{}
+
+	// A lost part holds the snapshot back, and a newer snapshot replaces it.
+	for _, p := range old[1:] {
+		if a.add(p) != nil {
+			t.Fatal("want: nil got: snapshot with a missing part")
+		}
+	}
+	for _, p := range cur[:len(cur)-1] {
+		if a.add(p) != nil {
+			t.Fatal("want: nil got: snapshot with a missing part")
+		}
+	}
+
+	// Parts of the older snapshot and duplicates are dropped.
+	if a.add(old[0]) != nil || a.add(cur[0]) != nil {
+		t.Fatal("want: nil got: snapshot")
+	}
+
+	m := a.add(cur[len(cur)-1])
+	if m == nil || m.tick != 2 || len(m.entities) != 100 {
+		t.Fatalf("want: tick 2 with 100 entities got: %v", m)
+	}
+}
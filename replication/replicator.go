@@ -0,0 +1,676 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package replication
+
+import (
+	"bytes"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/network"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ scene.ScriptComponent = &Replicator{}
+
+const (
+	// DefaultTickRate is the default number of snapshots sent per second.
+	DefaultTickRate = 20.0
+
+	// DefaultInterpolationDelay is the default time in seconds clients render
+	// behind the server.
+	DefaultInterpolationDelay = 0.1
+
+	// historySize is the number of snapshots kept as delta baselines.
+	historySize = 32
+)
+
+// ErrNotServer is returned when a server only operation is performed on a
+// client.
+type ErrNotServer string
+
+func (e ErrNotServer) Error() string {
+	return "replication: not the server: " + string(e)
+}
+
+// AuthorityFunc decides if a peer may send commands to an object.
+type AuthorityFunc func(peer core.PeerID, identity *NetworkIdentity) bool
+
+// snapshot holds the replicated state of every object at a tick.
+type snapshot struct {
+	tick   uint32
+	states map[uint32][][]byte
+}
+
+// Replicator spawns objects on clients and keeps their replicated components
+// in sync with the server. The server sends snapshots at a fixed tick rate,
+// delta encoded against the last snapshot acknowledged by each client.
+//
+// Attach one Replicator to an object of the active scene on both the server
+// and the clients. Replicated objects are added to the same scene. A
+// Replicator registers its messages with the network system when created, and
+// removes its handlers when deallocated, so only one should exist at a time.
+type Replicator struct {
+	scene.BaseScriptComponent
+
+	prefabs    map[string]func() *scene.GameObject
+	identities map[uint32]*NetworkIdentity
+	history    []*snapshot
+	acks       map[core.PeerID]uint32
+	authority  AuthorityFunc
+
+	tickRate float64
+	delay    float64
+	accum    float64
+	tick     uint32
+	nextID   uint32
+
+	server     bool
+	localPeer  core.PeerID
+	serverPeer core.PeerID
+	latest     uint32
+	latestTime float64
+	assembly   snapshotAssembly
+
+	subscriptions []core.NetSubscription
+}
+
+func NewReplicator() *Replicator {
+	r := &Replicator{
+		prefabs:    make(map[string]func() *scene.GameObject),
+		identities: make(map[uint32]*NetworkIdentity),
+		acks:       make(map[core.PeerID]uint32),
+		tickRate:   DefaultTickRate,
+		delay:      DefaultInterpolationDelay,
+		nextID:     1,
+	}
+
+	r.SetName("Replicator")
+	instance.MustAssign(r)
+
+	network.RegisterMessage(func() core.NetMessage { return &welcomeMsg{} })
+	network.RegisterMessage(func() core.NetMessage { return &spawnMsg{} })
+	network.RegisterMessage(func() core.NetMessage { return &despawnMsg{} })
+	network.RegisterMessage(func() core.NetMessage { return &snapshotMsg{} })
+	network.RegisterMessage(func() core.NetMessage { return &ackMsg{} })
+	network.RegisterMessage(func() core.NetMessage { return &commandMsg{} })
+
+	r.subscriptions = []core.NetSubscription{
+		network.Handle(MsgWelcome, r.onWelcome),
+		network.Handle(MsgSpawn, r.onSpawn),
+		network.Handle(MsgDespawn, r.onDespawn),
+		network.Handle(MsgSnapshot, r.onSnapshot),
+		network.Handle(MsgAck, r.onAck),
+		network.Handle(MsgCommand, r.onCommand),
+
+		network.OnConnect(r.onConnect),
+		network.OnDisconnect(r.onDisconnect),
+	}
+
+	return r
+}
+
+// RegisterPrefab registers a factory for objects spawned under name. The
+// factory must return an object with a NetworkIdentity, and must be
+// registered on the server and on every client.
+func (r *Replicator) RegisterPrefab(name string, factory func() *scene.GameObject) {
+	r.prefabs[name] = factory
+}
+
+// SetAuthority sets the hook deciding which peers may send commands to an
+// object. By default only the owner of an object may.
+func (r *Replicator) SetAuthority(fn AuthorityFunc) {
+	r.authority = fn
+}
+
+// TickRate returns the number of snapshots sent per second.
+func (r *Replicator) TickRate() float64 {
+	return r.tickRate
+}
+
+// SetTickRate sets the number of snapshots sent per second. Clients receive
+// the tick rate of the server when they connect.
+func (r *Replicator) SetTickRate(rate float64) {
+	if rate > 0 {
+		r.tickRate = rate
+	}
+}
+
+// InterpolationDelay returns the time in seconds clients render behind the
+// latest snapshot.
+func (r *Replicator) InterpolationDelay() float64 {
+	return r.delay
+}
+
+// SetInterpolationDelay sets the time in seconds clients render behind the
+// latest snapshot. It should span at least two ticks so there is always a
+// pair of snapshots to interpolate between.
+func (r *Replicator) SetInterpolationDelay(delay float64) {
+	r.delay = delay
+}
+
+// IsServer reports if the replicator is running on the server.
+func (r *Replicator) IsServer() bool {
+	return r.server
+}
+
+// LocalPeer returns the ID the server assigned to this client.
+func (r *Replicator) LocalPeer() core.PeerID {
+	return r.localPeer
+}
+
+// Tick returns the current tick. On clients this is the tick of the latest
+// snapshot received.
+func (r *Replicator) Tick() uint32 {
+	if r.server {
+		return r.tick
+	}
+
+	return r.latest
+}
+
+// ServerTick estimates the current server tick, including the fraction of
+// the tick elapsed since the latest snapshot was received.
+func (r *Replicator) ServerTick() float64 {
+	if r.server {
+		return float64(r.tick) + r.accum*r.tickRate
+	}
+
+	return float64(r.latest) + (time.Now()-r.latestTime)*r.tickRate
+}
+
+// InterpolationTick returns the tick clients should render at.
+func (r *Replicator) InterpolationTick() float64 {
+	return r.ServerTick() - r.delay*r.tickRate
+}
+
+// Find returns the spawned object with the network ID.
+func (r *Replicator) Find(netID uint32) *NetworkIdentity {
+	return r.identities[netID]
+}
+
+// Identities returns all spawned objects.
+func (r *Replicator) Identities() []*NetworkIdentity {
+	identities := make([]*NetworkIdentity, 0, len(r.identities))
+	for _, v := range r.identities {
+		identities = append(identities, v)
+	}
+
+	return identities
+}
+
+// Spawn creates an object from a prefab on the server and on all clients.
+// Owner is the peer which has authority over the object, or zero for the
+// server.
+func (r *Replicator) Spawn(prefab string, owner core.PeerID) (*scene.GameObject, error) {
+	if !network.IsServer() {
+		return nil, ErrNotServer(prefab)
+	}
+	r.server = true
+
+	object, identity, err := r.instantiate(prefab)
+	if err != nil {
+		return nil, err
+	}
+
+	identity.netID = r.nextID
+	identity.owner = owner
+	r.nextID++
+
+	if err := r.add(object, identity); err != nil {
+		return nil, err
+	}
+
+	msg := r.spawnMsg(identity)
+	for _, peer := range network.Peers() {
+		r.send(peer, core.NetChannelReliable, msg)
+	}
+
+	return object, nil
+}
+
+// Despawn removes an object spawned on the server from the server and all
+// clients.
+func (r *Replicator) Despawn(object *scene.GameObject) error {
+	if object == nil {
+		return ErrNotSpawned("<nil>")
+	}
+
+	identity := identityOf(object)
+	if identity == nil || identity.replicator != r {
+		return ErrNotSpawned(object.Name())
+	}
+	if !r.server {
+		return ErrNotServer(object.Name())
+	}
+
+	msg := &despawnMsg{netID: identity.netID}
+	for _, peer := range network.Peers() {
+		r.send(peer, core.NetChannelReliable, msg)
+	}
+
+	r.remove(identity)
+
+	return nil
+}
+
+// Update sends a snapshot to every client once per tick.
+func (r *Replicator) Update() {
+	r.server = network.IsServer()
+	if !r.server {
+		return
+	}
+
+	step := 1.0 / r.tickRate
+
+	r.accum += time.Delta()
+	if r.accum < step {
+		return
+	}
+	for r.accum >= step {
+		r.accum -= step
+	}
+
+	r.tick++
+
+	s := r.capture()
+
+	for _, peer := range network.Peers() {
+		for _, part := range splitSnapshot(r.delta(s, r.snapshot(r.acks[peer])), core.UDPMaxPayload) {
+			r.send(peer, core.NetChannelUnreliable, part)
+		}
+	}
+
+	r.history = append(r.history, s)
+	if len(r.history) > historySize {
+		r.history = r.history[1:]
+	}
+}
+
+// Dealloc removes all objects spawned by the replicator, and its handlers
+// from the network system.
+func (r *Replicator) Dealloc() {
+	for _, s := range r.subscriptions {
+		s.Unsubscribe()
+	}
+	r.subscriptions = nil
+
+	r.clear()
+}
+
+func (r *Replicator) capture() *snapshot {
+	s := &snapshot{
+		tick:   r.tick,
+		states: make(map[uint32][][]byte, len(r.identities)),
+	}
+
+	for id, identity := range r.identities {
+		s.states[id] = identity.writeStates()
+	}
+
+	return s
+}
+
+// delta builds a snapshot message containing the objects and components of s
+// which differ from the baseline.
+func (r *Replicator) delta(s, baseline *snapshot) *snapshotMsg {
+	msg := &snapshotMsg{
+		tick: s.tick,
+	}
+
+	var base map[uint32][][]byte
+	if baseline != nil {
+		msg.baseline = baseline.tick
+		base = baseline.states
+	}
+
+	for id, states := range s.states {
+		prev, ok := base[id]
+		if ok && len(prev) != len(states) {
+			prev = nil
+		}
+
+		e := entityDelta{
+			netID:  id,
+			states: make([][]byte, len(states)),
+		}
+
+		changed := false
+		for i, v := range states {
+			if prev == nil || !bytes.Equal(prev[i], v) {
+				e.states[i] = v
+				changed = true
+			}
+		}
+
+		if changed || !ok {
+			msg.entities = append(msg.entities, e)
+		}
+	}
+
+	return msg
+}
+
+func (r *Replicator) snapshot(tick uint32) *snapshot {
+	if tick == 0 {
+		return nil
+	}
+
+	for _, s := range r.history {
+		if s.tick == tick {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (r *Replicator) instantiate(prefab string) (*scene.GameObject, *NetworkIdentity, error) {
+	factory, ok := r.prefabs[prefab]
+	if !ok {
+		return nil, nil, ErrPrefabUnknown(prefab)
+	}
+
+	object := factory()
+
+	identity := identityOf(object)
+	if identity == nil {
+		return nil, nil, ErrPrefabIdentity(prefab)
+	}
+	if n := len(identity.Replicated()); n > maxComponents {
+		return nil, nil, ErrTooManyComponents(n)
+	}
+
+	identity.prefab = prefab
+
+	return object, identity, nil
+}
+
+func (r *Replicator) add(object *scene.GameObject, identity *NetworkIdentity) error {
+	if s := r.GameObject().Scene(); s != nil {
+		if err := s.AddObject(object, nil); err != nil {
+			return err
+		}
+	}
+
+	identity.replicator = r
+	r.identities[identity.netID] = identity
+
+	for _, c := range object.Components() {
+		if n, ok := c.(SpawnReceiver); ok {
+			n.OnNetworkSpawn(identity)
+		}
+	}
+
+	return nil
+}
+
+func (r *Replicator) remove(identity *NetworkIdentity) {
+	object := identity.GameObject()
+
+	for _, c := range object.Components() {
+		if n, ok := c.(SpawnReceiver); ok {
+			n.OnNetworkDespawn(identity)
+		}
+	}
+
+	delete(r.identities, identity.netID)
+	identity.replicator = nil
+
+	if s := object.Scene(); s != nil {
+		if err := s.RemoveObject(object); err != nil {
+			logrus.Warn("[Replication] ", err)
+		}
+	}
+}
+
+func (r *Replicator) clear() {
+	for _, identity := range r.identities {
+		r.remove(identity)
+	}
+
+	r.history = nil
+	r.latest = 0
+	r.assembly.reset()
+}
+
+func (r *Replicator) spawnMsg(identity *NetworkIdentity) *spawnMsg {
+	return &spawnMsg{
+		netID:  identity.netID,
+		prefab: identity.prefab,
+		owner:  identity.owner,
+		tick:   r.tick,
+		states: identity.writeStates(),
+	}
+}
+
+func (r *Replicator) send(peer core.PeerID, channel core.NetChannel, msg core.NetMessage) {
+	if err := network.Send(peer, channel, msg); err != nil {
+		logrus.Warn("[Replication] ", err)
+	}
+}
+
+func (r *Replicator) sendCommand(identity *NetworkIdentity, write func(w *core.NetWriter)) error {
+	w := core.NewNetWriter()
+	write(w)
+
+	if r.server {
+		r.dispatchCommand(0, identity, w.Bytes())
+		return nil
+	}
+
+	return network.Send(r.serverPeer, core.NetChannelReliable, &commandMsg{
+		netID: identity.netID,
+		data:  w.Bytes(),
+	})
+}
+
+func (r *Replicator) dispatchCommand(peer core.PeerID, identity *NetworkIdentity, data []byte) {
+	if r.authority != nil {
+		if !r.authority(peer, identity) {
+			return
+		}
+	} else if identity.owner != peer {
+		return
+	}
+
+	for _, c := range identity.GameObject().Components() {
+		if n, ok := c.(CommandReceiver); ok {
+			n.OnCommand(peer, core.NewNetReader(data))
+		}
+	}
+}
+
+func (r *Replicator) onConnect(peer core.PeerID) {
+	r.server = network.IsServer()
+
+	if !r.server {
+		r.serverPeer = peer
+		return
+	}
+
+	r.acks[peer] = 0
+	r.send(peer, core.NetChannelReliable, &welcomeMsg{peer: peer, tickRate: r.tickRate})
+	for _, identity := range r.identities {
+		r.send(peer, core.NetChannelReliable, r.spawnMsg(identity))
+	}
+}
+
+// onDisconnect despawns the objects owned by a client which left. Clients
+// remove every replicated object when the connection to the server is lost.
+func (r *Replicator) onDisconnect(peer core.PeerID) {
+	if !r.server {
+		if peer == r.serverPeer {
+			r.clear()
+			r.serverPeer = 0
+			r.localPeer = 0
+		}
+		return
+	}
+
+	delete(r.acks, peer)
+	for _, identity := range r.identities {
+		if identity.owner == peer {
+			if err := r.Despawn(identity.GameObject()); err != nil {
+				logrus.Warn("[Replication] ", err)
+			}
+		}
+	}
+}
+
+func (r *Replicator) onWelcome(peer core.PeerID, msg core.NetMessage) {
+	if r.server {
+		return
+	}
+
+	m := msg.(*welcomeMsg)
+	r.localPeer = m.peer
+	r.SetTickRate(m.tickRate)
+}
+
+func (r *Replicator) onSpawn(peer core.PeerID, msg core.NetMessage) {
+	m := msg.(*spawnMsg)
+	if r.server || r.identities[m.netID] != nil {
+		return
+	}
+
+	object, identity, err := r.instantiate(m.prefab)
+	if err != nil {
+		logrus.Warn("[Replication] ", err)
+		return
+	}
+
+	identity.netID = m.netID
+	identity.owner = m.owner
+
+	if err := r.add(object, identity); err != nil {
+		logrus.Warn("[Replication] ", err)
+		return
+	}
+
+	identity.readStates(m.states, m.tick)
+}
+
+func (r *Replicator) onDespawn(peer core.PeerID, msg core.NetMessage) {
+	if r.server {
+		return
+	}
+
+	if identity, ok := r.identities[msg.(*despawnMsg).netID]; ok {
+		r.remove(identity)
+	}
+}
+
+// onSnapshot reconstructs a snapshot from its parts and baseline, and
+// applies it. The snapshot is only acknowledged, and so used as a baseline by
+// the server, if every object in it has been spawned on this client.
+func (r *Replicator) onSnapshot(peer core.PeerID, msg core.NetMessage) {
+	m := msg.(*snapshotMsg)
+	if r.server || m.tick <= r.latest {
+		return
+	}
+
+	if m = r.assembly.add(m); m == nil {
+		return
+	}
+
+	s := &snapshot{
+		tick:   m.tick,
+		states: make(map[uint32][][]byte),
+	}
+
+	if m.baseline != 0 {
+		baseline := r.snapshot(m.baseline)
+		if baseline == nil {
+			return
+		}
+
+		for id, states := range baseline.states {
+			if _, ok := r.identities[id]; ok {
+				s.states[id] = states
+			}
+		}
+	}
+
+	complete := true
+	for _, e := range m.entities {
+		if _, ok := r.identities[e.netID]; !ok {
+			complete = false
+			continue
+		}
+
+		prev := s.states[e.netID]
+		states := make([][]byte, len(e.states))
+		for i, v := range e.states {
+			if v != nil {
+				states[i] = v
+			} else if i < len(prev) {
+				states[i] = prev[i]
+			}
+		}
+
+		s.states[e.netID] = states
+	}
+
+	for id, states := range s.states {
+		r.identities[id].readStates(states, s.tick)
+	}
+
+	r.latest = m.tick
+	r.latestTime = time.Now()
+
+	if !complete {
+		return
+	}
+
+	r.history = append(r.history, s)
+	if len(r.history) > historySize {
+		r.history = r.history[1:]
+	}
+
+	r.send(peer, core.NetChannelUnreliable, &ackMsg{tick: m.tick})
+}
+
+func (r *Replicator) onAck(peer core.PeerID, msg core.NetMessage) {
+	if !r.server {
+		return
+	}
+
+	if tick := msg.(*ackMsg).tick; tick > r.acks[peer] && tick <= r.tick {
+		r.acks[peer] = tick
+	}
+}
+
+func (r *Replicator) onCommand(peer core.PeerID, msg core.NetMessage) {
+	if !r.server {
+		return
+	}
+
+	m := msg.(*commandMsg)
+	if identity, ok := r.identities[m.netID]; ok {
+		r.dispatchCommand(peer, identity, m.data)
+	}
+}
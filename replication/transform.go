@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package replication
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ scene.ScriptComponent = &NetworkTransform{}
+var _ Replicated = &NetworkTransform{}
+
+// transformBufferSize is the number of received states kept for
+// interpolation.
+const transformBufferSize = 32
+
+type transformState struct {
+	tick     uint32
+	position mgl32.Vec3
+	rotation mgl32.Quat
+	scale    mgl32.Vec3
+}
+
+// NetworkTransform replicates the local transform of an object. Clients
+// buffer the received states and interpolate between them, rendering the
+// object InterpolationDelay behind the server.
+type NetworkTransform struct {
+	scene.BaseScriptComponent
+
+	buffer   []transformState
+	identity *NetworkIdentity
+}
+
+func NewNetworkTransform() *NetworkTransform {
+	n := &NetworkTransform{}
+
+	n.SetName("NetworkTransform")
+	instance.MustAssign(n)
+
+	return n
+}
+
+// WriteState writes the position, rotation and scale of the transform.
+func (n *NetworkTransform) WriteState(w *core.NetWriter) {
+	t := n.GetTransform()
+
+	w.WriteVec3(t.Position())
+	w.WriteQuat(t.Rotation())
+	w.WriteVec3(t.Scale())
+}
+
+// ReadState buffers a received state. The first state is applied directly.
+func (n *NetworkTransform) ReadState(r *core.NetReader, tick uint32) {
+	s := transformState{
+		tick:     tick,
+		position: r.ReadVec3(),
+		rotation: r.ReadQuat(),
+		scale:    r.ReadVec3(),
+	}
+	if r.Err() != nil {
+		return
+	}
+
+	if len(n.buffer) == 0 {
+		n.apply(s)
+	} else if tick <= n.buffer[len(n.buffer)-1].tick {
+		return
+	}
+
+	n.buffer = append(n.buffer, s)
+	if len(n.buffer) > transformBufferSize {
+		n.buffer = n.buffer[1:]
+	}
+}
+
+// Update interpolates the transform on clients.
+func (n *NetworkTransform) Update() {
+	if n.identity == nil {
+		n.identity = identityOf(n.GameObject())
+	}
+	if n.identity == nil || !n.identity.Spawned() || n.identity.IsServer() {
+		return
+	}
+	if len(n.buffer) == 0 {
+		return
+	}
+
+	t := n.identity.Replicator().InterpolationTick()
+
+	if t <= float64(n.buffer[0].tick) {
+		n.apply(n.buffer[0])
+		return
+	}
+
+	for i := 0; i < len(n.buffer)-1; i++ {
+		a, b := n.buffer[i], n.buffer[i+1]
+		if t >= float64(b.tick) {
+			continue
+		}
+
+		f := float32((t - float64(a.tick)) / float64(b.tick-a.tick))
+		n.apply(transformState{
+			position: a.position.Add(b.position.Sub(a.position).Mul(f)),
+			rotation: mgl32.QuatSlerp(a.rotation, b.rotation, f),
+			scale:    a.scale.Add(b.scale.Sub(a.scale).Mul(f)),
+		})
+
+		n.buffer = n.buffer[i:]
+		return
+	}
+
+	last := n.buffer[len(n.buffer)-1]
+	n.apply(last)
+	n.buffer = n.buffer[len(n.buffer)-1:]
+}
+
+func (n *NetworkTransform) apply(s transformState) {
+	t := n.GetTransform()
+
+	t.SetPosition(s.position)
+	t.SetRotation(s.rotation)
+	t.SetScale(s.scale)
+}
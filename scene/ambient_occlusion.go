@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+// MaxAmbientOcclusionSamples is the largest number of samples taken per
+// pixel.
+const MaxAmbientOcclusionSamples = 64
+
+// ambientOcclusionUnit is the texture unit the occlusion texture is bound to
+// in the ambient pass.
+const ambientOcclusionUnit = 9
+
+// AmbientOcclusion is a screen space ambient occlusion pass for the deferred
+// render path. It samples a hemisphere around each pixel of the GBuffer,
+// blurs the result, and darkens the ambient light of occluded pixels.
+type AmbientOcclusion struct {
+	shader      graphics.Shader
+	framebuffer graphics.Framebuffer
+	targets     [2]*graphics.AttachmentTexture2D
+	kernel      [MaxAmbientOcclusionSamples]mgl32.Vec3
+	radius      float32
+	bias        float32
+	samples     int32
+}
+
+// NewAmbientOcclusion creates an ambient occlusion pass.
+func NewAmbientOcclusion() *AmbientOcclusion {
+	a := &AmbientOcclusion{
+		shader:  shader.NewShaderUtilsSSAO(),
+		radius:  0.5,
+		bias:    0.025,
+		samples: 16,
+	}
+
+	// Samples are spread over the hemisphere and packed towards the center,
+	// so close geometry occludes more.
+	r := rand.New(rand.NewSource(1))
+	for i := range a.kernel {
+		s := mgl32.Vec3{r.Float32()*2 - 1, r.Float32()*2 - 1, r.Float32()}.Normalize()
+		scale := float32(i) / MaxAmbientOcclusionSamples
+		scale = 0.1 + scale*scale*0.9
+
+		a.kernel[i] = s.Mul(r.Float32() * scale)
+	}
+
+	return a
+}
+
+// Radius returns the world space radius of the sampled hemisphere.
+func (a *AmbientOcclusion) Radius() float32 {
+	return a.radius
+}
+
+// SetRadius sets the world space radius of the sampled hemisphere.
+func (a *AmbientOcclusion) SetRadius(radius float32) {
+	if radius > 0 {
+		a.radius = radius
+	}
+}
+
+// Bias returns the depth bias which prevents surfaces from occluding
+// themselves.
+func (a *AmbientOcclusion) Bias() float32 {
+	return a.bias
+}
+
+// SetBias sets the depth bias which prevents surfaces from occluding
+// themselves.
+func (a *AmbientOcclusion) SetBias(bias float32) {
+	if bias >= 0 {
+		a.bias = bias
+	}
+}
+
+// Samples returns the number of samples taken per pixel.
+func (a *AmbientOcclusion) Samples() int32 {
+	return a.samples
+}
+
+// SetSamples sets the number of samples taken per pixel, up to
+// MaxAmbientOcclusionSamples.
+func (a *AmbientOcclusion) SetSamples(samples int32) {
+	if samples > 0 && samples <= MaxAmbientOcclusionSamples {
+		a.samples = samples
+	}
+}
+
+// Texture returns the blurred occlusion of the last frame. One is fully lit,
+// zero is fully occluded.
+func (a *AmbientOcclusion) Texture() *graphics.Texture2D {
+	if a.targets[0] == nil {
+		return nil
+	}
+
+	return a.targets[0].AttachmentObject()
+}
+
+// render computes the occlusion from the GBuffer of the camera.
+func (a *AmbientOcclusion) render(c *Camera) {
+	size := c.gbuffer.Size()
+	a.setup(size)
+
+	device := graphics.ActiveDevice()
+	mesh := c.meshes[CameraMeshGBuffer]
+
+	a.framebuffer.Bind()
+	a.shader.Bind()
+	mesh.Bind()
+
+	// Pass 1 : Occlusion
+
+	a.shader.SetPass("pass_occlusion")
+	a.shader.SetUniform("f_view", c.ViewMatrix())
+	a.shader.SetUniform("f_projection", c.ProjectionMatrix())
+	a.shader.SetUniform("f_radius", a.radius)
+	a.shader.SetUniform("f_bias", a.bias)
+	a.shader.SetUniform("f_samples", a.samples)
+	for i := int32(0); i < a.samples; i++ {
+		a.shader.SetUniform(fmt.Sprintf("f_kernel[%d]", i), a.kernel[i])
+	}
+
+	a.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
+	device.BindTexture(1, c.gbuffer.AttachmentDepth())
+	device.BindTexture(2, c.gbuffer.Attachment0())
+	device.BindTexture(3, c.gbuffer.Attachment1())
+	mesh.Draw()
+
+	// Pass 2 : Blur
+
+	a.shader.SetPass("pass_blur")
+	a.shader.SetUniform("f_texel", mgl32.Vec2{1 / float32(size.X()), 1 / float32(size.Y())})
+
+	a.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+	device.BindTexture(0, a.targets[1].AttachmentObject())
+	mesh.Draw()
+
+	mesh.Unbind()
+	a.shader.Unbind()
+	a.framebuffer.Unbind()
+}
+
+// setup allocates the occlusion targets, or resizes them to size.
+func (a *AmbientOcclusion) setup(size math.IVec2) {
+	if a.framebuffer != nil {
+		if a.framebuffer.Size() != size {
+			a.framebuffer.SetSize(size)
+		}
+		return
+	}
+
+	a.framebuffer = graphics.NewFramebuffer(size)
+
+	for i := range a.targets {
+		a.targets[i] = graphics.NewAttachmentTexture2D(size, graphics.TextureFormatR8)
+	}
+
+	a.framebuffer.SetAttachment(graphics.AttachmentColor0, a.targets[0])
+	a.framebuffer.SetAttachment(graphics.AttachmentColor1, a.targets[1])
+
+	if err := a.framebuffer.Alloc(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// Vec3Keyframe is a keyframe of a position or scale channel.
+type Vec3Keyframe struct {
+	Time  float64
+	Value mgl32.Vec3
+}
+
+// QuatKeyframe is a keyframe of a rotation channel.
+type QuatKeyframe struct {
+	Time  float64
+	Value mgl32.Quat
+}
+
+// AnimationTrack animates the transform of a single object. Channels without
+// keyframes are left untouched when the track is applied.
+type AnimationTrack struct {
+	// Path is the slash separated path of object names from the animated
+	// object to the target of this track. An empty path targets the animated
+	// object itself.
+	Path string
+
+	Positions []Vec3Keyframe
+	Rotations []QuatKeyframe
+	Scales    []Vec3Keyframe
+}
+
+// AnimationEvent is a named event placed at a specific time in a clip.
+type AnimationEvent struct {
+	Name  string
+	Time  float64
+	Value string
+}
+
+// AnimationEventReceiver is implemented by script components which want to
+// receive the events of clips played by an Animator on the same object.
+type AnimationEventReceiver interface {
+	OnAnimationEvent(clip *AnimationClip, event AnimationEvent)
+}
+
+// RootMotionReceiver is implemented by components which want to consume the
+// root motion extracted by an Animator on the same object, such as a
+// character controller. If no component on the object implements it, root
+// motion is applied to the transform of the object.
+type RootMotionReceiver interface {
+	OnRootMotion(deltaPosition mgl32.Vec3, deltaRotation mgl32.Quat)
+}
+
+// AnimationClip is a set of tracks and events sampled by an Animator.
+type AnimationClip struct {
+	core.BaseObject
+
+	tracks   []*AnimationTrack
+	events   []AnimationEvent
+	rootPath string
+	length   float64
+	loop     bool
+	root     bool
+}
+
+// NewAnimationClip creates a new clip of the given length in seconds.
+func NewAnimationClip(length float64) *AnimationClip {
+	c := &AnimationClip{
+		length: length,
+	}
+
+	c.SetName("AnimationClip")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// Length returns the length of the clip in seconds.
+func (c *AnimationClip) Length() float64 {
+	return c.length
+}
+
+// Loop reports if the clip loops.
+func (c *AnimationClip) Loop() bool {
+	return c.loop
+}
+
+// SetLoop sets if the clip loops.
+func (c *AnimationClip) SetLoop(loop bool) {
+	c.loop = loop
+}
+
+// Tracks returns the tracks of the clip.
+func (c *AnimationClip) Tracks() []*AnimationTrack {
+	return c.tracks
+}
+
+// AddTrack adds a track to the clip.
+func (c *AnimationClip) AddTrack(track *AnimationTrack) {
+	if track == nil {
+		return
+	}
+
+	c.tracks = append(c.tracks, track)
+}
+
+// Track returns the track with the given path, or nil if there is none.
+func (c *AnimationClip) Track(path string) *AnimationTrack {
+	for i := range c.tracks {
+		if c.tracks[i].Path == path {
+			return c.tracks[i]
+		}
+	}
+
+	return nil
+}
+
+// RootMotionPath returns the path of the root track, and if root motion is
+// enabled for the clip.
+func (c *AnimationClip) RootMotionPath() (string, bool) {
+	return c.rootPath, c.root
+}
+
+// SetRootMotionPath enables root motion for the clip, using the track with
+// the given path as the root.
+func (c *AnimationClip) SetRootMotionPath(path string) {
+	c.rootPath = path
+	c.root = true
+}
+
+// ClearRootMotion disables root motion for the clip.
+func (c *AnimationClip) ClearRootMotion() {
+	c.rootPath = ""
+	c.root = false
+}
+
+// RootMotion returns the displacement of the root track between two times.
+// The position delta is horizontal only; vertical motion is left in the pose.
+func (c *AnimationClip) RootMotion(from, to float64) (mgl32.Vec3, mgl32.Quat) {
+	track := c.rootTrack()
+	if track == nil {
+		return mgl32.Vec3{}, mgl32.QuatIdent()
+	}
+
+	var dp mgl32.Vec3
+	if p0, ok := track.SamplePosition(from); ok {
+		p1, _ := track.SamplePosition(to)
+		dp = p1.Sub(p0)
+		dp[1] = 0
+	}
+
+	dr := mgl32.QuatIdent()
+	if r0, ok := track.SampleRotation(from); ok {
+		r1, _ := track.SampleRotation(to)
+		dr = r1.Mul(r0.Inverse()).Normalize()
+	}
+
+	return dp, dr
+}
+
+// Events returns the events of the clip, ordered by time.
+func (c *AnimationClip) Events() []AnimationEvent {
+	return c.events
+}
+
+// AddEvent adds a named event to the clip at the given time.
+func (c *AnimationClip) AddEvent(name string, time float64, value string) {
+	c.events = append(c.events, AnimationEvent{
+		Name:  name,
+		Time:  time,
+		Value: value,
+	})
+
+	sort.SliceStable(c.events, func(i, j int) bool {
+		return c.events[i].Time < c.events[j].Time
+	})
+}
+
+// RemoveEvents removes all events with the given name.
+func (c *AnimationClip) RemoveEvents(name string) {
+	events := c.events[:0]
+	for i := range c.events {
+		if c.events[i].Name != name {
+			events = append(events, c.events[i])
+		}
+	}
+	c.events = events
+}
+
+func (c *AnimationClip) rootTrack() *AnimationTrack {
+	if !c.root {
+		return nil
+	}
+
+	return c.Track(c.rootPath)
+}
+
+// eventsBetween returns the events in the interval (from, to]. If inclusive
+// is set, events at from are included as well.
+func (c *AnimationClip) eventsBetween(from, to float64, inclusive bool) []AnimationEvent {
+	var events []AnimationEvent
+
+	for i := range c.events {
+		t := c.events[i].Time
+		if (t > from || (inclusive && t == from)) && t <= to {
+			events = append(events, c.events[i])
+		}
+	}
+
+	return events
+}
+
+// SamplePosition samples the position channel at the given time.
+func (t *AnimationTrack) SamplePosition(time float64) (mgl32.Vec3, bool) {
+	return sampleVec3(t.Positions, time)
+}
+
+// SampleRotation samples the rotation channel at the given time.
+func (t *AnimationTrack) SampleRotation(time float64) (mgl32.Quat, bool) {
+	n := len(t.Rotations)
+	if n == 0 {
+		return mgl32.QuatIdent(), false
+	}
+
+	i := sort.Search(n, func(i int) bool {
+		return t.Rotations[i].Time > time
+	})
+
+	if i == 0 {
+		return t.Rotations[0].Value, true
+	}
+	if i == n {
+		return t.Rotations[n-1].Value, true
+	}
+
+	a, b := t.Rotations[i-1], t.Rotations[i]
+
+	return mgl32.QuatSlerp(a.Value, b.Value, keyframeFactor(a.Time, b.Time, time)), true
+}
+
+// SampleScale samples the scale channel at the given time.
+func (t *AnimationTrack) SampleScale(time float64) (mgl32.Vec3, bool) {
+	return sampleVec3(t.Scales, time)
+}
+
+// Apply samples the track at the given time and writes the result to the
+// transform.
+func (t *AnimationTrack) Apply(transform Transform, time float64) {
+	if v, ok := t.SamplePosition(time); ok {
+		transform.SetPosition(v)
+	}
+	if v, ok := t.SampleRotation(time); ok {
+		transform.SetRotation(v)
+	}
+	if v, ok := t.SampleScale(time); ok {
+		transform.SetScale(v)
+	}
+}
+
+// applyInPlace is like Apply, but holds the horizontal position and the
+// rotation of the track at their initial values, leaving the motion of the
+// track to be extracted as root motion.
+func (t *AnimationTrack) applyInPlace(transform Transform, time float64) {
+	if v, ok := t.SamplePosition(time); ok {
+		start := t.Positions[0].Value
+		transform.SetPosition(mgl32.Vec3{start.X(), v.Y(), start.Z()})
+	}
+	if len(t.Rotations) != 0 {
+		transform.SetRotation(t.Rotations[0].Value)
+	}
+	if v, ok := t.SampleScale(time); ok {
+		transform.SetScale(v)
+	}
+}
+
+func sampleVec3(keys []Vec3Keyframe, time float64) (mgl32.Vec3, bool) {
+	n := len(keys)
+	if n == 0 {
+		return mgl32.Vec3{}, false
+	}
+
+	i := sort.Search(n, func(i int) bool {
+		return keys[i].Time > time
+	})
+
+	if i == 0 {
+		return keys[0].Value, true
+	}
+	if i == n {
+		return keys[n-1].Value, true
+	}
+
+	a, b := keys[i-1], keys[i]
+	f := keyframeFactor(a.Time, b.Time, time)
+
+	return a.Value.Add(b.Value.Sub(a.Value).Mul(f)), true
+}
+
+func keyframeFactor(a, b, time float64) float32 {
+	if b <= a {
+		return 0
+	}
+
+	return float32((time - a) / (b - a))
+}
@@ -0,0 +1,319 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"math"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ ScriptComponent = &Animator{}
+var _ GraphListener = &Animator{}
+
+// Animator plays an AnimationClip on the object it is attached to. Events of
+// the clip are dispatched to the components of the object which implement
+// AnimationEventReceiver as the clip is sampled.
+type Animator struct {
+	BaseScriptComponent
+
+	clip    *AnimationClip
+	targets map[string]Transform
+	time    float64
+	speed   float64
+
+	playing    bool
+	started    bool
+	rootMotion bool
+}
+
+func NewAnimator() *Animator {
+	a := &Animator{
+		speed: 1.0,
+	}
+
+	a.SetName("Animator")
+	instance.MustAssign(a)
+
+	return a
+}
+
+// Play starts playing the clip from the beginning.
+func (a *Animator) Play(clip *AnimationClip) {
+	a.clip = clip
+	a.targets = nil
+	a.time = 0
+	a.playing = clip != nil
+	a.started = false
+}
+
+// Stop stops playback and rewinds the animator.
+func (a *Animator) Stop() {
+	a.playing = false
+	a.time = 0
+}
+
+// Pause pauses playback.
+func (a *Animator) Pause() {
+	a.playing = false
+}
+
+// Resume resumes a paused animator.
+func (a *Animator) Resume() {
+	a.playing = a.clip != nil
+}
+
+// Playing reports if the animator is playing.
+func (a *Animator) Playing() bool {
+	return a.playing
+}
+
+// Clip returns the current clip.
+func (a *Animator) Clip() *AnimationClip {
+	return a.clip
+}
+
+// Time returns the playback position in seconds.
+func (a *Animator) Time() float64 {
+	return a.time
+}
+
+// SetTime moves the playback position and samples the clip. No events are
+// dispatched.
+func (a *Animator) SetTime(t float64) {
+	if a.clip == nil {
+		return
+	}
+
+	a.time = math.Max(0, math.Min(t, a.clip.length))
+	a.Sample(a.time)
+}
+
+// Speed returns the playback speed multiplier.
+func (a *Animator) Speed() float64 {
+	return a.speed
+}
+
+// SetSpeed sets the playback speed multiplier. Events are only dispatched
+// when playing forwards.
+func (a *Animator) SetSpeed(speed float64) {
+	a.speed = speed
+}
+
+// ApplyRootMotion reports if root motion is extracted from clips.
+func (a *Animator) ApplyRootMotion() bool {
+	return a.rootMotion
+}
+
+// SetApplyRootMotion sets if root motion is extracted from clips which have a
+// root track. Extracted motion is handed to a RootMotionReceiver on the
+// object, or applied to the transform of the object if there is none.
+func (a *Animator) SetApplyRootMotion(rootMotion bool) {
+	a.rootMotion = rootMotion
+}
+
+// Update advances the animator by the scaled frame time.
+func (a *Animator) Update() {
+	if a.playing {
+		a.Step(time.ScaledDelta() * a.speed)
+	}
+}
+
+// Step advances the animator by dt seconds, samples the clip, and dispatches
+// any events which were passed.
+func (a *Animator) Step(dt float64) {
+	if a.clip == nil || !a.playing {
+		return
+	}
+
+	var events []AnimationEvent
+	var segments [][2]float64
+
+	length := a.clip.length
+	from := a.time
+	to := from + dt
+	inclusive := !a.started
+
+	a.started = true
+
+	switch {
+	case dt < 0:
+		if to < 0 {
+			if a.clip.loop && length > 0 {
+				to = length + math.Mod(to, length)
+				segments = append(segments, [2]float64{from, 0}, [2]float64{length, to})
+			} else {
+				to = 0
+				a.playing = false
+			}
+		}
+	case to >= length:
+		events = a.clip.eventsBetween(from, length, inclusive)
+
+		if a.clip.loop && length > 0 {
+			to = math.Mod(to, length)
+			events = append(events, a.clip.eventsBetween(0, to, true)...)
+			segments = append(segments, [2]float64{from, length}, [2]float64{0, to})
+		} else {
+			to = length
+			a.playing = false
+		}
+	default:
+		events = a.clip.eventsBetween(from, to, inclusive)
+	}
+
+	if segments == nil {
+		segments = append(segments, [2]float64{from, to})
+	}
+
+	a.time = to
+	a.Sample(a.time)
+
+	if a.rootMotionActive() {
+		dp := mgl32.Vec3{}
+		dr := mgl32.QuatIdent()
+
+		for _, s := range segments {
+			p, r := a.clip.RootMotion(s[0], s[1])
+			dp = dp.Add(dr.Rotate(p))
+			dr = r.Mul(dr)
+		}
+
+		a.applyRootMotion(dp, dr.Normalize())
+	}
+
+	for i := range events {
+		a.dispatch(events[i])
+	}
+}
+
+// Sample applies all tracks of the clip at the given time.
+func (a *Animator) Sample(t float64) {
+	if a.clip == nil {
+		return
+	}
+
+	root := a.clip.rootTrack()
+	inPlace := a.rootMotionActive()
+
+	for _, track := range a.clip.tracks {
+		transform := a.target(track.Path)
+		if transform == nil {
+			continue
+		}
+
+		if !inPlace || track != root {
+			track.Apply(transform, t)
+		} else if track.Path != "" {
+			// A root track targeting the animated object itself is carried
+			// entirely by the extracted root motion.
+			track.applyInPlace(transform, t)
+		}
+	}
+}
+
+// OnSceneGraphUpdate is called when the SceneGraph has been updated.
+func (a *Animator) OnSceneGraphUpdate() {
+	a.targets = nil
+}
+
+func (a *Animator) rootMotionActive() bool {
+	return a.rootMotion && a.clip != nil && a.clip.rootTrack() != nil
+}
+
+func (a *Animator) applyRootMotion(deltaPosition mgl32.Vec3, deltaRotation mgl32.Quat) {
+	if a.GameObject() == nil {
+		return
+	}
+
+	for _, c := range a.GameObject().Components() {
+		if r, ok := c.(RootMotionReceiver); ok {
+			r.OnRootMotion(deltaPosition, deltaRotation)
+			return
+		}
+	}
+
+	t := a.GetTransform()
+	t.SetPosition(t.Position().Add(t.Rotation().Rotate(deltaPosition)))
+	t.SetRotation(t.Rotation().Mul(deltaRotation).Normalize())
+}
+
+func (a *Animator) dispatch(event AnimationEvent) {
+	if a.GameObject() == nil {
+		return
+	}
+
+	for _, c := range a.GameObject().Components() {
+		if r, ok := c.(AnimationEventReceiver); ok {
+			r.OnAnimationEvent(a.clip, event)
+		}
+	}
+}
+
+func (a *Animator) target(path string) Transform {
+	if a.targets == nil {
+		a.targets = make(map[string]Transform)
+	}
+
+	if t, ok := a.targets[path]; ok {
+		return t
+	}
+
+	var transform Transform
+	if o := findObjectPath(a.GameObject(), path); o != nil {
+		transform = o.Transform()
+	}
+	a.targets[path] = transform
+
+	return transform
+}
+
+// findObjectPath finds a descendant of an object by a slash separated path of
+// object names.
+func findObjectPath(object *GameObject, path string) *GameObject {
+	if object == nil || path == "" {
+		return object
+	}
+
+	for _, name := range strings.Split(path, "/") {
+		var next *GameObject
+
+		for _, c := range object.children {
+			if c.Name() == name {
+				next = c
+				break
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+		object = next
+	}
+
+	return object
+}
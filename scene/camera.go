@@ -23,18 +23,24 @@ SOFTWARE.
 package scene
 
 import (
-	"github.com/go-gl/gl/v4.3-core/gl"
+	"image"
+	"math"
+	"sort"
+
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/graphics"
+	fmath "github.com/haakenlabs/arc/pkg/math"
 	"github.com/haakenlabs/arc/system/asset/shader"
 	"github.com/haakenlabs/arc/system/input"
 	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
 	"github.com/haakenlabs/arc/system/window"
 )
 
 var _ GraphListener = &Camera{}
+var _ GraphEventListener = &Camera{}
 var _ ScriptComponent = &Camera{}
 
 type RenderPath int
@@ -53,6 +59,9 @@ const (
 	CameraTextureHDR1
 	CameraTextureDepth
 	CameraTextureNormals
+	CameraTextureVelocity
+	CameraTexturePosition
+	CameraTextureMaterial
 )
 
 type CameraShader int
@@ -62,6 +71,9 @@ const (
 	CameraShaderDeferred
 	CameraShaderNormals
 	CameraShaderSkybox
+	CameraShaderShadow
+	CameraShaderOcclusion
+	CameraShaderDecal
 )
 
 type CameraMesh int
@@ -70,6 +82,28 @@ const (
 	CameraMeshEffect CameraMesh = iota
 	CameraMeshGBuffer
 	CameraMeshSkybox
+	CameraMeshLightSphere
+	CameraMeshLightCone
+	CameraMeshBounds
+)
+
+// lightVolumeScale enlarges light volumes so the flat faces of the meshes
+// enclose the whole range of the light.
+const lightVolumeScale = 1.05
+
+// lightVolumeMaxAngle is the largest half angle of a spot light drawn with a
+// cone. Wider spot lights are drawn with a sphere.
+const lightVolumeMaxAngle = 1.4
+
+// resizeDelay is the time in seconds the window size has to stay unchanged
+// before cameras resize their render targets, so dragging the window border
+// does not reallocate them every frame.
+const resizeDelay = 0.25
+
+// Bounds of the render scale of a camera.
+const (
+	MinRenderScale = 0.5
+	MaxRenderScale = 2.0
 )
 
 type ClearMode int
@@ -84,61 +118,165 @@ const (
 type Camera struct {
 	BaseScriptComponent
 
-	textures         map[CameraTexture]*graphics.Texture2D
-	shaders          map[CameraShader]*graphics.Shader
-	meshes           map[CameraMesh]*graphics.Mesh
-	effects          []Effect
-	deferredCache    []Drawable
-	forwardCache     []Drawable
-	framebuffer      *graphics.Framebuffer
-	gbuffer          *graphics.GBuffer
-	projectionMatrix mgl32.Mat4
-	viewMatrix       mgl32.Mat4
-	normalMatrix     mgl32.Mat3
-	clearColor       core.Color
-	clearMode        ClearMode
-	renderPath       RenderPath
-	activeRenderPath RenderPath
-	aspectRatio      float32
-	fov              float32
-	nearClip         float32
-	farClip          float32
-	effectPass       int32
-	effectActiveType EffectType
-	hdr              bool
-	orthographic     bool
+	textures            map[CameraTexture]*graphics.Texture2D
+	shaders             map[CameraShader]graphics.Shader
+	meshes              map[CameraMesh]*graphics.Mesh
+	effects             []Effect
+	disabledEffects     map[Effect]bool
+	renderPasses        map[RenderStage][]RenderPass
+	graph               *graphics.RenderGraph
+	eyes                [2]stereoEye
+	stereoTexture       graphics.Texture
+	ambientOcclusion    *AmbientOcclusion
+	deferredCache       []Drawable
+	forwardCache        []Drawable
+	deferredVisible     []Drawable
+	forwardVisible      []Drawable
+	drawablePaths       map[Drawable]bool
+	grouped             map[Drawable]bool
+	rebuildCache        bool
+	pruneCache          bool
+	indexed             []Bounded
+	lodGroups           []*LODGroup
+	lodSelected         []Drawable
+	decals              []*Decal
+	occlusion           map[Drawable]*occlusionState
+	occlusionCandidates []Drawable
+	lights              []*Light
+	activeLight         *Light
+	activeLightIndex    int32
+	cameraBuffer        *graphics.UniformBuffer
+	lightBuffer         *graphics.UniformBuffer
+	uniforms            graphics.Std140
+	framebuffer         graphics.Framebuffer
+	msaa                graphics.Framebuffer
+	gbuffer             *graphics.GBuffer
+	commands            *graphics.CommandBuffer
+	projectionMatrix    mgl32.Mat4
+	viewMatrix          mgl32.Mat4
+	prevViewProj        mgl32.Mat4
+	normalMatrix        mgl32.Mat3
+	clearColor          core.Color
+	clearMode           ClearMode
+	renderPath          RenderPath
+	activeRenderPath    RenderPath
+	aspectRatio         float32
+	fov                 float32
+	nearClip            float32
+	farClip             float32
+	orthographicSize    float32
+	effectPass          int32
+	samples             int32
+	effectActiveType    EffectType
+	cullingMask         LayerMask
+	stereoMode          StereoMode
+	activeEye           Eye
+	eyeSeparation       float32
+	convergence         float32
+	renderScale         float32
+	viewport            core.Rect
+	targetSize          fmath.IVec2
+	resizeTime          float64
+	resizePending       bool
+	depth               float32
+	enabled             bool
+	hdr                 bool
+	orthographic        bool
+	centered            bool
+	hasPrevious         bool
+	culling             bool
+	occlusionCulling    bool
+	offscreen           bool
+}
+
+// ClearMode returns how the camera clears its target before rendering.
+func (c *Camera) ClearMode() ClearMode {
+	return c.clearMode
 }
 
 func (c *Camera) SetClearMode(mode ClearMode) {
 	c.clearMode = mode
 }
 
+// ClearColor returns the color the camera clears to with ClearModeColor.
+func (c *Camera) ClearColor() core.Color {
+	return c.clearColor
+}
+
+// SetClearColor sets the color the camera clears to with ClearModeColor.
+func (c *Camera) SetClearColor(color core.Color) {
+	c.clearColor = color
+}
+
 func (c *Camera) Render() {
-	c.startRender()
+	device := graphics.ActiveDevice()
+
+	device.PushDebugGroup(c.Name())
+
+	if !c.hasPrevious {
+		c.prevViewProj = c.projectionMatrix.Mul4(c.viewMatrix)
+		c.hasPrevious = true
+	}
 
-	c.renderDeferred()
-	c.renderForward()
-	//c.renderNormals()
-	c.renderEffects()
+	if c.stereoMode != StereoModeNone {
+		c.renderStereo()
+		device.PopDebugGroup()
+		return
+	}
+
+	c.cull()
+	c.uploadUniforms()
+
+	if err := c.graph.Execute(); err != nil {
+		panic(err)
+	}
 
-	c.endRender()
+	c.prevViewProj = c.projectionMatrix.Mul4(c.viewMatrix)
+
+	device.PopDebugGroup()
 }
 
 func (c *Camera) startRender() {
 	c.framebuffer.Bind()
 
 	if c.hdr {
-		c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT1})
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
 	} else {
-		c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT0})
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+	}
+
+	if c.msaa != nil {
+		c.msaa.Bind()
 	}
 
 	c.clearBackground()
 }
 
+// resolve resolves the multisampled framebuffer into the framebuffer of the
+// camera, so the effect chain works on a regular texture.
+func (c *Camera) resolve() {
+	if c.msaa == nil {
+		return
+	}
+
+	graphics.ResolveFramebuffer(c.msaa, c.framebuffer, graphics.AttachmentColor0)
+	c.msaa.Unbind()
+}
+
 func (c *Camera) endRender() {
 	graphics.UnbindCurrentFramebuffer()
-	graphics.BlitFramebuffers(c.framebuffer, nil, gl.COLOR_ATTACHMENT0)
+
+	if c.stereoMode != StereoModeNone {
+		c.presentEye()
+		return
+	}
+	if c.offscreen {
+		return
+	}
+
+	r := c.PixelRect()
+	graphics.BlitFramebuffersRect(c.framebuffer, nil, graphics.AttachmentColor0,
+		int32(r.Left()), int32(r.Top()), int32(r.Width()), int32(r.Height()))
 }
 
 func (c *Camera) clearBackground() {
@@ -146,14 +284,14 @@ func (c *Camera) clearBackground() {
 		return
 	}
 	if c.clearMode == ClearModeDepth {
-		c.framebuffer.ClearBufferFlags(gl.DEPTH_BUFFER_BIT)
+		graphics.ActiveDevice().Clear(graphics.ClearDepth)
 		return
 	}
 
 	if c.clearMode == ClearModeColor {
-		gl.ClearColor(c.clearColor.Elem())
+		graphics.ActiveDevice().SetClearColor(c.clearColor)
 		c.framebuffer.ClearBuffers()
-		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+		graphics.ActiveDevice().SetClearColor(core.ColorBlack)
 	} else if c.clearMode == ClearModeSkybox {
 		c.framebuffer.ClearBuffers()
 
@@ -164,9 +302,7 @@ func (c *Camera) clearBackground() {
 
 		c.meshes[CameraMeshSkybox].Bind()
 		c.shaders[CameraShaderSkybox].Bind()
-		skybox.Specular().ActivateTexture(gl.TEXTURE0)
-		c.shaders[CameraShaderSkybox].SetUniform("v_view_matrix", c.ViewMatrix())
-		c.shaders[CameraShaderSkybox].SetUniform("v_projection_matrix", c.ProjectionMatrix())
+		graphics.ActiveDevice().BindTexture(0, skybox.Specular())
 		c.meshes[CameraMeshSkybox].Draw()
 		c.shaders[CameraShaderSkybox].Unbind()
 		c.meshes[CameraMeshSkybox].Unbind()
@@ -181,6 +317,12 @@ func (c *Camera) ViewMatrix() mgl32.Mat4 {
 	return c.viewMatrix
 }
 
+// PreviousViewProjection returns the view projection matrix the camera
+// rendered the last frame with. It is used to compute motion vectors.
+func (c *Camera) PreviousViewProjection() mgl32.Mat4 {
+	return c.prevViewProj
+}
+
 func (c *Camera) NormalMatrix() mgl32.Mat3 {
 	return c.normalMatrix
 }
@@ -206,11 +348,64 @@ func (c *Camera) SetNormalMatrix(m mgl32.Mat3) {
 }
 
 func (c *Camera) UpdateMatrices() {
-	if c.orthographic {
-		c.SetProjectionMatrix(mgl32.Ortho2D(0, float32(window.Resolution().X()), float32(window.Resolution().Y()), 0))
-	} else {
-		c.SetProjectionMatrix(mgl32.Perspective(c.fov, c.aspectRatio, c.nearClip, c.farClip))
+	c.SetProjectionMatrix(c.projection(c.nearClip, c.farClip))
+}
+
+// projection returns the projection matrix of the camera for the given clip
+// planes.
+func (c *Camera) projection(near, far float32) mgl32.Mat4 {
+	if !c.orthographic {
+		return mgl32.Perspective(c.fov, c.aspectRatio, near, far)
+	}
+
+	h := c.orthographicSize
+	w := h * c.aspectRatio
+
+	if c.centered {
+		return mgl32.Ortho(-w, w, -h, h, near, far)
+	}
+
+	return mgl32.Ortho(0, 2*w, 0, 2*h, near, far)
+}
+
+// Orthographic reports if the camera uses an orthographic projection.
+func (c *Camera) Orthographic() bool {
+	return c.orthographic
+}
+
+// SetOrthographic switches between orthographic and perspective projection.
+func (c *Camera) SetOrthographic(orthographic bool) {
+	c.orthographic = orthographic
+	c.UpdateMatrices()
+}
+
+// OrthographicSize returns half the height of the orthographic view volume in
+// world units.
+func (c *Camera) OrthographicSize() float32 {
+	return c.orthographicSize
+}
+
+// SetOrthographicSize sets half the height of the orthographic view volume in
+// world units. The width follows from the aspect ratio.
+func (c *Camera) SetOrthographicSize(size float32) {
+	if size <= 0 {
+		return
 	}
+
+	c.orthographicSize = size
+	c.UpdateMatrices()
+}
+
+// Centered reports if the orthographic view volume is centered on the camera.
+func (c *Camera) Centered() bool {
+	return c.centered
+}
+
+// SetCentered sets if the orthographic view volume is centered on the camera.
+// If not, the camera is at the bottom left corner of the view.
+func (c *Camera) SetCentered(centered bool) {
+	c.centered = centered
+	c.UpdateMatrices()
 }
 
 func (c *Camera) AspectRatio() float32 {
@@ -231,319 +426,1617 @@ func (c *Camera) FarClip() float32 {
 
 func (c *Camera) SetFov(fov float32) {
 	c.fov = fov
+	c.UpdateMatrices()
+}
+
+// SetNearClip sets the distance of the near clip plane.
+func (c *Camera) SetNearClip(near float32) {
+	c.nearClip = near
+	c.UpdateMatrices()
+}
+
+// SetFarClip sets the distance of the far clip plane.
+func (c *Camera) SetFarClip(far float32) {
+	c.farClip = far
+	c.UpdateMatrices()
 }
 
 func (c *Camera) CameraPosition() mgl32.Vec3 {
 	return c.GetTransform().Position()
 }
 
+// Look returns the rotation of the camera.
 func (c *Camera) Look() mgl32.Quat {
-	return mgl32.Quat{}
+	return c.GetTransform().Rotation()
 }
 
+// LookDirection returns the direction the camera is facing. Cameras look down
+// their negative Z axis.
 func (c *Camera) LookDirection() mgl32.Vec3 {
-	return mgl32.Vec3{}
+	return c.Look().Rotate(mgl32.Vec3{0, 0, -1}).Normalize()
+}
+
+// LookAt rotates the camera to face target and updates the view matrix.
+func (c *Camera) LookAt(target mgl32.Vec3) {
+	eye := c.GetTransform().Position()
+	if target.ApproxEqual(eye) {
+		return
+	}
+
+	up := mgl32.Vec3{0, 1, 0}
+	if d := target.Sub(eye).Normalize(); math.Abs(float64(d.Dot(up))) > 0.999 {
+		up = mgl32.Vec3{0, 0, -1}
+	}
+
+	view := mgl32.LookAtV(eye, target, up)
+
+	c.GetTransform().SetRotation(mgl32.Mat4ToQuat(view).Inverse())
+	c.SetViewMatrix(view)
 }
 
 func (c *Camera) HDR() bool {
 	return c.hdr
 }
 
-func (c *Camera) AddEffect(effect Effect) {
-	c.effects = append(c.effects, effect)
+// Samples returns the number of MSAA samples per pixel, or zero if the camera
+// does not use multisampling.
+func (c *Camera) Samples() int32 {
+	return c.samples
 }
 
-func (c *Camera) OnSceneGraphUpdate() {
-	c.deferredCache = c.deferredCache[:0]
-	c.forwardCache = c.forwardCache[:0]
+// Depth returns the render order of the camera. Cameras with a lower depth
+// are rendered first.
+func (c *Camera) Depth() float32 {
+	return c.depth
+}
 
-	var drawables []Drawable
+// SetDepth sets the render order of the camera.
+func (c *Camera) SetDepth(depth float32) {
+	c.depth = depth
+}
 
-	components := c.GameObject().Scene().Components()
-	for i := range components {
-		if r, ok := components[i].(Drawable); ok {
-			drawables = append(drawables, r)
-		}
-	}
+// Enabled reports if the camera is rendered by the scene.
+func (c *Camera) Enabled() bool {
+	return c.enabled
+}
 
-	switch c.renderPath {
-	case RenderPathForward:
-		c.forwardCache = drawables
-	case RenderPathDeferred:
-		for i := range drawables {
-			if drawables[i].SupportsDeferred() {
-				c.deferredCache = append(c.deferredCache, drawables[i])
-			} else {
-				c.forwardCache = append(c.forwardCache, drawables[i])
-			}
-		}
+// SetEnabled enables or disables rendering of the camera.
+func (c *Camera) SetEnabled(enable bool) {
+	c.enabled = enable
+}
+
+// Offscreen reports if the camera keeps its image in OutputTexture instead
+// of presenting it to the window.
+func (c *Camera) Offscreen() bool {
+	return c.offscreen
+}
+
+// SetOffscreen sets if the camera keeps its image in OutputTexture instead of
+// presenting it to the window.
+func (c *Camera) SetOffscreen(offscreen bool) {
+	c.offscreen = offscreen
+}
+
+// OutputTexture returns the texture holding the final image of the camera.
+func (c *Camera) OutputTexture() *graphics.Texture2D {
+	return c.textures[CameraTextureLDR0]
+}
+
+// Capture reads the final image of the camera from the last frame.
+func (c *Camera) Capture() *image.RGBA {
+	return graphics.CaptureFramebuffer(c.framebuffer, graphics.AttachmentColor0)
+}
+
+// CaptureHDR reads the HDR image of the camera from the last frame as RGBA
+// float values, row by row from the top. The image is read after the HDR
+// effects, before tonemapping. It returns nil if the camera is not HDR.
+func (c *Camera) CaptureHDR() ([]float32, fmath.IVec2) {
+	if !c.hdr {
+		return nil, fmath.IVec2{}
 	}
+
+	return graphics.CaptureFramebufferHDR(c.framebuffer, graphics.AttachmentColor1)
 }
 
-func (c *Camera) setupPipeline() {
-	size := window.Resolution()
+// ViewportRect returns the region of the window the camera renders to, in
+// normalized coordinates with the origin at the bottom left.
+func (c *Camera) ViewportRect() core.Rect {
+	return c.viewport
+}
 
-	c.framebuffer = graphics.NewFramebuffer(size)
+// SetViewportRect sets the region of the window the camera renders to, in
+// normalized coordinates with the origin at the bottom left. The default
+// covers the whole window.
+func (c *Camera) SetViewportRect(rect core.Rect) {
+	c.viewport = rect
+	c.Resize()
+}
 
-	c.meshes[CameraMeshEffect] = graphics.NewMeshQuad()
-	c.meshes[CameraMeshSkybox] = graphics.NewMeshQuadBack()
+// PixelRect returns the viewport rect of the camera in window pixels.
+func (c *Camera) PixelRect() core.Rect {
+	res := window.Resolution()
+	w, h := float32(res.X()), float32(res.Y())
 
-	c.shaders[CameraShaderCopy] = shader.NewShaderUtilsCopy()
-	c.shaders[CameraShaderSkybox] = shader.NewShaderUtilsSkybox()
-	// FIXME: Replace with real shader.
-	c.shaders[CameraShaderNormals] = shader.NewShaderUtilsCopy()
+	x := c.viewport.Left() * w
+	y := c.viewport.Top() * h
+	width := fmath.Max32(c.viewport.Width()*w, 1)
+	height := fmath.Max32(c.viewport.Height()*h, 1)
 
-	c.textures[CameraTextureLDR0] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultColor)
-	c.textures[CameraTextureLDR1] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultColor)
-	c.textures[CameraTextureDepth] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultDepth)
-	c.textures[CameraTextureNormals] = graphics.NewTexture2D(size, graphics.TextureFormatRGBA16)
+	return core.NewRect(mgl32.Vec2{x, y}, mgl32.Vec2{width, height})
+}
 
-	if c.hdr {
-		c.textures[CameraTextureHDR0] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultHDRColor)
-		c.textures[CameraTextureHDR1] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultHDRColor)
-	}
+// WorldToScreenPoint projects a world space point into window pixels, with
+// the origin at the top left like input.MousePosition. Z is the depth of the
+// point, from 0 at the near clip plane to 1 at the far clip plane.
+func (c *Camera) WorldToScreenPoint(point mgl32.Vec3) mgl32.Vec3 {
+	r := c.PixelRect()
 
-	for k := range c.textures {
-		c.textures[k].Alloc()
-	}
+	p := mgl32.Project(point, c.viewMatrix, c.projectionMatrix,
+		int(r.Left()), int(r.Top()), int(r.Width()), int(r.Height()))
+	p[1] = float32(window.Resolution().Y()) - p[1]
 
-	c.framebuffer.SetAttachment(gl.COLOR_ATTACHMENT0, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureLDR0], false))
-	c.framebuffer.SetAttachment(gl.COLOR_ATTACHMENT2, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureLDR1], false))
-	c.framebuffer.SetAttachment(gl.COLOR_ATTACHMENT4, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureNormals], false))
-	c.framebuffer.SetAttachment(gl.DEPTH_ATTACHMENT, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureDepth], false))
+	return p
+}
 
-	if c.hdr {
-		c.framebuffer.SetAttachment(gl.COLOR_ATTACHMENT1, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureHDR0], false))
-		c.framebuffer.SetAttachment(gl.COLOR_ATTACHMENT3, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureHDR1], false))
-	}
+// ScreenToWorldPoint returns the world space point under a point in window
+// pixels. Z selects the depth, from 0 at the near clip plane to 1 at the far
+// clip plane.
+func (c *Camera) ScreenToWorldPoint(point mgl32.Vec3) (mgl32.Vec3, error) {
+	r := c.PixelRect()
 
-	if err := c.framebuffer.Alloc(); err != nil {
-		panic(err)
-	}
+	point[1] = float32(window.Resolution().Y()) - point[1]
 
-	if c.renderPath == RenderPathDeferred {
-		c.meshes[CameraMeshGBuffer] = graphics.NewMeshQuad()
-		// FIXME: Get from scene's environment settings.
-		c.shaders[CameraShaderDeferred] = shader.DefaultShader()
+	return mgl32.UnProject(point, c.viewMatrix, c.projectionMatrix,
+		int(r.Left()), int(r.Top()), int(r.Width()), int(r.Height()))
+}
 
-		depthAttachment := c.framebuffer.GetAttachment(gl.DEPTH_ATTACHMENT).(*graphics.AttachmentTexture2D)
-		c.gbuffer = graphics.NewGBuffer(size, depthAttachment, c.hdr)
+// ScreenPointToRay returns the ray from the near clip plane through a point in
+// window pixels, for picking objects under the mouse.
+func (c *Camera) ScreenPointToRay(point mgl32.Vec2) (fmath.Ray, error) {
+	near, err := c.ScreenToWorldPoint(point.Vec3(0))
+	if err != nil {
+		return fmath.Ray{}, err
+	}
 
-		if err := c.gbuffer.Alloc(); err != nil {
-			panic(err)
-		}
+	far, err := c.ScreenToWorldPoint(point.Vec3(1))
+	if err != nil {
+		return fmath.Ray{}, err
 	}
+
+	return fmath.NewRay(near, far.Sub(near)), nil
 }
 
-func (c *Camera) renderDeferred() {
-	if c.renderPath != RenderPathDeferred {
-		return
-	}
+// RenderScale returns the size of the render targets of the camera relative
+// to its viewport.
+func (c *Camera) RenderScale() float32 {
+	return c.renderScale
+}
 
-	if c.shaders[CameraShaderDeferred] == nil {
+// SetRenderScale sets the size of the render targets of the camera relative
+// to its viewport, between MinRenderScale and MaxRenderScale. The image is
+// scaled to the viewport when it is presented. Scales below one trade quality
+// for speed on high resolution displays, scales above one supersample the
+// image.
+func (c *Camera) SetRenderScale(scale float32) {
+	scale = fmath.Clamp32(scale, MinRenderScale, MaxRenderScale)
+	if scale == c.renderScale {
 		return
 	}
 
-	skybox := c.GameObject().Environment().Skybox
+	c.renderScale = scale
+	c.Resize()
+}
 
-	c.activeRenderPath = RenderPathDeferred
+// pixelSize returns the size of the render targets of the camera.
+func (c *Camera) pixelSize() fmath.IVec2 {
+	size := c.eyeSize(c.windowSize())
 
-	// Pass 1 : Geometry
+	return fmath.IVec2{
+		int32(fmath.Max32(float32(size.X())*c.renderScale, 1)),
+		int32(fmath.Max32(float32(size.Y())*c.renderScale, 1)),
+	}
+}
 
-	c.gbuffer.Bind()
-	c.gbuffer.ClearBuffers()
+// TargetSize returns the fixed size of the camera in pixels, or a zero size
+// if the camera follows its viewport.
+func (c *Camera) TargetSize() fmath.IVec2 {
+	return c.targetSize
+}
+
+// SetTargetSize sets a fixed size of the camera in pixels, independent of the
+// window, for offscreen cameras. A zero size makes the camera follow its
+// viewport again.
+func (c *Camera) SetTargetSize(size fmath.IVec2) {
+	c.targetSize = size
+	c.Resize()
+}
 
-	for i := range c.deferredCache {
-		c.deferredCache[i].Draw(c)
+// windowSize returns the size of the viewport of the camera in pixels. In
+// side by side stereo mode it holds both eyes.
+func (c *Camera) windowSize() fmath.IVec2 {
+	if c.targetSize.X() > 0 && c.targetSize.Y() > 0 {
+		return c.targetSize
 	}
-	c.gbuffer.Unbind()
 
-	// Pass 2 : Ambient Lighting
+	r := c.PixelRect()
+
+	return fmath.IVec2{int32(r.Width()), int32(r.Height())}
+}
 
-	c.shaders[CameraShaderDeferred].Bind()
-	c.shaders[CameraShaderDeferred].SetSubroutine(graphics.ShaderComponentFragment, "deferred_pass_ambient")
-	c.shaders[CameraShaderDeferred].SetUniform("v_model_matrix", mgl32.Ident4())
-	c.shaders[CameraShaderDeferred].SetUniform("v_view_matrix", mgl32.Ident4())
-	c.shaders[CameraShaderDeferred].SetUniform("v_projection_matrix", mgl32.Ident4())
-	c.shaders[CameraShaderDeferred].SetUniform("f_camera", c.GetTransform().Position())
-	c.shaders[CameraShaderDeferred].SetUniform("f_dimensions", c.gbuffer.Size())
+// AmbientOcclusion returns the ambient occlusion pass of the camera, or nil
+// if it has none.
+func (c *Camera) AmbientOcclusion() *AmbientOcclusion {
+	return c.ambientOcclusion
+}
 
-	gl.DepthMask(false)
+// SetAmbientOcclusion sets the ambient occlusion pass of the camera. Set it
+// to nil to disable ambient occlusion. It is only used by the deferred render
+// path.
+func (c *Camera) SetAmbientOcclusion(ao *AmbientOcclusion) {
+	c.ambientOcclusion = ao
+}
 
-	c.meshes[CameraMeshGBuffer].Bind()
-	c.gbuffer.Attachment0().ActivateTexture(gl.TEXTURE0)
-	c.gbuffer.Attachment1().ActivateTexture(gl.TEXTURE1)
-	c.gbuffer.AttachmentDepth().ActivateTexture(gl.TEXTURE2)
+// AddRenderPass adds a custom render pass to a stage of the render sequence.
+// Passes of a stage are executed in the order they were added.
+func (c *Camera) AddRenderPass(stage RenderStage, pass RenderPass) {
+	pass.Setup()
 
-	if skybox != nil {
-		skybox.Specular().ActivateTexture(gl.TEXTURE3)
-		skybox.Irradiance().ActivateTexture(gl.TEXTURE4)
-	}
+	c.renderPasses[stage] = append(c.renderPasses[stage], pass)
+}
 
-	c.meshes[CameraMeshGBuffer].Draw()
+// RemoveRenderPass removes a custom render pass from all stages.
+func (c *Camera) RemoveRenderPass(pass RenderPass) {
+	removed := false
 
-	c.meshes[CameraMeshGBuffer].Unbind()
-	c.shaders[CameraShaderDeferred].Unbind()
+	for stage, passes := range c.renderPasses {
+		for i := range passes {
+			if passes[i] == pass {
+				c.renderPasses[stage] = append(passes[:i], passes[i+1:]...)
+				removed = true
+				break
+			}
+		}
+	}
 
-	gl.DepthMask(true)
+	if removed {
+		pass.Cleanup()
+	}
 }
 
-func (c *Camera) renderForward() {
-	c.activeRenderPath = RenderPathForward
+// RenderPasses returns the custom render passes of a stage.
+func (c *Camera) RenderPasses(stage RenderStage) []RenderPass {
+	return c.renderPasses[stage]
+}
 
-	// TODO: For each light?
+func (c *Camera) executeRenderPasses(stage RenderStage) {
+	passes := c.renderPasses[stage]
 
-	for i := range c.forwardCache {
-		c.forwardCache[i].Draw(c)
+	for i := range passes {
+		passes[i].Execute(c)
 	}
 }
 
-func (c *Camera) renderNormals() {
-	c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT4})
-	c.framebuffer.ClearBufferFlags(gl.COLOR_BUFFER_BIT)
-	c.shaders[CameraShaderNormals].Bind()
+// AddEffect appends an effect to the end of the effect chain.
+func (c *Camera) AddEffect(effect Effect) {
+	c.effects = append(c.effects, effect)
+}
 
-	gl.DepthFunc(gl.LEQUAL)
-	for i := range c.forwardCache {
-		c.forwardCache[i].DrawShader(c.shaders[CameraShaderNormals], c)
+// InsertEffect inserts an effect into the effect chain at index. The index is
+// clamped to the bounds of the chain.
+func (c *Camera) InsertEffect(index int, effect Effect) {
+	if index < 0 {
+		index = 0
 	}
-	for i := range c.deferredCache {
-		c.deferredCache[i].DrawShader(c.shaders[CameraShaderNormals], c)
+	if index > len(c.effects) {
+		index = len(c.effects)
 	}
-	gl.DepthFunc(gl.LESS)
 
-	c.shaders[CameraShaderNormals].Unbind()
+	c.effects = append(c.effects, nil)
+	copy(c.effects[index+1:], c.effects[index:])
+	c.effects[index] = effect
 }
 
-func (c *Camera) renderEffects() {
-	if len(c.effects) == 0 {
+// RemoveEffect removes an effect from the effect chain.
+func (c *Camera) RemoveEffect(effect Effect) {
+	idx := c.effectIndex(effect)
+	if idx == -1 {
 		return
 	}
 
-	gl.DepthMask(false)
-	gl.Disable(gl.DEPTH_TEST)
+	c.effects = append(c.effects[:idx], c.effects[idx+1:]...)
+	delete(c.disabledEffects, effect)
+}
 
-	if c.hdr {
-		c.effectActiveType = EffectTypeHDR
+// MoveEffect moves an effect of the effect chain to index. The index is
+// clamped to the bounds of the chain.
+func (c *Camera) MoveEffect(effect Effect, index int) {
+	idx := c.effectIndex(effect)
+	if idx == -1 {
+		return
+	}
 
-		for i := range c.effects {
-			if c.effects[i].Type() == EffectTypeTonemapper {
-				c.effectActiveType = EffectTypeTonemapper
+	c.effects = append(c.effects[:idx], c.effects[idx+1:]...)
+	c.InsertEffect(index, effect)
+}
 
-				c.startEffectPass()
-				c.effects[i].Render(c)
-				c.endEffectPass()
+// Effects returns the effect chain of the camera in render order.
+func (c *Camera) Effects() []Effect {
+	return c.effects
+}
 
-				c.effectActiveType = EffectTypeLDR
+// EffectEnabled reports whether an effect of the effect chain is rendered.
+func (c *Camera) EffectEnabled(effect Effect) bool {
+	return c.effectIndex(effect) != -1 && !c.disabledEffects[effect]
+}
 
-				continue
-			}
+// SetEffectEnabled enables or disables an effect without removing it from
+// the effect chain. Disabled effects are skipped when rendering.
+func (c *Camera) SetEffectEnabled(effect Effect, enable bool) {
+	if c.effectIndex(effect) == -1 {
+		return
+	}
 
-			c.startEffectPass()
-			c.effects[i].Render(c)
-			c.endEffectPass()
-		}
+	if enable {
+		delete(c.disabledEffects, effect)
 	} else {
-		c.effectActiveType = EffectTypeLDR
-		for i := range c.effects {
-			c.startEffectPass()
-			c.effects[i].Render(c)
-			c.endEffectPass()
-		}
+		c.disabledEffects[effect] = true
 	}
-
-	gl.Enable(gl.DEPTH_TEST)
-	gl.DepthMask(true)
 }
 
-func (c *Camera) EffectPass() {
-	if c.effectActiveType == EffectTypeHDR {
-		if c.effectPass%2 == 1 {
-			c.textures[CameraTextureHDR1].ActivateTexture(gl.TEXTURE0)
-			c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT1})
-
-		} else {
-			c.textures[CameraTextureHDR0].ActivateTexture(gl.TEXTURE0)
-			c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT3})
-		}
-	} else if c.effectActiveType == EffectTypeLDR {
-		if c.effectPass%2 == 1 {
-			c.textures[CameraTextureLDR1].ActivateTexture(gl.TEXTURE0)
-			c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT0})
-		} else {
-			c.textures[CameraTextureLDR0].ActivateTexture(gl.TEXTURE0)
-			c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT2})
+func (c *Camera) effectIndex(effect Effect) int {
+	for i := range c.effects {
+		if c.effects[i] == effect {
+			return i
 		}
 	}
 
-	c.meshes[CameraMeshEffect].Bind()
-	c.meshes[CameraMeshEffect].Draw()
-	c.meshes[CameraMeshEffect].Unbind()
-
-	c.effectPass++
+	return -1
 }
 
-func (c *Camera) startEffectPass() {
-	c.effectPass = 0
-
-	if c.effectActiveType == EffectTypeTonemapper {
-		c.textures[CameraTextureHDR0].ActivateTexture(gl.TEXTURE0)
-		c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT0})
-	}
+// Lights returns the lights of the scene.
+func (c *Camera) Lights() []*Light {
+	return c.lights
 }
 
-func (c *Camera) endEffectPass() {
-	if c.hdr {
-		c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT1})
-	} else {
-		c.framebuffer.ApplyDrawBuffers([]uint32{gl.COLOR_ATTACHMENT0})
-	}
+// ActiveLight returns the light being drawn, or nil if drawables are not
+// being lit.
+func (c *Camera) ActiveLight() *Light {
+	return c.activeLight
+}
 
-	if c.effectActiveType == EffectTypeTonemapper {
+// SetLightUniforms sets the uniforms of the active light on a bound shader.
+func (c *Camera) SetLightUniforms(shader graphics.Shader) {
+	if c.activeLight == nil {
+		shader.SetUniform("f_light_index", lightNone)
 		return
 	}
 
-	if c.effectPass%2 == 1 {
-		return
+	c.activeLight.SetUniforms(shader, c.activeLightIndex)
+}
+
+// uploadUniforms uploads the camera matrices and the lights shared by all
+// draw calls of a render to the uniform buffers of the camera, and binds them.
+func (c *Camera) uploadUniforms() {
+	if c.cameraBuffer == nil {
+		c.cameraBuffer = graphics.NewUniformBuffer(graphics.UniformBindingCamera)
+		c.lightBuffer = graphics.NewUniformBuffer(graphics.UniformBindingLights)
 	}
 
-	c.shaders[CameraShaderCopy].Bind()
-	c.shaders[CameraShaderCopy].SetSubroutine(graphics.ShaderComponentFragment, "pass_0")
+	c.uniforms.Reset()
+	c.uniforms.Mat4(c.viewMatrix)
+	c.uniforms.Mat4(c.projectionMatrix)
+	c.uniforms.Mat4(c.prevViewProj)
+	c.uniforms.Vec3(c.CameraPosition())
+	c.uniforms.Pad()
+
+	c.cameraBuffer.Upload(&c.uniforms)
+	c.cameraBuffer.Bind()
+
+	// The whole array is uploaded, shaders index it with f_light_index.
+	c.uniforms.Reset()
+	for i := 0; i < MaxLights; i++ {
+		if i < len(c.lights) {
+			c.lights[i].writeUniforms(&c.uniforms)
+			continue
+		}
 
-	if c.effectActiveType == EffectTypeHDR {
-		c.textures[CameraTextureHDR1].ActivateTexture(gl.TEXTURE0)
-	} else {
-		c.textures[CameraTextureLDR1].ActivateTexture(gl.TEXTURE0)
+		for j := 0; j < 4; j++ {
+			c.uniforms.Vec4(mgl32.Vec4{})
+		}
 	}
 
-	c.meshes[CameraMeshEffect].Bind()
-	c.meshes[CameraMeshEffect].Draw()
-	c.meshes[CameraMeshEffect].Unbind()
-	c.shaders[CameraShaderCopy].Unbind()
+	c.lightBuffer.Upload(&c.uniforms)
+	c.lightBuffer.Bind()
 }
 
-func NewCamera(renderPath RenderPath, hdr bool) *Camera {
-	c := &Camera{
-		hdr:           hdr,
-		renderPath:    renderPath,
-		meshes:        make(map[CameraMesh]*graphics.Mesh),
-		shaders:       make(map[CameraShader]*graphics.Shader),
-		textures:      make(map[CameraTexture]*graphics.Texture2D),
-		effects:       []Effect{},
-		deferredCache: []Drawable{},
-		forwardCache:  []Drawable{},
-		fov:           1.309,
-		nearClip:      0.01,
-		farClip:       100000.0,
-		aspectRatio:   window.AspectRatio(),
-		clearColor:    core.ColorBlack,
+// OnSceneGraphEvent updates the drawable caches of the camera for a change
+// of the graph. Changes to lights and LOD groups, or to the camera itself,
+// rebuild the caches on the next graph update.
+func (c *Camera) OnSceneGraphEvent(event GraphEvent) {
+	if c.rebuildCache {
+		return
 	}
 
-	c.SetName("Camera")
-	instance.MustAssign(c)
-
-	c.setupPipeline()
-	c.UpdateMatrices()
+	added := false
+	switch event.Type {
+	case GraphObjectAdded, GraphComponentAdded:
+		added = true
+	case GraphObjectRemoved, GraphComponentRemoved:
+	case GraphComponentChanged:
+		for _, v := range event.Components {
+			if _, ok := v.(*LODGroup); ok {
+				c.rebuildCache = true
+			}
+		}
+		return
+	default:
+		return
+	}
+
+	for _, v := range event.Components {
+		switch t := v.(type) {
+		case *Camera:
+			if t == c {
+				c.rebuildCache = true
+			}
+		case *LODGroup, *Light:
+			c.rebuildCache = true
+		case *Decal:
+			if added {
+				c.decals = append(c.decals, t)
+			} else {
+				c.decals = removeDecal(c.decals, t)
+			}
+		}
+
+		if c.rebuildCache {
+			return
+		}
+
+		if d, ok := v.(Drawable); ok && !c.grouped[d] {
+			if added {
+				c.addDrawable(d)
+			} else {
+				c.removeDrawable(d)
+			}
+		}
+	}
+}
+
+func (c *Camera) OnSceneGraphUpdate() {
+	if !c.rebuildCache {
+		if c.pruneCache {
+			c.pruneCache = false
+			c.pruneOcclusion()
+		}
+		return
+	}
+	c.rebuildCache = false
+	c.pruneCache = false
+
+	c.deferredCache = c.deferredCache[:0]
+	c.forwardCache = c.forwardCache[:0]
+	c.lights = c.lights[:0]
+	c.lodGroups = c.lodGroups[:0]
+	c.decals = c.decals[:0]
+
+	var drawables []Drawable
+
+	// Renderers of LOD groups are drawn through their group.
+	clear(c.grouped)
+
+	components := c.GameObject().Scene().Components()
+	for i := range components {
+		if g, ok := components[i].(*LODGroup); ok {
+			c.lodGroups = append(c.lodGroups, g)
+
+			for _, lod := range g.LODs() {
+				for _, r := range lod.Renderers {
+					c.grouped[r] = true
+				}
+			}
+		}
+	}
+
+	for i := range components {
+		if r, ok := components[i].(Drawable); ok && !c.grouped[r] {
+			drawables = append(drawables, r)
+		}
+		if l, ok := components[i].(*Light); ok && len(c.lights) < MaxLights {
+			c.lights = append(c.lights, l)
+		}
+		if d, ok := components[i].(*Decal); ok {
+			c.decals = append(c.decals, d)
+		}
+	}
+
+	clear(c.drawablePaths)
+
+	for i := range drawables {
+		c.addDrawable(drawables[i])
+	}
+
+	c.pruneOcclusion()
+}
+
+// addDrawable adds a drawable to the cache of its render path.
+func (c *Camera) addDrawable(d Drawable) {
+	if _, ok := c.drawablePaths[d]; ok {
+		return
+	}
+
+	deferred := c.deferredDrawable(d)
+	if deferred {
+		c.deferredCache = append(c.deferredCache, d)
+	} else {
+		c.forwardCache = append(c.forwardCache, d)
+	}
+	c.drawablePaths[d] = deferred
+}
+
+// removeDrawable removes a drawable from the cache of its render path. Its
+// occlusion state is released on the next graph update.
+func (c *Camera) removeDrawable(d Drawable) {
+	deferred, ok := c.drawablePaths[d]
+	if !ok {
+		return
+	}
+
+	if deferred {
+		c.deferredCache = removeDrawable(c.deferredCache, d)
+	} else {
+		c.forwardCache = removeDrawable(c.forwardCache, d)
+	}
+	delete(c.drawablePaths, d)
+	c.pruneCache = true
+}
+
+// removeDrawable removes a drawable from a list, keeping the order of the
+// others.
+func removeDrawable(drawables []Drawable, d Drawable) []Drawable {
+	for i := range drawables {
+		if drawables[i] == d {
+			return append(drawables[:i], drawables[i+1:]...)
+		}
+	}
+
+	return drawables
+}
+
+// removeDecal removes a decal from a list, keeping the order of the others.
+func removeDecal(decals []*Decal, d *Decal) []*Decal {
+	for i := range decals {
+		if decals[i] == d {
+			return append(decals[:i], decals[i+1:]...)
+		}
+	}
+
+	return decals
+}
+
+// deferredDrawable reports if a drawable is drawn by the deferred path of the
+// camera. Transparent drawables always use the forward path.
+func (c *Camera) deferredDrawable(d Drawable) bool {
+	return c.renderPath == RenderPathDeferred && d.SupportsDeferred() && drawableQueue(d) != RenderQueueTransparent
+}
+
+func (c *Camera) setupPipeline() {
+	size := c.pixelSize()
+
+	c.framebuffer = graphics.NewFramebuffer(size)
+
+	c.meshes[CameraMeshEffect] = graphics.NewMeshQuad()
+	c.meshes[CameraMeshSkybox] = graphics.NewMeshQuadBack()
+
+	c.shaders[CameraShaderCopy] = shader.NewShaderUtilsCopy()
+	c.shaders[CameraShaderSkybox] = shader.NewShaderUtilsSkybox()
+	c.shaders[CameraShaderShadow] = shader.NewShaderUtilsShadow()
+	c.shaders[CameraShaderNormals] = shader.NewShaderUtilsNormals()
+
+	c.textures[CameraTextureLDR0] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultColor)
+	c.textures[CameraTextureLDR1] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultColor)
+	c.textures[CameraTextureDepth] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultDepth)
+	c.textures[CameraTextureNormals] = graphics.NewTexture2D(size, graphics.TextureFormatRGBA16)
+
+	if c.hdr {
+		c.textures[CameraTextureHDR0] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultHDRColor)
+		c.textures[CameraTextureHDR1] = graphics.NewTexture2D(size, graphics.TextureFormatDefaultHDRColor)
+	}
+
+	for k := range c.textures {
+		c.textures[k].Alloc()
+	}
+
+	c.framebuffer.SetAttachment(graphics.AttachmentColor0, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureLDR0], false))
+	c.framebuffer.SetAttachment(graphics.AttachmentColor2, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureLDR1], false))
+	if c.hasNormals() {
+		c.framebuffer.SetAttachment(graphics.AttachmentColor4, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureNormals], false))
+	}
+	c.framebuffer.SetAttachment(graphics.AttachmentDepth, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureDepth], false))
+
+	if c.hdr {
+		c.framebuffer.SetAttachment(graphics.AttachmentColor1, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureHDR0], false))
+		c.framebuffer.SetAttachment(graphics.AttachmentColor3, graphics.NewAttachmentTexture2DFrom(c.textures[CameraTextureHDR1], false))
+	}
+
+	if err := c.framebuffer.Alloc(); err != nil {
+		panic(err)
+	}
+
+	if c.samples > 1 {
+		format := graphics.TextureFormatDefaultColor
+		if c.hdr {
+			format = graphics.TextureFormatDefaultHDRColor
+		}
+
+		c.msaa = graphics.NewFramebuffer(size)
+		c.msaa.SetAttachment(graphics.AttachmentColor0, graphics.NewAttachmentRenderBufferMultisample(size, format, c.samples))
+		c.msaa.SetAttachment(graphics.AttachmentDepth, graphics.NewAttachmentRenderBufferMultisample(size, graphics.TextureFormatDefaultDepth, c.samples))
+		c.msaa.SetDrawBuffers([]uint32{graphics.AttachmentColor0})
+
+		if err := c.msaa.Alloc(); err != nil {
+			panic(err)
+		}
+	}
+
+	if c.renderPath == RenderPathDeferred {
+		c.meshes[CameraMeshGBuffer] = graphics.NewMeshQuad()
+		c.meshes[CameraMeshLightSphere] = graphics.NewMeshSphere(16, 12)
+		c.meshes[CameraMeshLightCone] = graphics.NewMeshCone(16)
+		c.meshes[CameraMeshBounds] = graphics.NewMeshCube()
+		c.shaders[CameraShaderDecal] = shader.NewShaderUtilsDecal()
+		// FIXME: Get from scene's environment settings.
+		c.shaders[CameraShaderDeferred] = shader.DefaultShader()
+
+		depthAttachment := c.framebuffer.GetAttachment(graphics.AttachmentDepth).(*graphics.AttachmentTexture2D)
+		c.gbuffer = graphics.NewGBuffer(size, depthAttachment, c.hdr)
+
+		if err := c.gbuffer.Alloc(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// renderLightVolumes adds point and spot lights to the deferred lighting
+// result. Each light draws the back faces of a mesh enclosing its range, so
+// only the pixels in front of the back of the volume are shaded.
+func (c *Camera) renderLightVolumes() {
+	s := c.shaders[CameraShaderDeferred]
+
+	device := graphics.ActiveDevice()
+	prev := device.PipelineState()
+	state := prev
+	state.DepthTest = true
+	state.DepthWrite = false
+	state.DepthFunc = graphics.CompareGreaterEqual
+	state.Cull = graphics.CullFront
+	state.Blend = graphics.BlendAdditive
+
+	drawn := false
+
+	for i, l := range c.lights {
+		if l.Type() == LightDirectional {
+			continue
+		}
+
+		if !drawn {
+			device.SetPipelineState(state)
+			s.SetPass("deferred_pass_light")
+			drawn = true
+		}
+
+		mesh, model := c.lightVolume(l)
+
+		l.SetUniforms(s, int32(i))
+		s.SetUniform("v_model_matrix", model)
+
+		mesh.Bind()
+		mesh.Draw()
+		mesh.Unbind()
+	}
+
+	if drawn {
+		device.SetPipelineState(prev)
+	}
+}
+
+// lightVolume returns the mesh and model matrix of the volume of a point or
+// spot light.
+func (c *Camera) lightVolume(l *Light) (*graphics.Mesh, mgl32.Mat4) {
+	r := l.Range() * lightVolumeScale
+	translate := mgl32.Translate3D(l.Position().Elem())
+
+	angle := l.SpotAngle() / 2
+	if l.Type() != LightSpot || angle > lightVolumeMaxAngle {
+		return c.meshes[CameraMeshLightSphere], translate.Mul4(mgl32.Scale3D(r, r, r))
+	}
+
+	radius := r * float32(math.Tan(float64(angle)))
+	rotate := mgl32.QuatBetweenVectors(mgl32.Vec3{0, 0, -1}, l.Direction().Normalize()).Mat4()
+
+	return c.meshes[CameraMeshLightCone], translate.Mul4(rotate).Mul4(mgl32.Scale3D(radius, radius, r))
+}
+
+// renderDecals draws the decals into the albedo of the bound GBuffer. Like
+// light volumes, each decal draws the back faces of its box. The GBuffer is
+// read from a copy taken before the first decal is drawn.
+func (c *Camera) renderDecals() {
+	var frustum *fmath.Frustum
+	if c.culling {
+		f := c.Frustum()
+		frustum = &f
+	}
+
+	var visible []*Decal
+	for _, d := range c.decals {
+		if d.Texture() == nil || d.GameObject() == nil || !d.GameObject().Active() {
+			continue
+		}
+		if !c.cullingMask.Contains(d.GameObject().Layer()) {
+			continue
+		}
+		if frustum != nil && !frustum.IntersectsAABB(d.Bounds()) {
+			continue
+		}
+
+		visible = append(visible, d)
+	}
+
+	if len(visible) == 0 {
+		return
+	}
+
+	source := c.gbuffer.CopyAttachment1()
+
+	device := graphics.ActiveDevice()
+	prev := device.PipelineState()
+	state := prev
+	state.DepthTest = true
+	state.DepthWrite = false
+	state.DepthFunc = graphics.CompareGreaterEqual
+	state.Cull = graphics.CullFront
+	state.Blend = graphics.BlendNone
+
+	device.SetPipelineState(state)
+	c.gbuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
+
+	s := c.shaders[CameraShaderDecal]
+	mesh := c.meshes[CameraMeshBounds]
+
+	s.Bind()
+
+	device.BindTexture(0, c.gbuffer.Attachment0())
+	device.BindTexture(1, source)
+	device.BindTexture(2, c.gbuffer.AttachmentDepth())
+
+	mesh.Bind()
+
+	for _, d := range visible {
+		model := d.GetTransform().ActiveMatrix()
+
+		s.SetUniform("v_model_matrix", model)
+		s.SetUniform("f_inverse_model_matrix", model.Inv())
+		s.SetUniform("f_direction", d.Direction())
+		s.SetUniform("f_color", d.Color().Vec4())
+		s.SetUniform("f_normal_threshold", d.NormalThreshold())
+		device.BindTexture(3, d.Texture())
+
+		mesh.Draw()
+	}
+
+	mesh.Unbind()
+	s.Unbind()
+
+	c.gbuffer.ApplyDrawBuffers([]uint32{
+		graphics.AttachmentColor0, graphics.AttachmentColor1, graphics.AttachmentColor2, graphics.AttachmentColor3,
+	})
+	device.SetPipelineState(prev)
+}
+
+func (c *Camera) renderDeferred() {
+	if c.renderPath != RenderPathDeferred {
+		return
+	}
+
+	if c.shaders[CameraShaderDeferred] == nil {
+		return
+	}
+
+	skybox := c.GameObject().Environment().Skybox
+
+	c.activeRenderPath = RenderPathDeferred
+
+	// Pass 1 : Geometry
+
+	c.gbuffer.Bind()
+	c.gbuffer.ClearBuffers()
+
+	for i := range c.deferredVisible {
+		c.deferredVisible[i].Draw(c)
+	}
+
+	// Pass 2 : Decals
+
+	c.renderDecals()
+
+	c.gbuffer.Unbind()
+
+	// Pass 3 : Ambient Occlusion
+
+	ao := c.ambientOcclusion
+	if ao != nil {
+		ao.render(c)
+	}
+
+	// Pass 4 : Ambient Lighting
+
+	s := c.shaders[CameraShaderDeferred]
+	mesh := c.meshes[CameraMeshGBuffer]
+	cb := c.commands
+	cb.Reset()
+
+	state := graphics.ActiveDevice().PipelineState()
+	state.DepthWrite = false
+	cb.SetPipelineState(state)
+
+	cb.BindShader(s, "deferred_pass_ambient")
+	cb.SetUniform(s, "v_model_matrix", mgl32.Ident4())
+	cb.SetUniform(s, "v_screen_space", true)
+	cb.SetUniform(s, "f_dimensions", c.gbuffer.Size())
+	cb.SetUniform(s, "f_ao_enabled", ao != nil)
+	cb.SetUniform(s, "f_ibl_enabled", skybox != nil && skybox.BRDF() != nil)
+
+	cb.BindMesh(mesh)
+	cb.BindTexture(0, c.gbuffer.Attachment0())
+	cb.BindTexture(1, c.gbuffer.Attachment1())
+	cb.BindTexture(2, c.gbuffer.AttachmentDepth())
+	cb.BindTexture(gbufferEmissiveUnit, c.gbuffer.Attachment2())
+
+	if skybox != nil {
+		cb.BindTexture(3, skybox.Specular())
+		cb.BindTexture(4, skybox.Irradiance())
+		cb.SetUniform(s, "f_environment_levels", specularLevels(skybox.Specular()))
+
+		if skybox.BRDF() != nil {
+			cb.BindTexture(skyboxBRDFUnit, skybox.BRDF())
+		}
+	}
+	if ao != nil {
+		cb.BindTexture(ambientOcclusionUnit, ao.Texture())
+	}
+
+	cb.DrawMesh(mesh)
+
+	// Pass 5 : Directional Lights
+
+	if len(c.lights) != 0 {
+		state.Blend = graphics.BlendAdditive
+		cb.SetPipelineState(state)
+		cb.BindShader(s, "deferred_pass_light")
+
+		for i := range c.lights {
+			if c.lights[i].Type() != LightDirectional {
+				continue
+			}
+
+			l, index := c.lights[i], int32(i)
+			cb.Callback(func() { l.SetUniforms(s, index) })
+			cb.DrawMesh(mesh)
+		}
+
+		state.Blend = graphics.BlendNone
+		cb.SetPipelineState(state)
+	}
+
+	graphics.ActiveDevice().Submit(cb)
+
+	mesh.Unbind()
+	c.shaders[CameraShaderDeferred].SetUniform("v_screen_space", false)
+
+	// Pass 6 : Light Volumes
+
+	c.renderLightVolumes()
+
+	c.shaders[CameraShaderDeferred].Unbind()
+
+	graphics.ActiveDevice().SetDepthWrite(true)
+}
+
+// Culling reports if drawables outside of the view frustum are skipped.
+func (c *Camera) Culling() bool {
+	return c.culling
+}
+
+// SetCulling enables or disables frustum culling. Disabling it draws every
+// drawable, which is useful for debugging.
+func (c *Camera) SetCulling(enable bool) {
+	c.culling = enable
+}
+
+// CullingMask returns the layers drawn by the camera.
+func (c *Camera) CullingMask() LayerMask {
+	return c.cullingMask
+}
+
+// SetCullingMask sets the layers drawn by the camera. Drawables whose game
+// object is in a layer outside of the mask are skipped.
+func (c *Camera) SetCullingMask(mask LayerMask) {
+	c.cullingMask = mask
+}
+
+// Frustum returns the view frustum of the camera.
+func (c *Camera) Frustum() fmath.Frustum {
+	return fmath.NewFrustum(c.projectionMatrix.Mul4(c.viewMatrix))
+}
+
+// cull selects the drawables of this frame which are in the culling mask and
+// inside the view frustum.
+func (c *Camera) cull() {
+	var frustum *fmath.Frustum
+	if c.culling {
+		f := c.Frustum()
+		frustum = &f
+	}
+
+	if index := c.spatialIndex(); frustum != nil && index != nil {
+		c.cullIndexed(index, *frustum)
+	} else {
+		c.deferredVisible = cullDrawables(frustum, c.cullingMask, c.deferredCache, c.deferredVisible[:0])
+		c.forwardVisible = cullDrawables(frustum, c.cullingMask, c.forwardCache, c.forwardVisible[:0])
+	}
+
+	c.selectLODs(frustum)
+
+	if c.occlusionCulling {
+		c.cullOccluded()
+	}
+
+	c.sortForward()
+}
+
+// spatialIndex returns the spatial index of the scene of the camera, or nil
+// if the camera is not in a loaded scene.
+func (c *Camera) spatialIndex() *SpatialIndex {
+	if g := c.GameObject(); g != nil && g.scene != nil {
+		return g.scene.spatial
+	}
+
+	return nil
+}
+
+// cullIndexed selects the visible drawables through the spatial index of the
+// scene, so drawables far outside the frustum are never visited.
+func (c *Camera) cullIndexed(index *SpatialIndex, frustum fmath.Frustum) {
+	c.deferredVisible = c.deferredVisible[:0]
+	c.forwardVisible = c.forwardVisible[:0]
+
+	c.indexed = index.QueryFrustum(frustum, c.indexed[:0])
+	for _, b := range c.indexed {
+		d, ok := b.(Drawable)
+		if !ok {
+			continue
+		}
+
+		deferred, cached := c.drawablePaths[d]
+		if !cached || !c.cullingMask.Contains(drawableLayer(d)) {
+			continue
+		}
+
+		if deferred {
+			c.deferredVisible = append(c.deferredVisible, d)
+		} else {
+			c.forwardVisible = append(c.forwardVisible, d)
+		}
+	}
+}
+
+// sortForward orders the visible forward drawables by render queue, and the
+// transparent drawables back to front by the view depth of their bounds.
+func (c *Camera) sortForward() {
+	visible := c.forwardVisible
+
+	sort.SliceStable(visible, func(i, j int) bool {
+		qi, qj := drawableQueue(visible[i]), drawableQueue(visible[j])
+		if qi != qj {
+			return qi < qj
+		}
+		if qi != RenderQueueTransparent {
+			return false
+		}
+
+		return c.viewDepth(visible[i]) > c.viewDepth(visible[j])
+	})
+}
+
+// viewDepth returns the distance of the center of the bounds of a drawable in
+// front of the camera. Drawables with infinite bounds are at the far clip.
+func (c *Camera) viewDepth(d Drawable) float32 {
+	b := d.Bounds()
+	if b == fmath.InfiniteAABB() {
+		return c.farClip
+	}
+
+	center := b.Min.Add(b.Max).Mul(0.5)
+
+	return -c.viewMatrix.Mul4x1(center.Vec4(1)).Z()
+}
+
+// selectLODs selects the levels of the LOD groups drawn this frame, and adds
+// their visible renderers to the visible drawables.
+func (c *Camera) selectLODs(frustum *fmath.Frustum) {
+	c.lodSelected = c.lodSelected[:0]
+
+	for _, g := range c.lodGroups {
+		if o := g.GameObject(); o == nil || !o.Active() {
+			continue
+		}
+
+		c.lodSelected = g.selectLOD(c, c.lodSelected)
+	}
+
+	for _, d := range c.lodSelected {
+		if !c.cullingMask.Contains(drawableLayer(d)) {
+			continue
+		}
+		if frustum != nil && !frustum.IntersectsAABB(d.Bounds()) {
+			continue
+		}
+
+		if c.deferredDrawable(d) {
+			c.deferredVisible = append(c.deferredVisible, d)
+		} else {
+			c.forwardVisible = append(c.forwardVisible, d)
+		}
+	}
+}
+
+func cullDrawables(frustum *fmath.Frustum, mask LayerMask, drawables, visible []Drawable) []Drawable {
+	for i := range drawables {
+		if !mask.Contains(drawableLayer(drawables[i])) {
+			continue
+		}
+		if frustum != nil && !frustum.IntersectsAABB(drawables[i].Bounds()) {
+			continue
+		}
+
+		visible = append(visible, drawables[i])
+	}
+
+	return visible
+}
+
+// renderShadows renders the shadow maps of the lights which cast shadows.
+func (c *Camera) renderShadows() {
+	device := graphics.ActiveDevice()
+	prev := device.PipelineState()
+	state := graphics.DefaultPipelineState()
+	state.Cull = graphics.CullFront
+
+	s := c.shaders[CameraShaderShadow]
+
+	for _, l := range c.lights {
+		if !l.CastsShadows() {
+			continue
+		}
+
+		sm := l.ShadowMap()
+		far := c.farClip
+		if l.shadowDistance < far {
+			far = l.shadowDistance
+		}
+
+		l.shadowView = c.ViewMatrix()
+
+		near := c.nearClip
+		for i, split := range l.cascadeSplits(near, far) {
+			l.shadowMatrices[i] = shadowMatrix(c, l.Direction(), near, split, sm.Resolution())
+			l.shadowFar[i] = split
+			near = split
+
+			device.SetPipelineState(state)
+			sm.Begin(int32(i))
+			s.Bind()
+			s.SetUniform("v_light_matrix", l.shadowMatrices[i])
+
+			for j := range c.deferredCache {
+				c.deferredCache[j].DrawShader(s, c)
+			}
+			for j := range c.forwardCache {
+				if isLit(c.forwardCache[j]) {
+					c.forwardCache[j].DrawShader(s, c)
+				}
+			}
+			for j := range c.lodSelected {
+				c.lodSelected[j].DrawShader(s, c)
+			}
+
+			s.Unbind()
+			sm.End()
+		}
+	}
+
+	device.SetPipelineState(prev)
+}
+
+// Resources of the render graph of a camera.
+const (
+	graphShadows    graphics.RenderGraphResource = "shadows"
+	graphGBuffer    graphics.RenderGraphResource = "gbuffer"
+	graphColor      graphics.RenderGraphResource = "color"
+	graphDepth      graphics.RenderGraphResource = "depth"
+	graphNormals    graphics.RenderGraphResource = "normals"
+	graphBackbuffer graphics.RenderGraphResource = "backbuffer"
+)
+
+// RenderGraph returns the render graph of the camera.
+func (c *Camera) RenderGraph() *graphics.RenderGraph {
+	return c.graph
+}
+
+// GPUTimings returns the GPU time of each pass of the camera, such as the
+// deferred, forward, effects and present passes. It is empty unless GPU
+// timing is enabled with graphics.SetGPUTiming.
+func (c *Camera) GPUTimings() []graphics.RenderGraphTiming {
+	return c.graph.Timings()
+}
+
+// buildRenderGraph declares the render sequence of the camera. The targets
+// are owned by the camera, so they are imported into the graph without
+// textures.
+func (c *Camera) buildRenderGraph() {
+	g := graphics.NewRenderGraph()
+
+	for _, r := range []graphics.RenderGraphResource{graphShadows, graphGBuffer, graphColor, graphDepth, graphNormals, graphBackbuffer} {
+		g.Import(r, nil)
+	}
+	g.SetOutput(graphBackbuffer)
+
+	stage := func(name string, s RenderStage, reads, writes []graphics.RenderGraphResource) *graphics.RenderGraphPass {
+		return &graphics.RenderGraphPass{
+			Name:    name,
+			Reads:   reads,
+			Writes:  writes,
+			Enabled: func() bool { return len(c.renderPasses[s]) != 0 },
+			Execute: func(*graphics.RenderGraph) { c.executeRenderPasses(s) },
+		}
+	}
+
+	colorDepth := []graphics.RenderGraphResource{graphColor, graphDepth}
+
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Shadows",
+		Writes:  []graphics.RenderGraphResource{graphShadows},
+		Enabled: func() bool { return c.activeEye == EyeLeft },
+		Execute: func(*graphics.RenderGraph) { c.renderShadows() },
+	})
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Clear",
+		Writes:  colorDepth,
+		Execute: func(*graphics.RenderGraph) { c.startRender() },
+	})
+	g.AddPass(stage("BeforeOpaque", RenderStageBeforeOpaque, colorDepth, colorDepth))
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Deferred",
+		Reads:   []graphics.RenderGraphResource{graphShadows},
+		Writes:  []graphics.RenderGraphResource{graphGBuffer, graphColor, graphDepth},
+		Enabled: func() bool { return c.renderPath == RenderPathDeferred },
+		Execute: func(*graphics.RenderGraph) { c.renderDeferred() },
+	})
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:   "Forward",
+		Reads:  []graphics.RenderGraphResource{graphShadows},
+		Writes: colorDepth,
+		Execute: func(*graphics.RenderGraph) {
+			opaque, _ := c.splitForward()
+			c.renderForward(opaque)
+		},
+	})
+	g.AddPass(stage("AfterOpaque", RenderStageAfterOpaque, colorDepth, colorDepth))
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Occlusion",
+		Reads:   []graphics.RenderGraphResource{graphDepth},
+		Enabled: func() bool { return c.occlusionCulling && c.activeEye == EyeLeft },
+		Execute: func(*graphics.RenderGraph) { c.renderOcclusion() },
+	})
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:   "Transparent",
+		Reads:  []graphics.RenderGraphResource{graphShadows, graphDepth},
+		Writes: []graphics.RenderGraphResource{graphColor},
+		Execute: func(*graphics.RenderGraph) {
+			_, transparent := c.splitForward()
+			c.renderForward(transparent)
+		},
+	})
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Resolve",
+		Reads:   colorDepth,
+		Writes:  colorDepth,
+		Enabled: func() bool { return c.msaa != nil },
+		Execute: func(*graphics.RenderGraph) { c.resolve() },
+	})
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Normals",
+		Reads:   []graphics.RenderGraphResource{graphDepth},
+		Writes:  []graphics.RenderGraphResource{graphNormals},
+		Enabled: c.requiresNormals,
+		Execute: func(*graphics.RenderGraph) { c.renderNormals() },
+	})
+	g.AddPass(stage("BeforeEffects", RenderStageBeforeEffects, colorDepth, colorDepth))
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Effects",
+		Reads:   []graphics.RenderGraphResource{graphColor, graphDepth, graphNormals, graphGBuffer},
+		Writes:  []graphics.RenderGraphResource{graphColor},
+		Execute: func(*graphics.RenderGraph) { c.renderEffects() },
+	})
+	g.AddPass(stage("AfterEffects", RenderStageAfterEffects, colorDepth, []graphics.RenderGraphResource{graphColor}))
+	g.AddPass(&graphics.RenderGraphPass{
+		Name:    "Present",
+		Reads:   []graphics.RenderGraphResource{graphColor},
+		Writes:  []graphics.RenderGraphResource{graphBackbuffer},
+		Execute: func(*graphics.RenderGraph) { c.endRender() },
+	})
+
+	c.graph = g
+}
+
+// splitForward splits the sorted visible forward drawables into the opaque
+// and alpha tested drawables, and the transparent drawables.
+func (c *Camera) splitForward() (opaque, transparent []Drawable) {
+	for i := range c.forwardVisible {
+		if drawableQueue(c.forwardVisible[i]) == RenderQueueTransparent {
+			return c.forwardVisible[:i], c.forwardVisible[i:]
+		}
+	}
+
+	return c.forwardVisible, nil
+}
+
+// renderForward draws forward drawables once per light. The first light is
+// drawn opaque, the others are added on top. Drawables which are not lit are
+// only drawn with the first light.
+func (c *Camera) renderForward(drawables []Drawable) {
+	if len(drawables) == 0 {
+		return
+	}
+
+	c.activeRenderPath = RenderPathForward
+	c.activeLight = nil
+
+	if len(c.lights) == 0 {
+		for i := range drawables {
+			drawables[i].Draw(c)
+		}
+		return
+	}
+
+	device := graphics.ActiveDevice()
+	prev := device.PipelineState()
+
+	for i := range c.lights {
+		c.activeLight = c.lights[i]
+		c.activeLightIndex = int32(i)
+
+		if i == 1 {
+			state := prev
+			state.Blend = graphics.BlendAdditive
+			state.DepthWrite = false
+			state.DepthFunc = graphics.CompareLessEqual
+			device.SetPipelineState(state)
+		}
+
+		for j := range drawables {
+			if i != 0 && !isLit(drawables[j]) {
+				continue
+			}
+
+			drawables[j].Draw(c)
+		}
+	}
+
+	device.SetPipelineState(prev)
+	c.activeLight = nil
+}
+
+// hasNormals reports if the framebuffer has room for the normals texture.
+func (c *Camera) hasNormals() bool {
+	return graphics.ActiveProfile().MaxColorAttachments() > 4
+}
+
+// requiresNormals reports if an enabled effect reads the normals texture.
+func (c *Camera) requiresNormals() bool {
+	if !c.hasNormals() {
+		return false
+	}
+
+	for i := range c.effects {
+		if c.disabledEffects[c.effects[i]] {
+			continue
+		}
+		if e, ok := c.effects[i].(NormalsEffect); ok && e.RequiresNormals() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renderNormals draws the view space normals of the visible drawables into
+// CameraTextureNormals. It is drawn on top of the depth of the scene, so only
+// the visible surfaces pass the depth test.
+func (c *Camera) renderNormals() {
+	device := graphics.ActiveDevice()
+	prev := device.PipelineState()
+	state := prev
+	state.DepthWrite = false
+	state.DepthFunc = graphics.CompareLessEqual
+	state.Blend = graphics.BlendNone
+
+	c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor4})
+	device.Clear(graphics.ClearColor)
+	device.SetPipelineState(state)
+
+	s := c.shaders[CameraShaderNormals]
+	s.Bind()
+
+	for i := range c.deferredVisible {
+		c.deferredVisible[i].DrawShader(s, c)
+	}
+	for i := range c.forwardVisible {
+		if drawableQueue(c.forwardVisible[i]) == RenderQueueTransparent {
+			continue
+		}
+
+		c.forwardVisible[i].DrawShader(s, c)
+	}
+
+	s.Unbind()
+
+	device.SetPipelineState(prev)
+
+	if c.hdr {
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
+	} else {
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+	}
+}
+
+func (c *Camera) renderEffects() {
+	if len(c.effects) == 0 {
+		return
+	}
+
+	graphics.ActiveDevice().SetDepthWrite(false)
+	graphics.ActiveDevice().SetDepthTest(false)
+
+	if c.hdr {
+		c.effectActiveType = EffectTypeHDR
+
+		for i := range c.effects {
+			if c.disabledEffects[c.effects[i]] {
+				continue
+			}
+
+			if c.effects[i].Type() == EffectTypeTonemapper {
+				c.effectActiveType = EffectTypeTonemapper
+
+				c.renderEffect(c.effects[i])
+
+				c.effectActiveType = EffectTypeLDR
+
+				continue
+			}
+
+			c.renderEffect(c.effects[i])
+		}
+	} else {
+		c.effectActiveType = EffectTypeLDR
+		for i := range c.effects {
+			if c.disabledEffects[c.effects[i]] {
+				continue
+			}
+
+			c.renderEffect(c.effects[i])
+		}
+	}
+
+	graphics.ActiveDevice().SetDepthTest(true)
+	graphics.ActiveDevice().SetDepthWrite(true)
+}
+
+func (c *Camera) EffectPass() {
+	if c.effectActiveType == EffectTypeHDR {
+		if c.effectPass%2 == 1 {
+			graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureHDR1])
+			c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
+
+		} else {
+			graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureHDR0])
+			c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor3})
+		}
+	} else if c.effectActiveType == EffectTypeLDR {
+		if c.effectPass%2 == 1 {
+			graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureLDR1])
+			c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+		} else {
+			graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureLDR0])
+			c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor2})
+		}
+	}
+
+	c.meshes[CameraMeshEffect].Bind()
+	c.meshes[CameraMeshEffect].Draw()
+	c.meshes[CameraMeshEffect].Unbind()
+
+	c.effectPass++
+}
+
+// EffectSource returns the texture read by the next EffectPass.
+func (c *Camera) EffectSource() graphics.Texture {
+	switch c.effectActiveType {
+	case EffectTypeHDR:
+		if c.effectPass%2 == 1 {
+			return c.textures[CameraTextureHDR1]
+		}
+		return c.textures[CameraTextureHDR0]
+	case EffectTypeTonemapper:
+		return c.textures[CameraTextureHDR0]
+	}
+
+	if c.effectPass%2 == 1 {
+		return c.textures[CameraTextureLDR1]
+	}
+
+	return c.textures[CameraTextureLDR0]
+}
+
+// EffectTexture returns a texture of the camera, or nil if the camera does
+// not provide it. The velocity, position and material textures come from the
+// GBuffer, so they are only available on the deferred render path. The
+// normals texture is only rendered when an enabled NormalsEffect requires it.
+func (c *Camera) EffectTexture(texture CameraTexture) graphics.Texture {
+	switch texture {
+	case CameraTextureVelocity, CameraTexturePosition, CameraTextureMaterial:
+		if c.gbuffer == nil {
+			return nil
+		}
+
+		var t *graphics.Texture2D
+		switch texture {
+		case CameraTextureVelocity:
+			t = c.gbuffer.AttachmentVelocity()
+		case CameraTexturePosition:
+			t = c.gbuffer.Attachment0()
+		case CameraTextureMaterial:
+			t = c.gbuffer.Attachment1()
+		}
+
+		if t != nil {
+			return t
+		}
+		return nil
+	case CameraTextureNormals:
+		if !c.hasNormals() {
+			return nil
+		}
+	}
+
+	if t, ok := c.textures[texture]; ok {
+		return t
+	}
+
+	return nil
+}
+
+// EffectSize returns the size of the effect targets in pixels.
+func (c *Camera) EffectSize() fmath.IVec2 {
+	return c.framebuffer.Size()
+}
+
+// renderEffect renders an effect in a debug group named after the effect.
+func (c *Camera) renderEffect(effect Effect) {
+	device := graphics.ActiveDevice()
+	device.PushDebugGroup(effectName(effect))
+
+	c.startEffectPass()
+	effect.Render(c)
+	c.endEffectPass()
+
+	device.PopDebugGroup()
+}
+
+func (c *Camera) startEffectPass() {
+	c.effectPass = 0
+
+	if c.effectActiveType == EffectTypeTonemapper {
+		graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureHDR0])
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+	}
+}
+
+func (c *Camera) endEffectPass() {
+	if c.hdr {
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor1})
+	} else {
+		c.framebuffer.ApplyDrawBuffers([]uint32{graphics.AttachmentColor0})
+	}
+
+	if c.effectActiveType == EffectTypeTonemapper {
+		return
+	}
+
+	// After an even number of passes the result is back in the first target.
+	if c.effectPass%2 == 0 {
+		return
+	}
+
+	c.shaders[CameraShaderCopy].Bind()
+	c.shaders[CameraShaderCopy].SetPass("pass_0")
+
+	if c.effectActiveType == EffectTypeHDR {
+		graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureHDR1])
+	} else {
+		graphics.ActiveDevice().BindTexture(0, c.textures[CameraTextureLDR1])
+	}
+
+	c.meshes[CameraMeshEffect].Bind()
+	c.meshes[CameraMeshEffect].Draw()
+	c.meshes[CameraMeshEffect].Unbind()
+	c.shaders[CameraShaderCopy].Unbind()
+}
+
+// NewCamera creates a camera. Samples sets the number of MSAA samples per
+// pixel, values below two disable multisampling. Multisampling is only used by
+// the forward render path.
+func NewCamera(renderPath RenderPath, hdr bool, samples int32) *Camera {
+	// Profiles without deferred shading fall back to forward rendering.
+	if renderPath == RenderPathDeferred && !graphics.Supported(graphics.FeatureDeferred) {
+		renderPath = RenderPathForward
+	}
+	if renderPath == RenderPathDeferred {
+		samples = 0
+	}
+
+	c := &Camera{
+		hdr:              hdr,
+		samples:          graphics.ClampSamples(samples),
+		renderPath:       renderPath,
+		meshes:           make(map[CameraMesh]*graphics.Mesh),
+		commands:         graphics.NewCommandBuffer(),
+		shaders:          make(map[CameraShader]graphics.Shader),
+		textures:         make(map[CameraTexture]*graphics.Texture2D),
+		effects:          []Effect{},
+		disabledEffects:  make(map[Effect]bool),
+		renderPasses:     make(map[RenderStage][]RenderPass),
+		occlusion:        make(map[Drawable]*occlusionState),
+		deferredCache:    []Drawable{},
+		forwardCache:     []Drawable{},
+		drawablePaths:    make(map[Drawable]bool),
+		grouped:          make(map[Drawable]bool),
+		rebuildCache:     true,
+		culling:          true,
+		cullingMask:      LayerMaskAll,
+		enabled:          true,
+		viewport:         core.NewRect(mgl32.Vec2{0, 0}, mgl32.Vec2{1, 1}),
+		fov:              1.309,
+		orthographicSize: 5.0,
+		centered:         true,
+		eyeSeparation:    0.064,
+		convergence:      10.0,
+		renderScale:      1.0,
+		nearClip:         0.01,
+		farClip:          100000.0,
+		clearColor:       core.ColorBlack,
+	}
+
+	size := c.eyeSize(c.windowSize())
+	c.aspectRatio = float32(size.X()) / float32(size.Y())
+
+	c.SetName("Camera")
+	instance.MustAssign(c)
+
+	c.setupPipeline()
+	c.buildRenderGraph()
+	c.UpdateMatrices()
 
 	return c
 }
@@ -563,17 +2056,38 @@ func (c *Camera) Awake() {
 	c.Resize()
 }
 
+// Update resizes the camera once the window has stopped changing size. Until
+// then, only the aspect ratio follows the window and the image is scaled
+// to the viewport.
 func (c *Camera) Update() {
 	if input.WindowResized() {
+		c.resizePending = true
+		c.resizeTime = time.Now()
+
+		eye := c.eyeSize(c.windowSize())
+		c.aspectRatio = float32(eye.X()) / float32(eye.Y())
+		c.UpdateMatrices()
+	}
+
+	if c.resizePending && time.Now()-c.resizeTime >= resizeDelay {
 		c.Resize()
 	}
 }
 
 func (c *Camera) Resize() {
-	c.aspectRatio = window.AspectRatio()
-	c.framebuffer.SetSize(window.Resolution())
+	c.resizePending = false
+
+	eye := c.eyeSize(c.windowSize())
+	size := c.pixelSize()
+
+	c.aspectRatio = float32(eye.X()) / float32(eye.Y())
+	c.framebuffer.SetSize(size)
+	if c.msaa != nil {
+		c.msaa.SetSize(size)
+	}
 	if c.renderPath == RenderPathDeferred {
-		c.gbuffer.SetSize(window.Resolution())
+		c.gbuffer.SetSize(size)
 	}
+	c.setupStereo()
 	c.UpdateMatrices()
 }
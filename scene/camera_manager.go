@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"sort"
+)
+
+// CameraManager renders the cameras of a scene in order of their depth. Each
+// camera draws into its own viewport rect, so several cameras can share the
+// window for split-screen or picture-in-picture views.
+//
+// Reflection probes are rendered before the cameras, so the cameras see the
+// reflections of the current frame.
+type CameraManager struct {
+	cameras []*Camera
+	probes  []*ReflectionProbe
+}
+
+// NewCameraManager creates a new camera manager.
+func NewCameraManager() *CameraManager {
+	return &CameraManager{
+		cameras: []*Camera{},
+	}
+}
+
+// Cameras returns the cameras of the manager, sorted by depth.
+func (m *CameraManager) Cameras() []*Camera {
+	return m.cameras
+}
+
+// SetCameras replaces the cameras of the manager.
+func (m *CameraManager) SetCameras(cameras []*Camera) {
+	m.cameras = append(m.cameras[:0], cameras...)
+	m.Sort()
+}
+
+// Probes returns the reflection probes of the manager.
+func (m *CameraManager) Probes() []*ReflectionProbe {
+	return m.probes
+}
+
+// SetProbes replaces the reflection probes of the manager.
+func (m *CameraManager) SetProbes(probes []*ReflectionProbe) {
+	m.probes = append(m.probes[:0], probes...)
+}
+
+// Add adds a camera to the manager.
+func (m *CameraManager) Add(camera *Camera) {
+	for i := range m.cameras {
+		if m.cameras[i] == camera {
+			return
+		}
+	}
+
+	m.cameras = append(m.cameras, camera)
+	m.Sort()
+}
+
+// Remove removes a camera from the manager.
+func (m *CameraManager) Remove(camera *Camera) {
+	for i := range m.cameras {
+		if m.cameras[i] == camera {
+			m.cameras = append(m.cameras[:i], m.cameras[i+1:]...)
+			return
+		}
+	}
+}
+
+// Clear removes all cameras from the manager.
+func (m *CameraManager) Clear() {
+	m.cameras = m.cameras[:0]
+}
+
+// Sort orders the cameras by depth. Cameras with the same depth keep the
+// order they were added in. It must be called after changing the depth of a
+// camera.
+func (m *CameraManager) Sort() {
+	sort.SliceStable(m.cameras, func(i, j int) bool {
+		return m.cameras[i].Depth() < m.cameras[j].Depth()
+	})
+}
+
+// Render renders the reflection probes, then all enabled cameras whose game
+// object is active.
+func (m *CameraManager) Render() {
+	m.Sort()
+
+	for i := range m.probes {
+		if g := m.probes[i].GameObject(); g == nil || !g.Active() {
+			continue
+		}
+
+		m.probes[i].Render()
+	}
+
+	for i := range m.cameras {
+		if !m.cameras[i].Enabled() {
+			continue
+		}
+		if g := m.cameras[i].GameObject(); g == nil || !g.Active() {
+			continue
+		}
+
+		m.cameras[i].Render()
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+// occlusionMargin enlarges the tested bounding boxes, so a drawable is not
+// hidden by its own depth.
+const occlusionMargin = 1.01
+
+// occlusionState holds the occlusion query of a drawable.
+type occlusionState struct {
+	query    graphics.OcclusionQuery
+	occluded bool
+	pending  bool
+}
+
+// OcclusionCulling reports if drawables hidden behind other geometry are
+// skipped.
+func (c *Camera) OcclusionCulling() bool {
+	return c.occlusionCulling
+}
+
+// SetOcclusionCulling enables or disables occlusion culling. After the opaque
+// geometry is drawn, the bounding box of every drawable in the view frustum
+// is tested against the depth buffer with an occlusion query. Drawables whose
+// box was hidden are skipped in the next frame, until a query finds them
+// visible again. Results are read without waiting for the GPU, so drawables
+// may appear a frame late when they come into view.
+func (c *Camera) SetOcclusionCulling(enable bool) {
+	if enable == c.occlusionCulling {
+		return
+	}
+
+	c.occlusionCulling = enable
+
+	if enable {
+		if c.meshes[CameraMeshBounds] == nil {
+			c.meshes[CameraMeshBounds] = graphics.NewMeshCube()
+			c.shaders[CameraShaderOcclusion] = shader.NewShaderUtilsOcclusion()
+		}
+		return
+	}
+
+	for d := range c.occlusion {
+		c.releaseOcclusion(d)
+	}
+}
+
+// releaseOcclusion frees the occlusion query of a drawable.
+func (c *Camera) releaseOcclusion(d Drawable) {
+	if s, ok := c.occlusion[d]; ok {
+		s.query.Release()
+		delete(c.occlusion, d)
+	}
+}
+
+// pruneOcclusion frees the occlusion queries of drawables which left the
+// scene.
+func (c *Camera) pruneOcclusion() {
+	if len(c.occlusion) == 0 {
+		return
+	}
+
+	present := make(map[Drawable]bool, len(c.deferredCache)+len(c.forwardCache))
+	for i := range c.deferredCache {
+		present[c.deferredCache[i]] = true
+	}
+	for i := range c.forwardCache {
+		present[c.forwardCache[i]] = true
+	}
+	for _, g := range c.lodGroups {
+		for _, lod := range g.LODs() {
+			for _, r := range lod.Renderers {
+				present[r] = true
+			}
+		}
+	}
+
+	for d := range c.occlusion {
+		if !present[d] {
+			c.releaseOcclusion(d)
+		}
+	}
+}
+
+// cullOccluded collects the results of finished occlusion queries and removes
+// the drawables occluded in the last test from the visible drawables. The
+// drawables in the view frustum are kept as candidates for the next test.
+func (c *Camera) cullOccluded() {
+	c.occlusionCandidates = append(c.occlusionCandidates[:0], c.deferredVisible...)
+	c.occlusionCandidates = append(c.occlusionCandidates, c.forwardVisible...)
+
+	for _, s := range c.occlusion {
+		if s.pending && s.query.Available() {
+			s.occluded = !s.query.Visible()
+			s.pending = false
+		}
+	}
+
+	c.deferredVisible = c.removeOccluded(c.deferredVisible)
+	c.forwardVisible = c.removeOccluded(c.forwardVisible)
+}
+
+func (c *Camera) removeOccluded(drawables []Drawable) []Drawable {
+	visible := drawables[:0]
+
+	for i := range drawables {
+		if s, ok := c.occlusion[drawables[i]]; ok && s.occluded {
+			continue
+		}
+
+		visible = append(visible, drawables[i])
+	}
+
+	return visible
+}
+
+// renderOcclusion issues occlusion queries for the bounding boxes of the
+// candidates. Boxes are drawn against the depth of the opaque geometry,
+// without writing color or depth. A drawable whose last query is still in
+// flight is not tested again until its result arrives.
+func (c *Camera) renderOcclusion() {
+	if len(c.occlusionCandidates) == 0 {
+		return
+	}
+
+	device := graphics.ActiveDevice()
+	prev := device.PipelineState()
+	state := prev
+	state.DepthTest = true
+	state.DepthWrite = false
+	state.DepthFunc = graphics.CompareLessEqual
+	state.Cull = graphics.CullNone
+	state.Blend = graphics.BlendNone
+
+	device.SetPipelineState(state)
+	device.SetColorWrite(false)
+
+	s := c.shaders[CameraShaderOcclusion]
+	mesh := c.meshes[CameraMeshBounds]
+
+	s.Bind()
+	s.SetUniform("v_view_projection_matrix", c.projectionMatrix.Mul4(c.viewMatrix))
+	mesh.Bind()
+
+	eye := c.CameraPosition()
+	margin := c.nearClip * 2
+
+	for _, d := range c.occlusionCandidates {
+		b := d.Bounds()
+		if b.Empty() || b.Infinite() {
+			continue
+		}
+
+		st, ok := c.occlusion[d]
+		if !ok {
+			st = &occlusionState{query: device.NewOcclusionQuery()}
+			c.occlusion[d] = st
+		}
+
+		// The box would be clipped by the near plane with the camera inside
+		// of it, so it is always visible.
+		center, extents := b.Center(), b.Extents().Mul(occlusionMargin)
+		inside := true
+		for i := 0; i < 3; i++ {
+			if eye[i] < center[i]-extents[i]-margin || eye[i] > center[i]+extents[i]+margin {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			st.occluded = false
+			continue
+		}
+
+		if st.pending {
+			continue
+		}
+
+		model := mgl32.Translate3D(center.Elem()).Mul4(mgl32.Scale3D(extents.Elem()))
+		s.SetUniform("v_model_matrix", model)
+
+		st.query.Begin()
+		mesh.Draw()
+		st.query.End()
+		st.pending = true
+	}
+
+	mesh.Unbind()
+	s.Unbind()
+
+	device.SetColorWrite(true)
+	device.SetPipelineState(prev)
+}
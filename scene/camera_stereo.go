@@ -0,0 +1,286 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	fmath "github.com/haakenlabs/arc/pkg/math"
+)
+
+// StereoMode selects how a camera renders for two eyes.
+type StereoMode uint8
+
+const (
+	// StereoModeNone renders a single view.
+	StereoModeNone StereoMode = iota
+
+	// StereoModeSideBySide renders the left and right eye into the left and
+	// right half of a double wide target, which is shown on screen.
+	StereoModeSideBySide
+
+	// StereoModeLayered renders each eye at full size into a layer of a
+	// texture array. The left eye is shown on screen.
+	StereoModeLayered
+)
+
+// Eye is an eye of a stereo camera.
+type Eye uint8
+
+const (
+	EyeLeft Eye = iota
+	EyeRight
+)
+
+// stereoEye holds the state of an eye of a stereo camera.
+type stereoEye struct {
+	target       graphics.Framebuffer
+	view         mgl32.Mat4
+	projection   mgl32.Mat4
+	prevViewProj mgl32.Mat4
+	override     bool
+}
+
+// StereoMode returns the stereo mode of the camera.
+func (c *Camera) StereoMode() StereoMode {
+	return c.stereoMode
+}
+
+// SetStereoMode sets the stereo mode of the camera.
+func (c *Camera) SetStereoMode(mode StereoMode) {
+	if mode == c.stereoMode {
+		return
+	}
+
+	c.stereoMode = mode
+	c.hasPrevious = false
+
+	c.Resize()
+}
+
+// EyeSeparation returns the distance between the eyes in world units.
+func (c *Camera) EyeSeparation() float32 {
+	return c.eyeSeparation
+}
+
+// SetEyeSeparation sets the distance between the eyes in world units.
+func (c *Camera) SetEyeSeparation(separation float32) {
+	if separation >= 0 {
+		c.eyeSeparation = separation
+	}
+}
+
+// Convergence returns the distance at which the views of the eyes meet.
+// Objects at this distance appear at the depth of the screen.
+func (c *Camera) Convergence() float32 {
+	return c.convergence
+}
+
+// SetConvergence sets the distance at which the views of the eyes meet.
+func (c *Camera) SetConvergence(convergence float32) {
+	if convergence > 0 {
+		c.convergence = convergence
+	}
+}
+
+// SetEyeMatrices overrides the view and projection matrices of an eye, such
+// as the matrices reported by a head mounted display. The camera transform
+// is ignored for the eye until ClearEyeMatrices is called.
+func (c *Camera) SetEyeMatrices(eye Eye, view, projection mgl32.Mat4) {
+	if eye > EyeRight {
+		return
+	}
+
+	c.eyes[eye].view = view
+	c.eyes[eye].projection = projection
+	c.eyes[eye].override = true
+}
+
+// ClearEyeMatrices derives the matrices of both eyes from the camera again.
+func (c *Camera) ClearEyeMatrices() {
+	c.eyes[EyeLeft].override = false
+	c.eyes[EyeRight].override = false
+}
+
+// ActiveEye returns the eye being rendered. It is EyeLeft when the camera is
+// not in a stereo mode.
+func (c *Camera) ActiveEye() Eye {
+	return c.activeEye
+}
+
+// StereoTexture returns the target the eyes are rendered into: a double wide
+// texture in side by side mode, a texture array with a layer per eye in
+// layered mode, or nil if the camera is not in a stereo mode.
+func (c *Camera) StereoTexture() graphics.Texture {
+	return c.stereoTexture
+}
+
+// eyeSize returns the size of the view of an eye in pixels.
+func (c *Camera) eyeSize(full fmath.IVec2) fmath.IVec2 {
+	if c.stereoMode == StereoModeSideBySide && full.X() > 1 {
+		return fmath.IVec2{full.X() / 2, full.Y()}
+	}
+
+	return full
+}
+
+// eyeMatrices returns the view and projection matrices of an eye. Unless
+// they are overridden, the eyes are offset along the right axis of the
+// camera, with frustums skewed to meet at the convergence distance.
+func (c *Camera) eyeMatrices(eye Eye) (view, projection mgl32.Mat4) {
+	if c.eyes[eye].override {
+		return c.eyes[eye].view, c.eyes[eye].projection
+	}
+
+	offset := c.eyeSeparation / 2
+	if eye == EyeLeft {
+		offset = -offset
+	}
+
+	view = mgl32.Translate3D(-offset, 0, 0).Mul4(c.viewMatrix)
+
+	if c.orthographic {
+		return view, c.projectionMatrix
+	}
+
+	top := c.nearClip * float32(math.Tan(float64(c.fov/2)))
+	right := top * c.aspectRatio
+	shift := -offset * c.nearClip / c.convergence
+
+	projection = mgl32.Frustum(-right+shift, right+shift, -top, top, c.nearClip, c.farClip)
+
+	return view, projection
+}
+
+// setupStereo creates the stereo target and the framebuffers each eye is
+// copied into.
+func (c *Camera) setupStereo() {
+	c.releaseStereo()
+
+	if c.stereoMode == StereoModeNone {
+		return
+	}
+
+	full := c.windowSize()
+	format := graphics.TextureFormatDefaultColor
+
+	switch c.stereoMode {
+	case StereoModeSideBySide:
+		t := graphics.NewTexture2D(full, format)
+		t.Alloc()
+
+		fb := graphics.NewFramebuffer(full)
+		fb.SetAttachment(graphics.AttachmentColor0, graphics.NewAttachmentTexture2DFrom(t, false))
+		if err := fb.Alloc(); err != nil {
+			panic(err)
+		}
+
+		c.stereoTexture = t
+		c.eyes[EyeLeft].target = fb
+		c.eyes[EyeRight].target = fb
+	case StereoModeLayered:
+		t := graphics.NewTexture2DArray(full, 2, format)
+		t.Alloc()
+
+		for eye := range c.eyes {
+			fb := graphics.NewFramebuffer(full)
+			fb.SetAttachment(graphics.AttachmentColor0, graphics.NewAttachmentTextureLayer(t, int32(eye)))
+			if err := fb.Alloc(); err != nil {
+				panic(err)
+			}
+
+			c.eyes[eye].target = fb
+		}
+
+		c.stereoTexture = t
+	}
+}
+
+func (c *Camera) releaseStereo() {
+	for eye := range c.eyes {
+		if c.eyes[eye].target != nil && (eye == 0 || c.eyes[eye].target != c.eyes[0].target) {
+			c.eyes[eye].target.Dealloc()
+		}
+		c.eyes[eye].target = nil
+	}
+
+	switch t := c.stereoTexture.(type) {
+	case *graphics.Texture2D:
+		t.Dealloc()
+	case *graphics.Texture2DArray:
+		t.Dealloc()
+	}
+	c.stereoTexture = nil
+}
+
+// renderStereo renders the render graph once per eye and copies each eye
+// into the stereo target.
+func (c *Camera) renderStereo() {
+	view, projection, prev := c.viewMatrix, c.projectionMatrix, c.prevViewProj
+
+	for eye := range c.eyes {
+		c.activeEye = Eye(eye)
+
+		e := &c.eyes[eye]
+		c.viewMatrix, c.projectionMatrix = c.eyeMatrices(Eye(eye))
+
+		if !c.hasPrevious {
+			e.prevViewProj = c.projectionMatrix.Mul4(c.viewMatrix)
+		}
+		c.prevViewProj = e.prevViewProj
+
+		c.cull()
+		c.uploadUniforms()
+
+		if err := c.graph.Execute(); err != nil {
+			panic(err)
+		}
+
+		e.prevViewProj = c.projectionMatrix.Mul4(c.viewMatrix)
+	}
+
+	c.activeEye = EyeLeft
+	c.hasPrevious = true
+	c.viewMatrix, c.projectionMatrix, c.prevViewProj = view, projection, prev
+
+	r := c.PixelRect()
+	out := c.eyes[EyeLeft].target
+	graphics.BlitFramebuffersRect(out, nil, graphics.AttachmentColor0,
+		int32(r.Left()), int32(r.Top()), int32(r.Width()), int32(r.Height()))
+}
+
+// presentEye copies the rendered eye into the stereo target.
+func (c *Camera) presentEye() {
+	size := c.eyeSize(c.windowSize())
+
+	x := int32(0)
+	if c.stereoMode == StereoModeSideBySide && c.activeEye == EyeRight {
+		x = size.X()
+	}
+
+	graphics.BlitFramebuffersRect(c.framebuffer, c.eyes[c.activeEye].target, graphics.AttachmentColor0,
+		x, 0, size.X(), size.Y())
+}
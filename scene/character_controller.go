@@ -0,0 +1,408 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	stdmath "math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ Collider = &CharacterController{}
+var _ RootMotionReceiver = &CharacterController{}
+
+// characterSlides is the number of surfaces a single move slides along.
+const characterSlides = 4
+
+// characterMinMove is the shortest movement the controller makes.
+const characterMinMove = 1e-5
+
+// CollisionFlags reports which sides of a character controller touched
+// colliders during a move.
+type CollisionFlags uint8
+
+const (
+	// CollisionSides is set when the side of the capsule touched a collider.
+	CollisionSides CollisionFlags = 1 << iota
+	// CollisionAbove is set when the top of the capsule touched a collider.
+	CollisionAbove
+	// CollisionBelow is set when the bottom of the capsule touched a
+	// collider.
+	CollisionBelow
+)
+
+// CharacterController moves an object with an upright capsule which slides
+// along the colliders of the scene, rather than passing through them. It is
+// kinematic: it does not respond to forces, and moves only when told to,
+// which suits player and NPC movement.
+//
+// The controller climbs slopes up to its slope limit and steps onto ledges
+// up to its step offset. Steeper slopes block it like walls. It keeps a
+// skin of empty space between itself and colliders, so it does not get
+// stuck on the surfaces it slides along.
+//
+// The capsule ignores the rotation and scale of the object, and is also a
+// collider for the physics queries of the scene.
+type CharacterController struct {
+	BaseComponent
+
+	center       mgl32.Vec3
+	radius       float32
+	height       float32
+	slopeLimit   float32
+	stepOffset   float32
+	skinWidth    float32
+	mask         LayerMask
+	grounded     bool
+	groundNormal mgl32.Vec3
+	flags        CollisionFlags
+}
+
+// NewCharacterController creates a character controller with a capsule of
+// radius and height.
+func NewCharacterController(radius, height float32) *CharacterController {
+	c := &CharacterController{
+		radius:       radius,
+		height:       height,
+		slopeLimit:   mgl32.DegToRad(45),
+		stepOffset:   0.3,
+		skinWidth:    0.02,
+		mask:         LayerMaskAll,
+		groundNormal: mgl32.Vec3{0, 1, 0},
+	}
+
+	c.SetName("CharacterController")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// Center returns the center of the capsule relative to the object.
+func (c *CharacterController) Center() mgl32.Vec3 {
+	return c.center
+}
+
+// SetCenter sets the center of the capsule relative to the object.
+func (c *CharacterController) SetCenter(center mgl32.Vec3) {
+	c.center = center
+	colliderChanged(c)
+}
+
+// Radius returns the radius of the capsule.
+func (c *CharacterController) Radius() float32 {
+	return c.radius
+}
+
+// SetRadius sets the radius of the capsule.
+func (c *CharacterController) SetRadius(radius float32) {
+	c.radius = radius
+	colliderChanged(c)
+}
+
+// Height returns the height of the capsule, from end to end.
+func (c *CharacterController) Height() float32 {
+	return c.height
+}
+
+// SetHeight sets the height of the capsule, from end to end.
+func (c *CharacterController) SetHeight(height float32) {
+	c.height = height
+	colliderChanged(c)
+}
+
+// SlopeLimit returns the steepest slope the controller climbs, in radians.
+func (c *CharacterController) SlopeLimit() float32 {
+	return c.slopeLimit
+}
+
+// SetSlopeLimit sets the steepest slope the controller climbs, in radians.
+func (c *CharacterController) SetSlopeLimit(limit float32) {
+	c.slopeLimit = limit
+}
+
+// StepOffset returns the height of the highest ledge the controller steps
+// onto.
+func (c *CharacterController) StepOffset() float32 {
+	return c.stepOffset
+}
+
+// SetStepOffset sets the height of the highest ledge the controller steps
+// onto.
+func (c *CharacterController) SetStepOffset(offset float32) {
+	c.stepOffset = offset
+}
+
+// SkinWidth returns the space kept between the capsule and colliders.
+func (c *CharacterController) SkinWidth() float32 {
+	return c.skinWidth
+}
+
+// SetSkinWidth sets the space kept between the capsule and colliders.
+func (c *CharacterController) SetSkinWidth(width float32) {
+	c.skinWidth = width
+}
+
+// CollisionMask returns the layers of the colliders which block the
+// controller.
+func (c *CharacterController) CollisionMask() LayerMask {
+	return c.mask
+}
+
+// SetCollisionMask sets the layers of the colliders which block the
+// controller.
+func (c *CharacterController) SetCollisionMask(mask LayerMask) {
+	c.mask = mask
+}
+
+// IsGrounded reports if the controller stood on a walkable surface after its
+// last move.
+func (c *CharacterController) IsGrounded() bool {
+	return c.grounded
+}
+
+// GroundNormal returns the normal of the surface the controller stands on,
+// or up if it is not grounded.
+func (c *CharacterController) GroundNormal() mgl32.Vec3 {
+	return c.groundNormal
+}
+
+// CollisionFlags returns the sides of the capsule which touched colliders
+// during the last move.
+func (c *CharacterController) CollisionFlags() CollisionFlags {
+	return c.flags
+}
+
+// Move moves the object by motion, sliding along the colliders in its way.
+// It returns the sides of the capsule which touched colliders.
+func (c *CharacterController) Move(motion mgl32.Vec3) CollisionFlags {
+	t := c.GetTransform()
+	if t == nil {
+		return 0
+	}
+
+	pos := t.ActiveMatrix().Col(3).Vec3()
+	c.flags = 0
+
+	g := c.GameObject()
+	if g.scene == nil || g.scene.spatial == nil {
+		t.SetWorldPosition(pos.Add(motion))
+		return 0
+	}
+
+	pos = c.depenetrate(pos)
+
+	up := mgl32.Vec3{0, 1, 0}
+	vertical := up.Mul(motion.Dot(up))
+	horizontal := motion.Sub(vertical)
+
+	// Grounded controllers lift by the step offset before moving across,
+	// and lower by it after, which carries them over low ledges.
+	var step float32
+	if c.grounded && vertical[1] <= 0 && horizontal.LenSqr() > characterMinMove*characterMinMove {
+		before := pos
+		pos = c.slide(pos, up.Mul(c.stepOffset), false)
+		step = pos[1] - before[1]
+	}
+
+	pos = c.slide(pos, horizontal, true)
+	pos = c.slide(pos, vertical.Sub(up.Mul(step)), false)
+
+	c.probeGround(pos)
+	if c.grounded {
+		c.flags |= CollisionBelow
+	}
+
+	t.SetWorldPosition(pos)
+
+	return c.flags
+}
+
+// OnRootMotion moves the object by the root motion of its animator, so
+// animated characters collide like those moved by scripts.
+func (c *CharacterController) OnRootMotion(deltaPosition mgl32.Vec3, deltaRotation mgl32.Quat) {
+	t := c.GetTransform()
+	if t == nil {
+		return
+	}
+
+	t.SetRotation(t.Rotation().Mul(deltaRotation).Normalize())
+	c.Move(t.Rotation().Rotate(deltaPosition))
+}
+
+// Bounds returns the world space bounding box of the capsule.
+func (c *CharacterController) Bounds() math.AABB {
+	return c.shape().bounds()
+}
+
+// ClosestPoint returns the point of the capsule closest to p.
+func (c *CharacterController) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	return c.shape().closestPoint(p)
+}
+
+// Raycast returns the first hit on the capsule by a ray within maxDistance.
+func (c *CharacterController) Raycast(ray math.Ray, maxDistance float32) (RaycastHit, bool) {
+	return raycastShape(c, c.shape(), ray, maxDistance)
+}
+
+// IsTrigger reports false, as the controller is always solid. It enters
+// the triggers it moves into.
+func (c *CharacterController) IsTrigger() bool {
+	return false
+}
+
+func (c *CharacterController) shape() colliderShape {
+	return capsuleShape(c.capsule(colliderMatrix(c).Col(3).Vec3()))
+}
+
+// capsule returns the capsule of the controller with the object at pos.
+func (c *CharacterController) capsule(pos mgl32.Vec3) math.Capsule {
+	return worldCapsule(mgl32.Translate3D(pos[0], pos[1], pos[2]), c.center, c.radius, c.height)
+}
+
+// slide moves the capsule from pos by motion, and along the surfaces it
+// hits for the rest of the motion. When walls is set, surfaces steeper than
+// the slope limit are treated as walls so they cannot be climbed. Otherwise
+// the capsule stops on walkable surfaces, and slides down steeper ones.
+func (c *CharacterController) slide(pos, motion mgl32.Vec3, walls bool) mgl32.Vec3 {
+	cosLimit := float32(stdmath.Cos(float64(c.slopeLimit)))
+
+	for i := 0; i < characterSlides; i++ {
+		dist := motion.Len()
+		if dist < characterMinMove {
+			break
+		}
+		dir := motion.Mul(1 / dist)
+
+		hit, ok := c.cast(pos, dir, dist+c.skinWidth)
+		if !ok {
+			pos = pos.Add(motion)
+			break
+		}
+
+		travel := math.Max32(hit.Distance-c.skinWidth, 0)
+		pos = pos.Add(dir.Mul(travel))
+		c.flags |= c.side(pos, hit.Point)
+
+		n := hit.Normal
+		if !walls && n[1] >= cosLimit {
+			break
+		}
+		if walls && n[1] < cosLimit {
+			if flat := (mgl32.Vec3{n[0], 0, n[2]}); flat.LenSqr() > 0 {
+				n = flat.Normalize()
+			}
+		}
+
+		rest := dir.Mul(dist - travel)
+		motion = rest.Sub(n.Mul(rest.Dot(n)))
+	}
+
+	return pos
+}
+
+// cast sweeps the capsule from pos along dir, ignoring the colliders of the
+// object itself.
+func (c *CharacterController) cast(pos, dir mgl32.Vec3, distance float32) (RaycastHit, bool) {
+	g := c.GameObject()
+	capsule := c.capsule(pos)
+
+	return g.scene.sweep(math.Ray{Origin: pos, Direction: dir}, capsule.A, capsule.B, capsule.Radius, distance, c.mask, QueryColliders, func(b Bounded) bool {
+		return b.GameObject() == g
+	})
+}
+
+// side returns the side of the capsule at pos which touches point.
+func (c *CharacterController) side(pos, point mgl32.Vec3) CollisionFlags {
+	capsule := c.capsule(pos)
+
+	switch {
+	case point[1] < capsule.A[1]:
+		return CollisionBelow
+	case point[1] > capsule.B[1]:
+		return CollisionAbove
+	}
+
+	return CollisionSides
+}
+
+// probeGround looks for a walkable surface just below the capsule at pos.
+func (c *CharacterController) probeGround(pos mgl32.Vec3) {
+	c.grounded = false
+	c.groundNormal = mgl32.Vec3{0, 1, 0}
+
+	hit, ok := c.cast(pos, mgl32.Vec3{0, -1, 0}, c.skinWidth*2)
+	if !ok || c.side(pos, hit.Point) != CollisionBelow {
+		return
+	}
+
+	if hit.Normal[1] >= float32(stdmath.Cos(float64(c.slopeLimit))) {
+		c.grounded = true
+		c.groundNormal = hit.Normal
+	}
+}
+
+// depenetrate pushes the capsule at pos out of the colliders it overlaps,
+// such as those which moved into it.
+func (c *CharacterController) depenetrate(pos mgl32.Vec3) mgl32.Vec3 {
+	g := c.GameObject()
+
+	for i := 0; i < characterSlides; i++ {
+		capsule := c.capsule(pos)
+		moved := false
+
+		for _, b := range g.scene.overlap(capsuleShape(capsule), c.mask, QueryColliders, nil) {
+			if b.GameObject() == g {
+				continue
+			}
+
+			sh, ok := g.scene.queryShape(b, c.mask, QueryColliders)
+			if !ok {
+				continue
+			}
+
+			p, q := sh.closestPointSegment(capsule.A, capsule.B)
+			d := q.Sub(p)
+
+			// Capsules whose segment is inside a collider have no way out.
+			dist := d.Len()
+			if dist == 0 || dist >= capsule.Radius {
+				continue
+			}
+
+			push := d.Mul((capsule.Radius - dist + c.skinWidth) / dist)
+			pos = pos.Add(push)
+			capsule.A = capsule.A.Add(push)
+			capsule.B = capsule.B.Add(push)
+			moved = true
+		}
+
+		if !moved {
+			break
+		}
+	}
+
+	return pos
+}
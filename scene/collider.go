@@ -0,0 +1,413 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ Collider = &BoxCollider{}
+var _ Collider = &SphereCollider{}
+var _ Collider = &CapsuleCollider{}
+
+// Collider is a component with a solid shape, which is tested by the
+// physics queries of its scene. Colliders are bounded, so the spatial index
+// of the scene finds them without visiting every component.
+type Collider interface {
+	Bounded
+
+	// ClosestPoint returns the point of the collider closest to p, in world
+	// space. Points inside the collider are returned as they are.
+	ClosestPoint(p mgl32.Vec3) mgl32.Vec3
+
+	// Raycast returns the first hit on the collider by a ray within
+	// maxDistance. The direction of the ray must be normalized.
+	Raycast(ray math.Ray, maxDistance float32) (RaycastHit, bool)
+
+	// IsTrigger reports if the collider is a trigger. Triggers are not solid:
+	// they only report the colliders which enter and leave them.
+	IsTrigger() bool
+
+	shape() colliderShape
+}
+
+// colliderShape is the world space shape of a collider, or of a drawable
+// tested by its bounds. It is a box, or a capsule. Spheres are capsules
+// whose ends are the same point.
+type colliderShape struct {
+	box     bool
+	obb     math.OBB
+	capsule math.Capsule
+}
+
+func boxShape(o math.OBB) colliderShape {
+	return colliderShape{box: true, obb: o}
+}
+
+func capsuleShape(c math.Capsule) colliderShape {
+	return colliderShape{capsule: c}
+}
+
+func (s colliderShape) bounds() math.AABB {
+	if s.box {
+		return s.obb.AABB()
+	}
+
+	return s.capsule.AABB()
+}
+
+func (s colliderShape) closestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	if s.box {
+		return s.obb.ClosestPoint(p)
+	}
+
+	return s.capsule.ClosestPoint(p)
+}
+
+// closestPointSegment returns the closest points of the shape and of the
+// segment from a to b.
+func (s colliderShape) closestPointSegment(a, b mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	if s.box {
+		return s.obb.ClosestPointSegment(a, b)
+	}
+
+	return s.capsule.ClosestPointSegment(a, b)
+}
+
+func (s colliderShape) normal(p mgl32.Vec3) mgl32.Vec3 {
+	if s.box {
+		return s.obb.Normal(p)
+	}
+
+	return s.capsule.Normal(p)
+}
+
+func (s colliderShape) raycast(ray math.Ray) (float32, bool) {
+	if s.box {
+		return ray.IntersectOBB(s.obb)
+	}
+
+	return ray.IntersectCapsule(s.capsule)
+}
+
+func (s colliderShape) intersects(o colliderShape) bool {
+	switch {
+	case s.box && o.box:
+		return s.obb.Intersects(o.obb)
+	case s.box:
+		return o.capsule.IntersectsOBB(s.obb)
+	case o.box:
+		return s.capsule.IntersectsOBB(o.obb)
+	}
+
+	return s.capsule.Intersects(o.capsule)
+}
+
+// raycastShape returns the hit by a ray on the shape of a component within
+// maxDistance. Rays starting inside the shape hit it at their origin, with a
+// normal facing back along the ray.
+func raycastShape(c Bounded, s colliderShape, ray math.Ray, maxDistance float32) (RaycastHit, bool) {
+	t, ok := s.raycast(ray)
+	if !ok || t > maxDistance {
+		return RaycastHit{}, false
+	}
+
+	h := RaycastHit{
+		Component:  c,
+		GameObject: c.GameObject(),
+		Point:      ray.Point(t),
+		Distance:   t,
+	}
+	if t == 0 {
+		h.Normal = ray.Direction.Mul(-1)
+	} else {
+		h.Normal = s.normal(h.Point)
+	}
+
+	return h, true
+}
+
+// colliderMatrix returns the world matrix of the object of a collider, or
+// the identity matrix if it has none.
+func colliderMatrix(c Component) mgl32.Mat4 {
+	if t := c.GetTransform(); t != nil {
+		return t.ActiveMatrix()
+	}
+
+	return mgl32.Ident4()
+}
+
+// colliderChanged updates the bounds of a collider in the spatial index
+// after its shape changes.
+func colliderChanged(c Collider) {
+	if g := c.GameObject(); g != nil && g.scene != nil && g.scene.spatial != nil {
+		g.scene.spatial.Invalidate(c)
+	}
+}
+
+// baseCollider holds the trigger flag of the collider components.
+type baseCollider struct {
+	BaseComponent
+
+	trigger bool
+}
+
+// IsTrigger reports if the collider is a trigger.
+func (c *baseCollider) IsTrigger() bool {
+	return c.trigger
+}
+
+// SetTrigger sets if the collider is a trigger. Triggers are skipped by
+// character controllers and by queries which do not target them, and notify
+// the script components of the objects of colliders which overlap them.
+func (c *baseCollider) SetTrigger(trigger bool) {
+	c.trigger = trigger
+}
+
+// BoxCollider is a box shaped collider, centered on a point in the space of
+// its object.
+type BoxCollider struct {
+	baseCollider
+
+	center mgl32.Vec3
+	size   mgl32.Vec3
+}
+
+// NewBoxCollider creates a box collider of size.
+func NewBoxCollider(size mgl32.Vec3) *BoxCollider {
+	c := &BoxCollider{
+		size: size,
+	}
+
+	c.SetName("BoxCollider")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// Center returns the center of the box in the space of the object.
+func (c *BoxCollider) Center() mgl32.Vec3 {
+	return c.center
+}
+
+// SetCenter sets the center of the box in the space of the object.
+func (c *BoxCollider) SetCenter(center mgl32.Vec3) {
+	c.center = center
+	colliderChanged(c)
+}
+
+// Size returns the size of the box in the space of the object.
+func (c *BoxCollider) Size() mgl32.Vec3 {
+	return c.size
+}
+
+// SetSize sets the size of the box in the space of the object.
+func (c *BoxCollider) SetSize(size mgl32.Vec3) {
+	c.size = size
+	colliderChanged(c)
+}
+
+// Bounds returns the world space bounding box of the collider.
+func (c *BoxCollider) Bounds() math.AABB {
+	return c.shape().bounds()
+}
+
+// ClosestPoint returns the point of the collider closest to p.
+func (c *BoxCollider) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	return c.shape().closestPoint(p)
+}
+
+// Raycast returns the first hit on the collider by a ray within maxDistance.
+func (c *BoxCollider) Raycast(ray math.Ray, maxDistance float32) (RaycastHit, bool) {
+	return raycastShape(c, c.shape(), ray, maxDistance)
+}
+
+func (c *BoxCollider) shape() colliderShape {
+	return boxShape(math.NewOBB(colliderMatrix(c), c.center, c.size))
+}
+
+// SphereCollider is a sphere shaped collider, centered on a point in the
+// space of its object. The radius is scaled by the largest scale of the
+// object.
+type SphereCollider struct {
+	baseCollider
+
+	center mgl32.Vec3
+	radius float32
+}
+
+// NewSphereCollider creates a sphere collider of radius.
+func NewSphereCollider(radius float32) *SphereCollider {
+	c := &SphereCollider{
+		radius: radius,
+	}
+
+	c.SetName("SphereCollider")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// Center returns the center of the sphere in the space of the object.
+func (c *SphereCollider) Center() mgl32.Vec3 {
+	return c.center
+}
+
+// SetCenter sets the center of the sphere in the space of the object.
+func (c *SphereCollider) SetCenter(center mgl32.Vec3) {
+	c.center = center
+	colliderChanged(c)
+}
+
+// Radius returns the radius of the sphere.
+func (c *SphereCollider) Radius() float32 {
+	return c.radius
+}
+
+// SetRadius sets the radius of the sphere.
+func (c *SphereCollider) SetRadius(radius float32) {
+	c.radius = radius
+	colliderChanged(c)
+}
+
+// Bounds returns the world space bounding box of the collider.
+func (c *SphereCollider) Bounds() math.AABB {
+	return c.shape().bounds()
+}
+
+// ClosestPoint returns the point of the collider closest to p.
+func (c *SphereCollider) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	return c.shape().closestPoint(p)
+}
+
+// Raycast returns the first hit on the collider by a ray within maxDistance.
+func (c *SphereCollider) Raycast(ray math.Ray, maxDistance float32) (RaycastHit, bool) {
+	return raycastShape(c, c.shape(), ray, maxDistance)
+}
+
+func (c *SphereCollider) shape() colliderShape {
+	s := math.Sphere{Center: c.center, Radius: c.radius}.Transform(colliderMatrix(c))
+
+	return capsuleShape(math.Capsule{A: s.Center, B: s.Center, Radius: s.Radius})
+}
+
+// CapsuleCollider is a capsule shaped collider along the Y axis of its
+// object, centered on a point in its space. The height includes the ends of
+// the capsule. The height is scaled by the Y scale of the object, and the
+// radius by the largest of its X and Z scales.
+type CapsuleCollider struct {
+	baseCollider
+
+	center mgl32.Vec3
+	radius float32
+	height float32
+}
+
+// NewCapsuleCollider creates a capsule collider of radius and height.
+func NewCapsuleCollider(radius, height float32) *CapsuleCollider {
+	c := &CapsuleCollider{
+		radius: radius,
+		height: height,
+	}
+
+	c.SetName("CapsuleCollider")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// Center returns the center of the capsule in the space of the object.
+func (c *CapsuleCollider) Center() mgl32.Vec3 {
+	return c.center
+}
+
+// SetCenter sets the center of the capsule in the space of the object.
+func (c *CapsuleCollider) SetCenter(center mgl32.Vec3) {
+	c.center = center
+	colliderChanged(c)
+}
+
+// Radius returns the radius of the capsule.
+func (c *CapsuleCollider) Radius() float32 {
+	return c.radius
+}
+
+// SetRadius sets the radius of the capsule.
+func (c *CapsuleCollider) SetRadius(radius float32) {
+	c.radius = radius
+	colliderChanged(c)
+}
+
+// Height returns the height of the capsule, from end to end.
+func (c *CapsuleCollider) Height() float32 {
+	return c.height
+}
+
+// SetHeight sets the height of the capsule, from end to end. Heights below
+// twice the radius make a sphere.
+func (c *CapsuleCollider) SetHeight(height float32) {
+	c.height = height
+	colliderChanged(c)
+}
+
+// Bounds returns the world space bounding box of the collider.
+func (c *CapsuleCollider) Bounds() math.AABB {
+	return c.shape().bounds()
+}
+
+// ClosestPoint returns the point of the collider closest to p.
+func (c *CapsuleCollider) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	return c.shape().closestPoint(p)
+}
+
+// Raycast returns the first hit on the collider by a ray within maxDistance.
+func (c *CapsuleCollider) Raycast(ray math.Ray, maxDistance float32) (RaycastHit, bool) {
+	return raycastShape(c, c.shape(), ray, maxDistance)
+}
+
+func (c *CapsuleCollider) shape() colliderShape {
+	return capsuleShape(worldCapsule(colliderMatrix(c), c.center, c.radius, c.height))
+}
+
+// worldCapsule returns the capsule of radius and height along the Y axis
+// around center, transformed by m.
+func worldCapsule(m mgl32.Mat4, center mgl32.Vec3, radius, height float32) math.Capsule {
+	axis := m.Col(1).Vec3()
+	scaleY := axis.Len()
+	if scaleY != 0 {
+		axis = axis.Mul(1 / scaleY)
+	}
+
+	r := radius * math.Max32(m.Col(0).Vec3().Len(), m.Col(2).Vec3().Len())
+	half := math.Max32(height*scaleY/2-r, 0)
+	p := mgl32.TransformCoordinate(center, m)
+
+	return math.Capsule{
+		A:      p.Sub(axis.Mul(half)),
+		B:      p.Add(axis.Mul(half)),
+		Radius: r,
+	}
+}
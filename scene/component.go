@@ -97,7 +97,9 @@ type BaseComponent struct {
 type BaseScriptComponent struct {
 	BaseComponent
 
-	active bool
+	order   int
+	ordered bool
+	active  bool
 }
 
 // GameObject returns the GameObject for this component.
@@ -149,6 +151,24 @@ func (c *BaseScriptComponent) SetActive(active bool) {
 	}
 }
 
+// ExecutionOrder returns the execution order set with SetExecutionOrder, and
+// if one was set.
+func (c *BaseScriptComponent) ExecutionOrder() (int, bool) {
+	return c.order, c.ordered
+}
+
+// SetExecutionOrder sets the execution order of this component, overriding
+// the default of its type. Scripts with a lower order are started and
+// updated first.
+func (c *BaseScriptComponent) SetExecutionOrder(order int) {
+	c.order = order
+	c.ordered = true
+
+	if c.gameobject != nil && c.gameobject.scene != nil && c.gameobject.scene.graph != nil {
+		c.gameobject.scene.graph.SetDirty()
+	}
+}
+
 // OnActivate is called when the component transitions to the active state.
 func (c *BaseScriptComponent) OnActivate() {}
 
@@ -0,0 +1,280 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"iter"
+
+	"github.com/haakenlabs/arc/system/time"
+)
+
+// Routine is the body of a coroutine. It suspends the coroutine by calling
+// yield with the condition to resume it on, and must return as soon as yield
+// returns false, which means the coroutine was stopped.
+//
+//	c.StartCoroutine(func(yield func(scene.Yield) bool) {
+//	    for i := 0; i < 3; i++ {
+//	        light.SetIntensity(10)
+//	        if !yield(scene.WaitSeconds(0.1)) {
+//	            return
+//	        }
+//	        light.SetIntensity(0)
+//	        if !yield(scene.WaitSeconds(0.1)) {
+//	            return
+//	        }
+//	    }
+//	})
+//
+// Routines run on the main thread, and may use the scene and graphics
+// functions like Update does.
+type Routine = iter.Seq[Yield]
+
+// Yield is a condition on which a suspended coroutine resumes. A nil Yield
+// resumes the coroutine on the next frame.
+type Yield interface {
+	// Ready reports if the coroutine can resume. It is called once per frame
+	// from the frame after the coroutine yielded, until it returns true.
+	Ready() bool
+}
+
+// Coroutine is a routine which runs across frames, resumed by the scene
+// after Update and before LateUpdate.
+type Coroutine struct {
+	owner   Component
+	next    func() (Yield, bool)
+	stop    func()
+	wait    Yield
+	running bool
+	stopped bool
+	done    bool
+}
+
+type waitFrames struct {
+	frames int
+}
+
+type waitSeconds struct {
+	seconds  float64
+	unscaled bool
+}
+
+type waitFunc struct {
+	fn     func() bool
+	result bool
+}
+
+// WaitFrames resumes a coroutine after n frames.
+func WaitFrames(n int) Yield {
+	return &waitFrames{frames: n}
+}
+
+// WaitSeconds resumes a coroutine after a number of seconds of scaled time.
+func WaitSeconds(seconds float64) Yield {
+	return &waitSeconds{seconds: seconds}
+}
+
+// WaitSecondsRealtime resumes a coroutine after a number of seconds,
+// ignoring the time scale.
+func WaitSecondsRealtime(seconds float64) Yield {
+	return &waitSeconds{seconds: seconds, unscaled: true}
+}
+
+// WaitUntil resumes a coroutine once cond returns true.
+func WaitUntil(cond func() bool) Yield {
+	return &waitFunc{fn: cond, result: true}
+}
+
+// WaitWhile resumes a coroutine once cond returns false.
+func WaitWhile(cond func() bool) Yield {
+	return &waitFunc{fn: cond, result: false}
+}
+
+func (w *waitFrames) Ready() bool {
+	w.frames--
+
+	return w.frames <= 0
+}
+
+func (w *waitSeconds) Ready() bool {
+	if w.unscaled {
+		w.seconds -= time.Delta()
+	} else {
+		w.seconds -= time.ScaledDelta()
+	}
+
+	return w.seconds <= 0
+}
+
+func (w *waitFunc) Ready() bool {
+	return w.fn() == w.result
+}
+
+// Ready reports if the coroutine has finished, so that a coroutine can yield
+// another to wait for it.
+func (c *Coroutine) Ready() bool {
+	return c.done
+}
+
+// Done reports if the coroutine has returned or was stopped.
+func (c *Coroutine) Done() bool {
+	return c.done
+}
+
+// Owner returns the component which started the coroutine, or nil if it
+// was started by the scene.
+func (c *Coroutine) Owner() Component {
+	return c.owner
+}
+
+// Stop stops the coroutine. Its yield returns false when it is next resumed,
+// at the latest on the next frame. Stopping a coroutine from within itself
+// takes effect when it yields.
+func (c *Coroutine) Stop() {
+	if c.done {
+		return
+	}
+
+	c.stopped = true
+	if !c.running {
+		c.finish()
+	}
+}
+
+// resume runs the coroutine up to its next yield if its wait is over.
+func (c *Coroutine) resume() {
+	if c.done {
+		return
+	}
+
+	if c.wait != nil && !c.wait.Ready() {
+		return
+	}
+
+	c.running = true
+	wait, ok := c.next()
+	c.running = false
+
+	if !ok || c.stopped {
+		c.finish()
+		return
+	}
+
+	c.wait = wait
+}
+
+// orphaned reports if the owner of the coroutine has left the scene.
+func (c *Coroutine) orphaned(s *Scene) bool {
+	if c.owner == nil {
+		return false
+	}
+
+	g := c.owner.GameObject()
+
+	return g == nil || g.scene != s
+}
+
+func (c *Coroutine) finish() {
+	c.done = true
+	c.stop()
+}
+
+// StartCoroutine starts a coroutine owned by a component, or by the scene if
+// owner is nil. The routine runs right away up to its first yield. The
+// coroutine is stopped when its owner is destroyed, and when the scene is
+// unloaded.
+func (s *Scene) StartCoroutine(owner Component, routine Routine) *Coroutine {
+	next, stop := iter.Pull(routine)
+
+	c := &Coroutine{
+		owner: owner,
+		next:  next,
+		stop:  stop,
+	}
+
+	c.resume()
+	if !c.done {
+		s.coroutines = append(s.coroutines, c)
+	}
+
+	return c
+}
+
+// StopCoroutines stops the coroutines started by a component.
+func (s *Scene) StopCoroutines(owner Component) {
+	for _, c := range s.coroutines {
+		if c.owner != nil && owner != nil && c.owner.ID() == owner.ID() {
+			c.Stop()
+		}
+	}
+}
+
+// StartCoroutine starts a coroutine owned by the component in the scene of
+// its object. It returns nil if the component is not in a scene.
+func (c *BaseScriptComponent) StartCoroutine(routine Routine) *Coroutine {
+	if c.gameobject == nil || c.gameobject.scene == nil {
+		return nil
+	}
+
+	return c.gameobject.scene.StartCoroutine(c, routine)
+}
+
+// StopAllCoroutines stops the coroutines started by the component.
+func (c *BaseScriptComponent) StopAllCoroutines() {
+	if c.gameobject == nil || c.gameobject.scene == nil {
+		return
+	}
+
+	c.gameobject.scene.StopCoroutines(c)
+}
+
+// updateCoroutines resumes the coroutines of the scene whose waits are over,
+// and drops those which have finished. Coroutines started while updating
+// are first resumed on the next frame.
+func (s *Scene) updateCoroutines() {
+	coroutines := s.coroutines
+
+	for _, c := range coroutines {
+		if c.orphaned(s) {
+			c.Stop()
+		}
+		c.resume()
+	}
+
+	running := s.coroutines[:0]
+	for _, c := range s.coroutines {
+		if !c.done {
+			running = append(running, c)
+		}
+	}
+	for i := len(running); i < len(s.coroutines); i++ {
+		s.coroutines[i] = nil
+	}
+	s.coroutines = running
+}
+
+// stopCoroutines stops every coroutine of the scene.
+func (s *Scene) stopCoroutines() {
+	for _, c := range s.coroutines {
+		c.Stop()
+	}
+	s.coroutines = nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// Decal projects a texture onto the geometry inside of a box, such as bullet
+// holes and splats. The box spans -1 to 1 on each axis of the transform of
+// the game object, and the texture is projected along its forward (-Z) axis,
+// with U along X and V along Y.
+//
+// Decals are drawn into the GBuffer after the geometry pass, so they only
+// appear on drawables of the deferred render path. They replace the albedo of
+// the surface, blended by the alpha of the texture and the color. Overlapping
+// decals do not blend with each other.
+type Decal struct {
+	BaseComponent
+
+	texture         graphics.Texture
+	color           core.Color
+	normalThreshold float32
+}
+
+// NewDecal creates a decal which projects texture.
+func NewDecal(texture graphics.Texture) *Decal {
+	d := &Decal{
+		texture:         texture,
+		color:           core.ColorWhite,
+		normalThreshold: 0.3,
+	}
+
+	d.SetName("Decal")
+	instance.MustAssign(d)
+
+	return d
+}
+
+// Texture returns the projected texture.
+func (d *Decal) Texture() graphics.Texture {
+	return d.texture
+}
+
+// SetTexture sets the projected texture.
+func (d *Decal) SetTexture(texture graphics.Texture) {
+	d.texture = texture
+}
+
+// Color returns the color the texture is multiplied with.
+func (d *Decal) Color() core.Color {
+	return d.color
+}
+
+// SetColor sets the color the texture is multiplied with. The alpha of the
+// color scales the opacity of the decal.
+func (d *Decal) SetColor(color core.Color) {
+	d.color = color
+}
+
+// NormalThreshold returns the smallest cosine of the angle between a surface
+// and the projection direction at which the decal is drawn.
+func (d *Decal) NormalThreshold() float32 {
+	return d.normalThreshold
+}
+
+// SetNormalThreshold sets the smallest cosine of the angle between a surface
+// and the projection direction at which the decal is drawn. Surfaces facing
+// away further are clipped, so the decal does not stretch over the sides of
+// geometry.
+func (d *Decal) SetNormalThreshold(threshold float32) {
+	d.normalThreshold = threshold
+}
+
+// Direction returns the world space direction the texture is projected
+// along.
+func (d *Decal) Direction() mgl32.Vec3 {
+	return d.GetTransform().ActiveMatrix().Mul4x1(mgl32.Vec4{0, 0, -1, 0}).Vec3().Normalize()
+}
+
+// Bounds returns the world space bounding box of the projection box.
+func (d *Decal) Bounds() math.AABB {
+	return math.NewAABB(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}).Transform(d.GetTransform().ActiveMatrix())
+}
@@ -22,10 +22,78 @@ SOFTWARE.
 
 package scene
 
-import "github.com/haakenlabs/arc/graphics"
+import (
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/math"
+)
 
 type Drawable interface {
 	Draw(*Camera)
-	DrawShader(*graphics.Shader, *Camera)
+	DrawShader(graphics.Shader, *Camera)
 	SupportsDeferred() bool
+
+	// Bounds returns the world space bounding box of the drawable, which is
+	// used for frustum culling. Drawables with unknown bounds return
+	// math.InfiniteAABB so they are never culled.
+	Bounds() math.AABB
+}
+
+// LitDrawable is a Drawable which is lit by the lights of the scene. In the
+// forward pass it is drawn once per light, other drawables are drawn once.
+type LitDrawable interface {
+	Drawable
+
+	// Lit reports if the drawable uses the light uniforms.
+	Lit() bool
+}
+
+// RenderQueue orders drawables within the forward pass. Lower queues are
+// drawn first.
+type RenderQueue uint8
+
+const (
+	// RenderQueueOpaque is for solid geometry.
+	RenderQueueOpaque RenderQueue = iota
+
+	// RenderQueueAlphaTest is for geometry which discards fragments, such as
+	// foliage.
+	RenderQueueAlphaTest
+
+	// RenderQueueTransparent is for blended geometry. It is drawn after all
+	// other geometry, sorted back to front, and always uses the forward path.
+	RenderQueueTransparent
+)
+
+// QueuedDrawable is a Drawable which selects its render queue. Other
+// drawables are in RenderQueueOpaque.
+type QueuedDrawable interface {
+	Drawable
+
+	// RenderQueue returns the render queue of the drawable.
+	RenderQueue() RenderQueue
+}
+
+func drawableQueue(d Drawable) RenderQueue {
+	if q, ok := d.(QueuedDrawable); ok {
+		return q.RenderQueue()
+	}
+
+	return RenderQueueOpaque
+}
+
+func isLit(d Drawable) bool {
+	if l, ok := d.(LitDrawable); ok {
+		return l.Lit()
+	}
+
+	return false
+}
+
+// drawableLayer returns the layer of the game object of a drawable.
+func drawableLayer(d Drawable) Layer {
+	if c, ok := d.(Component); ok && c.GameObject() != nil {
+		return c.GameObject().Layer()
+	}
+
+	return LayerDefault
 }
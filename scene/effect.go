@@ -22,6 +22,16 @@ SOFTWARE.
 
 package scene
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
 type EffectType uint8
 
 const (
@@ -35,10 +45,49 @@ const (
 // attached to a renderer such as a camera. The EffectWriter is responsible for
 // rendering different types of effects
 type EffectWriter interface {
+	// EffectPass draws a full screen pass from the current source into the
+	// other target, which becomes the source of the next pass.
 	EffectPass()
+
+	// EffectSource returns the texture read by the next EffectPass.
+	EffectSource() graphics.Texture
+
+	// EffectSize returns the size of the effect targets in pixels.
+	EffectSize() math.IVec2
+
+	// EffectTexture returns a texture of the renderer, such as the depth or
+	// velocity of the scene, or nil if the renderer does not provide it.
+	EffectTexture(texture CameraTexture) graphics.Texture
+
+	// ViewMatrix returns the view matrix the scene was rendered with.
+	ViewMatrix() mgl32.Mat4
+
+	// ProjectionMatrix returns the projection matrix the scene was rendered
+	// with.
+	ProjectionMatrix() mgl32.Mat4
 }
 
 type Effect interface {
 	Render(EffectWriter)
 	Type() EffectType
 }
+
+// effectName returns the name of an effect for debug groups. Effects with a
+// Name method use it, others are named after their type.
+func effectName(effect Effect) string {
+	if n, ok := effect.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+
+	return strings.TrimPrefix(fmt.Sprintf("%T", effect), "*")
+}
+
+// NormalsEffect is an Effect which reads CameraTextureNormals. The camera
+// only renders the view space normals of the scene when an enabled effect
+// requires them.
+type NormalsEffect interface {
+	Effect
+
+	// RequiresNormals reports if the effect reads the normals texture.
+	RequiresNormals() bool
+}
@@ -42,7 +42,7 @@ type EnvironmentLighting struct {
 }
 
 type Environment struct {
-	DeferredShader *graphics.Shader
+	DeferredShader graphics.Shader
 	Skybox         *Skybox
 	SunSource      *Light
 }
@@ -50,6 +50,8 @@ type GameObject struct {
 	children   []*GameObject
 	parent     *GameObject
 	scene      *Scene
+	tag        string
+	layer      Layer
 	active     bool
 }
 
@@ -67,6 +69,39 @@ func (g *GameObject) SetActive(active bool) {
 	}
 }
 
+// Layer returns the layer of this object.
+func (g *GameObject) Layer() Layer {
+	return g.layer
+}
+
+// SetLayer sets the layer of this object. Cameras only draw objects whose
+// layer is in their culling mask.
+func (g *GameObject) SetLayer(layer Layer) {
+	if layer < MaxLayers && layer != g.layer {
+		g.layer = layer
+		g.indexChanged()
+	}
+}
+
+// Tag returns the tag of this object, or an empty string if it has none.
+func (g *GameObject) Tag() string {
+	return g.tag
+}
+
+// SetTag sets the tag of this object. Tags name objects for Scene.FindWithTag
+// and FindObjectsWithTag; many objects may share a tag.
+func (g *GameObject) SetTag(tag string) {
+	if tag != g.tag {
+		g.tag = tag
+		g.indexChanged()
+	}
+}
+
+// CompareTag reports if this object has a tag.
+func (g *GameObject) CompareTag(tag string) bool {
+	return g.tag == tag
+}
+
 func (g *GameObject) Scene() *Scene {
 	return g.scene
 }
@@ -130,6 +165,15 @@ func (g *GameObject) AddComponent(component Component) {
 	g.components = append(g.components, component)
 	component.SetGameObject(g)
 	component.OnParentChanged()
+
+	if g.scene != nil {
+		g.scene.graph.notify(GraphEvent{
+			Type:       GraphComponentAdded,
+			Object:     g,
+			Components: []Component{component},
+			Parent:     g.parent,
+		})
+	}
 }
 
 // AddComponent removes a component from this object.
@@ -139,6 +183,15 @@ func (g *GameObject) RemoveComponent(id int32) {
 			g.components[i] = g.components[len(g.components)-1]
 			g.components = g.components[:len(g.components)-1]
 			v.SetGameObject(nil)
+
+			if g.scene != nil {
+				g.scene.graph.notify(GraphEvent{
+					Type:       GraphComponentRemoved,
+					Object:     g,
+					Components: []Component{v},
+					Parent:     g.parent,
+				})
+			}
 		}
 	}
 }
@@ -254,13 +307,61 @@ func (g *GameObject) Environment() *Environment {
 	return nil
 }
 
+// destroy notifies the components of the object that it is being destroyed,
+// and detaches them. It returns the IDs of the components and the object, to
+// be released.
+func (g *GameObject) destroy() []int32 {
+	for _, c := range g.components {
+		if l, ok := c.(DestroyListener); ok {
+			l.OnDestroy()
+		}
+	}
+
+	ids := make([]int32, 0, len(g.components)+1)
+	for _, c := range g.components {
+		c.SetGameObject(nil)
+		ids = append(ids, c.ID())
+	}
+
+	if g.scene != nil && g.scene.world != nil {
+		w, id := g.scene.world, g.ID()
+		w.Defer(func() {
+			w.remove(id)
+		})
+	}
+
+	g.parent = nil
+	g.children = nil
+	g.scene = nil
+
+	return append(ids, g.ID())
+}
+
+// indexChanged discards the lookups of the scene of the object after its tag
+// or layer changes.
+func (g *GameObject) indexChanged() {
+	if g.scene != nil && g.scene.graph != nil {
+		g.scene.graph.invalidateIndex()
+	}
+}
+
 func (g *GameObject) parentChanged() {
 	for _, v := range g.components {
 		v.OnParentChanged()
 	}
 }
 
+// boundsChanged updates the bounds of the components of the object in the
+// spatial index of its scene before the next query.
+func (g *GameObject) boundsChanged() {
+	if g.scene != nil && g.scene.spatial != nil {
+		g.scene.spatial.move(g)
+	}
+}
+
 func (g *GameObject) transformChanged() {
+	g.boundsChanged()
+
 	for _, v := range g.components {
 		v.OnTransformChanged()
 	}
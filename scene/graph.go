@@ -32,12 +32,64 @@ type GraphListener interface {
 	OnSceneGraphUpdate()
 }
 
+// GraphEventType is the kind of change described by a GraphEvent.
+type GraphEventType int
+
+const (
+	// GraphObjectAdded is raised for each object added to the graph,
+	// including the descendants of an added object.
+	GraphObjectAdded GraphEventType = iota
+	// GraphObjectRemoved is raised for each object removed from the graph,
+	// including the descendants of a removed object.
+	GraphObjectRemoved
+	// GraphComponentAdded is raised when a component is added to an object
+	// of the graph.
+	GraphComponentAdded
+	// GraphComponentRemoved is raised when a component is removed from an
+	// object of the graph.
+	GraphComponentRemoved
+	// GraphComponentChanged is raised when a component changes the objects
+	// or components it refers to, such as the renderers of a LOD group.
+	GraphComponentChanged
+	// GraphParentChanged is raised when an object is moved to a new parent.
+	GraphParentChanged
+)
+
+// GraphEvent describes a single change to the graph.
+type GraphEvent struct {
+	Type GraphEventType
+	// Object is the object which was added, removed, moved or had its
+	// components changed.
+	Object *GameObject
+	// Components are the components affected by the change. For object
+	// events they are all the components of the object, as they were when
+	// the event was raised.
+	Components []Component
+	// Parent is the parent of the object after the change, and Previous the
+	// parent before it.
+	Parent   *GameObject
+	Previous *GameObject
+}
+
+// GraphEventListener is implemented by components which keep caches of the
+// graph, to update them incrementally rather than from every component.
+//
+// Events are queued as the graph changes, and delivered in order when the
+// graph is updated, before OnSceneGraphUpdate is called. Only the components
+// in the graph after the update receive them.
+type GraphEventListener interface {
+	OnSceneGraphEvent(event GraphEvent)
+}
+
 type Graph struct {
 	root   *GameObject
 	graph  *sg.Graph
 	aCache []*GameObject
 	cCache []Component
+	sCache []ScriptComponent
 	scene  *Scene
+	index  *graphIndex
+	events []GraphEvent
 	dirty  bool
 }
 
@@ -66,8 +118,12 @@ func (s *Graph) SetDirty() {
 }
 
 func (s *Graph) Update() {
-	s.aCache = s.aCache[:0]
-	s.cCache = s.cCache[:0]
+	// The caches are replaced rather than reused, as objects added or
+	// removed while a message is sent would otherwise overwrite the list
+	// being iterated.
+	s.aCache = make([]*GameObject, 0, len(s.aCache))
+	s.cCache = make([]Component, 0, len(s.cCache))
+	s.sCache = make([]ScriptComponent, 0, len(s.sCache))
 
 	for _, v := range s.graph.DFS(0, false) {
 		n, err := s.graph.NodeAtVertex(v)
@@ -78,7 +134,17 @@ func (s *Graph) Update() {
 		s.cCache = append(s.cCache, n.(*GameObject).Components()...)
 	}
 
+	for _, c := range s.cCache {
+		if sc, ok := c.(ScriptComponent); ok {
+			s.sCache = append(s.sCache, sc)
+		}
+	}
+	sortScripts(s.sCache)
+
 	s.dirty = false
+	s.index = nil
+
+	s.sendEvents()
 
 	s.scene.OnSceneGraphUpdate()
 	s.SendMessage(MessageSGUpdate)
@@ -118,19 +184,23 @@ func (s *Graph) AddObject(object, parent *GameObject) error {
 
 	object.scene = s.scene
 
+	// Objects built before they were added only have local transforms.
+	object.parentChanged()
+
+	s.notifyObject(GraphObjectAdded, object, parent)
+
 	s.Update()
 
 	return nil
 }
 
+// RemoveObject removes an object and its descendants from the graph. Their
+// components are notified and detached, and the objects and components are
+// released.
 func (s *Graph) RemoveObject(object *GameObject) error {
 	var r []int32
 
 	descendants := s.Descendants(object, true)
-	for i := len(descendants) - 1; i >= 0; i-- {
-		r = append(r, descendants[i].ID())
-	}
-	r = append(r, object.ID())
 
 	d, err := s.graph.DescriptorByNode(object)
 	if err != nil {
@@ -141,6 +211,20 @@ func (s *Graph) RemoveObject(object *GameObject) error {
 		return err
 	}
 
+	if object.parent != nil {
+		object.parent.RemoveChild(object.ID())
+	}
+
+	for i := len(descendants) - 1; i >= 0; i-- {
+		s.notifyObject(GraphObjectRemoved, descendants[i], nil)
+	}
+	s.notifyObject(GraphObjectRemoved, object, nil)
+
+	for i := len(descendants) - 1; i >= 0; i-- {
+		r = append(r, descendants[i].destroy()...)
+	}
+	r = append(r, object.destroy()...)
+
 	instance.Release(r...)
 
 	s.Update()
@@ -166,8 +250,16 @@ func (s *Graph) MoveObject(object, parent *GameObject) error {
 	oldParent.RemoveChild(object.ID())
 
 	object.parent = parent
+	parent.AddChild(object)
 	object.parentChanged()
 
+	s.notify(GraphEvent{
+		Type:     GraphParentChanged,
+		Object:   object,
+		Parent:   parent,
+		Previous: oldParent,
+	})
+
 	s.Update()
 
 	return nil
@@ -205,8 +297,69 @@ func (s *Graph) Components() []Component {
 	return s.cCache
 }
 
+// SendScriptMessage sends a message to the script components of active
+// objects in execution order. Only the start and update messages are
+// supported.
+func (s *Graph) SendScriptMessage(message Message) {
+	for _, c := range s.sCache {
+		g := c.GameObject()
+		if g == nil || !g.active {
+			continue
+		}
+
+		switch message {
+		case MessageStart:
+			c.Start()
+		case MessageUpdate:
+			c.Update()
+		case MessageLateUpdate:
+			c.LateUpdate()
+		case MessageFixedUpdate:
+			c.FixedUpdate()
+		}
+	}
+}
+
 func (s *Graph) SendMessage(message Message) {
 	for _, v := range s.aCache {
 		v.SendMessage(message)
 	}
 }
+
+// notify queues an event, to be delivered on the next update of the graph.
+func (s *Graph) notify(event GraphEvent) {
+	s.events = append(s.events, event)
+	s.dirty = true
+}
+
+// notifyObject queues an object event with a copy of the components of the
+// object, as they may be detached before the event is delivered.
+func (s *Graph) notifyObject(t GraphEventType, object, parent *GameObject) {
+	s.notify(GraphEvent{
+		Type:       t,
+		Object:     object,
+		Components: append([]Component(nil), object.components...),
+		Parent:     parent,
+	})
+}
+
+// sendEvents delivers the queued events to the listeners of the graph.
+// Events raised by the listeners are delivered on the next update.
+func (s *Graph) sendEvents() {
+	if len(s.events) == 0 {
+		return
+	}
+
+	events := s.events
+	s.events = nil
+
+	for _, c := range s.cCache {
+		l, ok := c.(GraphEventListener)
+		if !ok {
+			continue
+		}
+		for i := range events {
+			l.OnSceneGraphEvent(events[i])
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	fmath "github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+const (
+	// IrradianceSize is the face size of convolved irradiance maps.
+	IrradianceSize = 32
+
+	// SpecularMaxSize is the largest face size of prefiltered specular maps.
+	SpecularMaxSize = 256
+
+	// specularMipLevels is the number of roughness levels of specular maps,
+	// from 0 at the base level to 1 at the last level.
+	specularMipLevels = 5
+
+	// specularSamples is the number of samples taken per texel when
+	// prefiltering specular maps.
+	specularSamples = 1024
+)
+
+// CubemapViews are the view matrices of the faces of a cubemap, in the order
+// of the cubemap faces, used to draw a shader into each face.
+var CubemapViews = [6]mgl32.Mat4{
+	// X
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 1, 0}),
+	// Y
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, -1}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, 1}),
+	// Z
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}),
+	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}),
+}
+
+// NewSpecularCubemap allocates a specular map for a radiance map of the
+// given face size, with a mip level per roughness level.
+func NewSpecularCubemap(size int32) (*graphics.TextureCubemap, error) {
+	if size > SpecularMaxSize {
+		size = SpecularMaxSize
+	}
+
+	spec := graphics.NewTextureCubemap(fmath.IVec2{size, size}, graphics.TextureFormatRGBA16)
+	if err := spec.Alloc(); err != nil {
+		return nil, err
+	}
+
+	// Allocate the mip chain, then limit it to the prefiltered levels.
+	spec.GenerateMipmaps()
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, specularLevels(spec)-1)
+
+	return spec, nil
+}
+
+// NewIrradianceCubemap allocates an irradiance map.
+func NewIrradianceCubemap() (*graphics.TextureCubemap, error) {
+	irrd := graphics.NewTextureCubemap(fmath.IVec2{IrradianceSize, IrradianceSize}, graphics.TextureFormatRGBA16)
+	if err := irrd.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return irrd, nil
+}
+
+// PrefilterSpecular prefilters a radiance map into a specular map made by
+// NewSpecularCubemap. Each mip level of the result is convolved with the GGX
+// distribution of a higher roughness. The mip chain of the radiance map
+// should be generated to avoid aliasing.
+func PrefilterSpecular(radiance, spec *graphics.TextureCubemap, fbo graphics.Framebuffer) {
+	levels := specularLevels(spec)
+
+	s := shader.MustGet("utils/prefilter")
+
+	fbo.Bind()
+	s.Bind()
+	s.SetPass("pass_specular")
+	s.SetUniform("f_resolution", float32(radiance.Size().Y()))
+	s.SetUniform("f_samples", uint32(specularSamples))
+	graphics.ActiveDevice().BindTexture(0, radiance)
+
+	for level := int32(0); level < levels; level++ {
+		roughness := float32(0)
+		if levels > 1 {
+			roughness = float32(level) / float32(levels-1)
+		}
+
+		s.SetUniform("f_roughness", roughness)
+		renderCubemap(fbo, s, spec, level)
+	}
+
+	s.Unbind()
+	fbo.Unbind()
+}
+
+// ConvolveIrradiance convolves a radiance map with a cosine lobe into an
+// irradiance map made by NewIrradianceCubemap.
+func ConvolveIrradiance(radiance, irrd *graphics.TextureCubemap, fbo graphics.Framebuffer) {
+	s := shader.MustGet("utils/prefilter")
+
+	fbo.Bind()
+	s.Bind()
+	s.SetPass("pass_irradiance")
+	graphics.ActiveDevice().BindTexture(0, radiance)
+
+	renderCubemap(fbo, s, irrd, 0)
+
+	s.Unbind()
+	fbo.Unbind()
+}
+
+// specularLevels returns the number of prefiltered mip levels of a specular
+// map.
+func specularLevels(spec *graphics.TextureCubemap) int32 {
+	levels := int32(specularMipLevels)
+	if uint32(levels) > spec.MipLevels() {
+		levels = int32(spec.MipLevels())
+	}
+
+	return levels
+}
+
+// renderCubemap draws a shader into the six faces of a mip level of a
+// cubemap. The framebuffer and the shader must be bound.
+func renderCubemap(fbo graphics.Framebuffer, s graphics.Shader, cubemap *graphics.TextureCubemap, level int32) {
+	size := cubemap.Size().Y() >> uint(level)
+	if size < 1 {
+		size = 1
+	}
+	fbo.SetSize(fmath.IVec2{size, size})
+
+	mesh := graphics.NewMeshQuadBack()
+	defer mesh.Dealloc()
+	mesh.Bind()
+
+	graphics.ActiveDevice().SetDepthTest(false)
+	graphics.ActiveDevice().SetDepthWrite(false)
+
+	s.SetUniform("v_projection_matrix", mgl32.Perspective(fmath.Pi32/2.0, 1.0, 0.1, 2.0))
+
+	for i := uint32(0); i < 6; i++ {
+		s.SetUniform("v_view_matrix", CubemapViews[i])
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, cubemap.Reference(), level)
+		mesh.Draw()
+	}
+
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, 0, 0)
+
+	graphics.ActiveDevice().SetDepthWrite(true)
+	graphics.ActiveDevice().SetDepthTest(true)
+
+	mesh.Unbind()
+}
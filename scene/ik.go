@@ -0,0 +1,248 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/system/instance"
+
+	fmath "github.com/haakenlabs/arc/pkg/math"
+)
+
+var _ ScriptComponent = &TwoBoneIK{}
+var _ ScriptComponent = &LookAtConstraint{}
+
+// ikEpsilon guards the solvers against degenerate chains.
+const ikEpsilon = 1e-4
+
+// TwoBoneIK is an analytic solver for a limb of two bones, such as a leg or an
+// arm. The solver runs in LateUpdate, after animation has been sampled.
+type TwoBoneIK struct {
+	BaseScriptComponent
+
+	// Root, Mid and End are the joints of the limb, e.g. hip, knee and ankle.
+	Root Transform
+	Mid  Transform
+	End  Transform
+
+	// Target is the transform the end joint reaches for. If nil,
+	// TargetPosition is used instead.
+	Target         Transform
+	TargetPosition mgl32.Vec3
+
+	// Pole is an optional transform the middle joint bends towards. If nil,
+	// the current bend of the limb is kept, falling back to PoleDirection
+	// when the limb is straight.
+	Pole          Transform
+	PoleDirection mgl32.Vec3
+
+	// Weight blends between the animated pose (0) and the solved pose (1).
+	Weight float32
+}
+
+// LookAtConstraint rotates a transform so that its forward axis points at a
+// target, such as a head tracking a point of interest. The constraint runs in
+// LateUpdate, after animation has been sampled.
+type LookAtConstraint struct {
+	BaseScriptComponent
+
+	// Target is the transform to look at. If nil, TargetPosition is used
+	// instead.
+	Target         Transform
+	TargetPosition mgl32.Vec3
+
+	// Forward is the local axis which is aimed at the target.
+	Forward mgl32.Vec3
+
+	// MaxAngle limits the rotation away from the animated pose, in radians.
+	// Zero means no limit.
+	MaxAngle float32
+
+	// Weight blends between the animated pose (0) and the constrained pose (1).
+	Weight float32
+}
+
+func NewTwoBoneIK() *TwoBoneIK {
+	c := &TwoBoneIK{
+		PoleDirection: mgl32.Vec3{0, 0, 1},
+		Weight:        1.0,
+	}
+
+	c.SetName("TwoBoneIK")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// LateUpdate solves the limb.
+func (c *TwoBoneIK) LateUpdate() {
+	if c.Root == nil || c.Mid == nil || c.End == nil || c.Weight <= 0 {
+		return
+	}
+
+	target := c.TargetPosition
+	if c.Target != nil {
+		target = worldPosition(c.Target)
+	}
+
+	a := worldPosition(c.Root)
+	b := worldPosition(c.Mid)
+	e := worldPosition(c.End)
+
+	lab := b.Sub(a).Len()
+	lcb := b.Sub(e).Len()
+	if lab < ikEpsilon || lcb < ikEpsilon {
+		return
+	}
+
+	lat := fmath.Clamp32(target.Sub(a).Len(), ikEpsilon, lab+lcb-ikEpsilon)
+
+	// Current and desired interior angles of the limb.
+	acAB0 := angleBetween(e.Sub(a), b.Sub(a))
+	baBC0 := angleBetween(a.Sub(b), e.Sub(b))
+	acAT0 := angleBetween(e.Sub(a), target.Sub(a))
+
+	acAB1 := acosf((lcb*lcb - lab*lab - lat*lat) / (-2 * lab * lat))
+	baBC1 := acosf((lat*lat - lab*lab - lcb*lcb) / (-2 * lab * lcb))
+
+	bend := b.Sub(a)
+	if c.Pole != nil {
+		bend = worldPosition(c.Pole).Sub(a)
+	}
+
+	axis0 := e.Sub(a).Cross(bend)
+	if axis0.Len() < ikEpsilon {
+		axis0 = e.Sub(a).Cross(worldRotation(c.Root).Rotate(c.PoleDirection))
+	}
+	if axis0.Len() < ikEpsilon {
+		return
+	}
+	axis0 = axis0.Normalize()
+
+	axis1 := e.Sub(a).Cross(target.Sub(a))
+
+	aGR := worldRotation(c.Root)
+	bGR := worldRotation(c.Mid)
+
+	r0 := mgl32.QuatRotate(acAB1-acAB0, aGR.Inverse().Rotate(axis0))
+	r1 := mgl32.QuatRotate(baBC1-baBC0, bGR.Inverse().Rotate(axis0))
+
+	r2 := mgl32.QuatIdent()
+	if axis1.Len() > ikEpsilon {
+		r2 = mgl32.QuatRotate(acAT0, aGR.Inverse().Rotate(axis1.Normalize()))
+	}
+
+	aLR := c.Root.Rotation()
+	bLR := c.Mid.Rotation()
+
+	c.Root.SetRotation(blendRotation(aLR, aLR.Mul(r0.Mul(r2)), c.Weight))
+	c.Mid.SetRotation(blendRotation(bLR, bLR.Mul(r1), c.Weight))
+}
+
+func NewLookAtConstraint() *LookAtConstraint {
+	c := &LookAtConstraint{
+		Forward: mgl32.Vec3{0, 0, 1},
+		Weight:  1.0,
+	}
+
+	c.SetName("LookAtConstraint")
+	instance.MustAssign(c)
+
+	return c
+}
+
+// LateUpdate applies the constraint.
+func (c *LookAtConstraint) LateUpdate() {
+	t := c.GetTransform()
+	if t == nil || c.Weight <= 0 || c.Forward.Len() < ikEpsilon {
+		return
+	}
+
+	target := c.TargetPosition
+	if c.Target != nil {
+		target = worldPosition(c.Target)
+	}
+
+	dir := target.Sub(worldPosition(t))
+	if dir.Len() < ikEpsilon {
+		return
+	}
+
+	gr := worldRotation(t)
+	forward := gr.Rotate(c.Forward.Normalize())
+
+	delta := mgl32.QuatBetweenVectors(forward, dir.Normalize())
+
+	if c.MaxAngle > 0 {
+		if angle := angleBetween(forward, dir); angle > c.MaxAngle {
+			delta = mgl32.QuatSlerp(mgl32.QuatIdent(), delta, c.MaxAngle/angle)
+		}
+	}
+
+	// Convert the new world rotation back into the space of the parent.
+	lr := t.Rotation()
+	solved := gr.Mul(lr.Inverse()).Inverse().Mul(delta.Mul(gr))
+
+	t.SetRotation(blendRotation(lr, solved, c.Weight))
+}
+
+// worldPosition returns the position of the transform in world space.
+func worldPosition(t Transform) mgl32.Vec3 {
+	return t.ActiveMatrix().Col(3).Vec3()
+}
+
+// worldRotation returns the rotation of the transform in world space.
+func worldRotation(t Transform) mgl32.Quat {
+	r := t.Rotation()
+
+	if g := t.GameObject(); g != nil {
+		for p := g.Parent(); p != nil; p = p.Parent() {
+			r = p.Transform().Rotation().Mul(r)
+		}
+	}
+
+	return r
+}
+
+func blendRotation(from, to mgl32.Quat, weight float32) mgl32.Quat {
+	if weight >= 1 {
+		return to.Normalize()
+	}
+
+	return mgl32.QuatSlerp(from, to, weight).Normalize()
+}
+
+func angleBetween(a, b mgl32.Vec3) float32 {
+	if a.Len() < ikEpsilon || b.Len() < ikEpsilon {
+		return 0
+	}
+
+	return acosf(a.Normalize().Dot(b.Normalize()))
+}
+
+func acosf(x float32) float32 {
+	return float32(math.Acos(float64(fmath.Clamp32(x, -1, 1))))
+}
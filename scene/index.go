@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"reflect"
+)
+
+// graphIndex holds lookups of the objects and components of a graph. It is
+// rebuilt on the first query after the graph changes.
+type graphIndex struct {
+	tags   map[string][]*GameObject
+	layers [MaxLayers][]*GameObject
+	types  map[reflect.Type][]Component
+}
+
+// lookup returns the index of the graph, updating the graph and rebuilding
+// the index if either is out of date.
+func (s *Graph) lookup() *graphIndex {
+	if s.dirty {
+		s.Update()
+	}
+
+	if s.index != nil {
+		return s.index
+	}
+
+	s.index = &graphIndex{
+		tags:  make(map[string][]*GameObject),
+		types: make(map[reflect.Type][]Component),
+	}
+
+	for _, g := range s.aCache {
+		if g == s.root {
+			continue
+		}
+		if g.tag != "" {
+			s.index.tags[g.tag] = append(s.index.tags[g.tag], g)
+		}
+		s.index.layers[g.layer] = append(s.index.layers[g.layer], g)
+	}
+
+	return s.index
+}
+
+// invalidateIndex discards the index, so it is rebuilt by the next query.
+func (s *Graph) invalidateIndex() {
+	s.index = nil
+}
+
+// FindWithTag returns the first object with a tag, in depth first order, or
+// nil if there is none.
+func (s *Scene) FindWithTag(tag string) *GameObject {
+	if objects := s.graph.lookup().tags[tag]; len(objects) != 0 {
+		return objects[0]
+	}
+
+	return nil
+}
+
+// FindObjectsWithTag returns the objects with a tag, in depth first order.
+// The slice must not be modified.
+func (s *Scene) FindObjectsWithTag(tag string) []*GameObject {
+	return s.graph.lookup().tags[tag]
+}
+
+// FindObjectsInLayer returns the objects in a layer, in depth first order.
+// The slice must not be modified.
+func (s *Scene) FindObjectsInLayer(layer Layer) []*GameObject {
+	if layer >= MaxLayers {
+		return nil
+	}
+
+	return s.graph.lookup().layers[layer]
+}
+
+// FindObjectsInMask returns the objects whose layer is in a mask, in depth
+// first order.
+func (s *Scene) FindObjectsInMask(mask LayerMask) []*GameObject {
+	var objects []*GameObject
+
+	for _, g := range s.graph.lookup().layers {
+		if len(g) != 0 && mask.Contains(g[0].layer) {
+			objects = append(objects, g...)
+		}
+	}
+
+	return objects
+}
+
+// FindObjectsOfType returns the components of a scene which are of type T,
+// in depth first order. T may be a concrete component type or an interface.
+// Results are cached per type until the scene graph changes. The slice must
+// not be modified.
+func FindObjectsOfType[T any](s *Scene) []T {
+	index := s.graph.lookup()
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	components, ok := index.types[t]
+	if !ok {
+		for _, g := range s.graph.aCache {
+			if g == s.graph.root {
+				continue
+			}
+			for _, c := range g.components {
+				if _, ok := c.(T); ok {
+					components = append(components, c)
+				}
+			}
+		}
+		index.types[t] = components
+	}
+
+	objects := make([]T, len(components))
+	for i := range components {
+		objects[i] = components[i].(T)
+	}
+
+	return objects
+}
+
+// FindObjectOfType returns the first component of a scene which is of type
+// T, in depth first order, and whether there is one.
+func FindObjectOfType[T any](s *Scene) (T, bool) {
+	var zero T
+
+	if objects := FindObjectsOfType[T](s); len(objects) != 0 {
+		return objects[0], true
+	}
+
+	return zero, false
+}
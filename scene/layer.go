@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+// Layer groups game objects for selective rendering. A game object is in
+// exactly one of MaxLayers layers.
+type Layer uint8
+
+const (
+	LayerDefault Layer = iota
+	LayerUI
+	LayerGizmos
+)
+
+// MaxLayers is the number of layers.
+const MaxLayers = 32
+
+// LayerMask is a set of layers, one bit per layer.
+type LayerMask uint32
+
+const (
+	LayerMaskNone LayerMask = 0
+	LayerMaskAll  LayerMask = 0xFFFFFFFF
+)
+
+// MaskOf returns a mask containing the given layers.
+func MaskOf(layers ...Layer) LayerMask {
+	var m LayerMask
+
+	for _, l := range layers {
+		m = m.With(l)
+	}
+
+	return m
+}
+
+// Contains reports if the layer is in the mask.
+func (m LayerMask) Contains(layer Layer) bool {
+	if layer >= MaxLayers {
+		return false
+	}
+
+	return m&(1<<layer) != 0
+}
+
+// With returns the mask with the layer added.
+func (m LayerMask) With(layer Layer) LayerMask {
+	if layer >= MaxLayers {
+		return m
+	}
+
+	return m | 1<<layer
+}
+
+// Without returns the mask with the layer removed.
+func (m LayerMask) Without(layer Layer) LayerMask {
+	if layer >= MaxLayers {
+		return m
+	}
+
+	return m &^ (1 << layer)
+}
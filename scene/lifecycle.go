@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/system/time"
+)
+
+// DestroyListener is implemented by components which clean up when their
+// object is destroyed. OnDestroy is called before the components of the
+// object are detached and released.
+type DestroyListener interface {
+	OnDestroy()
+}
+
+// Prefab is a template of an object and its children, which can be
+// instantiated any number of times. Only components of registered types are
+// part of a prefab.
+type Prefab struct {
+	data ObjectData
+}
+
+// pendingDestroy is an object waiting to be destroyed.
+type pendingDestroy struct {
+	object *GameObject
+	at     float64
+}
+
+// NewPrefab creates a prefab from the description of an object.
+func NewPrefab(data ObjectData) *Prefab {
+	return &Prefab{data: data}
+}
+
+// NewPrefabFromObject creates a prefab from the current state of an object
+// and its children.
+func NewPrefabFromObject(object *GameObject) (*Prefab, error) {
+	data, err := objectData(object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Prefab{data: data}, nil
+}
+
+// ReadPrefab reads a prefab from the JSON description of an object, in the
+// format of the objects of a scene file.
+func ReadPrefab(r io.Reader) (*Prefab, error) {
+	p := &Prefab{}
+	if err := json.NewDecoder(r).Decode(&p.data); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Data returns the description of the object of the prefab.
+func (p *Prefab) Data() ObjectData {
+	return p.data
+}
+
+// Instantiate creates an object and its children from a prefab, and adds it
+// to the scene under parent, or at the top of the scene if parent is nil.
+// Once the scene has started, the components of the new objects are woken
+// and started right away.
+func (s *Scene) Instantiate(prefab *Prefab, parent *GameObject) (*GameObject, error) {
+	created, err := s.instantiate([]ObjectData{prefab.data}, parent)
+	if err != nil {
+		for _, g := range created {
+			s.RemoveObject(g)
+		}
+		return nil, err
+	}
+
+	g := created[0]
+
+	if s.started {
+		objects := append([]*GameObject{g}, s.Descendants(g, false)...)
+		for _, o := range objects {
+			o.SendMessage(MessageActivate)
+		}
+		for _, o := range objects {
+			o.SendMessage(MessageStart)
+		}
+	}
+
+	return g, nil
+}
+
+// Destroy removes an object and its children from the scene after delay
+// seconds. Objects are removed at the end of the frame, after every
+// component has been updated, so they can be destroyed from within Update.
+func (s *Scene) Destroy(object *GameObject, delay float64) {
+	if object == nil {
+		return
+	}
+
+	s.destroyed = append(s.destroyed, pendingDestroy{
+		object: object,
+		at:     time.Now() + delay,
+	})
+}
+
+// Destroy removes an object from its scene after delay seconds. It does
+// nothing if the object is not in a scene.
+func Destroy(object *GameObject, delay float64) {
+	if object == nil || object.Scene() == nil {
+		return
+	}
+
+	object.Scene().Destroy(object, delay)
+}
+
+// removeDestroyed removes the objects whose destruction is due.
+func (s *Scene) removeDestroyed() {
+	if len(s.destroyed) == 0 {
+		return
+	}
+
+	now := time.Now()
+	pending := s.destroyed[:0]
+	var due []*GameObject
+
+	for _, p := range s.destroyed {
+		if p.at > now {
+			pending = append(pending, p)
+		} else {
+			due = append(due, p.object)
+		}
+	}
+	s.destroyed = pending
+
+	// Objects may have been removed already, along with an ancestor or
+	// more than once.
+	for _, g := range due {
+		if g.scene != s {
+			continue
+		}
+
+		if err := s.RemoveObject(g); err != nil {
+			logrus.Error(err)
+		}
+	}
+}
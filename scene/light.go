@@ -22,6 +22,312 @@ SOFTWARE.
 
 package scene
 
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+type LightType int32
+
+const (
+	LightDirectional LightType = iota
+	LightPoint
+	LightSpot
+)
+
+// lightNone is the light index uniform value for drawing without a light.
+const lightNone int32 = -1
+
+// MaxLights is the number of lights a camera uploads to its light buffer.
+// Further lights of a scene are ignored.
+const MaxLights = 32
+
+// lightShadowUnit is the texture unit shadow maps are bound to.
+const lightShadowUnit = 8
+
+// Light is a light source. Directional lights shine along the forward (-Z)
+// direction of their transform, point lights from its position in all
+// directions, and spot lights from its position in a cone along its forward
+// direction.
 type Light struct {
 	BaseComponent
+
+	lightType  LightType
+	color      core.Color
+	intensity  float32
+	lightRange float32
+	spotAngle  float32
+	spotBlend  float32
+
+	shadowMap         *graphics.ShadowMap
+	shadowDistance    float32
+	shadowCascades    int32
+	shadowSplits      []float32
+	shadowSplitLambda float32
+	shadowView        mgl32.Mat4
+	shadowMatrices    [graphics.MaxShadowCascades]mgl32.Mat4
+	shadowFar         [graphics.MaxShadowCascades]float32
+}
+
+func NewLight(lightType LightType) *Light {
+	l := &Light{
+		lightType:  lightType,
+		color:      core.ColorWhite,
+		intensity:  1.0,
+		lightRange: 10.0,
+		spotAngle:  0.785,
+		spotBlend:  0.15,
+
+		shadowDistance:    50.0,
+		shadowCascades:    1,
+		shadowSplitLambda: 0.75,
+	}
+
+	l.SetName("Light")
+	instance.MustAssign(l)
+
+	return l
+}
+
+func (l *Light) Type() LightType {
+	return l.lightType
+}
+
+func (l *Light) Color() core.Color {
+	return l.color
+}
+
+func (l *Light) Intensity() float32 {
+	return l.intensity
+}
+
+// Range returns the distance at which point and spot lights fade out.
+func (l *Light) Range() float32 {
+	return l.lightRange
+}
+
+// SpotAngle returns the angle of the cone of a spot light, in radians.
+func (l *Light) SpotAngle() float32 {
+	return l.spotAngle
+}
+
+// SpotBlend returns the fraction of the cone of a spot light which fades out
+// towards its edge.
+func (l *Light) SpotBlend() float32 {
+	return l.spotBlend
+}
+
+func (l *Light) SetType(lightType LightType) {
+	l.lightType = lightType
+}
+
+func (l *Light) SetColor(color core.Color) {
+	l.color = color
+}
+
+func (l *Light) SetIntensity(intensity float32) {
+	l.intensity = intensity
+}
+
+func (l *Light) SetRange(lightRange float32) {
+	l.lightRange = lightRange
+}
+
+func (l *Light) SetSpotAngle(angle float32) {
+	l.spotAngle = angle
+}
+
+func (l *Light) SetSpotBlend(blend float32) {
+	l.spotBlend = mgl32.Clamp(blend, 0, 1)
+}
+
+// Position returns the world space position of the light.
+func (l *Light) Position() mgl32.Vec3 {
+	if t := l.GetTransform(); t != nil {
+		return t.ActiveMatrix().Col(3).Vec3()
+	}
+
+	return mgl32.Vec3{}
+}
+
+// Direction returns the world space direction the light shines in.
+func (l *Light) Direction() mgl32.Vec3 {
+	if t := l.GetTransform(); t != nil {
+		return t.ActiveMatrix().Mul4x1(mgl32.Vec4{0, 0, -1, 0}).Vec3().Normalize()
+	}
+
+	return mgl32.Vec3{0, 0, -1}
+}
+
+// EnableShadows creates a shadow map for the light. Only directional lights
+// cast shadows.
+func (l *Light) EnableShadows(resolution int32) error {
+	if l.shadowMap != nil {
+		return l.shadowMap.SetResolution(resolution)
+	}
+
+	s := graphics.NewShadowMap(resolution, l.shadowCascades)
+	if err := s.Alloc(); err != nil {
+		instance.Release(s.Depth().ID(), s.ID())
+		return err
+	}
+
+	l.shadowMap = s
+
+	return nil
+}
+
+// DisableShadows releases the shadow map of the light.
+func (l *Light) DisableShadows() {
+	if l.shadowMap != nil {
+		instance.Release(l.shadowMap.Depth().ID(), l.shadowMap.ID())
+		l.shadowMap = nil
+	}
+}
+
+// CastsShadows reports if the light renders a shadow map.
+func (l *Light) CastsShadows() bool {
+	return l.shadowMap != nil && l.lightType == LightDirectional
+}
+
+// ShadowMap returns the shadow map of the light, or nil if shadows are
+// disabled.
+func (l *Light) ShadowMap() *graphics.ShadowMap {
+	return l.shadowMap
+}
+
+// ShadowDistance returns the distance from the camera up to which shadows are
+// rendered.
+func (l *Light) ShadowDistance() float32 {
+	return l.shadowDistance
+}
+
+func (l *Light) SetShadowDistance(distance float32) {
+	l.shadowDistance = distance
+}
+
+// ShadowCascades returns the number of shadow cascades.
+func (l *Light) ShadowCascades() int32 {
+	return l.shadowCascades
+}
+
+// SetShadowCascades sets the number of shadow cascades, between 1 and
+// graphics.MaxShadowCascades. Each cascade covers a slice of the camera
+// frustum with its own shadow map layer, so shadows near the camera stay
+// sharp over large distances.
+func (l *Light) SetShadowCascades(cascades int32) {
+	if cascades < 1 {
+		cascades = 1
+	}
+	if cascades > graphics.MaxShadowCascades {
+		cascades = graphics.MaxShadowCascades
+	}
+
+	l.shadowCascades = cascades
+
+	if l.shadowMap != nil {
+		l.shadowMap.SetCascades(cascades)
+	}
+}
+
+// ShadowSplits returns the configured far distances of the cascades, or nil
+// if they are computed from the split lambda.
+func (l *Light) ShadowSplits() []float32 {
+	return l.shadowSplits
+}
+
+// SetShadowSplits sets the far distance from the camera of each cascade.
+// Missing distances are computed from the split lambda, and the last cascade
+// always ends at the shadow distance. Pass no distances to compute all of
+// them.
+func (l *Light) SetShadowSplits(distances ...float32) {
+	l.shadowSplits = distances
+}
+
+// ShadowSplitLambda returns the blend between logarithmic (1) and uniform (0)
+// cascade splits.
+func (l *Light) ShadowSplitLambda() float32 {
+	return l.shadowSplitLambda
+}
+
+func (l *Light) SetShadowSplitLambda(lambda float32) {
+	l.shadowSplitLambda = mgl32.Clamp(lambda, 0, 1)
+}
+
+// ShadowMatrices returns the view projection matrix of each cascade of the
+// last shadow pass.
+func (l *Light) ShadowMatrices() []mgl32.Mat4 {
+	return l.shadowMatrices[:l.shadowCascades]
+}
+
+// cascadeSplits returns the far distance of each cascade of a view between
+// near and far.
+func (l *Light) cascadeSplits(near, far float32) []float32 {
+	splits := make([]float32, l.shadowCascades)
+
+	for i := range splits {
+		if i < len(l.shadowSplits) && l.shadowSplits[i] > near && l.shadowSplits[i] < far {
+			splits[i] = l.shadowSplits[i]
+			continue
+		}
+
+		p := float64(i+1) / float64(len(splits))
+		log := float64(near) * math.Pow(float64(far/near), p)
+		uniform := float64(near) + float64(far-near)*p
+		splits[i] = float32(float64(l.shadowSplitLambda)*log + (1-float64(l.shadowSplitLambda))*uniform)
+	}
+
+	splits[len(splits)-1] = far
+
+	return splits
+}
+
+// SetUniforms selects the light in the light buffer of the camera and sets the
+// shadow uniforms of a bound shader. Index is the position of the light in the
+// light buffer.
+func (l *Light) SetUniforms(shader graphics.Shader, index int32) {
+	shader.SetUniform("f_light_index", index)
+
+	shader.SetUniform("f_shadow_enabled", l.CastsShadows())
+	if l.CastsShadows() {
+		for i := int32(0); i < l.shadowCascades; i++ {
+			shader.SetUniform(fmt.Sprintf("f_shadow_matrices[%d]", i), l.shadowMatrices[i])
+		}
+		shader.SetUniform("f_shadow_splits", mgl32.Vec4(l.shadowFar))
+		shader.SetUniform("f_shadow_cascades", l.shadowCascades)
+		shader.SetUniform("f_shadow_view", l.shadowView)
+		shader.SetUniform("f_shadow_bias", l.shadowMap.Bias())
+		shader.SetUniform("f_shadow_kernel", l.shadowMap.Filter().KernelRadius())
+		shader.SetUniform("f_shadow_texel", l.shadowMap.TexelSize())
+		graphics.ActiveDevice().BindTexture(lightShadowUnit, l.shadowMap.Depth())
+	}
+}
+
+// writeUniforms packs the light parameters as a LightData struct of the light
+// uniform block.
+func (l *Light) writeUniforms(s *graphics.Std140) {
+	outer := l.spotAngle / 2
+	inner := outer * (1 - l.spotBlend)
+
+	s.Vec4(l.Position().Vec4(float32(l.lightType)))
+	s.Vec4(l.Direction().Vec4(l.lightRange))
+	s.Vec4(l.color.Vec3().Mul(l.intensity).Vec4(0))
+	s.Vec4(mgl32.Vec4{float32(math.Cos(float64(inner))), float32(math.Cos(float64(outer))), 0, 0})
+}
+
+func LightComponent(g *GameObject) *Light {
+	c := g.Components()
+	for i := range c {
+		if lt, ok := c[i].(*Light); ok {
+			return lt
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"math"
+	"sort"
+
+	fmath "github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// LOD is a level of detail of a LODGroup.
+type LOD struct {
+	// ScreenSize is the smallest height of the group on screen, relative to
+	// the height of the viewport, at which the level is drawn.
+	ScreenSize float32
+
+	// Renderers are the renderers drawn for the level.
+	Renderers []*MeshRenderer
+}
+
+// LODGroup draws one of several levels of detail of an object, depending on
+// its size on screen. Cameras draw the renderers of the selected level in
+// place of the renderers themselves. Below the screen size of the last level
+// nothing is drawn.
+//
+// Near the screen size of a level, both adjacent levels are drawn with
+// complementary dither patterns, so the switch fades instead of popping.
+// Shaders support this through the f_lod_fade uniform.
+type LODGroup struct {
+	BaseComponent
+
+	lods      []LOD
+	fadeWidth float32
+}
+
+// NewLODGroup creates a LOD group without levels.
+func NewLODGroup() *LODGroup {
+	g := &LODGroup{
+		fadeWidth: 0.1,
+	}
+
+	g.SetName("LODGroup")
+	instance.MustAssign(g)
+
+	return g
+}
+
+// LODs returns the levels of the group, from the most to the least detailed.
+func (g *LODGroup) LODs() []LOD {
+	return g.lods
+}
+
+// SetLODs sets the levels of the group. They are ordered by screen size, from
+// the most to the least detailed.
+func (g *LODGroup) SetLODs(lods []LOD) {
+	g.lods = append(g.lods[:0], lods...)
+
+	sort.SliceStable(g.lods, func(i, j int) bool {
+		return g.lods[i].ScreenSize > g.lods[j].ScreenSize
+	})
+
+	if o := g.GameObject(); o != nil && o.scene != nil {
+		o.scene.graph.notify(GraphEvent{
+			Type:       GraphComponentChanged,
+			Object:     o,
+			Components: []Component{g},
+			Parent:     o.parent,
+		})
+	}
+}
+
+// FadeWidth returns the width of the cross-fade above the screen size of a
+// level, relative to the screen size.
+func (g *LODGroup) FadeWidth() float32 {
+	return g.fadeWidth
+}
+
+// SetFadeWidth sets the width of the cross-fade above the screen size of a
+// level, relative to the screen size. Zero disables cross-fading.
+func (g *LODGroup) SetFadeWidth(width float32) {
+	if width >= 0 {
+		g.fadeWidth = width
+	}
+}
+
+// Bounds returns the world space bounding box of all levels.
+func (g *LODGroup) Bounds() fmath.AABB {
+	b := fmath.EmptyAABB()
+
+	for i := range g.lods {
+		for _, r := range g.lods[i].Renderers {
+			b = b.Union(r.Bounds())
+		}
+	}
+
+	return b
+}
+
+// ScreenSize returns the height of the bounding sphere of the group on the
+// screen of a camera, relative to the height of its viewport.
+func (g *LODGroup) ScreenSize(c *Camera) float32 {
+	b := g.Bounds()
+	if b.Empty() {
+		return 0
+	}
+
+	radius := b.Extents().Len()
+
+	if c.Orthographic() {
+		return radius / c.OrthographicSize()
+	}
+
+	distance := b.Center().Sub(c.CameraPosition()).Len()
+	if distance <= radius {
+		return math.MaxFloat32
+	}
+
+	return radius / (distance * float32(math.Tan(float64(c.Fov()/2))))
+}
+
+// selectLOD appends the renderers of the level to draw for a camera to
+// drawables, and sets their dither fade.
+func (g *LODGroup) selectLOD(c *Camera, drawables []Drawable) []Drawable {
+	size := g.ScreenSize(c)
+
+	level := -1
+	for i := range g.lods {
+		if size >= g.lods[i].ScreenSize {
+			level = i
+			break
+		}
+	}
+	if level == -1 {
+		return drawables
+	}
+
+	var fade float32
+	if level > 0 && g.fadeWidth > 0 {
+		threshold := g.lods[level].ScreenSize
+		if width := threshold * g.fadeWidth; width > 0 && size < threshold+width {
+			fade = (size - threshold) / width
+		}
+	}
+
+	// The more detailed level keeps the pixels below the fade, the selected
+	// level the pixels above it.
+	if fade > 0 {
+		drawables = appendLOD(drawables, g.lods[level-1].Renderers, fade)
+	}
+
+	return appendLOD(drawables, g.lods[level].Renderers, -fade)
+}
+
+func appendLOD(drawables []Drawable, renderers []*MeshRenderer, fade float32) []Drawable {
+	for _, r := range renderers {
+		r.lodFade = fade
+		drawables = append(drawables, r)
+	}
+
+	return drawables
+}
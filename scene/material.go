@@ -23,11 +23,8 @@ SOFTWARE.
 package scene
 
 import (
-	"github.com/go-gl/gl/v4.3-core/gl"
-
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/graphics"
-	"github.com/haakenlabs/arc/system/asset/shader"
 	"github.com/haakenlabs/arc/system/instance"
 )
 
@@ -42,16 +39,30 @@ const (
 	MaterialTextureAlbedo
 	MaterialTextureNormal
 	MaterialTextureMetallic
+	MaterialTextureReflection
+)
+
+// Textures of the standard material. They are bound past the units used by
+// the camera for shadows, ambient occlusion and the BRDF lookup table.
+const (
+	MaterialTextureOcclusion MaterialTexture = iota + 11
+	MaterialTextureEmissive
 )
 
+// gbufferEmissiveUnit is the texture unit the emission of the GBuffer is
+// bound to in the ambient pass.
+const gbufferEmissiveUnit = 13
+
 const MaterialMaxTextures = 16
 
 type Material struct {
 	core.BaseObject
 
 	textures         [MaterialMaxTextures]graphics.Texture
+	samplers         [MaterialMaxTextures]graphics.Sampler
 	shaderProperties map[string]interface{}
-	shader           *graphics.Shader
+	shader           graphics.Shader
+	renderQueue      RenderQueue
 }
 
 func (m *Material) SetTexture(id MaterialTexture, texture graphics.Texture) {
@@ -60,7 +71,24 @@ func (m *Material) SetTexture(id MaterialTexture, texture graphics.Texture) {
 	}
 }
 
-func (m *Material) SetShader(shader *graphics.Shader) {
+// SetSampler sets the sampler overriding the sampling state of a texture. A
+// nil sampler uses the state of the texture.
+func (m *Material) SetSampler(id MaterialTexture, sampler graphics.Sampler) {
+	if id < MaterialMaxTextures {
+		m.samplers[id] = sampler
+	}
+}
+
+// Sampler returns the sampler of a texture, or nil if it has none.
+func (m *Material) Sampler(id MaterialTexture) graphics.Sampler {
+	if id >= MaterialMaxTextures {
+		return nil
+	}
+
+	return m.samplers[id]
+}
+
+func (m *Material) SetShader(shader graphics.Shader) {
 	m.shader = shader
 }
 
@@ -72,7 +100,7 @@ func (m *Material) Texture(id MaterialTexture) graphics.Texture {
 	return m.textures[id]
 }
 
-func (m *Material) Shader() *graphics.Shader {
+func (m *Material) Shader() graphics.Shader {
 	return m.shader
 }
 
@@ -85,7 +113,10 @@ func (m *Material) Bind() {
 
 	for i := range m.textures {
 		if m.textures[i] != nil {
-			m.textures[i].ActivateTexture(gl.TEXTURE0 + uint32(i))
+			graphics.ActiveDevice().BindTexture(uint32(i), m.textures[i])
+		}
+		if m.samplers[i] != nil {
+			m.samplers[i].Bind(uint32(i))
 		}
 	}
 	for key, value := range m.shaderProperties {
@@ -94,9 +125,25 @@ func (m *Material) Bind() {
 }
 
 func (m *Material) Unbind() {
+	for i := range m.samplers {
+		if m.samplers[i] != nil {
+			m.samplers[i].Unbind(uint32(i))
+		}
+	}
+
 	m.shader.Unbind()
 }
 
+// RenderQueue returns the render queue of drawables using the material.
+func (m *Material) RenderQueue() RenderQueue {
+	return m.renderQueue
+}
+
+// SetRenderQueue sets the render queue of drawables using the material.
+func (m *Material) SetRenderQueue(queue RenderQueue) {
+	m.renderQueue = queue
+}
+
 func (m *Material) SupportsDeferredPath() bool {
 	if m.shader != nil {
 		return m.shader.DeferredCapable()
@@ -109,6 +156,11 @@ func (m *Material) SetProperty(property string, value interface{}) {
 	m.shaderProperties[property] = value
 }
 
+// Property returns the value of a shader property, or nil if it is not set.
+func (m *Material) Property(property string) interface{} {
+	return m.shaderProperties[property]
+}
+
 func NewMaterial() *Material {
 	m := &Material{
 		shaderProperties: make(map[string]interface{}),
@@ -120,14 +172,13 @@ func NewMaterial() *Material {
 	return m
 }
 
+// NewMaterialPBR creates a copper colored standard material.
 func NewMaterialPBR() *Material {
-	m := NewMaterial()
-
-	m.shader = shader.DefaultShader()
+	m := NewStandardMaterial()
 
-	m.SetProperty("f_albedo", core.ColorCopper.Vec3())
-	m.SetProperty("f_metallic", 1.0)
-	m.SetProperty("f_roughness", 0.8)
+	m.SetAlbedo(core.ColorCopper)
+	m.SetMetallic(1.0)
+	m.SetRoughness(0.8)
 
-	return m
+	return m.Material
 }
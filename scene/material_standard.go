@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+// StandardMaterial is a physically based metallic-roughness material drawn
+// with the standard shader on both the forward and deferred paths. Each
+// factor is multiplied with its map if one is set, following the conventions
+// of common authoring tools: albedo and emissive maps are sRGB, and the
+// metallic roughness map holds roughness in green and metallic in blue.
+type StandardMaterial struct {
+	*Material
+}
+
+// NewStandardMaterial creates a white, fully rough dielectric material
+// without maps.
+func NewStandardMaterial() *StandardMaterial {
+	m := &StandardMaterial{
+		Material: NewMaterial(),
+	}
+
+	m.SetName("StandardMaterial")
+	m.SetShader(shader.DefaultShader())
+
+	m.SetAlbedo(core.ColorWhite)
+	m.SetMetallic(0)
+	m.SetRoughness(1)
+	m.SetEmissive(core.ColorBlack)
+
+	m.SetAlbedoMap(nil)
+	m.SetNormalMap(nil)
+	m.SetMetallicRoughnessMap(nil)
+	m.SetOcclusionMap(nil)
+	m.SetEmissiveMap(nil)
+
+	return m
+}
+
+func (m *StandardMaterial) Albedo() core.Color {
+	return m.color("f_albedo")
+}
+
+func (m *StandardMaterial) SetAlbedo(color core.Color) {
+	m.SetProperty("f_albedo", color.Vec3())
+}
+
+func (m *StandardMaterial) Metallic() float32 {
+	v, _ := m.Property("f_metallic").(float32)
+	return v
+}
+
+func (m *StandardMaterial) SetMetallic(metallic float32) {
+	m.SetProperty("f_metallic", mgl32.Clamp(metallic, 0, 1))
+}
+
+func (m *StandardMaterial) Roughness() float32 {
+	v, _ := m.Property("f_roughness").(float32)
+	return v
+}
+
+func (m *StandardMaterial) SetRoughness(roughness float32) {
+	m.SetProperty("f_roughness", mgl32.Clamp(roughness, 0, 1))
+}
+
+// Emissive returns the emitted color. It is not clamped, so values above one
+// can be used with HDR cameras.
+func (m *StandardMaterial) Emissive() core.Color {
+	return m.color("f_emissive")
+}
+
+func (m *StandardMaterial) SetEmissive(color core.Color) {
+	m.SetProperty("f_emissive", color.Vec3())
+}
+
+func (m *StandardMaterial) AlbedoMap() graphics.Texture {
+	return m.Texture(MaterialTextureAlbedo)
+}
+
+func (m *StandardMaterial) SetAlbedoMap(texture graphics.Texture) {
+	m.setMap(MaterialTextureAlbedo, "f_albedo_map_enabled", texture)
+}
+
+// NormalMap returns the tangent space normal map.
+func (m *StandardMaterial) NormalMap() graphics.Texture {
+	return m.Texture(MaterialTextureNormal)
+}
+
+func (m *StandardMaterial) SetNormalMap(texture graphics.Texture) {
+	m.setMap(MaterialTextureNormal, "f_normal_map_enabled", texture)
+}
+
+func (m *StandardMaterial) MetallicRoughnessMap() graphics.Texture {
+	return m.Texture(MaterialTextureMetallic)
+}
+
+func (m *StandardMaterial) SetMetallicRoughnessMap(texture graphics.Texture) {
+	m.setMap(MaterialTextureMetallic, "f_metallic_map_enabled", texture)
+}
+
+// OcclusionMap returns the ambient occlusion map. Its red channel darkens
+// ambient and image based lighting.
+func (m *StandardMaterial) OcclusionMap() graphics.Texture {
+	return m.Texture(MaterialTextureOcclusion)
+}
+
+func (m *StandardMaterial) SetOcclusionMap(texture graphics.Texture) {
+	m.setMap(MaterialTextureOcclusion, "f_occlusion_map_enabled", texture)
+}
+
+func (m *StandardMaterial) EmissiveMap() graphics.Texture {
+	return m.Texture(MaterialTextureEmissive)
+}
+
+func (m *StandardMaterial) SetEmissiveMap(texture graphics.Texture) {
+	m.setMap(MaterialTextureEmissive, "f_emissive_map_enabled", texture)
+}
+
+// setMap sets a map and the uniform telling the shader if it is set. The
+// shader is shared by all standard materials, so the uniform is always set.
+func (m *StandardMaterial) setMap(id MaterialTexture, enabled string, texture graphics.Texture) {
+	m.SetTexture(id, texture)
+	m.SetProperty(enabled, texture != nil)
+}
+
+func (m *StandardMaterial) color(property string) core.Color {
+	v, _ := m.Property(property).(mgl32.Vec3)
+	return core.Color{R: v[0], G: v[1], B: v[2], A: 1}
+}
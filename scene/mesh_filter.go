@@ -66,4 +66,8 @@ func (m *MeshFilter) Mesh() *graphics.Mesh {
 // SetMesh sets the Mesh for this MeshFilter.
 func (m *MeshFilter) SetMesh(mesh *graphics.Mesh) {
 	m.mesh = mesh
+
+	if g := m.GameObject(); g != nil {
+		g.boundsChanged()
+	}
 }
@@ -23,18 +23,25 @@ SOFTWARE.
 package scene
 
 import (
-	"github.com/go-gl/gl/v4.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/math"
 	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
 )
 
-var _ Drawable = &MeshRenderer{}
+var _ LitDrawable = &MeshRenderer{}
 
 type MeshRenderer struct {
 	BaseComponent
 
 	material   *Material
+	model      mgl32.Mat4
+	prevModel  mgl32.Mat4
+	modelFrame uint64
+	hasModel   bool
+	lodFade    float32
 	cullFace   bool
 	depthWrite bool
 	wireframe  bool
@@ -71,9 +78,9 @@ func (m *MeshRenderer) Draw(camera *Camera) {
 
 	if m.material.SupportsDeferredPath() {
 		if camera.ActiveRenderPath() == RenderPathForward {
-			m.material.Shader().SetSubroutine(graphics.ShaderComponentFragment, "forward_pass")
+			m.material.Shader().SetPass("forward_pass")
 		} else {
-			m.material.Shader().SetSubroutine(graphics.ShaderComponentFragment, "deferred_pass_geometry")
+			m.material.Shader().SetPass("deferred_pass_geometry")
 		}
 	}
 
@@ -82,64 +89,104 @@ func (m *MeshRenderer) Draw(camera *Camera) {
 	m.material.Unbind()
 }
 
-func (m *MeshRenderer) DrawShader(shader *graphics.Shader, camera *Camera) {
+func (m *MeshRenderer) DrawShader(shader graphics.Shader, camera *Camera) {
 	if shader == nil && m.GameObject() == nil {
 		return
 	}
 
-	// FIXME: Move this somewhere out of the render loop
-	var meshes []*graphics.Mesh
-	components := m.GameObject().Components()
-	for i := range components {
-		if meshFilter, ok := components[i].(*MeshFilter); ok {
-			if mesh := meshFilter.Mesh(); mesh != nil {
-				meshes = append(meshes, mesh)
-			}
-		}
-	}
-
+	meshes := m.meshes()
 	if len(meshes) == 0 {
 		return
 	}
 
 	shader.SetUniform("v_model_matrix", m.GetTransform().ActiveMatrix())
-	shader.SetUniform("v_view_matrix", camera.ViewMatrix())
-	shader.SetUniform("v_projection_matrix", camera.ProjectionMatrix())
 	shader.SetUniform("v_normal_matrix", camera.NormalMatrix())
-	shader.SetUniform("f_camera", camera.CameraPosition())
+	shader.SetUniform("v_prev_model_matrix", m.previousModel())
+	shader.SetUniform("f_lod_fade", m.lodFade)
+	camera.SetLightUniforms(shader)
+
+	device := graphics.ActiveDevice()
 
+	prev := device.PipelineState()
+	state := prev
 	if !m.cullFace {
-		gl.Disable(gl.CULL_FACE)
+		state.Cull = graphics.CullNone
 	}
 	if !m.depthWrite {
-		gl.DepthMask(false)
+		state.DepthWrite = false
 	}
-	if m.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	state.Wireframe = m.wireframe
+
+	if state != prev {
+		device.SetPipelineState(state)
 	}
 
 	for i := range meshes {
 		meshes[i].Bind()
 
 		if meshes[i].Indexed() {
-			gl.DrawElements(gl.TRIANGLES, int32(len(meshes[i].Triangles())), gl.UNSIGNED_INT, nil)
+			device.DrawIndexed(graphics.PrimitiveTriangles, int32(len(meshes[i].Triangles())))
 		} else {
-			gl.DrawArrays(gl.TRIANGLES, 0, int32(len(meshes[i].Vertices())))
+			device.Draw(graphics.PrimitiveTriangles, 0, int32(len(meshes[i].Vertices())))
 		}
 
 		meshes[i].Unbind()
 
 	}
 
-	if m.wireframe {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	if state != prev {
+		device.SetPipelineState(prev)
 	}
-	if !m.depthWrite {
-		gl.DepthMask(true)
+}
+
+// Bounds returns the world space bounding box of the meshes of the object.
+func (m *MeshRenderer) Bounds() math.AABB {
+	b := math.EmptyAABB()
+	if m.GameObject() == nil {
+		return b
 	}
-	if !m.cullFace {
-		gl.Enable(gl.CULL_FACE)
+
+	for _, mesh := range m.meshes() {
+		b = b.Union(mesh.Bounds())
+	}
+
+	return b.Transform(m.GetTransform().ActiveMatrix())
+}
+
+// previousModel returns the model matrix of the last frame. It is tracked by
+// frame so all cameras drawing the object in a frame see the same matrix.
+func (m *MeshRenderer) previousModel() mgl32.Mat4 {
+	frame := time.Frame()
+	current := m.GetTransform().ActiveMatrix()
+
+	if !m.hasModel {
+		m.model = current
+		m.prevModel = current
+		m.modelFrame = frame
+		m.hasModel = true
+	} else if frame != m.modelFrame {
+		m.prevModel = m.model
+		m.model = current
+		m.modelFrame = frame
+	}
+
+	return m.prevModel
+}
+
+// FIXME: Move this somewhere out of the render loop
+func (m *MeshRenderer) meshes() []*graphics.Mesh {
+	var meshes []*graphics.Mesh
+
+	components := m.GameObject().Components()
+	for i := range components {
+		if meshFilter, ok := components[i].(*MeshFilter); ok {
+			if mesh := meshFilter.Mesh(); mesh != nil {
+				meshes = append(meshes, mesh)
+			}
+		}
 	}
+
+	return meshes
 }
 
 func (m *MeshRenderer) CullFaceEnabled() bool {
@@ -173,3 +220,21 @@ func (m *MeshRenderer) SupportsDeferred() bool {
 
 	return false
 }
+
+// RenderQueue returns the render queue of the material.
+func (m *MeshRenderer) RenderQueue() RenderQueue {
+	if m.material == nil {
+		return RenderQueueOpaque
+	}
+
+	return m.material.RenderQueue()
+}
+
+// Lit reports if the shader of the material uses the light uniforms.
+func (m *MeshRenderer) Lit() bool {
+	if m.material == nil || m.material.Shader() == nil {
+		return false
+	}
+
+	return m.material.Shader().HasUniform("f_light_type")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ExecutionOrderer is implemented by script components with an execution
+// order of their own. BaseScriptComponent implements it through
+// SetExecutionOrder.
+type ExecutionOrderer interface {
+	// ExecutionOrder returns the execution order of the component, and if
+	// it has one. Components without one use the default of their type.
+	ExecutionOrder() (int, bool)
+}
+
+// executionOrders holds the default execution orders of script types and
+// the before and after declarations between them.
+var executionOrders = struct {
+	sync.Mutex
+
+	defaults map[reflect.Type]int
+	before   map[reflect.Type][]reflect.Type
+	ranks    map[reflect.Type]int
+}{
+	defaults: make(map[reflect.Type]int),
+	before:   make(map[reflect.Type][]reflect.Type),
+	ranks:    make(map[reflect.Type]int),
+}
+
+// SetDefaultExecutionOrder sets the execution order of the script
+// components of type T. Scripts are started and updated in increasing
+// execution order; the default is zero. Scripts of the same order run in
+// the order of the before and after declarations of their types, and then
+// in scene graph order.
+func SetDefaultExecutionOrder[T ScriptComponent](order int) {
+	executionOrders.Lock()
+	defer executionOrders.Unlock()
+
+	executionOrders.defaults[reflect.TypeOf((*T)(nil)).Elem()] = order
+}
+
+// ExecuteBefore declares that script components of type A run before those
+// of type B when both have the same execution order. It fails if B is
+// already declared to run before A.
+func ExecuteBefore[A, B ScriptComponent]() error {
+	a := reflect.TypeOf((*A)(nil)).Elem()
+	b := reflect.TypeOf((*B)(nil)).Elem()
+
+	executionOrders.Lock()
+	defer executionOrders.Unlock()
+
+	if a == b {
+		return fmt.Errorf("execution order: %s cannot run before itself", a)
+	}
+
+	prev := executionOrders.before[a]
+	executionOrders.before[a] = append(prev, b)
+
+	ranks, ok := rankTypes(executionOrders.before)
+	if !ok {
+		executionOrders.before[a] = prev
+		return fmt.Errorf("execution order: %s already runs after %s", a, b)
+	}
+	executionOrders.ranks = ranks
+
+	return nil
+}
+
+// ExecuteAfter declares that script components of type A run after those of
+// type B when both have the same execution order.
+func ExecuteAfter[A, B ScriptComponent]() error {
+	return ExecuteBefore[B, A]()
+}
+
+// rankTypes orders the types of the before declarations topologically. It
+// reports false if the declarations have a cycle.
+func rankTypes(before map[reflect.Type][]reflect.Type) (map[reflect.Type]int, bool) {
+	incoming := make(map[reflect.Type]int)
+	for a, bs := range before {
+		if _, ok := incoming[a]; !ok {
+			incoming[a] = 0
+		}
+		for _, b := range bs {
+			incoming[b]++
+		}
+	}
+
+	var ready []reflect.Type
+	for t, n := range incoming {
+		if n == 0 {
+			ready = append(ready, t)
+		}
+	}
+
+	ranks := make(map[reflect.Type]int, len(incoming))
+	for len(ready) != 0 {
+		// Ranks only need to be consistent, but sorting the ready types
+		// makes them the same between runs.
+		sort.Slice(ready, func(i, j int) bool {
+			return ready[i].String() < ready[j].String()
+		})
+
+		t := ready[0]
+		ready = ready[1:]
+		ranks[t] = len(ranks)
+
+		for _, b := range before[t] {
+			if incoming[b]--; incoming[b] == 0 {
+				ready = append(ready, b)
+			}
+		}
+	}
+
+	return ranks, len(ranks) == len(incoming)
+}
+
+// scriptOrder returns the execution order of a script component, and the
+// rank of its type among the before and after declarations.
+func scriptOrder(c ScriptComponent) (int, int) {
+	t := reflect.TypeOf(c)
+
+	executionOrders.Lock()
+	defer executionOrders.Unlock()
+
+	order := executionOrders.defaults[t]
+	if o, ok := c.(ExecutionOrderer); ok {
+		if v, set := o.ExecutionOrder(); set {
+			order = v
+		}
+	}
+
+	return order, executionOrders.ranks[t]
+}
+
+// sortScripts orders script components for execution. The sort is stable,
+// so scripts of the same order and rank keep their scene graph order.
+func sortScripts(scripts []ScriptComponent) {
+	type key struct{ order, rank int }
+
+	keys := make(map[ScriptComponent]key, len(scripts))
+	for _, c := range scripts {
+		order, rank := scriptOrder(c)
+		keys[c] = key{order, rank}
+	}
+
+	sort.SliceStable(scripts, func(i, j int) bool {
+		ki, kj := keys[scripts[i]], keys[scripts[j]]
+		if ki.order != kj.order {
+			return ki.order < kj.order
+		}
+
+		return ki.rank < kj.rank
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"fmt"
+
+	"github.com/haakenlabs/arc/core"
+)
+
+// PersistentSceneName is the name of the scene which holds the objects kept
+// across scene loads.
+const PersistentSceneName = "__persistent__"
+
+var persistentScene *Scene
+
+// PersistentScene returns the scene of the objects kept across scene loads.
+// It is created on first use and runs additively, so it is updated and
+// displayed alongside whichever scenes are loaded.
+func PersistentScene() (*Scene, error) {
+	if persistentScene == nil {
+		persistentScene = NewScene(PersistentSceneName)
+	}
+
+	sys := core.GetSceneSystem()
+	if sys == nil {
+		return persistentScene, persistentScene.Load()
+	}
+
+	if !sys.Registered(PersistentSceneName) {
+		if err := sys.Register(persistentScene); err != nil {
+			return nil, err
+		}
+	}
+	if !sys.Running(PersistentSceneName) {
+		if err := sys.LoadAdditive(PersistentSceneName); err != nil {
+			return nil, err
+		}
+	}
+
+	return persistentScene, nil
+}
+
+// DontDestroyOnLoad keeps an object alive across scene loads by moving it
+// to the persistent scene. Objects are moved with their whole hierarchy, so
+// a child keeps its top level ancestor alive too.
+func DontDestroyOnLoad(object *GameObject) error {
+	dst, err := PersistentScene()
+	if err != nil {
+		return err
+	}
+
+	for object.scene != nil && object.parent != object.scene.graph.root {
+		object = object.parent
+	}
+
+	return MoveToScene(object, dst)
+}
+
+// MoveToScene moves a top level object and its descendants from their scene
+// to the top of another. Coroutines and data components of the objects are
+// not carried over, as they belong to the scene which is left.
+func MoveToScene(object *GameObject, dst *Scene) error {
+	if object == nil {
+		return nil
+	}
+	if dst == nil || dst.graph == nil {
+		return fmt.Errorf("scene: %s cannot be moved to a scene which is not loaded", object.Name())
+	}
+
+	src := object.scene
+	if src == dst {
+		return nil
+	}
+
+	if src != nil {
+		if object.parent != src.graph.root {
+			return fmt.Errorf("scene: %s is not a top level object", object.Name())
+		}
+		if err := src.graph.detachObject(object); err != nil {
+			return err
+		}
+	}
+
+	return dst.AddObject(object, nil)
+}
+
+// detachObject removes an object and its descendants from the graph without
+// destroying them, so they can be added to another graph.
+func (s *Graph) detachObject(object *GameObject) error {
+	objects := append(s.Descendants(object, true), object)
+
+	d, err := s.graph.DescriptorByNode(object)
+	if err != nil {
+		return err
+	}
+
+	if err := s.graph.DeleteVertex(d); err != nil {
+		return err
+	}
+
+	if object.parent != nil {
+		object.parent.RemoveChild(object.ID())
+	}
+	object.parent = nil
+
+	for _, o := range objects {
+		s.notifyObject(GraphObjectRemoved, o, nil)
+	}
+
+	for _, o := range objects {
+		if w := o.scene.world; w != nil {
+			id := o.ID()
+			w.Defer(func() {
+				w.remove(id)
+			})
+		}
+		o.scene = nil
+	}
+
+	s.Update()
+
+	return nil
+}
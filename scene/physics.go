@@ -0,0 +1,332 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+// sweepTolerance is the distance at which a swept shape touches a collider.
+const sweepTolerance = 1e-4
+
+// sweepIterations limits the steps of a sweep. Sweeps which graze a
+// collider converge slowly, and miss it when they run out of steps.
+const sweepIterations = 64
+
+// QueryTarget selects the components tested by physics queries.
+type QueryTarget uint8
+
+const (
+	// QueryColliders tests colliders by their shapes.
+	QueryColliders QueryTarget = 1 << iota
+	// QueryRenderers tests drawables by their bounding boxes.
+	QueryRenderers
+	// QueryTriggers tests trigger colliders by their shapes.
+	QueryTriggers
+
+	// QueryAll tests colliders, triggers and drawables.
+	QueryAll = QueryColliders | QueryRenderers | QueryTriggers
+)
+
+// RaycastHit is a component hit by a physics query.
+type RaycastHit struct {
+	// Component is the collider or drawable which was hit.
+	Component  Bounded
+	GameObject *GameObject
+	// Point is the world space point of the hit, and Normal the surface
+	// normal there. Queries which start inside a component hit it at
+	// distance zero. Rays then have a normal facing back along them, and
+	// sweeps a normal pushing them out, if they are not entirely inside.
+	Point    mgl32.Vec3
+	Normal   mgl32.Vec3
+	Distance float32
+}
+
+// Raycast returns the nearest component hit by a ray within maxDistance.
+// Only components of active objects on a layer in mask are hit. The
+// direction of the ray must be normalized.
+func (s *Scene) Raycast(ray math.Ray, maxDistance float32, mask LayerMask, target QueryTarget) (RaycastHit, bool) {
+	var best RaycastHit
+	found := false
+
+	if s.spatial == nil {
+		return best, false
+	}
+
+	// Candidates are sorted by the distance to their bounds, which is never
+	// more than the distance to their shapes.
+	for _, c := range s.spatial.Raycast(ray, maxDistance, nil) {
+		if found && c.Distance > best.Distance {
+			break
+		}
+
+		sh, ok := s.queryShape(c.Component, mask, target)
+		if !ok {
+			continue
+		}
+
+		if h, ok := raycastShape(c.Component, sh, ray, maxDistance); ok && (!found || h.Distance < best.Distance) {
+			best, found = h, true
+		}
+	}
+
+	return best, found
+}
+
+// RaycastAll appends all components hit by a ray within maxDistance to dst,
+// nearest first.
+func (s *Scene) RaycastAll(ray math.Ray, maxDistance float32, mask LayerMask, target QueryTarget, dst []RaycastHit) []RaycastHit {
+	if s.spatial == nil {
+		return dst
+	}
+
+	start := len(dst)
+
+	for _, c := range s.spatial.Raycast(ray, maxDistance, nil) {
+		sh, ok := s.queryShape(c.Component, mask, target)
+		if !ok {
+			continue
+		}
+
+		if h, ok := raycastShape(c.Component, sh, ray, maxDistance); ok {
+			dst = append(dst, h)
+		}
+	}
+
+	hits := dst[start:]
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+
+	return dst
+}
+
+// SphereCast returns the nearest component hit by a sphere of radius moved
+// along a ray within maxDistance. The point of the hit is on the surface of
+// the component.
+func (s *Scene) SphereCast(ray math.Ray, radius, maxDistance float32, mask LayerMask, target QueryTarget) (RaycastHit, bool) {
+	return s.sweep(ray, ray.Origin, ray.Origin, radius, maxDistance, mask, target, nil)
+}
+
+// OverlapBox appends the components which overlap a box to dst. The box has
+// halfExtents along the axes of rotation, around center.
+func (s *Scene) OverlapBox(center, halfExtents mgl32.Vec3, rotation mgl32.Quat, mask LayerMask, target QueryTarget, dst []Bounded) []Bounded {
+	box := math.OBB{
+		Center:      center,
+		HalfExtents: halfExtents,
+		Axes: [3]mgl32.Vec3{
+			rotation.Rotate(mgl32.Vec3{1, 0, 0}),
+			rotation.Rotate(mgl32.Vec3{0, 1, 0}),
+			rotation.Rotate(mgl32.Vec3{0, 0, 1}),
+		},
+	}
+
+	return s.overlap(boxShape(box), mask, target, dst)
+}
+
+// OverlapSphere appends the components which overlap a sphere to dst.
+func (s *Scene) OverlapSphere(center mgl32.Vec3, radius float32, mask LayerMask, target QueryTarget, dst []Bounded) []Bounded {
+	return s.overlap(capsuleShape(math.Capsule{A: center, B: center, Radius: radius}), mask, target, dst)
+}
+
+// overlap appends the components which overlap a shape to dst.
+func (s *Scene) overlap(shape colliderShape, mask LayerMask, target QueryTarget, dst []Bounded) []Bounded {
+	if s.spatial == nil {
+		return dst
+	}
+
+	for _, c := range s.spatial.QueryAABB(shape.bounds(), nil) {
+		if sh, ok := s.queryShape(c, mask, target); ok && sh.intersects(shape) {
+			dst = append(dst, c)
+		}
+	}
+
+	return dst
+}
+
+// sweep returns the nearest component hit by the capsule from a to b of
+// radius moved along a ray within maxDistance. Components for which skip
+// returns true are ignored.
+//
+// Each candidate is approached by conservative advancement: the capsule is
+// moved by its distance to the shape until it touches it.
+func (s *Scene) sweep(ray math.Ray, a, b mgl32.Vec3, radius, maxDistance float32, mask LayerMask, target QueryTarget, skip func(Bounded) bool) (RaycastHit, bool) {
+	var best RaycastHit
+	found := false
+
+	if s.spatial == nil {
+		return best, false
+	}
+
+	start := math.NewAABB(a, b).Expand(radius)
+	end := math.AABB{Min: start.Min.Add(ray.Direction.Mul(maxDistance)), Max: start.Max.Add(ray.Direction.Mul(maxDistance))}
+
+	for _, c := range s.spatial.QueryAABB(start.Union(end), nil) {
+		if skip != nil && skip(c) {
+			continue
+		}
+
+		sh, ok := s.queryShape(c, mask, target)
+		if !ok {
+			continue
+		}
+
+		limit := maxDistance
+		if found {
+			limit = best.Distance
+		}
+
+		if h, ok := sweepShape(sh, ray.Direction, a, b, radius, limit); ok && (!found || h.Distance < best.Distance) {
+			h.Component = c
+			h.GameObject = c.GameObject()
+			best, found = h, true
+		}
+	}
+
+	return best, found
+}
+
+// sweepShape moves the capsule from a to b of radius along dir until it
+// touches a shape, within maxDistance.
+func sweepShape(shape colliderShape, dir, a, b mgl32.Vec3, radius, maxDistance float32) (RaycastHit, bool) {
+	var t float32
+
+	for i := 0; i < sweepIterations; i++ {
+		offset := dir.Mul(t)
+		p, q := shape.closestPointSegment(a.Add(offset), b.Add(offset))
+
+		d := q.Sub(p)
+		dist := d.Len() - radius
+		if dist <= sweepTolerance {
+			h := RaycastHit{Point: p, Distance: t}
+			if d.LenSqr() == 0 {
+				h.Normal = dir.Mul(-1)
+			} else {
+				h.Normal = d.Normalize()
+			}
+
+			return h, true
+		}
+
+		t += dist
+		if t > maxDistance {
+			break
+		}
+	}
+
+	return RaycastHit{}, false
+}
+
+// queryShape returns the shape of a component tested by a query, if it is
+// one of its targets.
+func (s *Scene) queryShape(c Bounded, mask LayerMask, target QueryTarget) (colliderShape, bool) {
+	g := c.GameObject()
+	if g == nil || !g.Active() || !mask.Contains(g.Layer()) {
+		return colliderShape{}, false
+	}
+
+	if col, ok := c.(Collider); ok {
+		if col.IsTrigger() {
+			return col.shape(), target&QueryTriggers != 0
+		}
+
+		return col.shape(), target&QueryColliders != 0
+	}
+
+	if _, ok := c.(Drawable); ok && target&QueryRenderers != 0 {
+		b := c.Bounds()
+		if b.Empty() || b.Infinite() {
+			return colliderShape{}, false
+		}
+
+		return boxShape(math.NewOBBFromAABB(b)), true
+	}
+
+	return colliderShape{}, false
+}
+
+// ColliderOcclusionQuery returns an audio occlusion query which reports a
+// sound as occluded when a collider on a layer in mask lies between it and
+// the listener. Like OcclusionQuery, it follows the active scene.
+func ColliderOcclusionQuery(mask LayerMask) core.OcclusionQuery {
+	var hits, back []RaycastHit
+
+	return func(listener, source mgl32.Vec3) bool {
+		sys := core.GetSceneSystem()
+		if sys == nil {
+			return false
+		}
+
+		s, ok := sys.Active().(*Scene)
+		if !ok {
+			return false
+		}
+
+		var occluded bool
+		occluded, hits, back = s.occludedByColliders(listener, source, mask, hits, back)
+
+		return occluded
+	}
+}
+
+// occludedByColliders reports if a collider in mask lies between the
+// listener and a sound. Colliders containing the listener or the sound are
+// ignored, so a sound is not occluded by the collider of its own source.
+// The hits of the rays are appended to hits and back, which are returned
+// for reuse.
+func (s *Scene) occludedByColliders(listener, source mgl32.Vec3, mask LayerMask, hits, back []RaycastHit) (bool, []RaycastHit, []RaycastHit) {
+	d := source.Sub(listener)
+	dist := d.Len()
+	if dist == 0 {
+		return false, hits, back
+	}
+
+	// Rays hit the colliders they start in at distance zero, so the
+	// colliders containing the sound are found by a ray from it.
+	hits = s.RaycastAll(math.NewRay(listener, d), dist, mask, QueryColliders, hits[:0])
+	back = s.RaycastAll(math.NewRay(source, d.Mul(-1)), dist, mask, QueryColliders, back[:0])
+
+next:
+	for _, h := range hits {
+		if h.Distance == 0 {
+			continue
+		}
+		for _, b := range back {
+			if b.Distance > 0 {
+				break
+			}
+			if b.Component == h.Component {
+				continue next
+			}
+		}
+
+		return true, hits, back
+	}
+
+	return false, hits, back
+}
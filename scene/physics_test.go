@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestScene_OccludedByColliders(t *testing.T) {
+	s := NewScene("test")
+	s.spatial = NewSpatialIndex()
+
+	// A wall across the X axis, and a box around a sound.
+	wall := NewGameObject("wall")
+	wall.Transform().SetPosition(mgl32.Vec3{5, 0, 0})
+	wall.AddComponent(NewBoxCollider(mgl32.Vec3{1, 10, 10}))
+
+	radio := NewGameObject("radio")
+	radio.Transform().SetPosition(mgl32.Vec3{0, 0, 20})
+	radio.AddComponent(NewBoxCollider(mgl32.Vec3{2, 2, 2}))
+
+	var components []Component
+	components = append(components, wall.Components()...)
+	components = append(components, radio.Components()...)
+	s.spatial.sync(components)
+
+	tests := []struct {
+		listener, source mgl32.Vec3
+		want             bool
+	}{
+		// Through the wall.
+		{listener: mgl32.Vec3{0, 0, 0}, source: mgl32.Vec3{10, 0, 0}, want: true},
+		// Past the end of the wall.
+		{listener: mgl32.Vec3{0, 0, 8}, source: mgl32.Vec3{10, 0, 8}},
+		// From inside the box of the radio, which does not occlude it.
+		{listener: mgl32.Vec3{-5, 0, 20}, source: mgl32.Vec3{0, 0, 20}},
+		// From inside the wall.
+		{listener: mgl32.Vec3{5, 0, 0}, source: mgl32.Vec3{10, 0, 0}},
+		{listener: mgl32.Vec3{1, 1, 1}, source: mgl32.Vec3{1, 1, 1}},
+	}
+
+	var hits, back []RaycastHit
+	for i, v := range tests {
+		var got bool
+		got, hits, back = s.occludedByColliders(v.listener, v.source, LayerMaskAll, hits, back)
+		if got != v.want {
+			t.Errorf("occludedByColliders case %d failed. want: %v got: %v", i, v.want, got)
+		}
+	}
+
+	// Colliders outside the mask do not occlude.
+	if got, _, _ := s.occludedByColliders(tests[0].listener, tests[0].source, 0, nil, nil); got {
+		t.Errorf("occludedByColliders failed. want: %v got: %v", false, got)
+	}
+}
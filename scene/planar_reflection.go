@@ -0,0 +1,219 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ GraphEventListener = &PlanarReflection{}
+var _ GraphListener = &PlanarReflection{}
+var _ ScriptComponent = &PlanarReflection{}
+
+// PlanarReflection renders the scene mirrored about a plane into a texture,
+// for water and mirror surfaces. The plane passes through the position of the
+// game object and faces along its local Y axis.
+//
+// The reflection is rendered by an internal camera which follows a source
+// camera and is rendered right before it. Geometry behind the plane is
+// clipped by an oblique near plane. The texture has the size of the source
+// camera, so materials sample it at the window coordinates of the fragment.
+type PlanarReflection struct {
+	BaseScriptComponent
+
+	source     *Camera
+	camera     *Camera
+	object     *GameObject
+	materials  []*Material
+	clipOffset float32
+}
+
+// NewPlanarReflection creates a planar reflection which mirrors the view of
+// source.
+func NewPlanarReflection(source *Camera) *PlanarReflection {
+	p := &PlanarReflection{
+		source:     source,
+		clipOffset: 0.01,
+	}
+
+	p.camera = NewCamera(source.RenderPath(), false, 0)
+	p.camera.SetName("ReflectionCamera")
+	p.camera.SetOffscreen(true)
+	p.camera.SetViewportRect(source.ViewportRect())
+
+	// The camera is not part of the scene graph. Its game object only
+	// provides the transform and the environment of the scene.
+	p.object = NewGameObject("ReflectionCamera")
+	p.object.AddComponent(p.camera)
+
+	p.SetName("PlanarReflection")
+	instance.MustAssign(p)
+
+	return p
+}
+
+// Source returns the camera the reflection follows.
+func (p *PlanarReflection) Source() *Camera {
+	return p.source
+}
+
+// Camera returns the camera which renders the reflection. Its culling mask,
+// clear mode and effects can be changed, the view is set every frame.
+func (p *PlanarReflection) Camera() *Camera {
+	return p.camera
+}
+
+// Texture returns the texture holding the reflection.
+func (p *PlanarReflection) Texture() *graphics.Texture2D {
+	return p.camera.OutputTexture()
+}
+
+// ClipOffset returns the distance the clip plane is moved along the normal
+// of the plane, to hide geometry touching the surface.
+func (p *PlanarReflection) ClipOffset() float32 {
+	return p.clipOffset
+}
+
+// SetClipOffset sets the distance the clip plane is moved along the normal of
+// the plane, to hide geometry touching the surface.
+func (p *PlanarReflection) SetClipOffset(offset float32) {
+	p.clipOffset = offset
+}
+
+// AddMaterial binds the reflection texture to MaterialTextureReflection of a
+// material.
+func (p *PlanarReflection) AddMaterial(m *Material) {
+	for i := range p.materials {
+		if p.materials[i] == m {
+			return
+		}
+	}
+
+	m.SetTexture(MaterialTextureReflection, p.Texture())
+	p.materials = append(p.materials, m)
+}
+
+// RemoveMaterial unbinds the reflection texture from a material.
+func (p *PlanarReflection) RemoveMaterial(m *Material) {
+	for i := range p.materials {
+		if p.materials[i] == m {
+			m.SetTexture(MaterialTextureReflection, nil)
+			p.materials = append(p.materials[:i], p.materials[i+1:]...)
+			return
+		}
+	}
+}
+
+// Plane returns the normal and the distance from the origin of the plane in
+// world space. Points on the plane satisfy normal.Dot(p) + d == 0.
+func (p *PlanarReflection) Plane() (mgl32.Vec3, float32) {
+	m := p.GetTransform().ActiveMatrix()
+	normal := m.Mul4x1(mgl32.Vec4{0, 1, 0, 0}).Vec3().Normalize()
+
+	return normal, -normal.Dot(m.Col(3).Vec3())
+}
+
+func (p *PlanarReflection) OnSceneGraphEvent(event GraphEvent) {
+	p.camera.OnSceneGraphEvent(event)
+}
+
+func (p *PlanarReflection) OnSceneGraphUpdate() {
+	// The camera rebuilds its caches when the reflection moves to another
+	// scene, as it has missed the events of the new scene.
+	if s := p.GameObject().Scene(); p.object.scene != s {
+		p.object.scene = s
+		p.camera.rebuildCache = true
+	}
+	p.camera.OnSceneGraphUpdate()
+}
+
+func (p *PlanarReflection) Update() {
+	p.camera.Update()
+}
+
+// LateUpdate mirrors the view of the source camera once it has moved for this
+// frame.
+func (p *PlanarReflection) LateUpdate() {
+	if p.camera.ViewportRect() != p.source.ViewportRect() {
+		p.camera.SetViewportRect(p.source.ViewportRect())
+	}
+
+	p.camera.SetDepth(p.source.Depth() - 1)
+
+	normal, d := p.Plane()
+	position := p.source.CameraPosition()
+	distance := normal.Dot(position) + d
+
+	// Nothing is reflected towards a camera behind the plane.
+	p.camera.SetEnabled(p.source.Enabled() && distance > 0)
+	if !p.camera.Enabled() {
+		return
+	}
+
+	view := p.source.ViewMatrix().Mul4(reflectionMatrix(normal, d))
+
+	p.camera.GetTransform().SetPosition(position.Sub(normal.Mul(2 * distance)))
+	p.camera.SetViewMatrix(view)
+
+	clip := view.Inv().Transpose().Mul4x1(normal.Vec4(d - p.clipOffset))
+	p.camera.SetProjectionMatrix(obliqueProjection(p.source.ProjectionMatrix(), clip))
+}
+
+// reflectionMatrix returns the matrix which mirrors points about the plane
+// normal.Dot(p) + d == 0.
+func reflectionMatrix(n mgl32.Vec3, d float32) mgl32.Mat4 {
+	x, y, z := n.Elem()
+
+	return mgl32.Mat4{
+		1 - 2*x*x, -2 * x * y, -2 * x * z, 0,
+		-2 * x * y, 1 - 2*y*y, -2 * y * z, 0,
+		-2 * x * z, -2 * y * z, 1 - 2*z*z, 0,
+		-2 * d * x, -2 * d * y, -2 * d * z, 1,
+	}
+}
+
+// obliqueProjection replaces the near plane of a perspective projection with
+// a view space clip plane, following Lengyel's oblique view frustum. The
+// camera must be behind the clip plane.
+func obliqueProjection(proj mgl32.Mat4, clip mgl32.Vec4) mgl32.Mat4 {
+	q := proj.Inv().Mul4x1(mgl32.Vec4{sign32(clip.X()), sign32(clip.Y()), 1, 1})
+	c := clip.Mul(2 / clip.Dot(q))
+
+	proj.SetRow(2, c.Sub(proj.Row(3)))
+
+	return proj
+}
+
+func sign32(v float32) float32 {
+	if v > 0 {
+		return 1
+	}
+	if v < 0 {
+		return -1
+	}
+
+	return 0
+}
@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	fmath "github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ GraphEventListener = &ReflectionProbe{}
+var _ GraphListener = &ReflectionProbe{}
+var _ ScriptComponent = &ReflectionProbe{}
+
+// ReflectionProbeMode selects when a reflection probe renders the scene.
+type ReflectionProbeMode int
+
+const (
+	// ReflectionProbeOnce renders the probe the first time it is displayed
+	// and keeps the result until Refresh is called.
+	ReflectionProbeOnce ReflectionProbeMode = iota
+
+	// ReflectionProbeEveryFrame renders the probe every frame.
+	ReflectionProbeEveryFrame
+)
+
+// probeFace is the look direction and up vector of a face of a reflection
+// probe, following the orientation of the cubemap faces.
+type probeFace struct {
+	direction mgl32.Vec3
+	up        mgl32.Vec3
+}
+
+var probeFaces = [6]probeFace{
+	{mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, -1, 0}},
+	{mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, -1, 0}},
+	{mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, 1}},
+	{mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, -1}},
+	{mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, -1, 0}},
+	{mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, -1, 0}},
+}
+
+// ReflectionProbe renders the scene around the position of its game object
+// into a cubemap, for local specular reflections. The cubemap is convolved
+// like the skybox, so materials bound to the probe use its specular and
+// irradiance maps in place of the skybox.
+//
+// The faces are rendered by an internal camera before the cameras of the
+// scene. Static surroundings only need to be rendered once, moving objects
+// require ReflectionProbeEveryFrame at the cost of six extra scene renders
+// and a convolution per frame.
+type ReflectionProbe struct {
+	BaseScriptComponent
+
+	camera     *Camera
+	object     *GameObject
+	capture    graphics.Framebuffer
+	attachment *graphics.AttachmentTextureCubemap
+	fbo        graphics.Framebuffer
+	radiance   *graphics.TextureCubemap
+	specular   *graphics.TextureCubemap
+	irradiance *graphics.TextureCubemap
+	materials  []*Material
+	mode       ReflectionProbeMode
+	size       int32
+	rendered   bool
+}
+
+// NewReflectionProbe creates a reflection probe which renders faces of size
+// pixels with the given render path.
+func NewReflectionProbe(renderPath RenderPath, size int32) *ReflectionProbe {
+	var err error
+
+	p := &ReflectionProbe{
+		size: size,
+	}
+
+	p.camera = NewCamera(renderPath, false, 0)
+	p.camera.SetName("ProbeCamera")
+	p.camera.SetOffscreen(true)
+	p.camera.SetTargetSize(fmath.IVec2{size, size})
+	p.camera.SetFov(fmath.Pi32 / 2)
+
+	// The camera is not part of the scene graph. Its game object only
+	// provides the transform and the environment of the scene.
+	p.object = NewGameObject("ProbeCamera")
+	p.object.AddComponent(p.camera)
+
+	p.radiance = graphics.NewTextureCubemap(fmath.IVec2{size, size}, graphics.TextureFormatRGBA16)
+	p.radiance.Alloc()
+
+	if p.specular, err = NewSpecularCubemap(size); err != nil {
+		panic(err)
+	}
+	if p.irradiance, err = NewIrradianceCubemap(); err != nil {
+		panic(err)
+	}
+
+	p.attachment = graphics.NewAttachmentTextureCubemap(p.radiance, 0)
+
+	p.capture = graphics.NewFramebuffer(fmath.IVec2{size, size})
+	p.capture.SetAttachment(graphics.AttachmentColor0, p.attachment)
+	if err := p.capture.Alloc(); err != nil {
+		panic(err)
+	}
+
+	p.fbo = graphics.NewFramebufferRaw()
+
+	p.SetName("ReflectionProbe")
+	instance.MustAssign(p)
+
+	return p
+}
+
+// Camera returns the camera which renders the faces of the probe. Its culling
+// mask, clear mode and clip planes can be changed, the view is set for every
+// face.
+func (p *ReflectionProbe) Camera() *Camera {
+	return p.camera
+}
+
+// Size returns the face size of the probe in pixels.
+func (p *ReflectionProbe) Size() int32 {
+	return p.size
+}
+
+// Mode returns when the probe renders the scene.
+func (p *ReflectionProbe) Mode() ReflectionProbeMode {
+	return p.mode
+}
+
+// SetMode sets when the probe renders the scene.
+func (p *ReflectionProbe) SetMode(mode ReflectionProbeMode) {
+	p.mode = mode
+}
+
+// Refresh renders the probe again the next time it is displayed.
+func (p *ReflectionProbe) Refresh() {
+	p.rendered = false
+}
+
+// Radiance returns the cubemap the scene is rendered into.
+func (p *ReflectionProbe) Radiance() *graphics.TextureCubemap {
+	return p.radiance
+}
+
+// Specular returns the prefiltered specular map of the probe.
+func (p *ReflectionProbe) Specular() *graphics.TextureCubemap {
+	return p.specular
+}
+
+// Irradiance returns the irradiance map of the probe.
+func (p *ReflectionProbe) Irradiance() *graphics.TextureCubemap {
+	return p.irradiance
+}
+
+// AddMaterial binds the specular and irradiance maps of the probe to
+// MaterialTextureEnvironment and MaterialTextureIrradiance of a material.
+func (p *ReflectionProbe) AddMaterial(m *Material) {
+	for i := range p.materials {
+		if p.materials[i] == m {
+			return
+		}
+	}
+
+	m.SetTexture(MaterialTextureEnvironment, p.specular)
+	m.SetTexture(MaterialTextureIrradiance, p.irradiance)
+	p.materials = append(p.materials, m)
+}
+
+// RemoveMaterial unbinds the maps of the probe from a material.
+func (p *ReflectionProbe) RemoveMaterial(m *Material) {
+	for i := range p.materials {
+		if p.materials[i] == m {
+			m.SetTexture(MaterialTextureEnvironment, nil)
+			m.SetTexture(MaterialTextureIrradiance, nil)
+			p.materials = append(p.materials[:i], p.materials[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *ReflectionProbe) OnSceneGraphEvent(event GraphEvent) {
+	p.camera.OnSceneGraphEvent(event)
+}
+
+func (p *ReflectionProbe) OnSceneGraphUpdate() {
+	// The camera rebuilds its caches when the reflection moves to another
+	// scene, as it has missed the events of the new scene.
+	if s := p.GameObject().Scene(); p.object.scene != s {
+		p.object.scene = s
+		p.camera.rebuildCache = true
+	}
+	p.camera.OnSceneGraphUpdate()
+}
+
+func (p *ReflectionProbe) Update() {
+	p.camera.Update()
+}
+
+// Render renders the faces of the probe and convolves them, unless the probe
+// only renders once and has already been rendered.
+func (p *ReflectionProbe) Render() {
+	if p.mode == ReflectionProbeOnce && p.rendered {
+		return
+	}
+
+	device := graphics.ActiveDevice()
+	device.PushDebugGroup(p.Name())
+
+	position := p.GetTransform().ActiveMatrix().Col(3).Vec3()
+	p.camera.GetTransform().SetPosition(position)
+
+	for face := range probeFaces {
+		p.camera.SetViewMatrix(mgl32.LookAtV(position, position.Add(probeFaces[face].direction), probeFaces[face].up))
+		p.camera.Render()
+
+		p.capture.Bind()
+		p.attachment.SetFace(int32(face))
+		p.capture.Reattach(graphics.AttachmentColor0)
+		p.capture.Unbind()
+
+		graphics.BlitFramebuffers(p.camera.framebuffer, p.capture, graphics.AttachmentColor0)
+	}
+
+	// The mip chain of the radiance map is sampled by the prefilter passes
+	// to avoid aliasing.
+	p.radiance.GenerateMipmaps()
+
+	PrefilterSpecular(p.radiance, p.specular, p.fbo)
+	ConvolveIrradiance(p.radiance, p.irradiance, p.fbo)
+
+	p.rendered = true
+
+	device.PopDebugGroup()
+}
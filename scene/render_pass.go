@@ -0,0 +1,59 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+// RenderStage is a point in the render sequence of a camera where custom
+// render passes are executed.
+type RenderStage uint8
+
+const (
+	// RenderStageBeforeOpaque runs after the camera target is cleared and
+	// before any drawables are drawn.
+	RenderStageBeforeOpaque RenderStage = iota
+
+	// RenderStageAfterOpaque runs after the deferred and forward opaque
+	// drawables are drawn and before the transparent drawables.
+	RenderStageAfterOpaque
+
+	// RenderStageBeforeEffects runs after all drawables are drawn and before
+	// the effect chain.
+	RenderStageBeforeEffects
+
+	// RenderStageAfterEffects runs after the effect chain, before the camera
+	// target is copied to the screen.
+	RenderStageAfterEffects
+)
+
+// RenderPass is custom rendering injected into the render sequence of a
+// camera, such as outlines, debug overlays or decals. Execute draws into the
+// camera target bound at its stage.
+type RenderPass interface {
+	// Setup is called when the pass is added to a camera.
+	Setup()
+
+	// Execute renders the pass for a camera.
+	Execute(c *Camera)
+
+	// Cleanup is called when the pass is removed from a camera.
+	Cleanup()
+}
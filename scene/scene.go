@@ -22,18 +22,34 @@ SOFTWARE.
 
 package scene
 
-import "github.com/haakenlabs/arc/core"
+import (
+	"github.com/sirupsen/logrus"
 
-var _ core.Scene = &Scene{}
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ core.SceneStepLoader = &Scene{}
 
 type Scene struct {
+	// LoadFunc is called first when the scene loads, and LoadSteps after it
+	// in order. Loading asynchronously runs as many steps per frame as fit
+	// in the budget of the scene system.
 	LoadFunc         func() error
+	LoadSteps        []func() error
 	OnActivateFunc   func()
 	OnDeacticateFunc func()
 
 	environment *Environment
 	graph       *Graph
-	cameras     []*Camera
+	cameras     *CameraManager
+	events      *core.EventBus
+	spatial     *SpatialIndex
+	world       *World
+	coroutines  []*Coroutine
+	destroyed   []pendingDestroy
+	triggers    []triggerPair
+	loadStep    int
 	name        string
 	loaded      bool
 	started     bool
@@ -60,20 +76,90 @@ func (s *Scene) OnDeactivate() {
 
 // Load is called when the scene is being initialized.
 func (s *Scene) Load() error {
+	for !s.loaded {
+		if _, err := s.LoadStep(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadStep runs LoadFunc, or the next of LoadSteps once it has run, and
+// returns the progress of the load. A failed step is run again by the next
+// call.
+func (s *Scene) LoadStep() (float64, error) {
 	if s.loaded {
-		return nil
+		return 1, nil
 	}
 
-	s.graph = NewGraph(s)
-	s.environment = NewEnvironment()
+	if s.graph == nil {
+		s.spatial = NewSpatialIndex()
+		s.world = NewWorld()
+		s.graph = NewGraph(s)
+		s.environment = NewEnvironment()
+		s.loadStep = 0
+
+		if s.LoadFunc != nil {
+			if err := s.LoadFunc(); err != nil {
+				s.Unload()
+				return 0, err
+			}
+		}
+	} else if err := s.LoadSteps[s.loadStep](); err != nil {
+		return s.loadProgress(), err
+	} else {
+		s.loadStep++
+	}
 
-	if s.LoadFunc != nil {
-		s.LoadFunc()
+	if s.loadStep == len(s.LoadSteps) {
+		s.loaded = true
 	}
 
-	s.loaded = true
+	return s.loadProgress(), nil
+}
 
-	return nil
+// loadProgress returns the fraction of the steps of the load which have run.
+func (s *Scene) loadProgress() float64 {
+	if s.loaded {
+		return 1
+	}
+	if s.graph == nil {
+		return 0
+	}
+
+	return float64(s.loadStep+1) / float64(len(s.LoadSteps)+1)
+}
+
+// Unload removes and releases the objects of the scene, including those of
+// a partial load. The scene can be loaded again afterwards.
+func (s *Scene) Unload() {
+	if s.graph == nil {
+		return
+	}
+
+	root := s.graph.root
+	for _, g := range append([]*GameObject(nil), root.children...) {
+		if err := s.graph.RemoveObject(g); err != nil {
+			logrus.Error(err)
+		}
+	}
+	instance.Release(root.destroy()...)
+
+	s.cameras.Clear()
+	s.cameras.SetProbes(nil)
+
+	s.graph = nil
+	s.spatial = nil
+	s.world = nil
+	s.environment = nil
+	s.destroyed = nil
+	s.triggers = nil
+	s.stopCoroutines()
+	s.events.Clear()
+	s.loadStep = 0
+	s.loaded = false
+	s.started = false
 }
 
 // Loaded reports if the scene has been loaded.
@@ -86,15 +172,34 @@ func (s *Scene) OnSceneGraphUpdate() {
 		return
 	}
 
-	s.cameras = s.cameras[:0]
+	if s.spatial != nil {
+		s.spatial.sync(s.graph.Components())
+	}
+
+	var cameras []*Camera
+	var probes []*ReflectionProbe
 
 	// Update renderer cache.
 	components := s.graph.Components()
 	for i := range components {
 		if c, ok := components[i].(*Camera); ok {
-			s.cameras = append(s.cameras, c)
+			cameras = append(cameras, c)
+		}
+		if r, ok := components[i].(*PlanarReflection); ok {
+			cameras = append(cameras, r.Camera())
+		}
+		if p, ok := components[i].(*ReflectionProbe); ok {
+			probes = append(probes, p)
 		}
 	}
+
+	s.cameras.SetCameras(cameras)
+	s.cameras.SetProbes(probes)
+}
+
+// CameraManager returns the camera manager of the scene.
+func (s *Scene) CameraManager() *CameraManager {
+	return s.cameras
 }
 
 func (s *Scene) Objects() []*GameObject {
@@ -110,10 +215,7 @@ func (s *Scene) Display() {
 		s.graph.Update()
 	}
 
-	cameras := s.cameras
-	for i := range cameras {
-		cameras[i].Render()
-	}
+	s.cameras.Render()
 
 	s.graph.SendMessage(MessageGUIRender)
 }
@@ -123,7 +225,8 @@ func (s *Scene) FixedUpdate() {
 		s.graph.Update()
 	}
 
-	s.graph.SendMessage(MessageFixedUpdate)
+	s.graph.SendScriptMessage(MessageFixedUpdate)
+	s.updateTriggers()
 }
 
 func (s *Scene) Update() {
@@ -133,11 +236,45 @@ func (s *Scene) Update() {
 
 	if !s.started {
 		s.started = true
-		s.graph.SendMessage(MessageStart)
+		s.graph.SendScriptMessage(MessageStart)
 	}
 
-	s.graph.SendMessage(MessageUpdate)
-	s.graph.SendMessage(MessageLateUpdate)
+	s.events.Flush()
+	s.graph.SendScriptMessage(MessageUpdate)
+	s.world.Update()
+	s.updateCoroutines()
+}
+
+// LateUpdate late updates the scripts of the scene, once every scene has
+// been updated. Objects destroyed during the frame are removed afterwards.
+func (s *Scene) LateUpdate() {
+	if s.graph.Dirty() {
+		s.graph.Update()
+	}
+
+	s.graph.SendScriptMessage(MessageLateUpdate)
+	s.events.Flush()
+
+	s.removeDestroyed()
+}
+
+// Events returns the event bus of the scene. Its events are delivered before
+// the components of the scene are updated, and again after LateUpdate.
+// Subscriptions are removed when the scene is unloaded.
+func (s *Scene) Events() *core.EventBus {
+	return s.events
+}
+
+// World returns the storage of the data components of the objects of the
+// scene, or nil if the scene is not loaded.
+func (s *Scene) World() *World {
+	return s.world
+}
+
+// Spatial returns the spatial index of the bounded components of the scene,
+// or nil if the scene is not loaded.
+func (s *Scene) Spatial() *SpatialIndex {
+	return s.spatial
 }
 
 func (s *Scene) Environment() *Environment {
@@ -162,7 +299,9 @@ func (s *Scene) Descendants(object *GameObject, disable bool) []*GameObject {
 
 func NewScene(name string) *Scene {
 	s := &Scene{
-		name: name,
+		name:    name,
+		cameras: NewCameraManager(),
+		events:  core.NewEventBus(),
 	}
 
 	return s
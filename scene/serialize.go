@@ -0,0 +1,341 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+)
+
+// File is a scene file. It describes the objects of a scene, their hierarchy
+// and components, and can be written and read as JSON.
+//
+//	{
+//	    "name": "level1",
+//	    "objects": [
+//	        {
+//	            "name": "Sun",
+//	            "transform": {"rotation": [-0.38, 0, 0, 0.92]},
+//	            "components": [
+//	                {"type": "Light", "properties": {"type": "directional", "intensity": 3}}
+//	            ]
+//	        }
+//	    ]
+//	}
+type File struct {
+	Name    string       `json:"name"`
+	Objects []ObjectData `json:"objects"`
+}
+
+// ObjectData describes a GameObject and its children.
+type ObjectData struct {
+	Name       string          `json:"name"`
+	Active     *bool           `json:"active,omitempty"`
+	Tag        string          `json:"tag,omitempty"`
+	Layer      Layer           `json:"layer,omitempty"`
+	Transform  *TransformData  `json:"transform,omitempty"`
+	Components []ComponentData `json:"components,omitempty"`
+	Children   []ObjectData    `json:"children,omitempty"`
+}
+
+// TransformData is the local transform of an object. Rotation is a
+// quaternion stored as x, y, z, w. Missing fields keep their defaults.
+type TransformData struct {
+	Position *mgl32.Vec3 `json:"position,omitempty"`
+	Rotation *mgl32.Vec4 `json:"rotation,omitempty"`
+	Scale    *mgl32.Vec3 `json:"scale,omitempty"`
+}
+
+// ComponentData describes a component by its registered type name and its
+// properties, which are decoded by the factory of the type.
+type ComponentData struct {
+	Type       string          `json:"type"`
+	Properties json.RawMessage `json:"properties,omitempty"`
+}
+
+// ComponentFactory creates a component from its properties in a scene file.
+// Properties are empty if the file has none.
+type ComponentFactory func(properties []byte) (Component, error)
+
+var (
+	componentMu        sync.RWMutex
+	componentFactories = make(map[string]ComponentFactory)
+	componentTypes     = make(map[reflect.Type]string)
+)
+
+// RegisterComponent registers a component type under a name, so scene files
+// can hold it. The factory creates a component from its properties. Saved
+// properties are the JSON encoding of the component, so types control them
+// by implementing json.Marshaler, or by their exported fields.
+func RegisterComponent[T Component](name string, factory func(properties []byte) (T, error)) {
+	componentMu.Lock()
+	defer componentMu.Unlock()
+
+	componentFactories[name] = func(properties []byte) (Component, error) {
+		return factory(properties)
+	}
+	componentTypes[reflect.TypeOf((*T)(nil)).Elem()] = name
+}
+
+// JSONComponent returns a factory which creates a component with newFunc and
+// decodes its properties into it with encoding/json. It suits components
+// whose properties are their exported fields.
+//
+//	scene.RegisterComponent("Spinner", scene.JSONComponent(NewSpinner))
+func JSONComponent[T Component](newFunc func() T) func(properties []byte) (T, error) {
+	return func(properties []byte) (T, error) {
+		c := newFunc()
+		if len(properties) == 0 {
+			return c, nil
+		}
+
+		return c, json.Unmarshal(properties, c)
+	}
+}
+
+// RegisteredComponents returns the names of the registered component types.
+func RegisteredComponents() []string {
+	componentMu.RLock()
+	defer componentMu.RUnlock()
+
+	names := make([]string, 0, len(componentFactories))
+	for name := range componentFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewComponent creates a component of a registered type from its
+// properties.
+func NewComponent(data ComponentData) (Component, error) {
+	componentMu.RLock()
+	factory, ok := componentFactories[data.Type]
+	componentMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("scene: component type not registered: %s", data.Type)
+	}
+
+	c, err := factory(data.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("scene: component %s: %v", data.Type, err)
+	}
+
+	return c, nil
+}
+
+// componentTypeName returns the name a component type is registered under.
+func componentTypeName(c Component) (string, bool) {
+	componentMu.RLock()
+	defer componentMu.RUnlock()
+
+	name, ok := componentTypes[reflect.TypeOf(c)]
+
+	return name, ok
+}
+
+// ReadFile reads a scene file.
+func ReadFile(r io.Reader) (*File, error) {
+	f := &File{}
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// NewSceneFromFile creates a scene which instantiates the objects of a scene
+// file when it is loaded, a top level object per load step.
+func NewSceneFromFile(f *File) *Scene {
+	s := NewScene(f.Name)
+
+	for i := range f.Objects {
+		objects := f.Objects[i : i+1]
+		s.LoadSteps = append(s.LoadSteps, func() error {
+			created, err := s.instantiate(objects, nil)
+			if err != nil {
+				for _, g := range created {
+					s.RemoveObject(g)
+				}
+			}
+			return err
+		})
+	}
+
+	return s
+}
+
+// instantiate creates objects from their descriptions and adds them to the
+// scene under parent, or at the top of the scene if parent is nil. The scene
+// must be loaded.
+func (s *Scene) instantiate(objects []ObjectData, parent *GameObject) ([]*GameObject, error) {
+	if s.graph == nil {
+		return nil, fmt.Errorf("scene: %s is not loaded", s.name)
+	}
+
+	created := make([]*GameObject, 0, len(objects))
+	for i := range objects {
+		g, err := newObject(&objects[i])
+		if err != nil {
+			return created, err
+		}
+
+		if err := s.AddObject(g, parent); err != nil {
+			return created, err
+		}
+		created = append(created, g)
+
+		if _, err := s.instantiate(objects[i].Children, g); err != nil {
+			return created, err
+		}
+	}
+
+	return created, nil
+}
+
+// newObject creates an object from its description, without its children.
+func newObject(data *ObjectData) (*GameObject, error) {
+	g := NewGameObject(data.Name)
+
+	if data.Active != nil {
+		g.SetActive(*data.Active)
+	}
+	g.SetTag(data.Tag)
+	g.SetLayer(data.Layer)
+
+	// The transform is applied when the object is added to the scene.
+	if t, ok := g.Transform().(*BaseTransform); ok && data.Transform != nil {
+		if p := data.Transform.Position; p != nil {
+			t.SetPositionN(*p)
+		}
+		if r := data.Transform.Rotation; r != nil {
+			t.SetRotationN(mgl32.Quat{W: r[3], V: r.Vec3()}.Normalize())
+		}
+		if sc := data.Transform.Scale; sc != nil {
+			t.SetScaleN(*sc)
+		}
+	}
+
+	for _, cd := range data.Components {
+		c, err := NewComponent(cd)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", data.Name, err)
+		}
+
+		g.AddComponent(c)
+	}
+
+	return g, nil
+}
+
+// File returns a scene file describing the objects of the scene. Components
+// of types which are not registered are left out.
+func (s *Scene) File() (*File, error) {
+	f := &File{Name: s.name, Objects: []ObjectData{}}
+	if s.graph == nil {
+		return f, nil
+	}
+
+	for _, g := range s.graph.root.children {
+		data, err := objectData(g)
+		if err != nil {
+			return nil, err
+		}
+
+		f.Objects = append(f.Objects, data)
+	}
+
+	return f, nil
+}
+
+// Save writes the scene as a scene file.
+func (s *Scene) Save(w io.Writer) error {
+	f, err := s.File()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+
+	return enc.Encode(f)
+}
+
+// objectData describes an object and its children.
+func objectData(g *GameObject) (ObjectData, error) {
+	data := ObjectData{
+		Name:  g.Name(),
+		Tag:   g.tag,
+		Layer: g.layer,
+	}
+
+	if !g.active {
+		active := false
+		data.Active = &active
+	}
+
+	t := g.Transform()
+	p, r, sc := t.Position(), t.Rotation(), t.Scale()
+	data.Transform = &TransformData{
+		Position: &p,
+		Rotation: &mgl32.Vec4{r.V[0], r.V[1], r.V[2], r.W},
+		Scale:    &sc,
+	}
+
+	for _, c := range g.components[1:] {
+		name, ok := componentTypeName(c)
+		if !ok {
+			logrus.Warnf("scene: %s: skipping component of unregistered type %T", g.Name(), c)
+			continue
+		}
+
+		properties, err := json.Marshal(c)
+		if err != nil {
+			return data, fmt.Errorf("scene: %s: component %s: %v", g.Name(), name, err)
+		}
+		if string(properties) == "{}" {
+			properties = nil
+		}
+
+		data.Components = append(data.Components, ComponentData{Type: name, Properties: properties})
+	}
+
+	for _, child := range g.children {
+		cd, err := objectData(child)
+		if err != nil {
+			return data, err
+		}
+
+		data.Children = append(data.Children, cd)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,486 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/asset/shader"
+)
+
+// assetKindMesh is the asset kind of meshes. The mesh asset package imports
+// this one, so it cannot be used here.
+const assetKindMesh = "mesh"
+
+func init() {
+	RegisterComponent("Camera", newCameraFromProperties)
+	RegisterComponent("Light", newLightFromProperties)
+	RegisterComponent("MeshFilter", newMeshFilterFromProperties)
+	RegisterComponent("MeshRenderer", newMeshRendererFromProperties)
+	RegisterComponent("BoxCollider", newBoxColliderFromProperties)
+	RegisterComponent("SphereCollider", newSphereColliderFromProperties)
+	RegisterComponent("CapsuleCollider", newCapsuleColliderFromProperties)
+	RegisterComponent("CharacterController", newCharacterControllerFromProperties)
+}
+
+var (
+	lightTypeNames = map[LightType]string{
+		LightDirectional: "directional",
+		LightPoint:       "point",
+		LightSpot:        "spot",
+	}
+	renderPathNames = map[RenderPath]string{
+		RenderPathForward:  "forward",
+		RenderPathDeferred: "deferred",
+	}
+	clearModeNames = map[ClearMode]string{
+		ClearModeSkybox:  "skybox",
+		ClearModeColor:   "color",
+		ClearModeDepth:   "depth",
+		ClearModeNothing: "nothing",
+	}
+)
+
+// enumValue returns the value of an enum by its name in a scene file.
+func enumValue[T comparable](names map[T]string, name string) (T, error) {
+	for v, n := range names {
+		if n == name {
+			return v, nil
+		}
+	}
+
+	var zero T
+	return zero, fmt.Errorf("unknown value: %s", name)
+}
+
+// decodeProperties decodes the properties of a component into v, which holds
+// the defaults of properties the file leaves out.
+func decodeProperties(properties []byte, v interface{}) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(properties, v)
+}
+
+type lightData struct {
+	Type           string     `json:"type"`
+	Color          mgl32.Vec4 `json:"color"`
+	Intensity      float32    `json:"intensity"`
+	Range          float32    `json:"range"`
+	SpotAngle      float32    `json:"spot_angle"`
+	SpotBlend      float32    `json:"spot_blend"`
+	Shadows        int32      `json:"shadows,omitempty"`
+	ShadowDistance float32    `json:"shadow_distance"`
+	ShadowCascades int32      `json:"shadow_cascades"`
+}
+
+// MarshalJSON encodes the properties of the light for scene files. Shadows
+// is the resolution of the shadow map, or zero if the light casts none.
+func (l *Light) MarshalJSON() ([]byte, error) {
+	d := lightData{
+		Type:           lightTypeNames[l.lightType],
+		Color:          l.color.Vec4(),
+		Intensity:      l.intensity,
+		Range:          l.lightRange,
+		SpotAngle:      l.spotAngle,
+		SpotBlend:      l.spotBlend,
+		ShadowDistance: l.shadowDistance,
+		ShadowCascades: l.shadowCascades,
+	}
+	if l.shadowMap != nil {
+		d.Shadows = l.shadowMap.Resolution()
+	}
+
+	return json.Marshal(d)
+}
+
+func newLightFromProperties(properties []byte) (*Light, error) {
+	l := NewLight(LightPoint)
+
+	data, err := l.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	d := lightData{}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	if l.lightType, err = enumValue(lightTypeNames, d.Type); err != nil {
+		return nil, err
+	}
+
+	l.SetColor(core.NewColorRGBA(d.Color))
+	l.SetIntensity(d.Intensity)
+	l.SetRange(d.Range)
+	l.SetSpotAngle(d.SpotAngle)
+	l.SetSpotBlend(d.SpotBlend)
+	l.SetShadowDistance(d.ShadowDistance)
+	l.SetShadowCascades(d.ShadowCascades)
+
+	if d.Shadows > 0 {
+		if err := l.EnableShadows(d.Shadows); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+type cameraData struct {
+	RenderPath       string     `json:"render_path"`
+	HDR              bool       `json:"hdr"`
+	Samples          int32      `json:"samples"`
+	Fov              float32    `json:"fov"`
+	NearClip         float32    `json:"near_clip"`
+	FarClip          float32    `json:"far_clip"`
+	Orthographic     bool       `json:"orthographic"`
+	OrthographicSize float32    `json:"orthographic_size"`
+	Depth            float32    `json:"depth"`
+	ClearMode        string     `json:"clear_mode"`
+	ClearColor       mgl32.Vec4 `json:"clear_color"`
+	CullingMask      LayerMask  `json:"culling_mask"`
+}
+
+// MarshalJSON encodes the properties of the camera for scene files.
+func (c *Camera) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cameraData{
+		RenderPath:       renderPathNames[c.renderPath],
+		HDR:              c.hdr,
+		Samples:          c.samples,
+		Fov:              c.fov,
+		NearClip:         c.nearClip,
+		FarClip:          c.farClip,
+		Orthographic:     c.orthographic,
+		OrthographicSize: c.orthographicSize,
+		Depth:            c.depth,
+		ClearMode:        clearModeNames[c.clearMode],
+		ClearColor:       c.clearColor.Vec4(),
+		CullingMask:      c.cullingMask,
+	})
+}
+
+func newCameraFromProperties(properties []byte) (*Camera, error) {
+	// The render path, HDR and samples are fixed when the camera is made,
+	// so they are decoded first.
+	d := cameraData{RenderPath: renderPathNames[RenderPathForward]}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	renderPath, err := enumValue(renderPathNames, d.RenderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCamera(renderPath, d.HDR, d.Samples)
+
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	clearMode, err := enumValue(clearModeNames, d.ClearMode)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetFov(d.Fov)
+	c.SetNearClip(d.NearClip)
+	c.SetFarClip(d.FarClip)
+	c.SetOrthographic(d.Orthographic)
+	c.SetOrthographicSize(d.OrthographicSize)
+	c.SetDepth(d.Depth)
+	c.SetClearMode(clearMode)
+	c.SetClearColor(core.NewColorRGBA(d.ClearColor))
+	c.SetCullingMask(d.CullingMask)
+
+	return c, nil
+}
+
+type meshFilterData struct {
+	Mesh string `json:"mesh,omitempty"`
+}
+
+// MarshalJSON encodes the properties of the mesh filter for scene files. The
+// mesh is stored by its asset name, so meshes which are not assets, such as
+// those made in code, are left out.
+func (m *MeshFilter) MarshalJSON() ([]byte, error) {
+	d := meshFilterData{}
+
+	if m.mesh != nil {
+		if a := core.GetAssetSystem(); a != nil && a.Exists(assetKindMesh, m.mesh.Name()) {
+			d.Mesh = m.mesh.Name()
+		} else {
+			logrus.Warnf("scene: mesh %s is not an asset and is not saved", m.mesh.Name())
+		}
+	}
+
+	return json.Marshal(d)
+}
+
+func newMeshFilterFromProperties(properties []byte) (*MeshFilter, error) {
+	d := meshFilterData{}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	m := NewMeshFilter(nil)
+	if d.Mesh == "" {
+		return m, nil
+	}
+
+	obj, err := core.GetAssetSystem().Get(assetKindMesh, d.Mesh)
+	if err != nil {
+		return nil, err
+	}
+
+	mesh, ok := obj.(*graphics.Mesh)
+	if !ok {
+		return nil, core.ErrAssetType(d.Mesh)
+	}
+	m.SetMesh(mesh)
+
+	return m, nil
+}
+
+// standardMaterialData holds the factors of a standard material. Materials
+// with maps or other shaders cannot be saved yet.
+type standardMaterialData struct {
+	Albedo    mgl32.Vec4 `json:"albedo"`
+	Metallic  float32    `json:"metallic"`
+	Roughness float32    `json:"roughness"`
+	Emissive  mgl32.Vec4 `json:"emissive"`
+}
+
+type meshRendererData struct {
+	CullFace   bool                  `json:"cull_face"`
+	DepthWrite bool                  `json:"depth_write"`
+	Wireframe  bool                  `json:"wireframe"`
+	Material   *standardMaterialData `json:"material,omitempty"`
+}
+
+// MarshalJSON encodes the properties of the mesh renderer for scene files.
+// Only materials drawn with the standard shader are saved, by their factors.
+func (m *MeshRenderer) MarshalJSON() ([]byte, error) {
+	d := meshRendererData{
+		CullFace:   m.cullFace,
+		DepthWrite: m.depthWrite,
+		Wireframe:  m.wireframe,
+	}
+
+	if m.material != nil && m.material.Shader() == shader.DefaultShader() {
+		s := &StandardMaterial{Material: m.material}
+		d.Material = &standardMaterialData{
+			Albedo:    s.Albedo().Vec4(),
+			Metallic:  s.Metallic(),
+			Roughness: s.Roughness(),
+			Emissive:  s.Emissive().Vec4(),
+		}
+	}
+
+	return json.Marshal(d)
+}
+
+func newMeshRendererFromProperties(properties []byte) (*MeshRenderer, error) {
+	m := NewMeshRenderer()
+
+	d := meshRendererData{
+		CullFace:   m.cullFace,
+		DepthWrite: m.depthWrite,
+		Wireframe:  m.wireframe,
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	m.SetCullFaceEnabled(d.CullFace)
+	m.SetDepthWriteEnabled(d.DepthWrite)
+	m.SetWireframeEnabled(d.Wireframe)
+
+	if d.Material != nil {
+		s := NewStandardMaterial()
+		s.SetAlbedo(core.NewColorRGBA(d.Material.Albedo))
+		s.SetMetallic(d.Material.Metallic)
+		s.SetRoughness(d.Material.Roughness)
+		s.SetEmissive(core.NewColorRGBA(d.Material.Emissive))
+
+		m.SetMaterial(s.Material)
+	}
+
+	return m, nil
+}
+
+type boxColliderData struct {
+	Center  mgl32.Vec3 `json:"center"`
+	Size    mgl32.Vec3 `json:"size"`
+	Trigger bool       `json:"trigger"`
+}
+
+// MarshalJSON encodes the properties of the box collider for scene files.
+func (c *BoxCollider) MarshalJSON() ([]byte, error) {
+	return json.Marshal(boxColliderData{
+		Center:  c.center,
+		Size:    c.size,
+		Trigger: c.trigger,
+	})
+}
+
+func newBoxColliderFromProperties(properties []byte) (*BoxCollider, error) {
+	d := boxColliderData{Size: mgl32.Vec3{1, 1, 1}}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	c := NewBoxCollider(d.Size)
+	c.SetCenter(d.Center)
+	c.SetTrigger(d.Trigger)
+
+	return c, nil
+}
+
+type sphereColliderData struct {
+	Center  mgl32.Vec3 `json:"center"`
+	Radius  float32    `json:"radius"`
+	Trigger bool       `json:"trigger"`
+}
+
+// MarshalJSON encodes the properties of the sphere collider for scene files.
+func (c *SphereCollider) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sphereColliderData{
+		Center:  c.center,
+		Radius:  c.radius,
+		Trigger: c.trigger,
+	})
+}
+
+func newSphereColliderFromProperties(properties []byte) (*SphereCollider, error) {
+	d := sphereColliderData{Radius: 0.5}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	c := NewSphereCollider(d.Radius)
+	c.SetCenter(d.Center)
+	c.SetTrigger(d.Trigger)
+
+	return c, nil
+}
+
+type capsuleColliderData struct {
+	Center  mgl32.Vec3 `json:"center"`
+	Radius  float32    `json:"radius"`
+	Height  float32    `json:"height"`
+	Trigger bool       `json:"trigger"`
+}
+
+// MarshalJSON encodes the properties of the capsule collider for scene
+// files.
+func (c *CapsuleCollider) MarshalJSON() ([]byte, error) {
+	return json.Marshal(capsuleColliderData{
+		Center:  c.center,
+		Radius:  c.radius,
+		Height:  c.height,
+		Trigger: c.trigger,
+	})
+}
+
+func newCapsuleColliderFromProperties(properties []byte) (*CapsuleCollider, error) {
+	d := capsuleColliderData{Radius: 0.5, Height: 2}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	c := NewCapsuleCollider(d.Radius, d.Height)
+	c.SetCenter(d.Center)
+	c.SetTrigger(d.Trigger)
+
+	return c, nil
+}
+
+type characterControllerData struct {
+	Center        mgl32.Vec3 `json:"center"`
+	Radius        float32    `json:"radius"`
+	Height        float32    `json:"height"`
+	SlopeLimit    float32    `json:"slope_limit"`
+	StepOffset    float32    `json:"step_offset"`
+	SkinWidth     float32    `json:"skin_width"`
+	CollisionMask LayerMask  `json:"collision_mask"`
+}
+
+// MarshalJSON encodes the properties of the character controller for scene
+// files.
+func (c *CharacterController) MarshalJSON() ([]byte, error) {
+	return json.Marshal(characterControllerData{
+		Center:        c.center,
+		Radius:        c.radius,
+		Height:        c.height,
+		SlopeLimit:    c.slopeLimit,
+		StepOffset:    c.stepOffset,
+		SkinWidth:     c.skinWidth,
+		CollisionMask: c.mask,
+	})
+}
+
+func newCharacterControllerFromProperties(properties []byte) (*CharacterController, error) {
+	c := NewCharacterController(0.5, 2)
+
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	d := characterControllerData{}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if err := decodeProperties(properties, &d); err != nil {
+		return nil, err
+	}
+
+	c.SetCenter(d.Center)
+	c.SetRadius(d.Radius)
+	c.SetHeight(d.Height)
+	c.SetSlopeLimit(d.SlopeLimit)
+	c.SetStepOffset(d.StepOffset)
+	c.SetSkinWidth(d.SkinWidth)
+	c.SetCollisionMask(d.CollisionMask)
+
+	return c, nil
+}
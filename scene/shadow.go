@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// shadowCasterRange is how far, in multiples of the shadow radius, the depth
+// range of a shadow map extends towards the light. It lets objects outside of
+// the view cast shadows into it.
+const shadowCasterRange = 4.0
+
+// frustumCorners returns the world space corners of the frustum of a view and
+// projection matrix.
+func frustumCorners(projection, view mgl32.Mat4) [8]mgl32.Vec3 {
+	var corners [8]mgl32.Vec3
+
+	inv := projection.Mul4(view).Inv()
+
+	i := 0
+	for _, x := range []float32{-1, 1} {
+		for _, y := range []float32{-1, 1} {
+			for _, z := range []float32{-1, 1} {
+				p := inv.Mul4x1(mgl32.Vec4{x, y, z, 1})
+				corners[i] = p.Vec3().Mul(1 / p.W())
+				i++
+			}
+		}
+	}
+
+	return corners
+}
+
+// shadowMatrix returns the view projection matrix of a directional light
+// which covers the part of the camera frustum between near and far. The
+// matrix is fit to a bounding sphere and snapped to shadow map texels, so
+// shadows do not shimmer as the camera moves.
+func shadowMatrix(c *Camera, direction mgl32.Vec3, near, far float32, resolution int32) mgl32.Mat4 {
+	projection := c.projection(near, far)
+	corners := frustumCorners(projection, c.ViewMatrix())
+
+	var center mgl32.Vec3
+	for i := range corners {
+		center = center.Add(corners[i])
+	}
+	center = center.Mul(1.0 / float32(len(corners)))
+
+	var radius float32
+	for i := range corners {
+		if d := corners[i].Sub(center).Len(); d > radius {
+			radius = d
+		}
+	}
+	radius = float32(math.Ceil(float64(radius)))
+
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(direction.Dot(up))) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1}
+	}
+
+	view := mgl32.LookAtV(mgl32.Vec3{}, direction, up)
+
+	texel := 2 * radius / float32(resolution)
+	ls := view.Mul4x1(center.Vec4(1))
+	x := float32(math.Floor(float64(ls.X()/texel))) * texel
+	y := float32(math.Floor(float64(ls.Y()/texel))) * texel
+	depth := -ls.Z()
+
+	ortho := mgl32.Ortho(x-radius, x+radius, y-radius, y+radius, depth-radius*shadowCasterRange, depth+radius)
+
+	return ortho.Mul4(view)
+}
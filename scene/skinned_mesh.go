@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+var _ ScriptComponent = &SkinnedMesh{}
+
+// MaxJointInfluences is the number of joints which can influence a vertex of
+// a skinned mesh.
+const MaxJointInfluences = 4
+
+// SkinnedMesh deforms a mesh by a skeleton of joint game objects. Each vertex
+// is moved by up to MaxJointInfluences joints, blended by its weights. The
+// mesh is skinned on the CPU in LateUpdate, after animation has been sampled,
+// into a copy owned by the component, so the bind pose mesh can be shared.
+type SkinnedMesh struct {
+	BaseScriptComponent
+
+	mesh        *graphics.Mesh
+	vertices    []mgl32.Vec3
+	normals     []mgl32.Vec3
+	joints      [][MaxJointInfluences]uint16
+	weights     [][MaxJointInfluences]float32
+	skeleton    []*GameObject
+	inverseBind []mgl32.Mat4
+	palette     []mgl32.Mat4
+}
+
+// NewSkinnedMesh creates a skinned mesh from a mesh in bind pose, with the
+// joint indices and weights of each vertex.
+func NewSkinnedMesh(bind *graphics.Mesh, joints [][MaxJointInfluences]uint16, weights [][MaxJointInfluences]float32) *SkinnedMesh {
+	s := &SkinnedMesh{
+		vertices: bind.Vertices(),
+		normals:  bind.Normals(),
+		joints:   joints,
+		weights:  weights,
+	}
+
+	s.mesh = graphics.NewMesh()
+	s.mesh.SetName(bind.Name())
+	s.mesh.SetVertices(append([]mgl32.Vec3(nil), s.vertices...))
+	s.mesh.SetNormals(append([]mgl32.Vec3(nil), s.normals...))
+	s.mesh.SetUvs(bind.Uvs())
+	s.mesh.SetTriangles(bind.Triangles())
+	s.mesh.SetReversedWinding(bind.ReversedWinding())
+	s.mesh.Alloc()
+
+	s.SetName("SkinnedMesh")
+	instance.MustAssign(s)
+
+	return s
+}
+
+// Mesh returns the skinned mesh, to be drawn through a MeshFilter.
+func (s *SkinnedMesh) Mesh() *graphics.Mesh {
+	return s.mesh
+}
+
+// Skeleton returns the joints of the skin.
+func (s *SkinnedMesh) Skeleton() []*GameObject {
+	return s.skeleton
+}
+
+// SetSkeleton sets the joints of the skin and their inverse bind matrices,
+// which move a vertex from the space of the mesh into the space of the joint
+// in bind pose. Joint indices of the vertices index into joints.
+func (s *SkinnedMesh) SetSkeleton(joints []*GameObject, inverseBind []mgl32.Mat4) {
+	s.skeleton = joints
+	s.inverseBind = inverseBind
+	s.palette = make([]mgl32.Mat4, len(joints))
+}
+
+// LateUpdate skins the mesh with the current pose of the skeleton.
+func (s *SkinnedMesh) LateUpdate() {
+	if len(s.skeleton) == 0 || s.GameObject() == nil {
+		return
+	}
+
+	// Joints are posed in world space, vertices stay in the space of the
+	// mesh so the model matrix of the game object still applies.
+	toMesh := s.GetTransform().ActiveMatrix().Inv()
+
+	for i := range s.skeleton {
+		s.palette[i] = toMesh.Mul4(s.skeleton[i].Transform().ActiveMatrix())
+		if i < len(s.inverseBind) {
+			s.palette[i] = s.palette[i].Mul4(s.inverseBind[i])
+		}
+	}
+
+	vertices := s.mesh.Vertices()
+	normals := s.mesh.Normals()
+
+	for v := range s.vertices {
+		if v >= len(s.joints) || v >= len(s.weights) {
+			break
+		}
+
+		var m mgl32.Mat4
+		var total float32
+
+		for k := 0; k < MaxJointInfluences; k++ {
+			w := s.weights[v][k]
+			j := int(s.joints[v][k])
+			if w == 0 || j >= len(s.palette) {
+				continue
+			}
+
+			m = m.Add(s.palette[j].Mul(w))
+			total += w
+		}
+
+		if total == 0 {
+			vertices[v] = s.vertices[v]
+			normals[v] = s.normals[v]
+			continue
+		}
+
+		m = m.Mul(1 / total)
+
+		vertices[v] = m.Mul4x1(s.vertices[v].Vec4(1)).Vec3()
+		if n := m.Mul4x1(s.normals[v].Vec4(0)).Vec3(); n.Len() > 0 {
+			normals[v] = n.Normalize()
+		}
+	}
+
+	s.mesh.SetVertices(vertices)
+	s.mesh.Upload()
+}
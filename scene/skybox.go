@@ -28,12 +28,16 @@ import (
 	"github.com/haakenlabs/arc/system/instance"
 )
 
+// skyboxBRDFUnit is the texture unit the BRDF lookup table is bound to.
+const skyboxBRDFUnit = 10
+
 type Skybox struct {
 	core.BaseObject
 
 	radiance   *graphics.TextureCubemap
 	specular   *graphics.TextureCubemap
 	irradiance *graphics.TextureCubemap
+	brdf       *graphics.Texture2D
 }
 
 func NewSkybox(radiance, specular, irradiance *graphics.TextureCubemap) *Skybox {
@@ -60,3 +64,28 @@ func (s *Skybox) Specular() *graphics.TextureCubemap {
 func (s *Skybox) Irradiance() *graphics.TextureCubemap {
 	return s.irradiance
 }
+
+// Owned returns the IDs of the cubemaps of the skybox, so they are released
+// when the skybox is unloaded. The BRDF lookup table is shared and not owned.
+func (s *Skybox) Owned() []int32 {
+	var ids []int32
+	for _, t := range []*graphics.TextureCubemap{s.radiance, s.specular, s.irradiance} {
+		if t != nil {
+			ids = append(ids, t.ID())
+		}
+	}
+
+	return ids
+}
+
+// BRDF returns the lookup table of the split sum approximation of image based
+// lighting, indexed by the view angle and roughness. It is nil if the skybox
+// has no lookup table.
+func (s *Skybox) BRDF() *graphics.Texture2D {
+	return s.brdf
+}
+
+// SetBRDF sets the lookup table of the split sum approximation.
+func (s *Skybox) SetBRDF(brdf *graphics.Texture2D) {
+	s.brdf = brdf
+}
@@ -0,0 +1,599 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+// spatialMargin is how far the bounds of a moving component may move before
+// it is reinserted into the tree.
+const spatialMargin = 0.1
+
+const nullNode int32 = -1
+
+// Bounded is implemented by components which occupy a region of the world.
+// Drawables are bounded, as are other components with a Bounds method.
+type Bounded interface {
+	Component
+
+	// Bounds returns the world space bounding box of the component.
+	Bounds() math.AABB
+}
+
+// RayHit is a component hit by a ray cast through a SpatialIndex.
+type RayHit struct {
+	Component Bounded
+	Distance  float32
+}
+
+// SpatialIndex is a bounding volume hierarchy of the bounded components of a
+// scene. It follows the scene graph and the transforms of the objects, and
+// answers region queries without visiting every component.
+//
+// The tree is a dynamic AABB tree: leaves hold the bounds of components
+// grown by a margin, so small movements do not change the tree, and it is
+// kept balanced by rotations as leaves are inserted and removed.
+//
+// Components with infinite bounds are not part of the tree. They are
+// returned by every region query, and never by ray casts or pairs.
+// Components with empty bounds are returned by no query.
+type SpatialIndex struct {
+	nodes     []spatialNode
+	free      int32
+	root      int32
+	proxies   map[Bounded]*spatialProxy
+	unbounded []*spatialProxy
+	moved     []*GameObject
+	isMoved   map[*GameObject]bool
+	stack     []int32
+	gen       uint32
+}
+
+type spatialNode struct {
+	bounds math.AABB
+	parent int32
+	child1 int32
+	child2 int32
+	height int32
+	proxy  *spatialProxy
+}
+
+// spatialProxy is a component in the index.
+type spatialProxy struct {
+	item      Bounded
+	bounds    math.AABB
+	leaf      int32
+	unbounded bool
+	gen       uint32
+}
+
+// NewSpatialIndex creates an empty spatial index.
+func NewSpatialIndex() *SpatialIndex {
+	return &SpatialIndex{
+		free:    nullNode,
+		root:    nullNode,
+		proxies: make(map[Bounded]*spatialProxy),
+		isMoved: make(map[*GameObject]bool),
+	}
+}
+
+// Len returns the number of components in the index.
+func (t *SpatialIndex) Len() int {
+	return len(t.proxies)
+}
+
+// Invalidate updates the bounds of a component which changed without its
+// transform changing, for example when its mesh was replaced.
+func (t *SpatialIndex) Invalidate(item Bounded) {
+	if p, ok := t.proxies[item]; ok {
+		t.place(p)
+	}
+}
+
+// QueryAABB appends the components whose bounds intersect a box to dst.
+func (t *SpatialIndex) QueryAABB(b math.AABB, dst []Bounded) []Bounded {
+	return t.query(b.Intersects, dst)
+}
+
+// QuerySphere appends the components whose bounds intersect a sphere to dst.
+func (t *SpatialIndex) QuerySphere(s math.Sphere, dst []Bounded) []Bounded {
+	return t.query(s.IntersectsAABB, dst)
+}
+
+// QueryFrustum appends the components whose bounds intersect a view frustum
+// to dst.
+func (t *SpatialIndex) QueryFrustum(f math.Frustum, dst []Bounded) []Bounded {
+	return t.query(f.IntersectsAABB, dst)
+}
+
+// Raycast appends the components whose bounds are hit by a ray within
+// maxDistance to dst, nearest first. The direction of the ray must be
+// normalized.
+func (t *SpatialIndex) Raycast(ray math.Ray, maxDistance float32, dst []RayHit) []RayHit {
+	t.refresh()
+
+	start := len(dst)
+
+	t.traverse(func(b math.AABB) bool {
+		d, ok := ray.IntersectAABB(b)
+		return ok && d <= maxDistance
+	}, func(p *spatialProxy) {
+		if d, ok := ray.IntersectAABB(p.bounds); ok && d <= maxDistance {
+			dst = append(dst, RayHit{Component: p.item, Distance: d})
+		}
+	})
+
+	hits := dst[start:]
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+
+	return dst
+}
+
+// Pairs calls fn for each pair of components whose bounds overlap, once
+// per pair. It is the broadphase of collision detection.
+func (t *SpatialIndex) Pairs(fn func(a, b Bounded)) {
+	t.refresh()
+
+	for i := range t.nodes {
+		p := t.nodes[i].proxy
+		if p == nil || t.nodes[i].height != 0 {
+			continue
+		}
+
+		t.traverse(p.bounds.Intersects, func(o *spatialProxy) {
+			// Each pair is found from both of its leaves.
+			if o.leaf > p.leaf && o.bounds.Intersects(p.bounds) {
+				fn(p.item, o.item)
+			}
+		})
+	}
+}
+
+// occluded reports if the bounds of a component in mask lie between the
+// listener and a sound. Components containing the listener or the sound are
+// ignored, so a sound is not occluded by its own source. The hits of the ray
+// are appended to hits, which is returned for reuse.
+func (t *SpatialIndex) occluded(listener, source mgl32.Vec3, mask LayerMask, hits []RayHit) (bool, []RayHit) {
+	d := source.Sub(listener)
+	dist := d.Len()
+	if dist == 0 {
+		return false, hits
+	}
+
+	hits = t.Raycast(math.NewRay(listener, d), dist, hits[:0])
+	for _, h := range hits {
+		g := h.Component.GameObject()
+		if g == nil || !mask.Contains(g.Layer()) {
+			continue
+		}
+
+		b := h.Component.Bounds()
+		if !b.Contains(listener) && !b.Contains(source) {
+			return true, hits
+		}
+	}
+
+	return false, hits
+}
+
+// OcclusionQuery returns an audio occlusion query which reports a sound as
+// occluded when the bounds of a component in mask lie between it and the
+// listener. It is cheaper than ColliderOcclusionQuery, but the bounds of
+// renderers also occlude. The spatial index of the active scene is looked up on each call,
+// so the query follows scene loads, and nothing is occluded while no scene
+// is loaded.
+func OcclusionQuery(mask LayerMask) core.OcclusionQuery {
+	var hits []RayHit
+
+	return func(listener, source mgl32.Vec3) bool {
+		sys := core.GetSceneSystem()
+		if sys == nil {
+			return false
+		}
+
+		s, ok := sys.Active().(*Scene)
+		if !ok || s.spatial == nil {
+			return false
+		}
+
+		var occluded bool
+		occluded, hits = s.spatial.occluded(listener, source, mask, hits)
+
+		return occluded
+	}
+}
+
+// query appends the components whose bounds pass test to dst.
+func (t *SpatialIndex) query(test func(math.AABB) bool, dst []Bounded) []Bounded {
+	t.refresh()
+
+	for _, p := range t.unbounded {
+		dst = append(dst, p.item)
+	}
+
+	t.traverse(test, func(p *spatialProxy) {
+		if test(p.bounds) {
+			dst = append(dst, p.item)
+		}
+	})
+
+	return dst
+}
+
+// traverse calls visit with the proxy of each leaf reached through nodes
+// whose bounds pass test.
+func (t *SpatialIndex) traverse(test func(math.AABB) bool, visit func(*spatialProxy)) {
+	if t.root == nullNode {
+		return
+	}
+
+	// Visits may run queries of their own, so the stack is only borrowed.
+	stack := append(t.stack[:0], t.root)
+	t.stack = nil
+
+	for len(stack) != 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &t.nodes[i]
+		if !test(n.bounds) {
+			continue
+		}
+
+		if n.child1 == nullNode {
+			visit(n.proxy)
+		} else {
+			stack = append(stack, n.child1, n.child2)
+		}
+	}
+
+	t.stack = stack
+}
+
+// sync adds the bounded components of the scene to the index, and removes
+// those which left it.
+func (t *SpatialIndex) sync(components []Component) {
+	t.gen++
+
+	for _, c := range components {
+		item, ok := c.(Bounded)
+		if !ok {
+			continue
+		}
+
+		p, ok := t.proxies[item]
+		if !ok {
+			p = &spatialProxy{item: item, leaf: nullNode}
+			t.proxies[item] = p
+			t.place(p)
+		}
+		p.gen = t.gen
+	}
+
+	var stale []*spatialProxy
+	for _, p := range t.proxies {
+		if p.gen != t.gen {
+			stale = append(stale, p)
+		}
+	}
+
+	// Remove in a fixed order, so the tree is the same between runs.
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].item.ID() < stale[j].item.ID()
+	})
+	for _, p := range stale {
+		t.unplace(p)
+		delete(t.proxies, p.item)
+	}
+}
+
+// move records that the transform of an object changed.
+func (t *SpatialIndex) move(g *GameObject) {
+	if !t.isMoved[g] {
+		t.isMoved[g] = true
+		t.moved = append(t.moved, g)
+	}
+}
+
+// refresh updates the bounds of the components of moved objects.
+func (t *SpatialIndex) refresh() {
+	for i, g := range t.moved {
+		for _, c := range g.components {
+			if item, ok := c.(Bounded); ok {
+				if p, ok := t.proxies[item]; ok {
+					t.place(p)
+				}
+			}
+		}
+		delete(t.isMoved, g)
+		t.moved[i] = nil
+	}
+	t.moved = t.moved[:0]
+}
+
+// place updates the bounds of a proxy, and moves it in the tree if they
+// left its leaf.
+func (t *SpatialIndex) place(p *spatialProxy) {
+	p.bounds = p.item.Bounds()
+
+	switch {
+	case p.bounds.Empty():
+		t.unplace(p)
+	case p.bounds.Infinite():
+		if !p.unbounded {
+			t.unplace(p)
+			p.unbounded = true
+			t.unbounded = append(t.unbounded, p)
+		}
+	case p.leaf != nullNode && t.nodes[p.leaf].bounds.ContainsAABB(p.bounds):
+	default:
+		t.unplace(p)
+
+		p.leaf = t.allocNode()
+		t.nodes[p.leaf].bounds = p.bounds.Expand(spatialMargin)
+		t.nodes[p.leaf].proxy = p
+		t.insertLeaf(p.leaf)
+	}
+}
+
+// unplace removes a proxy from the tree and the unbounded list.
+func (t *SpatialIndex) unplace(p *spatialProxy) {
+	if p.leaf != nullNode {
+		t.removeLeaf(p.leaf)
+		t.freeNode(p.leaf)
+		p.leaf = nullNode
+	}
+
+	if p.unbounded {
+		for i := range t.unbounded {
+			if t.unbounded[i] == p {
+				t.unbounded = append(t.unbounded[:i], t.unbounded[i+1:]...)
+				break
+			}
+		}
+		p.unbounded = false
+	}
+}
+
+func (t *SpatialIndex) allocNode() int32 {
+	node := spatialNode{
+		parent: nullNode,
+		child1: nullNode,
+		child2: nullNode,
+	}
+
+	if t.free == nullNode {
+		t.nodes = append(t.nodes, node)
+		return int32(len(t.nodes) - 1)
+	}
+
+	i := t.free
+	t.free = t.nodes[i].parent
+	t.nodes[i] = node
+
+	return i
+}
+
+func (t *SpatialIndex) freeNode(i int32) {
+	t.nodes[i] = spatialNode{parent: t.free, child1: nullNode, child2: nullNode, height: -1}
+	t.free = i
+}
+
+// insertLeaf adds a leaf to the tree next to the node which grows the
+// surface area of the tree the least.
+func (t *SpatialIndex) insertLeaf(leaf int32) {
+	if t.root == nullNode {
+		t.root = leaf
+		t.nodes[leaf].parent = nullNode
+		return
+	}
+
+	lb := t.nodes[leaf].bounds
+
+	index := t.root
+	for t.nodes[index].child1 != nullNode {
+		n := t.nodes[index]
+
+		area := n.bounds.SurfaceArea()
+		combined := n.bounds.Union(lb).SurfaceArea()
+
+		// Cost of pairing the leaf with this node, and the cost pushed down
+		// to the children if it descends.
+		cost := 2 * combined
+		inherit := 2 * (combined - area)
+
+		cost1 := t.descendCost(n.child1, lb) + inherit
+		cost2 := t.descendCost(n.child2, lb) + inherit
+
+		if cost < cost1 && cost < cost2 {
+			break
+		}
+
+		if cost1 < cost2 {
+			index = n.child1
+		} else {
+			index = n.child2
+		}
+	}
+
+	sibling := index
+	oldParent := t.nodes[sibling].parent
+
+	parent := t.allocNode()
+	t.nodes[parent].parent = oldParent
+	t.nodes[parent].bounds = lb.Union(t.nodes[sibling].bounds)
+	t.nodes[parent].height = t.nodes[sibling].height + 1
+	t.nodes[parent].child1 = sibling
+	t.nodes[parent].child2 = leaf
+
+	if oldParent == nullNode {
+		t.root = parent
+	} else if t.nodes[oldParent].child1 == sibling {
+		t.nodes[oldParent].child1 = parent
+	} else {
+		t.nodes[oldParent].child2 = parent
+	}
+
+	t.nodes[sibling].parent = parent
+	t.nodes[leaf].parent = parent
+
+	t.refit(parent)
+}
+
+// descendCost returns the growth in surface area of inserting a box below
+// a node.
+func (t *SpatialIndex) descendCost(i int32, b math.AABB) float32 {
+	n := &t.nodes[i]
+	grown := n.bounds.Union(b).SurfaceArea()
+
+	if n.child1 == nullNode {
+		return grown
+	}
+
+	return grown - n.bounds.SurfaceArea()
+}
+
+// removeLeaf takes a leaf out of the tree, replacing its parent with its
+// sibling. The leaf node itself is not freed.
+func (t *SpatialIndex) removeLeaf(leaf int32) {
+	if leaf == t.root {
+		t.root = nullNode
+		return
+	}
+
+	parent := t.nodes[leaf].parent
+	grand := t.nodes[parent].parent
+
+	sibling := t.nodes[parent].child1
+	if sibling == leaf {
+		sibling = t.nodes[parent].child2
+	}
+
+	t.nodes[sibling].parent = grand
+	t.freeNode(parent)
+
+	if grand == nullNode {
+		t.root = sibling
+		return
+	}
+
+	if t.nodes[grand].child1 == parent {
+		t.nodes[grand].child1 = sibling
+	} else {
+		t.nodes[grand].child2 = sibling
+	}
+
+	t.refit(grand)
+}
+
+// refit balances and recomputes the bounds and heights of a node and its
+// ancestors.
+func (t *SpatialIndex) refit(i int32) {
+	for i != nullNode {
+		i = t.balance(i)
+
+		n := &t.nodes[i]
+		c1, c2 := &t.nodes[n.child1], &t.nodes[n.child2]
+
+		n.height = 1 + max(c1.height, c2.height)
+		n.bounds = c1.bounds.Union(c2.bounds)
+
+		i = n.parent
+	}
+}
+
+// balance rotates the taller child of a node up if the heights of its
+// children differ by more than one. It returns the node now in its place.
+func (t *SpatialIndex) balance(a int32) int32 {
+	nodes := t.nodes
+
+	A := &nodes[a]
+	if A.child1 == nullNode || A.height < 2 {
+		return a
+	}
+
+	b, c := A.child1, A.child2
+	B, C := &nodes[b], &nodes[c]
+
+	switch d := C.height - B.height; {
+	case d > 1:
+		t.rotate(a, c, b, false)
+		return c
+	case d < -1:
+		t.rotate(a, b, c, true)
+		return b
+	}
+
+	return a
+}
+
+// rotate moves up the child up of node a, which becomes the parent of a.
+// The taller child of up replaces it below a, next to other. left reports
+// if up is the first child of a.
+func (t *SpatialIndex) rotate(a, up, other int32, left bool) {
+	nodes := t.nodes
+	A, U, O := &nodes[a], &nodes[up], &nodes[other]
+
+	f, g := U.child1, U.child2
+	F, G := &nodes[f], &nodes[g]
+
+	U.child1 = a
+	U.parent = A.parent
+	A.parent = up
+
+	if U.parent == nullNode {
+		t.root = up
+	} else if nodes[U.parent].child1 == a {
+		nodes[U.parent].child1 = up
+	} else {
+		nodes[U.parent].child2 = up
+	}
+
+	// The taller grandchild stays with up, the other moves to a.
+	keep, move := f, g
+	K, M := F, G
+	if F.height <= G.height {
+		keep, move = g, f
+		K, M = G, F
+	}
+
+	U.child2 = keep
+	if left {
+		A.child1 = move
+	} else {
+		A.child2 = move
+	}
+	M.parent = a
+
+	A.bounds = O.bounds.Union(M.bounds)
+	A.height = 1 + max(O.height, M.height)
+	U.bounds = A.bounds.Union(K.bounds)
+	U.height = 1 + max(A.height, K.height)
+}
@@ -0,0 +1,293 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/pkg/math"
+)
+
+func TestMain(m *testing.M) {
+	core.NewInstanceSystem().Setup()
+
+	os.Exit(m.Run())
+}
+
+// testBounded is a bounded component with fixed bounds.
+type testBounded struct {
+	BaseComponent
+
+	bounds math.AABB
+}
+
+func (b *testBounded) Bounds() math.AABB { return b.bounds }
+
+func newTestBounded(id int32, min, max mgl32.Vec3) *testBounded {
+	b := &testBounded{bounds: math.AABB{Min: min, Max: max}}
+	b.SetID(id)
+
+	return b
+}
+
+// setupTestIndex returns an index of a 10x10 grid of unit boxes on the XZ
+// plane, one every two units.
+func setupTestIndex() (*SpatialIndex, []Component) {
+	var components []Component
+	for x := 0; x < 10; x++ {
+		for z := 0; z < 10; z++ {
+			min := mgl32.Vec3{float32(x * 2), 0, float32(z * 2)}
+			components = append(components, newTestBounded(int32(x*10+z), min, min.Add(mgl32.Vec3{1, 1, 1})))
+		}
+	}
+
+	t := NewSpatialIndex()
+	t.sync(components)
+
+	return t, components
+}
+
+func ids(items []Bounded) []int32 {
+	out := make([]int32, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.ID())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	return out
+}
+
+func equalIDs(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSpatialIndex_QueryAABB(t *testing.T) {
+	index, components := setupTestIndex()
+
+	tests := []math.AABB{
+		{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{0.5, 0.5, 0.5}},
+		{Min: mgl32.Vec3{1.5, 0, 1.5}, Max: mgl32.Vec3{6.5, 1, 3.5}},
+		{Min: mgl32.Vec3{-10, -10, -10}, Max: mgl32.Vec3{30, 30, 30}},
+		{Min: mgl32.Vec3{1.2, 0, 1.2}, Max: mgl32.Vec3{1.8, 1, 1.8}},
+		{Min: mgl32.Vec3{0, 5, 0}, Max: mgl32.Vec3{20, 6, 20}},
+	}
+
+	for i, box := range tests {
+		var want []Bounded
+		for _, c := range components {
+			if b := c.(Bounded); b.Bounds().Intersects(box) {
+				want = append(want, b)
+			}
+		}
+
+		if got := ids(index.QueryAABB(box, nil)); !equalIDs(got, ids(want)) {
+			t.Errorf("test %d: QueryAABB() = %v, want %v", i, got, ids(want))
+		}
+	}
+}
+
+func TestSpatialIndex_Sync(t *testing.T) {
+	index, components := setupTestIndex()
+
+	if got := index.Len(); got != 100 {
+		t.Fatalf("Len() = %d, want 100", got)
+	}
+
+	// Components missing from the next sync are removed.
+	index.sync(components[50:])
+	if got := index.Len(); got != 50 {
+		t.Errorf("Len() = %d, want 50", got)
+	}
+
+	all := math.AABB{Min: mgl32.Vec3{-100, -100, -100}, Max: mgl32.Vec3{100, 100, 100}}
+	for _, id := range ids(index.QueryAABB(all, nil)) {
+		if id < 50 {
+			t.Errorf("removed component %d was returned", id)
+		}
+	}
+}
+
+func TestSpatialIndex_Invalidate(t *testing.T) {
+	index, components := setupTestIndex()
+	b := components[0].(*testBounded)
+
+	b.bounds = math.AABB{Min: mgl32.Vec3{50, 0, 50}, Max: mgl32.Vec3{51, 1, 51}}
+	index.Invalidate(b)
+
+	if got := ids(index.QueryAABB(math.AABB{Min: mgl32.Vec3{49, 0, 49}, Max: mgl32.Vec3{52, 1, 52}}, nil)); !equalIDs(got, []int32{0}) {
+		t.Errorf("QueryAABB() at new bounds = %v, want [0]", got)
+	}
+	if got := index.QueryAABB(math.AABB{Min: mgl32.Vec3{0.2, 0.2, 0.2}, Max: mgl32.Vec3{0.8, 0.8, 0.8}}, nil); len(got) != 0 {
+		t.Errorf("QueryAABB() at old bounds = %v, want none", ids(got))
+	}
+}
+
+func TestSpatialIndex_Move(t *testing.T) {
+	g := NewGameObject("box")
+	c := NewBoxCollider(mgl32.Vec3{1, 1, 1})
+	g.AddComponent(c)
+
+	index := NewSpatialIndex()
+	index.sync(g.Components())
+
+	g.Transform().SetPosition(mgl32.Vec3{100, 0, 0})
+	index.move(g)
+
+	near := math.AABB{Min: mgl32.Vec3{99, -1, -1}, Max: mgl32.Vec3{101, 1, 1}}
+	if got := index.QueryAABB(near, nil); len(got) != 1 || got[0] != Bounded(c) {
+		t.Errorf("QueryAABB() at new position = %v, want the collider", got)
+	}
+
+	origin := math.AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}}
+	if got := index.QueryAABB(origin, nil); len(got) != 0 {
+		t.Errorf("QueryAABB() at old position = %v, want none", got)
+	}
+}
+
+func TestSpatialIndex_Raycast(t *testing.T) {
+	index, _ := setupTestIndex()
+
+	tests := []struct {
+		ray         math.Ray
+		maxDistance float32
+		want        []int32
+	}{
+		// Along the first row of boxes, nearest first.
+		{ray: math.NewRay(mgl32.Vec3{-1, 0.5, 0.5}, mgl32.Vec3{1, 0, 0}), maxDistance: 100, want: []int32{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}},
+		{ray: math.NewRay(mgl32.Vec3{-1, 0.5, 0.5}, mgl32.Vec3{1, 0, 0}), maxDistance: 3.5, want: []int32{0, 10}},
+		// Back along the second row.
+		{ray: math.NewRay(mgl32.Vec3{30, 0.5, 2.5}, mgl32.Vec3{-1, 0, 0}), maxDistance: 15, want: []int32{91, 81, 71}},
+		// Between the rows.
+		{ray: math.NewRay(mgl32.Vec3{-1, 0.5, 1.5}, mgl32.Vec3{1, 0, 0}), maxDistance: 100},
+		// Down onto a box.
+		{ray: math.NewRay(mgl32.Vec3{4.5, 10, 6.5}, mgl32.Vec3{0, -1, 0}), maxDistance: 100, want: []int32{23}},
+	}
+
+	for i, test := range tests {
+		hits := index.Raycast(test.ray, test.maxDistance, nil)
+
+		got := make([]int32, 0, len(hits))
+		for j, h := range hits {
+			got = append(got, h.Component.ID())
+			if j > 0 && h.Distance < hits[j-1].Distance {
+				t.Errorf("test %d: hits are not sorted by distance", i)
+			}
+		}
+
+		if !equalIDs(got, test.want) && !(len(got) == 0 && len(test.want) == 0) {
+			t.Errorf("test %d: Raycast() = %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestSpatialIndex_QueryFrustum(t *testing.T) {
+	index, _ := setupTestIndex()
+
+	// A narrow camera above the grid, looking down at the box at (8, 8).
+	view := mgl32.LookAtV(mgl32.Vec3{8.5, 10, 8.5}, mgl32.Vec3{8.5, 0, 8.5}, mgl32.Vec3{0, 0, -1})
+	proj := mgl32.Perspective(mgl32.DegToRad(10), 1, 0.1, 100)
+	f := math.NewFrustum(proj.Mul4(view))
+
+	if got := ids(index.QueryFrustum(f, nil)); !equalIDs(got, []int32{44}) {
+		t.Errorf("QueryFrustum() = %v, want [44]", got)
+	}
+
+	// Looking away from the grid.
+	view = mgl32.LookAtV(mgl32.Vec3{8.5, 10, 8.5}, mgl32.Vec3{8.5, 20, 8.5}, mgl32.Vec3{0, 0, -1})
+	f = math.NewFrustum(proj.Mul4(view))
+
+	if got := index.QueryFrustum(f, nil); len(got) != 0 {
+		t.Errorf("QueryFrustum() looking away = %v, want none", ids(got))
+	}
+}
+
+func TestSpatialIndex_Unbounded(t *testing.T) {
+	index, components := setupTestIndex()
+
+	sky := &testBounded{bounds: math.InfiniteAABB()}
+	sky.SetID(1000)
+	index.sync(append(components, sky))
+
+	far := math.AABB{Min: mgl32.Vec3{500, 500, 500}, Max: mgl32.Vec3{501, 501, 501}}
+	if got := ids(index.QueryAABB(far, nil)); !equalIDs(got, []int32{1000}) {
+		t.Errorf("QueryAABB() = %v, want [1000]", got)
+	}
+
+	for _, h := range index.Raycast(math.NewRay(mgl32.Vec3{-1, 0.5, 0.5}, mgl32.Vec3{1, 0, 0}), 100, nil) {
+		if h.Component == Bounded(sky) {
+			t.Error("Raycast() hit a component with infinite bounds")
+		}
+	}
+}
+
+func TestSpatialIndex_Occluded(t *testing.T) {
+	index, components := setupTestIndex()
+
+	g := NewGameObject("grid")
+	for _, c := range components {
+		c.SetGameObject(g)
+	}
+
+	tests := []struct {
+		listener, source mgl32.Vec3
+		want             bool
+	}{
+		// Through the box at (2, 0).
+		{listener: mgl32.Vec3{1.5, 0.5, 0.5}, source: mgl32.Vec3{3.5, 0.5, 0.5}, want: true},
+		// Between the rows.
+		{listener: mgl32.Vec3{-1, 0.5, 1.5}, source: mgl32.Vec3{19, 0.5, 1.5}},
+		// From inside a box, which does not occlude its own sounds.
+		{listener: mgl32.Vec3{-1, 0.5, 0.5}, source: mgl32.Vec3{0.5, 0.5, 0.5}},
+		// Above the grid.
+		{listener: mgl32.Vec3{0, 2, 0}, source: mgl32.Vec3{18, 2, 18}},
+		{listener: mgl32.Vec3{1, 1, 1}, source: mgl32.Vec3{1, 1, 1}},
+	}
+
+	var hits []RayHit
+	for i, test := range tests {
+		var got bool
+		got, hits = index.occluded(test.listener, test.source, ^LayerMask(0), hits)
+		if got != test.want {
+			t.Errorf("test %d: occluded() = %v, want %v", i, got, test.want)
+		}
+	}
+
+	// Components outside the mask do not occlude.
+	if got, _ := index.occluded(tests[0].listener, tests[0].source, 0, nil); got {
+		t.Error("occluded() with an empty mask = true, want false")
+	}
+}
@@ -23,6 +23,8 @@ SOFTWARE.
 package scene
 
 import (
+	"fmt"
+
 	"github.com/go-gl/mathgl/mgl32"
 
 	"github.com/haakenlabs/arc/system/instance"
@@ -42,10 +44,54 @@ type Transform interface {
 	SetPosition(mgl32.Vec3)
 	SetScale(mgl32.Vec3)
 	Recompute(bool)
+
+	// WorldRotation returns the rotation of the transform in world space.
+	WorldRotation() mgl32.Quat
+
+	// LossyScale returns the scale of the transform in world space. It is
+	// only exact if no ancestor is both rotated and non-uniformly scaled.
+	LossyScale() mgl32.Vec3
+
+	// SetWorldPosition moves the transform to a position in world space.
+	SetWorldPosition(mgl32.Vec3)
+
+	// SetWorldRotation rotates the transform to a rotation in world space.
+	SetWorldRotation(mgl32.Quat)
+
+	// TransformPoint converts a point from local to world space.
+	TransformPoint(mgl32.Vec3) mgl32.Vec3
+
+	// InverseTransformPoint converts a point from world to local space.
+	InverseTransformPoint(mgl32.Vec3) mgl32.Vec3
+
+	// TransformDirection converts a direction from local to world space. It
+	// is only rotated, so its length is kept.
+	TransformDirection(mgl32.Vec3) mgl32.Vec3
+
+	// InverseTransformDirection converts a direction from world to local
+	// space. It is only rotated, so its length is kept.
+	InverseTransformDirection(mgl32.Vec3) mgl32.Vec3
+
+	// TransformVector converts a vector from local to world space, including
+	// scale but not position.
+	TransformVector(mgl32.Vec3) mgl32.Vec3
+
+	// InverseTransformVector converts a vector from world to local space.
+	InverseTransformVector(mgl32.Vec3) mgl32.Vec3
+
+	// SetParent moves the object of the transform under parent, or to the
+	// top of its scene if parent is nil. If keepWorld is set, the local
+	// values are changed so the object stays where it is in the world.
+	SetParent(parent *GameObject, keepWorld bool) error
 }
 
 // Transform is a component which handles scale, rotation, and
 // position transformations.
+//
+// Matrices are computed lazily. Changing a transform marks it and its
+// descendants dirty, and their matrices are recomputed the next time they
+// are read, so a hierarchy which moves many times in a frame is only
+// computed once.
 type BaseTransform struct {
 	BaseScriptComponent
 
@@ -54,13 +100,29 @@ type BaseTransform struct {
 	rotation     mgl32.Quat
 	position     mgl32.Vec3
 	scale        mgl32.Vec3
+	localDirty   bool
+	worldDirty   bool
 }
 
 func (t *BaseTransform) ModelMatrix() mgl32.Mat4 {
+	if t.localDirty {
+		tp := mgl32.Translate3D(t.position.X(), t.position.Y(), t.position.Z())
+		tr := t.rotation.Mat4()
+		ts := mgl32.Scale3D(t.scale.X(), t.scale.Y(), t.scale.Z())
+
+		t.modelMatrix = tp.Mul4(tr.Mul4(ts))
+		t.localDirty = false
+	}
+
 	return t.modelMatrix
 }
 
 func (t *BaseTransform) ActiveMatrix() mgl32.Mat4 {
+	if t.worldDirty || t.localDirty {
+		t.activeMatrix = t.parentMatrix().Mul4(t.ModelMatrix())
+		t.worldDirty = false
+	}
+
 	return t.activeMatrix
 }
 
@@ -91,48 +153,222 @@ func (t *BaseTransform) SetScale(scale mgl32.Vec3) {
 	t.Recompute(true)
 }
 
+// SetRotationN sets the rotation without notifying the object or its
+// children. It is meant for transforms which are not in a hierarchy yet.
 func (t *BaseTransform) SetRotationN(rotation mgl32.Quat) {
 	t.rotation = rotation
+	t.localDirty = true
 }
 
+// SetPositionN sets the position without notifying the object or its
+// children.
 func (t *BaseTransform) SetPositionN(position mgl32.Vec3) {
 	t.position = position
+	t.localDirty = true
 }
 
+// SetScaleN sets the scale without notifying the object or its children.
 func (t *BaseTransform) SetScaleN(scale mgl32.Vec3) {
 	t.scale = scale
+	t.localDirty = true
 }
 
-func (t *BaseTransform) Recompute(updateChildren bool) {
-	tp := mgl32.Translate3D(t.position.X(), t.position.Y(), t.position.Z())
-	tr := t.rotation.Mat4()
-	ts := mgl32.Scale3D(t.scale.X(), t.scale.Y(), t.scale.Z())
+// WorldPosition returns the position of the transform in world space. It is
+// not part of Transform, as RectTransform replaces it with a 2D position;
+// TransformPoint of the origin is the same for any transform.
+func (t *BaseTransform) WorldPosition() mgl32.Vec3 {
+	return t.ActiveMatrix().Col(3).Vec3()
+}
 
-	t.modelMatrix = tp.Mul4(tr.Mul4(ts))
-	t.activeMatrix = t.modelMatrix
+// WorldRotation returns the rotation of the transform in world space.
+func (t *BaseTransform) WorldRotation() mgl32.Quat {
+	_, r, _ := decompose(t.ActiveMatrix())
+
+	return r
+}
+
+// LossyScale returns the scale of the transform in world space.
+func (t *BaseTransform) LossyScale() mgl32.Vec3 {
+	_, _, s := decompose(t.ActiveMatrix())
+
+	return s
+}
+
+// SetWorldPosition moves the transform to a position in world space.
+func (t *BaseTransform) SetWorldPosition(position mgl32.Vec3) {
+	t.SetPosition(mgl32.TransformCoordinate(position, t.parentMatrix().Inv()))
+}
+
+// SetWorldRotation rotates the transform to a rotation in world space.
+func (t *BaseTransform) SetWorldRotation(rotation mgl32.Quat) {
+	_, parent, _ := decompose(t.parentMatrix())
+
+	t.SetRotation(parent.Inverse().Mul(rotation).Normalize())
+}
+
+// TransformPoint converts a point from local to world space.
+func (t *BaseTransform) TransformPoint(point mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.TransformCoordinate(point, t.ActiveMatrix())
+}
+
+// InverseTransformPoint converts a point from world to local space.
+func (t *BaseTransform) InverseTransformPoint(point mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.TransformCoordinate(point, t.ActiveMatrix().Inv())
+}
+
+// TransformDirection converts a direction from local to world space.
+func (t *BaseTransform) TransformDirection(direction mgl32.Vec3) mgl32.Vec3 {
+	return t.WorldRotation().Rotate(direction)
+}
+
+// InverseTransformDirection converts a direction from world to local space.
+func (t *BaseTransform) InverseTransformDirection(direction mgl32.Vec3) mgl32.Vec3 {
+	return t.WorldRotation().Inverse().Rotate(direction)
+}
+
+// TransformVector converts a vector from local to world space.
+func (t *BaseTransform) TransformVector(vector mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.TransformNormal(vector, t.ActiveMatrix())
+}
+
+// InverseTransformVector converts a vector from world to local space.
+func (t *BaseTransform) InverseTransformVector(vector mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.TransformNormal(vector, t.ActiveMatrix().Inv())
+}
 
-	if t.GameObject() != nil {
-		if parent := t.GameObject().Parent(); parent != nil {
-			t.activeMatrix = parent.Transform().ActiveMatrix().Mul4(t.modelMatrix)
+// SetParent moves the object of the transform under parent, or to the top
+// of its scene if parent is nil. Objects in a scene can only be moved within
+// it.
+func (t *BaseTransform) SetParent(parent *GameObject, keepWorld bool) error {
+	g := t.GameObject()
+	if g == nil {
+		return fmt.Errorf("transform: %s has no object", t.Name())
+	}
+
+	for p := parent; p != nil; p = p.parent {
+		if p == g {
+			return fmt.Errorf("transform: %s cannot be moved under itself", g.Name())
+		}
+	}
+
+	world := t.ActiveMatrix()
+
+	switch {
+	case g.scene != nil && parent != nil && parent.scene != g.scene:
+		return fmt.Errorf("transform: %s and %s are in different scenes", g.Name(), parent.Name())
+	case g.scene != nil:
+		if parent == nil {
+			parent = g.scene.graph.root
+		}
+		if err := g.scene.MoveObject(g, parent); err != nil {
+			return err
+		}
+	case parent != nil && parent.scene != nil:
+		if err := parent.scene.AddObject(g, parent); err != nil {
+			return err
+		}
+	default:
+		if g.parent != nil {
+			g.parent.RemoveChild(g.ID())
+		}
+		g.parent = parent
+		if parent != nil {
+			parent.AddChild(g)
 		}
+	}
+
+	if keepWorld {
+		local := t.parentMatrix().Inv().Mul4(world)
+		t.position, t.rotation, t.scale = decompose(local)
+	}
+	g.parentChanged()
+
+	return nil
+}
+
+// Recompute marks the transform and its descendants dirty, and notifies
+// their objects. If updateChildren is set, descendants whose local values
+// depend on their parent, such as RectTransform, recompute them.
+func (t *BaseTransform) Recompute(updateChildren bool) {
+	t.localDirty = true
+
+	g := t.GameObject()
+	if g == nil {
+		t.worldDirty = true
+		return
+	}
+
+	t.invalidate(g)
 
-		if updateChildren {
-			childComponents := t.GameObject().ComponentsInChildren()
-			for idx := range childComponents {
-				if child, ok := childComponents[idx].(Transform); ok {
+	if updateChildren {
+		for _, c := range g.ComponentsInChildren() {
+			if child, ok := c.(Transform); ok {
+				if _, lazy := child.(*BaseTransform); !lazy {
 					child.Recompute(false)
 				}
 			}
 		}
+	}
+}
+
+// invalidate marks the world matrices of an object and its descendants
+// dirty and notifies them. Descendants which are already dirty have been
+// notified since they were last computed, and are skipped with their
+// children.
+func (t *BaseTransform) invalidate(g *GameObject) {
+	t.worldDirty = true
+	g.transformChanged()
+
+	for _, child := range g.children {
+		if c, ok := child.Transform().(interface{ base() *BaseTransform }); ok {
+			if b := c.base(); !b.worldDirty {
+				b.invalidate(child)
+			}
+		}
+	}
+}
+
+// base returns the transform, for transforms which embed it.
+func (t *BaseTransform) base() *BaseTransform {
+	return t
+}
 
-		t.GameObject().transformChanged()
+// parentMatrix returns the world matrix of the parent of the object.
+func (t *BaseTransform) parentMatrix() mgl32.Mat4 {
+	if g := t.GameObject(); g != nil && g.parent != nil {
+		return g.parent.Transform().ActiveMatrix()
 	}
+
+	return mgl32.Ident4()
 }
 
 func (t *BaseTransform) OnParentChanged() {
 	t.Recompute(true)
 }
 
+// decompose splits a matrix without shear into position, rotation and
+// scale.
+func decompose(m mgl32.Mat4) (mgl32.Vec3, mgl32.Quat, mgl32.Vec3) {
+	x := m.Col(0).Vec3()
+	y := m.Col(1).Vec3()
+	z := m.Col(2).Vec3()
+
+	s := mgl32.Vec3{x.Len(), y.Len(), z.Len()}
+	if x.Cross(y).Dot(z) < 0 {
+		s[0] = -s[0]
+	}
+
+	var r mgl32.Mat3
+	for i, axis := range [3]mgl32.Vec3{x, y, z} {
+		if s[i] != 0 {
+			axis = axis.Mul(1 / s[i])
+		}
+		r.SetCol(i, axis)
+	}
+
+	return m.Col(3).Vec3(), mgl32.Mat4ToQuat(r.Mat4()).Normalize(), s
+}
+
 func NewTransform() *BaseTransform {
 	t := &BaseTransform{
 		rotation: mgl32.QuatIdent(),
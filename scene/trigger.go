@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+// TriggerEnterListener is implemented by components which are notified when
+// a collider starts to overlap a trigger. The components of both objects are
+// notified, each with the collider of the other.
+type TriggerEnterListener interface {
+	OnTriggerEnter(other Collider)
+}
+
+// TriggerStayListener is implemented by components which are notified on
+// every fixed update while a collider overlaps a trigger.
+type TriggerStayListener interface {
+	OnTriggerStay(other Collider)
+}
+
+// TriggerExitListener is implemented by components which are notified when
+// a collider stops overlapping a trigger, or either is deactivated or
+// destroyed.
+type TriggerExitListener interface {
+	OnTriggerExit(other Collider)
+}
+
+// triggerPair is a trigger overlapped by a solid collider.
+type triggerPair struct {
+	trigger Collider
+	other   Collider
+}
+
+// updateTriggers finds the colliders which overlap triggers, and notifies
+// the components of their objects of the colliders which entered, stayed in
+// or left triggers since the last fixed update.
+func (s *Scene) updateTriggers() {
+	if s.spatial == nil {
+		return
+	}
+
+	var pairs []triggerPair
+
+	s.spatial.Pairs(func(a, b Bounded) {
+		if p, ok := newTriggerPair(a, b); ok && p.trigger.shape().intersects(p.other.shape()) {
+			pairs = append(pairs, p)
+		}
+	})
+
+	previous := make(map[triggerPair]bool, len(s.triggers))
+	for _, p := range s.triggers {
+		previous[p] = true
+	}
+
+	for _, p := range pairs {
+		if previous[p] {
+			delete(previous, p)
+			p.send(func(c Component, other Collider) {
+				if l, ok := c.(TriggerStayListener); ok {
+					l.OnTriggerStay(other)
+				}
+			})
+		} else {
+			p.send(func(c Component, other Collider) {
+				if l, ok := c.(TriggerEnterListener); ok {
+					l.OnTriggerEnter(other)
+				}
+			})
+		}
+	}
+
+	// The pairs left over were not found again, so they are sent in the
+	// order they were entered.
+	for _, p := range s.triggers {
+		if previous[p] {
+			p.send(func(c Component, other Collider) {
+				if l, ok := c.(TriggerExitListener); ok {
+					l.OnTriggerExit(other)
+				}
+			})
+		}
+	}
+
+	s.triggers = pairs
+}
+
+// newTriggerPair returns the pair of a trigger and a solid collider of
+// another object, if a and b are one. Both objects must be active.
+func newTriggerPair(a, b Bounded) (triggerPair, bool) {
+	ca, ok := a.(Collider)
+	if !ok {
+		return triggerPair{}, false
+	}
+	cb, ok := b.(Collider)
+	if !ok || ca.IsTrigger() == cb.IsTrigger() {
+		return triggerPair{}, false
+	}
+
+	ga, gb := ca.GameObject(), cb.GameObject()
+	if ga == nil || gb == nil || ga == gb || !ga.Active() || !gb.Active() {
+		return triggerPair{}, false
+	}
+
+	if cb.IsTrigger() {
+		ca, cb = cb, ca
+	}
+
+	return triggerPair{trigger: ca, other: cb}, true
+}
+
+// send calls fn with the components of the object of the trigger and the
+// solid collider, and the collider of the other object. Objects which were
+// destroyed are skipped.
+func (p triggerPair) send(fn func(c Component, other Collider)) {
+	if g := p.trigger.GameObject(); g != nil {
+		for _, c := range g.Components() {
+			fn(c, p.other)
+		}
+	}
+
+	if g := p.other.GameObject(); g != nil {
+		for _, c := range g.Components() {
+			fn(c, p.trigger)
+		}
+	}
+}
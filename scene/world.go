@@ -0,0 +1,412 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package scene
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// World stores the data components of the objects of a scene in
+// archetypes. An archetype holds the objects which have exactly the same
+// set of data types, with the values of each type stored contiguously, so
+// systems iterate plain slices instead of visiting objects one by one.
+//
+// Data components are plain values attached to objects with AddData and
+// read with GetData. They are a separate API next to the regular components
+// of the objects, which are not stored in the world. They are for state
+// which is updated in bulk, such as velocities or health, while behaviour
+// which belongs to a single object stays in components.
+type World struct {
+	archetypes []*archetype
+	byKey      map[string]*archetype
+	entities   map[int32]entity
+	typeIDs    map[reflect.Type]int
+	columns    map[reflect.Type]func() column
+	systems    []WorldSystem
+	deferred   []func()
+	iterating  int
+}
+
+// WorldSystem updates the data components of a World, once per frame after
+// the Update of the components of the scene.
+type WorldSystem interface {
+	Update(w *World)
+}
+
+// WorldSystemFunc adapts a function to a WorldSystem.
+type WorldSystemFunc func(w *World)
+
+// Update calls f(w).
+func (f WorldSystemFunc) Update(w *World) {
+	f(w)
+}
+
+type entity struct {
+	arch *archetype
+	row  int
+}
+
+type archetype struct {
+	key     string
+	types   []reflect.Type
+	ids     []int32
+	columns map[reflect.Type]column
+}
+
+// column is the storage of the values of a type in an archetype.
+type column interface {
+	// appendFrom appends the value at row of src, a column of the same type.
+	appendFrom(src column, row int)
+
+	// swapRemove removes the value at row, moving the last value into it.
+	swapRemove(row int)
+}
+
+type typedColumn[T any] struct {
+	data []T
+}
+
+func (c *typedColumn[T]) appendFrom(src column, row int) {
+	c.data = append(c.data, src.(*typedColumn[T]).data[row])
+}
+
+func (c *typedColumn[T]) swapRemove(row int) {
+	var zero T
+
+	last := len(c.data) - 1
+	c.data[row] = c.data[last]
+	c.data[last] = zero
+	c.data = c.data[:last]
+}
+
+// NewWorld creates an empty world.
+func NewWorld() *World {
+	return &World{
+		byKey:    make(map[string]*archetype),
+		entities: make(map[int32]entity),
+		typeIDs:  make(map[reflect.Type]int),
+		columns:  make(map[reflect.Type]func() column),
+	}
+}
+
+// AddSystem adds a system, which is run after the systems added before it.
+func (w *World) AddSystem(system WorldSystem) {
+	w.systems = append(w.systems, system)
+}
+
+// Len returns the number of objects with data components.
+func (w *World) Len() int {
+	return len(w.entities)
+}
+
+// Defer runs fn once no query is running. Adding or removing data during a
+// query is deferred the same way.
+func (w *World) Defer(fn func()) {
+	if w.iterating == 0 {
+		fn()
+		return
+	}
+
+	w.deferred = append(w.deferred, fn)
+}
+
+// Update runs the systems of the world.
+func (w *World) Update() {
+	for _, s := range w.systems {
+		s.Update(w)
+	}
+}
+
+// AddData attaches a data component of type T to an object, replacing the
+// value if it has one. The object must be in a loaded scene.
+func AddData[T any](g *GameObject, value T) error {
+	w, err := objectWorld(g)
+	if err != nil {
+		return err
+	}
+
+	id := g.ID()
+	w.Defer(func() {
+		if p := getData[T](w, id); p != nil {
+			*p = value
+			return
+		}
+
+		t := registerType[T](w)
+		e, ok := w.entities[id]
+
+		var types []reflect.Type
+		if ok {
+			types = append(types, e.arch.types...)
+		}
+		types = append(types, t)
+
+		dst := w.archetype(types)
+		w.move(id, e, ok, dst)
+
+		c := dst.columns[t].(*typedColumn[T])
+		c.data = append(c.data, value)
+	})
+
+	return nil
+}
+
+// GetData returns a pointer to the data component of type T of an object,
+// or nil if it has none. The pointer is valid until data is next added to
+// or removed from any object of the world.
+func GetData[T any](g *GameObject) *T {
+	w, err := objectWorld(g)
+	if err != nil {
+		return nil
+	}
+
+	return getData[T](w, g.ID())
+}
+
+// HasData reports if an object has a data component of type T.
+func HasData[T any](g *GameObject) bool {
+	return GetData[T](g) != nil
+}
+
+// RemoveData detaches the data component of type T from an object.
+func RemoveData[T any](g *GameObject) {
+	w, err := objectWorld(g)
+	if err != nil {
+		return
+	}
+
+	id := g.ID()
+	w.Defer(func() {
+		e, ok := w.entities[id]
+		if !ok {
+			return
+		}
+
+		t := reflect.TypeOf((*T)(nil)).Elem()
+		if _, ok := e.arch.columns[t]; !ok {
+			return
+		}
+
+		var types []reflect.Type
+		for _, v := range e.arch.types {
+			if v != t {
+				types = append(types, v)
+			}
+		}
+
+		if len(types) == 0 {
+			w.remove(id)
+			return
+		}
+
+		w.move(id, e, true, w.archetype(types))
+	})
+}
+
+// Query1 calls fn with the objects which have data of type A and their
+// values, one archetype at a time. Values may be changed in place.
+func Query1[A any](w *World, fn func(ids []int32, a []A)) {
+	ta := registerType[A](w)
+
+	w.each([]reflect.Type{ta}, func(arch *archetype) {
+		fn(arch.ids, arch.columns[ta].(*typedColumn[A]).data)
+	})
+}
+
+// Query2 calls fn with the objects which have data of types A and B and
+// their values, one archetype at a time.
+func Query2[A, B any](w *World, fn func(ids []int32, a []A, b []B)) {
+	ta, tb := registerType[A](w), registerType[B](w)
+
+	w.each([]reflect.Type{ta, tb}, func(arch *archetype) {
+		fn(arch.ids,
+			arch.columns[ta].(*typedColumn[A]).data,
+			arch.columns[tb].(*typedColumn[B]).data)
+	})
+}
+
+// Query3 calls fn with the objects which have data of types A, B and C and
+// their values, one archetype at a time.
+func Query3[A, B, C any](w *World, fn func(ids []int32, a []A, b []B, c []C)) {
+	ta, tb, tc := registerType[A](w), registerType[B](w), registerType[C](w)
+
+	w.each([]reflect.Type{ta, tb, tc}, func(arch *archetype) {
+		fn(arch.ids,
+			arch.columns[ta].(*typedColumn[A]).data,
+			arch.columns[tb].(*typedColumn[B]).data,
+			arch.columns[tc].(*typedColumn[C]).data)
+	})
+}
+
+// each calls fn with the non-empty archetypes which have all of types.
+// Structural changes made by fn are deferred until the iteration ends.
+func (w *World) each(types []reflect.Type, fn func(*archetype)) {
+	w.iterating++
+	defer w.endIteration()
+
+	for _, arch := range w.archetypes {
+		if len(arch.ids) == 0 {
+			continue
+		}
+
+		match := true
+		for _, t := range types {
+			if _, ok := arch.columns[t]; !ok {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			fn(arch)
+		}
+	}
+}
+
+func (w *World) endIteration() {
+	w.iterating--
+	if w.iterating != 0 {
+		return
+	}
+
+	for len(w.deferred) != 0 {
+		fn := w.deferred[0]
+		w.deferred = w.deferred[1:]
+		fn()
+	}
+	w.deferred = nil
+}
+
+// archetype returns the archetype of a set of types, creating it if needed.
+func (w *World) archetype(types []reflect.Type) *archetype {
+	sort.Slice(types, func(i, j int) bool {
+		return w.typeIDs[types[i]] < w.typeIDs[types[j]]
+	})
+
+	ids := make([]string, len(types))
+	for i, t := range types {
+		ids[i] = strconv.Itoa(w.typeIDs[t])
+	}
+	key := strings.Join(ids, ",")
+
+	if arch, ok := w.byKey[key]; ok {
+		return arch
+	}
+
+	arch := &archetype{
+		key:     key,
+		types:   types,
+		columns: make(map[reflect.Type]column, len(types)),
+	}
+	for _, t := range types {
+		arch.columns[t] = w.columns[t]()
+	}
+
+	w.archetypes = append(w.archetypes, arch)
+	w.byKey[key] = arch
+
+	return arch
+}
+
+// move moves the values of an object to another archetype. Types which the
+// destination has and the source lacks are left for the caller to append.
+func (w *World) move(id int32, e entity, ok bool, dst *archetype) {
+	if ok {
+		for t, c := range e.arch.columns {
+			if d, has := dst.columns[t]; has {
+				d.appendFrom(c, e.row)
+			}
+		}
+		w.removeRow(e)
+	}
+
+	dst.ids = append(dst.ids, id)
+	w.entities[id] = entity{arch: dst, row: len(dst.ids) - 1}
+}
+
+// remove removes every data component of an object.
+func (w *World) remove(id int32) {
+	if e, ok := w.entities[id]; ok {
+		w.removeRow(e)
+		delete(w.entities, id)
+	}
+}
+
+// removeRow removes a row of an archetype, updating the object whose row
+// is moved into its place.
+func (w *World) removeRow(e entity) {
+	arch := e.arch
+	last := len(arch.ids) - 1
+
+	for _, c := range arch.columns {
+		c.swapRemove(e.row)
+	}
+
+	if e.row != last {
+		moved := arch.ids[last]
+		arch.ids[e.row] = moved
+		w.entities[moved] = entity{arch: arch, row: e.row}
+	}
+	arch.ids = arch.ids[:last]
+}
+
+// registerType returns the type of T, registering its column constructor
+// the first time it is used.
+func registerType[T any](w *World) reflect.Type {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	if _, ok := w.typeIDs[t]; !ok {
+		w.typeIDs[t] = len(w.typeIDs)
+		w.columns[t] = func() column {
+			return &typedColumn[T]{}
+		}
+	}
+
+	return t
+}
+
+func getData[T any](w *World, id int32) *T {
+	e, ok := w.entities[id]
+	if !ok {
+		return nil
+	}
+
+	c, ok := e.arch.columns[reflect.TypeOf((*T)(nil)).Elem()]
+	if !ok {
+		return nil
+	}
+
+	return &c.(*typedColumn[T]).data[e.row]
+}
+
+// objectWorld returns the world of the scene of an object.
+func objectWorld(g *GameObject) (*World, error) {
+	if g == nil || g.scene == nil || g.scene.world == nil {
+		return nil, fmt.Errorf("scene: object is not in a loaded scene")
+	}
+
+	return g.scene.world, nil
+}
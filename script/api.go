@@ -0,0 +1,403 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package script
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/input"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+const (
+	typeGameObject = "gameobject"
+	typeTransform  = "transform"
+)
+
+// keyNames maps the names of common keys, as exposed to scripts in the keys
+// table, to their key codes.
+var keyNames = map[string]glfw.Key{
+	"space":  glfw.KeySpace,
+	"escape": glfw.KeyEscape,
+	"enter":  glfw.KeyEnter,
+	"tab":    glfw.KeyTab,
+	"left":   glfw.KeyLeft,
+	"right":  glfw.KeyRight,
+	"up":     glfw.KeyUp,
+	"down":   glfw.KeyDown,
+	"shift":  glfw.KeyLeftShift,
+	"ctrl":   glfw.KeyLeftControl,
+	"alt":    glfw.KeyLeftAlt,
+}
+
+var gameObjectMethods = map[string]lua.LGFunction{
+	"name":       gameObjectName,
+	"active":     gameObjectActive,
+	"set_active": gameObjectSetActive,
+	"transform":  gameObjectTransform,
+	"parent":     gameObjectParent,
+	"send":       gameObjectSend,
+}
+
+var transformMethods = map[string]lua.LGFunction{
+	"position":     transformPosition,
+	"set_position": transformSetPosition,
+	"translate":    transformTranslate,
+	"rotation":     transformRotation,
+	"set_rotation": transformSetRotation,
+	"rotate":       transformRotate,
+	"scale":        transformScale,
+	"set_scale":    transformSetScale,
+}
+
+// registerAPI exposes the engine API to the state.
+func registerAPI(L *lua.LState, s *Script) {
+	mt := L.NewTypeMetatable(typeGameObject)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), gameObjectMethods))
+
+	mt = L.NewTypeMetatable(typeTransform)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), transformMethods))
+
+	L.SetGlobal("input", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"key_down":       inputKeyDown,
+		"key_up":         inputKeyUp,
+		"mouse_down":     inputMouseDown,
+		"mouse_up":       inputMouseUp,
+		"mouse_position": inputMousePosition,
+		"mouse_wheel":    inputMouseWheel,
+	}))
+
+	keys := L.NewTable()
+	for name, key := range keyNames {
+		L.SetField(keys, name, lua.LNumber(key))
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		L.SetField(keys, string(c), lua.LNumber(glfw.KeyA+glfw.Key(c-'a')))
+	}
+	for c := '0'; c <= '9'; c++ {
+		L.SetField(keys, string(c), lua.LNumber(glfw.Key0+glfw.Key(c-'0')))
+	}
+	L.SetGlobal("keys", keys)
+
+	L.SetGlobal("time", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"delta":        timeDelta,
+		"scaled_delta": timeScaledDelta,
+		"now":          timeNow,
+		"frame":        timeFrame,
+	}))
+
+	L.SetGlobal("log", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"debug": logFunc(s, logrus.DebugLevel),
+		"info":  logFunc(s, logrus.InfoLevel),
+		"warn":  logFunc(s, logrus.WarnLevel),
+		"error": logFunc(s, logrus.ErrorLevel),
+	}))
+}
+
+func newGameObject(L *lua.LState, g *scene.GameObject) lua.LValue {
+	if g == nil {
+		return lua.LNil
+	}
+
+	ud := L.NewUserData()
+	ud.Value = g
+	L.SetMetatable(ud, L.GetTypeMetatable(typeGameObject))
+
+	return ud
+}
+
+func newTransform(L *lua.LState, t scene.Transform) lua.LValue {
+	if t == nil {
+		return lua.LNil
+	}
+
+	ud := L.NewUserData()
+	ud.Value = t
+	L.SetMetatable(ud, L.GetTypeMetatable(typeTransform))
+
+	return ud
+}
+
+func checkGameObject(L *lua.LState) *scene.GameObject {
+	ud := L.CheckUserData(1)
+	if g, ok := ud.Value.(*scene.GameObject); ok {
+		return g
+	}
+
+	L.ArgError(1, "gameobject expected")
+
+	return nil
+}
+
+func checkTransform(L *lua.LState) scene.Transform {
+	ud := L.CheckUserData(1)
+	if t, ok := ud.Value.(scene.Transform); ok {
+		return t
+	}
+
+	L.ArgError(1, "transform expected")
+
+	return nil
+}
+
+func checkVec3(L *lua.LState, n int) mgl32.Vec3 {
+	return mgl32.Vec3{
+		float32(L.CheckNumber(n)),
+		float32(L.CheckNumber(n + 1)),
+		float32(L.CheckNumber(n + 2)),
+	}
+}
+
+func pushVec3(L *lua.LState, v mgl32.Vec3) int {
+	L.Push(lua.LNumber(v.X()))
+	L.Push(lua.LNumber(v.Y()))
+	L.Push(lua.LNumber(v.Z()))
+
+	return 3
+}
+
+// toLValue converts basic Go values to Lua values.
+func toLValue(L *lua.LState, v interface{}) lua.LValue {
+	switch v := v.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(v)
+	case string:
+		return lua.LString(v)
+	case int:
+		return lua.LNumber(v)
+	case int32:
+		return lua.LNumber(v)
+	case float32:
+		return lua.LNumber(v)
+	case float64:
+		return lua.LNumber(v)
+	case *scene.GameObject:
+		return newGameObject(L, v)
+	case scene.Transform:
+		return newTransform(L, v)
+	case lua.LValue:
+		return v
+	}
+
+	return lua.LNil
+}
+
+// fromLValue converts basic Lua values to Go values.
+func fromLValue(v lua.LValue) interface{} {
+	switch v := v.(type) {
+	case lua.LBool:
+		return bool(v)
+	case lua.LString:
+		return string(v)
+	case lua.LNumber:
+		return float64(v)
+	case *lua.LUserData:
+		return v.Value
+	}
+
+	return nil
+}
+
+/* GameObject */
+
+func gameObjectName(L *lua.LState) int {
+	L.Push(lua.LString(checkGameObject(L).Name()))
+	return 1
+}
+
+func gameObjectActive(L *lua.LState) int {
+	L.Push(lua.LBool(checkGameObject(L).Active()))
+	return 1
+}
+
+func gameObjectSetActive(L *lua.LState) int {
+	checkGameObject(L).SetActive(L.CheckBool(2))
+	return 0
+}
+
+func gameObjectTransform(L *lua.LState) int {
+	L.Push(newTransform(L, checkGameObject(L).Transform()))
+	return 1
+}
+
+func gameObjectParent(L *lua.LState) int {
+	L.Push(newGameObject(L, checkGameObject(L).Parent()))
+	return 1
+}
+
+// gameObjectSend sends an event to all scripts attached to the object.
+func gameObjectSend(L *lua.LState) int {
+	g := checkGameObject(L)
+	event := L.CheckString(2)
+
+	var args []interface{}
+	for i := 3; i <= L.GetTop(); i++ {
+		args = append(args, fromLValue(L.Get(i)))
+	}
+
+	for _, c := range g.Components() {
+		if s, ok := c.(*Script); ok {
+			s.Send(event, args...)
+		}
+	}
+
+	return 0
+}
+
+/* Transform */
+
+func transformPosition(L *lua.LState) int {
+	return pushVec3(L, checkTransform(L).Position())
+}
+
+func transformSetPosition(L *lua.LState) int {
+	checkTransform(L).SetPosition(checkVec3(L, 2))
+	return 0
+}
+
+func transformTranslate(L *lua.LState) int {
+	t := checkTransform(L)
+	t.SetPosition(t.Position().Add(checkVec3(L, 2)))
+	return 0
+}
+
+// transformRotation returns the rotation as a quaternion in w, x, y, z order.
+func transformRotation(L *lua.LState) int {
+	q := checkTransform(L).Rotation()
+
+	L.Push(lua.LNumber(q.W))
+	pushVec3(L, q.V)
+
+	return 4
+}
+
+func transformSetRotation(L *lua.LState) int {
+	w := float32(L.CheckNumber(2))
+	checkTransform(L).SetRotation(mgl32.Quat{W: w, V: checkVec3(L, 3)}.Normalize())
+	return 0
+}
+
+// transformRotate rotates the transform by an angle in degrees about an axis.
+func transformRotate(L *lua.LState) int {
+	t := checkTransform(L)
+	angle := mgl32.DegToRad(float32(L.CheckNumber(2)))
+	axis := checkVec3(L, 3)
+
+	if axis.Len() == 0 {
+		L.ArgError(3, "axis must not be zero")
+		return 0
+	}
+
+	t.SetRotation(t.Rotation().Mul(mgl32.QuatRotate(angle, axis.Normalize())).Normalize())
+	return 0
+}
+
+func transformScale(L *lua.LState) int {
+	return pushVec3(L, checkTransform(L).Scale())
+}
+
+func transformSetScale(L *lua.LState) int {
+	checkTransform(L).SetScale(checkVec3(L, 2))
+	return 0
+}
+
+/* Input */
+
+func inputKeyDown(L *lua.LState) int {
+	L.Push(lua.LBool(input.KeyDown(glfw.Key(L.CheckInt(1)))))
+	return 1
+}
+
+func inputKeyUp(L *lua.LState) int {
+	L.Push(lua.LBool(input.KeyUp(glfw.Key(L.CheckInt(1)))))
+	return 1
+}
+
+func inputMouseDown(L *lua.LState) int {
+	L.Push(lua.LBool(input.MouseDown(glfw.MouseButton(L.CheckInt(1)))))
+	return 1
+}
+
+func inputMouseUp(L *lua.LState) int {
+	L.Push(lua.LBool(input.MouseUp(glfw.MouseButton(L.CheckInt(1)))))
+	return 1
+}
+
+func inputMousePosition(L *lua.LState) int {
+	p := input.MousePosition()
+
+	L.Push(lua.LNumber(p.X()))
+	L.Push(lua.LNumber(p.Y()))
+
+	return 2
+}
+
+func inputMouseWheel(L *lua.LState) int {
+	L.Push(lua.LNumber(input.MouseWheelX()))
+	L.Push(lua.LNumber(input.MouseWheelY()))
+
+	return 2
+}
+
+/* Time */
+
+func timeDelta(L *lua.LState) int {
+	L.Push(lua.LNumber(time.Delta()))
+	return 1
+}
+
+func timeScaledDelta(L *lua.LState) int {
+	L.Push(lua.LNumber(time.ScaledDelta()))
+	return 1
+}
+
+func timeNow(L *lua.LState) int {
+	L.Push(lua.LNumber(time.Now()))
+	return 1
+}
+
+func timeFrame(L *lua.LState) int {
+	L.Push(lua.LNumber(time.Frame()))
+	return 1
+}
+
+/* Log */
+
+func logFunc(s *Script, level logrus.Level) lua.LGFunction {
+	return func(L *lua.LState) int {
+		var args []interface{}
+		for i := 1; i <= L.GetTop(); i++ {
+			args = append(args, L.ToStringMeta(L.Get(i)).String())
+		}
+
+		logrus.WithField("script", s.displayName()).Log(level, args...)
+
+		return 0
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package script
+
+import (
+	"fmt"
+	"os"
+	gotime "time"
+
+	"github.com/sirupsen/logrus"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/instance"
+	"github.com/haakenlabs/arc/system/time"
+)
+
+var _ scene.ScriptComponent = &Script{}
+var _ scene.AnimationEventReceiver = &Script{}
+
+// reloadInterval is how often, in seconds, script files are checked for
+// changes.
+const reloadInterval = 1.0
+
+// Names of the functions a script may define. Each is called with the self
+// table of the script as its first argument.
+const (
+	hookStart          = "start"
+	hookUpdate         = "update"
+	hookLateUpdate     = "late_update"
+	hookFixedUpdate    = "fixed_update"
+	hookEvent          = "on_event"
+	hookAnimationEvent = "on_animation_event"
+	hookReload         = "on_reload"
+)
+
+// Script is a component which runs a Lua script. The script is reloaded when
+// its file changes on disk.
+type Script struct {
+	scene.BaseScriptComponent
+
+	path      string
+	source    string
+	state     *lua.LState
+	self      *lua.LTable
+	modTime   gotime.Time
+	nextCheck float64
+	started   bool
+}
+
+// NewScript creates a new script component which runs the Lua file at path.
+func NewScript(path string) *Script {
+	s := &Script{
+		path: path,
+	}
+
+	s.SetName("Script")
+	instance.MustAssign(s)
+
+	return s
+}
+
+// NewScriptString creates a new script component from Lua source. Scripts
+// created from source are not hot reloaded.
+func NewScriptString(source string) *Script {
+	s := &Script{
+		source: source,
+	}
+
+	s.SetName("Script")
+	instance.MustAssign(s)
+
+	return s
+}
+
+// Path returns the path of the script file, if any.
+func (s *Script) Path() string {
+	return s.path
+}
+
+// Awake loads the script.
+func (s *Script) Awake() {
+	if s.state != nil {
+		return
+	}
+
+	if err := s.load(); err != nil {
+		logrus.Error("[Script] ", err)
+	}
+}
+
+// Start loads the script if needed, then calls its start function.
+func (s *Script) Start() {
+	s.Awake()
+
+	s.started = true
+	s.call(hookStart)
+}
+
+// Update reloads the script if it has changed, then calls its update
+// function.
+func (s *Script) Update() {
+	s.checkReload()
+	s.call(hookUpdate, lua.LNumber(time.ScaledDelta()))
+}
+
+// LateUpdate calls the late_update function of the script.
+func (s *Script) LateUpdate() {
+	s.call(hookLateUpdate, lua.LNumber(time.ScaledDelta()))
+}
+
+// FixedUpdate calls the fixed_update function of the script.
+func (s *Script) FixedUpdate() {
+	s.call(hookFixedUpdate, lua.LNumber(time.FixedTime()))
+}
+
+// OnAnimationEvent forwards animation events to the script.
+func (s *Script) OnAnimationEvent(clip *scene.AnimationClip, event scene.AnimationEvent) {
+	s.call(hookAnimationEvent, lua.LString(event.Name), lua.LString(event.Value))
+}
+
+// Send calls the on_event function of the script with the event name and
+// arguments.
+func (s *Script) Send(event string, args ...interface{}) {
+	if s.state == nil {
+		return
+	}
+
+	values := []lua.LValue{lua.LString(event)}
+	for _, v := range args {
+		values = append(values, toLValue(s.state, v))
+	}
+
+	s.call(hookEvent, values...)
+}
+
+// Reload discards the state of the script and loads it again. The on_reload
+// function is called if the script defines one, otherwise start is called
+// again if the script had already started.
+func (s *Script) Reload() error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if s.hook(hookReload) != nil {
+		s.call(hookReload)
+	} else if s.started {
+		s.call(hookStart)
+	}
+
+	return nil
+}
+
+// Dealloc closes the Lua state of the script.
+func (s *Script) Dealloc() {
+	s.close()
+}
+
+func (s *Script) load() error {
+	L := lua.NewState()
+	registerAPI(L, s)
+
+	var err error
+	if s.path != "" {
+		var info os.FileInfo
+		if info, err = os.Stat(s.path); err == nil {
+			s.modTime = info.ModTime()
+			err = L.DoFile(s.path)
+		}
+	} else {
+		err = L.DoString(s.source)
+	}
+
+	if err != nil {
+		L.Close()
+		return fmt.Errorf("script: %s: %v", s.displayName(), err)
+	}
+
+	s.close()
+	s.state = L
+	s.self = L.NewTable()
+	L.SetField(s.self, "gameobject", newGameObject(L, s.GameObject()))
+
+	return nil
+}
+
+func (s *Script) close() {
+	if s.state != nil {
+		s.state.Close()
+		s.state = nil
+	}
+}
+
+func (s *Script) checkReload() {
+	if s.path == "" {
+		return
+	}
+
+	now := time.Now()
+	if now < s.nextCheck {
+		return
+	}
+	s.nextCheck = now + reloadInterval
+
+	info, err := os.Stat(s.path)
+	if err != nil || !info.ModTime().After(s.modTime) {
+		return
+	}
+
+	logrus.Debug("[Script] Reloading ", s.path)
+
+	if err := s.Reload(); err != nil {
+		// Keep running the previous version of the script.
+		s.modTime = info.ModTime()
+		logrus.Error("[Script] ", err)
+	}
+}
+
+func (s *Script) hook(name string) *lua.LFunction {
+	if s.state == nil {
+		return nil
+	}
+
+	fn, _ := s.state.GetGlobal(name).(*lua.LFunction)
+
+	return fn
+}
+
+func (s *Script) call(name string, args ...lua.LValue) {
+	fn := s.hook(name)
+	if fn == nil {
+		return
+	}
+
+	p := lua.P{
+		Fn:      fn,
+		NRet:    0,
+		Protect: true,
+	}
+
+	if err := s.state.CallByParam(p, append([]lua.LValue{s.self}, args...)...); err != nil {
+		logrus.Errorf("[Script] %s: %s: %v", s.displayName(), name, err)
+	}
+}
+
+func (s *Script) displayName() string {
+	if s.path != "" {
+		return s.path
+	}
+
+	return s.Name()
+}
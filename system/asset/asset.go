@@ -1,6 +1,9 @@
 package asset
 
 import (
+	"io/fs"
+	"os"
+
 	"github.com/haakenlabs/arc/core"
 )
 
@@ -14,16 +17,101 @@ func GetHandler(name string) (core.AssetHandler, error) {
 	return core.GetAssetSystem().GetHandler(name)
 }
 
-// Get gets an asset by name from a handler by kind.
-func Get(kind, name string) (core.Object, error) {
+// Get gets an asset of type T by name from any handler, such as
+// Get[*graphics.Texture2D]("rock.png"). Assets of other kinds which share the
+// name are skipped.
+func Get[T core.Object](name string) (T, error) {
+	var zero T
+	var found bool
+
+	a := core.GetAssetSystem()
+	for _, kind := range a.Kinds() {
+		obj, err := a.GetAsset(kind, name)
+		if err != nil {
+			continue
+		}
+		if t, ok := obj.(T); ok {
+			return t, nil
+		}
+		found = true
+	}
+
+	if found {
+		return zero, core.ErrAssetType(name)
+	}
+
+	return zero, core.ErrAssetNotFound(name)
+}
+
+// MustGet is like Get, but panics if an error is encountered.
+func MustGet[T core.Object](name string) T {
+	t, err := Get[T](name)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// GetAsset gets an asset by name from a handler by kind.
+func GetAsset(kind, name string) (core.Object, error) {
 	return core.GetAssetSystem().Get(kind, name)
 }
 
-// // MustGet is like Get, but panics if an error is encountered.
-func MustGet(kind, name string) core.Object {
+// MustGetAsset is like GetAsset, but panics if an error is encountered.
+func MustGetAsset(kind, name string) core.Object {
 	return core.GetAssetSystem().MustGet(kind, name)
 }
 
+// Acquire gets an asset and adds a reference to it, which keeps it loaded
+// until it is released.
+func Acquire(kind, name string) (core.Object, error) {
+	return core.GetAssetSystem().Acquire(kind, name)
+}
+
+// MustAcquire is like Acquire, but panics if an error is encountered.
+func MustAcquire(kind, name string) core.Object {
+	return core.GetAssetSystem().MustAcquire(kind, name)
+}
+
+// Release removes a reference added by Acquire.
+func Release(kind, name string) {
+	core.GetAssetSystem().Release(kind, name)
+}
+
+// Unload unloads an asset and releases its GPU resources. It fails if the
+// asset is still acquired.
+func Unload(kind, name string) error {
+	return core.GetAssetSystem().Unload(kind, name)
+}
+
+// UnloadUnused unloads every acquired asset which has been released by all of
+// its users. It is called automatically when the active scene is replaced.
+func UnloadUnused() int {
+	return core.GetAssetSystem().UnloadUnused()
+}
+
+// Kinds returns the names of the registered asset handlers.
+func Kinds() []string {
+	return core.GetAssetSystem().Kinds()
+}
+
+// List returns the names of the loaded assets of a kind.
+func List(kind string) ([]string, error) {
+	return core.GetAssetSystem().List(kind)
+}
+
+// Exists reports if an asset of a kind is loaded.
+func Exists(kind, name string) bool {
+	return core.GetAssetSystem().Exists(kind, name)
+}
+
+// Info describes an asset: where it was loaded from, its size and its load
+// state.
+func Info(kind, name string) (core.AssetInfo, error) {
+	return core.GetAssetSystem().Info(kind, name)
+}
+
 func MountPackage(name string) error {
 	return core.GetAssetSystem().MountPackage(name)
 }
@@ -38,11 +126,73 @@ func UnmountAllPackages() {
 	core.GetAssetSystem().UnmountAllPackages()
 }
 
+// Mount overlays a filesystem on the resources below prefix, which is a
+// package name, "<builtin>", a directory within either ("base:textures"), a
+// directory of plain files, or empty for all plain files. Newer mounts
+// override older ones, which override packages, builtin assets and files.
+func Mount(prefix string, fsys fs.FS) error {
+	return core.GetAssetSystem().Mount(prefix, fsys)
+}
+
+// MountDir overlays a directory on disk on the resources below prefix.
+func MountDir(prefix, dir string) error {
+	return Mount(prefix, os.DirFS(dir))
+}
+
+// MountZip overlays a zip archive on the resources below prefix.
+func MountZip(prefix, name string) error {
+	fsys, err := core.OpenZipFS(name)
+	if err != nil {
+		return err
+	}
+
+	return Mount(prefix, fsys)
+}
+
+// MountBuiltin overlays the builtin assets on the resources below prefix.
+func MountBuiltin(prefix string) error {
+	return Mount(prefix, core.BuiltinFS())
+}
+
+// Unmount removes all filesystems mounted at prefix.
+func Unmount(prefix string) error {
+	return core.GetAssetSystem().Unmount(prefix)
+}
+
 // LoadManifest loads a manifest of assets.
 func LoadManifest(files ...string) error {
 	return core.GetAssetSystem().LoadManifest(files...)
 }
 
+// LoadGroup loads a named group of assets of a manifest, such as the assets
+// of a level, along with everything they depend on.
+func LoadGroup(file, group string) error {
+	return core.GetAssetSystem().LoadGroup(file, group)
+}
+
+// LoadGroupAsync is like LoadGroup, but loads the group without blocking.
+func LoadGroupAsync(file, group string) *core.AssetLoad {
+	return core.GetAssetSystem().LoadGroupAsync(file, group)
+}
+
+// LoadManifestAsync loads a manifest of assets without blocking. Assets are
+// allocated a few per frame, so the returned load can drive a loading screen.
+func LoadManifestAsync(files ...string) *core.AssetLoad {
+	return core.GetAssetSystem().LoadManifestAsync(files...)
+}
+
+// SetHotReload enables or disables reloading assets when their files change
+// on disk. Builtin assets are only watched if dir is set to the directory
+// holding the builtin assets.
+func SetHotReload(enable bool, dir string) {
+	core.GetAssetSystem().SetHotReload(enable, dir)
+}
+
+// OnReload adds a callback invoked when an asset is reloaded.
+func OnReload(fn func(core.AssetReloadEvent)) {
+	core.GetAssetSystem().OnReload(fn)
+}
+
 func ReadResource(r *core.Resource) error {
 	return core.GetAssetSystem().ReadResource(r)
 }
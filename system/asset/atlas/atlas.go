@@ -0,0 +1,288 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"path"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/texture"
+)
+
+const AssetNameAtlas = "atlas"
+
+var _ core.AssetHandler = &Handler{}
+
+// Metadata describes a texture atlas. Sprites are regions of the texture in
+// pixels from its top left corner. A grid adds a sprite for each cell of a
+// uniform sheet, named by the prefix and the index of the cell, counting
+// rows from the top.
+//
+//	{
+//	    "name": "icons",
+//	    "texture": "icons.png",
+//	    "filter": "nearest",
+//	    "sprites": {
+//	        "play": {"x": 0, "y": 0, "w": 32, "h": 32}
+//	    },
+//	    "grid": {"prefix": "walk_", "x": 0, "y": 32, "w": 16, "h": 16, "count": 8}
+//	}
+type Metadata struct {
+	Name    string                  `json:"name"`
+	Texture string                  `json:"texture"`
+	Filter  string                  `json:"filter"`
+	Sprites map[string]SpriteRegion `json:"sprites"`
+	Grid    *Grid                   `json:"grid"`
+}
+
+// SpriteRegion is the region of a sprite in pixels.
+type SpriteRegion struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Grid is a sheet of equally sized sprites. The sheet starts at X, Y and
+// fills rows from left to right. Count is the number of cells; if it is zero,
+// every cell fitting in the texture is used.
+type Grid struct {
+	Prefix string `json:"prefix"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	W      int    `json:"w"`
+	H      int    `json:"h"`
+	Count  int    `json:"count"`
+}
+
+// Handler loads texture atlases from JSON files describing regions of a
+// texture.
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	m := &Metadata{}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return err
+	}
+
+	if m.Name == "" {
+		m.Name = r.Base()
+	}
+
+	if _, dup := h.Items[m.Name]; dup {
+		return core.ErrAssetExists(m.Name)
+	}
+
+	if g := m.Grid; g != nil && (g.W <= 0 || g.H <= 0) {
+		return fmt.Errorf("atlas: %s: invalid grid cell size", m.Name)
+	}
+
+	tex, err := loadTexture(path.Join(r.DirPrefix(), m.Texture), m.Filter)
+	if err != nil {
+		return err
+	}
+
+	a := graphics.NewTextureAtlas(tex)
+	a.SetName(m.Name)
+
+	for name, s := range m.Sprites {
+		a.AddSprite(name, region(s.X, s.Y, s.W, s.H))
+	}
+
+	if m.Grid != nil {
+		addGrid(a, m.Grid)
+	}
+
+	return h.Add(m.Name, a)
+}
+
+func (h *Handler) Add(name string, atlas *graphics.TextureAtlas) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	h.Items[name] = atlas.ID()
+
+	return nil
+}
+
+// Get gets an asset by name.
+func (h *Handler) Get(name string) (*graphics.TextureAtlas, error) {
+	a, err := h.GetAsset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*graphics.TextureAtlas)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+// MustGet is like GetAsset, but panics if an error occurs.
+func (h *Handler) MustGet(name string) *graphics.TextureAtlas {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+// Sprite gets a sprite of an atlas.
+func (h *Handler) Sprite(atlas, sprite string) (*graphics.Sprite, error) {
+	a, err := h.Get(atlas)
+	if err != nil {
+		return nil, err
+	}
+
+	s := a.Sprite(sprite)
+	if s == nil {
+		return nil, core.ErrAssetNotFound(atlas + "/" + sprite)
+	}
+
+	return s, nil
+}
+
+func (h *Handler) Name() string {
+	return AssetNameAtlas
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+// Get gets an atlas by name.
+func Get(name string) (*graphics.TextureAtlas, error) {
+	return mustHandler().Get(name)
+}
+
+// MustGet is like Get, but panics if an error occurs.
+func MustGet(name string) *graphics.TextureAtlas {
+	return mustHandler().MustGet(name)
+}
+
+// Sprite gets a sprite of an atlas by the names of both.
+func Sprite(atlas, sprite string) (*graphics.Sprite, error) {
+	return mustHandler().Sprite(atlas, sprite)
+}
+
+// MustSprite is like Sprite, but panics if an error occurs.
+func MustSprite(atlas, sprite string) *graphics.Sprite {
+	s, err := Sprite(atlas, sprite)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameAtlas)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
+
+// loadTexture loads the texture of an atlas. The texture belongs to the atlas
+// rather than the texture handler, and is not mipmapped, so neighbouring
+// sprites do not bleed into each other.
+func loadTexture(location, filter string) (*graphics.Texture2D, error) {
+	r, err := core.NewResource(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r.Reader())
+	if err != nil {
+		return nil, err
+	}
+
+	tex, err := texture.NewTexture(img)
+	if err != nil {
+		return nil, err
+	}
+
+	tex.SetName(r.Base())
+	if err := tex.Alloc(); err != nil {
+		return nil, err
+	}
+
+	tex.SetWrapMode(graphics.WrapClamp)
+	switch filter {
+	case "", "linear":
+		tex.SetFilterMode(graphics.FilterBilinear)
+	case "nearest":
+		tex.SetFilterMode(graphics.FilterNearest)
+	default:
+		return nil, fmt.Errorf("atlas: unknown filter: %s", filter)
+	}
+
+	return tex, nil
+}
+
+// addGrid adds the sprites of a grid to an atlas.
+func addGrid(a *graphics.TextureAtlas, g *Grid) {
+	size := a.Texture().Size()
+	columns := (int(size.X()) - g.X) / g.W
+	rows := (int(size.Y()) - g.Y) / g.H
+
+	count := g.Count
+	if count == 0 || count > columns*rows {
+		count = columns * rows
+	}
+
+	for i := 0; i < count; i++ {
+		x := g.X + (i%columns)*g.W
+		y := g.Y + (i/columns)*g.H
+
+		a.AddSprite(fmt.Sprintf("%s%d", g.Prefix, i), region(x, y, g.W, g.H))
+	}
+}
+
+// region returns a rect in pixels.
+func region(x, y, w, h int) core.Rect {
+	return core.NewRect(mgl32.Vec2{float32(x), float32(y)}, mgl32.Vec2{float32(w), float32(h)})
+}
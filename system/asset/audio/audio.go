@@ -23,56 +23,147 @@ SOFTWARE.
 package audio
 
 import (
+	"bytes"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/faiface/beep"
 	"github.com/faiface/beep/flac"
 	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
 	"github.com/faiface/beep/wav"
 
-	"fmt"
-
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/system/asset"
 )
 
 const AssetNameAudio = "audio"
 
+// DefaultStreamSize is the default encoded size in bytes above which sounds
+// are streamed rather than decoded when loaded.
+const DefaultStreamSize = 1 << 20
+
 var _ core.AssetHandler = &Handler{}
+var _ core.AsyncAssetHandler = &Handler{}
 
+// Handler loads sounds from WAV, Ogg Vorbis, MP3 and FLAC files. Sounds are
+// decoded to PCM when loaded, so they can be played any number of times
+// without decoding again. Files larger than the stream size, such as music
+// tracks, are instead kept encoded in memory and decoded as they play.
 type Handler struct {
 	core.BaseAssetHandler
+
+	streamSize int
 }
 
-func (h *Handler) Load(r *core.Resource) error {
-	var streamer beep.Streamer
-	var format beep.Format
-	var err error
+// stream is a sound decoded by Decode which is streamed when played.
+type stream struct {
+	streamer beep.StreamSeekCloser
+	format   beep.Format
+}
+
+// memFile is an in-memory file that streamed sounds are decoded from.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
 
+// SetStreamSize sets the encoded size in bytes above which sounds are
+// streamed. A size of zero or less disables streaming.
+func (h *Handler) SetStreamSize(size int) {
+	h.streamSize = size
+}
+
+// StreamSize returns the encoded size in bytes above which sounds are
+// streamed.
+func (h *Handler) StreamSize() int {
+	return h.streamSize
+}
+
+func (h *Handler) Load(r *core.Resource) error {
 	name := r.Base()
-	ext := filepath.Ext(name)
 
 	if _, dup := h.Items[name]; dup {
 		return core.ErrAssetExists(name)
 	}
 
+	data, err := h.Decode(r)
+	if err != nil {
+		return err
+	}
+
+	return h.Upload(r, data)
+}
+
+// Decode decodes a sound into a PCM buffer, or opens a stream over it if it
+// is larger than the stream size. It is safe to call from any goroutine.
+func (h *Handler) Decode(r *core.Resource) (interface{}, error) {
+	var streamer beep.StreamSeekCloser
+	var format beep.Format
+	var err error
+
+	// Decoders can only seek an io.Seeker, which streamed sounds need to
+	// restart and loop. A streamed sound keeps its own copy of the encoded
+	// data, as the resource may be reused.
+	data := r.Bytes()
+	streamed := h.streamSize > 0 && len(data) > h.streamSize
+	if streamed {
+		data = append([]byte(nil), data...)
+	}
+	f := memFile{bytes.NewReader(data)}
+
+	ext := strings.ToLower(filepath.Ext(r.Base()))
+
 	switch ext {
-	case "mp3":
-		streamer, format, err = mp3.Decode(r.ReadCloser())
-	case "wav":
-		streamer, format, err = wav.Decode(r.ReadCloser())
-	case "flac":
-		streamer, format, err = flac.Decode(r.ReadCloser())
+	case ".wav":
+		streamer, format, err = wav.Decode(f)
+	case ".ogg":
+		streamer, format, err = vorbis.Decode(f)
+	case ".mp3":
+		streamer, format, err = mp3.Decode(f)
+	case ".flac":
+		streamer, format, err = flac.Decode(f)
 	default:
-		return fmt.Errorf("unknown audio type: %s", ext)
+		return nil, fmt.Errorf("unknown audio type: %s", ext)
 	}
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if streamed {
+		return &stream{streamer: streamer, format: format}, nil
+	}
+	defer streamer.Close()
+
+	buffer := beep.NewBuffer(format)
+	buffer.Append(streamer)
+
+	if err := streamer.Err(); err != nil {
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+// Upload adds a sound decoded by Decode.
+func (h *Handler) Upload(r *core.Resource, data interface{}) error {
+	name := r.Base()
+
+	var s *core.Sound
+
+	switch data := data.(type) {
+	case *beep.Buffer:
+		s = core.NewSoundBuffer(data)
+	case *stream:
+		s = core.NewSoundStream(data.streamer, data.format)
+	default:
+		return core.ErrAssetType(name)
 	}
 
-	s := core.NewSound(streamer, format)
 	s.SetName(name)
 
 	return h.Add(name, s)
@@ -120,7 +211,9 @@ func (h *Handler) Name() string {
 }
 
 func NewHandler() *Handler {
-	h := &Handler{}
+	h := &Handler{
+		streamSize: DefaultStreamSize,
+	}
 	h.Items = make(map[string]int32)
 	h.Mu = &sync.RWMutex{}
 
@@ -0,0 +1,201 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package cue
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/audio"
+)
+
+const AssetNameCue = "cue"
+
+var _ core.AssetHandler = &Handler{}
+
+type Metadata struct {
+	Name   string         `json:"name"`
+	Bus    string         `json:"bus"`
+	Volume []float64      `json:"volume"`
+	Pitch  []float64      `json:"pitch"`
+	Clips  []ClipMetadata `json:"clips"`
+}
+
+type ClipMetadata struct {
+	Sound  string   `json:"sound"`
+	Weight *float64 `json:"weight"`
+}
+
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+func (h *Handler) Load(r *core.Resource) error {
+	m := &Metadata{}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return err
+	}
+
+	if _, dup := h.Items[m.Name]; dup {
+		return core.ErrAssetExists(m.Name)
+	}
+
+	c := core.NewAudioCue()
+	c.SetName(m.Name)
+	c.SetBus(m.Bus)
+
+	if min, max, ok := parseRange(m.Volume); ok {
+		c.SetVolumeRange(min, max)
+	}
+	if min, max, ok := parseRange(m.Pitch); ok {
+		c.SetPitchRange(min, max)
+	}
+
+	for _, v := range m.Clips {
+		weight := 1.0
+		if v.Weight != nil {
+			weight = *v.Weight
+		}
+
+		c.AddNamedClip(v.Sound, weight)
+	}
+
+	return h.Add(m.Name, c)
+}
+
+func (h *Handler) Add(name string, cue *core.AudioCue) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	if err := cue.Alloc(); err != nil {
+		return err
+	}
+
+	h.Items[name] = cue.ID()
+
+	return nil
+}
+
+// Get gets a cue by name. Sounds referenced by the cue are resolved from the
+// audio asset handler on first use, as they may be loaded after the cue.
+func (h *Handler) Get(name string) (*core.AudioCue, error) {
+	a, err := h.GetAsset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*core.AudioCue)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	if err := resolve(a2); err != nil {
+		return nil, err
+	}
+
+	return a2, nil
+}
+
+func (h *Handler) MustGet(name string) *core.AudioCue {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func (h *Handler) Name() string {
+	return AssetNameCue
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func Get(name string) (*core.AudioCue, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *core.AudioCue {
+	return mustHandler().MustGet(name)
+}
+
+// Play plays the cue with the given name.
+func Play(name string) error {
+	c, err := Get(name)
+	if err != nil {
+		return err
+	}
+
+	c.Play()
+
+	return nil
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameCue)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
+
+func resolve(c *core.AudioCue) error {
+	clips := c.Clips()
+
+	for i := range clips {
+		if clips[i].Sound != nil {
+			continue
+		}
+
+		s, err := audio.Get(clips[i].Name)
+		if err != nil {
+			return err
+		}
+
+		clips[i].Sound = s
+	}
+
+	return nil
+}
+
+func parseRange(r []float64) (min, max float64, ok bool) {
+	switch len(r) {
+	case 1:
+		return r[0], r[0], true
+	case 2:
+		return r[0], r[1], true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package cue
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/faiface/beep"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/audio"
+)
+
+const testCue = `{
+	"name": "hit",
+	"bus": "sfx",
+	"volume": [0.5, 0.8],
+	"pitch": [1.2],
+	"clips": [
+		{"sound": "hit1.wav"},
+		{"sound": "hit2.wav", "weight": 0}
+	]
+}`
+
+func TestMain(m *testing.M) {
+	if err := setupTestAssets(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	audioSys := core.NewAudioSystem(beep.SampleRate(44100))
+	audioSys.Setup()
+
+	code := m.Run()
+
+	audioSys.Teardown()
+	os.Exit(code)
+}
+
+// setupTestAssets registers the sound and cue handlers, adds two silent
+// sounds and loads the test cue from a mounted filesystem.
+func setupTestAssets() error {
+	core.NewInstanceSystem().Setup()
+	assets := core.NewAssetSystem()
+	assets.Setup()
+
+	sounds := audio.NewHandler()
+	asset.RegisterHandler(sounds)
+	asset.RegisterHandler(NewHandler())
+
+	format := beep.Format{SampleRate: 44100, NumChannels: 2, Precision: 2}
+	for _, name := range []string{"hit1.wav", "hit2.wav"} {
+		buffer := beep.NewBuffer(format)
+		buffer.Append(beep.Silence(4410))
+
+		s := core.NewSoundBuffer(buffer)
+		s.SetName(name)
+		if err := sounds.Add(name, s); err != nil {
+			return err
+		}
+	}
+
+	if err := assets.Mount("", fstest.MapFS{"hit.json": {Data: []byte(testCue)}}); err != nil {
+		return err
+	}
+
+	r, err := core.NewResource("hit.json")
+	if err != nil {
+		return err
+	}
+	if err := assets.ReadResource(r); err != nil {
+		return err
+	}
+
+	return mustHandler().Load(r)
+}
+
+func TestHandler_Load(t *testing.T) {
+	c, err := Get("hit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Bus(); got != "sfx" {
+		t.Errorf("Bus() = %q, want %q", got, "sfx")
+	}
+	if min, max := c.VolumeRange(); min != 0.5 || max != 0.8 {
+		t.Errorf("VolumeRange() = %v, %v, want 0.5, 0.8", min, max)
+	}
+	if min, max := c.PitchRange(); min != 1.2 || max != 1.2 {
+		t.Errorf("PitchRange() = %v, %v, want 1.2, 1.2", min, max)
+	}
+
+	clips := c.Clips()
+	if len(clips) != 2 {
+		t.Fatalf("len(Clips()) = %d, want 2", len(clips))
+	}
+	for i, want := range []float64{1, 0} {
+		if clips[i].Sound == nil {
+			t.Errorf("clip %d: sound %q not resolved", i, clips[i].Name)
+		}
+		if clips[i].Weight != want {
+			t.Errorf("clip %d: weight = %v, want %v", i, clips[i].Weight, want)
+		}
+	}
+}
+
+func TestPlay(t *testing.T) {
+	if err := Play("hit"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := audio.Get("hit1.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Playing() {
+		t.Error("hit1.wav is not playing")
+	}
+
+	if err := Play("missing"); err == nil {
+		t.Error("Play of a missing cue succeeded")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Package asset loads and looks up assets through the asset system of the
+// running app.
+//
+// Assets are loaded by handlers, each handling one kind of asset such as
+// "texture" or "mesh". Manifests list the files to load by kind:
+//
+//	{
+//	    "name": "level1",
+//	    "assets": {
+//	        "texture": ["textures/rock.png"],
+//	        "dialogue": ["dialogue/intro.json"]
+//	    }
+//	}
+//
+// Loaded assets are looked up by type and name with Get:
+//
+//	rock := asset.MustGet[*graphics.Texture2D]("rock.png")
+//
+// Games add their own kinds of assets, such as dialogue trees or level data,
+// by registering a handler before loading manifests which use them. Assets
+// must implement core.Object, usually by embedding core.BaseObject, and
+// handlers embed core.BaseAssetHandler, which tracks assets by name:
+//
+//	type Dialogue struct {
+//	    core.BaseObject
+//	    Lines []string `json:"lines"`
+//	}
+//
+//	type DialogueHandler struct {
+//	    core.BaseAssetHandler
+//	}
+//
+//	func (h *DialogueHandler) Name() string { return "dialogue" }
+//
+//	func (h *DialogueHandler) Load(r *core.Resource) error {
+//	    d := &Dialogue{}
+//	    if err := json.Unmarshal(r.Bytes(), d); err != nil {
+//	        return err
+//	    }
+//	    return h.AddAsset(r.Base(), d)
+//	}
+//
+//	asset.RegisterHandler(&DialogueHandler{core.NewBaseAssetHandler()})
+//
+//	intro := asset.MustGet[*Dialogue]("intro.json")
+//
+// Handlers whose assets hold GPU resources release them in Dealloc, which is
+// called when the asset is unloaded. Handlers may also implement
+// core.AsyncAssetHandler to decode on worker goroutines, and
+// core.ReloadableAssetHandler to reload assets when their files change.
+package asset
@@ -0,0 +1,76 @@
+//go:build assimp && cgo
+// +build assimp,cgo
+
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package fbx
+
+/*
+#cgo pkg-config: assimp
+
+#include <stdlib.h>
+#include <assimp/cexport.h>
+#include <assimp/cimport.h>
+#include <assimp/postprocess.h>
+#include <assimp/scene.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// importFlags triangulates meshes, fills in missing normals and limits
+// vertices to the four joint influences supported by skinned meshes.
+const importFlags = C.aiProcess_Triangulate |
+	C.aiProcess_GenSmoothNormals |
+	C.aiProcess_LimitBoneWeights |
+	C.aiProcess_ValidateDataStructure
+
+// convert imports a scene with assimp and exports it as a glb file, with
+// embedded textures written into its binary chunk.
+func convert(data []byte, hint string) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("fbx: empty file")
+	}
+
+	cHint := C.CString(hint)
+	defer C.free(unsafe.Pointer(cHint))
+
+	scene := C.aiImportFileFromMemory((*C.char)(unsafe.Pointer(&data[0])), C.uint(len(data)), importFlags, cHint)
+	if scene == nil {
+		return nil, fmt.Errorf("fbx: %s", C.GoString(C.aiGetErrorString()))
+	}
+	defer C.aiReleaseImport(scene)
+
+	format := C.CString("glb2")
+	defer C.free(unsafe.Pointer(format))
+
+	blob := C.aiExportSceneToBlob(scene, format, 0)
+	if blob == nil {
+		return nil, fmt.Errorf("fbx: %s", C.GoString(C.aiGetErrorString()))
+	}
+	defer C.aiReleaseExportBlob(blob)
+
+	return C.GoBytes(blob.data, C.int(blob.size)), nil
+}
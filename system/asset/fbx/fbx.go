@@ -0,0 +1,145 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package fbx
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/gltf"
+)
+
+const (
+	AssetNameFBX = "fbx"
+)
+
+// FBX errors
+var (
+	ErrUnsupportedFile = errors.New("fbx: only .fbx files are supported")
+	ErrNoImporter      = errors.New("fbx: built without the assimp build tag")
+)
+
+var _ core.AssetHandler = &Handler{}
+
+// Handler imports FBX scenes with meshes, materials, skeletons and animation
+// clips. Files are read with assimp, which is only linked when building with
+// the assimp tag, and converted to glTF, so imported scenes are gltf.Models.
+// Without the tag, loading an FBX file fails with ErrNoImporter.
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	name := r.Base()
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	if strings.ToLower(filepath.Ext(name)) != ".fbx" {
+		return ErrUnsupportedFile
+	}
+
+	glb, err := convert(r.Bytes(), "fbx")
+	if err != nil {
+		return err
+	}
+
+	m, err := gltf.Decode(name, glb, r.DirPrefix())
+	if err != nil {
+		return err
+	}
+
+	return h.Add(name, m)
+}
+
+func (h *Handler) Add(name string, model *gltf.Model) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	model.SetName(name)
+	if err := model.Alloc(); err != nil {
+		return err
+	}
+
+	h.Items[name] = model.ID()
+
+	return nil
+}
+
+func (h *Handler) Get(name string) (*gltf.Model, error) {
+	a, err := h.GetAsset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*gltf.Model)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+func (h *Handler) MustGet(name string) *gltf.Model {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func (h *Handler) Name() string {
+	return AssetNameFBX
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func Get(name string) (*gltf.Model, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *gltf.Model {
+	return mustHandler().MustGet(name)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameFBX)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
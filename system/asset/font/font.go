@@ -23,6 +23,9 @@ SOFTWARE.
 package font
 
 import (
+	"encoding/json"
+	"path"
+	"strings"
 	"sync"
 
 	"github.com/golang/freetype/truetype"
@@ -38,27 +41,80 @@ const (
 
 var _ core.AssetHandler = &Handler{}
 
+// Metadata describes a font loaded from a JSON file rather than directly from
+// a TrueType file. Fonts with SDF set render their glyphs into a signed
+// distance field atlas at load time, which scales to any size and lets text
+// have outlines and glows. Size and spread default to graphics.DefaultSDFSize
+// and graphics.DefaultSDFSpread. Runes adds characters beyond ASCII.
+//
+//	{
+//	    "name": "title",
+//	    "file": "Title-Regular.ttf",
+//	    "sdf": true,
+//	    "size": 64,
+//	    "spread": 8,
+//	    "runes": "äöüß"
+//	}
+type Metadata struct {
+	Name   string  `json:"name"`
+	File   string  `json:"file"`
+	SDF    bool    `json:"sdf"`
+	Size   float64 `json:"size"`
+	Spread float64 `json:"spread"`
+	Runes  string  `json:"runes"`
+}
+
 type Handler struct {
 	core.BaseAssetHandler
 }
 
-// Load will load data from the reader.
+// Load will load data from the reader. The resource is either a TrueType
+// font or a JSON file describing one.
 func (h *Handler) Load(r *core.Resource) error {
-	name := r.Base()
+	m, ttf, err := readFont(r)
+	if err != nil {
+		return err
+	}
 
-	if _, dup := h.Items[name]; dup {
-		return core.ErrAssetExists(name)
+	if _, dup := h.Items[m.Name]; dup {
+		return core.ErrAssetExists(m.Name)
 	}
 
-	ttf, err := truetype.Parse(r.Bytes())
-	if err != nil {
+	f := graphics.NewFont(ttf, graphics.ASCII, []rune(m.Runes))
+	f.SetName(m.Name)
+
+	if m.SDF {
+		f.SetSDF(m.Size, m.Spread)
+	}
+
+	if err := h.Add(m.Name, f); err != nil {
 		return err
 	}
 
-	f := graphics.NewFont(ttf, graphics.ASCII)
-	f.SetName(name)
+	// Distance field fonts have a single atlas, so it is made up front
+	// rather than when text first uses the font.
+	if f.SDF() {
+		f.Atlas(f.SDFSize())
+	}
+
+	return nil
+}
+
+// Reload replaces the typeface of a loaded font.
+func (h *Handler) Reload(r *core.Resource) (string, error) {
+	m, ttf, err := readFont(r)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := h.Get(m.Name)
+	if err != nil {
+		return "", err
+	}
+
+	f.Replace(ttf)
 
-	return h.Add(name, f)
+	return m.Name, nil
 }
 
 func (h *Handler) Add(name string, font *graphics.Font) error {
@@ -120,6 +176,43 @@ func MustGet(name string) *graphics.Font {
 	return mustHandler().MustGet(name)
 }
 
+// readFont reads a TrueType font, or the JSON description of one and the
+// font it names.
+func readFont(r *core.Resource) (*Metadata, *truetype.Font, error) {
+	if !strings.EqualFold(path.Ext(r.Base()), ".json") {
+		ttf, err := truetype.Parse(r.Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &Metadata{Name: r.Base()}, ttf, nil
+	}
+
+	m := &Metadata{}
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return nil, nil, err
+	}
+
+	if m.Name == "" {
+		m.Name = r.Base()
+	}
+
+	fr, err := core.NewResource(path.Join(r.DirPrefix(), m.File))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := asset.ReadResource(fr); err != nil {
+		return nil, nil, err
+	}
+
+	ttf, err := truetype.Parse(fr.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, ttf, nil
+}
+
 func mustHandler() *Handler {
 	h, err := asset.GetHandler(AssetNameFont)
 	if err != nil {
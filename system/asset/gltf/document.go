@@ -0,0 +1,507 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/asset"
+)
+
+// glTF errors
+var (
+	ErrInvalidGLB      = errors.New("gltf: invalid glb container")
+	ErrSparseAccessor  = errors.New("gltf: sparse accessors are not supported")
+	ErrUnsupportedFile = errors.New("gltf: only glTF 2.0 files are supported")
+)
+
+const (
+	glbMagic     = 0x46546c67 // "glTF"
+	glbChunkJSON = 0x4e4f534a // "JSON"
+	glbChunkBIN  = 0x004e4942 // "BIN"
+)
+
+// Accessor component types.
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+// maxAccessorCount is the largest number of elements an accessor may have.
+const maxAccessorCount = 1 << 24
+
+// Primitive modes.
+const (
+	modeTriangles = 4
+)
+
+type document struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Scene       *int            `json:"scene"`
+	Scenes      []docScene      `json:"scenes"`
+	Nodes       []docNode       `json:"nodes"`
+	Meshes      []docMesh       `json:"meshes"`
+	Materials   []docMaterial   `json:"materials"`
+	Textures    []docTexture    `json:"textures"`
+	Images      []docImage      `json:"images"`
+	Skins       []docSkin       `json:"skins"`
+	Animations  []docAnimation  `json:"animations"`
+	Accessors   []docAccessor   `json:"accessors"`
+	BufferViews []docBufferView `json:"bufferViews"`
+	Buffers     []docBuffer     `json:"buffers"`
+
+	// data holds the contents of the buffers once they are loaded.
+	data [][]byte
+}
+
+type docScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type docNode struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Skin        *int      `json:"skin"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+}
+
+type docMesh struct {
+	Name       string         `json:"name"`
+	Primitives []docPrimitive `json:"primitives"`
+}
+
+type docPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type docTextureRef struct {
+	Index int `json:"index"`
+}
+
+type docMaterial struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          []float32      `json:"baseColorFactor"`
+		BaseColorTexture         *docTextureRef `json:"baseColorTexture"`
+		MetallicFactor           *float32       `json:"metallicFactor"`
+		RoughnessFactor          *float32       `json:"roughnessFactor"`
+		MetallicRoughnessTexture *docTextureRef `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *docTextureRef `json:"normalTexture"`
+	OcclusionTexture *docTextureRef `json:"occlusionTexture"`
+	EmissiveTexture  *docTextureRef `json:"emissiveTexture"`
+	EmissiveFactor   []float32      `json:"emissiveFactor"`
+	AlphaMode        string         `json:"alphaMode"`
+	DoubleSided      bool           `json:"doubleSided"`
+}
+
+type docTexture struct {
+	Source *int `json:"source"`
+}
+
+type docImage struct {
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type docSkin struct {
+	InverseBindMatrices *int  `json:"inverseBindMatrices"`
+	Joints              []int `json:"joints"`
+}
+
+type docAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
+
+type docAccessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"`
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type docBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type docBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// decode parses a .gltf or .glb file. Buffers are loaded relative to dir.
+func decode(data []byte, dir string) (*document, error) {
+	var bin []byte
+
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		if data, bin, err = splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &document{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(d.Asset.Version, "2.") {
+		return nil, ErrUnsupportedFile
+	}
+
+	d.data = make([][]byte, len(d.Buffers))
+	for i, b := range d.Buffers {
+		var err error
+
+		switch {
+		case b.URI == "" && i == 0 && bin != nil:
+			d.data[i] = bin
+		case b.URI == "":
+			return nil, fmt.Errorf("gltf: buffer %d has no data", i)
+		default:
+			if d.data[i], err = loadURI(b.URI, dir); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(d.data[i]) < b.ByteLength {
+			return nil, fmt.Errorf("gltf: buffer %d is shorter than %d bytes", i, b.ByteLength)
+		}
+	}
+
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// validate checks the buffer views and accessors of a document, so that
+// reading them cannot go out of range.
+func (d *document) validate() error {
+	for i, v := range d.BufferViews {
+		if v.Buffer < 0 || v.Buffer >= len(d.data) {
+			return fmt.Errorf("gltf: buffer view %d has an invalid buffer %d", i, v.Buffer)
+		}
+		if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(d.data[v.Buffer])-v.ByteLength {
+			return fmt.Errorf("gltf: buffer view %d is out of range", i)
+		}
+		if v.ByteStride != 0 && (v.ByteStride < 4 || v.ByteStride > 252) {
+			return fmt.Errorf("gltf: buffer view %d has an invalid stride %d", i, v.ByteStride)
+		}
+	}
+
+	for i, a := range d.Accessors {
+		if a.ByteOffset < 0 || a.Count < 0 || a.Count > maxAccessorCount {
+			return fmt.Errorf("gltf: accessor %d is out of range", i)
+		}
+		if a.BufferView != nil && (*a.BufferView < 0 || *a.BufferView >= len(d.BufferViews)) {
+			return fmt.Errorf("gltf: accessor %d has an invalid buffer view %d", i, *a.BufferView)
+		}
+	}
+
+	return nil
+}
+
+// splitGLB returns the JSON and binary chunks of a glb container.
+func splitGLB(data []byte) (jsonChunk, bin []byte, err error) {
+	if binary.LittleEndian.Uint32(data[4:]) != 2 {
+		return nil, nil, ErrUnsupportedFile
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, ErrInvalidGLB
+	}
+
+	for offset := 12; offset+8 <= length; {
+		size := int(binary.LittleEndian.Uint32(data[offset:]))
+		kind := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+
+		if size < 0 || offset+size > length {
+			return nil, nil, ErrInvalidGLB
+		}
+
+		switch kind {
+		case glbChunkJSON:
+			jsonChunk = data[offset : offset+size]
+		case glbChunkBIN:
+			bin = data[offset : offset+size]
+		}
+
+		offset += size
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, ErrInvalidGLB
+	}
+
+	return jsonChunk, bin, nil
+}
+
+// loadURI loads a data URI or a resource relative to dir.
+func loadURI(uri, dir string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.Index(uri, ";base64,")
+		if i < 0 {
+			return nil, fmt.Errorf("gltf: unsupported data uri")
+		}
+
+		return base64.StdEncoding.DecodeString(uri[i+len(";base64,"):])
+	}
+
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := core.NewResource(filepath.Join(dir, path))
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	return r.Bytes(), nil
+}
+
+// bufferView returns the bytes of a buffer view.
+func (d *document) bufferView(index int) ([]byte, error) {
+	if index < 0 || index >= len(d.BufferViews) {
+		return nil, fmt.Errorf("gltf: invalid buffer view %d", index)
+	}
+
+	v := d.BufferViews[index]
+	if v.Buffer < 0 || v.Buffer >= len(d.data) {
+		return nil, fmt.Errorf("gltf: invalid buffer %d", v.Buffer)
+	}
+
+	data := d.data[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(data)-v.ByteLength {
+		return nil, fmt.Errorf("gltf: buffer view %d is out of range", index)
+	}
+
+	return data[v.ByteOffset : v.ByteOffset+v.ByteLength], nil
+}
+
+// componentCount returns the number of components of an accessor type.
+func componentCount(kind string) int {
+	switch kind {
+	case "SCALAR":
+		return 1
+	case "VEC2":
+		return 2
+	case "VEC3":
+		return 3
+	case "VEC4", "MAT2":
+		return 4
+	case "MAT3":
+		return 9
+	case "MAT4":
+		return 16
+	}
+
+	return 0
+}
+
+// componentSize returns the size in bytes of an accessor component type.
+func componentSize(componentType int) int {
+	switch componentType {
+	case componentByte, componentUnsignedByte:
+		return 1
+	case componentShort, componentUnsignedShort:
+		return 2
+	case componentUnsignedInt, componentFloat:
+		return 4
+	}
+
+	return 0
+}
+
+// read calls fn with each component of an accessor, converted to float64,
+// and returns the number of components per element. Normalized integers are
+// mapped to [0, 1] or [-1, 1].
+func (d *document) read(index int, fn func(i int, v float64)) (int, error) {
+	if index < 0 || index >= len(d.Accessors) {
+		return 0, fmt.Errorf("gltf: invalid accessor %d", index)
+	}
+
+	a := d.Accessors[index]
+	if len(a.Sparse) != 0 {
+		return 0, ErrSparseAccessor
+	}
+
+	count := componentCount(a.Type)
+	size := componentSize(a.ComponentType)
+	if count == 0 || size == 0 {
+		return 0, fmt.Errorf("gltf: accessor %d has an invalid type", index)
+	}
+
+	// Accessors without a buffer view are all zeros.
+	if a.BufferView == nil {
+		for i := 0; i < a.Count*count; i++ {
+			fn(i, 0)
+		}
+		return count, nil
+	}
+
+	data, err := d.bufferView(*a.BufferView)
+	if err != nil {
+		return 0, err
+	}
+
+	stride := d.BufferViews[*a.BufferView].ByteStride
+	if stride == 0 {
+		stride = count * size
+	}
+
+	// The last element must end within the view.
+	if a.Count > 0 {
+		last := len(data) - a.ByteOffset - count*size
+		if last < 0 || a.Count-1 > last/stride {
+			return 0, fmt.Errorf("gltf: accessor %d is out of range", index)
+		}
+	}
+
+	for e := 0; e < a.Count; e++ {
+		base := a.ByteOffset + e*stride
+
+		for c := 0; c < count; c++ {
+			b := data[base+c*size:]
+
+			var v float64
+			switch a.ComponentType {
+			case componentByte:
+				v = float64(int8(b[0]))
+				if a.Normalized {
+					v = math.Max(v/127, -1)
+				}
+			case componentUnsignedByte:
+				v = float64(b[0])
+				if a.Normalized {
+					v /= 255
+				}
+			case componentShort:
+				v = float64(int16(binary.LittleEndian.Uint16(b)))
+				if a.Normalized {
+					v = math.Max(v/32767, -1)
+				}
+			case componentUnsignedShort:
+				v = float64(binary.LittleEndian.Uint16(b))
+				if a.Normalized {
+					v /= 65535
+				}
+			case componentUnsignedInt:
+				v = float64(binary.LittleEndian.Uint32(b))
+			case componentFloat:
+				v = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			}
+
+			fn(e*count+c, v)
+		}
+	}
+
+	return count, nil
+}
+
+// floats reads an accessor into a slice of float32 values.
+func (d *document) floats(index int) ([]float32, int, error) {
+	values := make([]float32, 0)
+
+	count, err := d.read(index, func(i int, v float64) {
+		values = append(values, float32(v))
+	})
+
+	return values, count, err
+}
+
+// uints reads an accessor of integers into a slice of uint32 values.
+func (d *document) uints(index int) ([]uint32, int, error) {
+	values := make([]uint32, 0)
+
+	count, err := d.read(index, func(i int, v float64) {
+		values = append(values, uint32(v))
+	})
+
+	return values, count, err
+}
+
+// imageData returns the encoded data of an image.
+func (d *document) imageData(index int, dir string) ([]byte, error) {
+	if index < 0 || index >= len(d.Images) {
+		return nil, fmt.Errorf("gltf: invalid image %d", index)
+	}
+
+	img := d.Images[index]
+	if img.BufferView != nil {
+		data, err := d.bufferView(*img.BufferView)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(nil), data...), nil
+	}
+
+	return loadURI(img.URI, dir)
+}
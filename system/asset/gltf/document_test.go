@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testBuffer holds the floats 0 to 7, as a data URI.
+var testBuffer = func() string {
+	b := make([]byte, 32)
+	for i := 0; i < 8; i++ {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(float32(i)))
+	}
+
+	return "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(b)
+}()
+
+// testDocument returns a document with one buffer of 32 bytes, and the
+// buffer view and accessor given as JSON.
+func testDocument(view, accessor string) []byte {
+	return []byte(fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"uri": %q, "byteLength": 32}],
+		"bufferViews": [%s],
+		"accessors": [%s]
+	}`, testBuffer, view, accessor))
+}
+
+func TestDecode_Accessor(t *testing.T) {
+	tests := []struct {
+		view     string
+		accessor string
+		want     []float32
+	}{
+		{
+			view:     `{"buffer": 0, "byteLength": 32}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC2"}`,
+			want:     []float32{0, 1, 2, 3, 4, 5, 6, 7},
+		},
+		{
+			view:     `{"buffer": 0, "byteOffset": 8, "byteLength": 24, "byteStride": 8}`,
+			accessor: `{"bufferView": 0, "byteOffset": 4, "componentType": 5126, "count": 3, "type": "SCALAR"}`,
+			want:     []float32{3, 5, 7},
+		},
+		{
+			view:     `{"buffer": 0, "byteLength": 32}`,
+			accessor: `{"componentType": 5126, "count": 2, "type": "VEC2"}`,
+			want:     []float32{0, 0, 0, 0},
+		},
+	}
+
+	for i, v := range tests {
+		d, err := decode(testDocument(v.view, v.accessor), "")
+		if err != nil {
+			t.Errorf("Accessor case %d failed. error: %v", i, err)
+			continue
+		}
+
+		got, _, err := d.floats(0)
+		if err != nil {
+			t.Errorf("Accessor case %d failed. error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(v.want, got) {
+			t.Errorf("Accessor case %d failed. want: %v got: %v", i, v.want, got)
+		}
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct {
+		view     string
+		accessor string
+	}{
+		{
+			view:     `{"buffer": 0, "byteLength": -8}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteOffset": -8, "byteLength": 8}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteOffset": 16, "byteLength": 24}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 1, "byteLength": 8}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteLength": 32, "byteStride": -4}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteLength": 32}`,
+			accessor: `{"bufferView": 0, "byteOffset": -8, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteLength": 32}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": -1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteLength": 32}`,
+			accessor: `{"bufferView": 1, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		},
+		{
+			view:     `{"buffer": 0, "byteLength": 32}`,
+			accessor: `{"componentType": 5126, "count": 1000000000, "type": "SCALAR"}`,
+		},
+	}
+
+	for i, v := range tests {
+		if _, err := decode(testDocument(v.view, v.accessor), ""); err == nil {
+			t.Errorf("Invalid case %d failed. want: error got: nil", i)
+		}
+	}
+}
+
+func TestDocument_ReadOutOfRange(t *testing.T) {
+	tests := []string{
+		`{"bufferView": 0, "componentType": 5126, "count": 9, "type": "SCALAR"}`,
+		`{"bufferView": 0, "byteOffset": 32, "componentType": 5126, "count": 1, "type": "SCALAR"}`,
+		`{"bufferView": 0, "byteOffset": 4, "componentType": 5126, "count": 2, "type": "VEC4"}`,
+		fmt.Sprintf(`{"bufferView": 0, "componentType": 5126, "count": %d, "type": "MAT4"}`, maxAccessorCount),
+	}
+
+	for i, v := range tests {
+		d, err := decode(testDocument(`{"buffer": 0, "byteLength": 32}`, v), "")
+		if err != nil {
+			t.Errorf("ReadOutOfRange case %d failed. error: %v", i, err)
+			continue
+		}
+
+		if _, _, err := d.floats(0); err == nil {
+			t.Errorf("ReadOutOfRange case %d failed. want: error got: nil", i)
+		}
+	}
+}
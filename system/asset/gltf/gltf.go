@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package gltf
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/asset"
+)
+
+const (
+	AssetNameGLTF = "gltf"
+)
+
+var _ core.AssetHandler = &Handler{}
+
+// Handler imports glTF 2.0 models from .gltf files, with embedded or
+// external buffers and images, and from binary .glb files.
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	name := r.Base()
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gltf", ".glb":
+	default:
+		return ErrUnsupportedFile
+	}
+
+	m, err := Decode(name, r.Bytes(), r.DirPrefix())
+	if err != nil {
+		return err
+	}
+
+	return h.Add(name, m)
+}
+
+func (h *Handler) Add(name string, model *Model) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	model.SetName(name)
+	if err := model.Alloc(); err != nil {
+		return err
+	}
+
+	h.Items[name] = model.ID()
+
+	return nil
+}
+
+func (h *Handler) Get(name string) (*Model, error) {
+	a, err := h.GetAsset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*Model)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+func (h *Handler) MustGet(name string) *Model {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func (h *Handler) Name() string {
+	return AssetNameGLTF
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func Get(name string) (*Model, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *Model {
+	return mustHandler().MustGet(name)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameGLTF)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
@@ -0,0 +1,782 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package gltf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/texture"
+	"github.com/haakenlabs/arc/system/instance"
+
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Model is an imported glTF scene. Its meshes, materials and textures are
+// shared by every game object created with Instantiate.
+type Model struct {
+	core.BaseObject
+
+	nodes     []node
+	roots     []int
+	meshes    [][]primitive
+	materials []*scene.StandardMaterial
+	cullFace  []bool
+	textures  []*graphics.Texture2D
+	skins     []skin
+	clips     []*scene.AnimationClip
+}
+
+type node struct {
+	name     string
+	position mgl32.Vec3
+	rotation mgl32.Quat
+	scale    mgl32.Vec3
+	children []int
+	mesh     int
+	skin     int
+}
+
+type primitive struct {
+	mesh     *graphics.Mesh
+	material *scene.StandardMaterial
+	cullFace bool
+	joints   [][scene.MaxJointInfluences]uint16
+	weights  [][scene.MaxJointInfluences]float32
+}
+
+type skin struct {
+	joints      []int
+	inverseBind []mgl32.Mat4
+}
+
+// Decode creates a model from the contents of a .gltf or .glb file. External
+// buffers and images are loaded relative to dir.
+func Decode(name string, data []byte, dir string) (*Model, error) {
+	d, err := decode(data, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return newModel(name, d, dir)
+}
+
+// newModel creates a model from a decoded document. Meshes and textures are
+// allocated as they are created.
+func newModel(name string, d *document, dir string) (*Model, error) {
+	m := &Model{}
+
+	m.SetName(name)
+	instance.MustAssign(m)
+
+	loaders := []func(*document, string) error{
+		m.loadTextures,
+		m.loadMaterials,
+		m.loadMeshes,
+		m.loadSkins,
+		m.loadNodes,
+		m.loadAnimations,
+	}
+
+	for _, load := range loaders {
+		if err := load(d, dir); err != nil {
+			m.release()
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// release releases the model and the meshes and textures created for it.
+func (m *Model) release() {
+	instance.Release(append(m.Owned(), m.ID())...)
+}
+
+// Owned returns the IDs of the meshes, textures and clips created for the
+// model, so they are released when the model is unloaded.
+func (m *Model) Owned() []int32 {
+	var ids []int32
+
+	for i := range m.meshes {
+		for _, p := range m.meshes[i] {
+			ids = append(ids, p.mesh.ID())
+		}
+	}
+	for _, t := range m.textures {
+		if t != nil {
+			ids = append(ids, t.ID())
+		}
+	}
+	for _, c := range m.clips {
+		ids = append(ids, c.ID())
+	}
+
+	return ids
+}
+
+// Clips returns the animation clips of the model. Track paths are relative
+// to the object returned by Instantiate, so an Animator on that object can
+// play them.
+func (m *Model) Clips() []*scene.AnimationClip {
+	return m.clips
+}
+
+// Clip returns the clip with the given name, or nil if there is none.
+func (m *Model) Clip(name string) *scene.AnimationClip {
+	for _, c := range m.clips {
+		if c.Name() == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Instantiate creates the game objects of the model. The returned object is
+// the root of the hierarchy and should be added with Scene.AddObject.
+func (m *Model) Instantiate() *scene.GameObject {
+	root := scene.NewGameObject(m.Name())
+	objects := make([]*scene.GameObject, len(m.nodes))
+
+	var build func(index int) *scene.GameObject
+	build = func(index int) *scene.GameObject {
+		n := &m.nodes[index]
+
+		object := scene.NewGameObject(n.name)
+		object.Transform().SetPosition(n.position)
+		object.Transform().SetRotation(n.rotation)
+		object.Transform().SetScale(n.scale)
+
+		objects[index] = object
+
+		if n.mesh >= 0 {
+			m.addMesh(object, n)
+		}
+		for _, c := range n.children {
+			object.AddChild(build(c))
+		}
+
+		return object
+	}
+
+	for _, r := range m.roots {
+		root.AddChild(build(r))
+	}
+
+	// Joints can be anywhere in the hierarchy, so skins are bound once all
+	// objects exist.
+	for i := range m.nodes {
+		n := &m.nodes[i]
+		if n.skin < 0 || objects[i] == nil {
+			continue
+		}
+
+		s := m.skins[n.skin]
+		joints := make([]*scene.GameObject, len(s.joints))
+		for j, index := range s.joints {
+			joints[j] = objects[index]
+		}
+
+		for _, c := range objects[i].ComponentsInChildren() {
+			if skinned, ok := c.(*scene.SkinnedMesh); ok {
+				skinned.SetSkeleton(joints, s.inverseBind)
+			}
+		}
+	}
+
+	return root
+}
+
+// addMesh adds the primitives of a node's mesh to its game object. Each
+// primitive needs its own renderer, so meshes with more than one primitive
+// get a child object per primitive.
+func (m *Model) addMesh(object *scene.GameObject, n *node) {
+	primitives := m.meshes[n.mesh]
+
+	for i, p := range primitives {
+		target := object
+		if len(primitives) > 1 {
+			target = scene.NewGameObject(fmt.Sprintf("%s.%d", n.name, i))
+			object.AddChild(target)
+		}
+
+		mesh := p.mesh
+		if n.skin >= 0 && len(p.joints) != 0 {
+			skinned := scene.NewSkinnedMesh(p.mesh, p.joints, p.weights)
+			target.AddComponent(skinned)
+			mesh = skinned.Mesh()
+		}
+
+		renderer := scene.NewMeshRenderer()
+		renderer.SetMaterial(p.material.Material)
+		renderer.SetCullFaceEnabled(p.cullFace)
+
+		target.AddComponent(scene.NewMeshFilter(mesh))
+		target.AddComponent(renderer)
+	}
+}
+
+func (m *Model) loadTextures(d *document, dir string) error {
+	var anisotropy float32 = 1
+	if h, err := asset.GetHandler(texture.AssetNameTexture); err == nil {
+		anisotropy = h.(*texture.Handler).Anisotropy()
+	}
+
+	images := make(map[int]*graphics.Texture2D)
+	m.textures = make([]*graphics.Texture2D, len(d.Textures))
+
+	for i, t := range d.Textures {
+		if t.Source == nil {
+			continue
+		}
+		if tex, ok := images[*t.Source]; ok {
+			m.textures[i] = tex
+			continue
+		}
+
+		data, err := d.imageData(*t.Source, dir)
+		if err != nil {
+			return err
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("gltf: image %d: %v", *t.Source, err)
+		}
+
+		// Images are drawn into a single color model so that palettes and
+		// YCbCr images can be uploaded.
+		rgba := image.NewNRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+		tex, err := texture.NewTexture(rgba)
+		if err != nil {
+			return err
+		}
+
+		tex.SetName(fmt.Sprintf("%s.image%d", m.Name(), *t.Source))
+		if err := tex.Alloc(); err != nil {
+			return err
+		}
+
+		tex.SetWrapMode(graphics.WrapRepeat)
+		tex.GenerateMipmaps()
+		tex.SetAnisotropy(anisotropy)
+
+		images[*t.Source] = tex
+		m.textures[i] = tex
+	}
+
+	return nil
+}
+
+func (m *Model) texture(ref *docTextureRef) graphics.Texture {
+	if ref == nil || ref.Index < 0 || ref.Index >= len(m.textures) || m.textures[ref.Index] == nil {
+		return nil
+	}
+
+	return m.textures[ref.Index]
+}
+
+func (m *Model) loadMaterials(d *document, _ string) error {
+	m.materials = make([]*scene.StandardMaterial, len(d.Materials))
+	m.cullFace = make([]bool, len(d.Materials))
+
+	for i, dm := range d.Materials {
+		mat := scene.NewStandardMaterial()
+		if dm.Name != "" {
+			mat.SetName(dm.Name)
+		}
+
+		// glTF defaults to a fully metallic, fully rough material.
+		mat.SetMetallic(1)
+		mat.SetRoughness(1)
+
+		if pbr := dm.PBRMetallicRoughness; pbr != nil {
+			if len(pbr.BaseColorFactor) == 4 {
+				c := pbr.BaseColorFactor
+				mat.SetAlbedo(core.Color{R: c[0], G: c[1], B: c[2], A: c[3]})
+			}
+			if pbr.MetallicFactor != nil {
+				mat.SetMetallic(*pbr.MetallicFactor)
+			}
+			if pbr.RoughnessFactor != nil {
+				mat.SetRoughness(*pbr.RoughnessFactor)
+			}
+
+			mat.SetAlbedoMap(m.texture(pbr.BaseColorTexture))
+			mat.SetMetallicRoughnessMap(m.texture(pbr.MetallicRoughnessTexture))
+		}
+
+		mat.SetNormalMap(m.texture(dm.NormalTexture))
+		mat.SetOcclusionMap(m.texture(dm.OcclusionTexture))
+		mat.SetEmissiveMap(m.texture(dm.EmissiveTexture))
+
+		if len(dm.EmissiveFactor) == 3 {
+			c := dm.EmissiveFactor
+			mat.SetEmissive(core.Color{R: c[0], G: c[1], B: c[2], A: 1})
+		}
+
+		switch dm.AlphaMode {
+		case "BLEND":
+			mat.SetRenderQueue(scene.RenderQueueTransparent)
+		case "MASK":
+			mat.SetRenderQueue(scene.RenderQueueAlphaTest)
+		}
+
+		m.materials[i] = mat
+		m.cullFace[i] = !dm.DoubleSided
+	}
+
+	return nil
+}
+
+func (m *Model) loadMeshes(d *document, _ string) error {
+	var fallback *scene.StandardMaterial
+
+	m.meshes = make([][]primitive, len(d.Meshes))
+
+	for i, dm := range d.Meshes {
+		for j, dp := range dm.Primitives {
+			if dp.Mode != nil && *dp.Mode != modeTriangles {
+				logrus.Warnf("gltf: %s: skipping primitive %d of mesh %d with mode %d", m.Name(), j, i, *dp.Mode)
+				continue
+			}
+
+			p, err := m.loadPrimitive(d, dp)
+			if err != nil {
+				return fmt.Errorf("gltf: mesh %d primitive %d: %v", i, j, err)
+			}
+
+			name := dm.Name
+			if name == "" {
+				name = fmt.Sprintf("%s.mesh%d", m.Name(), i)
+			}
+			p.mesh.SetName(fmt.Sprintf("%s.%d", name, j))
+			if err := p.mesh.Alloc(); err != nil {
+				return err
+			}
+
+			switch {
+			case dp.Material != nil && *dp.Material >= 0 && *dp.Material < len(m.materials):
+				p.material = m.materials[*dp.Material]
+				p.cullFace = m.cullFace[*dp.Material]
+			default:
+				if fallback == nil {
+					fallback = scene.NewStandardMaterial()
+				}
+				p.material = fallback
+				p.cullFace = true
+			}
+
+			m.meshes[i] = append(m.meshes[i], p)
+		}
+	}
+
+	return nil
+}
+
+// loadPrimitive reads the attributes of a triangle primitive. Meshes are not
+// indexed, so indexed primitives are expanded into separate triangles.
+func (m *Model) loadPrimitive(d *document, dp docPrimitive) (primitive, error) {
+	p := primitive{}
+
+	position, ok := dp.Attributes["POSITION"]
+	if !ok {
+		return p, fmt.Errorf("missing positions")
+	}
+
+	positions, _, err := d.floats(position)
+	if err != nil {
+		return p, err
+	}
+	count := len(positions) / 3
+
+	// indices maps each triangle corner to a vertex.
+	var indices []uint32
+	if dp.Indices != nil {
+		if indices, _, err = d.uints(*dp.Indices); err != nil {
+			return p, err
+		}
+	} else {
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	indices = indices[:len(indices)-len(indices)%3]
+	if len(indices) == 0 {
+		return p, fmt.Errorf("no triangles")
+	}
+	for _, index := range indices {
+		if int(index) >= count {
+			return p, fmt.Errorf("index %d is out of range", index)
+		}
+	}
+
+	attribute := func(name string, size int) ([]float32, error) {
+		index, ok := dp.Attributes[name]
+		if !ok {
+			return nil, nil
+		}
+
+		values, n, err := d.floats(index)
+		if err != nil {
+			return nil, err
+		}
+		if n != size || len(values) != count*size {
+			return nil, fmt.Errorf("invalid %s attribute", name)
+		}
+
+		return values, nil
+	}
+
+	normals, err := attribute("NORMAL", 3)
+	if err != nil {
+		return p, err
+	}
+	uvs, err := attribute("TEXCOORD_0", 2)
+	if err != nil {
+		return p, err
+	}
+	tangents, err := attribute("TANGENT", 4)
+	if err != nil {
+		return p, err
+	}
+	weights, err := attribute("WEIGHTS_0", scene.MaxJointInfluences)
+	if err != nil {
+		return p, err
+	}
+
+	var joints []uint32
+	if index, ok := dp.Attributes["JOINTS_0"]; ok && weights != nil {
+		var n int
+		if joints, n, err = d.uints(index); err != nil {
+			return p, err
+		}
+		if n != scene.MaxJointInfluences || len(joints) != count*n {
+			return p, fmt.Errorf("invalid JOINTS_0 attribute")
+		}
+	}
+
+	v := make([]mgl32.Vec3, len(indices))
+	n := make([]mgl32.Vec3, len(indices))
+	t := make([]mgl32.Vec2, len(indices))
+
+	var tn []mgl32.Vec4
+	if tangents != nil && normals != nil {
+		tn = make([]mgl32.Vec4, len(indices))
+	}
+
+	for i, index := range indices {
+		k := int(index)
+
+		v[i] = mgl32.Vec3{positions[k*3], positions[k*3+1], positions[k*3+2]}
+		if normals != nil {
+			n[i] = mgl32.Vec3{normals[k*3], normals[k*3+1], normals[k*3+2]}
+		}
+		if uvs != nil {
+			t[i] = mgl32.Vec2{uvs[k*2], uvs[k*2+1]}
+		}
+		if tn != nil {
+			tn[i] = mgl32.Vec4{tangents[k*4], tangents[k*4+1], tangents[k*4+2], tangents[k*4+3]}
+		}
+
+		if joints != nil {
+			var j [scene.MaxJointInfluences]uint16
+			var w [scene.MaxJointInfluences]float32
+			for c := 0; c < scene.MaxJointInfluences; c++ {
+				j[c] = uint16(joints[k*scene.MaxJointInfluences+c])
+				w[c] = weights[k*scene.MaxJointInfluences+c]
+			}
+			p.joints = append(p.joints, j)
+			p.weights = append(p.weights, w)
+		}
+	}
+
+	// Primitives without normals are flat shaded.
+	if normals == nil {
+		for i := 0; i < len(v); i += 3 {
+			normal := v[i+1].Sub(v[i]).Cross(v[i+2].Sub(v[i]))
+			if normal.Len() > 0 {
+				normal = normal.Normalize()
+			}
+			n[i], n[i+1], n[i+2] = normal, normal, normal
+		}
+	}
+
+	p.mesh = graphics.NewMesh()
+	p.mesh.SetVertices(v)
+	p.mesh.SetNormals(n)
+	p.mesh.SetUvs(t)
+
+	// glTF tangents are MikkTSpace tangents, so generated ones match normal
+	// maps made for the model.
+	if tn != nil {
+		p.mesh.SetTangents(tn)
+	} else if uvs != nil {
+		p.mesh.GenerateTangents()
+	}
+
+	return p, nil
+}
+
+func (m *Model) loadSkins(d *document, _ string) error {
+	m.skins = make([]skin, len(d.Skins))
+
+	for i, ds := range d.Skins {
+		s := skin{
+			joints:      ds.Joints,
+			inverseBind: make([]mgl32.Mat4, len(ds.Joints)),
+		}
+
+		for j := range s.inverseBind {
+			s.inverseBind[j] = mgl32.Ident4()
+		}
+
+		if ds.InverseBindMatrices != nil {
+			values, n, err := d.floats(*ds.InverseBindMatrices)
+			if err != nil {
+				return err
+			}
+			if n != 16 || len(values) < len(ds.Joints)*16 {
+				return fmt.Errorf("gltf: skin %d has invalid inverse bind matrices", i)
+			}
+
+			for j := range s.inverseBind {
+				copy(s.inverseBind[j][:], values[j*16:])
+			}
+		}
+
+		for _, joint := range ds.Joints {
+			if joint < 0 || joint >= len(d.Nodes) {
+				return fmt.Errorf("gltf: skin %d has an invalid joint %d", i, joint)
+			}
+		}
+
+		m.skins[i] = s
+	}
+
+	return nil
+}
+
+func (m *Model) loadNodes(d *document, _ string) error {
+	m.nodes = make([]node, len(d.Nodes))
+
+	hasParent := make([]bool, len(d.Nodes))
+
+	for i, dn := range d.Nodes {
+		n := node{
+			name:     dn.Name,
+			rotation: mgl32.QuatIdent(),
+			scale:    mgl32.Vec3{1, 1, 1},
+			children: dn.Children,
+			mesh:     -1,
+			skin:     -1,
+		}
+
+		if n.name == "" {
+			n.name = fmt.Sprintf("node%d", i)
+		}
+		if dn.Mesh != nil && *dn.Mesh >= 0 && *dn.Mesh < len(m.meshes) {
+			n.mesh = *dn.Mesh
+		}
+		if dn.Skin != nil && *dn.Skin >= 0 && *dn.Skin < len(m.skins) {
+			n.skin = *dn.Skin
+		}
+
+		if len(dn.Matrix) == 16 {
+			var matrix mgl32.Mat4
+			copy(matrix[:], dn.Matrix)
+			n.position, n.rotation, n.scale = decompose(matrix)
+		}
+		if len(dn.Translation) == 3 {
+			n.position = mgl32.Vec3{dn.Translation[0], dn.Translation[1], dn.Translation[2]}
+		}
+		if len(dn.Rotation) == 4 {
+			n.rotation = mgl32.Quat{W: dn.Rotation[3], V: mgl32.Vec3{dn.Rotation[0], dn.Rotation[1], dn.Rotation[2]}}
+		}
+		if len(dn.Scale) == 3 {
+			n.scale = mgl32.Vec3{dn.Scale[0], dn.Scale[1], dn.Scale[2]}
+		}
+
+		for _, c := range dn.Children {
+			if c < 0 || c >= len(d.Nodes) || hasParent[c] {
+				return fmt.Errorf("gltf: node %d has an invalid child %d", i, c)
+			}
+			hasParent[c] = true
+		}
+
+		m.nodes[i] = n
+	}
+
+	switch {
+	case d.Scene != nil && *d.Scene >= 0 && *d.Scene < len(d.Scenes):
+		m.roots = d.Scenes[*d.Scene].Nodes
+	case len(d.Scenes) > 0:
+		m.roots = d.Scenes[0].Nodes
+	default:
+		for i := range m.nodes {
+			if !hasParent[i] {
+				m.roots = append(m.roots, i)
+			}
+		}
+	}
+
+	for _, r := range m.roots {
+		if r < 0 || r >= len(m.nodes) || hasParent[r] {
+			return fmt.Errorf("gltf: invalid root node %d", r)
+		}
+	}
+
+	return nil
+}
+
+// decompose splits a transform matrix without shear into its translation,
+// rotation and scale.
+func decompose(matrix mgl32.Mat4) (mgl32.Vec3, mgl32.Quat, mgl32.Vec3) {
+	position := matrix.Col(3).Vec3()
+	scale := mgl32.Vec3{
+		matrix.Col(0).Vec3().Len(),
+		matrix.Col(1).Vec3().Len(),
+		matrix.Col(2).Vec3().Len(),
+	}
+
+	if matrix.Mat3().Det() < 0 {
+		scale[0] = -scale[0]
+	}
+
+	var rotation mgl32.Mat4
+	for c := 0; c < 3; c++ {
+		if scale[c] != 0 {
+			rotation.SetCol(c, matrix.Col(c).Mul(1/scale[c]))
+		}
+	}
+	rotation.Set(3, 3, 1)
+
+	return position, mgl32.Mat4ToQuat(rotation).Normalize(), scale
+}
+
+// loadAnimations converts animations into clips. Each animated node gets a
+// track. Cubic spline keyframes keep only their values and morph target
+// weights are not supported.
+func (m *Model) loadAnimations(d *document, _ string) error {
+	paths := make(map[int]string)
+
+	var walk func(index int, prefix string)
+	walk = func(index int, prefix string) {
+		paths[index] = prefix + m.nodes[index].name
+		for _, c := range m.nodes[index].children {
+			walk(c, paths[index]+"/")
+		}
+	}
+	for _, r := range m.roots {
+		walk(r, "")
+	}
+
+	for i, da := range d.Animations {
+		tracks := make(map[int]*scene.AnimationTrack)
+		var length float64
+		var order []int
+
+		for _, ch := range da.Channels {
+			if ch.Target.Node == nil || ch.Sampler < 0 || ch.Sampler >= len(da.Samplers) {
+				continue
+			}
+
+			path, ok := paths[*ch.Target.Node]
+			if !ok {
+				continue
+			}
+
+			sampler := da.Samplers[ch.Sampler]
+
+			times, _, err := d.floats(sampler.Input)
+			if err != nil {
+				return err
+			}
+			values, size, err := d.floats(sampler.Output)
+			if err != nil {
+				return err
+			}
+
+			// Cubic splines store an in tangent, value and out tangent.
+			stride := 1
+			if sampler.Interpolation == "CUBICSPLINE" {
+				stride = 3
+			}
+
+			if len(values) < len(times)*size*stride {
+				return fmt.Errorf("gltf: animation %d has too few keyframe values", i)
+			}
+
+			track, ok := tracks[*ch.Target.Node]
+			if !ok {
+				track = &scene.AnimationTrack{Path: path}
+				tracks[*ch.Target.Node] = track
+				order = append(order, *ch.Target.Node)
+			}
+
+			for k, t := range times {
+				v := values[(k*stride+stride/2)*size:]
+				length = math.Max(length, float64(t))
+
+				switch {
+				case ch.Target.Path == "translation" && size == 3:
+					track.Positions = append(track.Positions, scene.Vec3Keyframe{Time: float64(t), Value: mgl32.Vec3{v[0], v[1], v[2]}})
+				case ch.Target.Path == "scale" && size == 3:
+					track.Scales = append(track.Scales, scene.Vec3Keyframe{Time: float64(t), Value: mgl32.Vec3{v[0], v[1], v[2]}})
+				case ch.Target.Path == "rotation" && size == 4:
+					q := mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}
+					track.Rotations = append(track.Rotations, scene.QuatKeyframe{Time: float64(t), Value: q.Normalize()})
+				}
+			}
+		}
+
+		clip := scene.NewAnimationClip(length)
+		clip.SetName(da.Name)
+		if da.Name == "" {
+			clip.SetName(fmt.Sprintf("%s.animation%d", m.Name(), i))
+		}
+
+		for _, n := range order {
+			clip.AddTrack(tracks[n])
+		}
+
+		m.clips = append(m.clips, clip)
+	}
+
+	return nil
+}
@@ -24,6 +24,8 @@ package mesh
 
 import (
 	"encoding/gob"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -32,7 +34,9 @@ import (
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/graphics"
 	"github.com/haakenlabs/arc/pkg/math"
+	"github.com/haakenlabs/arc/scene"
 	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/instance"
 )
 
 const (
@@ -75,27 +79,62 @@ var _ core.AssetHandler = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	materials map[string]*scene.StandardMaterial
 }
 
-// Load will load data from the reader.
+// Load will load data from the reader. Wavefront .obj files are imported
+// with their materials, other files are meshes encoded with gob.
 func (h *Handler) Load(r *core.Resource) error {
-	metadata := &Metadata{}
-	m := graphics.NewMesh()
+	if strings.ToLower(filepath.Ext(r.Base())) == ".obj" {
+		return h.loadOBJ(r)
+	}
 
-	dec := gob.NewDecoder(r.Reader())
-	err := dec.Decode(&metadata)
+	name, m, err := decodeGob(r)
 	if err != nil {
 		return err
 	}
 
-	name := metadata.Name
-
 	if _, dup := h.Items[name]; dup {
+		instance.Release(m.ID())
 		return core.ErrAssetExists(name)
 	}
 
+	return h.Add(name, m)
+}
+
+// Reload replaces the geometry of a loaded mesh. Materials of OBJ files are
+// not reloaded.
+func (h *Handler) Reload(r *core.Resource) (string, error) {
+	if strings.ToLower(filepath.Ext(r.Base())) == ".obj" {
+		return h.reloadOBJ(r)
+	}
+
+	name, src, err := decodeGob(r)
+	if err != nil {
+		return "", err
+	}
+	defer instance.Release(src.ID())
+
+	m, err := h.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	return name, m.Replace(src)
+}
+
+// decodeGob decodes a mesh encoded with gob into an unallocated mesh.
+func decodeGob(r *core.Resource) (string, *graphics.Mesh, error) {
+	metadata := &Metadata{}
+
+	dec := gob.NewDecoder(r.Reader())
+	if err := dec.Decode(&metadata); err != nil {
+		return "", nil, err
+	}
+
 	if len(metadata.F) == 0 {
-		return ErrMeshMissingFaces
+		return "", nil, ErrMeshMissingFaces
 	}
 
 	v := make([]mgl32.Vec3, len(metadata.F)*3)
@@ -118,16 +157,21 @@ func (h *Handler) Load(r *core.Resource) error {
 				t[i*3+j] = metadata.T[metadata.F[i][j][FaceTexture]]
 				n[i*3+j] = metadata.N[metadata.F[i][j][FaceNormal]]
 			default:
-				return ErrMeshInvalidFaceType
+				return "", nil, ErrMeshInvalidFaceType
 			}
 		}
 	}
 
+	m := graphics.NewMesh()
 	m.SetVertices(v)
 	m.SetNormals(n)
 	m.SetUvs(t)
 
-	return h.Add(name, m)
+	if metadata.FType == FaceTypeVTN {
+		m.GenerateTangents()
+	}
+
+	return metadata.Name, m, nil
 }
 
 func (h *Handler) Add(name string, mesh *graphics.Mesh) error {
@@ -138,6 +182,7 @@ func (h *Handler) Add(name string, mesh *graphics.Mesh) error {
 		return core.ErrAssetExists(name)
 	}
 
+	mesh.SetName(name)
 	if err := mesh.Alloc(); err != nil {
 		return err
 	}
@@ -147,6 +192,19 @@ func (h *Handler) Add(name string, mesh *graphics.Mesh) error {
 	return nil
 }
 
+// Material returns the material imported with the mesh of the given name.
+func (h *Handler) Material(name string) (*scene.StandardMaterial, error) {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+
+	m, ok := h.materials[name]
+	if !ok {
+		return nil, core.ErrAssetNotFound(name)
+	}
+
+	return m, nil
+}
+
 // Get gets an asset by name.
 func (h *Handler) Get(name string) (*graphics.Mesh, error) {
 	h.Mu.RLock()
@@ -183,6 +241,7 @@ func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
 	h.Mu = &sync.RWMutex{}
+	h.materials = make(map[string]*scene.StandardMaterial)
 
 	return h
 }
@@ -195,6 +254,11 @@ func MustGet(name string) *graphics.Mesh {
 	return mustHandler().MustGet(name)
 }
 
+// Material returns the material imported with the mesh of the given name.
+func Material(name string) (*scene.StandardMaterial, error) {
+	return mustHandler().Material(name)
+}
+
 func mustHandler() *Handler {
 	h, err := asset.GetHandler(AssetNameMesh)
 	if err != nil {
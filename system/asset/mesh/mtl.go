@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package mesh
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/asset/texture"
+)
+
+// loadMTL parses an MTL file into standard materials, keyed by name. Phong
+// parameters are approximated: Kd is the albedo, Ns is converted to a
+// roughness, and the PBR extension's Pr and Pm are used when present.
+// Dissolved materials are drawn as transparent.
+func loadMTL(location string, materials map[string]*scene.StandardMaterial) error {
+	r, err := readResource(location)
+	if err != nil {
+		return err
+	}
+
+	dir := r.DirPrefix()
+
+	var m *scene.StandardMaterial
+
+	scanner := bufio.NewScanner(bytes.NewReader(r.Bytes()))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] == "newmtl" {
+			name := strings.Join(fields[1:], " ")
+
+			m = scene.NewStandardMaterial()
+			m.SetName(name)
+			materials[name] = m
+
+			continue
+		}
+		if m == nil {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "Kd":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 3); err == nil {
+				m.SetAlbedo(core.Color{R: v[0], G: v[1], B: v[2], A: 1})
+			}
+		case "Ke":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 3); err == nil {
+				m.SetEmissive(core.Color{R: v[0], G: v[1], B: v[2], A: 1})
+			}
+		case "Ns":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 1); err == nil {
+				// Blinn-Phong exponent to GGX roughness.
+				m.SetRoughness(float32(math.Sqrt(2 / (float64(mgl32.Clamp(v[0], 0, 1000)) + 2))))
+			}
+		case "Pr":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 1); err == nil {
+				m.SetRoughness(v[0])
+			}
+		case "Pm":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 1); err == nil {
+				m.SetMetallic(v[0])
+			}
+		case "d", "Tr":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 1); err == nil {
+				opacity := v[0]
+				if fields[0] == "Tr" {
+					opacity = 1 - opacity
+				}
+				if opacity < 1 {
+					m.SetRenderQueue(scene.RenderQueueTransparent)
+				}
+			}
+		case "map_Kd":
+			err = setMap(m.SetAlbedoMap, dir, fields[1:])
+		case "map_Ke":
+			err = setMap(m.SetEmissiveMap, dir, fields[1:])
+		case "norm", "map_Bump", "map_bump", "bump":
+			err = setMap(m.SetNormalMap, dir, fields[1:])
+		case "map_ao":
+			err = setMap(m.SetOcclusionMap, dir, fields[1:])
+		}
+
+		if err != nil {
+			return errors.Annotatef(err, "%s line %d", r.Base(), line)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// setMap loads the texture of a map statement and passes it to set. Options
+// before the filename are ignored.
+func setMap(set func(graphics.Texture), dir string, fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("missing texture")
+	}
+
+	t, err := loadTexture(filepath.Join(dir, fields[len(fields)-1]))
+	if err != nil {
+		return err
+	}
+
+	set(t)
+
+	return nil
+}
+
+// loadTexture loads a texture through the texture handler. Textures which
+// are already loaded are shared.
+func loadTexture(location string) (*graphics.Texture2D, error) {
+	h, err := asset.GetHandler(texture.AssetNameTexture)
+	if err != nil {
+		return nil, err
+	}
+	textures := h.(*texture.Handler)
+
+	name := filepath.Base(location)
+	if t, err := textures.Get(name); err == nil {
+		return t, nil
+	}
+
+	r, err := readResource(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := textures.Load(r); err != nil {
+		return nil, err
+	}
+
+	return textures.Get(name)
+}
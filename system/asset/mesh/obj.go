@@ -0,0 +1,379 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package mesh
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/juju/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/instance"
+)
+
+// objCorner is a corner of a face, holding indices into the vertex, uv and
+// normal lists of an OBJ file. Missing indices are -1.
+type objCorner struct {
+	v, t, n int
+}
+
+// objGroup is a run of faces using the same material.
+type objGroup struct {
+	material string
+	corners  []objCorner
+}
+
+// objFile holds the parsed contents of an OBJ file.
+type objFile struct {
+	v       []mgl32.Vec3
+	t       []mgl32.Vec2
+	n       []mgl32.Vec3
+	groups  []*objGroup
+	mtllibs []string
+}
+
+// loadOBJ imports a Wavefront OBJ file and the materials of its MTL files.
+// The whole file is added as a mesh named after the resource. If the file
+// uses more than one material, each material's faces are also added as a
+// mesh named "<file>/<material>". Missing normals and all tangents are
+// generated.
+func (h *Handler) loadOBJ(r *core.Resource) error {
+	name := r.Base()
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	obj, err := parseOBJ(r.Bytes())
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	materials := make(map[string]*scene.StandardMaterial)
+	for _, lib := range obj.mtllibs {
+		if err := loadMTL(filepath.Join(r.DirPrefix(), lib), materials); err != nil {
+			logrus.Warnf("mesh: %s: cannot load material library %s: %v", name, lib, err)
+		}
+	}
+
+	material := func(g *objGroup) *scene.StandardMaterial {
+		if m, ok := materials[g.material]; ok {
+			return m
+		}
+		if g.material != "" {
+			logrus.Warnf("mesh: %s: unknown material %s", name, g.material)
+		}
+
+		m := scene.NewStandardMaterial()
+		materials[g.material] = m
+
+		return m
+	}
+
+	if err := h.addOBJ(name, obj, obj.all(), material(obj.groups[0])); err != nil {
+		return err
+	}
+
+	if len(obj.groups) > 1 {
+		for _, g := range obj.groups {
+			if err := h.addOBJ(name+"/"+g.material, obj, g, material(g)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// reloadOBJ replaces the geometry of the meshes imported from an OBJ file.
+// Meshes of materials which were added to the file are not created.
+func (h *Handler) reloadOBJ(r *core.Resource) (string, error) {
+	name := r.Base()
+
+	obj, err := parseOBJ(r.Bytes())
+	if err != nil {
+		return "", errors.Annotate(err, name)
+	}
+
+	replace := func(name string, g *objGroup) error {
+		m, err := h.Get(name)
+		if err != nil {
+			return err
+		}
+
+		src, err := obj.mesh(g)
+		if err != nil {
+			return err
+		}
+		defer instance.Release(src.ID())
+
+		return m.Replace(src)
+	}
+
+	if err := replace(name, obj.all()); err != nil {
+		return "", err
+	}
+
+	if len(obj.groups) > 1 {
+		for _, g := range obj.groups {
+			if _, err := h.Get(name + "/" + g.material); err != nil {
+				continue
+			}
+			if err := replace(name+"/"+g.material, g); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return name, nil
+}
+
+// addOBJ adds the faces of a group as a mesh.
+func (h *Handler) addOBJ(name string, obj *objFile, g *objGroup, material *scene.StandardMaterial) error {
+	m, err := obj.mesh(g)
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	if err := h.Add(name, m); err != nil {
+		return err
+	}
+
+	h.Mu.Lock()
+	h.materials[name] = material
+	h.Mu.Unlock()
+
+	return nil
+}
+
+// parseOBJ parses the geometry of an OBJ file. Polygons are triangulated as
+// fans. Objects, groups and smoothing groups are ignored.
+func parseOBJ(data []byte) (*objFile, error) {
+	obj := &objFile{}
+	group := &objGroup{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "v":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 3); err == nil {
+				obj.v = append(obj.v, mgl32.Vec3{v[0], v[1], v[2]})
+			}
+		case "vt":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 1); err == nil {
+				uv := mgl32.Vec2{v[0], 0}
+				if len(v) > 1 {
+					uv[1] = v[1]
+				}
+
+				// OBJ uvs start at the bottom of the image, textures at the top.
+				uv[1] = 1 - uv[1]
+				obj.t = append(obj.t, uv)
+			}
+		case "vn":
+			var v []float32
+			if v, err = parseFloats(fields[1:], 3); err == nil {
+				obj.n = append(obj.n, mgl32.Vec3{v[0], v[1], v[2]})
+			}
+		case "f":
+			err = obj.parseFace(group, fields[1:])
+		case "usemtl":
+			material := strings.Join(fields[1:], " ")
+			if material != group.material {
+				if len(group.corners) != 0 {
+					obj.groups = append(obj.groups, group)
+				}
+				group = &objGroup{material: material}
+			}
+		case "mtllib":
+			obj.mtllibs = append(obj.mtllibs, fields[1:]...)
+		}
+
+		if err != nil {
+			return nil, errors.Annotatef(err, "line %d", line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(group.corners) != 0 {
+		obj.groups = append(obj.groups, group)
+	}
+	if len(obj.groups) == 0 {
+		return nil, ErrMeshMissingFaces
+	}
+
+	return obj, nil
+}
+
+// parseFace adds the triangles of a face to a group.
+func (obj *objFile) parseFace(g *objGroup, fields []string) error {
+	if len(fields) < 3 {
+		return ErrMeshInvalidFaceType
+	}
+
+	corners := make([]objCorner, len(fields))
+	for i, field := range fields {
+		c := objCorner{-1, -1, -1}
+		parts := strings.Split(field, "/")
+		if len(parts) > 3 {
+			return ErrMeshInvalidFaceType
+		}
+
+		lengths := []int{len(obj.v), len(obj.t), len(obj.n)}
+		indices := []*int{&c.v, &c.t, &c.n}
+
+		for j, part := range parts {
+			if part == "" {
+				if j == 0 {
+					return ErrMeshInvalidFaceType
+				}
+				continue
+			}
+
+			index, err := strconv.Atoi(part)
+			if err != nil {
+				return err
+			}
+
+			// Negative indices count back from the last element.
+			if index < 0 {
+				index += lengths[j]
+			} else {
+				index--
+			}
+			if index < 0 || index >= lengths[j] {
+				return errors.Errorf("index %s is out of range", part)
+			}
+
+			*indices[j] = index
+		}
+
+		corners[i] = c
+	}
+
+	for i := 1; i+1 < len(corners); i++ {
+		g.corners = append(g.corners, corners[0], corners[i], corners[i+1])
+	}
+
+	return nil
+}
+
+// all returns a group holding the faces of every group.
+func (obj *objFile) all() *objGroup {
+	all := &objGroup{}
+	for _, g := range obj.groups {
+		all.corners = append(all.corners, g.corners...)
+	}
+
+	return all
+}
+
+// mesh creates an unallocated mesh from the faces of a group.
+func (obj *objFile) mesh(g *objGroup) (*graphics.Mesh, error) {
+	if len(g.corners) == 0 {
+		return nil, ErrMeshMissingFaces
+	}
+
+	v := make([]mgl32.Vec3, len(g.corners))
+	n := make([]mgl32.Vec3, len(g.corners))
+	t := make([]mgl32.Vec2, len(g.corners))
+
+	hasNormals, hasUvs := true, false
+	for i, c := range g.corners {
+		v[i] = obj.v[c.v]
+		if c.n >= 0 {
+			n[i] = obj.n[c.n]
+		} else {
+			hasNormals = false
+		}
+		if c.t >= 0 {
+			t[i] = obj.t[c.t]
+			hasUvs = true
+		}
+	}
+
+	m := graphics.NewMesh()
+	m.SetVertices(v)
+	m.SetNormals(n)
+	m.SetUvs(t)
+
+	if !hasNormals {
+		m.GenerateNormals()
+	}
+	if hasUvs {
+		m.GenerateTangents()
+	}
+
+	return m, nil
+}
+
+// parseFloats parses at least min floats.
+func parseFloats(fields []string, min int) ([]float32, error) {
+	if len(fields) < min {
+		return nil, errors.Errorf("expected %d values", min)
+	}
+
+	values := make([]float32, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = float32(v)
+	}
+
+	return values, nil
+}
+
+// readResource reads a resource relative to an asset.
+func readResource(location string) (*core.Resource, error) {
+	r, err := core.NewResource(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package shader
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/arc/core"
+)
+
+// reloadInterval is the minimum time between two checks for changed shader
+// sources.
+const reloadInterval = 500 * time.Millisecond
+
+// source is the location on disk of a loaded shader.
+type source struct {
+	metadata string
+	files    []string
+	modTimes map[string]time.Time
+}
+
+// SetHotReload enables or disables reloading shaders when their sources
+// change on disk. Builtin shaders are only watched if dir is set to the
+// directory holding the builtin assets, such as internal/builtin/assets of
+// an engine checkout. It must be called before shaders are loaded.
+func (h *Handler) SetHotReload(enable bool, dir string) {
+	h.hotReload = enable
+	h.builtinDir = dir
+}
+
+// HotReload reports if shaders are reloaded when their sources change.
+func (h *Handler) HotReload() bool {
+	return h.hotReload
+}
+
+// Poll reloads the shaders whose sources changed on disk since the last call.
+// A shader failing to build keeps its previous program. Poll must be called
+// from the thread owning the graphics context.
+func (h *Handler) Poll() {
+	if !h.hotReload || time.Since(h.lastPoll) < reloadInterval {
+		return
+	}
+	h.lastPoll = time.Now()
+
+	for name, src := range h.sources {
+		if !src.changed() {
+			continue
+		}
+
+		if err := h.reload(name, src); err != nil {
+			logrus.Errorf("shader: reload of %s failed: %v", name, err)
+			continue
+		}
+
+		logrus.Infof("shader: reloaded %s", name)
+		core.GetAssetSystem().NotifyReload(AssetNameShader, name)
+	}
+}
+
+// watch records the disk location of a loaded shader. Shaders which are
+// not backed by files, such as packaged shaders, are not watched.
+func (h *Handler) watch(name string, r *core.Resource, files []string) {
+	if !h.hotReload {
+		return
+	}
+
+	src := &source{
+		metadata: h.diskPath(r),
+		modTimes: make(map[string]time.Time),
+	}
+	if src.metadata == "" {
+		return
+	}
+
+	src.setFiles(files)
+	h.sources[name] = src
+}
+
+// diskPath returns the path on disk of a resource, or an empty string if it
+// is not backed by a file.
+func (h *Handler) diskPath(r *core.Resource) string {
+	switch r.Type() {
+	case core.ResourceFile:
+		return r.Location()
+	case core.ResourceBindata:
+		if h.builtinDir != "" {
+			return filepath.Join(h.builtinDir, r.Location())
+		}
+	}
+
+	return ""
+}
+
+func (h *Handler) reload(name string, src *source) error {
+	s, err := h.Get(name)
+	if err != nil {
+		return err
+	}
+
+	raw, err := ioutil.ReadFile(src.metadata)
+	if err != nil {
+		return err
+	}
+
+	m := &Metadata{}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return err
+	}
+
+	files := make([]string, len(m.Files))
+	for i := range m.Files {
+		files[i] = filepath.Join(filepath.Dir(src.metadata), m.Files[i])
+	}
+	src.setFiles(files)
+
+	var data []byte
+	for i := range files {
+		b, err := ioutil.ReadFile(files[i])
+		if err != nil {
+			return err
+		}
+
+		data = append(data, b...)
+	}
+
+	return s.Reload(data)
+}
+
+// setFiles replaces the watched source files and records their current
+// modification times.
+func (s *source) setFiles(files []string) {
+	s.files = files
+	s.modTimes = make(map[string]time.Time, len(files)+1)
+
+	s.changed()
+}
+
+// changed reports if the metadata or a source file was modified since the
+// last call.
+func (s *source) changed() bool {
+	changed := false
+
+	for _, path := range append([]string{s.metadata}, s.files...) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if last, ok := s.modTimes[path]; ok && !info.ModTime().Equal(last) {
+			changed = true
+		}
+		s.modTimes[path] = info.ModTime()
+	}
+
+	return changed
+}
+
+// Poll reloads changed shaders of the registered shader handler.
+func Poll() {
+	mustHandler().Poll()
+}
@@ -27,6 +27,9 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
 
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/graphics"
@@ -41,6 +44,11 @@ var _ core.AssetHandler = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	sources    map[string]*source
+	builtinDir string
+	hotReload  bool
+	lastPoll   time.Time
 }
 
 type Metadata struct {
@@ -71,22 +79,40 @@ func (h *Handler) Load(r *core.Resource) error {
 	s.SetName(m.Name)
 
 	// Populate shader data.
+	files := make([]string, 0, len(m.Files))
 	for i := range m.Files {
-		r, err := core.NewResource(filepath.Join(r.DirPrefix(), m.Files[i]))
+		f, err := core.NewResource(filepath.Join(r.DirPrefix(), m.Files[i]))
 		if err != nil {
 			return err
 		}
-		if err := asset.ReadResource(r); err != nil {
+		if err := asset.ReadResource(f); err != nil {
 			return err
 		}
 
-		s.AddData(r.Bytes())
+		s.AddData(f.Bytes())
+
+		if path := h.diskPath(f); path != "" {
+			files = append(files, path)
+		}
+	}
+
+	if err := h.Add(name, s); err != nil {
+		// Shaders using features the context lacks are left out, the
+		// code using them checks graphics.Supported first.
+		if _, ok := err.(graphics.ErrProfileUnsupported); ok {
+			logrus.Warnf("Skipping shader %s: %v", name, err)
+			return nil
+		}
+
+		return err
 	}
 
-	return h.Add(name, s)
+	h.watch(name, r, files)
+
+	return nil
 }
 
-func (h *Handler) Add(name string, shader *graphics.Shader) error {
+func (h *Handler) Add(name string, shader graphics.Shader) error {
 	if _, dup := h.Items[name]; dup {
 		return core.ErrAssetExists(name)
 	}
@@ -101,13 +127,13 @@ func (h *Handler) Add(name string, shader *graphics.Shader) error {
 }
 
 // Get gets an asset by name.
-func (h *Handler) Get(name string) (*graphics.Shader, error) {
+func (h *Handler) Get(name string) (graphics.Shader, error) {
 	a, err := h.GetAsset(name)
 	if err != nil {
 		return nil, err
 	}
 
-	a2, ok := a.(*graphics.Shader)
+	a2, ok := a.(graphics.Shader)
 	if !ok {
 		return nil, core.ErrAssetType(name)
 	}
@@ -116,7 +142,7 @@ func (h *Handler) Get(name string) (*graphics.Shader, error) {
 }
 
 // MustGet is like GetAsset, but panics if an error occurs.
-func (h *Handler) MustGet(name string) *graphics.Shader {
+func (h *Handler) MustGet(name string) graphics.Shader {
 	a, err := h.Get(name)
 	if err != nil {
 		panic(err)
@@ -133,27 +159,68 @@ func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
 	h.Mu = &sync.RWMutex{}
+	h.sources = make(map[string]*source)
 
 	return h
 }
 
-func NewShaderUtilsCopy() *graphics.Shader {
+func NewShaderUtilsCopy() graphics.Shader {
 	return MustGet("utils/copy")
 }
 
-func NewShaderUtilsSkybox() *graphics.Shader {
+func NewShaderUtilsSkybox() graphics.Shader {
 	return MustGet("utils/skybox")
 }
 
-func DefaultShader() *graphics.Shader {
+func NewShaderUtilsDecal() graphics.Shader {
+	return MustGet("utils/decal")
+}
+
+func NewShaderUtilsNormals() graphics.Shader {
+	return MustGet("utils/normals")
+}
+
+func NewShaderUtilsShadow() graphics.Shader {
+	return MustGet("utils/shadow")
+}
+
+func NewShaderUtilsOcclusion() graphics.Shader {
+	return MustGet("utils/occlusion")
+}
+
+func NewShaderUtilsSSAO() graphics.Shader {
+	return MustGet("utils/ssao")
+}
+
+func NewShaderEffectBloom() graphics.Shader {
+	return MustGet("effect/bloom")
+}
+
+func NewShaderEffectColorGrading() graphics.Shader {
+	return MustGet("effect/color_grading")
+}
+
+func NewShaderEffectMotionBlur() graphics.Shader {
+	return MustGet("effect/motion_blur")
+}
+
+func NewShaderEffectSSR() graphics.Shader {
+	return MustGet("effect/ssr")
+}
+
+func NewShaderEffectTonemapper() graphics.Shader {
+	return MustGet("effect/tonemapper")
+}
+
+func DefaultShader() graphics.Shader {
 	return MustGet("standard")
 }
 
-func Get(name string) (*graphics.Shader, error) {
+func Get(name string) (graphics.Shader, error) {
 	return mustHandler().Get(name)
 }
 
-func MustGet(name string) *graphics.Shader {
+func MustGet(name string) graphics.Shader {
 	return mustHandler().MustGet(name)
 }
 
@@ -33,7 +33,6 @@ import (
 
 	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/sirupsen/logrus"
 
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/graphics"
@@ -45,23 +44,16 @@ import (
 
 	_ "image/jpeg"
 	_ "image/png"
+
+	_ "github.com/haakenlabs/arc/pkg/image/exr"
 )
 
 const (
 	AssetNameSkybox = "skybox"
 )
 
-var rotMatrices = [6]mgl32.Mat4{
-	// X
-	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}),
-	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 1, 0}),
-	// Y
-	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, -1}),
-	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, 1}),
-	// Z
-	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}),
-	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}),
-}
+// brdfSize is the size of the BRDF lookup table.
+const brdfSize = 512
 
 var _ core.AssetHandler = &Handler{}
 
@@ -74,6 +66,8 @@ type Metadata struct {
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	brdf *graphics.Texture2D
 }
 
 func NewHandler() *Handler {
@@ -144,22 +138,18 @@ func (h *Handler) loadMap(m *Metadata, dir string) (skybox *scene.Skybox, err er
 		}
 	}
 
-	rimg := loadImage(radiR)
-	simg := loadImage(specR)
-	iimg := loadImage(irrdR)
-
-	radiTex, err := loadTexture(rimg)
+	radiTex, err := loadTexture(radiR)
 	if err != nil {
 		return nil, err
 	}
 	if !genSpecular {
-		specTex, err = loadTexture(simg)
+		specTex, err = loadTexture(specR)
 		if err != nil {
 			return nil, err
 		}
 	}
 	if !genIrradiance {
-		irrdTex, err = loadTexture(iimg)
+		irrdTex, err = loadTexture(irrdR)
 		if err != nil {
 			return nil, err
 		}
@@ -173,10 +163,17 @@ func (h *Handler) loadMap(m *Metadata, dir string) (skybox *scene.Skybox, err er
 		return nil, err
 	}
 
+	// The mip chain of the radiance map is sampled by the prefilter passes
+	// to avoid aliasing.
+	radiance.GenerateMipmaps()
+
 	if genSpecular {
 		specular, err = generateSpecular(radiance, fbo)
 	} else {
 		specular, err = makeCubemap(specTex, fbo, specTex.Size().Y()/2)
+		if err == nil {
+			specular.GenerateMipmaps()
+		}
 	}
 	if err != nil {
 		return nil, err
@@ -191,7 +188,15 @@ func (h *Handler) loadMap(m *Metadata, dir string) (skybox *scene.Skybox, err er
 		return nil, err
 	}
 
+	if h.brdf == nil {
+		h.brdf, err = generateBRDF(fbo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	skybox = scene.NewSkybox(radiance, specular, irradiance)
+	skybox.SetBRDF(h.brdf)
 
 	return skybox, nil
 }
@@ -221,17 +226,14 @@ func (h *Handler) MustGet(name string) *scene.Skybox {
 	return a
 }
 
-func loadImage(r *core.Resource) (img image.Image) {
+// loadTexture decodes an image, which may be a Radiance .hdr or OpenEXR file,
+// into a texture.
+func loadTexture(r *core.Resource) (tex *graphics.Texture2D, err error) {
 	img, _, err := image.Decode(r.Reader())
 	if err != nil {
-		logrus.Error(err)
-		return nil
+		return nil, err
 	}
 
-	return img
-}
-
-func loadTexture(img image.Image) (tex *graphics.Texture2D, err error) {
 	x := int32(img.Bounds().Dx())
 	y := int32(img.Bounds().Dy())
 
@@ -266,20 +268,14 @@ func loadTexture(img image.Image) (tex *graphics.Texture2D, err error) {
 		tex.SetTexFormat(graphics.TextureFormatRGBA8)
 		tex.SetData(rgba.Pix)
 	case hdr.RGB96Model:
-		rgba := hdr.NewRGB96(img.Bounds())
-		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		tex.SetTexFormat(graphics.TextureFormatRGB32)
-
-		var data []float32
-		for y := 0; y < rgba.Rect.Dy(); y++ {
-			for x := 0; x < rgba.Rect.Dx(); x++ {
-				c := rgba.RGB96At(x, y)
-				data = append(data, c.R)
-				data = append(data, c.G)
-				data = append(data, c.B)
-			}
+		// Radiance and OpenEXR maps keep their range, so the lighting derived
+		// from them matches the scene when rendered with an HDR camera.
+		rgb, ok := img.(*hdr.RGB96)
+		if !ok {
+			return nil, errors.New("unknown HDR image type")
 		}
-		tex.SetHDRData(data)
+		tex.SetTexFormat(graphics.TextureFormatRGB32)
+		tex.SetHDRData(rgb.Floats())
 
 	default:
 		return nil, errors.New("unknown image type")
@@ -292,7 +288,7 @@ func loadTexture(img image.Image) (tex *graphics.Texture2D, err error) {
 	return tex, err
 }
 
-func makeCubemap(tex *graphics.Texture2D, fbo *graphics.Framebuffer, faceSize int32) (cubemap *graphics.TextureCubemap, err error) {
+func makeCubemap(tex *graphics.Texture2D, fbo graphics.Framebuffer, faceSize int32) (cubemap *graphics.TextureCubemap, err error) {
 	fbo.Bind()
 	fbo.SetSize(math.IVec2{faceSize, faceSize})
 
@@ -304,8 +300,8 @@ func makeCubemap(tex *graphics.Texture2D, fbo *graphics.Framebuffer, faceSize in
 	mesh := graphics.NewMeshQuadBack()
 	mesh.Bind()
 
-	gl.Disable(gl.DEPTH_TEST)
-	gl.DepthMask(false)
+	graphics.ActiveDevice().SetDepthTest(false)
+	graphics.ActiveDevice().SetDepthWrite(false)
 
 	s := shader.MustGet("utils/cubeconv")
 	s.Bind()
@@ -315,15 +311,15 @@ func makeCubemap(tex *graphics.Texture2D, fbo *graphics.Framebuffer, faceSize in
 	fbo.ClearBuffers()
 
 	for i := uint32(0); i < 6; i++ {
-		s.SetUniform("v_view_matrix", rotMatrices[i])
+		s.SetUniform("v_view_matrix", scene.CubemapViews[i])
 		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, cubemap.Reference(), 0)
 		mesh.Draw()
 	}
 
 	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, 0, 0)
 
-	gl.DepthMask(true)
-	gl.Enable(gl.DEPTH_TEST)
+	graphics.ActiveDevice().SetDepthWrite(true)
+	graphics.ActiveDevice().SetDepthTest(true)
 
 	s.Unbind()
 	mesh.Unbind()
@@ -332,16 +328,65 @@ func makeCubemap(tex *graphics.Texture2D, fbo *graphics.Framebuffer, faceSize in
 	return
 }
 
-func generateSpecular(radiance *graphics.TextureCubemap, fbo *graphics.Framebuffer) (spec *graphics.TextureCubemap, err error) {
-	//return nil, ErrNotImplemented
+// generateSpecular prefilters the radiance map for specular image based
+// lighting.
+func generateSpecular(radiance *graphics.TextureCubemap, fbo graphics.Framebuffer) (spec *graphics.TextureCubemap, err error) {
+	spec, err = scene.NewSpecularCubemap(radiance.Size().Y())
+	if err != nil {
+		return nil, err
+	}
 
-	return nil, nil
+	scene.PrefilterSpecular(radiance, spec, fbo)
+
+	return spec, nil
 }
 
-func generateIrradiance(radiance *graphics.TextureCubemap, fbo *graphics.Framebuffer) (irrd *graphics.TextureCubemap, err error) {
-	//return nil, ErrNotImplemented
+// generateIrradiance convolves the radiance map for diffuse image based
+// lighting.
+func generateIrradiance(radiance *graphics.TextureCubemap, fbo graphics.Framebuffer) (irrd *graphics.TextureCubemap, err error) {
+	irrd, err = scene.NewIrradianceCubemap()
+	if err != nil {
+		return nil, err
+	}
+
+	scene.ConvolveIrradiance(radiance, irrd, fbo)
+
+	return irrd, nil
+}
+
+// generateBRDF integrates the lookup table of the split sum approximation.
+// It does not depend on the environment, so it is shared by all skyboxes.
+func generateBRDF(fbo graphics.Framebuffer) (lut *graphics.Texture2D, err error) {
+	lut = graphics.NewTexture2D(math.IVec2{brdfSize, brdfSize}, graphics.TextureFormatRG16)
+	if err := lut.Alloc(); err != nil {
+		return nil, err
+	}
+
+	fbo.Bind()
+	fbo.SetSize(lut.Size())
+
+	mesh := graphics.NewMeshQuad()
+	defer mesh.Dealloc()
+	mesh.Bind()
+
+	graphics.ActiveDevice().SetDepthTest(false)
+	graphics.ActiveDevice().SetDepthWrite(false)
+
+	s := shader.MustGet("utils/brdf")
+	s.Bind()
+
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, lut.Reference(), 0)
+	mesh.Draw()
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, 0, 0)
+
+	graphics.ActiveDevice().SetDepthWrite(true)
+	graphics.ActiveDevice().SetDepthTest(true)
+
+	s.Unbind()
+	mesh.Unbind()
+	fbo.Unbind()
 
-	return nil, nil
+	return lut, nil
 }
 
 func Get(name string) (*scene.Skybox, error) {
@@ -27,49 +27,158 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/haakenlabs/arc/core"
 	"github.com/haakenlabs/arc/graphics"
+	"github.com/haakenlabs/arc/pkg/image/bc"
+	"github.com/haakenlabs/arc/pkg/image/hdr"
+	"github.com/haakenlabs/arc/pkg/image/lut"
 	"github.com/haakenlabs/arc/pkg/math"
 	"github.com/haakenlabs/arc/system/asset"
+	"github.com/haakenlabs/arc/system/instance"
 
 	_ "image/jpeg"
 	_ "image/png"
 
-	_ "github.com/haakenlabs/arc/pkg/image/hdr"
+	_ "github.com/haakenlabs/arc/pkg/image/exr"
 )
 
 const (
 	AssetNameTexture = "texture"
 )
 
+// lutStripSuffix marks images which are loaded as color lookup table strips.
+const lutStripSuffix = ".lut"
+
 var _ core.AssetHandler = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	anisotropy float32
+}
+
+// SetAnisotropy sets the anisotropic filtering level of loaded textures.
+func (h *Handler) SetAnisotropy(level float32) {
+	h.anisotropy = level
+}
+
+// Anisotropy returns the anisotropic filtering level of loaded textures.
+func (h *Handler) Anisotropy() float32 {
+	return h.anisotropy
 }
 
 // Load will load data from the reader.
 func (h *Handler) Load(r *core.Resource) error {
-	var texture *graphics.Texture2D
-	var img image.Image
-
 	name := r.Base()
 
-	if _, dup := h.Items[r.Base()]; dup {
+	if _, dup := h.Items[name]; dup {
 		return core.ErrAssetExists(name)
 	}
 
-	img, _, err := image.Decode(r.Reader())
+	data, err := h.Decode(r)
 	if err != nil {
 		return err
 	}
 
+	return h.Upload(r, data)
+}
+
+// Decode decodes a texture without allocating it, so it is safe to call from
+// any goroutine.
+func (h *Handler) Decode(r *core.Resource) (interface{}, error) {
+	name := r.Base()
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".cube":
+		return lut.DecodeCube(r.Reader())
+	case ".dds":
+		return bc.DecodeDDS(r.Reader())
+	case ".ktx2":
+		return bc.DecodeKTX2(r.Reader())
+	}
+
+	img, _, err := image.Decode(r.Reader())
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))), lutStripSuffix) {
+		return lut.FromStrip(img)
+	}
+
+	return NewTexture(img)
+}
+
+// Upload allocates a texture returned by Decode. It must be called on the
+// main thread.
+func (h *Handler) Upload(r *core.Resource, data interface{}) error {
+	name := r.Base()
+
+	switch d := data.(type) {
+	case *lut.LUT:
+		return h.AddLUT(name, NewLUTTexture(d))
+	case *bc.Image:
+		return h.addCompressed(name, d)
+	case *graphics.Texture2D:
+		if err := h.Add(name, d); err != nil {
+			return err
+		}
+
+		// Loaded textures are usually tiled across surfaces and seen from a
+		// distance, so they repeat and are filtered with mipmaps.
+		d.SetWrapMode(graphics.WrapRepeat)
+		d.GenerateMipmaps()
+		d.SetAnisotropy(h.anisotropy)
+
+		return nil
+	}
+
+	return core.ErrAssetType(name)
+}
+
+// Reload replaces the contents of a loaded texture. Textures keep their
+// sampling state, and lookup tables cannot be reloaded.
+func (h *Handler) Reload(r *core.Resource) (string, error) {
+	name := r.Base()
+
+	t, err := h.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := h.Decode(r)
+	if err != nil {
+		return "", err
+	}
+
+	var src *graphics.Texture2D
+	switch d := data.(type) {
+	case *graphics.Texture2D:
+		src = d
+	case *bc.Image:
+		src = graphics.NewTexture2D(math.IVec2{int32(d.Width), int32(d.Height)}, compressedFormat(d.Format))
+		src.SetCompressedData(d.Levels)
+	default:
+		return "", core.ErrAssetType(name)
+	}
+	defer instance.Release(src.ID())
+
+	t.Replace(src)
+
+	return name, nil
+}
+
+// NewTexture creates an unallocated texture holding an image. The format of
+// the texture follows the color model of the image.
+func NewTexture(img image.Image) (*graphics.Texture2D, error) {
 	x := int32(img.Bounds().Dx())
 	y := int32(img.Bounds().Dy())
 
-	texture = graphics.NewTexture2D(math.IVec2{x, y}, graphics.TextureFormatDefaultColor)
+	texture := graphics.NewTexture2D(math.IVec2{x, y}, graphics.TextureFormatDefaultColor)
 
 	switch img.ColorModel() {
 	// 4 channels, 16 bits per channel
@@ -118,11 +227,20 @@ func (h *Handler) Load(r *core.Resource) error {
 		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
 		texture.SetTexFormat(graphics.TextureFormatRGBA8)
 		texture.SetData(rgba.Pix)
+		// 3 channels, 32 bit floats per channel
+	case hdr.RGB96Model:
+		rgb, ok := img.(*hdr.RGB96)
+		if !ok {
+			return nil, fmt.Errorf("invalid HDR image: %T", img)
+		}
+		texture.SetTexFormat(graphics.TextureFormatRGB32)
+		texture.SetHDRData(rgb.Floats())
 	default:
-		return fmt.Errorf("invalid color format: %v", img.ColorModel())
+		return nil, fmt.Errorf("invalid color format: %v", img.ColorModel())
 	}
 
-	return h.Add(name, texture)
+	return texture, nil
+
 }
 
 func (h *Handler) Add(name string, texture *graphics.Texture2D) error {
@@ -130,6 +248,47 @@ func (h *Handler) Add(name string, texture *graphics.Texture2D) error {
 		return core.ErrAssetExists(name)
 	}
 
+	texture.SetName(name)
+	if err := texture.Alloc(); err != nil {
+		return err
+	}
+
+	h.Items[name] = texture.ID()
+
+	return nil
+}
+
+// addCompressed adds a block compressed texture. Mipmaps cannot be generated
+// for compressed formats, so the texture uses the mip levels stored in the
+// file. Like uncompressed textures, the data is uploaded without sRGB
+// decoding and materials convert colors in the shader.
+func (h *Handler) addCompressed(name string, c *bc.Image) error {
+	format := compressedFormat(c.Format)
+	if !graphics.TextureFormatSupported(format) {
+		return fmt.Errorf("texture: %s: %s compression is not supported", name, c.Format)
+	}
+
+	texture := graphics.NewTexture2D(math.IVec2{int32(c.Width), int32(c.Height)}, format)
+	texture.SetCompressedData(c.Levels)
+
+	if err := h.Add(name, texture); err != nil {
+		return err
+	}
+
+	texture.SetWrapMode(graphics.WrapRepeat)
+	texture.SetFilterMode(graphics.FilterTrilinear)
+	texture.SetAnisotropy(h.anisotropy)
+
+	return nil
+}
+
+// AddLUT adds a color lookup table.
+func (h *Handler) AddLUT(name string, texture *graphics.Texture3D) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	texture.SetName(name)
 	if err := texture.Alloc(); err != nil {
 		return err
 	}
@@ -139,6 +298,31 @@ func (h *Handler) Add(name string, texture *graphics.Texture2D) error {
 	return nil
 }
 
+// GetLUT gets a color lookup table by name.
+func (h *Handler) GetLUT(name string) (*graphics.Texture3D, error) {
+	a, err := h.GetAsset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*graphics.Texture3D)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+// MustGetLUT is like GetLUT, but panics if an error occurs.
+func (h *Handler) MustGetLUT(name string) *graphics.Texture3D {
+	a, err := h.GetLUT(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
 // Get gets an asset by name.
 func (h *Handler) Get(name string) (*graphics.Texture2D, error) {
 	a, err := h.GetAsset(name)
@@ -169,7 +353,9 @@ func (h *Handler) Name() string {
 }
 
 func NewHandler() *Handler {
-	h := &Handler{}
+	h := &Handler{
+		anisotropy: 1,
+	}
 	h.Items = make(map[string]int32)
 	h.Mu = &sync.RWMutex{}
 
@@ -184,6 +370,39 @@ func MustGet(name string) *graphics.Texture2D {
 	return mustHandler().MustGet(name)
 }
 
+func GetLUT(name string) (*graphics.Texture3D, error) {
+	return mustHandler().GetLUT(name)
+}
+
+func MustGetLUT(name string) *graphics.Texture3D {
+	return mustHandler().MustGetLUT(name)
+}
+
+// NewLUTTexture creates a 3D texture from a color lookup table. Colors are
+// stored as floats, so tables may map outside of [0, 1].
+func NewLUTTexture(l *lut.LUT) *graphics.Texture3D {
+	size := int32(l.Size)
+
+	t := graphics.NewTexture3D(math.IVec2{size, size}, size, graphics.TextureFormatRGB32)
+	t.SetHDRData(l.Data)
+
+	return t
+}
+
+// compressedFormat returns the texture format of a block compression format.
+func compressedFormat(format bc.Format) graphics.TextureFormat {
+	switch format {
+	case bc.FormatBC3:
+		return graphics.TextureFormatBC3
+	case bc.FormatBC5:
+		return graphics.TextureFormatBC5
+	case bc.FormatBC7:
+		return graphics.TextureFormatBC7
+	}
+
+	return graphics.TextureFormatBC1
+}
+
 func mustHandler() *Handler {
 	h, err := asset.GetHandler(AssetNameTexture)
 	if err != nil {
@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tree
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/haakenlabs/arc/behavior"
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/system/asset"
+)
+
+const AssetNameTree = "tree"
+
+var _ core.AssetHandler = &Handler{}
+
+type Metadata struct {
+	Name string         `json:"name"`
+	Root *behavior.Spec `json:"root"`
+}
+
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+func (h *Handler) Load(r *core.Resource) error {
+	m := &Metadata{}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return err
+	}
+
+	t := behavior.NewTree(m.Root)
+	t.SetName(m.Name)
+
+	return h.Add(m.Name, t)
+}
+
+func (h *Handler) Add(name string, tree *behavior.Tree) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	if err := tree.Alloc(); err != nil {
+		return err
+	}
+
+	h.Items[name] = tree.ID()
+
+	return nil
+}
+
+func (h *Handler) Get(name string) (*behavior.Tree, error) {
+	a, err := h.GetAsset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*behavior.Tree)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+func (h *Handler) MustGet(name string) *behavior.Tree {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func (h *Handler) Name() string {
+	return AssetNameTree
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func Get(name string) (*behavior.Tree, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *behavior.Tree {
+	return mustHandler().MustGet(name)
+}
+
+// NewAgent creates an agent which runs the tree with the given name. No
+// agent is created if the tree is missing or cannot be instantiated.
+func NewAgent(name string) (*behavior.Agent, error) {
+	t, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return behavior.NewTreeAgent(t)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameTree)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
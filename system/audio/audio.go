@@ -22,7 +22,11 @@ SOFTWARE.
 
 package audio
 
-import "github.com/haakenlabs/arc/core"
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+)
 
 func Volume() float64 {
 	return core.GetAudioSystem().Volume()
@@ -43,3 +47,35 @@ func Unmute() {
 func SetMute(mute bool) {
 	core.GetAudioSystem().SetMute(mute)
 }
+
+func NewBus(name string) *core.AudioBus {
+	return core.GetAudioSystem().NewBus(name)
+}
+
+func Bus(name string) *core.AudioBus {
+	return core.GetAudioSystem().Bus(name)
+}
+
+func MasterBus() *core.AudioBus {
+	return core.GetAudioSystem().MasterBus()
+}
+
+func SetListenerPosition(position mgl32.Vec3) {
+	core.GetAudioSystem().SetListenerPosition(position)
+}
+
+func SetOcclusionQuery(query core.OcclusionQuery) {
+	core.GetAudioSystem().SetOcclusionQuery(query)
+}
+
+func SetOcclusionCutoff(cutoff float64) {
+	core.GetAudioSystem().SetOcclusionCutoff(cutoff)
+}
+
+func AddReverbZone(zone *core.ReverbZone) {
+	core.GetAudioSystem().AddReverbZone(zone)
+}
+
+func RemoveReverbZone(zone *core.ReverbZone) {
+	core.GetAudioSystem().RemoveReverbZone(zone)
+}
@@ -51,3 +51,8 @@ func ReleaseAll() {
 func Get(id int32) (core.Object, error) {
 	return core.GetInstanceSystem().Get(id)
 }
+
+// Objects returns all objects in the instance database, ordered by ID.
+func Objects() []core.Object {
+	return core.GetInstanceSystem().Objects()
+}
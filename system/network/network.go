@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package network
+
+import (
+	"github.com/haakenlabs/arc/core"
+)
+
+func Listen(addr string) error {
+	return core.GetNetworkSystem().Listen(addr)
+}
+
+func Connect(addr string) error {
+	return core.GetNetworkSystem().Connect(addr)
+}
+
+func Shutdown() {
+	core.GetNetworkSystem().Shutdown()
+}
+
+func SetTransport(transport core.NetTransport) {
+	core.GetNetworkSystem().SetTransport(transport)
+}
+
+func IsServer() bool {
+	return core.GetNetworkSystem().IsServer()
+}
+
+func Peers() []core.PeerID {
+	return core.GetNetworkSystem().Peers()
+}
+
+func Connected(peer core.PeerID) bool {
+	return core.GetNetworkSystem().Connected(peer)
+}
+
+func RegisterMessage(factory func() core.NetMessage) {
+	core.GetNetworkSystem().RegisterMessage(factory)
+}
+
+func Handle(id uint16, handler core.NetMessageHandler) core.NetSubscription {
+	return core.GetNetworkSystem().Handle(id, handler)
+}
+
+func OnConnect(fn func(core.PeerID)) core.NetSubscription {
+	return core.GetNetworkSystem().OnConnect(fn)
+}
+
+func OnDisconnect(fn func(core.PeerID)) core.NetSubscription {
+	return core.GetNetworkSystem().OnDisconnect(fn)
+}
+
+func Send(peer core.PeerID, channel core.NetChannel, msg core.NetMessage) error {
+	return core.GetNetworkSystem().Send(peer, channel, msg)
+}
+
+func Broadcast(channel core.NetChannel, msg core.NetMessage) error {
+	return core.GetNetworkSystem().Broadcast(channel, msg)
+}
+
+func Disconnect(peer core.PeerID) {
+	core.GetNetworkSystem().Disconnect(peer)
+}
@@ -23,7 +23,12 @@ SOFTWARE.
 package scene
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/haakenlabs/arc/core"
+	ascene "github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/system/asset"
 )
 
 func Register(scene core.Scene) error {
@@ -34,6 +39,38 @@ func Load(name string) error {
 	return core.GetSceneSystem().Load(name)
 }
 
+func LoadAdditive(name string) error {
+	return core.GetSceneSystem().LoadAdditive(name)
+}
+
+func Unload(name string) error {
+	return core.GetSceneSystem().Unload(name)
+}
+
+func SetActive(name string) error {
+	return core.GetSceneSystem().SetActive(name)
+}
+
+func Running(name string) bool {
+	return core.GetSceneSystem().Running(name)
+}
+
+func Scenes() []core.Scene {
+	return core.GetSceneSystem().Scenes()
+}
+
+func LoadAsync(name string) (*core.SceneLoad, error) {
+	return core.GetSceneSystem().LoadAsync(name)
+}
+
+func OnSceneWillLoad(fn func(name string)) {
+	core.GetSceneSystem().OnSceneWillLoad(fn)
+}
+
+func OnSceneLoaded(fn func(name string)) {
+	core.GetSceneSystem().OnSceneLoaded(fn)
+}
+
 func PurgePush(name string) error {
 	return core.GetSceneSystem().PurgePush(name)
 }
@@ -77,3 +114,54 @@ func Count() int {
 func ActiveCount() int {
 	return core.GetSceneSystem().ActiveCount()
 }
+
+// LoadFile reads a scene file through the asset system and registers the
+// scene it describes. Its objects are created when the scene is loaded.
+func LoadFile(file string) (*ascene.Scene, error) {
+	r, err := core.NewResource(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	f, err := ascene.ReadFile(r.Reader())
+	if err != nil {
+		return nil, fmt.Errorf("scene: %s: %v", file, err)
+	}
+	if f.Name == "" {
+		f.Name = r.Base()
+	}
+
+	s := ascene.NewSceneFromFile(f)
+	if err := Register(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SaveFile writes a registered scene to a scene file on disk.
+func SaveFile(name, file string) error {
+	if !Registered(name) {
+		return fmt.Errorf("save scene: '%s' not registered", name)
+	}
+
+	s, ok := core.GetSceneSystem().Get(name).(*ascene.Scene)
+	if !ok {
+		return fmt.Errorf("save scene: '%s' cannot be saved", name)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -42,6 +42,18 @@ func Delta() float64 {
 	return core.GetTimeSystem().Delta()
 }
 
+func TimeScale() float64 {
+	return core.GetTimeSystem().TimeScale()
+}
+
+func SetTimeScale(scale float64) {
+	core.GetTimeSystem().SetTimeScale(scale)
+}
+
+func ScaledDelta() float64 {
+	return core.GetTimeSystem().ScaledDelta()
+}
+
 func Now() float64 {
 	return core.GetTimeSystem().Now()
 }
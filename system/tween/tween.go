@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tween
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/arc/core"
+	"github.com/haakenlabs/arc/scene"
+	"github.com/haakenlabs/arc/ui"
+)
+
+// To creates a tween which interpolates from one value to another, passing
+// the result to set.
+func To(from, to, duration float64, set func(float64)) *core.Tween {
+	return core.NewTween(duration, nil, func(t float64) {
+		set(lerp(from, to, t))
+	})
+}
+
+// Position creates a tween which moves a transform to the given position.
+func Position(transform scene.Transform, to mgl32.Vec3, duration float64) *core.Tween {
+	var from mgl32.Vec3
+
+	return core.NewTween(duration, func() {
+		from = transform.Position()
+	}, func(t float64) {
+		transform.SetPosition(lerpVec3(from, to, t))
+	})
+}
+
+// Rotation creates a tween which rotates a transform to the given rotation.
+func Rotation(transform scene.Transform, to mgl32.Quat, duration float64) *core.Tween {
+	var from mgl32.Quat
+
+	return core.NewTween(duration, func() {
+		from = transform.Rotation()
+	}, func(t float64) {
+		transform.SetRotation(mgl32.QuatSlerp(from, to, float32(t)))
+	})
+}
+
+// Scale creates a tween which scales a transform to the given scale.
+func Scale(transform scene.Transform, to mgl32.Vec3, duration float64) *core.Tween {
+	var from mgl32.Vec3
+
+	return core.NewTween(duration, func() {
+		from = transform.Scale()
+	}, func(t float64) {
+		transform.SetScale(lerpVec3(from, to, t))
+	})
+}
+
+// MaterialFloat creates a tween which animates a float shader property of a
+// material. If the property is not set, it is animated from zero.
+func MaterialFloat(material *scene.Material, property string, to float32, duration float64) *core.Tween {
+	var from float32
+
+	return core.NewTween(duration, func() {
+		from, _ = material.Property(property).(float32)
+	}, func(t float64) {
+		material.SetProperty(property, float32(lerp(float64(from), float64(to), t)))
+	})
+}
+
+// MaterialVec4 creates a tween which animates a vec4 shader property of a
+// material, such as a color. If the property is not set, it is animated from
+// zero.
+func MaterialVec4(material *scene.Material, property string, to mgl32.Vec4, duration float64) *core.Tween {
+	var from mgl32.Vec4
+
+	return core.NewTween(duration, func() {
+		from, _ = material.Property(property).(mgl32.Vec4)
+	}, func(t float64) {
+		material.SetProperty(property, from.Add(to.Sub(from).Mul(float32(t))))
+	})
+}
+
+// RectPosition creates a tween which moves a rect transform to the given
+// position.
+func RectPosition(rect *ui.RectTransform, to mgl32.Vec2, duration float64) *core.Tween {
+	var from mgl32.Vec2
+
+	return core.NewTween(duration, func() {
+		from = rect.Rect().Origin()
+	}, func(t float64) {
+		rect.SetPosition2D(from.Add(to.Sub(from).Mul(float32(t))))
+	})
+}
+
+// RectSize creates a tween which resizes a rect transform to the given size.
+func RectSize(rect *ui.RectTransform, to mgl32.Vec2, duration float64) *core.Tween {
+	var from mgl32.Vec2
+
+	return core.NewTween(duration, func() {
+		from = rect.Size()
+	}, func(t float64) {
+		rect.SetSize(from.Add(to.Sub(from).Mul(float32(t))))
+	})
+}
+
+// Sequence creates a new, empty tween sequence.
+func Sequence() *core.TweenSequence {
+	return core.NewTweenSequence()
+}
+
+func Add(t core.Tweener) {
+	core.GetTweenSystem().Add(t)
+}
+
+func KillAll() {
+	core.GetTweenSystem().KillAll()
+}
+
+func Count() int {
+	return core.GetTweenSystem().Count()
+}
+
+// lerp does not clamp t, so that ease functions which overshoot are honored.
+func lerp(a, b, t float64) float64 {
+	return a + t*(b-a)
+}
+
+func lerpVec3(a, b mgl32.Vec3, t float64) mgl32.Vec3 {
+	return a.Add(b.Sub(a).Mul(float32(t)))
+}
@@ -62,6 +62,11 @@ func Vsync() bool {
 	return core.GetWindowSystem().Vsync()
 }
 
+// Headless reports if the window is hidden.
+func Headless() bool {
+	return core.GetWindowSystem().Headless()
+}
+
 func GLFWWindow() *glfw.Window {
 	return core.GetWindowSystem().GLFWWindow()
 }
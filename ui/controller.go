@@ -41,7 +41,7 @@ type Controller struct {
 	selected    Widget
 	highlighted Widget
 
-	fbo        *graphics.Framebuffer
+	fbo        graphics.Framebuffer
 	fboTexture *graphics.Texture2D
 
 	pixelPerfect bool
@@ -73,12 +73,13 @@ func (c *Controller) GUIRender() {
 	}
 
 	c.fbo.Bind()
-	c.fbo.ClearBufferFlags(gl.COLOR_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+	c.fbo.ClearBufferFlags(graphics.ClearColor | graphics.ClearStencil)
 
-	gl.Disable(gl.DEPTH_TEST)
-	gl.Enable(gl.STENCIL_TEST)
-	gl.Enable(gl.BLEND)
-	gl.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	state := graphics.CurrentState()
+	state.Enable(gl.DEPTH_TEST, false)
+	state.Enable(gl.STENCIL_TEST, true)
+	state.Enable(gl.BLEND, true)
+	state.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
 
 	c.maskIndex = 0
 	for _, v := range c.mCache {
@@ -91,12 +92,12 @@ func (c *Controller) GUIRender() {
 
 	c.fbo.Unbind()
 
-	gl.Disable(gl.STENCIL_TEST)
-	gl.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
-	graphics.BlitFramebuffers(c.fbo, graphics.CurrentFramebuffer(), gl.COLOR_ATTACHMENT0)
+	state.Enable(gl.STENCIL_TEST, false)
+	state.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	graphics.BlitFramebuffers(c.fbo, graphics.CurrentFramebuffer(), graphics.AttachmentColor0)
 
-	gl.Disable(gl.BLEND)
-	gl.Enable(gl.DEPTH_TEST)
+	state.Enable(gl.BLEND, false)
+	state.Enable(gl.DEPTH_TEST, true)
 }
 
 func (c *Controller) nextMaskIndex() uint8 {
@@ -226,8 +227,8 @@ func NewController() *Controller {
 
 	c.fboTexture.Alloc()
 
-	c.fbo.SetAttachment(gl.COLOR_ATTACHMENT0, graphics.NewAttachmentTexture2DFrom(c.fboTexture, false))
-	c.fbo.SetAttachment(gl.DEPTH_STENCIL_ATTACHMENT, graphics.NewAttachmentRenderBuffer(c.fbo.Size(), graphics.TextureFormatDepth24Stencil8))
+	c.fbo.SetAttachment(graphics.AttachmentColor0, graphics.NewAttachmentTexture2DFrom(c.fboTexture, false))
+	c.fbo.SetAttachment(graphics.AttachmentDepthStencil, graphics.NewAttachmentRenderBuffer(c.fbo.Size(), graphics.TextureFormatDepth24Stencil8))
 
 	if err := c.fbo.Alloc(); err != nil {
 		panic(err)
@@ -33,7 +33,7 @@ type Mask struct {
 	BaseComponent
 
 	mesh   *Mesh
-	shader *graphics.Shader
+	shader graphics.Shader
 	maskID uint8
 }
 
@@ -69,7 +69,7 @@ func (m *Mask) WriteMask() {
 	gl.StencilFunc(gl.EQUAL, int32(parentMask), 0xFF)
 	gl.StencilOp(gl.KEEP, gl.INCR, gl.INCR)
 
-	gl.ColorMask(false, false, false, false)
+	graphics.CurrentState().ColorMask(false)
 
 	m.shader.SetUniform("v_ortho_matrix", window.OrthoMatrix())
 	m.shader.SetUniform("v_model_matrix", m.RectTransform().Rect().Matrix())
@@ -79,7 +79,7 @@ func (m *Mask) WriteMask() {
 	m.mesh.Unbind()
 	m.shader.Unbind()
 
-	gl.ColorMask(true, true, true, true)
+	graphics.CurrentState().ColorMask(true)
 }
 
 func MaskComponent(g *scene.GameObject) *Mask {
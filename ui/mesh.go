@@ -23,6 +23,8 @@ SOFTWARE.
 package ui
 
 import (
+	"unsafe"
+
 	"github.com/go-gl/gl/v4.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
@@ -31,37 +33,31 @@ import (
 	"github.com/haakenlabs/arc/system/instance"
 )
 
+// vertexSize is the size of a graphics.Vertex in bytes.
+const vertexSize = 32
+
+// Mesh is the geometry of a UI element. Vertices are streamed, so widgets
+// which change every frame do not wait for the GPU.
 type Mesh struct {
 	core.BaseObject
 
-	size int32
-	vao  uint32
-	vbo  uint32
+	size   int32
+	first  int32
+	vao    uint32
+	stream *graphics.StreamBuffer
 }
 
 func (m *Mesh) Alloc() error {
 	gl.GenVertexArrays(1, &m.vao)
-	gl.BindVertexArray(m.vao)
-
-	gl.GenBuffers(1, &m.vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
 
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 32, gl.PtrOffset(0))
-	gl.EnableVertexAttribArray(1)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 32, gl.PtrOffset(12))
-	gl.EnableVertexAttribArray(2)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 32, gl.PtrOffset(24))
-
-	gl.BufferData(gl.ARRAY_BUFFER, 32, nil, gl.DYNAMIC_DRAW)
-
-	m.Unbind()
+	m.stream = graphics.NewStreamBuffer(graphics.BufferVertex, 6*vertexSize)
+	m.setAttributes()
 
 	return nil
 }
 
 func (m *Mesh) Dealloc() {
-	gl.DeleteBuffers(1, &m.vbo)
+	m.stream.Release()
 	gl.DeleteVertexArrays(1, &m.vao)
 }
 
@@ -75,17 +71,17 @@ func (m *Mesh) Unbind() {
 
 func (m *Mesh) Upload(vertices []graphics.Vertex) {
 	m.size = int32(len(vertices))
-
-	m.Bind()
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
-
 	if m.size == 0 {
-		gl.BufferData(gl.ARRAY_BUFFER, 0, nil, gl.DYNAMIC_DRAW)
-	} else {
-		gl.BufferData(gl.ARRAY_BUFFER, int(m.size*32), gl.Ptr(vertices), gl.DYNAMIC_DRAW)
+		return
 	}
 
-	m.Unbind()
+	n := len(vertices) * vertexSize
+	if m.stream.Reserve(n) {
+		m.setAttributes()
+	}
+
+	copy(m.stream.Map(), (*[1 << 30]byte)(unsafe.Pointer(&vertices[0]))[:n:n])
+	m.first = int32(m.stream.Unmap(n) / vertexSize)
 }
 
 func (m *Mesh) Draw() {
@@ -93,7 +89,22 @@ func (m *Mesh) Draw() {
 		return
 	}
 
-	gl.DrawArrays(gl.TRIANGLES, 0, m.size)
+	gl.DrawArrays(gl.TRIANGLES, m.first, m.size)
+}
+
+// setAttributes points the vertex attributes at the stream buffer.
+func (m *Mesh) setAttributes() {
+	m.Bind()
+	m.stream.Bind()
+
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, vertexSize, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, vertexSize, gl.PtrOffset(12))
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, vertexSize, gl.PtrOffset(24))
+
+	m.Unbind()
 }
 
 func NewMesh() *Mesh {
@@ -39,6 +39,7 @@ type Graphic struct {
 	BasePrimitive
 
 	color       core.Color
+	uvRect      core.Rect
 	textureMode bool
 	invertX     bool
 	invertY     bool
@@ -60,6 +61,23 @@ func (g *Graphic) Color() core.Color {
 	return g.color
 }
 
+// UVRect returns the region of the texture drawn by the graphic.
+func (g *Graphic) UVRect() core.Rect {
+	return g.uvRect
+}
+
+// SetUVRect sets the region of the texture drawn by the graphic, in texture
+// coordinates from the top left corner of the image.
+func (g *Graphic) SetUVRect(rect core.Rect) {
+	g.uvRect = rect
+}
+
+// SetSprite draws a sprite of a texture atlas.
+func (g *Graphic) SetSprite(sprite *graphics.Sprite) {
+	g.SetTexture(sprite.Texture())
+	g.SetUVRect(sprite.UV())
+}
+
 func (g *Graphic) Refresh() {
 	r := g.Rect()
 
@@ -85,6 +103,9 @@ func (g *Graphic) Draw(matrix mgl32.Mat4) {
 	g.material.SetProperty("f_color", g.color.Vec4())
 	g.material.SetProperty("f_invert_x", g.invertX)
 	g.material.SetProperty("f_invert_y", g.invertY)
+	g.material.SetProperty("f_uv_rect", mgl32.Vec4{
+		g.uvRect.Left(), g.uvRect.Top(), g.uvRect.Width(), g.uvRect.Height(),
+	})
 
 	gl.StencilFunc(gl.ALWAYS, int32(g.maskLayer), 0xFF)
 	gl.StencilMask(0)
@@ -98,6 +119,7 @@ func (g *Graphic) Draw(matrix mgl32.Mat4) {
 func NewGraphic() *Graphic {
 	g := &Graphic{
 		color:   core.ColorWhite,
+		uvRect:  core.NewRect(mgl32.Vec2{}, mgl32.Vec2{1, 1}),
 		invertY: true,
 	}
 
@@ -44,6 +44,11 @@ type Text struct {
 	color     core.Color
 	value     string
 	maskLayer uint8
+
+	outlineColor core.Color
+	outlineWidth float32
+	glowColor    core.Color
+	glowWidth    float32
 }
 
 func (t *Text) Font() *graphics.Font {
@@ -81,6 +86,38 @@ func (t *Text) Color() core.Color {
 	return t.color
 }
 
+// SetOutline draws an outline of width pixels around the glyphs. Outlines
+// need a distance field font, and are at most as wide as its spread allows.
+func (t *Text) SetOutline(color core.Color, width float32) {
+	t.outlineColor = color
+	t.outlineWidth = width
+}
+
+// Outline returns the color and width of the outline.
+func (t *Text) Outline() (core.Color, float32) {
+	return t.outlineColor, t.outlineWidth
+}
+
+// SetGlow draws a glow fading out over width pixels around the glyphs and
+// their outline. Glows need a distance field font.
+func (t *Text) SetGlow(color core.Color, width float32) {
+	t.glowColor = color
+	t.glowWidth = width
+}
+
+// Glow returns the color and width of the glow.
+func (t *Text) Glow() (core.Color, float32) {
+	return t.glowColor, t.glowWidth
+}
+
+// fieldWidth converts a width in pixels into distance field units, in which
+// the field covers one unit on either side of an outline.
+func (t *Text) fieldWidth(width float32) float32 {
+	scale := float64(t.fontSize) / t.font.SDFSize()
+
+	return width / float32(2*t.font.SDFSpread()*scale)
+}
+
 func (t *Text) Refresh() {
 	if t.font == nil {
 		return
@@ -108,6 +145,15 @@ func (t *Text) Draw(matrix mgl32.Mat4) {
 	t.material.SetProperty("f_alpha", float32(1.0))
 	t.material.SetProperty("f_color", t.color.Vec4())
 
+	sdf := t.font != nil && t.font.SDF()
+	t.material.SetProperty("f_sdf", sdf)
+	if sdf {
+		t.material.SetProperty("f_outline_color", t.outlineColor.Vec4())
+		t.material.SetProperty("f_outline_width", t.fieldWidth(t.outlineWidth))
+		t.material.SetProperty("f_glow_color", t.glowColor.Vec4())
+		t.material.SetProperty("f_glow_width", t.fieldWidth(t.glowWidth))
+	}
+
 	gl.StencilFunc(gl.ALWAYS, int32(t.maskLayer), 0xFF)
 	gl.StencilMask(0)
 
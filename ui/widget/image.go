@@ -54,11 +54,18 @@ func (w *Image) SetColor(color core.Color) {
 
 func (w *Image) SetTexture(texture *graphics.Texture2D) {
 	w.graphic.SetTexture(texture)
+	w.graphic.SetUVRect(core.NewRect(mgl32.Vec2{}, mgl32.Vec2{1, 1}))
 	if w.graphic.Texture() != nil {
 		w.RectTransform().SetSize(w.graphic.Texture().Size().Vec2())
 	}
 }
 
+// SetSprite shows a sprite of a texture atlas, sized to the sprite.
+func (w *Image) SetSprite(sprite *graphics.Sprite) {
+	w.graphic.SetSprite(sprite)
+	w.RectTransform().SetSize(sprite.Size())
+}
+
 func (w *Image) OnActivate() {
 	w.Rearrange()
 }